@@ -15,6 +15,7 @@ package ast
 
 import (
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/util/auth"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -23,6 +24,7 @@ var (
 	_ DDLNode = &CreateDatabaseStmt{}
 	_ DDLNode = &CreateIndexStmt{}
 	_ DDLNode = &CreateTableStmt{}
+	_ DDLNode = &CreateViewStmt{}
 	_ DDLNode = &DropDatabaseStmt{}
 	_ DDLNode = &DropIndexStmt{}
 	_ DDLNode = &DropTableStmt{}
@@ -398,6 +400,7 @@ type CreateTableStmt struct {
 	Cols        []*ColumnDef
 	Constraints []*Constraint
 	Options     []*TableOption
+	Partition   *PartitionOptions
 }
 
 // Accept implements Node Accept interface.
@@ -436,13 +439,54 @@ func (n *CreateTableStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
-// DropTableStmt is a statement to drop one or more tables.
+// CreateViewStmt is a statement to create a view.
+// See https://dev.mysql.com/doc/refman/5.7/en/create-view.html
+type CreateViewStmt struct {
+	ddlNode
+
+	OrReplace bool
+	ViewName  *TableName
+	Cols      []model.CIStr
+	Select    ResultSetNode
+	// SelectOffset is the offset of the SELECT statement in the original text,
+	// it's used to locate the select fields in the text of the SELECT statement.
+	SelectOffset int
+	Algorithm    model.ViewAlgorithm
+	// Definer is nil if the definer is the current user.
+	Definer     *auth.UserIdentity
+	Security    model.ViewSecurity
+	CheckOption model.ViewCheckOption
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateViewStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateViewStmt)
+	node, ok := n.ViewName.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.ViewName = node.(*TableName)
+	node, ok = n.Select.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Select = node.(ResultSetNode)
+	return v.Leave(n)
+}
+
+// DropTableStmt is a statement to drop one or more tables or views.
 // See https://dev.mysql.com/doc/refman/5.7/en/drop-table.html
+// See https://dev.mysql.com/doc/refman/5.7/en/drop-view.html
 type DropTableStmt struct {
 	ddlNode
 
 	IfExists bool
 	Tables   []*TableName
+	IsView   bool
 }
 
 // Accept implements Node Accept interface.
@@ -673,6 +717,26 @@ func (n *ColumnPosition) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// PartitionDefinition defines a single partition.
+type PartitionDefinition struct {
+	Name model.CIStr
+	// LessThan is the VALUES LESS THAN list of a range partition, it's empty if MaxValue is true.
+	LessThan []ExprNode
+	MaxValue bool
+}
+
+// PartitionOptions is the PARTITION BY clause of CREATE TABLE.
+// The expression is not visited by Accept, it's evaluated against the table columns by DDL.
+type PartitionOptions struct {
+	Tp model.PartitionType
+	// Expr is the partition expression, its Text is the original SQL text.
+	Expr ExprNode
+	// ColumnNames are the partition columns of KEY partitioning.
+	ColumnNames []*ColumnName
+	Num         uint64
+	Definitions []*PartitionDefinition
+}
+
 // AlterTableType is the type for AlterTableSpec.
 type AlterTableType int
 
@@ -690,6 +754,9 @@ const (
 	AlterTableRenameTable
 	AlterTableAlterColumn
 	AlterTableLock
+	AlterTableAddPartitions
+	AlterTableDropPartition
+	AlterTableTruncatePartition
 
 // TODO: Add more actions
 )
@@ -719,6 +786,10 @@ type AlterTableSpec struct {
 	OldColumnName *ColumnName
 	Position      *ColumnPosition
 	LockType      LockType
+	// PartDefinitions is used by AlterTableAddPartitions.
+	PartDefinitions []*PartitionDefinition
+	// PartitionNames is used by AlterTableDropPartition and AlterTableTruncatePartition.
+	PartitionNames []model.CIStr
 }
 
 // Accept implements Node Accept interface.
@@ -18,6 +18,11 @@ func HasAggFlag(expr ExprNode) bool {
 	return expr.GetFlag()&FlagHasAggregateFunc > 0
 }
 
+// HasWindowFlag checks if the expr contains FlagHasWindowFunc.
+func HasWindowFlag(expr ExprNode) bool {
+	return expr.GetFlag()&FlagHasWindowFunc > 0
+}
+
 // SetFlag sets flag for expression.
 func SetFlag(n Node) {
 	var setter flagSetter
@@ -78,6 +83,8 @@ func (f *flagSetter) Leave(in Node) (Node, bool) {
 	case *ValueExpr:
 	case *ValuesExpr:
 		x.SetFlag(FlagHasReference)
+	case *WindowFuncExpr:
+		f.windowFunc(x)
 	case *VariableExpr:
 		if x.Value == nil {
 			x.SetFlag(FlagHasVariable)
@@ -154,3 +161,21 @@ func (f *flagSetter) aggregateFunc(x *AggregateFuncExpr) {
 	}
 	x.SetFlag(flag)
 }
+
+func (f *flagSetter) windowFunc(x *WindowFuncExpr) {
+	flag := FlagHasWindowFunc
+	for _, val := range x.Args {
+		flag |= val.GetFlag()
+	}
+	if x.Spec.PartitionBy != nil {
+		for _, item := range x.Spec.PartitionBy.Items {
+			flag |= item.Expr.GetFlag()
+		}
+	}
+	if x.Spec.OrderBy != nil {
+		for _, item := range x.Spec.OrderBy.Items {
+			flag |= item.Expr.GetFlag()
+		}
+	}
+	x.SetFlag(flag)
+}
@@ -105,6 +105,14 @@ func (ts *testFlagSuite) TestFlag(c *C) {
 			"sum(a)",
 			ast.FlagHasAggregateFunc | ast.FlagHasReference,
 		},
+		{
+			"sum(a) over (partition by b order by count(c))",
+			ast.FlagHasWindowFunc | ast.FlagHasAggregateFunc | ast.FlagHasReference,
+		},
+		{
+			"row_number() over ()",
+			ast.FlagHasWindowFunc,
+		},
 		{
 			"(select 1) as a",
 			ast.FlagHasSubquery,
@@ -36,9 +36,13 @@ var (
 	_ StmtNode = &ExecuteStmt{}
 	_ StmtNode = &ExplainStmt{}
 	_ StmtNode = &GrantStmt{}
+	_ StmtNode = &GrantRoleStmt{}
 	_ StmtNode = &PrepareStmt{}
+	_ StmtNode = &RevokeRoleStmt{}
 	_ StmtNode = &RollbackStmt{}
+	_ StmtNode = &SetDefaultRoleStmt{}
 	_ StmtNode = &SetPwdStmt{}
+	_ StmtNode = &SetRoleStmt{}
 	_ StmtNode = &SetStmt{}
 	_ StmtNode = &UseStmt{}
 	_ StmtNode = &FlushStmt{}
@@ -87,7 +91,8 @@ type AuthOption struct {
 	ByAuthString bool
 	AuthString   string
 	HashString   string
-	// TODO: support auth_plugin
+	// AuthPlugin is the authentication plugin specified by IDENTIFIED WITH, empty means the default plugin.
+	AuthPlugin string
 }
 
 // ExplainStmt is a statement to provide information about how is SQL statement executed
@@ -96,8 +101,9 @@ type AuthOption struct {
 type ExplainStmt struct {
 	stmtNode
 
-	Stmt   StmtNode
-	Format string
+	Stmt    StmtNode
+	Format  string
+	Analyze bool
 }
 
 // Accept implements Node Accept interface.
@@ -436,6 +442,57 @@ func (n *SetPwdStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// SetRoleStmtType is the type for SET ROLE and SET DEFAULT ROLE statement.
+type SetRoleStmtType int
+
+// SetRole statement types.
+const (
+	SetRoleDefault SetRoleStmtType = iota
+	SetRoleNone
+	SetRoleAll
+	SetRoleAllExcept
+	SetRoleRegular
+)
+
+// SetRoleStmt is the statement to activate roles for the current session.
+// See https://dev.mysql.com/doc/refman/8.0/en/set-role.html
+type SetRoleStmt struct {
+	stmtNode
+
+	SetRoleOpt SetRoleStmtType
+	RoleList   []*auth.RoleIdentity
+}
+
+// Accept implements Node Accept interface.
+func (n *SetRoleStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*SetRoleStmt)
+	return v.Leave(n)
+}
+
+// SetDefaultRoleStmt is the statement to set the roles activated when the users connect.
+// See https://dev.mysql.com/doc/refman/8.0/en/set-default-role.html
+type SetDefaultRoleStmt struct {
+	stmtNode
+
+	SetRoleOpt SetRoleStmtType
+	RoleList   []*auth.RoleIdentity
+	UserList   []*auth.UserIdentity
+}
+
+// Accept implements Node Accept interface.
+func (n *SetDefaultRoleStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*SetDefaultRoleStmt)
+	return v.Leave(n)
+}
+
 // UserSpec is used for parsing create user statement.
 type UserSpec struct {
 	User    *auth.UserIdentity
@@ -457,12 +514,14 @@ func (u *UserSpec) SecurityString() string {
 }
 
 // CreateUserStmt creates user account.
+// If IsCreateRole is true, it creates roles, which are stored as locked accounts.
 // See https://dev.mysql.com/doc/refman/5.7/en/create-user.html
 type CreateUserStmt struct {
 	stmtNode
 
-	IfNotExists bool
-	Specs       []*UserSpec
+	IsCreateRole bool
+	IfNotExists  bool
+	Specs        []*UserSpec
 }
 
 // Accept implements Node Accept interface.
@@ -478,7 +537,11 @@ func (n *CreateUserStmt) Accept(v Visitor) (Node, bool) {
 // SecureText implements SensitiveStatement interface.
 func (n *CreateUserStmt) SecureText() string {
 	var buf bytes.Buffer
-	buf.WriteString("create user")
+	if n.IsCreateRole {
+		buf.WriteString("create role")
+	} else {
+		buf.WriteString("create user")
+	}
 	for _, user := range n.Specs {
 		buf.WriteString(" ")
 		buf.WriteString(user.SecurityString())
@@ -517,13 +580,15 @@ func (n *AlterUserStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
-// DropUserStmt creates user account.
+// DropUserStmt drops user account.
+// If IsDropRole is true, it drops roles.
 // See http://dev.mysql.com/doc/refman/5.7/en/drop-user.html
 type DropUserStmt struct {
 	stmtNode
 
-	IfExists bool
-	UserList []*auth.UserIdentity
+	IfExists   bool
+	IsDropRole bool
+	UserList   []*auth.UserIdentity
 }
 
 // Accept implements Node Accept interface.
@@ -682,6 +747,24 @@ func (n *RevokeStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// RevokeRoleStmt is the struct for REVOKE role statement.
+type RevokeRoleStmt struct {
+	stmtNode
+
+	Roles []*auth.RoleIdentity
+	Users []*auth.UserIdentity
+}
+
+// Accept implements Node Accept interface.
+func (n *RevokeRoleStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*RevokeRoleStmt)
+	return v.Leave(n)
+}
+
 // GrantStmt is the struct for GRANT statement.
 type GrantStmt struct {
 	stmtNode
@@ -721,6 +804,25 @@ func (n *GrantStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// GrantRoleStmt is the struct for GRANT role statement.
+// See https://dev.mysql.com/doc/refman/8.0/en/grant.html#grant-roles
+type GrantRoleStmt struct {
+	stmtNode
+
+	Roles []*auth.RoleIdentity
+	Users []*auth.UserIdentity
+}
+
+// Accept implements Node Accept interface.
+func (n *GrantRoleStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*GrantRoleStmt)
+	return v.Leave(n)
+}
+
 // Ident is the table identifier composed of schema name and table name.
 type Ident struct {
 	Schema model.CIStr
@@ -55,9 +55,13 @@ func (ts *testMiscSuite) TestMiscVisitorCover(c *C) {
 		(&ExecuteStmt{UsingVars: []ExprNode{&ValueExpr{}}}),
 		(&ExplainStmt{Stmt: &ShowStmt{}}),
 		(&GrantStmt{}),
+		(&GrantRoleStmt{}),
 		(&PrepareStmt{SQLVar: &VariableExpr{Value: &ValueExpr{}}}),
+		(&RevokeRoleStmt{}),
 		(&RollbackStmt{}),
+		(&SetDefaultRoleStmt{}),
 		(&SetPwdStmt{}),
+		(&SetRoleStmt{}),
 		(&SetStmt{Variables: []*VariableAssignment{
 			{
 				Value: &ValueExpr{},
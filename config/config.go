@@ -30,13 +30,20 @@ type Config struct {
 	Lease        string `toml:"lease" json:"lease"`
 	RunDDL       bool   `toml:"run-ddl" json:"run-ddl"`
 	SplitTable   bool   `toml:"split-table" json:"split-table"`
-
-	Log         Log         `toml:"log" json:"log"`
-	Security    Security    `toml:"security" json:"security"`
-	Status      Status      `toml:"status" json:"status"`
-	Performance Performance `toml:"performance" json:"performance"`
-	XProtocol   XProtocol   `toml:"xprotocol" json:"xprotocol"`
-	PlanCache   PlanCache   `toml:"plan-cache" json:"plan-cache"`
+	// TmpStoragePath is the directory of the temporary files, e.g. the files spilled to disk by a large sort.
+	// The temporary directory of the OS is used if it's empty.
+	TmpStoragePath string `toml:"tmp-storage-path" json:"tmp-storage-path"`
+	// OOMAction is the action taken when the memory usage of a query exceeds 'tidb_mem_quota_query',
+	// one of "log" and "cancel".
+	OOMAction string `toml:"oom-action" json:"oom-action"`
+
+	Log           Log           `toml:"log" json:"log"`
+	Security      Security      `toml:"security" json:"security"`
+	Status        Status        `toml:"status" json:"status"`
+	Performance   Performance   `toml:"performance" json:"performance"`
+	XProtocol     XProtocol     `toml:"xprotocol" json:"xprotocol"`
+	PlanCache     PlanCache     `toml:"plan-cache" json:"plan-cache"`
+	ProxyProtocol ProxyProtocol `toml:"proxy-protocol" json:"proxy-protocol"`
 }
 
 // Log is the log section of config.
@@ -73,12 +80,13 @@ type Status struct {
 
 // Performance is the performance section of the config.
 type Performance struct {
-	TCPKeepAlive    bool   `toml:"tcp-keep-alive" json:"tcp-keep-alive"`
-	RetryLimit      int    `toml:"retry-limit" json:"retry-limit"`
-	JoinConcurrency int    `toml:"join-concurrency" json:"join-concurrency"`
-	CrossJoin       bool   `toml:"cross-join" json:"cross-join"`
-	StatsLease      string `toml:"stats-lease" json:"stats-lease"`
-	RunAutoAnalyze  bool   `toml:"run-auto-analyze" json:"run-auto-analyze"`
+	TCPKeepAlive      bool   `toml:"tcp-keep-alive" json:"tcp-keep-alive"`
+	RetryLimit        int    `toml:"retry-limit" json:"retry-limit"`
+	JoinConcurrency   int    `toml:"join-concurrency" json:"join-concurrency"`
+	CrossJoin         bool   `toml:"cross-join" json:"cross-join"`
+	StatsLease        string `toml:"stats-lease" json:"stats-lease"`
+	RunAutoAnalyze    bool   `toml:"run-auto-analyze" json:"run-auto-analyze"`
+	EnableCompression bool   `toml:"enable-compression" json:"enable-compression"`
 }
 
 // XProtocol is the XProtocol section of the config.
@@ -96,13 +104,31 @@ type PlanCache struct {
 	Shards   int64 `toml:"plan-cache-shards" json:"plan-cache-shards"`
 }
 
+// ProxyProtocol is the PROXY protocol section of the config.
+type ProxyProtocol struct {
+	// Networks is a comma separated list of the trusted proxy networks, e.g. "192.168.1.0/24,10.0.0.1",
+	// "*" means all networks. The PROXY protocol is disabled if it's empty.
+	Networks string `toml:"networks" json:"networks"`
+	// HeaderTimeout is the timeout of reading the PROXY protocol header in seconds.
+	HeaderTimeout uint `toml:"header-timeout" json:"header-timeout"`
+}
+
+// The actions taken when the memory usage of a query exceeds the quota.
+const (
+	// OOMActionLog logs a warning and keeps the query running.
+	OOMActionLog = "log"
+	// OOMActionCancel cancels the query.
+	OOMActionCancel = "cancel"
+)
+
 var defaultConf = Config{
-	Host:   "0.0.0.0",
-	Port:   4000,
-	Store:  "mocktikv",
-	Path:   "/tmp/tidb",
-	RunDDL: true,
-	Lease:  "10s",
+	Host:      "0.0.0.0",
+	Port:      4000,
+	Store:     "mocktikv",
+	Path:      "/tmp/tidb",
+	RunDDL:    true,
+	Lease:     "10s",
+	OOMAction: OOMActionLog,
 	Log: Log{
 		Level:  "info",
 		Format: "text",
@@ -118,12 +144,13 @@ var defaultConf = Config{
 		MetricsInterval: 15,
 	},
 	Performance: Performance{
-		TCPKeepAlive:    true,
-		RetryLimit:      10,
-		JoinConcurrency: 5,
-		CrossJoin:       true,
-		StatsLease:      "3s",
-		RunAutoAnalyze:  true,
+		TCPKeepAlive:      true,
+		RetryLimit:        10,
+		JoinConcurrency:   5,
+		CrossJoin:         true,
+		StatsLease:        "3s",
+		RunAutoAnalyze:    true,
+		EnableCompression: true,
 	},
 	XProtocol: XProtocol{
 		XHost: "0.0.0.0",
@@ -134,6 +161,10 @@ var defaultConf = Config{
 		Capacity: 2560,
 		Shards:   256,
 	},
+	ProxyProtocol: ProxyProtocol{
+		Networks:      "",
+		HeaderTimeout: 5,
+	},
 }
 
 var globalConf = defaultConf
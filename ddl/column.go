@@ -14,6 +14,7 @@
 package ddl
 
 import (
+	"math"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -27,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -68,13 +70,18 @@ func (d *ddl) createColumnInfo(tblInfo *model.TableInfo, colInfo *model.ColumnIn
 	if pos.Tp == ast.ColumnPositionFirst {
 		position = 0
 	} else if pos.Tp == ast.ColumnPositionAfter {
-		c := findCol(cols, pos.RelativeColumn.Name.L)
-		if c == nil {
+		position = -1
+		for i, c := range cols {
+			if c.Name.L == pos.RelativeColumn.Name.L {
+				// Insert position is after the mentioned column. The mentioned column may be a non-public one
+				// which is being added in the same job, so the position in the column list is used.
+				position = i + 1
+				break
+			}
+		}
+		if position == -1 {
 			return nil, 0, infoschema.ErrColumnNotExists.GenByArgs(pos.RelativeColumn, tblInfo.Name)
 		}
-
-		// Insert position is after the mentioned column.
-		position = c.Offset + 1
 	}
 	colInfo.ID = allocateColumnID(tblInfo)
 	colInfo.State = model.StateNone
@@ -185,7 +192,7 @@ func (d *ddl) onDropColumn(t *meta.Meta, job *model.Job) (ver int64, _ error) {
 		job.State = model.JobStateCancelled
 		return ver, ErrCantDropFieldOrKey.Gen("column %s doesn't exist", colName)
 	}
-	if err = isDroppableColumn(tblInfo, colName); err != nil {
+	if err = isDroppableColumn(tblInfo, colName, nil); err != nil {
 		job.State = model.JobStateCancelled
 		return ver, errors.Trace(err)
 	}
@@ -404,16 +411,24 @@ func (d *ddl) onModifyColumn(t *meta.Meta, job *model.Job) (ver int64, _ error)
 		return ver, errors.Trace(err)
 	}
 
-	return d.doModifyColumn(t, job, newCol, oldColName, pos)
-}
-
-// doModifyColumn updates the column information and reorders all columns.
-func (d *ddl) doModifyColumn(t *meta.Meta, job *model.Job, col *model.ColumnInfo, oldName *model.CIStr, pos *ast.ColumnPosition) (ver int64, _ error) {
 	tblInfo, err := getTableInfo(t, job, job.SchemaID)
 	if err != nil {
 		return ver, errors.Trace(err)
 	}
+	// If the type can't be modified in place, or the job has started to change the column data,
+	// the data is converted with a hidden changing column.
+	oldCol := findCol(tblInfo.Columns, oldColName.L)
+	if findCol(tblInfo.Columns, getChangingColumnName(*oldColName).L) != nil ||
+		(oldCol != nil && modifiable(&oldCol.FieldType, &newCol.FieldType) != nil) {
+		return d.doModifyColumnTypeWithData(t, job, tblInfo, newCol, oldColName, pos)
+	}
 
+	return d.doModifyColumn(t, job, tblInfo, newCol, oldColName, pos)
+}
+
+// doModifyColumn updates the column information and reorders all columns.
+func (d *ddl) doModifyColumn(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, col *model.ColumnInfo,
+	oldName *model.CIStr, pos *ast.ColumnPosition) (ver int64, _ error) {
 	oldCol := findCol(tblInfo.Columns, oldName.L)
 	if oldCol == nil || oldCol.State != model.StatePublic {
 		job.State = model.JobStateCancelled
@@ -421,18 +436,38 @@ func (d *ddl) doModifyColumn(t *meta.Meta, job *model.Job, col *model.ColumnInfo
 	}
 
 	// Calculate column's new position.
+	newPos, err := getModifyColumnPosition(tblInfo, oldCol, pos)
+	if err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+	moveColumn(tblInfo, col, oldName, oldCol.Offset, newPos)
+
+	originalState := job.SchemaState
+	job.SchemaState = model.StatePublic
+	ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+
+	job.State = model.JobStateDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return ver, nil
+}
+
+// getModifyColumnPosition calculates the new position of the modified column.
+func getModifyColumnPosition(tblInfo *model.TableInfo, oldCol *model.ColumnInfo, pos *ast.ColumnPosition) (int, error) {
 	oldPos, newPos := oldCol.Offset, oldCol.Offset
 	if pos.Tp == ast.ColumnPositionAfter {
-		if oldName.L == pos.RelativeColumn.Name.L {
-			// `alter table tableName modify column b int after b` will return ver,ErrColumnNotExists.
-			job.State = model.JobStateCancelled
-			return ver, infoschema.ErrColumnNotExists.GenByArgs(oldName, tblInfo.Name)
+		if oldCol.Name.L == pos.RelativeColumn.Name.L {
+			// `alter table tableName modify column b int after b` will return ErrColumnNotExists.
+			return 0, infoschema.ErrColumnNotExists.GenByArgs(oldCol.Name, tblInfo.Name)
 		}
 
 		relative := findCol(tblInfo.Columns, pos.RelativeColumn.Name.L)
 		if relative == nil || relative.State != model.StatePublic {
-			job.State = model.JobStateCancelled
-			return ver, infoschema.ErrColumnNotExists.GenByArgs(pos.RelativeColumn, tblInfo.Name)
+			return 0, infoschema.ErrColumnNotExists.GenByArgs(pos.RelativeColumn, tblInfo.Name)
 		}
 
 		if relative.Offset < oldPos {
@@ -443,7 +478,12 @@ func (d *ddl) doModifyColumn(t *meta.Meta, job *model.Job, col *model.ColumnInfo
 	} else if pos.Tp == ast.ColumnPositionFirst {
 		newPos = 0
 	}
+	return newPos, nil
+}
 
+// moveColumn replaces the column at oldPos with col, moves it to newPos,
+// and updates the offsets and names of the columns in indices.
+func moveColumn(tblInfo *model.TableInfo, col *model.ColumnInfo, oldName *model.CIStr, oldPos, newPos int) {
 	columnChanged := make(map[string]*model.ColumnInfo)
 	columnChanged[oldName.L] = col
 
@@ -477,18 +517,391 @@ func (d *ddl) doModifyColumn(t *meta.Meta, job *model.Job, col *model.ColumnInfo
 			}
 		}
 	}
+}
 
-	originalState := job.SchemaState
-	job.SchemaState = model.StatePublic
-	ver, err = updateTableInfo(t, job, tblInfo, originalState)
-	if err != nil {
+// changingColumnPrefix is the name prefix of the hidden column used to change the column data.
+const changingColumnPrefix = "_Col$_"
+
+func getChangingColumnName(colName model.CIStr) model.CIStr {
+	return model.NewCIStr(changingColumnPrefix + colName.O)
+}
+
+// doModifyColumnTypeWithData modifies the column type which needs to convert the column data.
+// How to modify the column type with data?
+//  1. Add a hidden changing column with the new type, the state changes like adding a column,
+//     the DML statements write the value converted from the origin column into it since it's write only.
+//  2. In reorganization state, traverse the snapshot and backfill the changing column with the converted value
+//     of every row. If any value can't be converted, the job is rolled back and the changing column is removed.
+//  3. Replace the origin column with the changing column, the changing column becomes public
+//     and the origin column becomes write only with the hidden name.
+//  4. Drop the origin column like dropping a column.
+func (d *ddl) doModifyColumnTypeWithData(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo,
+	newCol *model.ColumnInfo, oldName *model.CIStr, pos *ast.ColumnPosition) (ver int64, err error) {
+	changingName := getChangingColumnName(*oldName)
+	changingCol := findCol(tblInfo.Columns, changingName.L)
+	if changingCol != nil && changingCol.ChangeStateInfo == nil {
+		// The origin column has been replaced by the changing column and renamed, drop it.
+		return d.dropReplacedColumn(t, job, tblInfo, changingCol)
+	}
+	if job.IsRollingback() {
+		return d.rollbackModifyColumn(t, job, tblInfo, changingCol)
+	}
+
+	oldCol := findCol(tblInfo.Columns, oldName.L)
+	if oldCol == nil || oldCol.State != model.StatePublic {
 		job.State = model.JobStateCancelled
-		return ver, errors.Trace(err)
+		return ver, infoschema.ErrColumnNotExists.GenByArgs(oldName, tblInfo.Name)
+	}
+	if changingCol == nil {
+		// Check the position before the column data is changed.
+		if _, err = getModifyColumnPosition(tblInfo, oldCol, pos); err != nil {
+			job.State = model.JobStateCancelled
+			return ver, errors.Trace(err)
+		}
+		changingCol = newCol.Clone()
+		changingCol.ID = allocateColumnID(tblInfo)
+		changingCol.Name = changingName
+		// The changing column is added at the end of the columns, so that we can use origin column offset
+		// to get the value from row.
+		changingCol.Offset = len(tblInfo.Columns)
+		changingCol.OriginDefaultValue = nil
+		changingCol.State = model.StateNone
+		changingCol.ChangeStateInfo = &model.ChangeStateInfo{DependencyColumnOffset: oldCol.Offset}
+		tblInfo.Columns = append(tblInfo.Columns, changingCol)
+	}
+	// If the job is cancelled before backfilling the data, remove the changing column.
+	// When backfilling, the reorganization is notified to be cancelled, see runDDLJob.
+	if job.IsCancelling() && (changingCol.State != model.StateWriteReorganization || job.SnapshotVer == 0) {
+		return d.convertModifyColumn2RollbackJob(t, job, tblInfo, changingCol, errCancelledDDLJob)
 	}
 
-	job.State = model.JobStateDone
-	job.BinlogInfo.AddTableInfo(ver, tblInfo)
-	return ver, nil
+	originalState := changingCol.State
+	switch changingCol.State {
+	case model.StateNone:
+		// none -> delete only
+		job.SchemaState = model.StateDeleteOnly
+		changingCol.State = model.StateDeleteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateDeleteOnly:
+		// delete only -> write only
+		job.SchemaState = model.StateWriteOnly
+		changingCol.State = model.StateWriteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateWriteOnly:
+		// write only -> reorganization
+		job.SchemaState = model.StateWriteReorganization
+		changingCol.State = model.StateWriteReorganization
+		// Initialize SnapshotVer to 0 for later reorganization check.
+		job.SnapshotVer = 0
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateWriteReorganization:
+		// reorganization -> public
+		var tbl table.Table
+		tbl, err = d.getTable(job.SchemaID, tblInfo)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+
+		var reorgInfo *reorgInfo
+		reorgInfo, err = d.getReorgInfo(t, job)
+		if err != nil || reorgInfo.first {
+			if err == nil {
+				// Get the first handle of this table.
+				err = iterateSnapshotRows(d.store, tbl, reorgInfo.SnapshotVer, math.MinInt64,
+					func(h int64, rowKey kv.Key, rawRecord []byte) (bool, error) {
+						reorgInfo.Handle = h
+						return false, nil
+					})
+				return ver, errors.Trace(t.UpdateDDLReorgHandle(reorgInfo.Job, reorgInfo.Handle))
+			}
+			// If we run reorg firstly, we should update the job snapshot version
+			// and then run the reorg next time.
+			return ver, errors.Trace(err)
+		}
+
+		err = d.runReorgJob(job, func() error {
+			return d.updateColumnData(tbl, oldCol, changingCol, reorgInfo, job)
+		})
+		if err != nil {
+			if errWaitReorgTimeout.Equal(err) {
+				// if timeout, we should return, check for the owner and re-wait job done.
+				return ver, nil
+			}
+			if errCancelledDDLJob.Equal(err) || isColumnDataConvertError(err) {
+				log.Warnf("[ddl] run DDL job %v err %v, convert job to rollback job", job, err)
+				ver, err = d.convertModifyColumn2RollbackJob(t, job, tblInfo, changingCol, err)
+			}
+			// Clean up the channel of notifyCancelReorgJob. Make sure it can't affect other jobs.
+			cleanNotify(d.notifyCancelReorgJob)
+			return ver, errors.Trace(err)
+		}
+		// Clean up the channel of notifyCancelReorgJob. Make sure it can't affect other jobs.
+		cleanNotify(d.notifyCancelReorgJob)
+
+		var newPos int
+		newPos, err = getModifyColumnPosition(tblInfo, oldCol, pos)
+		if err != nil {
+			return d.convertModifyColumn2RollbackJob(t, job, tblInfo, changingCol, err)
+		}
+		replaceColumn(tblInfo, oldCol, changingCol, newCol.Name)
+		moveColumn(tblInfo, changingCol, oldName, changingCol.Offset, newPos)
+
+		job.SchemaState = model.StateWriteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	default:
+		err = ErrInvalidColumnState.Gen("invalid column state %v", changingCol.State)
+	}
+
+	return ver, errors.Trace(err)
+}
+
+// replaceColumn makes the changing column public with the new name at the position of the origin column,
+// and makes the origin column write only with the hidden name at the end of the columns.
+func replaceColumn(tblInfo *model.TableInfo, oldCol, changingCol *model.ColumnInfo, newName model.CIStr) {
+	var oldIdx, changingIdx int
+	for i, col := range tblInfo.Columns {
+		if col == oldCol {
+			oldIdx = i
+		} else if col == changingCol {
+			changingIdx = i
+		}
+	}
+	tblInfo.Columns[oldIdx], tblInfo.Columns[changingIdx] = changingCol, oldCol
+	oldCol.Offset, changingCol.Offset = changingCol.Offset, oldCol.Offset
+
+	oldCol.Name = changingCol.Name
+	oldCol.State = model.StateWriteOnly
+	changingCol.Name = newName
+	changingCol.State = model.StatePublic
+	changingCol.ChangeStateInfo = nil
+}
+
+// dropReplacedColumn drops the origin column which has been replaced by the changing column.
+func (d *ddl) dropReplacedColumn(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, colInfo *model.ColumnInfo) (
+	ver int64, err error) {
+	originalState := colInfo.State
+	switch colInfo.State {
+	case model.StateWriteOnly:
+		// write only -> delete only
+		job.SchemaState = model.StateDeleteOnly
+		colInfo.State = model.StateDeleteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateDeleteOnly:
+		// delete only -> reorganization
+		job.SchemaState = model.StateDeleteReorganization
+		colInfo.State = model.StateDeleteReorganization
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateDeleteReorganization:
+		// reorganization -> absent
+		removeColumnInfo(tblInfo, colInfo)
+		job.SchemaState = model.StatePublic
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+
+		// Finish this job.
+		job.State = model.JobStateDone
+		job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	default:
+		err = ErrInvalidColumnState.Gen("invalid column state %v", colInfo.State)
+	}
+	return ver, errors.Trace(err)
+}
+
+func (d *ddl) convertModifyColumn2RollbackJob(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo,
+	changingCol *model.ColumnInfo, err error) (ver int64, _ error) {
+	job.State = model.JobStateRollingback
+	// The changing column is removed like dropping a column, the next state is delete only.
+	originalState := changingCol.State
+	changingCol.State = model.StateDeleteOnly
+	job.SchemaState = model.StateDeleteOnly
+	ver, err1 := updateTableInfo(t, job, tblInfo, originalState)
+	if err1 != nil {
+		return ver, errors.Trace(err1)
+	}
+	return ver, errors.Trace(err)
+}
+
+// rollbackModifyColumn removes the changing column of the rolling back job.
+func (d *ddl) rollbackModifyColumn(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, changingCol *model.ColumnInfo) (
+	ver int64, err error) {
+	if changingCol == nil {
+		job.State = model.JobStateRollbackDone
+		return ver, nil
+	}
+
+	originalState := changingCol.State
+	switch changingCol.State {
+	case model.StateDeleteOnly:
+		// delete only -> reorganization
+		job.SchemaState = model.StateDeleteReorganization
+		changingCol.State = model.StateDeleteReorganization
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateDeleteReorganization:
+		// reorganization -> absent
+		removeColumnInfo(tblInfo, changingCol)
+		job.SchemaState = model.StateNone
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+
+		job.State = model.JobStateRollbackDone
+		job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	default:
+		err = ErrInvalidColumnState.Gen("invalid column state %v", changingCol.State)
+	}
+	return ver, errors.Trace(err)
+}
+
+func removeColumnInfo(tblInfo *model.TableInfo, colInfo *model.ColumnInfo) {
+	newColumns := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		if col.Name.L != colInfo.Name.L {
+			newColumns = append(newColumns, col)
+		}
+	}
+	tblInfo.Columns = newColumns
+}
+
+// isColumnDataConvertError checks whether the error is returned when converting the column data,
+// the modify column job can't go on and should be rolled back in this case.
+func isColumnDataConvertError(err error) bool {
+	tErr, ok := errors.Cause(err).(*terror.Error)
+	if !ok {
+		return false
+	}
+	switch tErr.Class() {
+	case terror.ClassTypes, terror.ClassTable, terror.ClassExpression, terror.ClassJSON:
+		return true
+	}
+	return false
+}
+
+// updateColumnData backfills the changing column with the value converted from the origin column.
+// How to backfill the changing column?
+//  1. Traverse the snapshot with special version, get the handles of the rows.
+//  2. For every row, if the row has been already deleted, skip to next row.
+//  3. Convert the value of the origin column, or the origin default value of it if the value doesn't exist,
+//     and write the converted value into the changing column.
+func (d *ddl) updateColumnData(t table.Table, oldCol, changingCol *model.ColumnInfo, reorgInfo *reorgInfo, job *model.Job) error {
+	seekHandle := reorgInfo.Handle
+	version := reorgInfo.SnapshotVer
+	count := job.GetRowCount()
+	ctx := d.newReorgContext(job)
+
+	colMap := make(map[int64]*types.FieldType, len(t.Meta().Columns))
+	for _, col := range t.Meta().Columns {
+		colMap[col.ID] = &col.FieldType
+	}
+	handles := make([]int64, 0, defaultBatchCnt)
+	for {
+		startTime := time.Now()
+		handles = handles[:0]
+		err := iterateSnapshotRows(d.store, t, version, seekHandle,
+			func(h int64, rowKey kv.Key, rawRecord []byte) (bool, error) {
+				handles = append(handles, h)
+				if len(handles) == defaultBatchCnt {
+					return false, nil
+				}
+				return true, nil
+			})
+		if err != nil {
+			return errors.Trace(err)
+		} else if len(handles) == 0 {
+			return nil
+		}
+
+		count += int64(len(handles))
+		seekHandle = handles[len(handles)-1] + 1
+		err = d.backfillChangingColumn(ctx, t, oldCol, changingCol, colMap, handles, reorgInfo)
+		sub := time.Since(startTime).Seconds()
+		if err != nil {
+			log.Warnf("[ddl] updated column data for %v rows failed, take time %v", count, sub)
+			return errors.Trace(err)
+		}
+
+		d.setReorgRowCount(count)
+		batchHandleDataHistogram.WithLabelValues(batchModifyCol).Observe(sub)
+		log.Infof("[ddl] updated column data for %v rows, take time %v", count, sub)
+	}
+}
+
+func (d *ddl) backfillChangingColumn(ctx context.Context, t table.Table, oldCol, changingCol *model.ColumnInfo,
+	colMap map[int64]*types.FieldType, handles []int64, reorgInfo *reorgInfo) error {
+	var endIdx int
+	for len(handles) > 0 {
+		if len(handles) >= defaultSmallBatchCnt {
+			endIdx = defaultSmallBatchCnt
+		} else {
+			endIdx = len(handles)
+		}
+
+		err := kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+			if err := d.isReorgRunnable(); err != nil {
+				return errors.Trace(err)
+			}
+
+			for _, handle := range handles[:endIdx] {
+				if err := backfillChangingColumnInTxn(ctx, t, oldCol, changingCol, colMap, handle, txn); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			return errors.Trace(reorgInfo.UpdateHandle(txn, handles[0]))
+		})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		handles = handles[endIdx:]
+	}
+
+	return nil
+}
+
+// backfillChangingColumnInTxn converts the origin column value of a row and writes it into the changing column.
+func backfillChangingColumnInTxn(ctx context.Context, t table.Table, oldCol, changingCol *model.ColumnInfo,
+	colMap map[int64]*types.FieldType, handle int64, txn kv.Transaction) error {
+	rowKey := t.RecordKey(handle)
+	rowVal, err := txn.Get(rowKey)
+	if err != nil {
+		if kv.ErrNotExist.Equal(err) {
+			// If row doesn't exist, skip it.
+			return nil
+		}
+		return errors.Trace(err)
+	}
+
+	// The time zone is used to convert the timestamp values, they're stored in UTC.
+	loc := ctx.GetSessionVars().TimeZone
+	rowColumns, err := tablecodec.DecodeRow(rowVal, colMap, loc)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	oldVal, ok := rowColumns[oldCol.ID]
+	if !ok {
+		oldVal, err = table.GetColOriginDefaultValue(ctx, oldCol)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	newVal, err := table.CastColumnValue(ctx, oldVal, oldCol, changingCol)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	rowColumns[changingCol.ID] = newVal
+
+	newColumnIDs := make([]int64, 0, len(rowColumns))
+	newRow := make([]types.Datum, 0, len(rowColumns))
+	for colID, val := range rowColumns {
+		newColumnIDs = append(newColumnIDs, colID)
+		newRow = append(newRow, val)
+	}
+	newRowVal, err := tablecodec.EncodeRow(newRow, newColumnIDs, loc)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(txn.Set(rowKey, newRowVal))
 }
 
 func (d *ddl) updateColumn(t *meta.Meta, job *model.Job, newCol *model.ColumnInfo, oldColName *model.CIStr) (ver int64, _ error) {
@@ -37,6 +37,7 @@ import (
 	"github.com/pingcap/tidb/sessionctx/binloginfo"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/types"
 	"github.com/twinj/uuid"
 	goctx "golang.org/x/net/context"
 )
@@ -108,6 +109,43 @@ var (
 	errBlobCantHaveDefault = terror.ClassDDL.New(codeBlobCantHaveDefault, mysql.MySQLErrName[mysql.ErrBlobCantHaveDefault])
 	errTooLongIndexComment = terror.ClassDDL.New(codeErrTooLongIndexComment, mysql.MySQLErrName[mysql.ErrTooLongIndexComment])
 
+	// errUnsupportedPartitionedTable is for unsupported actions on partitioned tables.
+	errUnsupportedPartitionedTable = terror.ClassDDL.New(codeUnsupportedPartitionedTable, "unsupported %s on partitioned table")
+	// errUnsupportedPartitionType is the warning for the partition types which aren't supported.
+	errUnsupportedPartitionType = terror.ClassDDL.New(codeUnsupportedPartitionType, "unsupported partition type %s, treat as normal table")
+	// ErrPartitionRequiresValues returns for a range partition without VALUES LESS THAN.
+	ErrPartitionRequiresValues = terror.ClassDDL.New(codePartitionRequiresValues, mysql.MySQLErrName[mysql.ErrPartitionRequiresValues])
+	// ErrPartitionWrongValues returns for a hash partition with VALUES LESS THAN.
+	ErrPartitionWrongValues = terror.ClassDDL.New(codePartitionWrongValues, mysql.MySQLErrName[mysql.ErrPartitionWrongValues])
+	// ErrPartitionMaxvalue returns for MAXVALUE in a partition which isn't the last one.
+	ErrPartitionMaxvalue = terror.ClassDDL.New(codePartitionMaxvalue, mysql.MySQLErrName[mysql.ErrPartitionMaxvalue])
+	// ErrPartitionWrongNoPart returns when the number of partitions mismatches the partition definitions.
+	ErrPartitionWrongNoPart = terror.ClassDDL.New(codePartitionWrongNoPart, mysql.MySQLErrName[mysql.ErrPartitionWrongNoPart])
+	// ErrPartitionFuncNotAllowed returns when the partition function doesn't return an integer.
+	ErrPartitionFuncNotAllowed = terror.ClassDDL.New(codePartitionFuncNotAllowed, mysql.MySQLErrName[mysql.ErrPartitionFuncNotAllowed])
+	// ErrPartitionsMustBeDefined returns for a range partitioned table without partition definitions.
+	ErrPartitionsMustBeDefined = terror.ClassDDL.New(codePartitionsMustBeDefined, mysql.MySQLErrName[mysql.ErrPartitionsMustBeDefined])
+	// ErrRangeNotIncreasing returns when the VALUES LESS THAN values aren't strictly increasing.
+	ErrRangeNotIncreasing = terror.ClassDDL.New(codeRangeNotIncreasing, mysql.MySQLErrName[mysql.ErrRangeNotIncreasing])
+	// ErrTooManyPartitions returns when too many partitions are defined.
+	ErrTooManyPartitions = terror.ClassDDL.New(codeTooManyPartitions, mysql.MySQLErrName[mysql.ErrTooManyPartitions])
+	// ErrUniqueKeyNeedAllFieldsInPf returns when a unique key doesn't include all the partition columns.
+	ErrUniqueKeyNeedAllFieldsInPf = terror.ClassDDL.New(codeUniqueKeyNeedAllFieldsInPf, mysql.MySQLErrName[mysql.ErrUniqueKeyNeedAllFieldsInPf])
+	// ErrPartitionMgmtOnNonpartitioned returns for partition management on a non-partitioned table.
+	ErrPartitionMgmtOnNonpartitioned = terror.ClassDDL.New(codePartitionMgmtOnNonpartitioned, mysql.MySQLErrName[mysql.ErrPartitionMgmtOnNonpartitioned])
+	// ErrDropPartitionNonExistent returns when a partition to drop or truncate doesn't exist.
+	ErrDropPartitionNonExistent = terror.ClassDDL.New(codeDropPartitionNonExistent, mysql.MySQLErrName[mysql.ErrDropPartitionNonExistent])
+	// ErrDropLastPartition returns when dropping all the partitions.
+	ErrDropLastPartition = terror.ClassDDL.New(codeDropLastPartition, mysql.MySQLErrName[mysql.ErrDropLastPartition])
+	// ErrOnlyOnRangeListPartition returns for adding or dropping partitions of a hash partitioned table.
+	ErrOnlyOnRangeListPartition = terror.ClassDDL.New(codeOnlyOnRangeListPartition, mysql.MySQLErrName[mysql.ErrOnlyOnRangeListPartition])
+	// ErrSameNamePartition returns for duplicate partition names.
+	ErrSameNamePartition = terror.ClassDDL.New(codeSameNamePartition, mysql.MySQLErrName[mysql.ErrSameNamePartition])
+	// ErrPartitionColumnList returns for more than one value in VALUES LESS THAN.
+	ErrPartitionColumnList = terror.ClassDDL.New(codePartitionColumnList, mysql.MySQLErrName[mysql.ErrPartitionColumnList])
+	// ErrValuesIsNotIntType returns when a VALUES LESS THAN value isn't an integer.
+	ErrValuesIsNotIntType = terror.ClassDDL.New(codeValuesIsNotIntType, mysql.MySQLErrName[mysql.ErrValuesIsNotIntType])
+
 	// ErrInvalidDBState returns for invalid database state.
 	ErrInvalidDBState = terror.ClassDDL.New(codeInvalidDBState, "invalid database state")
 	// ErrInvalidTableState returns for invalid Table state.
@@ -118,9 +156,6 @@ var (
 	ErrInvalidIndexState = terror.ClassDDL.New(codeInvalidIndexState, "invalid index state")
 	// ErrInvalidForeignKeyState returns for invalid foreign key state.
 	ErrInvalidForeignKeyState = terror.ClassDDL.New(codeInvalidForeignKeyState, "invalid foreign key state")
-	// ErrUnsupportedModifyPrimaryKey returns an error when add or drop the primary key.
-	// It's exported for testing.
-	ErrUnsupportedModifyPrimaryKey = terror.ClassDDL.New(codeUnsupportedModifyPrimaryKey, "unsupported %s primary key")
 
 	// ErrColumnBadNull returns for a bad null value.
 	ErrColumnBadNull = terror.ClassDDL.New(codeBadNull, "column cann't be null")
@@ -140,6 +175,17 @@ var (
 	ErrWrongColumnName = terror.ClassDDL.New(codeWrongColumnName, mysql.MySQLErrName[mysql.ErrWrongColumnName])
 	// ErrWrongNameForIndex returns for wrong index name.
 	ErrWrongNameForIndex = terror.ClassDDL.New(codeWrongNameForIndex, mysql.MySQLErrName[mysql.ErrWrongNameForIndex])
+
+	// ErrFkNoIndexChild is returned when the foreign table has no index on the columns of the foreign key.
+	ErrFkNoIndexChild = terror.ClassDDL.New(codeFkNoIndexChild, mysql.MySQLErrName[mysql.ErrFkNoIndexChild])
+	// ErrFkNoIndexParent is returned when the referenced table has no index on the referenced columns.
+	ErrFkNoIndexParent = terror.ClassDDL.New(codeFkNoIndexParent, mysql.MySQLErrName[mysql.ErrFkNoIndexParent])
+	// ErrDropIndexFk is returned when the dropped index is needed in a foreign key constraint.
+	ErrDropIndexFk = terror.ClassDDL.New(codeDropIndexFk, mysql.MySQLErrName[mysql.ErrDropIndexFk])
+	// ErrTruncateIllegalFk is returned when the truncated table is referenced by a foreign key.
+	ErrTruncateIllegalFk = terror.ClassDDL.New(codeTruncateIllegalFk, mysql.MySQLErrName[mysql.ErrTruncateIllegalFk])
+	// ErrFkCannotDropParent is returned when the dropped table is referenced by a foreign key.
+	ErrFkCannotDropParent = terror.ClassDDL.New(codeFkCannotDropParent, mysql.MySQLErrName[mysql.ErrFkCannotDropParent])
 )
 
 // DDL is responsible for updating schema in data store and maintaining in-memory InfoSchema cache.
@@ -147,9 +193,12 @@ type DDL interface {
 	CreateSchema(ctx context.Context, name model.CIStr, charsetInfo *ast.CharsetOpt) error
 	DropSchema(ctx context.Context, schema model.CIStr) error
 	CreateTable(ctx context.Context, ident ast.Ident, cols []*ast.ColumnDef,
-		constrs []*ast.Constraint, options []*ast.TableOption) error
+		constrs []*ast.Constraint, options []*ast.TableOption, partition *ast.PartitionOptions) error
 	CreateTableWithLike(ctx context.Context, ident, referIdent ast.Ident) error
 	DropTable(ctx context.Context, tableIdent ast.Ident) (err error)
+	CreateView(ctx context.Context, viewIdent ast.Ident, viewInfo *model.ViewInfo, fieldTypes []*types.FieldType,
+		orReplace bool) error
+	DropView(ctx context.Context, viewIdent ast.Ident) (err error)
 	CreateIndex(ctx context.Context, tableIdent ast.Ident, unique bool, indexName model.CIStr,
 		columnNames []*ast.IndexColName, indexOption *ast.IndexOption) error
 	DropIndex(ctx context.Context, tableIdent ast.Ident, indexName model.CIStr) error
@@ -188,6 +237,8 @@ type Event struct {
 	TableInfo  *model.TableInfo
 	ColumnInfo *model.ColumnInfo
 	IndexInfo  *model.IndexInfo
+	// OldTableID is the ID of the table replaced by a rebuilt table, which has a new ID.
+	OldTableID int64
 }
 
 // String implements fmt.Stringer interface.
@@ -196,6 +247,9 @@ func (e *Event) String() string {
 	if e.TableInfo != nil {
 		ret += fmt.Sprintf(", Table ID: %d, Table Name %s", e.TableInfo.ID, e.TableInfo.Name)
 	}
+	if e.OldTableID != 0 {
+		ret += fmt.Sprintf(", Old Table ID: %d", e.OldTableID)
+	}
 	if e.ColumnInfo != nil {
 		ret += fmt.Sprintf(", Column ID: %d, Column Name %s", e.ColumnInfo.ID, e.ColumnInfo.Name)
 	}
@@ -552,7 +606,8 @@ const (
 	codeUnsupportedModifyColumn     = 203
 	codeUnsupportedDropPKHandle     = 204
 	codeUnsupportedCharset          = 205
-	codeUnsupportedModifyPrimaryKey = 206
+	codeUnsupportedPartitionedTable = 207
+	codeUnsupportedPartitionType    = 208
 
 	codeFileNotFound                 = 1017
 	codeErrorOnRename                = 1025
@@ -580,6 +635,29 @@ const (
 	codeJSONUsedAsKey                = 3152
 	codeWrongNameForIndex            = terror.ErrCode(mysql.ErrWrongNameForIndex)
 	codeErrTooLongIndexComment       = terror.ErrCode(mysql.ErrTooLongIndexComment)
+
+	codePartitionRequiresValues       = terror.ErrCode(mysql.ErrPartitionRequiresValues)
+	codePartitionWrongValues          = terror.ErrCode(mysql.ErrPartitionWrongValues)
+	codePartitionMaxvalue             = terror.ErrCode(mysql.ErrPartitionMaxvalue)
+	codePartitionWrongNoPart          = terror.ErrCode(mysql.ErrPartitionWrongNoPart)
+	codePartitionFuncNotAllowed       = terror.ErrCode(mysql.ErrPartitionFuncNotAllowed)
+	codePartitionsMustBeDefined       = terror.ErrCode(mysql.ErrPartitionsMustBeDefined)
+	codeRangeNotIncreasing            = terror.ErrCode(mysql.ErrRangeNotIncreasing)
+	codeTooManyPartitions             = terror.ErrCode(mysql.ErrTooManyPartitions)
+	codeUniqueKeyNeedAllFieldsInPf    = terror.ErrCode(mysql.ErrUniqueKeyNeedAllFieldsInPf)
+	codePartitionMgmtOnNonpartitioned = terror.ErrCode(mysql.ErrPartitionMgmtOnNonpartitioned)
+	codeDropPartitionNonExistent      = terror.ErrCode(mysql.ErrDropPartitionNonExistent)
+	codeDropLastPartition             = terror.ErrCode(mysql.ErrDropLastPartition)
+	codeOnlyOnRangeListPartition      = terror.ErrCode(mysql.ErrOnlyOnRangeListPartition)
+	codeSameNamePartition             = terror.ErrCode(mysql.ErrSameNamePartition)
+	codePartitionColumnList           = terror.ErrCode(mysql.ErrPartitionColumnList)
+	codeValuesIsNotIntType            = terror.ErrCode(mysql.ErrValuesIsNotIntType)
+
+	codeFkNoIndexChild     = terror.ErrCode(mysql.ErrFkNoIndexChild)
+	codeFkNoIndexParent    = terror.ErrCode(mysql.ErrFkNoIndexParent)
+	codeDropIndexFk        = terror.ErrCode(mysql.ErrDropIndexFk)
+	codeTruncateIllegalFk  = terror.ErrCode(mysql.ErrTruncateIllegalFk)
+	codeFkCannotDropParent = terror.ErrCode(mysql.ErrFkCannotDropParent)
 )
 
 func init() {
@@ -610,6 +688,29 @@ func init() {
 		codeWrongNameForIndex:            mysql.ErrWrongNameForIndex,
 		codeTooManyFields:                mysql.ErrTooManyFields,
 		codeErrTooLongIndexComment:       mysql.ErrTooLongIndexComment,
+
+		codePartitionRequiresValues:       mysql.ErrPartitionRequiresValues,
+		codePartitionWrongValues:          mysql.ErrPartitionWrongValues,
+		codePartitionMaxvalue:             mysql.ErrPartitionMaxvalue,
+		codePartitionWrongNoPart:          mysql.ErrPartitionWrongNoPart,
+		codePartitionFuncNotAllowed:       mysql.ErrPartitionFuncNotAllowed,
+		codePartitionsMustBeDefined:       mysql.ErrPartitionsMustBeDefined,
+		codeRangeNotIncreasing:            mysql.ErrRangeNotIncreasing,
+		codeTooManyPartitions:             mysql.ErrTooManyPartitions,
+		codeUniqueKeyNeedAllFieldsInPf:    mysql.ErrUniqueKeyNeedAllFieldsInPf,
+		codePartitionMgmtOnNonpartitioned: mysql.ErrPartitionMgmtOnNonpartitioned,
+		codeDropPartitionNonExistent:      mysql.ErrDropPartitionNonExistent,
+		codeDropLastPartition:             mysql.ErrDropLastPartition,
+		codeOnlyOnRangeListPartition:      mysql.ErrOnlyOnRangeListPartition,
+		codeSameNamePartition:             mysql.ErrSameNamePartition,
+		codePartitionColumnList:           mysql.ErrPartitionColumnList,
+		codeValuesIsNotIntType:            mysql.ErrValuesIsNotIntType,
+
+		codeFkNoIndexChild:     mysql.ErrFkNoIndexChild,
+		codeFkNoIndexParent:    mysql.ErrFkNoIndexParent,
+		codeDropIndexFk:        mysql.ErrDropIndexFk,
+		codeTruncateIllegalFk:  mysql.ErrTruncateIllegalFk,
+		codeFkCannotDropParent: mysql.ErrFkCannotDropParent,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassDDL] = ddlMySQLErrCodes
 }
@@ -181,6 +181,14 @@ func buildColumnsAndConstraints(ctx context.Context, colDefs []*ast.ColumnDef,
 func setCharsetCollationFlenDecimal(tp *types.FieldType) error {
 	tp.Charset = strings.ToLower(tp.Charset)
 	tp.Collate = strings.ToLower(tp.Collate)
+	if len(tp.Charset) == 0 && len(tp.Collate) != 0 {
+		// The charset is specified by the collation, e.g. `c varchar(10) collate utf8mb4_general_ci`.
+		coll, err := charset.GetCollationByName(tp.Collate)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		tp.Charset = coll.CharsetName
+	}
 	if len(tp.Charset) == 0 {
 		switch tp.Tp {
 		case mysql.TypeString, mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeBlob, mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob, mysql.TypeEnum, mysql.TypeSet:
@@ -606,6 +614,7 @@ func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constr
 			}
 			var fk model.FKInfo
 			fk.Name = model.NewCIStr(constr.Name)
+			fk.RefSchema = constr.Refer.Table.Schema
 			fk.RefTable = constr.Refer.Table.Name
 			fk.State = model.StatePublic
 			for _, key := range constr.Keys {
@@ -688,7 +697,8 @@ func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constr
 		idxInfo.ID = allocateIndexID(tbInfo)
 		tbInfo.Indices = append(tbInfo.Indices, idxInfo)
 	}
-	return
+	err = addFKIndexes(tbInfo)
+	return tbInfo, errors.Trace(err)
 }
 
 func (d *ddl) CreateTableWithLike(ctx context.Context, ident, referIdent ast.Ident) error {
@@ -717,6 +727,15 @@ func (d *ddl) CreateTableWithLike(ctx context.Context, ident, referIdent ast.Ide
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if tblInfo.Partition != nil {
+		tblInfo.Partition = tblInfo.Partition.Clone()
+		for i := range tblInfo.Partition.Definitions {
+			tblInfo.Partition.Definitions[i].ID, err = d.genGlobalID()
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
 	job := &model.Job{
 		SchemaID:   schema.ID,
 		TableID:    tblInfo.ID,
@@ -731,7 +750,7 @@ func (d *ddl) CreateTableWithLike(ctx context.Context, ident, referIdent ast.Ide
 }
 
 func (d *ddl) CreateTable(ctx context.Context, ident ast.Ident, colDefs []*ast.ColumnDef,
-	constraints []*ast.Constraint, options []*ast.TableOption) (err error) {
+	constraints []*ast.Constraint, options []*ast.TableOption, partition *ast.PartitionOptions) (err error) {
 	is := d.GetInformationSchema()
 	schema, ok := is.SchemaByName(ident.Schema)
 	if !ok {
@@ -770,6 +789,21 @@ func (d *ddl) CreateTable(ctx context.Context, ident ast.Ident, colDefs []*ast.C
 	if err != nil {
 		return errors.Trace(err)
 	}
+	for _, fk := range tbInfo.ForeignKeys {
+		if fk.RefSchema.L == "" {
+			fk.RefSchema = ident.Schema
+		}
+		if err = checkFKParentIndex(ctx, is, ident.Schema, tbInfo, fk); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	if partition != nil {
+		err = d.buildTablePartitionInfo(ctx, partition, tbInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
 
 	job := &model.Job{
 		SchemaID:   schema.ID,
@@ -792,6 +826,83 @@ func (d *ddl) CreateTable(ctx context.Context, ident ast.Ident, colDefs []*ast.C
 	return errors.Trace(err)
 }
 
+// CreateView creates a view. The columns of the view are named by viewInfo.Cols and typed by fieldTypes,
+// an existing view with the same name is replaced if orReplace is true.
+func (d *ddl) CreateView(ctx context.Context, ident ast.Ident, viewInfo *model.ViewInfo, fieldTypes []*types.FieldType,
+	orReplace bool) (err error) {
+	is := d.GetInformationSchema()
+	schema, ok := is.SchemaByName(ident.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(ident.Schema)
+	}
+	var oldViewTblID int64
+	if oldTbl, err1 := is.TableByName(ident.Schema, ident.Name); err1 == nil {
+		if !orReplace {
+			return infoschema.ErrTableExists.GenByArgs(ident)
+		}
+		if !oldTbl.Meta().IsView() {
+			return infoschema.ErrWrongObject.GenByArgs(ident.Schema, ident.Name, "VIEW")
+		}
+		oldViewTblID = oldTbl.Meta().ID
+	}
+	if err = checkTooLongTable(ident.Name); err != nil {
+		return errors.Trace(err)
+	}
+	if err = checkViewColumns(viewInfo.Cols); err != nil {
+		return errors.Trace(err)
+	}
+
+	tbInfo := &model.TableInfo{
+		Name: ident.Name,
+		View: viewInfo,
+	}
+	tbInfo.Charset, tbInfo.Collate = getDefaultCharsetAndCollate()
+	tbInfo.ID, err = d.genGlobalID()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for i, name := range viewInfo.Cols {
+		col := &model.ColumnInfo{
+			ID:        allocateColumnID(tbInfo),
+			Name:      name,
+			Offset:    i,
+			FieldType: *fieldTypes[i],
+			State:     model.StatePublic,
+		}
+		// A view has no key, the key flags of the underlying columns are removed.
+		col.Flag &^= mysql.PriKeyFlag | mysql.UniqueKeyFlag | mysql.MultipleKeyFlag | mysql.AutoIncrementFlag
+		tbInfo.Columns = append(tbInfo.Columns, col)
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    tbInfo.ID,
+		Type:       model.ActionCreateView,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{tbInfo, orReplace, oldViewTblID},
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+func checkViewColumns(names []model.CIStr) error {
+	if len(names) > TableColumnCountLimit {
+		return errTooManyFields
+	}
+	colNames := map[string]bool{}
+	for _, name := range names {
+		if len(name.O) > mysql.MaxColumnNameLength {
+			return ErrTooLongIdent.Gen("too long column %s", name)
+		}
+		if colNames[name.L] {
+			return infoschema.ErrColumnExists.GenByArgs(name)
+		}
+		colNames[name.L] = true
+	}
+	return nil
+}
+
 // handleAutoIncID handles auto_increment option in DDL. It creates a ID counter for the table and initiates the counter to a proper value.
 // For example if the option sets auto_increment to 10. The counter will be set to 9. So the next allocated ID will be 10.
 func (d *ddl) handleAutoIncID(tbInfo *model.TableInfo, schemaID int64) error {
@@ -840,11 +951,19 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 		validSpecs = append(validSpecs, spec)
 	}
 
-	if len(validSpecs) != 1 {
+	is := d.GetInformationSchema()
+	if tb, err1 := is.TableByName(ident.Schema, ident.Name); err1 == nil && tb.Meta().IsView() {
+		return infoschema.ErrWrongObject.GenByArgs(ident.Schema, ident.Name, "BASE TABLE")
+	}
+
+	if len(validSpecs) == 0 {
 		// TODO: Hanlde len(validSpecs) == 0.
-		// Now we only allow one schema changing at the same time.
 		return errRunMultiSchemaChanges
 	}
+	if len(validSpecs) > 1 {
+		// The changes are applied by one job, so they are visible or rolled back together.
+		return errors.Trace(d.multiSchemaChange(ctx, ident, validSpecs))
+	}
 
 	for _, spec := range validSpecs {
 		switch spec.Tp {
@@ -864,7 +983,7 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 			case ast.ConstraintForeignKey:
 				err = d.CreateForeignKey(ctx, ident, model.NewCIStr(constr.Name), spec.Constraint.Keys, spec.Constraint.Refer)
 			case ast.ConstraintPrimaryKey:
-				err = ErrUnsupportedModifyPrimaryKey.GenByArgs("add")
+				err = d.CreatePrimaryKey(ctx, ident, spec.Constraint.Keys, constr.Option)
 			default:
 				// Nothing to do now.
 			}
@@ -880,7 +999,13 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 			newIdent := ast.Ident{Schema: spec.NewTable.Schema, Name: spec.NewTable.Name}
 			err = d.RenameTable(ctx, ident, newIdent)
 		case ast.AlterTableDropPrimaryKey:
-			err = ErrUnsupportedModifyPrimaryKey.GenByArgs("drop")
+			err = d.DropPrimaryKey(ctx, ident)
+		case ast.AlterTableAddPartitions:
+			err = d.AddTablePartitions(ctx, ident, spec)
+		case ast.AlterTableDropPartition:
+			err = d.DropTablePartition(ctx, ident, spec)
+		case ast.AlterTableTruncatePartition:
+			err = d.TruncateTablePartition(ctx, ident, spec)
 		default:
 			// Nothing to do now.
 		}
@@ -904,29 +1029,161 @@ func checkColumnConstraint(constraints []*ast.ColumnOption) error {
 	return nil
 }
 
+// multiSchemaChange runs several changes of the table in one ActionMultiSchemaChange job.
+// Now only adding or dropping columns and indices is supported.
+func (d *ddl) multiSchemaChange(ctx context.Context, ti ast.Ident, specs []*ast.AlterTableSpec) error {
+	is := d.infoHandle.Get()
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+	}
+	if t.Meta().Partition != nil {
+		return errUnsupportedPartitionedTable.GenByArgs("multi schema change")
+	}
+
+	var (
+		job            *model.Job
+		addedCols      = make(map[string]struct{})
+		droppedCols    = make(map[string]struct{})
+		addedIndices   = make(map[string][]*ast.IndexColName)
+		droppedIndices = make(map[string]struct{})
+		// The columns are added before the indices, so the indices can be built on the added columns.
+		addColumnJobs []*model.SubJob
+		addIndexJobs  []*model.SubJob
+		dropJobs      []*model.SubJob
+	)
+	// The columns covered by the indices dropped in the same statement can be dropped.
+	indicesToDrop := make(map[string]struct{})
+	for _, spec := range specs {
+		if spec.Tp == ast.AlterTableDropIndex {
+			indicesToDrop[strings.ToLower(spec.Name)] = struct{}{}
+		}
+	}
+	for _, spec := range specs {
+		switch spec.Tp {
+		case ast.AlterTableAddColumn:
+			colName := spec.NewColumn.Name.Name
+			if _, ok := addedCols[colName.L]; ok {
+				return infoschema.ErrColumnExists.GenByArgs(colName.O)
+			}
+			addedCols[colName.L] = struct{}{}
+			job, err = d.buildAddColumnJob(ctx, ti, spec)
+		case ast.AlterTableDropColumn:
+			colName := spec.OldColumnName.Name
+			if _, ok := droppedCols[colName.L]; ok {
+				return ErrCantDropFieldOrKey.Gen("column %s doesn't exist", colName)
+			}
+			droppedCols[colName.L] = struct{}{}
+			job, err = d.buildDropColumnJob(ti, colName, indicesToDrop)
+		case ast.AlterTableDropIndex:
+			indexName := model.NewCIStr(spec.Name)
+			if _, ok := droppedIndices[indexName.L]; ok {
+				return ErrCantDropFieldOrKey.Gen("index %s doesn't exist", indexName)
+			}
+			droppedIndices[indexName.L] = struct{}{}
+			job, err = d.buildDropIndexJob(ti, indexName)
+		case ast.AlterTableAddConstraint:
+			constr := spec.Constraint
+			switch constr.Tp {
+			case ast.ConstraintKey, ast.ConstraintIndex:
+				job, err = d.buildCreateIndexJob(ctx, ti, false, model.NewCIStr(constr.Name), constr.Keys, constr.Option)
+			case ast.ConstraintUniq, ast.ConstraintUniqIndex, ast.ConstraintUniqKey:
+				job, err = d.buildCreateIndexJob(ctx, ti, true, model.NewCIStr(constr.Name), constr.Keys, constr.Option)
+			default:
+				return errRunMultiSchemaChanges
+			}
+			if err == nil {
+				// The name of the anonymous index is generated by the builder.
+				indexName := job.Args[1].(model.CIStr)
+				if _, ok := addedIndices[indexName.L]; ok {
+					return errDupKeyName.Gen("index already exist %s", indexName)
+				}
+				addedIndices[indexName.L] = constr.Keys
+			}
+		default:
+			return errRunMultiSchemaChanges
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		subJob, err1 := model.NewSubJob(job.Type, job.Args...)
+		if err1 != nil {
+			return errors.Trace(err1)
+		}
+		switch job.Type {
+		case model.ActionAddColumn:
+			addColumnJobs = append(addColumnJobs, subJob)
+		case model.ActionAddIndex:
+			addIndexJobs = append(addIndexJobs, subJob)
+		default:
+			dropJobs = append(dropJobs, subJob)
+		}
+	}
+
+	// Check the changes depending on each other.
+	for _, idxColNames := range addedIndices {
+		for _, ic := range idxColNames {
+			if _, ok := droppedCols[ic.Column.Name.L]; ok {
+				return errKeyColumnDoesNotExits.Gen("column does not exist: %s", ic.Column.Name)
+			}
+		}
+	}
+	if len(t.Cols())+len(addedCols) <= len(droppedCols) {
+		return ErrCantRemoveAllFields.Gen("can't drop all columns in table %s", t.Meta().Name)
+	}
+
+	subJobs := make([]*model.SubJob, 0, len(specs))
+	subJobs = append(subJobs, addColumnJobs...)
+	subJobs = append(subJobs, addIndexJobs...)
+	subJobs = append(subJobs, dropJobs...)
+	job = &model.Job{
+		SchemaID:        job.SchemaID,
+		TableID:         job.TableID,
+		Type:            model.ActionMultiSchemaChange,
+		BinlogInfo:      &model.HistoryInfo{},
+		MultiSchemaInfo: &model.MultiSchemaInfo{SubJobs: subJobs, Revertible: true},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
 // AddColumn will add a new column to the table.
 func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableSpec) error {
+	job, err := d.buildAddColumnJob(ctx, ti, spec)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+func (d *ddl) buildAddColumnJob(ctx context.Context, ti ast.Ident, spec *ast.AlterTableSpec) (*model.Job, error) {
 	// Check whether the added column constraints are supported.
 	err := checkColumnConstraint(spec.NewColumn.Options)
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 
 	is := d.infoHandle.Get()
 	schema, ok := is.SchemaByName(ti.Schema)
 	if !ok {
-		return errors.Trace(infoschema.ErrDatabaseNotExists)
+		return nil, errors.Trace(infoschema.ErrDatabaseNotExists)
 	}
 	t, err := is.TableByName(ti.Schema, ti.Name)
 	if err != nil {
-		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+		return nil, errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
 	}
 
 	// Check whether added column has existed.
 	colName := spec.NewColumn.Name.Name.O
 	col := table.FindCol(t.Cols(), colName)
 	if col != nil {
-		return infoschema.ErrColumnExists.GenByArgs(colName)
+		return nil, infoschema.ErrColumnExists.GenByArgs(colName)
 	}
 
 	// If new column is a generated column, do validation.
@@ -941,13 +1198,13 @@ func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableS
 			}
 			_, dependColNames := findDependedColumnNames(spec.NewColumn)
 			if err = columnNamesCover(referableColNames, dependColNames); err != nil {
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 		}
 	}
 
 	if len(colName) > mysql.MaxColumnNameLength {
-		return ErrTooLongIdent.Gen("too long column %s", colName)
+		return nil, ErrTooLongIdent.Gen("too long column %s", colName)
 	}
 
 	// Ingore table constraints now, maybe return error later.
@@ -955,14 +1212,14 @@ func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableS
 	// column's offset later.
 	col, _, err = buildColumnAndConstraint(ctx, len(t.Cols()), spec.NewColumn)
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 	col.OriginDefaultValue = col.DefaultValue
 	if col.OriginDefaultValue == nil && mysql.HasNotNullFlag(col.Flag) {
 		zeroVal := table.GetZeroValue(col.ToInfo())
 		col.OriginDefaultValue, err = zeroVal.ToString()
 		if err != nil {
-			return errors.Trace(err)
+			return nil, errors.Trace(err)
 		}
 	}
 
@@ -971,12 +1228,20 @@ func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableS
 		col.OriginDefaultValue = time.Now().Format(types.TimeFormat)
 	}
 
-	job := &model.Job{
+	return &model.Job{
 		SchemaID:   schema.ID,
 		TableID:    t.Meta().ID,
 		Type:       model.ActionAddColumn,
 		BinlogInfo: &model.HistoryInfo{},
 		Args:       []interface{}{col, spec.Position, 0},
+	}, nil
+}
+
+// DropColumn will drop a column from the table, now we don't support drop the column with index covered.
+func (d *ddl) DropColumn(ctx context.Context, ti ast.Ident, colName model.CIStr) error {
+	job, err := d.buildDropColumnJob(ti, colName, nil)
+	if err != nil {
+		return errors.Trace(err)
 	}
 
 	err = d.doDDLJob(ctx, job)
@@ -984,50 +1249,49 @@ func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableS
 	return errors.Trace(err)
 }
 
-// DropColumn will drop a column from the table, now we don't support drop the column with index covered.
-func (d *ddl) DropColumn(ctx context.Context, ti ast.Ident, colName model.CIStr) error {
+func (d *ddl) buildDropColumnJob(ti ast.Ident, colName model.CIStr, droppedIndices map[string]struct{}) (*model.Job, error) {
 	is := d.infoHandle.Get()
 	schema, ok := is.SchemaByName(ti.Schema)
 	if !ok {
-		return errors.Trace(infoschema.ErrDatabaseNotExists)
+		return nil, errors.Trace(infoschema.ErrDatabaseNotExists)
 	}
 	t, err := is.TableByName(ti.Schema, ti.Name)
 	if err != nil {
-		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+		return nil, errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
 	}
 
 	// Check whether dropped column has existed.
 	col := table.FindCol(t.Cols(), colName.L)
 	if col == nil {
-		return ErrCantDropFieldOrKey.Gen("column %s doesn't exist", colName)
+		return nil, ErrCantDropFieldOrKey.Gen("column %s doesn't exist", colName)
 	}
 
 	tblInfo := t.Meta()
-	if err = isDroppableColumn(tblInfo, colName); err != nil {
-		return errors.Trace(err)
+	if err = isDroppableColumn(tblInfo, colName, droppedIndices); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = checkPartitionedTableColumn(tblInfo, colName, "drop"); err != nil {
+		return nil, errors.Trace(err)
 	}
 	// We don't support dropping column with PK handle covered now.
 	if col.IsPKHandleColumn(tblInfo) {
-		return errUnsupportedPKHandle
+		return nil, errUnsupportedPKHandle
 	}
 
-	job := &model.Job{
+	return &model.Job{
 		SchemaID:   schema.ID,
 		TableID:    t.Meta().ID,
 		Type:       model.ActionDropColumn,
 		BinlogInfo: &model.HistoryInfo{},
 		Args:       []interface{}{colName},
-	}
-
-	err = d.doDDLJob(ctx, job)
-	err = d.callHookOnChanged(err)
-	return errors.Trace(err)
+	}, nil
 }
 
 // modifiable checks if the 'origin' type can be modified to 'to' type with out the need to
 // change or check existing data in the table.
-// It returns true if the two types has the same Charset and Collation, the same sign, both are
+// It returns nil if the two types has the same Charset and Collation, the same sign, both are
 // integer types or string types, and new Flen and Decimal must be greater than or equal to origin.
+// Otherwise the column data is converted by the modify column job, see checkModifyColumnWithData.
 func modifiable(origin *types.FieldType, to *types.FieldType) error {
 	if to.Flen > 0 && to.Flen < origin.Flen {
 		msg := fmt.Sprintf("length %d is less than origin %d", to.Flen, origin.Flen)
@@ -1037,11 +1301,12 @@ func modifiable(origin *types.FieldType, to *types.FieldType) error {
 		msg := fmt.Sprintf("decimal %d is less than origin %d", to.Decimal, origin.Decimal)
 		return errUnsupportedModifyColumn.GenByArgs(msg)
 	}
-	if to.Charset != origin.Charset {
+	if to.Charset != origin.Charset && !isCharsetWidened(origin.Charset, to.Charset) {
 		msg := fmt.Sprintf("charset %s not match origin %s", to.Charset, origin.Charset)
 		return errUnsupportedModifyColumn.GenByArgs(msg)
 	}
-	if to.Collate != origin.Collate {
+	// The strings are encoded by the sort keys of their collations in the indexes.
+	if to.Collate != origin.Collate && charset.GetCollator(to.Collate) != charset.GetCollator(origin.Collate) {
 		msg := fmt.Sprintf("collate %s not match origin %s", to.Collate, origin.Collate)
 		return errUnsupportedModifyColumn.GenByArgs(msg)
 	}
@@ -1075,6 +1340,18 @@ func modifiable(origin *types.FieldType, to *types.FieldType) error {
 	return errUnsupportedModifyColumn.GenByArgs(msg)
 }
 
+// isCharsetWidened checks if every string of the origin charset can be represented in the new charset,
+// the strings are always stored in utf8, so the data needn't be changed.
+func isCharsetWidened(origin, to string) bool {
+	switch origin {
+	case charset.CharsetASCII, charset.CharsetLatin1:
+		return to == charset.CharsetUTF8 || to == charset.CharsetUTF8MB4
+	case charset.CharsetUTF8:
+		return to == charset.CharsetUTF8MB4
+	}
+	return false
+}
+
 func setDefaultValue(ctx context.Context, col *table.Column, option *ast.ColumnOption) error {
 	value, err := getDefaultValue(ctx, option, col.Tp, col.Decimal)
 	if err != nil {
@@ -1173,6 +1450,9 @@ func (d *ddl) getModifiableColumnJob(ctx context.Context, ident ast.Ident, origi
 	if col == nil {
 		return nil, infoschema.ErrColumnNotExists.GenByArgs(originalColName, ident.Name)
 	}
+	if err = checkPartitionedTableColumn(t.Meta(), originalColName, "modify"); err != nil {
+		return nil, errors.Trace(err)
+	}
 
 	// Constraints in the new column means adding new constraints. Errors should thrown,
 	// which will be done by `setDefaultAndComment` later.
@@ -1194,13 +1474,16 @@ func (d *ddl) getModifiableColumnJob(ctx context.Context, ident ast.Ident, origi
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	err = modifiable(&col.FieldType, &newCol.FieldType)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
+	// If the type can't be modified in place, the column data needs to be converted.
+	changeData := modifiable(&col.FieldType, &newCol.FieldType) != nil
 	if err = setDefaultAndComment(ctx, newCol, spec.NewColumn.Options); err != nil {
 		return nil, errors.Trace(err)
 	}
+	if changeData {
+		if err = checkModifyColumnWithData(t, col, newCol); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 
 	// Copy index related options to the new spec.
 	indexFlags := col.FieldType.Flag & (mysql.PriKeyFlag | mysql.UniqueKeyFlag | mysql.MultipleKeyFlag)
@@ -1230,13 +1513,32 @@ func (d *ddl) getModifiableColumnJob(ctx context.Context, ident ast.Ident, origi
 		Type:       model.ActionModifyColumn,
 		BinlogInfo: &model.HistoryInfo{},
 		Args:       []interface{}{&newCol, originalColName, spec.Position},
+		ReorgMeta:  newReorgMeta(ctx),
 	}
 	return job, nil
 }
 
-// ChangeColumn renames an existing column and modifies the column's definition,
-// currently we only support limited kind of changes
-// that do not need to change or check data on the table.
+// checkModifyColumnWithData checks whether the column type can be changed by converting the column data.
+// The data of the column is backfilled into a new column, so the column can't be indexed or be
+// related to the generated columns.
+func checkModifyColumnWithData(t table.Table, oldCol, newCol *table.Column) error {
+	tblInfo := t.Meta()
+	if isColumnWithIndex(oldCol.Name.L, tblInfo.Indices) || mysql.HasPriKeyFlag(oldCol.Flag) {
+		return errUnsupportedModifyColumn.GenByArgs("type of the indexed column which needs to change the data")
+	}
+	if oldCol.IsGenerated() || newCol.IsGenerated() {
+		return errUnsupportedModifyColumn.GenByArgs("type of the generated column which needs to change the data")
+	}
+	for _, col := range t.Cols() {
+		if _, ok := col.Dependences[oldCol.Name.L]; ok {
+			return errUnsupportedModifyColumn.GenByArgs("type of the column which the generated column depends on")
+		}
+	}
+	return nil
+}
+
+// ChangeColumn renames an existing column and modifies the column's definition.
+// If the column data needs to be changed, it's converted in the reorganization of the job.
 func (d *ddl) ChangeColumn(ctx context.Context, ident ast.Ident, spec *ast.AlterTableSpec) error {
 	if len(spec.NewColumn.Name.Schema.O) != 0 && ident.Schema.L != spec.NewColumn.Name.Schema.L {
 		return ErrWrongDBName.GenByArgs(spec.NewColumn.Name.Schema.O)
@@ -1261,8 +1563,8 @@ func (d *ddl) ChangeColumn(ctx context.Context, ident ast.Ident, spec *ast.Alter
 	return errors.Trace(err)
 }
 
-// ModifyColumn does modification on an existing column, currently we only support limited kind of changes
-// that do not need to change or check data on the table.
+// ModifyColumn does modification on an existing column.
+// If the column data needs to be changed, it's converted in the reorganization of the job.
 func (d *ddl) ModifyColumn(ctx context.Context, ident ast.Ident, spec *ast.AlterTableSpec) error {
 	if len(spec.NewColumn.Name.Schema.O) != 0 && ident.Schema.L != spec.NewColumn.Name.Schema.L {
 		return ErrWrongDBName.GenByArgs(spec.NewColumn.Name.Schema.O)
@@ -1337,6 +1639,14 @@ func (d *ddl) DropTable(ctx context.Context, ti ast.Ident) (err error) {
 	if err != nil {
 		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
 	}
+	if tb.Meta().IsView() {
+		return infoschema.ErrWrongObject.GenByArgs(ti.Schema, ti.Name, "BASE TABLE")
+	}
+	if ctx.GetSessionVars().ForeignKeyChecks {
+		if refs := referringForeignKeys(is, tb.Meta()); len(refs) > 0 {
+			return ErrFkCannotDropParent.GenByArgs(tb.Meta().Name.O, refs[0].FK.Name.O, refs[0].Child.Name.O)
+		}
+	}
 
 	job := &model.Job{
 		SchemaID:   schema.ID,
@@ -1350,6 +1660,34 @@ func (d *ddl) DropTable(ctx context.Context, ti ast.Ident) (err error) {
 	return errors.Trace(err)
 }
 
+// DropView drops a view, it returns ErrWrongObject if the table is not a view.
+func (d *ddl) DropView(ctx context.Context, ti ast.Ident) (err error) {
+	is := d.GetInformationSchema()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(ti.Schema)
+	}
+
+	tb, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+	}
+	if !tb.Meta().IsView() {
+		return infoschema.ErrWrongObject.GenByArgs(ti.Schema, ti.Name, "VIEW")
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    tb.Meta().ID,
+		Type:       model.ActionDropView,
+		BinlogInfo: &model.HistoryInfo{},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
 func (d *ddl) TruncateTable(ctx context.Context, ti ast.Ident) error {
 	is := d.GetInformationSchema()
 	schema, ok := is.SchemaByName(ti.Schema)
@@ -1360,16 +1698,161 @@ func (d *ddl) TruncateTable(ctx context.Context, ti ast.Ident) error {
 	if err != nil {
 		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
 	}
+	if tb.Meta().IsView() {
+		return infoschema.ErrWrongObject.GenByArgs(ti.Schema, ti.Name, "BASE TABLE")
+	}
+	if ctx.GetSessionVars().ForeignKeyChecks {
+		if refs := referringForeignKeys(is, tb.Meta()); len(refs) > 0 {
+			return ErrTruncateIllegalFk.GenByArgs(fmt.Sprintf("`%s`.`%s`, CONSTRAINT `%s`",
+				refs[0].ChildSchema.O, refs[0].Child.Name.O, refs[0].FK.Name.O))
+		}
+	}
 	newTableID, err := d.genGlobalID()
 	if err != nil {
 		return errors.Trace(err)
 	}
+	// The partitions get new IDs too.
+	newPartitionIDs := getPartitionIDs(tb.Meta())
+	for i := range newPartitionIDs {
+		newPartitionIDs[i], err = d.genGlobalID()
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
 	job := &model.Job{
 		SchemaID:   schema.ID,
 		TableID:    tb.Meta().ID,
 		Type:       model.ActionTruncateTable,
 		BinlogInfo: &model.HistoryInfo{},
-		Args:       []interface{}{newTableID},
+		Args:       []interface{}{newTableID, newPartitionIDs},
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// getPartitionedTable returns the table to manage partitions,
+// it returns ErrPartitionMgmtOnNonpartitioned if the table isn't partitioned.
+func (d *ddl) getPartitionedTable(ident ast.Ident) (*model.DBInfo, table.Table, error) {
+	is := d.GetInformationSchema()
+	schema, ok := is.SchemaByName(ident.Schema)
+	if !ok {
+		return nil, nil, infoschema.ErrDatabaseNotExists.GenByArgs(ident.Schema)
+	}
+	t, err := is.TableByName(ident.Schema, ident.Name)
+	if err != nil {
+		return nil, nil, errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ident.Schema, ident.Name))
+	}
+	if t.Meta().Partition == nil {
+		return nil, nil, ErrPartitionMgmtOnNonpartitioned
+	}
+	return schema, t, nil
+}
+
+// AddTablePartitions adds partitions to a range partitioned table,
+// the new partitions must be above the last partition.
+func (d *ddl) AddTablePartitions(ctx context.Context, ident ast.Ident, spec *ast.AlterTableSpec) error {
+	schema, t, err := d.getPartitionedTable(ident)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	pi := t.Meta().Partition
+	if pi.Type != model.PartitionTypeRange {
+		return ErrOnlyOnRangeListPartition.GenByArgs("ADD")
+	}
+	defs, err := d.buildRangePartitionDefinitions(ctx, spec.PartDefinitions)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = checkPartitionNamesUnique(pi.Definitions, defs); err != nil {
+		return errors.Trace(err)
+	}
+	if err = checkRangePartitionsIncreasing(append(append([]model.PartitionDefinition{}, pi.Definitions...), defs...)); err != nil {
+		return errors.Trace(err)
+	}
+	for i := range defs {
+		defs[i].ID, err = d.genGlobalID()
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionAddTablePartition,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{defs},
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// DropTablePartition drops partitions of a range partitioned table, the data is removed in background.
+func (d *ddl) DropTablePartition(ctx context.Context, ident ast.Ident, spec *ast.AlterTableSpec) error {
+	schema, t, err := d.getPartitionedTable(ident)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	pi := t.Meta().Partition
+	if pi.Type != model.PartitionTypeRange {
+		return ErrOnlyOnRangeListPartition.GenByArgs("DROP")
+	}
+	defs, err := getPartitionDefinitionsByNames(pi, spec.PartitionNames, "DROP")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = checkPartitionNamesUnique(nil, defs); err != nil {
+		return errors.Trace(err)
+	}
+	if len(defs) == len(pi.Definitions) {
+		return ErrDropLastPartition
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionDropTablePartition,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{spec.PartitionNames},
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// TruncateTablePartition removes all the data of the partitions, the partitions get new IDs
+// and the old data is removed in background.
+func (d *ddl) TruncateTablePartition(ctx context.Context, ident ast.Ident, spec *ast.AlterTableSpec) error {
+	schema, t, err := d.getPartitionedTable(ident)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defs, err := getPartitionDefinitionsByNames(t.Meta().Partition, spec.PartitionNames, "TRUNCATE")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = checkPartitionNamesUnique(nil, defs); err != nil {
+		return errors.Trace(err)
+	}
+	oldIDs := make([]int64, 0, len(defs))
+	newIDs := make([]int64, 0, len(defs))
+	for _, def := range defs {
+		newID, err := d.genGlobalID()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		oldIDs = append(oldIDs, def.ID)
+		newIDs = append(newIDs, newID)
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionTruncateTablePartition,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{oldIDs, newIDs},
 	}
 	err = d.doDDLJob(ctx, job)
 	err = d.callHookOnChanged(err)
@@ -1423,14 +1906,35 @@ func getAnonymousIndex(t table.Table, colName model.CIStr) model.CIStr {
 
 func (d *ddl) CreateIndex(ctx context.Context, ti ast.Ident, unique bool, indexName model.CIStr,
 	idxColNames []*ast.IndexColName, indexOption *ast.IndexOption) error {
+	job, err := d.buildCreateIndexJob(ctx, ti, unique, indexName, idxColNames, indexOption)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+func (d *ddl) buildCreateIndexJob(ctx context.Context, ti ast.Ident, unique bool, indexName model.CIStr,
+	idxColNames []*ast.IndexColName, indexOption *ast.IndexOption) (*model.Job, error) {
 	is := d.infoHandle.Get()
 	schema, ok := is.SchemaByName(ti.Schema)
 	if !ok {
-		return infoschema.ErrDatabaseNotExists.GenByArgs(ti.Schema)
+		return nil, infoschema.ErrDatabaseNotExists.GenByArgs(ti.Schema)
 	}
 	t, err := is.TableByName(ti.Schema, ti.Name)
 	if err != nil {
-		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+		return nil, errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+	}
+	if t.Meta().IsView() {
+		return nil, infoschema.ErrWrongObject.GenByArgs(ti.Schema, ti.Name, "BASE TABLE")
+	}
+	if unique {
+		// The uniqueness is guaranteed in each partition.
+		if err = checkPartitionUniqueIndex(t.Meta(), idxColNames); err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
 
 	// Deal with anonymous index.
@@ -1439,7 +1943,7 @@ func (d *ddl) CreateIndex(ctx context.Context, ti ast.Ident, unique bool, indexN
 	}
 
 	if indexInfo := findIndexByName(indexName.L, t.Meta().Indices); indexInfo != nil {
-		return errDupKeyName.Gen("index already exist %s", indexName)
+		return nil, errDupKeyName.Gen("index already exist %s", indexName)
 	}
 
 	if indexOption != nil {
@@ -1449,26 +1953,28 @@ func (d *ddl) CreateIndex(ctx context.Context, ti ast.Ident, unique bool, indexN
 			maxCommentLength,
 			errTooLongIndexComment.GenByArgs(indexName.String(), maxCommentLength))
 		if err != nil {
-			return errors.Trace(err)
+			return nil, errors.Trace(err)
 		}
 	}
 
-	job := &model.Job{
+	return &model.Job{
 		SchemaID:   schema.ID,
 		TableID:    t.Meta().ID,
 		Type:       model.ActionAddIndex,
 		BinlogInfo: &model.HistoryInfo{},
 		Args:       []interface{}{unique, indexName, idxColNames, indexOption},
-	}
-
-	err = d.doDDLJob(ctx, job)
-	err = d.callHookOnChanged(err)
-	return errors.Trace(err)
+	}, nil
 }
 
-func buildFKInfo(fkName model.CIStr, keys []*ast.IndexColName, refer *ast.ReferenceDef) (*model.FKInfo, error) {
+// buildFKInfo builds the foreign key of the table in the schema dbName, the referenced table is in the same schema
+// if its schema isn't specified.
+func buildFKInfo(dbName, fkName model.CIStr, keys []*ast.IndexColName, refer *ast.ReferenceDef) (*model.FKInfo, error) {
 	var fkInfo model.FKInfo
 	fkInfo.Name = fkName
+	fkInfo.RefSchema = refer.Table.Schema
+	if fkInfo.RefSchema.L == "" {
+		fkInfo.RefSchema = dbName
+	}
 	fkInfo.RefTable = refer.Table.Name
 
 	fkInfo.Cols = make([]model.CIStr, len(keys))
@@ -1500,10 +2006,16 @@ func (d *ddl) CreateForeignKey(ctx context.Context, ti ast.Ident, fkName model.C
 		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
 	}
 
-	fkInfo, err := buildFKInfo(fkName, keys, refer)
+	fkInfo, err := buildFKInfo(ti.Schema, fkName, keys, refer)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if !fkIndexCovers(t.Meta(), fkInfo.Cols, model.CIStr{}) {
+		return ErrFkNoIndexChild.GenByArgs(fkInfo.Name.O, t.Meta().Name.O)
+	}
+	if err = checkFKParentIndex(ctx, is, ti.Schema, t.Meta(), fkInfo); err != nil {
+		return errors.Trace(err)
+	}
 
 	job := &model.Job{
 		SchemaID:   schema.ID,
@@ -1545,26 +2057,139 @@ func (d *ddl) DropForeignKey(ctx context.Context, ti ast.Ident, fkName model.CIS
 }
 
 func (d *ddl) DropIndex(ctx context.Context, ti ast.Ident, indexName model.CIStr) error {
+	job, err := d.buildDropIndexJob(ti, indexName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+func (d *ddl) buildDropIndexJob(ti ast.Ident, indexName model.CIStr) (*model.Job, error) {
 	is := d.infoHandle.Get()
 	schema, ok := is.SchemaByName(ti.Schema)
 	if !ok {
-		return errors.Trace(infoschema.ErrDatabaseNotExists)
+		return nil, errors.Trace(infoschema.ErrDatabaseNotExists)
 	}
 	t, err := is.TableByName(ti.Schema, ti.Name)
 	if err != nil {
-		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+		return nil, errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
 	}
 
 	if indexInfo := findIndexByName(indexName.L, t.Meta().Indices); indexInfo == nil {
-		return ErrCantDropFieldOrKey.Gen("index %s doesn't exist", indexName)
+		return nil, ErrCantDropFieldOrKey.Gen("index %s doesn't exist", indexName)
+	}
+	if err = checkDropIndexForFK(is, t.Meta(), indexName); err != nil {
+		return nil, errors.Trace(err)
 	}
 
-	job := &model.Job{
+	return &model.Job{
 		SchemaID:   schema.ID,
 		TableID:    t.Meta().ID,
 		Type:       model.ActionDropIndex,
 		BinlogInfo: &model.HistoryInfo{},
 		Args:       []interface{}{indexName},
+	}, nil
+}
+
+// CreatePrimaryKey adds the primary key to the table.
+// The primary index is added like a unique index first, if the primary key can be the handle,
+// the rows are rebuilt with the primary key as handle and the primary index is removed.
+func (d *ddl) CreatePrimaryKey(ctx context.Context, ti ast.Ident, idxColNames []*ast.IndexColName,
+	indexOption *ast.IndexOption) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(ti.Schema)
+	}
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+	}
+	if t.Meta().IsView() {
+		return infoschema.ErrWrongObject.GenByArgs(ti.Schema, ti.Name, "BASE TABLE")
+	}
+	if t.Meta().Partition != nil {
+		return errUnsupportedPartitionedTable.GenByArgs("add primary key")
+	}
+	if hasPrimaryKey(t.Meta()) {
+		return infoschema.ErrMultiplePriKey
+	}
+	for _, key := range idxColNames {
+		col := table.FindCol(t.Cols(), key.Column.Name.O)
+		if col == nil {
+			return errKeyColumnDoesNotExits.Gen("key column %s doesn't exist in table", key.Column.Name)
+		}
+		// Virtual columns cannot be used in primary key.
+		if col.IsGenerated() && !col.GeneratedStored {
+			return errUnsupportedOnGeneratedColumn.GenByArgs("Defining a virtual generated column as primary key")
+		}
+	}
+
+	indexName := model.NewCIStr(mysql.PrimaryKeyName)
+	if indexOption != nil {
+		indexOption.Comment, err = validateCommentLength(ctx.GetSessionVars(),
+			indexOption.Comment,
+			maxCommentLength,
+			errTooLongIndexComment.GenByArgs(indexName.String(), maxCommentLength))
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	// The rows are written with the new table ID if they are rebuilt.
+	newTableID, err := d.genGlobalID()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionAddPrimaryKey,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{indexName, idxColNames, indexOption, newTableID},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// DropPrimaryKey drops the primary key of the table.
+// If the primary key is the handle, the rows are rebuilt with the same handles which are not the primary key any more.
+func (d *ddl) DropPrimaryKey(ctx context.Context, ti ast.Ident) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return errors.Trace(infoschema.ErrDatabaseNotExists)
+	}
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+	}
+	if t.Meta().Partition != nil {
+		return errUnsupportedPartitionedTable.GenByArgs("drop primary key")
+	}
+	if !hasPrimaryKey(t.Meta()) {
+		return ErrCantDropFieldOrKey.Gen("index %s doesn't exist", mysql.PrimaryKeyName)
+	}
+	if err = checkDropIndexForFK(is, t.Meta(), model.NewCIStr(mysql.PrimaryKeyName)); err != nil {
+		return errors.Trace(err)
+	}
+
+	// The rows are written with the new table ID if they are rebuilt.
+	newTableID, err := d.genGlobalID()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionDropPrimaryKey,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{newTableID},
 	}
 
 	err = d.doDDLJob(ctx, job)
@@ -1584,7 +2209,9 @@ func findCol(cols []*model.ColumnInfo, name string) *model.ColumnInfo {
 	return nil
 }
 
-func isDroppableColumn(tblInfo *model.TableInfo, colName model.CIStr) error {
+// isDroppableColumn checks whether the column can be dropped, the indices in droppedIndices are
+// dropped together with the column, so they don't prevent the column from being dropped.
+func isDroppableColumn(tblInfo *model.TableInfo, colName model.CIStr, droppedIndices map[string]struct{}) error {
 	// Check whether there are other columns depend on this column or not.
 	for _, col := range tblInfo.Columns {
 		for dep := range col.Dependences {
@@ -1599,7 +2226,13 @@ func isDroppableColumn(tblInfo *model.TableInfo, colName model.CIStr) error {
 	}
 	// We don't support dropping column with index covered now.
 	// We must drop the index first, then drop the column.
-	if isColumnWithIndex(colName.L, tblInfo.Indices) {
+	indices := make([]*model.IndexInfo, 0, len(tblInfo.Indices))
+	for _, idx := range tblInfo.Indices {
+		if _, ok := droppedIndices[idx.Name.L]; !ok {
+			indices = append(indices, idx)
+		}
+	}
+	if isColumnWithIndex(colName.L, indices) {
 		return errCantDropColWithIndex.Gen("can't drop column %s with index covered now", colName)
 	}
 	return nil
@@ -937,11 +937,255 @@ func (s *testDBSuite) TestPrimaryKey(c *C) {
 	s.tk = testkit.NewTestKit(c, s.store)
 	s.tk.MustExec("use " + s.schemaName)
 
-	s.mustExec(c, "create table primary_key_test (a int, b varchar(10))")
-	_, err := s.tk.Exec("alter table primary_key_test add primary key(a)")
-	c.Assert(ddl.ErrUnsupportedModifyPrimaryKey.Equal(err), IsTrue)
-	_, err = s.tk.Exec("alter table primary_key_test drop primary key")
-	c.Assert(ddl.ErrUnsupportedModifyPrimaryKey.Equal(err), IsTrue)
+	s.mustExec(c, "create table primary_key_test (a int, b varchar(10), c int, index idx_c(c))")
+	s.mustExec(c, "insert into primary_key_test values (1, 'a', 10), (2, 'b', 20), (3, 'c', 30)")
+	oldTblID := s.testGetTable(c, "primary_key_test").Meta().ID
+
+	// The rows are rebuilt with the primary key as handle.
+	s.mustExec(c, "alter table primary_key_test add primary key(a)")
+	tblInfo := s.testGetTable(c, "primary_key_test").Meta()
+	c.Assert(tblInfo.PKIsHandle, IsTrue)
+	c.Assert(tblInfo.ID, Not(Equals), oldTblID)
+	c.Assert(tblInfo.RebuildInfo, IsNil)
+	c.Assert(tblInfo.Indices, HasLen, 1)
+	s.tk.MustQuery("select * from primary_key_test where a = 2").Check(testkit.Rows("2 b 20"))
+	s.tk.MustQuery("select a from primary_key_test where c = 30").Check(testkit.Rows("3"))
+	s.mustExec(c, "admin check table primary_key_test")
+	rows := s.mustQuery(c, "admin show ddl jobs")
+	c.Assert(rows[0][0], Matches, ".*Type:add primary key, State:synced.*RowCount:3.*")
+	s.testErrorCode(c, "insert into primary_key_test values (1, 'd', 40)", tmysql.ErrDupEntry)
+	s.testErrorCode(c, "alter table primary_key_test add primary key(b)", tmysql.ErrMultiplePriKey)
+
+	// The rows are rebuilt with the same handles.
+	s.mustExec(c, "alter table primary_key_test drop primary key")
+	tblInfo = s.testGetTable(c, "primary_key_test").Meta()
+	c.Assert(tblInfo.PKIsHandle, IsFalse)
+	c.Assert(tblInfo.RebuildInfo, IsNil)
+	s.mustExec(c, "insert into primary_key_test values (1, 'd', 40)")
+	s.tk.MustQuery("select * from primary_key_test where a = 1").Check(testkit.Rows("1 a 10", "1 d 40"))
+	s.mustExec(c, "admin check table primary_key_test")
+	s.testErrorCode(c, "alter table primary_key_test drop primary key", tmysql.ErrCantDropFieldOrKey)
+
+	// The job is rolled back if the values are duplicated or null.
+	s.testErrorCode(c, "alter table primary_key_test add primary key(a)", tmysql.ErrDupEntry)
+	s.mustExec(c, "delete from primary_key_test where b = 'd'")
+	s.mustExec(c, "insert into primary_key_test values (5, 'e', null)")
+	s.testErrorCode(c, "alter table primary_key_test add primary key(c)", tmysql.ErrInvalidUseOfNull)
+	s.mustExec(c, "delete from primary_key_test where b = 'e'")
+	tblInfo = s.testGetTable(c, "primary_key_test").Meta()
+	c.Assert(tblInfo.Indices, HasLen, 1)
+	c.Assert(tblInfo.PKIsHandle, IsFalse)
+
+	// The primary index is added if the primary key can't be the handle.
+	s.mustExec(c, "alter table primary_key_test add primary key(b)")
+	tblInfo = s.testGetTable(c, "primary_key_test").Meta()
+	c.Assert(tblInfo.PKIsHandle, IsFalse)
+	c.Assert(tblInfo.Indices, HasLen, 2)
+	c.Assert(tblInfo.Indices[1].Primary, IsTrue)
+	s.testErrorCode(c, "insert into primary_key_test values (4, 'a', 40)", tmysql.ErrDupEntry)
+	s.testErrorCode(c, "insert into primary_key_test values (4, null, 40)", tmysql.ErrBadNull)
+	s.mustExec(c, "alter table primary_key_test drop primary key")
+	c.Assert(s.testGetTable(c, "primary_key_test").Meta().Indices, HasLen, 1)
+	s.mustExec(c, "insert into primary_key_test values (4, 'a', 40)")
+	s.tk.MustQuery("select * from primary_key_test where b = 'a'").Check(testkit.Rows("1 a 10", "4 a 40"))
+	s.mustExec(c, "admin check table primary_key_test")
+
+	// The primary key can't be dropped if a foreign key references it.
+	s.mustExec(c, "alter table primary_key_test add primary key(a)")
+	s.mustExec(c, "create table primary_key_child (a int, foreign key (a) references primary_key_test(a))")
+	s.testErrorCode(c, "alter table primary_key_test drop primary key", tmysql.ErrDropIndexFk)
+	s.mustExec(c, "drop table primary_key_child")
+	s.mustExec(c, "alter table primary_key_test drop primary key")
+}
+
+func (s *testDBSuite) TestMultiSchemaChange(c *C) {
+	s.tk = testkit.NewTestKit(c, s.store)
+	s.tk.MustExec("use " + s.schemaName)
+	s.tk.MustExec("drop table if exists t_multi")
+	s.tk.MustExec("create table t_multi (a int, b int, c int, index idx_b(b))")
+	s.tk.MustExec("insert into t_multi values (1, 1, 1), (2, 2, 2), (3, 3, 3)")
+
+	// The changes are applied by one job.
+	s.mustExec(c, "alter table t_multi add column d int default 5 after a, add unique index idx_d_b(d, b), drop column c, drop index idx_b")
+	rows := s.mustQuery(c, "admin show ddl jobs")
+	c.Assert(rows[0][0], Matches, ".*Type:multi schema change, State:synced.*")
+	c.Assert(rows[1][0], Not(Matches), ".*Type:multi schema change.*")
+	tblInfo := s.testGetTable(c, "t_multi").Meta()
+	c.Assert(tblInfo.Columns, HasLen, 3)
+	c.Assert(tblInfo.Indices, HasLen, 1)
+	c.Assert(tblInfo.Indices[0].Name.L, Equals, "idx_d_b")
+	c.Assert(tblInfo.Indices[0].Columns[0].Offset, Equals, 1)
+	c.Assert(tblInfo.Indices[0].Columns[1].Offset, Equals, 2)
+	// The existing rows are backfilled into the index with the default value of the added column.
+	s.tk.MustQuery("select * from t_multi use index(idx_d_b) where d = 5 and b = 2").Check(testkit.Rows("2 5 2"))
+	s.tk.MustQuery("select count(*) from t_multi use index(idx_d_b) where d = 5").Check(testkit.Rows("3"))
+	s.testErrorCode(c, "insert into t_multi values (4, 5, 1)", tmysql.ErrDupEntry)
+
+	// The added columns and indices are removed together if a sub-job fails.
+	s.testErrorCode(c, "alter table t_multi add column e int default 1, add index idx_e(e), add unique index idx_d(d)", tmysql.ErrDupEntry)
+	tblInfo = s.testGetTable(c, "t_multi").Meta()
+	c.Assert(tblInfo.Columns, HasLen, 3)
+	c.Assert(tblInfo.Indices, HasLen, 1)
+	rows = s.mustQuery(c, "admin show ddl jobs")
+	c.Assert(rows[0][0], Matches, ".*Type:multi schema change, State:rollback done.*")
+	s.tk.MustQuery("select count(*) from t_multi use index(idx_d_b) where d = 5").Check(testkit.Rows("3"))
+	s.mustExec(c, "insert into t_multi values (4, 6, 4)")
+	s.tk.MustQuery("select * from t_multi where a = 4").Check(testkit.Rows("4 6 4"))
+
+	// The dependent changes are checked.
+	s.testErrorCode(c, "alter table t_multi add column e int, add column e int", tmysql.ErrDupFieldName)
+	s.testErrorCode(c, "alter table t_multi add index idx_a(a), drop column a", tmysql.ErrKeyColumnDoesNotExits)
+	s.tk.MustExec("create table t_multi2 (a int, b int)")
+	s.testErrorCode(c, "alter table t_multi2 drop column a, drop column b", tmysql.ErrCantRemoveAllFields)
+	s.testErrorCode(c, "alter table t_multi add column e int, modify column a bigint", tmysql.ErrUnknown)
+
+	// The columns covered by the indices dropped in the same statement can be dropped.
+	s.tk.MustExec("create table t_multi3 (a int, b int, c int, unique index idx_a(a), index idx_a_b(a, b), index idx_c(c))")
+	s.tk.MustExec("insert into t_multi3 values (1, 1, 1), (2, 2, 2)")
+	s.testErrorCode(c, "alter table t_multi3 drop index idx_a, drop column a", tmysql.ErrUnknown)
+	s.mustExec(c, "alter table t_multi3 drop column a, drop index idx_a, drop index idx_a_b")
+	tblInfo = s.testGetTable(c, "t_multi3").Meta()
+	c.Assert(tblInfo.Columns, HasLen, 2)
+	c.Assert(tblInfo.Indices, HasLen, 1)
+	c.Assert(tblInfo.Indices[0].Name.L, Equals, "idx_c")
+	c.Assert(tblInfo.Indices[0].Columns[0].Offset, Equals, 1)
+	s.tk.MustExec("admin check table t_multi3")
+	s.tk.MustQuery("select * from t_multi3 use index(idx_c) where c = 2").Check(testkit.Rows("2 2"))
+
+	// The DML statements in every state are applied to the changed columns and indices.
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use " + s.schemaName)
+	var checkErr error
+	times := 0
+	hook := &ddl.TestDDLCallback{}
+	hook.OnJobUpdatedExported = func(job *model.Job) {
+		if checkErr != nil || job.SchemaState == model.StateNone || job.SchemaState == model.StatePublic {
+			return
+		}
+		times++
+		_, checkErr = tk.Exec("insert into t_multi2 (a) values (?), (?)", times, -times)
+		if checkErr == nil {
+			_, checkErr = tk.Exec("update t_multi2 set a = a + 1000 where a = ?", times)
+		}
+		if checkErr == nil {
+			_, checkErr = tk.Exec("delete from t_multi2 where a = ?", -times)
+		}
+	}
+	originHook := s.dom.DDL().GetHook()
+	s.dom.DDL().SetHook(hook)
+	defer s.dom.DDL().SetHook(originHook)
+	s.tk.MustExec("alter table t_multi2 add column c int not null default 10, add unique index idx_c_a(c, a), drop column b")
+	c.Assert(checkErr, IsNil)
+	c.Assert(times, Greater, 0)
+	s.tk.MustQuery("select count(*) from t_multi2 use index(idx_c_a) where c = 10").Check(testkit.Rows(fmt.Sprintf("%d", times)))
+	s.tk.MustQuery("select count(*) from t_multi2 use index() where c = 10").Check(testkit.Rows(fmt.Sprintf("%d", times)))
+	s.tk.MustQuery("select a, c from t_multi2 use index(idx_c_a) where c = 10 and a = 1001").Check(testkit.Rows("1001 10"))
+
+	// The added columns and indices are removed together if the job is cancelled.
+	hook.OnJobUpdatedExported = func(job *model.Job) {
+		if checkErr != nil || job.SchemaState != model.StateWriteOnly || job.IsRollingback() {
+			return
+		}
+		hookCtx := mock.NewContext()
+		hookCtx.Store = s.store
+		if checkErr = hookCtx.NewTxn(); checkErr != nil {
+			return
+		}
+		var errs []error
+		errs, checkErr = admin.CancelJobs(hookCtx.Txn(), []int64{job.ID})
+		if checkErr == nil {
+			checkErr = errs[0]
+		}
+		if checkErr == nil {
+			checkErr = hookCtx.Txn().Commit()
+		}
+	}
+	_, err := s.tk.Exec("alter table t_multi2 add column d int, add index idx_d(d), drop index idx_c_a")
+	c.Assert(err, NotNil)
+	c.Assert(checkErr, IsNil)
+	tblInfo = s.testGetTable(c, "t_multi2").Meta()
+	c.Assert(tblInfo.Columns, HasLen, 2)
+	c.Assert(tblInfo.Indices, HasLen, 1)
+	c.Assert(tblInfo.Indices[0].Name.L, Equals, "idx_c_a")
+	c.Assert(tblInfo.Indices[0].State, Equals, model.StatePublic)
+}
+
+func (s *testDBSuite) TestPrimaryKeyWithDML(c *C) {
+	s.tk = testkit.NewTestKit(c, s.store)
+	s.tk.MustExec("use " + s.schemaName)
+	s.tk.MustExec("drop table if exists t_pk")
+	s.tk.MustExec("create table t_pk (a int, b int, unique index idx_b(b))")
+	s.tk.MustExec("insert into t_pk values (1, 1), (2, 2), (3, 3)")
+
+	// The DML statements in every state are written into the origin table and the rebuilt table.
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use " + s.schemaName)
+	var checkErr error
+	times := 0
+	hook := &ddl.TestDDLCallback{}
+	hook.OnJobUpdatedExported = func(job *model.Job) {
+		if checkErr != nil || job.SchemaState == model.StateNone || job.SchemaState == model.StatePublic {
+			return
+		}
+		times++
+		v := times * 10
+		_, checkErr = tk.Exec("insert into t_pk values (?, ?)", v, v)
+		if checkErr == nil {
+			_, checkErr = tk.Exec("update t_pk set b = b + 5 where a = ?", v)
+		}
+		if checkErr == nil {
+			_, checkErr = tk.Exec("delete from t_pk where a = 1")
+		}
+	}
+	originHook := s.dom.DDL().GetHook()
+	s.dom.DDL().SetHook(hook)
+	defer s.dom.DDL().SetHook(originHook)
+
+	s.tk.MustExec("alter table t_pk add primary key(a)")
+	c.Assert(checkErr, IsNil)
+	c.Assert(s.testGetTable(c, "t_pk").Meta().PKIsHandle, IsTrue)
+	s.tk.MustExec("admin check table t_pk")
+	s.tk.MustQuery("select count(*) from t_pk").Check(testkit.Rows(fmt.Sprintf("%d", 2+times)))
+	s.tk.MustQuery("select a, b from t_pk where a = 10").Check(testkit.Rows("10 15"))
+
+	s.tk.MustExec("alter table t_pk drop primary key")
+	c.Assert(checkErr, IsNil)
+	c.Assert(s.testGetTable(c, "t_pk").Meta().PKIsHandle, IsFalse)
+	s.tk.MustExec("admin check table t_pk")
+	s.tk.MustQuery("select a, b from t_pk where b = 15").Check(testkit.Rows("10 15"))
+	// The handles allocated after the rebuilding don't overwrite the rows.
+	count := len(s.mustQuery(c, "select * from t_pk"))
+	s.tk.MustExec("insert into t_pk values (1000, 1000)")
+	s.tk.MustQuery("select count(*) from t_pk").Check(testkit.Rows(fmt.Sprintf("%d", count+1)))
+
+	// The rebuilt table is removed if the job is cancelled.
+	hook.OnJobUpdatedExported = func(job *model.Job) {
+		if checkErr != nil || job.SchemaState != model.StateWriteOnly || job.IsRollingback() {
+			return
+		}
+		hookCtx := mock.NewContext()
+		hookCtx.Store = s.store
+		if checkErr = hookCtx.NewTxn(); checkErr != nil {
+			return
+		}
+		var errs []error
+		errs, checkErr = admin.CancelJobs(hookCtx.Txn(), []int64{job.ID})
+		if checkErr == nil {
+			checkErr = errs[0]
+		}
+		if checkErr == nil {
+			checkErr = hookCtx.Txn().Commit()
+		}
+	}
+	s.tk.MustExec("delete from t_pk where a = 1000")
+	_, err := s.tk.Exec("alter table t_pk add primary key(a)")
+	c.Assert(err, NotNil)
+	c.Assert(checkErr, IsNil)
+	tblInfo := s.testGetTable(c, "t_pk").Meta()
+	c.Assert(tblInfo.PKIsHandle, IsFalse)
+	c.Assert(tblInfo.RebuildInfo, IsNil)
+	c.Assert(tblInfo.Indices, HasLen, 1)
+	s.tk.MustExec("admin check table t_pk")
 }
 
 func (s *testDBSuite) TestChangeColumn(c *C) {
@@ -999,8 +1243,12 @@ func (s *testDBSuite) TestChangeColumn(c *C) {
 	s.testErrorCode(c, sql, tmysql.ErrWrongTableName)
 	sql = "alter table t3 change aa a bigint not null"
 	s.testErrorCode(c, sql, tmysql.ErrUnknown)
-	sql = "alter table t3 modify en enum('a', 'z', 'b', 'c') not null default 'a'"
-	s.testErrorCode(c, sql, tmysql.ErrUnknown)
+	sql = "alter table t3 modify en enum('b', 'c') not null default 'b'"
+	s.testErrorCode(c, sql, tmysql.WarnDataTruncated)
+
+	// The data of the enum column is converted.
+	s.mustExec(c, "alter table t3 modify en enum('a', 'z', 'b', 'c') not null default 'a'")
+	s.tk.MustQuery("select en from t3").Check(testkit.Rows("a", "a", "a"))
 
 	s.tk.MustExec("drop table t3")
 }
@@ -1517,6 +1765,108 @@ func (s *testDBSuite) TestChangeColumnPosition(c *C) {
 	c.Assert(createSQL, Equals, strings.Join(exceptedSQL, "\n"))
 }
 
+func (s *testDBSuite) TestModifyColumnWithData(c *C) {
+	s.tk = testkit.NewTestKit(c, s.store)
+	s.tk.MustExec("use " + s.schemaName)
+	s.tk.MustExec("drop table if exists t_mc")
+	s.tk.MustExec("create table t_mc (a int, b int, c varchar(255), d int)")
+	s.tk.MustExec("insert into t_mc values (1, 10, '1.5', 100), (2, 20, '-3', 200), (3, null, null, 300)")
+
+	s.tk.MustExec("alter table t_mc modify column b varchar(10)")
+	s.tk.MustQuery("select * from t_mc where b = '20'").Check(testkit.Rows("2 20 -3 200"))
+	s.tk.MustExec("alter table t_mc change column c c1 decimal(5, 2) first")
+	s.tk.MustQuery("select * from t_mc").Check(testkit.Rows("1.50 1 10 100", "-3.00 2 20 200", "<nil> 3 <nil> 300"))
+	createSQL := s.tk.MustQuery("show create table t_mc").Rows()[0][1]
+	expectedSQL := []string{
+		"CREATE TABLE `t_mc` (",
+		"  `c1` decimal(5,2) DEFAULT NULL,",
+		"  `a` int(11) DEFAULT NULL,",
+		"  `b` varchar(10) DEFAULT NULL,",
+		"  `d` int(11) DEFAULT NULL",
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin",
+	}
+	c.Assert(createSQL, Equals, strings.Join(expectedSQL, "\n"))
+	c.Assert(s.testGetTable(c, "t_mc").Meta().Columns, HasLen, 4)
+
+	// The job is rolled back if the data can't be converted, and the origin column is kept.
+	s.tk.MustExec("insert into t_mc values (4.5, 4, 'abc', 400)")
+	s.testErrorCode(c, "alter table t_mc modify column b int", tmysql.WarnDataTruncated)
+	s.tk.MustQuery("select b from t_mc").Check(testkit.Rows("10", "20", "<nil>", "abc"))
+	c.Assert(s.testGetTable(c, "t_mc").Meta().Columns, HasLen, 4)
+	s.tk.MustExec("delete from t_mc where a = 4")
+
+	// The data is converted with the sql_mode and the time zone of the session.
+	s.tk.MustExec("drop table if exists t_mc_session")
+	s.tk.MustExec("create table t_mc_session (a varchar(10), b timestamp null)")
+	s.tk.MustExec("set time_zone = '+08:00'")
+	s.tk.MustExec("insert into t_mc_session values ('abc', '2018-01-01 08:00:00')")
+	s.tk.MustExec("alter table t_mc_session modify column b datetime")
+	s.tk.MustQuery("select b from t_mc_session").Check(testkit.Rows("2018-01-01 08:00:00"))
+	s.tk.MustExec("set sql_mode = ''")
+	s.tk.MustExec("alter table t_mc_session modify column a int")
+	s.tk.MustQuery("select a from t_mc_session").Check(testkit.Rows("0"))
+	s.tk.MustExec("set sql_mode = default, time_zone = default")
+	s.tk.MustExec("drop table t_mc_session")
+
+	// The data of the indexed column can't be changed.
+	s.tk.MustExec("alter table t_mc add index idx_d(d)")
+	_, err := s.tk.Exec("alter table t_mc modify column d varchar(10)")
+	c.Assert(err, NotNil)
+
+	// The DML statements write the converted value into the changing column.
+	// The job is in write only state twice, for the changing column before the reorganization
+	// and for the origin column after it's replaced.
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use " + s.schemaName)
+	var checkErr error
+	writeOnlyTimes := 0
+	hook := &ddl.TestDDLCallback{}
+	hook.OnJobUpdatedExported = func(job *model.Job) {
+		if checkErr != nil || job.Type != model.ActionModifyColumn || job.SchemaState != model.StateWriteOnly {
+			return
+		}
+		writeOnlyTimes++
+		_, checkErr = tk.Exec("insert into t_mc values (5.5, ?, '50', 500)", 4+writeOnlyTimes)
+		if checkErr == nil {
+			_, checkErr = tk.Exec("update t_mc set b = ? where a = 2", 20+writeOnlyTimes)
+		}
+	}
+	originHook := s.dom.DDL().GetHook()
+	s.dom.DDL().SetHook(hook)
+	defer s.dom.DDL().SetHook(originHook)
+	s.tk.MustExec("alter table t_mc modify column b bigint")
+	c.Assert(checkErr, IsNil)
+	c.Assert(writeOnlyTimes, Equals, 2)
+	s.tk.MustQuery("select a, b from t_mc").Check(testkit.Rows("1 10", "2 22", "3 <nil>", "5 50", "6 50"))
+
+	// The changing column is removed if the job is cancelled.
+	hook.OnJobUpdatedExported = func(job *model.Job) {
+		if checkErr != nil || job.Type != model.ActionModifyColumn || job.SchemaState != model.StateDeleteOnly ||
+			job.IsRollingback() {
+			return
+		}
+		hookCtx := mock.NewContext()
+		hookCtx.Store = s.store
+		if checkErr = hookCtx.NewTxn(); checkErr != nil {
+			return
+		}
+		var errs []error
+		errs, checkErr = admin.CancelJobs(hookCtx.Txn(), []int64{job.ID})
+		if checkErr == nil {
+			checkErr = errs[0]
+		}
+		if checkErr == nil {
+			checkErr = hookCtx.Txn().Commit()
+		}
+	}
+	_, err = s.tk.Exec("alter table t_mc modify column b varchar(10)")
+	c.Assert(err, NotNil)
+	c.Assert(checkErr, IsNil)
+	tblInfo := s.testGetTable(c, "t_mc").Meta()
+	c.Assert(tblInfo.Columns, HasLen, 4)
+	c.Assert(tblInfo.Columns[2].Tp, Equals, tmysql.TypeLonglong)
+}
+
 func (s *testDBSuite) TestGeneratedColumnDDL(c *C) {
 	s.tk = testkit.NewTestKit(c, s.store)
 	s.tk.MustExec("use test")
@@ -1613,3 +1963,53 @@ func (s *testDBSuite) TestComment(c *C) {
 
 	s.tk.MustExec("drop table if exists ct, ct1")
 }
+
+func (s *testDBSuite) TestOldIndexVersion(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t_old_idx, t_join")
+	tk.MustExec("create table t_old_idx (id int, a varchar(20) collate utf8mb4_general_ci, unique key idx_a(a))")
+	ctx := tk.Se.(context.Context)
+	domain := sessionctx.GetDomain(ctx)
+	is := domain.InfoSchema()
+	db, ok := is.SchemaByName(model.NewCIStr("test"))
+	c.Assert(ok, IsTrue)
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t_old_idx"))
+	c.Assert(err, IsNil)
+	tblInfo := tbl.Meta()
+	c.Assert(tblInfo.Indices[0].Version, Equals, model.CurrentIndexVersion)
+
+	// The index created before the case-insensitive collations are supported keeps the raw strings.
+	tblInfo.Indices[0].Version = model.IndexVersionRaw
+	kv.RunInNewTxn(s.store, false, func(txn kv.Transaction) error {
+		m := meta.NewMeta(txn)
+		_, err = m.GenSchemaVersion()
+		c.Assert(err, IsNil)
+		c.Assert(m.UpdateTable(db.ID, tblInfo), IsNil)
+		return nil
+	})
+	c.Assert(domain.Reload(), IsNil)
+
+	tk.MustExec("insert into t_old_idx values (1, 'abc'), (2, 'ABC'), (3, 'abd')")
+	tk.MustQuery("select id from t_old_idx use index(idx_a) where a = 'Abc' order by id").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select id from t_old_idx use index(idx_a) where a in ('ABD', 'x') order by id").Check(testkit.Rows("3"))
+	tk.MustQuery("select id from t_old_idx use index(idx_a) where a > 'ABC' order by id").Check(testkit.Rows("3"))
+	tk.MustQuery("select id from t_old_idx use index(idx_a) order by a desc, id").Check(testkit.Rows("3", "1", "2"))
+	tk.MustExec("create table t_join (a varchar(20) collate utf8mb4_general_ci)")
+	tk.MustExec("insert into t_join values ('ABC')")
+	tk.MustQuery("select /*+ TIDB_INLJ(t_old_idx) */ t_old_idx.id from t_join join t_old_idx on t_join.a = t_old_idx.a order by t_old_idx.id").Check(testkit.Rows("1", "2"))
+	tk.MustExec("admin check table t_old_idx")
+
+	// The index is rebuilt in the current version.
+	tk.MustExec("delete from t_old_idx where id = 2")
+	tk.MustExec("alter table t_old_idx drop index idx_a")
+	tk.MustExec("alter table t_old_idx add unique index idx_a(a)")
+	tbl, err = domain.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t_old_idx"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Meta().Indices[0].Version, Equals, model.CurrentIndexVersion)
+	_, err = tk.Exec("insert into t_old_idx values (2, 'ABC')")
+	c.Assert(err, NotNil)
+	tk.MustQuery("select id from t_old_idx use index(idx_a) where a = 'Abc'").Check(testkit.Rows("1"))
+	tk.MustExec("admin check table t_old_idx")
+	tk.MustExec("drop table t_old_idx, t_join")
+}
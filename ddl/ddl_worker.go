@@ -133,7 +133,20 @@ func (d *ddl) updateDDLJob(t *meta.Meta, job *model.Job, updateTS uint64) error
 // If the DDL job need to handle in background, it will prepare a background job.
 func (d *ddl) finishDDLJob(t *meta.Meta, job *model.Job) (err error) {
 	switch job.Type {
-	case model.ActionDropSchema, model.ActionDropTable, model.ActionTruncateTable, model.ActionDropIndex:
+	case model.ActionAddPrimaryKey, model.ActionDropPrimaryKey, model.ActionMultiSchemaChange:
+		// The cancelled job doesn't write any data.
+		if job.State == model.JobStateCancelled {
+			break
+		}
+		// The rolled back job is finished without being updated, encode the arguments for deleting the ranges.
+		if job.State == model.JobStateRollbackDone {
+			if _, err = job.Encode(true); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		fallthrough
+	case model.ActionDropSchema, model.ActionDropTable, model.ActionTruncateTable, model.ActionDropIndex,
+		model.ActionDropTablePartition, model.ActionTruncateTablePartition:
 		if job.Version <= currentVersion {
 			err = d.delRangeManager.addDelRangeJob(job)
 		} else {
@@ -258,9 +271,17 @@ func (d *ddl) runDDLJob(t *meta.Meta, job *model.Job) (ver int64) {
 	// The cause of this job state is that the job is cancelled by client.
 	if job.IsCancelling() {
 		// If the value of SnapshotVer isn't zero, it means the work is backfilling the indexes.
-		if job.Type == model.ActionAddIndex && job.SchemaState == model.StateWriteReorganization && job.SnapshotVer != 0 {
+		if (job.Type == model.ActionAddIndex || job.Type == model.ActionModifyColumn ||
+			job.Type == model.ActionAddPrimaryKey || job.Type == model.ActionDropPrimaryKey ||
+			job.Type == model.ActionMultiSchemaChange) &&
+			job.SchemaState == model.StateWriteReorganization && job.SnapshotVer != 0 {
 			log.Infof("[ddl] run the cancelling DDL job %s", job)
 			asyncNotify(d.notifyCancelReorgJob)
+		} else if (job.Type == model.ActionModifyColumn || job.Type == model.ActionAddPrimaryKey ||
+			job.Type == model.ActionDropPrimaryKey || job.Type == model.ActionMultiSchemaChange) &&
+			job.SchemaState != model.StateNone {
+			// The job is changing the data, the changed data is removed when the job is rolled back.
+			log.Infof("[ddl] run the cancelling DDL job %s", job)
 		} else {
 			job.State = model.JobStateCancelled
 			job.Error = errCancelledDDLJob
@@ -283,6 +304,10 @@ func (d *ddl) runDDLJob(t *meta.Meta, job *model.Job) (ver int64) {
 		ver, err = d.onCreateTable(t, job)
 	case model.ActionDropTable:
 		ver, err = d.onDropTable(t, job)
+	case model.ActionCreateView:
+		ver, err = d.onCreateView(t, job)
+	case model.ActionDropView:
+		ver, err = d.onDropView(t, job)
 	case model.ActionAddColumn:
 		ver, err = d.onAddColumn(t, job)
 	case model.ActionDropColumn:
@@ -303,6 +328,18 @@ func (d *ddl) runDDLJob(t *meta.Meta, job *model.Job) (ver int64) {
 		ver, err = d.onRenameTable(t, job)
 	case model.ActionSetDefaultValue:
 		ver, err = d.onSetDefaultValue(t, job)
+	case model.ActionAddTablePartition:
+		ver, err = d.onAddTablePartition(t, job)
+	case model.ActionDropTablePartition:
+		ver, err = d.onDropTablePartition(t, job)
+	case model.ActionTruncateTablePartition:
+		ver, err = d.onTruncateTablePartition(t, job)
+	case model.ActionAddPrimaryKey:
+		ver, err = d.onAddPrimaryKey(t, job)
+	case model.ActionDropPrimaryKey:
+		ver, err = d.onDropPrimaryKey(t, job)
+	case model.ActionMultiSchemaChange:
+		ver, err = d.onMultiSchemaChange(t, job)
 	default:
 		// Invalid job, cancel it.
 		job.State = model.JobStateCancelled
@@ -425,6 +462,14 @@ func updateSchemaVersion(t *meta.Meta, job *model.Job) (int64, error) {
 			return 0, errors.Trace(err)
 		}
 		diff.TableID = job.TableID
+	} else if job.Type == model.ActionCreateView {
+		// Create or replace view has the old view ID as the third argument, it's 0 if there is no old view.
+		var orReplace bool
+		err = job.DecodeArgs(&model.TableInfo{}, &orReplace, &diff.OldTableID)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		diff.TableID = job.TableID
 	} else {
 		diff.TableID = job.TableID
 	}
@@ -230,19 +230,89 @@ func insertJobIntoDeleteRangeTable(ctx context.Context, job *model.Job) error {
 		}
 	case model.ActionDropTable, model.ActionTruncateTable:
 		tableID := job.TableID
-		startKey := tablecodec.EncodeTablePrefix(tableID)
+		// The first argument is the start key of the table, it is followed by the physical IDs of the partitions.
+		var startKey kv.Key
+		var physicalTableIDs []int64
+		if err := job.DecodeArgs(&startKey, &physicalTableIDs); err != nil {
+			return errors.Trace(err)
+		}
+		for _, physicalTableID := range physicalTableIDs {
+			startKey := tablecodec.EncodeTablePrefix(physicalTableID)
+			endKey := tablecodec.EncodeTablePrefix(physicalTableID + 1)
+			if err := doInsert(s, job.ID, physicalTableID, startKey, endKey, now); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		startKey = tablecodec.EncodeTablePrefix(tableID)
 		endKey := tablecodec.EncodeTablePrefix(tableID + 1)
 		return doInsert(s, job.ID, tableID, startKey, endKey, now)
+	case model.ActionDropTablePartition, model.ActionTruncateTablePartition:
+		var physicalTableIDs []int64
+		if err := job.DecodeArgs(&physicalTableIDs); err != nil {
+			return errors.Trace(err)
+		}
+		for _, physicalTableID := range physicalTableIDs {
+			startKey := tablecodec.EncodeTablePrefix(physicalTableID)
+			endKey := tablecodec.EncodeTablePrefix(physicalTableID + 1)
+			if err := doInsert(s, job.ID, physicalTableID, startKey, endKey, now); err != nil {
+				return errors.Trace(err)
+			}
+		}
 	case model.ActionDropIndex:
 		tableID := job.TableID
 		var indexName interface{}
 		var indexID int64
-		if err := job.DecodeArgs(&indexName, &indexID); err != nil {
+		var partitionIDs []int64
+		if err := job.DecodeArgs(&indexName, &indexID, &partitionIDs); err != nil {
 			return errors.Trace(err)
 		}
+		// The index of a partitioned table is stored in each partition.
+		if len(partitionIDs) > 0 {
+			for _, physicalTableID := range partitionIDs {
+				startKey := tablecodec.EncodeTableIndexPrefix(physicalTableID, indexID)
+				endKey := tablecodec.EncodeTableIndexPrefix(physicalTableID, indexID+1)
+				if err := doInsert(s, job.ID, physicalTableID, startKey, endKey, now); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			return nil
+		}
 		startKey := tablecodec.EncodeTableIndexPrefix(tableID, indexID)
 		endKey := tablecodec.EncodeTableIndexPrefix(tableID, indexID+1)
 		return doInsert(s, job.ID, indexID, startKey, endKey, now)
+	case model.ActionMultiSchemaChange:
+		// The arguments are the IDs of the dropped indices, or the IDs of the added indices if the job is rolled back.
+		var indexIDs []int64
+		if err := job.DecodeArgs(&indexIDs); err != nil {
+			return errors.Trace(err)
+		}
+		for _, indexID := range indexIDs {
+			startKey := tablecodec.EncodeTableIndexPrefix(job.TableID, indexID)
+			endKey := tablecodec.EncodeTableIndexPrefix(job.TableID, indexID+1)
+			if err := doInsert(s, job.ID, indexID, startKey, endKey, now); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	case model.ActionAddPrimaryKey, model.ActionDropPrimaryKey:
+		// The arguments are the IDs of the origin or rebuilt tables, and the ID of the primary index, it's 0 if
+		// the primary index isn't dropped.
+		var tableIDs []int64
+		var indexID int64
+		if err := job.DecodeArgs(&tableIDs, &indexID); err != nil {
+			return errors.Trace(err)
+		}
+		for _, tableID := range tableIDs {
+			startKey := tablecodec.EncodeTablePrefix(tableID)
+			endKey := tablecodec.EncodeTablePrefix(tableID + 1)
+			if err := doInsert(s, job.ID, tableID, startKey, endKey, now); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if indexID != 0 {
+			startKey := tablecodec.EncodeTableIndexPrefix(job.TableID, indexID)
+			endKey := tablecodec.EncodeTableIndexPrefix(job.TableID, indexID+1)
+			return doInsert(s, job.ID, indexID, startKey, endKey, now)
+		}
 	}
 	return nil
 }
@@ -14,10 +14,15 @@
 package ddl
 
 import (
+	"fmt"
+
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/util/types"
 )
 
 func (d *ddl) onCreateForeignKey(t *meta.Meta, job *model.Job) (ver int64, _ error) {
@@ -114,3 +119,107 @@ func (d *ddl) onDropForeignKey(t *meta.Meta, job *model.Job) (ver int64, _ error
 	}
 
 }
+
+// fkIndexCovers checks whether the primary key or an index of the table starts with the columns, the rows of a
+// foreign key are looked up by it. The index named except is ignored, it's used to check dropping the index.
+func fkIndexCovers(tblInfo *model.TableInfo, cols []model.CIStr, except model.CIStr) bool {
+	if len(cols) == 1 && tblInfo.PKIsHandle && except.L != "primary" {
+		if pkCol := tblInfo.GetPkColInfo(); pkCol != nil && pkCol.Name.L == cols[0].L {
+			return true
+		}
+	}
+	for _, idx := range tblInfo.Indices {
+		if idx.Name.L == except.L || idx.State != model.StatePublic || len(idx.Columns) < len(cols) {
+			continue
+		}
+		match := true
+		for i, col := range cols {
+			if idx.Columns[i].Name.L != col.L || idx.Columns[i].Length != types.UnspecifiedLength {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// addFKIndexes adds the indexes on the columns of the foreign keys which aren't covered by the primary key or
+// another index, as MySQL does, so the rows referring to a parent row are looked up by the index.
+func addFKIndexes(tbInfo *model.TableInfo) error {
+	for _, fk := range tbInfo.ForeignKeys {
+		if fkIndexCovers(tbInfo, fk.Cols, model.CIStr{}) {
+			continue
+		}
+		keys := make([]*ast.IndexColName, 0, len(fk.Cols))
+		for _, col := range fk.Cols {
+			keys = append(keys, &ast.IndexColName{Column: &ast.ColumnName{Name: col}, Length: types.UnspecifiedLength})
+		}
+		name := fk.Name
+		for i := 2; findIndexByName(name.L, tbInfo.Indices) != nil; i++ {
+			name = model.NewCIStr(fmt.Sprintf("%s_%d", fk.Name.O, i))
+		}
+		idxInfo, err := buildIndexInfo(tbInfo, name, keys, model.StatePublic)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		idxInfo.Tp = model.IndexTypeBtree
+		idxInfo.ID = allocateIndexID(tbInfo)
+		tbInfo.Indices = append(tbInfo.Indices, idxInfo)
+	}
+	return nil
+}
+
+// checkFKParentIndex checks that the referenced table has an index on the referenced columns.
+// The referenced table must exist if foreign_key_checks is enabled, otherwise the check is skipped if it doesn't exist.
+func checkFKParentIndex(ctx context.Context, is infoschema.InfoSchema, dbName model.CIStr, tblInfo *model.TableInfo,
+	fk *model.FKInfo) error {
+	parentInfo := tblInfo
+	if fk.RefSchema.L != dbName.L || fk.RefTable.L != tblInfo.Name.L {
+		parent, err := is.TableByName(fk.RefSchema, fk.RefTable)
+		if err != nil {
+			if ctx.GetSessionVars().ForeignKeyChecks {
+				return infoschema.ErrCannotAddForeign
+			}
+			return nil
+		}
+		parentInfo = parent.Meta()
+	}
+	if !fkIndexCovers(parentInfo, fk.RefCols, model.CIStr{}) {
+		return ErrFkNoIndexParent.GenByArgs(fk.Name.O, fk.RefTable.O)
+	}
+	return nil
+}
+
+// referringForeignKeys returns the foreign keys of the other tables which refer to the table.
+func referringForeignKeys(is infoschema.InfoSchema, tblInfo *model.TableInfo) []*infoschema.ForeignKeyRef {
+	var refs []*infoschema.ForeignKeyRef
+	for _, ref := range is.ReferredForeignKeys(tblInfo.ID) {
+		if ref.Child.ID != tblInfo.ID {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// checkDropIndexForFK returns ErrDropIndexFk if the index is needed by a foreign key of the table or a foreign key
+// referring to the table, that is no other index covers the columns of the foreign key.
+func checkDropIndexForFK(is infoschema.InfoSchema, tblInfo *model.TableInfo, indexName model.CIStr) error {
+	needed := func(cols []model.CIStr) bool {
+		return fkIndexCovers(tblInfo, cols, model.CIStr{}) && !fkIndexCovers(tblInfo, cols, indexName)
+	}
+	for _, fk := range tblInfo.ForeignKeys {
+		if fk.State == model.StatePublic && needed(fk.Cols) {
+			return ErrDropIndexFk.GenByArgs(indexName.O)
+		}
+	}
+	// The foreign keys of the table referring to itself are included.
+	for _, ref := range is.ReferredForeignKeys(tblInfo.ID) {
+		if needed(ref.FK.RefCols) {
+			return ErrDropIndexFk.GenByArgs(indexName.O)
+		}
+	}
+	return nil
+}
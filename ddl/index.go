@@ -136,6 +136,7 @@ func buildIndexInfo(tblInfo *model.TableInfo, indexName model.CIStr, idxColNames
 		Name:    indexName,
 		Columns: idxColumns,
 		State:   state,
+		Version: model.CurrentIndexVersion,
 	}
 	return idxInfo, nil
 }
@@ -173,6 +174,32 @@ func dropIndexColumnFlag(tblInfo *model.TableInfo, indexInfo *model.IndexInfo) {
 	}
 }
 
+// createIndexInfo builds the index info in none state and appends it to the table info.
+func createIndexInfo(tblInfo *model.TableInfo, unique bool, indexName model.CIStr, idxColNames []*ast.IndexColName,
+	indexOption *ast.IndexOption) (*model.IndexInfo, error) {
+	indexInfo, err := buildIndexInfo(tblInfo, indexName, idxColNames, model.StateNone)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if indexOption != nil {
+		indexInfo.Comment = indexOption.Comment
+		if indexOption.Tp == model.IndexTypeInvalid {
+			// Use btree as default index type.
+			indexInfo.Tp = model.IndexTypeBtree
+		} else {
+			indexInfo.Tp = indexOption.Tp
+		}
+	} else {
+		// Use btree as default index type.
+		indexInfo.Tp = model.IndexTypeBtree
+	}
+	indexInfo.Primary = false
+	indexInfo.Unique = unique
+	indexInfo.ID = allocateIndexID(tblInfo)
+	tblInfo.Indices = append(tblInfo.Indices, indexInfo)
+	return indexInfo, nil
+}
+
 func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) (ver int64, err error) {
 	// Handle the rolling back job.
 	if job.IsRollingback() {
@@ -209,27 +236,11 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) (ver int64, err error)
 	}
 
 	if indexInfo == nil {
-		indexInfo, err = buildIndexInfo(tblInfo, indexName, idxColNames, model.StateNone)
+		indexInfo, err = createIndexInfo(tblInfo, unique, indexName, idxColNames, indexOption)
 		if err != nil {
 			job.State = model.JobStateCancelled
 			return ver, errors.Trace(err)
 		}
-		if indexOption != nil {
-			indexInfo.Comment = indexOption.Comment
-			if indexOption.Tp == model.IndexTypeInvalid {
-				// Use btree as default index type.
-				indexInfo.Tp = model.IndexTypeBtree
-			} else {
-				indexInfo.Tp = indexOption.Tp
-			}
-		} else {
-			// Use btree as default index type.
-			indexInfo.Tp = model.IndexTypeBtree
-		}
-		indexInfo.Primary = false
-		indexInfo.Unique = unique
-		indexInfo.ID = allocateIndexID(tblInfo)
-		tblInfo.Indices = append(tblInfo.Indices, indexInfo)
 	}
 	originalState := indexInfo.State
 	switch indexInfo.State {
@@ -263,11 +274,10 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) (ver int64, err error)
 		if err != nil || reorgInfo.first {
 			if err == nil {
 				// Get the first handle of this table.
-				err = iterateSnapshotRows(d.store, tbl, reorgInfo.SnapshotVer, math.MinInt64,
-					func(h int64, rowKey kv.Key, rawRecord []byte) (bool, error) {
-						reorgInfo.Handle = h
-						return false, nil
-					})
+				reorgInfo.Handle, err = d.getFirstHandle(getPhysicalTables(tbl), reorgInfo.SnapshotVer)
+				if err != nil {
+					return ver, errors.Trace(err)
+				}
 				return ver, errors.Trace(t.UpdateDDLReorgHandle(reorgInfo.Job, reorgInfo.Handle))
 			}
 			// If we run reorg firstly, we should update the job snapshot version
@@ -397,7 +407,7 @@ func (d *ddl) onDropIndex(t *meta.Meta, job *model.Job) (ver int64, _ error) {
 			d.asyncNotifyEvent(&Event{Tp: model.ActionDropIndex, TableInfo: tblInfo, IndexInfo: indexInfo})
 		}
 		job.BinlogInfo.AddTableInfo(ver, tblInfo)
-		job.Args = append(job.Args, indexInfo.ID)
+		job.Args = append(job.Args, indexInfo.ID, getPartitionIDs(tblInfo))
 	default:
 		err = ErrInvalidTableState.Gen("invalid table state %v", tblInfo.State)
 	}
@@ -440,8 +450,13 @@ func (w *worker) fetchRowColVals(txn kv.Transaction, t table.Table, colMap map[i
 }
 
 func (w *worker) getIndexRecord(t table.Table, colMap map[int64]*types.FieldType, rawRecord []byte, idxRecord *indexRecord) error {
-	cols := t.Cols()
+	// The index may be built on the columns which are being added with it.
+	cols := t.WritableCols()
 	idxInfo := w.index.Meta()
+	// The null values aren't stored in the row, clean up the values of the previous row.
+	for id := range w.rowMap {
+		delete(w.rowMap, id)
+	}
 	_, err := tablecodec.DecodeRowWithMap(rawRecord, colMap, time.UTC, w.rowMap)
 	if err != nil {
 		return errors.Trace(err)
@@ -462,12 +477,25 @@ func (w *worker) getIndexRecord(t table.Table, colMap map[int64]*types.FieldType
 			idxVal[j] = idxColumnVal
 			continue
 		}
-		idxColumnVal, err = tables.GetColDefaultValue(w.ctx, col, w.defaultVals)
+		if col.State != model.StatePublic {
+			// The column is being added with the index, the rows written before have its origin default value.
+			idxColumnVal, err = table.GetColOriginDefaultValue(w.ctx, col.ToInfo())
+		} else {
+			idxColumnVal, err = tables.GetColDefaultValue(w.ctx, col, w.defaultVals)
+		}
 		if err != nil {
 			return errors.Trace(err)
 		}
 		idxVal[j] = idxColumnVal
 	}
+	if idxInfo.Primary {
+		// The columns may not have the not null flag yet when the primary key is being added.
+		for _, val := range idxVal {
+			if val.IsNull() {
+				return errors.Trace(errInvalidUseOfNull)
+			}
+		}
+	}
 	idxRecord.vals = idxVal
 	return nil
 }
@@ -497,7 +525,8 @@ type indexRecord struct {
 type worker struct {
 	id          int
 	ctx         context.Context
-	index       table.Index
+	indices     []table.Index  // They're the indices of the physical tables, every partition has its own index data.
+	index       table.Index    // It's the index of the physical table which is being backfilled.
 	defaultVals []types.Datum  // It's used to reduce the number of new slice.
 	idxRecords  []*indexRecord // It's used to reduce the number of new slice.
 	taskRange   handleInfo     // Every task's handle range.
@@ -545,7 +574,7 @@ type handleInfo struct {
 // an error message is displayed, exit the traversal.
 // Finally, update the concurrent processing of the total number of rows, and store the completed handle value.
 func (d *ddl) addTableIndex(t table.Table, indexInfo *model.IndexInfo, reorgInfo *reorgInfo, job *model.Job) error {
-	cols := t.Cols()
+	cols := t.WritableCols()
 	colMap := make(map[int64]*types.FieldType)
 	for _, v := range indexInfo.Columns {
 		col := cols[v.Offset]
@@ -555,13 +584,18 @@ func (d *ddl) addTableIndex(t table.Table, indexInfo *model.IndexInfo, reorgInfo
 	taskBatch := int64(defaultTaskHandleCnt)
 	addedCount := job.GetRowCount()
 	baseHandle := reorgInfo.Handle
+	// The handles are unique among the partitions, so every task deals with the handle range in all the partitions.
+	tbls := getPhysicalTables(t)
 
 	workers := make([]*worker, workerCnt)
 	for i := 0; i < workerCnt; i++ {
 		ctx := d.newContext()
 		workers[i] = newWorker(ctx, i, int(taskBatch), len(cols), len(colMap))
 		// Make sure every worker has its own index buffer.
-		workers[i].index = tables.NewIndexWithBuffer(t.Meta(), indexInfo)
+		workers[i].indices = make([]table.Index, 0, len(tbls))
+		for _, tbl := range tbls {
+			workers[i].indices = append(workers[i].indices, tables.NewIndexWithBuffer(tbl.GetPhysicalID(), t.Meta(), indexInfo))
+		}
 	}
 	for {
 		startTime := time.Now()
@@ -570,7 +604,7 @@ func (d *ddl) addTableIndex(t table.Table, indexInfo *model.IndexInfo, reorgInfo
 			wg.Add(1)
 			workers[i].setTaskNewRange(baseHandle, baseHandle+taskBatch)
 			// TODO: Consider one worker to one goroutine.
-			go workers[i].doBackfillIndexTask(t, colMap, &wg)
+			go workers[i].doBackfillIndexTask(tbls, colMap, &wg)
 			baseHandle += taskBatch
 		}
 		wg.Wait()
@@ -619,13 +653,13 @@ func getCountAndHandle(workers []*worker) (int64, int64, bool, error) {
 	return taskAddedCount, nextHandle, isEnd, errors.Trace(err)
 }
 
-func (w *worker) doBackfillIndexTask(t table.Table, colMap map[int64]*types.FieldType, wg *sync.WaitGroup) {
+func (w *worker) doBackfillIndexTask(tbls []table.PhysicalTable, colMap map[int64]*types.FieldType, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	startTime := time.Now()
 	var ret *taskResult
 	err := kv.RunInNewTxn(w.ctx.GetStore(), true, func(txn kv.Transaction) error {
-		ret = w.doBackfillIndexTaskInTxn(t, txn, colMap)
+		ret = w.doBackfillPhysicalTablesInTxn(tbls, txn, colMap)
 		return errors.Trace(ret.err)
 	})
 	if err != nil {
@@ -637,6 +671,29 @@ func (w *worker) doBackfillIndexTask(t table.Table, colMap map[int64]*types.Fiel
 		w.taskRange, time.Since(startTime), ret.err)
 }
 
+// doBackfillPhysicalTablesInTxn backfills the index data of the task's handle range in every physical table.
+// The task isn't done until it's done in all the physical tables, and the handle out of the range is the
+// smallest one of them.
+func (w *worker) doBackfillPhysicalTablesInTxn(tbls []table.PhysicalTable, txn kv.Transaction,
+	colMap map[int64]*types.FieldType) *taskResult {
+	ret := &taskResult{outOfRangeHandle: w.taskRange.endHandle, isAllDone: true}
+	for i, t := range tbls {
+		w.index = w.indices[i]
+		taskRet := w.doBackfillIndexTaskInTxn(t, txn, colMap)
+		if taskRet.err != nil {
+			return taskRet
+		}
+		ret.count += taskRet.count
+		if !taskRet.isAllDone {
+			if ret.isAllDone || taskRet.outOfRangeHandle < ret.outOfRangeHandle {
+				ret.outOfRangeHandle = taskRet.outOfRangeHandle
+			}
+			ret.isAllDone = false
+		}
+	}
+	return ret
+}
+
 // doBackfillIndexTaskInTxn deals with a part of backfilling index data in a Transaction.
 // This part of the index data rows is defaultTaskHandleCnt.
 func (w *worker) doBackfillIndexTaskInTxn(t table.Table, txn kv.Transaction, colMap map[int64]*types.FieldType) *taskResult {
@@ -682,6 +739,27 @@ func allocateIndexID(tblInfo *model.TableInfo) int64 {
 	return tblInfo.MaxIndexID
 }
 
+// getFirstHandle returns the smallest handle of the rows in the physical tables, it's 0 if there is no row.
+func (d *ddl) getFirstHandle(tbls []table.PhysicalTable, version uint64) (int64, error) {
+	var (
+		firstHandle int64
+		found       bool
+	)
+	for _, t := range tbls {
+		err := iterateSnapshotRows(d.store, t, version, math.MinInt64,
+			func(h int64, rowKey kv.Key, rawRecord []byte) (bool, error) {
+				if !found || h < firstHandle {
+					firstHandle, found = h, true
+				}
+				return false, nil
+			})
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+	return firstHandle, nil
+}
+
 // recordIterFunc is used for low-level record iteration.
 type recordIterFunc func(h int64, rowKey kv.Key, rawRecord []byte) (more bool, err error)
 
@@ -39,6 +39,8 @@ var (
 	// handle batch data type.
 	batchAddCol              = "batch_add_col"
 	batchAddIdx              = "batch_add_idx"
+	batchModifyCol           = "batch_modify_col"
+	batchRebuildTable        = "batch_rebuild_table"
 	batchDelData             = "batch_del_data"
 	batchHandleDataHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
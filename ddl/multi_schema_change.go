@@ -0,0 +1,582 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"math"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/model"
+)
+
+// onMultiSchemaChange runs an ActionMultiSchemaChange job, the changes are applied together:
+// First, the sub-jobs adding columns and indices go through the schema states one by one, until the added column
+// is in write reorganization state, or the added index is backfilled.
+// Then all the added columns and indices become public, and the dropped columns and indices become write only
+// in the same schema version. The job can't be rolled back since then.
+// At last, the dropped columns and indices go through the rest of the schema states together and are removed.
+// If any sub-job fails or the job is cancelled before the changes are public, all the added columns and indices
+// are removed together, so the table is the same as before.
+func (d *ddl) onMultiSchemaChange(t *meta.Meta, job *model.Job) (ver int64, err error) {
+	schemaID := job.SchemaID
+	tblInfo, err := getTableInfo(t, job, schemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	info := job.MultiSchemaInfo
+	if job.IsRollingback() {
+		return d.rollbackMultiSchemaChange(t, job, tblInfo)
+	}
+	if !info.Revertible {
+		// The changes are public, the job can't be cancelled now.
+		if job.IsCancelling() {
+			job.State = model.JobStateRunning
+		}
+		return d.dropMultiSchemaChangeElements(t, job, tblInfo)
+	}
+	// When backfilling, the reorganization is notified to be cancelled, see runDDLJob.
+	if job.IsCancelling() && (job.SchemaState != model.StateWriteReorganization || job.SnapshotVer == 0) {
+		return d.convertMultiSchemaChange2RollbackJob(t, job, tblInfo, errCancelledDDLJob)
+	}
+	if job.SchemaState == model.StateNone {
+		if err = checkDropSubJobs(tblInfo, info); err != nil {
+			job.State = model.JobStateCancelled
+			return ver, errors.Trace(err)
+		}
+	}
+
+	for _, sub := range info.SubJobs {
+		if sub.Ready {
+			continue
+		}
+		switch sub.Type {
+		case model.ActionAddColumn:
+			return d.runAddColumnSubJob(t, job, tblInfo, sub)
+		case model.ActionAddIndex:
+			return d.runAddIndexSubJob(t, job, tblInfo, sub)
+		}
+	}
+	return d.commitMultiSchemaChange(t, job, tblInfo)
+}
+
+// updateVersionAndTableInfo updates the table info with a new schema version.
+func updateVersionAndTableInfo(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo) (int64, error) {
+	ver, err := updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	return ver, errors.Trace(t.UpdateTable(job.SchemaID, tblInfo))
+}
+
+// getSubJobElementName returns the name of the column or index changed by the sub-job.
+func getSubJobElementName(sub *model.SubJob) (model.CIStr, error) {
+	var (
+		name model.CIStr
+		err  error
+	)
+	switch sub.Type {
+	case model.ActionAddColumn:
+		col := &model.ColumnInfo{}
+		err = sub.DecodeArgs(col)
+		name = col.Name
+	case model.ActionAddIndex:
+		var unique bool
+		err = sub.DecodeArgs(&unique, &name)
+	default:
+		err = sub.DecodeArgs(&name)
+	}
+	return name, errors.Trace(err)
+}
+
+// checkDropSubJobs checks whether the columns and indices to drop exist and can be dropped.
+func checkDropSubJobs(tblInfo *model.TableInfo, info *model.MultiSchemaInfo) error {
+	droppedIndices := make(map[string]struct{})
+	droppedCols := make([]model.CIStr, 0, len(info.SubJobs))
+	for _, sub := range info.SubJobs {
+		if sub.Type != model.ActionDropColumn && sub.Type != model.ActionDropIndex {
+			continue
+		}
+		name, err := getSubJobElementName(sub)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if sub.Type == model.ActionDropIndex {
+			if findIndexByName(name.L, tblInfo.Indices) == nil {
+				return ErrCantDropFieldOrKey.Gen("index %s doesn't exist", name)
+			}
+			droppedIndices[name.L] = struct{}{}
+			continue
+		}
+		if findCol(tblInfo.Columns, name.L) == nil {
+			return ErrCantDropFieldOrKey.Gen("column %s doesn't exist", name)
+		}
+		droppedCols = append(droppedCols, name)
+	}
+	// The indices dropped by the sibling sub-jobs don't prevent their columns from being dropped.
+	for _, name := range droppedCols {
+		if err := isDroppableColumn(tblInfo, name, droppedIndices); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// resetColumnOffsets sets the offsets of the public columns by their positions, the offsets of the non-public
+// columns follow them. The offsets of the index columns are updated too.
+func resetColumnOffsets(tblInfo *model.TableInfo) {
+	offsetChanged := make(map[int]int, len(tblInfo.Columns))
+	offset := 0
+	for _, public := range []bool{true, false} {
+		for _, col := range tblInfo.Columns {
+			if (col.State == model.StatePublic) != public {
+				continue
+			}
+			offsetChanged[col.Offset] = offset
+			col.Offset = offset
+			offset++
+		}
+	}
+
+	for _, idx := range tblInfo.Indices {
+		for _, col := range idx.Columns {
+			if newOffset, ok := offsetChanged[col.Offset]; ok {
+				col.Offset = newOffset
+			}
+		}
+	}
+}
+
+func (d *ddl) runAddColumnSubJob(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, sub *model.SubJob) (
+	ver int64, err error) {
+	col := &model.ColumnInfo{}
+	pos := &ast.ColumnPosition{}
+	if err = sub.DecodeArgs(col, pos); err != nil {
+		return d.convertMultiSchemaChange2RollbackJob(t, job, tblInfo, err)
+	}
+
+	columnInfo := findCol(tblInfo.Columns, col.Name.L)
+	if columnInfo == nil {
+		columnInfo, _, err = d.createColumnInfo(tblInfo, col, pos)
+		if err != nil {
+			return d.convertMultiSchemaChange2RollbackJob(t, job, tblInfo, err)
+		}
+	} else if sub.SchemaState == model.StateNone {
+		// We already have a column with the same column name.
+		return d.convertMultiSchemaChange2RollbackJob(t, job, tblInfo, infoschema.ErrColumnExists.GenByArgs(col.Name))
+	}
+
+	switch columnInfo.State {
+	case model.StateNone:
+		// none -> delete only
+		columnInfo.State = model.StateDeleteOnly
+	case model.StateDeleteOnly:
+		// delete only -> write only
+		columnInfo.State = model.StateWriteOnly
+	case model.StateWriteOnly:
+		// write only -> reorganization, the column becomes public with the other changes.
+		columnInfo.State = model.StateWriteReorganization
+		sub.Ready = true
+	default:
+		return ver, ErrInvalidColumnState.Gen("invalid column state %v", columnInfo.State)
+	}
+	sub.SchemaState = columnInfo.State
+	job.SchemaState = columnInfo.State
+	ver, err = updateVersionAndTableInfo(t, job, tblInfo)
+	return ver, errors.Trace(err)
+}
+
+func (d *ddl) runAddIndexSubJob(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, sub *model.SubJob) (
+	ver int64, err error) {
+	var (
+		unique      bool
+		indexName   model.CIStr
+		idxColNames []*ast.IndexColName
+		indexOption *ast.IndexOption
+	)
+	if err = sub.DecodeArgs(&unique, &indexName, &idxColNames, &indexOption); err != nil {
+		return d.convertMultiSchemaChange2RollbackJob(t, job, tblInfo, err)
+	}
+
+	indexInfo := findIndexByName(indexName.L, tblInfo.Indices)
+	if indexInfo == nil {
+		indexInfo, err = createIndexInfo(tblInfo, unique, indexName, idxColNames, indexOption)
+		if err != nil {
+			return d.convertMultiSchemaChange2RollbackJob(t, job, tblInfo, err)
+		}
+	} else if sub.SchemaState == model.StateNone {
+		return d.convertMultiSchemaChange2RollbackJob(t, job, tblInfo,
+			errDupKeyName.Gen("index already exist %s", indexName))
+	}
+
+	switch indexInfo.State {
+	case model.StateNone:
+		// none -> delete only
+		indexInfo.State = model.StateDeleteOnly
+	case model.StateDeleteOnly:
+		// delete only -> write only
+		indexInfo.State = model.StateWriteOnly
+	case model.StateWriteOnly:
+		// write only -> reorganization
+		indexInfo.State = model.StateWriteReorganization
+		// Initialize SnapshotVer to 0 for later reorganization check.
+		job.SnapshotVer = 0
+		job.SetRowCount(0)
+	case model.StateWriteReorganization:
+		return d.backfillIndexSubJob(t, job, tblInfo, indexInfo, sub)
+	default:
+		return ver, ErrInvalidIndexState.Gen("invalid index state %v", indexInfo.State)
+	}
+	sub.SchemaState = indexInfo.State
+	job.SchemaState = indexInfo.State
+	ver, err = updateVersionAndTableInfo(t, job, tblInfo)
+	return ver, errors.Trace(err)
+}
+
+// backfillIndexSubJob backfills the index in write reorganization state, the index becomes public with the other changes.
+func (d *ddl) backfillIndexSubJob(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, indexInfo *model.IndexInfo,
+	sub *model.SubJob) (ver int64, err error) {
+	tbl, err := d.getTable(job.SchemaID, tblInfo)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	reorgInfo, err := d.getReorgInfo(t, job)
+	if err != nil || reorgInfo.first {
+		if err == nil {
+			// Get the first handle of this table.
+			err = iterateSnapshotRows(d.store, tbl, reorgInfo.SnapshotVer, math.MinInt64,
+				func(h int64, rowKey kv.Key, rawRecord []byte) (bool, error) {
+					reorgInfo.Handle = h
+					return false, nil
+				})
+			return ver, errors.Trace(t.UpdateDDLReorgHandle(reorgInfo.Job, reorgInfo.Handle))
+		}
+		// If we run reorg firstly, we should update the job snapshot version
+		// and then run the reorg next time.
+		return ver, errors.Trace(err)
+	}
+
+	err = d.runReorgJob(job, func() error {
+		return d.addTableIndex(tbl, indexInfo, reorgInfo, job)
+	})
+	if err != nil {
+		if errWaitReorgTimeout.Equal(err) {
+			// if timeout, we should return, check for the owner and re-wait job done.
+			return ver, nil
+		}
+		if kv.ErrKeyExists.Equal(err) || errCancelledDDLJob.Equal(err) {
+			log.Warnf("[ddl] run DDL job %v err %v, convert job to rollback job", job, err)
+			if kv.ErrKeyExists.Equal(err) {
+				err = kv.ErrKeyExists.Gen("Duplicate for key %s", indexInfo.Name.O)
+			}
+			ver, err = d.convertMultiSchemaChange2RollbackJob(t, job, tblInfo, err)
+		}
+		// Clean up the channel of notifyCancelReorgJob. Make sure it can't affect other jobs.
+		cleanNotify(d.notifyCancelReorgJob)
+		return ver, errors.Trace(err)
+	}
+	// Clean up the channel of notifyCancelReorgJob. Make sure it can't affect other jobs.
+	cleanNotify(d.notifyCancelReorgJob)
+
+	sub.Ready = true
+	sub.RowCount = job.GetRowCount()
+	// The job isn't backfilling any more, reset SnapshotVer so that cancelling it doesn't notify the reorganization.
+	job.SnapshotVer = 0
+	return ver, nil
+}
+
+// commitMultiSchemaChange makes the added columns and indices public, and makes the dropped columns and indices
+// write only in the same schema version.
+func (d *ddl) commitMultiSchemaChange(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo) (ver int64, err error) {
+	info := job.MultiSchemaInfo
+	var (
+		addedCols      []*model.ColumnInfo
+		addedIndices   []*model.IndexInfo
+		droppedCols    []*model.ColumnInfo
+		droppedIndices []*model.IndexInfo
+	)
+	for _, sub := range info.SubJobs {
+		name, err := getSubJobElementName(sub)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+		switch sub.Type {
+		case model.ActionAddColumn, model.ActionDropColumn:
+			col := findCol(tblInfo.Columns, name.L)
+			if col == nil {
+				return ver, ErrInvalidColumnState.Gen("column %s doesn't exist", name)
+			}
+			if sub.Type == model.ActionAddColumn {
+				col.State = model.StatePublic
+				addedCols = append(addedCols, col)
+			} else {
+				droppedCols = append(droppedCols, col)
+			}
+		case model.ActionAddIndex, model.ActionDropIndex:
+			idx := findIndexByName(name.L, tblInfo.Indices)
+			if idx == nil {
+				return ver, ErrInvalidIndexState.Gen("index %s doesn't exist", name)
+			}
+			if sub.Type == model.ActionAddIndex {
+				idx.State = model.StatePublic
+				addedIndices = append(addedIndices, idx)
+			} else {
+				droppedIndices = append(droppedIndices, idx)
+			}
+		}
+	}
+	resetColumnOffsets(tblInfo)
+	// Set column index flag.
+	for _, idx := range addedIndices {
+		addIndexColumnFlag(tblInfo, idx)
+	}
+
+	// public -> write only
+	for _, col := range droppedCols {
+		col.State = model.StateWriteOnly
+	}
+	for _, idx := range droppedIndices {
+		idx.State = model.StateWriteOnly
+	}
+	// The dropped columns' offsets are moved to the last.
+	resetColumnOffsets(tblInfo)
+	for _, sub := range info.SubJobs {
+		if sub.Type == model.ActionAddColumn || sub.Type == model.ActionAddIndex {
+			sub.SchemaState = model.StatePublic
+		} else {
+			sub.SchemaState = model.StateWriteOnly
+		}
+	}
+	info.Revertible = false
+	hasDropped := len(droppedCols)+len(droppedIndices) > 0
+	job.SchemaState = model.StatePublic
+	if hasDropped {
+		job.SchemaState = model.StateWriteOnly
+	}
+	ver, err = updateVersionAndTableInfo(t, job, tblInfo)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	for _, col := range addedCols {
+		d.asyncNotifyEvent(&Event{Tp: model.ActionAddColumn, TableInfo: tblInfo, ColumnInfo: col})
+	}
+	if !hasDropped {
+		// Finish this job.
+		job.State = model.JobStateDone
+		job.BinlogInfo.AddTableInfo(ver, tblInfo)
+		job.Args = []interface{}{[]int64{}}
+	}
+	return ver, nil
+}
+
+// dropMultiSchemaChangeElements makes the dropped columns and indices go through the rest of the schema states
+// together, then removes them.
+func (d *ddl) dropMultiSchemaChangeElements(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo) (
+	ver int64, err error) {
+	var nextState model.SchemaState
+	switch job.SchemaState {
+	case model.StateWriteOnly:
+		// write only -> delete only
+		nextState = model.StateDeleteOnly
+	case model.StateDeleteOnly:
+		// delete only -> reorganization
+		nextState = model.StateDeleteReorganization
+	case model.StateDeleteReorganization:
+		// reorganization -> absent
+		nextState = model.StateNone
+	default:
+		return ver, ErrInvalidTableState.Gen("invalid multi-schema change state %v", job.SchemaState)
+	}
+
+	droppedCols := make(map[string]*model.ColumnInfo)
+	droppedIndices := make(map[string]*model.IndexInfo)
+	for _, sub := range job.MultiSchemaInfo.SubJobs {
+		if sub.Type != model.ActionDropColumn && sub.Type != model.ActionDropIndex {
+			continue
+		}
+		name, err := getSubJobElementName(sub)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+		if sub.Type == model.ActionDropColumn {
+			col := findCol(tblInfo.Columns, name.L)
+			if col == nil {
+				return ver, ErrInvalidColumnState.Gen("column %s doesn't exist", name)
+			}
+			col.State = nextState
+			droppedCols[name.L] = col
+		} else {
+			idx := findIndexByName(name.L, tblInfo.Indices)
+			if idx == nil {
+				return ver, ErrInvalidIndexState.Gen("index %s doesn't exist", name)
+			}
+			idx.State = nextState
+			droppedIndices[name.L] = idx
+		}
+		sub.SchemaState = nextState
+	}
+	job.SchemaState = nextState
+	if nextState != model.StateNone {
+		ver, err = updateVersionAndTableInfo(t, job, tblInfo)
+		return ver, errors.Trace(err)
+	}
+
+	// All reorganization jobs are done, drop these columns and indices.
+	newColumns := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		if _, ok := droppedCols[col.Name.L]; !ok {
+			newColumns = append(newColumns, col)
+		}
+	}
+	tblInfo.Columns = newColumns
+	// The offsets of the index columns are updated before the indices are removed, the flags are set by the offsets.
+	resetColumnOffsets(tblInfo)
+	newIndices := make([]*model.IndexInfo, 0, len(tblInfo.Indices))
+	for _, idx := range tblInfo.Indices {
+		if _, ok := droppedIndices[idx.Name.L]; !ok {
+			newIndices = append(newIndices, idx)
+		}
+	}
+	tblInfo.Indices = newIndices
+	indexIDs := make([]int64, 0, len(droppedIndices))
+	for _, idx := range droppedIndices {
+		// Set column index flag, unless the first column of the index is dropped too.
+		if _, ok := droppedCols[idx.Columns[0].Name.L]; !ok {
+			dropIndexColumnFlag(tblInfo, idx)
+		}
+		indexIDs = append(indexIDs, idx.ID)
+	}
+	ver, err = updateVersionAndTableInfo(t, job, tblInfo)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	// Finish this job.
+	job.State = model.JobStateDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	job.Args = []interface{}{indexIDs}
+	for _, col := range droppedCols {
+		d.asyncNotifyEvent(&Event{Tp: model.ActionDropColumn, TableInfo: tblInfo, ColumnInfo: col})
+	}
+	for _, idx := range droppedIndices {
+		d.asyncNotifyEvent(&Event{Tp: model.ActionDropIndex, TableInfo: tblInfo, IndexInfo: idx})
+	}
+	return ver, nil
+}
+
+// convertMultiSchemaChange2RollbackJob converts the job to a rolling back job, the added columns and indices are
+// removed like dropping them, so the next state is delete only.
+func (d *ddl) convertMultiSchemaChange2RollbackJob(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, err error) (
+	ver int64, _ error) {
+	if job.SchemaState == model.StateNone {
+		// Nothing has been changed, cancel the job directly.
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+
+	job.State = model.JobStateRollingback
+	if err1 := setAddedElementsState(job, tblInfo, model.StateDeleteOnly); err1 != nil {
+		return ver, errors.Trace(err1)
+	}
+	job.SchemaState = model.StateDeleteOnly
+	ver, err1 := updateVersionAndTableInfo(t, job, tblInfo)
+	if err1 != nil {
+		return ver, errors.Trace(err1)
+	}
+	return ver, errors.Trace(err)
+}
+
+// setAddedElementsState sets the state of the columns and indices which have been added by the job.
+func setAddedElementsState(job *model.Job, tblInfo *model.TableInfo, state model.SchemaState) error {
+	for _, sub := range job.MultiSchemaInfo.SubJobs {
+		if sub.SchemaState == model.StateNone {
+			// The column or index to add hasn't been created, or it's one to drop.
+			continue
+		}
+		if sub.Type != model.ActionAddColumn && sub.Type != model.ActionAddIndex {
+			continue
+		}
+		name, err := getSubJobElementName(sub)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if sub.Type == model.ActionAddColumn {
+			if col := findCol(tblInfo.Columns, name.L); col != nil {
+				col.State = state
+			}
+		} else if idx := findIndexByName(name.L, tblInfo.Indices); idx != nil {
+			idx.State = state
+		}
+		sub.SchemaState = state
+	}
+	return nil
+}
+
+// rollbackMultiSchemaChange removes the added columns and indices together.
+func (d *ddl) rollbackMultiSchemaChange(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo) (
+	ver int64, err error) {
+	switch job.SchemaState {
+	case model.StateDeleteOnly:
+		// delete only -> reorganization
+		if err = setAddedElementsState(job, tblInfo, model.StateDeleteReorganization); err != nil {
+			return ver, errors.Trace(err)
+		}
+		job.SchemaState = model.StateDeleteReorganization
+		ver, err = updateVersionAndTableInfo(t, job, tblInfo)
+		return ver, errors.Trace(err)
+	case model.StateDeleteReorganization:
+	default:
+		return ver, ErrInvalidTableState.Gen("invalid multi-schema change state %v", job.SchemaState)
+	}
+
+	// reorganization -> absent
+	newColumns := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		if col.State != model.StateDeleteReorganization {
+			newColumns = append(newColumns, col)
+		}
+	}
+	tblInfo.Columns = newColumns
+	newIndices := make([]*model.IndexInfo, 0, len(tblInfo.Indices))
+	indexIDs := make([]int64, 0, len(tblInfo.Indices))
+	for _, idx := range tblInfo.Indices {
+		if idx.State != model.StateDeleteReorganization {
+			newIndices = append(newIndices, idx)
+		} else {
+			indexIDs = append(indexIDs, idx.ID)
+		}
+	}
+	tblInfo.Indices = newIndices
+	for _, sub := range job.MultiSchemaInfo.SubJobs {
+		sub.SchemaState = model.StateNone
+	}
+	job.SchemaState = model.StateNone
+	ver, err = updateVersionAndTableInfo(t, job, tblInfo)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	// Finish this job.
+	job.State = model.JobStateRollbackDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	job.Args = []interface{}{indexIDs}
+	return ver, nil
+}
@@ -0,0 +1,483 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// maxPartitions is the max number of partitions of a table.
+const maxPartitions = 1024
+
+// buildTablePartitionInfo builds the partition info of the table from the PARTITION BY clause.
+func (d *ddl) buildTablePartitionInfo(ctx context.Context, s *ast.PartitionOptions, tbInfo *model.TableInfo) error {
+	if s.Tp == model.PartitionTypeKey {
+		ctx.GetSessionVars().StmtCtx.AppendWarning(errUnsupportedPartitionType.GenByArgs(s.Tp))
+		return nil
+	}
+	pi := &model.PartitionInfo{
+		Type: s.Tp,
+		Expr: s.Expr.Text(),
+		Num:  s.Num,
+	}
+	if err := checkPartitionFuncType(ctx, s.Expr, tbInfo); err != nil {
+		return errors.Trace(err)
+	}
+
+	switch s.Tp {
+	case model.PartitionTypeRange:
+		if len(s.Definitions) == 0 {
+			return ErrPartitionsMustBeDefined.GenByArgs("RANGE")
+		}
+		if s.Num != 0 && s.Num != uint64(len(s.Definitions)) {
+			return ErrPartitionWrongNoPart
+		}
+		defs, err := d.buildRangePartitionDefinitions(ctx, s.Definitions)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		pi.Definitions = defs
+	case model.PartitionTypeHash:
+		if len(s.Definitions) > 0 {
+			if s.Num != 0 && s.Num != uint64(len(s.Definitions)) {
+				return ErrPartitionWrongNoPart
+			}
+			for _, def := range s.Definitions {
+				if def.MaxValue || len(def.LessThan) > 0 {
+					return ErrPartitionWrongValues.GenByArgs("RANGE", "LESS THAN")
+				}
+				pi.Definitions = append(pi.Definitions, model.PartitionDefinition{Name: def.Name})
+			}
+		} else {
+			if pi.Num == 0 {
+				pi.Num = 1
+			}
+			if pi.Num > maxPartitions {
+				return ErrTooManyPartitions
+			}
+			for i := uint64(0); i < pi.Num; i++ {
+				pi.Definitions = append(pi.Definitions, model.PartitionDefinition{Name: model.NewCIStr(fmt.Sprintf("p%d", i))})
+			}
+		}
+	}
+	pi.Num = uint64(len(pi.Definitions))
+	if pi.Num > maxPartitions {
+		return ErrTooManyPartitions
+	}
+	if err := checkPartitionNamesUnique(nil, pi.Definitions); err != nil {
+		return errors.Trace(err)
+	}
+	if err := checkPartitionKeysConstraint(s.Expr, tbInfo); err != nil {
+		return errors.Trace(err)
+	}
+	for i := range pi.Definitions {
+		id, err := d.genGlobalID()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		pi.Definitions[i].ID = id
+	}
+	tbInfo.Partition = pi
+	return nil
+}
+
+// checkPartitionFuncType checks the partition function refers to the columns of the table and returns an integer.
+func checkPartitionFuncType(ctx context.Context, expr ast.ExprNode, tbInfo *model.TableInfo) error {
+	schema := expression.NewSchema(expression.ColumnInfos2Columns(tbInfo.Name, tbInfo.Columns)...)
+	e, err := expression.RewriteAstExpr(expr, schema, ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if e.GetType().EvalType() != types.ETInt {
+		return ErrPartitionFuncNotAllowed.GenByArgs("PARTITION")
+	}
+	return nil
+}
+
+// buildRangePartitionDefinitions evaluates the VALUES LESS THAN values, the values must be strictly increasing integers.
+func (d *ddl) buildRangePartitionDefinitions(ctx context.Context, defs []*ast.PartitionDefinition) ([]model.PartitionDefinition, error) {
+	result := make([]model.PartitionDefinition, 0, len(defs))
+	for i, def := range defs {
+		partDef := model.PartitionDefinition{Name: def.Name}
+		if def.MaxValue {
+			if i != len(defs)-1 {
+				return nil, ErrPartitionMaxvalue
+			}
+			partDef.LessThan = []string{tables.PartitionMaxValue}
+			result = append(result, partDef)
+			continue
+		}
+		if len(def.LessThan) == 0 {
+			return nil, ErrPartitionRequiresValues.GenByArgs("RANGE", "LESS THAN")
+		}
+		if len(def.LessThan) != 1 {
+			return nil, ErrPartitionColumnList
+		}
+		v, err := expression.EvalAstExpr(def.LessThan[0], ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var bound string
+		switch v.Kind() {
+		case types.KindInt64:
+			bound = strconv.FormatInt(v.GetInt64(), 10)
+		case types.KindUint64:
+			bound = strconv.FormatUint(v.GetUint64(), 10)
+		default:
+			return nil, ErrValuesIsNotIntType.GenByArgs(def.Name.O)
+		}
+		partDef.LessThan = []string{bound}
+		result = append(result, partDef)
+	}
+	if err := checkRangePartitionsIncreasing(result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return result, nil
+}
+
+// checkRangePartitionsIncreasing checks the upper bounds of range partitions are strictly increasing.
+func checkRangePartitionsIncreasing(defs []model.PartitionDefinition) error {
+	for i := 1; i < len(defs); i++ {
+		prev := defs[i-1].LessThan[0]
+		if prev == tables.PartitionMaxValue {
+			return ErrPartitionMaxvalue
+		}
+		cur := defs[i].LessThan[0]
+		if cur == tables.PartitionMaxValue {
+			continue
+		}
+		prevValue, err := strconv.ParseInt(prev, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		curValue, err := strconv.ParseInt(cur, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if curValue <= prevValue {
+			return ErrRangeNotIncreasing
+		}
+	}
+	return nil
+}
+
+// checkPartitionNamesUnique checks the names of newDefs are unique, and don't exist in oldDefs.
+func checkPartitionNamesUnique(oldDefs, newDefs []model.PartitionDefinition) error {
+	names := make(map[string]struct{}, len(oldDefs)+len(newDefs))
+	for _, def := range oldDefs {
+		names[def.Name.L] = struct{}{}
+	}
+	for _, def := range newDefs {
+		if _, ok := names[def.Name.L]; ok {
+			return ErrSameNamePartition.GenByArgs(def.Name.O)
+		}
+		names[def.Name.L] = struct{}{}
+	}
+	return nil
+}
+
+// checkPartitionKeysConstraint checks every unique key includes all the columns in the partition function,
+// so that the uniqueness can be guaranteed in each partition.
+func checkPartitionKeysConstraint(expr ast.ExprNode, tbInfo *model.TableInfo) error {
+	partCols := findColumnNamesInExpr(expr)
+	if tbInfo.PKIsHandle {
+		pk := tbInfo.GetPkColInfo()
+		for _, col := range partCols {
+			if col.Name.L != pk.Name.L {
+				return ErrUniqueKeyNeedAllFieldsInPf.GenByArgs("PRIMARY KEY")
+			}
+		}
+	}
+	for _, idx := range tbInfo.Indices {
+		if !idx.Unique {
+			continue
+		}
+		for _, col := range partCols {
+			if findIndexColumn(idx, col.Name.L) == nil {
+				if idx.Primary {
+					return ErrUniqueKeyNeedAllFieldsInPf.GenByArgs("PRIMARY KEY")
+				}
+				return ErrUniqueKeyNeedAllFieldsInPf.GenByArgs("UNIQUE INDEX")
+			}
+		}
+	}
+	return nil
+}
+
+func findIndexColumn(idx *model.IndexInfo, name string) *model.IndexColumn {
+	for _, col := range idx.Columns {
+		if col.Name.L == name {
+			return col
+		}
+	}
+	return nil
+}
+
+// getPhysicalTables returns the partitions of a partitioned table, or the table itself if it isn't partitioned.
+func getPhysicalTables(t table.Table) []table.PhysicalTable {
+	pt, ok := t.(table.PartitionedTable)
+	if !ok {
+		return []table.PhysicalTable{t.(table.PhysicalTable)}
+	}
+	defs := t.Meta().Partition.Definitions
+	tbls := make([]table.PhysicalTable, 0, len(defs))
+	for _, def := range defs {
+		tbls = append(tbls, pt.GetPartition(def.ID))
+	}
+	return tbls
+}
+
+// checkPartitionUniqueIndex checks the unique index to add includes all the columns in the partition function.
+func checkPartitionUniqueIndex(tblInfo *model.TableInfo, idxColNames []*ast.IndexColName) error {
+	if tblInfo.Partition == nil {
+		return nil
+	}
+	expr, err := parsePartitionExpr(tblInfo.Partition.Expr)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, col := range findColumnNamesInExpr(expr) {
+		found := false
+		for _, idxCol := range idxColNames {
+			if idxCol.Column.Name.L == col.Name.L {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrUniqueKeyNeedAllFieldsInPf.GenByArgs("UNIQUE INDEX")
+		}
+	}
+	return nil
+}
+
+// getPartitionIDs returns the physical IDs of the partitions, it's empty if the table isn't partitioned.
+func getPartitionIDs(tblInfo *model.TableInfo) []int64 {
+	if tblInfo.Partition == nil {
+		return []int64{}
+	}
+	ids := make([]int64, 0, len(tblInfo.Partition.Definitions))
+	for _, def := range tblInfo.Partition.Definitions {
+		ids = append(ids, def.ID)
+	}
+	return ids
+}
+
+// getPartitionDefinitionsByNames returns the partition definitions with the names,
+// it returns ErrDropPartitionNonExistent if any partition doesn't exist.
+func getPartitionDefinitionsByNames(pi *model.PartitionInfo, names []model.CIStr, op string) ([]model.PartitionDefinition, error) {
+	defs := make([]model.PartitionDefinition, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, def := range pi.Definitions {
+			if def.Name.L == name.L {
+				defs = append(defs, def)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrDropPartitionNonExistent.GenByArgs(op)
+		}
+	}
+	return defs, nil
+}
+
+func (d *ddl) onAddTablePartition(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	var defs []model.PartitionDefinition
+	if err := job.DecodeArgs(&defs); err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	pi := tblInfo.Partition
+	if pi == nil || pi.Type != model.PartitionTypeRange {
+		job.State = model.JobStateCancelled
+		return ver, ErrPartitionMgmtOnNonpartitioned
+	}
+	if err = checkPartitionNamesUnique(pi.Definitions, defs); err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+	newDefs := append(append([]model.PartitionDefinition{}, pi.Definitions...), defs...)
+	if err = checkRangePartitionsIncreasing(newDefs); err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+	if len(newDefs) > maxPartitions {
+		job.State = model.JobStateCancelled
+		return ver, ErrTooManyPartitions
+	}
+	pi.Definitions = newDefs
+	pi.Num = uint64(len(newDefs))
+
+	ver, err = updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	if err = t.UpdateTable(job.SchemaID, tblInfo); err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+	// Finish this job.
+	job.State = model.JobStateDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return ver, nil
+}
+
+// onDropTablePartition removes the partitions from the table meta, the data is removed by the delete-range worker.
+func (d *ddl) onDropTablePartition(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	var names []model.CIStr
+	if err := job.DecodeArgs(&names); err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	pi := tblInfo.Partition
+	if pi == nil {
+		job.State = model.JobStateCancelled
+		return ver, ErrPartitionMgmtOnNonpartitioned
+	}
+	dropped, err := getPartitionDefinitionsByNames(pi, names, "DROP")
+	if err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+	physicalIDs := make([]int64, 0, len(dropped))
+	newDefs := make([]model.PartitionDefinition, 0, len(pi.Definitions))
+	for _, def := range pi.Definitions {
+		if findPartitionDefinition(dropped, def.ID) == nil {
+			newDefs = append(newDefs, def)
+		} else {
+			physicalIDs = append(physicalIDs, def.ID)
+		}
+	}
+	if len(newDefs) == 0 {
+		job.State = model.JobStateCancelled
+		return ver, ErrDropLastPartition
+	}
+	pi.Definitions = newDefs
+	pi.Num = uint64(len(newDefs))
+
+	ver, err = updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	if err = t.UpdateTable(job.SchemaID, tblInfo); err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+	// Finish this job.
+	job.State = model.JobStateDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	// A background job will be created to delete old partition data.
+	job.Args = []interface{}{physicalIDs}
+	return ver, nil
+}
+
+// onTruncateTablePartition assigns new IDs to the partitions, as the old data is encoded with the old IDs,
+// it can not be accessed any more, and it's removed by the delete-range worker.
+func (d *ddl) onTruncateTablePartition(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	var oldIDs, newIDs []int64
+	if err := job.DecodeArgs(&oldIDs, &newIDs); err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	pi := tblInfo.Partition
+	if pi == nil {
+		job.State = model.JobStateCancelled
+		return ver, ErrPartitionMgmtOnNonpartitioned
+	}
+	for i, oldID := range oldIDs {
+		def := findPartitionDefinition(pi.Definitions, oldID)
+		if def == nil {
+			job.State = model.JobStateCancelled
+			return ver, ErrDropPartitionNonExistent.GenByArgs("TRUNCATE")
+		}
+		def.ID = newIDs[i]
+	}
+
+	ver, err = updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	if err = t.UpdateTable(job.SchemaID, tblInfo); err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+	// Finish this job.
+	job.State = model.JobStateDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	// A background job will be created to delete old partition data.
+	job.Args = []interface{}{oldIDs}
+	return ver, nil
+}
+
+func findPartitionDefinition(defs []model.PartitionDefinition, physicalID int64) *model.PartitionDefinition {
+	for i := range defs {
+		if defs[i].ID == physicalID {
+			return &defs[i]
+		}
+	}
+	return nil
+}
+
+// checkPartitionedTableColumn is used by DDL on columns, the columns in the partition function can't be changed.
+func checkPartitionedTableColumn(tblInfo *model.TableInfo, colName model.CIStr, op string) error {
+	if tblInfo.Partition == nil {
+		return nil
+	}
+	expr, err := parsePartitionExpr(tblInfo.Partition.Expr)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, col := range findColumnNamesInExpr(expr) {
+		if col.Name.L == colName.L {
+			return errUnsupportedPartitionedTable.GenByArgs(fmt.Sprintf("%s column %s in the partition function", op, colName))
+		}
+	}
+	return nil
+}
+
+// parsePartitionExpr parses the partition expression string.
+func parsePartitionExpr(expr string) (ast.ExprNode, error) {
+	charset, collation := getDefaultCharsetAndCollate()
+	stmts, err := parser.New().Parse(fmt.Sprintf("select %s", expr), charset, collation)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return stmts[0].(*ast.SelectStmt).Fields.Fields[0].Expr, nil
+}
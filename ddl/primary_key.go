@@ -0,0 +1,751 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"math"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// hasPrimaryKey checks whether the table has the primary key.
+func hasPrimaryKey(tblInfo *model.TableInfo) bool {
+	return tblInfo.PKIsHandle || findPrimaryIndex(tblInfo) != nil
+}
+
+func findPrimaryIndex(tblInfo *model.TableInfo) *model.IndexInfo {
+	for _, idx := range tblInfo.Indices {
+		if idx.Primary {
+			return idx
+		}
+	}
+	return nil
+}
+
+// isHandlePrimaryKey checks whether the primary key can be the handle, it's the same as creating the table.
+func isHandlePrimaryKey(tblInfo *model.TableInfo, indexInfo *model.IndexInfo) bool {
+	if len(indexInfo.Columns) != 1 {
+		return false
+	}
+	switch tblInfo.Columns[indexInfo.Columns[0].Offset].Tp {
+	case mysql.TypeLong, mysql.TypeLonglong,
+		mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24:
+		return true
+	}
+	return false
+}
+
+func setPrimaryKeyColumnFlag(tblInfo *model.TableInfo, indexInfo *model.IndexInfo) {
+	for _, col := range indexInfo.Columns {
+		// Primary key can not be NULL.
+		tblInfo.Columns[col.Offset].Flag |= mysql.PriKeyFlag | mysql.NotNullFlag
+	}
+}
+
+func removeIndexInfo(tblInfo *model.TableInfo, indexInfo *model.IndexInfo) {
+	newIndices := make([]*model.IndexInfo, 0, len(tblInfo.Indices))
+	for _, idx := range tblInfo.Indices {
+		if idx.Name.L != indexInfo.Name.L {
+			newIndices = append(newIndices, idx)
+		}
+	}
+	tblInfo.Indices = newIndices
+}
+
+// onAddPrimaryKey adds the primary key.
+// The primary index is added like a unique index at first, the values of it can't be null.
+// If the primary key can be the handle, the rows are rebuilt into a new table with the primary key as handle,
+// and the new table replaces the origin table without the primary index, see onRebuildTable.
+// When the job is finished, the IDs of the tables and the ID of the index to be deleted are saved in the job arguments.
+func (d *ddl) onAddPrimaryKey(t *meta.Meta, job *model.Job) (ver int64, err error) {
+	schemaID := job.SchemaID
+	tblInfo, err := getTableInfo(t, job, schemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	var (
+		indexName   model.CIStr
+		idxColNames []*ast.IndexColName
+		indexOption *ast.IndexOption
+		newTableID  int64
+	)
+	err = job.DecodeArgs(&indexName, &idxColNames, &indexOption, &newTableID)
+	if err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+
+	if tblInfo.RebuildInfo != nil {
+		return d.onRebuildTable(t, job, tblInfo)
+	}
+	indexInfo := findPrimaryIndex(tblInfo)
+	if job.IsRollingback() {
+		return d.dropPrimaryIndex(t, job, tblInfo, indexInfo, []int64{newTableID})
+	}
+	if tblInfo.PKIsHandle || (indexInfo != nil && indexInfo.State == model.StatePublic) {
+		job.State = model.JobStateCancelled
+		return ver, infoschema.ErrMultiplePriKey
+	}
+
+	if indexInfo == nil {
+		indexInfo, err = buildIndexInfo(tblInfo, indexName, idxColNames, model.StateNone)
+		if err != nil {
+			job.State = model.JobStateCancelled
+			return ver, errors.Trace(err)
+		}
+		if indexOption != nil {
+			indexInfo.Comment = indexOption.Comment
+			if indexOption.Tp == model.IndexTypeInvalid {
+				// Use btree as default index type.
+				indexInfo.Tp = model.IndexTypeBtree
+			} else {
+				indexInfo.Tp = indexOption.Tp
+			}
+		} else {
+			// Use btree as default index type.
+			indexInfo.Tp = model.IndexTypeBtree
+		}
+		indexInfo.Primary = true
+		indexInfo.Unique = true
+		indexInfo.ID = allocateIndexID(tblInfo)
+		tblInfo.Indices = append(tblInfo.Indices, indexInfo)
+	}
+	// If the job is cancelled before backfilling the index, drop the primary index.
+	// When backfilling, the reorganization is notified to be cancelled, see runDDLJob.
+	if job.IsCancelling() && (indexInfo.State != model.StateWriteReorganization || job.SnapshotVer == 0) {
+		return d.convertAddPrimaryKey2RollbackJob(t, job, tblInfo, indexInfo, errCancelledDDLJob)
+	}
+
+	originalState := indexInfo.State
+	switch indexInfo.State {
+	case model.StateNone:
+		// none -> delete only
+		job.SchemaState = model.StateDeleteOnly
+		indexInfo.State = model.StateDeleteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateDeleteOnly:
+		// delete only -> write only
+		job.SchemaState = model.StateWriteOnly
+		indexInfo.State = model.StateWriteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateWriteOnly:
+		// write only -> reorganization
+		job.SchemaState = model.StateWriteReorganization
+		indexInfo.State = model.StateWriteReorganization
+		// Initialize SnapshotVer to 0 for later reorganization check.
+		job.SnapshotVer = 0
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateWriteReorganization:
+		// reorganization -> public
+		var tbl table.Table
+		tbl, err = d.getTable(schemaID, tblInfo)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+
+		var reorgInfo *reorgInfo
+		reorgInfo, err = d.getReorgInfo(t, job)
+		if err != nil || reorgInfo.first {
+			if err == nil {
+				// Get the first handle of this table.
+				err = iterateSnapshotRows(d.store, tbl, reorgInfo.SnapshotVer, math.MinInt64,
+					func(h int64, rowKey kv.Key, rawRecord []byte) (bool, error) {
+						reorgInfo.Handle = h
+						return false, nil
+					})
+				return ver, errors.Trace(t.UpdateDDLReorgHandle(reorgInfo.Job, reorgInfo.Handle))
+			}
+			// If we run reorg firstly, we should update the job snapshot version
+			// and then run the reorg next time.
+			return ver, errors.Trace(err)
+		}
+
+		err = d.runReorgJob(job, func() error {
+			return d.addTableIndex(tbl, indexInfo, reorgInfo, job)
+		})
+		if err != nil {
+			if errWaitReorgTimeout.Equal(err) {
+				// if timeout, we should return, check for the owner and re-wait job done.
+				return ver, nil
+			}
+			if kv.ErrKeyExists.Equal(err) || errInvalidUseOfNull.Equal(err) || errCancelledDDLJob.Equal(err) {
+				log.Warnf("[ddl] run DDL job %v err %v, convert job to rollback job", job, err)
+				ver, err = d.convertAddPrimaryKey2RollbackJob(t, job, tblInfo, indexInfo, err)
+			}
+			// Clean up the channel of notifyCancelReorgJob. Make sure it can't affect other jobs.
+			cleanNotify(d.notifyCancelReorgJob)
+			return ver, errors.Trace(err)
+		}
+		// Clean up the channel of notifyCancelReorgJob. Make sure it can't affect other jobs.
+		cleanNotify(d.notifyCancelReorgJob)
+
+		indexInfo.State = model.StatePublic
+		if isHandlePrimaryKey(tblInfo, indexInfo) {
+			// The rows are rebuilt with the primary key as handle.
+			tblInfo.RebuildInfo = &model.TableRebuildInfo{
+				NewTableID: newTableID,
+				PKIsHandle: true,
+				State:      model.StateDeleteOnly,
+			}
+			job.SchemaState = model.StateDeleteOnly
+			ver, err = updateTableInfo(t, job, tblInfo, originalState)
+			return ver, errors.Trace(err)
+		}
+		setPrimaryKeyColumnFlag(tblInfo, indexInfo)
+
+		job.SchemaState = model.StatePublic
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+		// Finish this job.
+		job.State = model.JobStateDone
+		job.BinlogInfo.AddTableInfo(ver, tblInfo)
+		job.Args = []interface{}{[]int64{}, int64(0)}
+	default:
+		err = ErrInvalidIndexState.Gen("invalid index state %v", indexInfo.State)
+	}
+
+	return ver, errors.Trace(err)
+}
+
+func (d *ddl) convertAddPrimaryKey2RollbackJob(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo,
+	indexInfo *model.IndexInfo, err error) (ver int64, _ error) {
+	job.State = model.JobStateRollingback
+	// The primary index is dropped like dropping an index, the next state is delete only.
+	originalState := indexInfo.State
+	indexInfo.State = model.StateDeleteOnly
+	job.SchemaState = model.StateDeleteOnly
+	ver, err1 := updateTableInfo(t, job, tblInfo, originalState)
+	if err1 != nil {
+		return ver, errors.Trace(err1)
+	}
+
+	if kv.ErrKeyExists.Equal(err) {
+		return ver, kv.ErrKeyExists.Gen("Duplicate for key %s", indexInfo.Name.O)
+	}
+	return ver, errors.Trace(err)
+}
+
+// onDropPrimaryKey drops the primary key.
+// If the primary key is the handle, the rows are rebuilt into a new table with the same handles,
+// otherwise the primary index is dropped like dropping an index.
+func (d *ddl) onDropPrimaryKey(t *meta.Meta, job *model.Job) (ver int64, err error) {
+	schemaID := job.SchemaID
+	tblInfo, err := getTableInfo(t, job, schemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	var newTableID int64
+	if err = job.DecodeArgs(&newTableID); err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+
+	if tblInfo.RebuildInfo != nil {
+		return d.onRebuildTable(t, job, tblInfo)
+	}
+	if tblInfo.PKIsHandle {
+		// The rows are rebuilt without the primary key as handle.
+		tblInfo.RebuildInfo = &model.TableRebuildInfo{
+			NewTableID: newTableID,
+			PKIsHandle: false,
+			State:      model.StateDeleteOnly,
+		}
+		originalState := job.SchemaState
+		job.SchemaState = model.StateDeleteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+		return ver, errors.Trace(err)
+	}
+
+	indexInfo := findPrimaryIndex(tblInfo)
+	if indexInfo == nil {
+		job.State = model.JobStateCancelled
+		return ver, ErrCantDropFieldOrKey.Gen("index %s doesn't exist", mysql.PrimaryKeyName)
+	}
+	if job.IsCancelling() {
+		if indexInfo.State != model.StateWriteOnly {
+			// The index entries may have been removed, the job can't be cancelled.
+			job.State = model.JobStateRunning
+			return d.dropPrimaryIndex(t, job, tblInfo, indexInfo, []int64{})
+		}
+		// The index is still written in write only state, make it public again.
+		originalState := indexInfo.State
+		indexInfo.State = model.StatePublic
+		job.SchemaState = model.StatePublic
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+		job.State = model.JobStateRollbackDone
+		job.BinlogInfo.AddTableInfo(ver, tblInfo)
+		job.Args = []interface{}{[]int64{}, int64(0)}
+		return ver, errCancelledDDLJob
+	}
+	return d.dropPrimaryIndex(t, job, tblInfo, indexInfo, []int64{})
+}
+
+// dropPrimaryIndex drops the primary index when dropping the primary key or rolling back adding the primary key.
+// The tableIDs are the IDs of the tables to be deleted when the job is finished.
+func (d *ddl) dropPrimaryIndex(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, indexInfo *model.IndexInfo,
+	tableIDs []int64) (ver int64, err error) {
+	if indexInfo == nil {
+		// The primary index has not been added.
+		job.State = model.JobStateRollbackDone
+		job.Args = []interface{}{tableIDs, int64(0)}
+		return ver, nil
+	}
+
+	originalState := indexInfo.State
+	switch indexInfo.State {
+	case model.StatePublic:
+		// public -> write only
+		job.SchemaState = model.StateWriteOnly
+		indexInfo.State = model.StateWriteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateWriteOnly:
+		// write only -> delete only
+		job.SchemaState = model.StateDeleteOnly
+		indexInfo.State = model.StateDeleteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateDeleteOnly:
+		// delete only -> reorganization
+		job.SchemaState = model.StateDeleteReorganization
+		indexInfo.State = model.StateDeleteReorganization
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateDeleteReorganization:
+		// reorganization -> absent
+		removeIndexInfo(tblInfo, indexInfo)
+		for _, col := range indexInfo.Columns {
+			tblInfo.Columns[col.Offset].Flag &^= mysql.PriKeyFlag
+		}
+
+		job.SchemaState = model.StateNone
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+
+		// Finish this job.
+		if job.IsRollingback() {
+			job.State = model.JobStateRollbackDone
+		} else {
+			job.State = model.JobStateDone
+		}
+		job.BinlogInfo.AddTableInfo(ver, tblInfo)
+		job.Args = []interface{}{tableIDs, indexInfo.ID}
+	default:
+		err = ErrInvalidIndexState.Gen("invalid index state %v", indexInfo.State)
+	}
+	return ver, errors.Trace(err)
+}
+
+// onRebuildTable rebuilds the rows of the table into a new table with the new handles.
+// The rebuilt table goes through delete only, write only and write reorganization states like an index,
+// the rows and index entries are written into it by DML, see tables.Table.addRebuildingRecord.
+// In write reorganization state, the rows of the snapshot are backfilled into it,
+// then it replaces the origin table like truncating the table.
+func (d *ddl) onRebuildTable(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo) (ver int64, err error) {
+	if job.IsRollingback() {
+		return d.rollbackRebuildTable(t, job, tblInfo)
+	}
+	info := tblInfo.RebuildInfo
+	// If the job is cancelled before backfilling the rows, remove the rebuilt table.
+	// When backfilling, the reorganization is notified to be cancelled, see runDDLJob.
+	if job.IsCancelling() && (info.State != model.StateWriteReorganization || job.SnapshotVer == 0) {
+		return d.convertRebuildTable2RollbackJob(t, job, tblInfo, errCancelledDDLJob)
+	}
+
+	originalState := info.State
+	switch info.State {
+	case model.StateDeleteOnly:
+		// delete only -> write only
+		job.SchemaState = model.StateWriteOnly
+		info.State = model.StateWriteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateWriteOnly:
+		// write only -> reorganization
+		job.SchemaState = model.StateWriteReorganization
+		info.State = model.StateWriteReorganization
+		// Initialize SnapshotVer to 0 for later reorganization check.
+		job.SnapshotVer = 0
+		// The row count is the number of the rebuilt rows.
+		job.SetRowCount(0)
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateWriteReorganization:
+		// reorganization -> public
+		var tbl, rebuiltTbl table.Table
+		tbl, err = d.getTable(job.SchemaID, tblInfo)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+		rebuiltTbl, err = d.getTable(job.SchemaID, tblInfo.RebuildingTableInfo())
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+
+		var reorgInfo *reorgInfo
+		reorgInfo, err = d.getReorgInfo(t, job)
+		if err != nil || reorgInfo.first {
+			if err == nil {
+				// Get the first handle of this table.
+				err = iterateSnapshotRows(d.store, tbl, reorgInfo.SnapshotVer, math.MinInt64,
+					func(h int64, rowKey kv.Key, rawRecord []byte) (bool, error) {
+						reorgInfo.Handle = h
+						return false, nil
+					})
+				return ver, errors.Trace(t.UpdateDDLReorgHandle(reorgInfo.Job, reorgInfo.Handle))
+			}
+			// If we run reorg firstly, we should update the job snapshot version
+			// and then run the reorg next time.
+			return ver, errors.Trace(err)
+		}
+
+		err = d.runReorgJob(job, func() error {
+			return d.rebuildTableRecords(tbl, rebuiltTbl, reorgInfo, job)
+		})
+		if err != nil {
+			if errWaitReorgTimeout.Equal(err) {
+				// if timeout, we should return, check for the owner and re-wait job done.
+				return ver, nil
+			}
+			if errCancelledDDLJob.Equal(err) {
+				log.Warnf("[ddl] run DDL job %v err %v, convert job to rollback job", job, err)
+				ver, err = d.convertRebuildTable2RollbackJob(t, job, tblInfo, err)
+			}
+			// Clean up the channel of notifyCancelReorgJob. Make sure it can't affect other jobs.
+			cleanNotify(d.notifyCancelReorgJob)
+			return ver, errors.Trace(err)
+		}
+		// Clean up the channel of notifyCancelReorgJob. Make sure it can't affect other jobs.
+		cleanNotify(d.notifyCancelReorgJob)
+
+		var maxHandle int64
+		if tblInfo.PKIsHandle && !info.PKIsHandle {
+			// The handles are kept when the table is rebuilt without the primary key as handle,
+			// the handles allocated after rebuilding should be greater than them.
+			maxHandle, err = d.getMaxHandle(rebuiltTbl, reorgInfo.Handle)
+			if err != nil {
+				return ver, errors.Trace(err)
+			}
+		}
+		ver, err = d.replaceWithRebuiltTable(t, job, tblInfo, maxHandle)
+	default:
+		err = ErrInvalidTableState.Gen("invalid rebuilt table state %v", info.State)
+	}
+	return ver, errors.Trace(err)
+}
+
+// getMaxHandle returns the max handle of the rows in the table, the rows before seekHandle are skipped.
+func (d *ddl) getMaxHandle(t table.Table, seekHandle int64) (int64, error) {
+	ver, err := d.store.CurrentVersion()
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	maxHandle := int64(math.MinInt64)
+	err = iterateSnapshotRows(d.store, t, ver.Ver, seekHandle,
+		func(h int64, rowKey kv.Key, rawRecord []byte) (bool, error) {
+			maxHandle = h
+			return true, nil
+		})
+	return maxHandle, errors.Trace(err)
+}
+
+// replaceWithRebuiltTable drops the origin table and creates the rebuilt table with the new table ID,
+// the auto ID and the statistics of the origin table are kept. The auto ID is rebased to maxHandle
+// if it's less than that.
+func (d *ddl) replaceWithRebuiltTable(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo,
+	maxHandle int64) (ver int64, err error) {
+	schemaID := job.SchemaID
+	oldTableID := tblInfo.ID
+	oldDBID := schemaID
+	if tblInfo.OldSchemaID != 0 {
+		oldDBID = tblInfo.OldSchemaID
+	}
+	autoID, err := t.GetAutoTableID(oldDBID, oldTableID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	if autoID < maxHandle {
+		autoID = maxHandle
+	}
+	if err = t.DropTable(schemaID, oldTableID, true); err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	info := tblInfo.RebuildInfo
+	tblInfo.ID = info.NewTableID
+	tblInfo.PKIsHandle = info.PKIsHandle
+	tblInfo.RebuildInfo = nil
+	tblInfo.OldSchemaID = 0
+	if tblInfo.PKIsHandle {
+		// The primary index is replaced by the handle.
+		indexInfo := findPrimaryIndex(tblInfo)
+		setPrimaryKeyColumnFlag(tblInfo, indexInfo)
+		removeIndexInfo(tblInfo, indexInfo)
+	} else {
+		pkCol := tblInfo.GetPkColInfo()
+		pkCol.Flag &^= mysql.PriKeyFlag
+	}
+	if err = t.CreateTable(schemaID, tblInfo); err != nil {
+		return ver, errors.Trace(err)
+	}
+	if _, err = t.GenAutoTableID(schemaID, tblInfo.ID, autoID); err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	// The table has two table IDs like truncating the table.
+	ver, err = t.GenSchemaVersion()
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	diff := &model.SchemaDiff{
+		Version:    ver,
+		Type:       job.Type,
+		SchemaID:   schemaID,
+		TableID:    tblInfo.ID,
+		OldTableID: oldTableID,
+	}
+	if err = t.SetSchemaDiff(diff); err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	// Finish this job.
+	job.SchemaState = model.StatePublic
+	job.State = model.JobStateDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	job.Args = []interface{}{[]int64{oldTableID}, int64(0)}
+	// The statistics are moved to the new table ID.
+	d.asyncNotifyEvent(&Event{Tp: job.Type, TableInfo: tblInfo, OldTableID: oldTableID})
+	return ver, nil
+}
+
+func (d *ddl) convertRebuildTable2RollbackJob(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo,
+	err error) (ver int64, _ error) {
+	job.State = model.JobStateRollingback
+	// The rebuilt table is removed like dropping an index, the next state is delete only.
+	info := tblInfo.RebuildInfo
+	originalState := info.State
+	info.State = model.StateDeleteOnly
+	job.SchemaState = model.StateDeleteOnly
+	ver, err1 := updateTableInfo(t, job, tblInfo, originalState)
+	if err1 != nil {
+		return ver, errors.Trace(err1)
+	}
+	return ver, errors.Trace(err)
+}
+
+// rollbackRebuildTable removes the rebuilt table of the rolling back job.
+// For adding the primary key, the primary index is dropped after that.
+func (d *ddl) rollbackRebuildTable(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo) (ver int64, err error) {
+	info := tblInfo.RebuildInfo
+	if info.State != model.StateDeleteOnly {
+		return ver, ErrInvalidTableState.Gen("invalid rebuilt table state %v", info.State)
+	}
+
+	// delete only -> absent
+	originalState := info.State
+	tblInfo.RebuildInfo = nil
+	if job.Type == model.ActionAddPrimaryKey {
+		// The primary index is public, it's dropped from write only state.
+		indexInfo := findPrimaryIndex(tblInfo)
+		indexInfo.State = model.StateWriteOnly
+		job.SchemaState = model.StateWriteOnly
+		ver, err = updateTableInfo(t, job, tblInfo, originalState)
+		return ver, errors.Trace(err)
+	}
+
+	job.SchemaState = model.StateNone
+	ver, err = updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	job.State = model.JobStateRollbackDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	job.Args = []interface{}{[]int64{info.NewTableID}, int64(0)}
+	return ver, nil
+}
+
+// rebuildTableRecords backfills the rows of the snapshot into the rebuilt table.
+// How to backfill the rebuilt table?
+//  1. Traverse the snapshot with special version, get the handles of the rows.
+//  2. For every row, if the row has been already deleted, skip to next row.
+//  3. If the row has been written into the rebuilt table by DML, skip to next row.
+//  4. Write the row with the new handle and its index entries into the rebuilt table.
+func (d *ddl) rebuildTableRecords(t, rebuiltTbl table.Table, reorgInfo *reorgInfo, job *model.Job) error {
+	seekHandle := reorgInfo.Handle
+	version := reorgInfo.SnapshotVer
+	count := job.GetRowCount()
+	ctx := d.newContext()
+
+	colMap := make(map[int64]*types.FieldType, len(t.Meta().Columns))
+	for _, col := range t.Meta().Columns {
+		colMap[col.ID] = &col.FieldType
+	}
+	handles := make([]int64, 0, defaultBatchCnt)
+	for {
+		startTime := time.Now()
+		handles = handles[:0]
+		err := iterateSnapshotRows(d.store, t, version, seekHandle,
+			func(h int64, rowKey kv.Key, rawRecord []byte) (bool, error) {
+				handles = append(handles, h)
+				if len(handles) == defaultBatchCnt {
+					return false, nil
+				}
+				return true, nil
+			})
+		if err != nil {
+			return errors.Trace(err)
+		} else if len(handles) == 0 {
+			return nil
+		}
+
+		count += int64(len(handles))
+		seekHandle = handles[len(handles)-1] + 1
+		err = d.backfillRebuiltTable(ctx, t, rebuiltTbl, colMap, handles, reorgInfo)
+		sub := time.Since(startTime).Seconds()
+		if err != nil {
+			log.Warnf("[ddl] rebuilt table for %v rows failed, take time %v", count, sub)
+			return errors.Trace(err)
+		}
+
+		d.setReorgRowCount(count)
+		batchHandleDataHistogram.WithLabelValues(batchRebuildTable).Observe(sub)
+		log.Infof("[ddl] rebuilt table for %v rows, take time %v", count, sub)
+	}
+}
+
+func (d *ddl) backfillRebuiltTable(ctx context.Context, t, rebuiltTbl table.Table, colMap map[int64]*types.FieldType,
+	handles []int64, reorgInfo *reorgInfo) error {
+	var endIdx int
+	for len(handles) > 0 {
+		if len(handles) >= defaultSmallBatchCnt {
+			endIdx = defaultSmallBatchCnt
+		} else {
+			endIdx = len(handles)
+		}
+
+		err := kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+			if err := d.isReorgRunnable(); err != nil {
+				return errors.Trace(err)
+			}
+
+			for _, handle := range handles[:endIdx] {
+				if err := rebuildRecordInTxn(ctx, t, rebuiltTbl, colMap, handle, txn); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			return errors.Trace(reorgInfo.UpdateHandle(txn, handles[0]))
+		})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		handles = handles[endIdx:]
+	}
+
+	return nil
+}
+
+// rebuildRecordInTxn writes a row and its index entries into the rebuilt table.
+func rebuildRecordInTxn(ctx context.Context, t, rebuiltTbl table.Table, colMap map[int64]*types.FieldType,
+	handle int64, txn kv.Transaction) error {
+	rowVal, err := txn.Get(t.RecordKey(handle))
+	if err != nil {
+		if kv.ErrNotExist.Equal(err) {
+			// If row doesn't exist, skip it.
+			return nil
+		}
+		return errors.Trace(err)
+	}
+	rowMap, err := tablecodec.DecodeRow(rowVal, colMap, time.UTC)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	cols := t.Cols()
+	rebuiltCols := rebuiltTbl.Cols()
+	row := make([]types.Datum, len(cols))
+	newHandle := handle
+	for i, col := range cols {
+		if col.IsPKHandleColumn(t.Meta()) {
+			if mysql.HasUnsignedFlag(col.Flag) {
+				row[i].SetUint64(uint64(handle))
+			} else {
+				row[i].SetInt64(handle)
+			}
+		} else if val, ok := rowMap[col.ID]; ok {
+			row[i] = val
+		} else {
+			row[i], err = table.GetColOriginDefaultValue(ctx, col.ToInfo())
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if rebuiltCols[i].IsPKHandleColumn(rebuiltTbl.Meta()) {
+			newHandle = row[i].GetInt64()
+		}
+	}
+
+	newKey := rebuiltTbl.RecordKey(newHandle)
+	_, err = txn.Get(newKey)
+	if err == nil {
+		// The row has been written by DML, skip it.
+		return nil
+	} else if !kv.ErrNotExist.Equal(err) {
+		return errors.Trace(err)
+	}
+
+	colIDs := make([]int64, 0, len(cols))
+	vals := make([]types.Datum, 0, len(cols))
+	for i, col := range rebuiltCols {
+		if col.IsPKHandleColumn(rebuiltTbl.Meta()) || (col.IsGenerated() && !col.GeneratedStored) {
+			continue
+		}
+		colIDs = append(colIDs, col.ID)
+		vals = append(vals, row[i])
+	}
+	newRowVal, err := tablecodec.EncodeRow(vals, colIDs, time.UTC)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = txn.Set(newKey, newRowVal); err != nil {
+		return errors.Trace(err)
+	}
+
+	for _, idx := range rebuiltTbl.Indices() {
+		idxVals, err := idx.FetchValues(row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		dupHandle, err := idx.Create(txn, idxVals, newHandle)
+		if err != nil && !(kv.ErrKeyExists.Equal(err) && dupHandle == newHandle) {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
@@ -35,6 +35,44 @@ func (d *ddl) newContext() context.Context {
 	return c
 }
 
+// newReorgMeta saves the sql_mode and the time zone of the session for the job reorganizing the data.
+func newReorgMeta(ctx context.Context) *model.DDLReorgMeta {
+	vars := ctx.GetSessionVars()
+	return &model.DDLReorgMeta{
+		SQLMode:  vars.SQLMode,
+		TimeZone: model.NewTimeZoneLocation(vars.GetTimeZone()),
+	}
+}
+
+// getReorgLocation returns the time zone to decode and encode the rows when reorganizing the data.
+// The jobs submitted by the old versions don't have the time zone, UTC is used.
+func getReorgLocation(job *model.Job) *time.Location {
+	if job.ReorgMeta == nil || job.ReorgMeta.TimeZone == nil {
+		return time.UTC
+	}
+	return job.ReorgMeta.TimeZone.GetLocation()
+}
+
+// newReorgContext returns a context with the sql_mode and the time zone of the session which submits the job,
+// so the data is converted like the session converts it. The conversion is strict if the job doesn't have them.
+func (d *ddl) newReorgContext(job *model.Job) context.Context {
+	ctx := d.newContext()
+	vars := ctx.GetSessionVars()
+	if job.ReorgMeta != nil {
+		vars.SQLMode = job.ReorgMeta.SQLMode
+		vars.StrictSQLMode = job.ReorgMeta.SQLMode.HasStrictMode()
+	} else {
+		vars.StrictSQLMode = true
+	}
+	vars.TimeZone = getReorgLocation(job)
+	sc := vars.StmtCtx
+	sc.TimeZone = vars.TimeZone
+	// The values are converted like they're written by an insert statement.
+	sc.TruncateAsWarning = !vars.StrictSQLMode
+	sc.IgnoreZeroInDate = !vars.StrictSQLMode
+	return ctx
+}
+
 const waitReorgTimeout = 10 * time.Second
 
 func (d *ddl) setReorgRowCount(count int64) {
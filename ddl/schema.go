@@ -132,10 +132,12 @@ func (d *ddl) onDropSchema(t *meta.Meta, job *model.Job) (ver int64, _ error) {
 	return ver, errors.Trace(err)
 }
 
+// getIDs returns the IDs of the tables, including the physical IDs of the partitions.
 func getIDs(tables []*model.TableInfo) []int64 {
 	ids := make([]int64, 0, len(tables))
 	for _, t := range tables {
 		ids = append(ids, t.ID)
+		ids = append(ids, getPartitionIDs(t)...)
 	}
 
 	return ids
@@ -122,7 +122,7 @@ func (d *ddl) onDropTable(t *meta.Meta, job *model.Job) (ver int64, _ error) {
 		job.State = model.JobStateDone
 		job.BinlogInfo.AddTableInfo(ver, tblInfo)
 		startKey := tablecodec.EncodeTablePrefix(tableID)
-		job.Args = append(job.Args, startKey)
+		job.Args = append(job.Args, startKey, getPartitionIDs(tblInfo))
 		d.asyncNotifyEvent(&Event{Tp: model.ActionDropTable, TableInfo: tblInfo})
 	default:
 		err = ErrInvalidTableState.Gen("invalid table state %v", tblInfo.State)
@@ -131,6 +131,78 @@ func (d *ddl) onDropTable(t *meta.Meta, job *model.Job) (ver int64, _ error) {
 	return ver, errors.Trace(err)
 }
 
+// onCreateView creates a view, the old view is dropped in the same step when replacing a view.
+func (d *ddl) onCreateView(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	schemaID := job.SchemaID
+	tbInfo := &model.TableInfo{}
+	var orReplace bool
+	var oldTbInfoID int64
+	if err := job.DecodeArgs(tbInfo, &orReplace, &oldTbInfoID); err != nil {
+		// Invalid arguments, cancel this job.
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+
+	tbInfo.State = model.StateNone
+	replace := orReplace && oldTbInfoID > 0
+	if !replace {
+		err := checkTableNotExists(t, job, schemaID, tbInfo.Name.L)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+	}
+
+	ver, err := updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	switch tbInfo.State {
+	case model.StateNone:
+		// none -> public
+		job.SchemaState = model.StatePublic
+		tbInfo.State = model.StatePublic
+		if replace {
+			err = t.DropTable(schemaID, oldTbInfoID, true)
+			if err != nil {
+				return ver, errors.Trace(err)
+			}
+		}
+		err = t.CreateTable(schemaID, tbInfo)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+		// Finish this job.
+		job.State = model.JobStateDone
+		job.BinlogInfo.AddTableInfo(ver, tbInfo)
+		return ver, nil
+	default:
+		return ver, ErrInvalidTableState.Gen("invalid view state %v", tbInfo.State)
+	}
+}
+
+// onDropView drops a view. A view has no data, so it's removed in one step.
+func (d *ddl) onDropView(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	ver, err = updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	if err = t.DropTable(job.SchemaID, job.TableID, true); err != nil {
+		return ver, errors.Trace(err)
+	}
+	// Finish this job.
+	tblInfo.State = model.StateNone
+	job.SchemaState = model.StateNone
+	job.State = model.JobStateDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return ver, nil
+}
+
 func (d *ddl) splitTableRegion(tableID int64) error {
 	type splitableStore interface {
 		SplitRegion(splitKey kv.Key) error
@@ -196,7 +268,8 @@ func (d *ddl) onTruncateTable(t *meta.Meta, job *model.Job) (ver int64, _ error)
 	schemaID := job.SchemaID
 	tableID := job.TableID
 	var newTableID int64
-	err := job.DecodeArgs(&newTableID)
+	var newPartitionIDs []int64
+	err := job.DecodeArgs(&newTableID, &newPartitionIDs)
 	if err != nil {
 		job.State = model.JobStateCancelled
 		return ver, errors.Trace(err)
@@ -211,6 +284,17 @@ func (d *ddl) onTruncateTable(t *meta.Meta, job *model.Job) (ver int64, _ error)
 		job.State = model.JobStateCancelled
 		return ver, errors.Trace(err)
 	}
+	oldPartitionIDs := getPartitionIDs(tblInfo)
+	if tblInfo.Partition != nil {
+		if len(newPartitionIDs) != len(oldPartitionIDs) {
+			job.State = model.JobStateCancelled
+			return ver, errors.Errorf("the number of new partition IDs %d mismatches the partitions %d",
+				len(newPartitionIDs), len(oldPartitionIDs))
+		}
+		for i := range tblInfo.Partition.Definitions {
+			tblInfo.Partition.Definitions[i].ID = newPartitionIDs[i]
+		}
+	}
 	tblInfo.ID = newTableID
 	err = t.CreateTable(schemaID, tblInfo)
 	if err != nil {
@@ -225,7 +309,7 @@ func (d *ddl) onTruncateTable(t *meta.Meta, job *model.Job) (ver int64, _ error)
 	job.State = model.JobStateDone
 	job.BinlogInfo.AddTableInfo(ver, tblInfo)
 	startKey := tablecodec.EncodeTablePrefix(tableID)
-	job.Args = []interface{}{startKey}
+	job.Args = []interface{}{startKey, oldPartitionIDs}
 	return ver, nil
 }
 
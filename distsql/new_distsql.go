@@ -19,6 +19,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/execdetails"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
 	goctx "golang.org/x/net/context"
@@ -40,6 +41,9 @@ type NewSelectResult interface {
 	// Fetch fetches partial results from client.
 	// The caller should call SetFields() before call Fetch().
 	Fetch(ctx goctx.Context)
+	// SetCopRuntimeStats sets the statistics to record the information of the coprocessor tasks,
+	// it should be called before Fetch().
+	SetCopRuntimeStats(stats *execdetails.CopRuntimeStats)
 }
 
 // NewPartialResult is the result from a single region server.
@@ -60,6 +64,8 @@ type newSelectResult struct {
 	closed  chan struct{}
 
 	rowLen int
+
+	copStats *execdetails.CopRuntimeStats
 }
 
 type newResultWithErr struct {
@@ -79,6 +85,7 @@ func (r *newSelectResult) fetch(ctx goctx.Context) {
 		queryHistgram.WithLabelValues(r.label).Observe(duration.Seconds())
 	}()
 	for {
+		taskStart := time.Now()
 		resultSubset, err := r.resp.Next()
 		if err != nil {
 			r.results <- newResultWithErr{err: errors.Trace(err)}
@@ -87,6 +94,7 @@ func (r *newSelectResult) fetch(ctx goctx.Context) {
 		if resultSubset == nil {
 			return
 		}
+		r.copStats.RecordTask(time.Since(taskStart), len(resultSubset))
 
 		select {
 		case r.results <- newResultWithErr{result: resultSubset}:
@@ -110,6 +118,7 @@ func (r *newSelectResult) Next() (NewPartialResult, error) {
 	}
 	pr := &newPartialResult{}
 	pr.rowLen = r.rowLen
+	pr.copStats = r.copStats
 	err := pr.unmarshal(re.result)
 	return pr, errors.Trace(err)
 }
@@ -120,6 +129,11 @@ func (r *newSelectResult) NextRaw() ([]byte, error) {
 	return re.result, errors.Trace(re.err)
 }
 
+// SetCopRuntimeStats implements the NewSelectResult SetCopRuntimeStats interface.
+func (r *newSelectResult) SetCopRuntimeStats(stats *execdetails.CopRuntimeStats) {
+	r.copStats = stats
+}
+
 // Close closes SelectResult.
 func (r *newSelectResult) Close() error {
 	// Close this channel tell fetch goroutine to exit.
@@ -131,6 +145,7 @@ type newPartialResult struct {
 	resp     *tipb.SelectResponse
 	chunkIdx int
 	rowLen   int
+	copStats *execdetails.CopRuntimeStats
 }
 
 func (pr *newPartialResult) unmarshal(resultSubset []byte) error {
@@ -163,6 +178,7 @@ func (pr *newPartialResult) Next() (data []types.Datum, err error) {
 		}
 		data[i].SetRaw(l)
 	}
+	pr.copStats.RecordRows(1)
 	return
 }
 
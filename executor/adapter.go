@@ -27,9 +27,12 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/perfschema"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/chunk"
 )
 
 type processinfoSetter interface {
@@ -43,6 +46,9 @@ type recordSet struct {
 	stmt        *ExecStmt
 	processinfo processinfoSetter
 	err         error
+	// chk and chkCursor are used to fetch the rows by NextChunk when the executor supports it.
+	chk       *chunk.Chunk
+	chkCursor int
 }
 
 func (a *recordSet) Fields() ([]*ast.ResultField, error) {
@@ -69,8 +75,9 @@ func (a *recordSet) Fields() ([]*ast.ResultField, error) {
 }
 
 func (a *recordSet) Next() (*ast.Row, error) {
-	row, err := a.executor.Next()
+	row, err := a.nextRow()
 	if err != nil {
+		a.err = err
 		return nil, errors.Trace(err)
 	}
 	if row == nil {
@@ -86,9 +93,32 @@ func (a *recordSet) Next() (*ast.Row, error) {
 	return &ast.Row{Data: row}, nil
 }
 
+// nextRow fetches the next row from the executor, the rows are fetched by NextChunk if the executor supports it.
+func (a *recordSet) nextRow() (Row, error) {
+	if !supportChunk(a.executor) {
+		return a.executor.Next()
+	}
+	if a.chk == nil {
+		a.chk = newChunk(a.executor)
+	}
+	if a.chkCursor >= a.chk.NumRows() {
+		if err := a.executor.NextChunk(a.chk); err != nil {
+			return nil, errors.Trace(err)
+		}
+		a.chkCursor = 0
+		if a.chk.NumRows() == 0 {
+			return nil, nil
+		}
+	}
+	row := a.chk.GetRow(a.chkCursor).GetDatumRow()
+	a.chkCursor++
+	return row, nil
+}
+
 func (a *recordSet) Close() error {
 	err := a.executor.Close()
 	a.stmt.logSlowQuery()
+	a.stmt.endStmtEvent(a.err)
 	if a.processinfo != nil {
 		a.processinfo.SetProcessInfo("")
 	}
@@ -107,10 +137,13 @@ type ExecStmt struct {
 	Cacheable bool
 	// Text represents the origin query text.
 	Text string
+	// StmtNode is the ast of the statement, it may be nil.
+	StmtNode ast.StmtNode
 
 	ctx            context.Context
 	startTime      time.Time
 	isPreparedStmt bool
+	stmtEvent      *perfschema.StatementEvent
 }
 
 // OriginText implements ast.Statement interface.
@@ -127,9 +160,16 @@ func (a *ExecStmt) IsPrepared() bool {
 // This function builds an Executor from a plan. If the Executor doesn't return result,
 // like the INSERT, UPDATE statements, it executes in this function, if the Executor returns
 // result, execution is done after this function returns, in the returned ast.RecordSet Next method.
-func (a *ExecStmt) Exec(ctx context.Context) (ast.RecordSet, error) {
+func (a *ExecStmt) Exec(ctx context.Context) (_ ast.RecordSet, err error) {
 	a.startTime = time.Now()
 	a.ctx = ctx
+	a.startStmtEvent()
+	defer func() {
+		// The statement event of a statement returning result set is ended when the record set is closed.
+		if err != nil {
+			a.endStmtEvent(err)
+		}
+	}()
 
 	if _, ok := a.Plan.(*plan.Analyze); ok && ctx.GetSessionVars().InRestrictedSQL {
 		oriStats := ctx.GetSessionVars().Systems[variable.TiDBBuildStatsConcurrency]
@@ -153,22 +193,15 @@ func (a *ExecStmt) Exec(ctx context.Context) (ast.RecordSet, error) {
 		return nil, errors.Trace(err)
 	}
 
-	if err := e.Open(); err != nil {
+	if err = e.Open(); err != nil {
 		return nil, errors.Trace(err)
 	}
 
 	var pi processinfoSetter
 	if raw, ok := ctx.(processinfoSetter); ok {
 		pi = raw
-		sql := a.OriginText()
-		if simple, ok := a.Plan.(*plan.Simple); ok && simple.Statement != nil {
-			if ss, ok := simple.Statement.(ast.SensitiveStmtNode); ok {
-				// Use SecureText to avoid leak password information.
-				sql = ss.SecureText()
-			}
-		}
 		// Update processinfo, ShowProcess() will use it.
-		pi.SetProcessInfo(sql)
+		pi.SetProcessInfo(a.secureText())
 	}
 	// Fields or Schema are only used for statements that return result set.
 	if e.Schema().Len() == 0 {
@@ -182,7 +215,7 @@ func (a *ExecStmt) Exec(ctx context.Context) (ast.RecordSet, error) {
 	}, nil
 }
 
-func (a *ExecStmt) handleNoDelayExecutor(e Executor, ctx context.Context, pi processinfoSetter) (ast.RecordSet, error) {
+func (a *ExecStmt) handleNoDelayExecutor(e Executor, ctx context.Context, pi processinfoSetter) (_ ast.RecordSet, err error) {
 	// Check if "tidb_snapshot" is set for the write executors.
 	// In history read mode, we can not do write operations.
 	switch e.(type) {
@@ -199,6 +232,9 @@ func (a *ExecStmt) handleNoDelayExecutor(e Executor, ctx context.Context, pi pro
 		}
 		terror.Log(errors.Trace(e.Close()))
 		a.logSlowQuery()
+		if err == nil {
+			a.endStmtEvent(nil)
+		}
 	}()
 	for {
 		row, err := e.Next()
@@ -262,6 +298,7 @@ func (a *ExecStmt) buildExecutor(ctx context.Context) (Executor, error) {
 			return nil, errors.Trace(err)
 		}
 		a.Text = executorExec.Stmt.Text()
+		a.StmtNode = executorExec.Stmt
 		a.isPreparedStmt = true
 		a.Plan = executorExec.Plan
 		e = executorExec.StmtExec
@@ -289,6 +326,157 @@ func (a *ExecStmt) logSlowQuery() {
 	}
 }
 
+// secureText returns the query text which doesn't contain password information.
+func (a *ExecStmt) secureText() string {
+	if simple, ok := a.Plan.(*plan.Simple); ok && simple.Statement != nil {
+		if ss, ok := simple.Statement.(ast.SensitiveStmtNode); ok {
+			// Use SecureText to avoid leak password information.
+			return ss.SecureText()
+		}
+	}
+	return a.OriginText()
+}
+
+// startStmtEvent records the start of the statement into the performance_schema statement event tables.
+func (a *ExecStmt) startStmtEvent() {
+	sessVars := a.ctx.GetSessionVars()
+	// The retried statements have been recorded in their first execution.
+	if sessVars.InRestrictedSQL || sessVars.RetryInfo.Retrying {
+		return
+	}
+	a.stmtEvent = &perfschema.StatementEvent{
+		ThreadID:      sessVars.ConnectionID,
+		EventName:     stmtEventName(a.StmtNode),
+		SQLText:       a.secureText(),
+		CurrentSchema: sessVars.CurrentDB,
+	}
+	perfschema.StartStatement(a.stmtEvent)
+}
+
+// endStmtEvent records the end of the statement with its execution information.
+func (a *ExecStmt) endStmtEvent(err error) {
+	ev := a.stmtEvent
+	if ev == nil {
+		return
+	}
+	a.stmtEvent = nil
+	sc := a.ctx.GetSessionVars().StmtCtx
+	// The prepared statement is known after the executor is built.
+	ev.EventName = stmtEventName(a.StmtNode)
+	ev.SQLText = a.secureText()
+	ev.DigestText, ev.Digest = parser.NormalizeDigest(a.Text)
+	ev.Warnings = uint64(sc.WarningCount())
+	ev.RowsAffected = sc.AffectedRows()
+	ev.RowsSent = sc.FoundRows()
+	ev.RowsExamined = sc.ExaminedRows()
+	if err != nil {
+		var sqlErr *mysql.SQLError
+		if te, ok := errors.Cause(err).(*terror.Error); ok {
+			sqlErr = te.ToSQLError()
+		} else {
+			sqlErr = mysql.NewErrf(mysql.ErrUnknown, "%s", err.Error())
+		}
+		ev.ErrNo = sqlErr.Code
+		ev.SQLState = sqlErr.State
+		ev.MessageText = sqlErr.Message
+		ev.Errors = 1
+	}
+	perfschema.EndStatement(ev)
+}
+
+// stmtEventName returns the instrument name of a statement, same as MySQL.
+func stmtEventName(node ast.StmtNode) string {
+	var name string
+	switch x := node.(type) {
+	case *ast.SelectStmt, *ast.UnionStmt:
+		name = "select"
+	case *ast.InsertStmt:
+		name = "insert"
+		if x.IsReplace {
+			name = "replace"
+		}
+	case *ast.UpdateStmt:
+		name = "update"
+	case *ast.DeleteStmt:
+		name = "delete"
+	case *ast.LoadDataStmt:
+		name = "load"
+	case *ast.BeginStmt:
+		name = "begin"
+	case *ast.CommitStmt:
+		name = "commit"
+	case *ast.RollbackStmt:
+		name = "rollback"
+	case *ast.SetStmt:
+		name = "set_option"
+	case *ast.SetPwdStmt:
+		name = "set_password"
+	case *ast.SetRoleStmt:
+		name = "set_role"
+	case *ast.SetDefaultRoleStmt:
+		name = "alter_user_default_role"
+	case *ast.ShowStmt:
+		name = "show"
+	case *ast.UseStmt:
+		name = "change_db"
+	case *ast.CreateDatabaseStmt:
+		name = "create_db"
+	case *ast.DropDatabaseStmt:
+		name = "drop_db"
+	case *ast.CreateTableStmt:
+		name = "create_table"
+	case *ast.DropTableStmt:
+		name = "drop_table"
+	case *ast.AlterTableStmt:
+		name = "alter_table"
+	case *ast.RenameTableStmt:
+		name = "rename_table"
+	case *ast.TruncateTableStmt:
+		name = "truncate"
+	case *ast.CreateIndexStmt:
+		name = "create_index"
+	case *ast.DropIndexStmt:
+		name = "drop_index"
+	case *ast.CreateViewStmt:
+		name = "create_view"
+	case *ast.CreateUserStmt:
+		name = "create_user"
+		if x.IsCreateRole {
+			name = "create_role"
+		}
+	case *ast.DropUserStmt:
+		name = "drop_user"
+		if x.IsDropRole {
+			name = "drop_role"
+		}
+	case *ast.AlterUserStmt:
+		name = "alter_user"
+	case *ast.GrantStmt, *ast.GrantRoleStmt:
+		name = "grant"
+	case *ast.RevokeStmt, *ast.RevokeRoleStmt:
+		name = "revoke"
+	case *ast.AnalyzeTableStmt:
+		name = "analyze"
+	case *ast.ExplainStmt:
+		name = "explain_other"
+	case *ast.PrepareStmt:
+		name = "prepare_sql"
+	case *ast.ExecuteStmt:
+		name = "execute_sql"
+	case *ast.DeallocateStmt:
+		name = "dealloc_sql"
+	case *ast.FlushStmt:
+		name = "flush"
+	case *ast.KillStmt:
+		name = "kill"
+	case *ast.DoStmt:
+		name = "do"
+	default:
+		name = "other"
+	}
+	return "statement/sql/" + name
+}
+
 // IsPointGetWithPKOrUniqueKeyByAutoCommit returns true when meets following conditions:
 //  1. ctx is auto commit tagged
 //  2. txn is nil
@@ -14,12 +14,17 @@
 package executor
 
 import (
+	"unsafe"
+
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/expression/aggregation"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/mvmap"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -29,6 +34,8 @@ type aggCtxsMapper map[string][]*aggregation.AggEvaluateContext
 // HashAggExec deals with all the aggregate functions.
 // It is built from the Aggregate Plan. When Next() is called, it reads all the data from Src
 // and updates all the items in AggFuncs.
+// If the hash aggregation concurrencies are larger than 1, the data is aggregated by the partial and final workers
+// in parallel, see aggregate_parallel.go.
 type HashAggExec struct {
 	baseExecutor
 
@@ -41,6 +48,24 @@ type HashAggExec struct {
 	groupMap      *mvmap.MVMap
 	groupIterator *mvmap.Iterator
 	GroupByItems  []expression.Expression
+	memTracker    *memory.Tracker
+
+	// The fields below are used by the parallel execution.
+	isParallel     bool
+	finishCh       chan struct{}
+	partialWorkers []*hashAggPartialWorker
+	finalWorkers   []*hashAggFinalWorker
+	// finalWorkerIdx and finalGroupIdx point to the next group to be returned in the final workers.
+	finalWorkerIdx int
+	finalGroupIdx  int
+}
+
+// memoryUsage implements the memoryUsageReporter interface, the groups and their aggregation contexts are counted.
+func (e *HashAggExec) memoryUsage() int64 {
+	if e.memTracker == nil {
+		return 0
+	}
+	return e.memTracker.BytesConsumed()
 }
 
 // Close implements the Executor Close interface.
@@ -48,6 +73,9 @@ func (e *HashAggExec) Close() error {
 	e.groupMap = nil
 	e.groupIterator = nil
 	e.aggCtxsMap = nil
+	e.partialWorkers = nil
+	e.finalWorkers = nil
+	e.memTracker.Detach()
 	return errors.Trace(e.children[0].Close())
 }
 
@@ -57,6 +85,10 @@ func (e *HashAggExec) Open() error {
 	e.groupMap = mvmap.NewMVMap()
 	e.groupIterator = e.groupMap.NewIterator()
 	e.aggCtxsMap = make(aggCtxsMapper, 0)
+	e.isParallel = e.canParallel()
+	e.finalWorkerIdx, e.finalGroupIdx = 0, 0
+	e.memTracker = memory.NewTracker("HashAggExec", -1)
+	e.memTracker.AttachTo(e.ctx.GetSessionVars().StmtCtx.MemTracker)
 	return errors.Trace(e.children[0].Open())
 }
 
@@ -64,39 +96,160 @@ func (e *HashAggExec) Open() error {
 func (e *HashAggExec) Next() (Row, error) {
 	// In this stage we consider all data from src as a single group.
 	if !e.executed {
-		for {
-			hasMore, err := e.innerNext()
-			if err != nil {
-				return nil, errors.Trace(err)
-			}
-			if !hasMore {
-				break
-			}
+		var err error
+		if e.isParallel {
+			err = e.parallelExec()
+		} else {
+			err = e.execute()
 		}
-		if (e.groupMap.Len() == 0) && !e.hasGby {
-			// If no groupby and no data, we should add an empty group.
-			// For example:
-			// "select count(c) from t;" should return one row [0]
-			// "select count(c) from t group by c1;" should return empty result set.
-			e.groupMap.Put([]byte{}, []byte{})
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
-		e.executed = true
+		e.finishExecute()
 	}
-	groupKey, _ := e.groupIterator.Next()
-	if groupKey == nil {
+	aggFuncs, aggCtxs, ok := e.nextGroup()
+	if !ok {
 		return nil, nil
 	}
-	retRow := make([]types.Datum, 0, len(e.AggFuncs))
-	aggCtxs := e.getContexts(groupKey)
-	for i, af := range e.AggFuncs {
+	retRow := make([]types.Datum, 0, len(aggFuncs))
+	for i, af := range aggFuncs {
 		retRow = append(retRow, af.GetResult(aggCtxs[i]))
 	}
 	return retRow, nil
 }
 
-func (e *HashAggExec) getGroupKey(row Row) ([]byte, error) {
+// nextGroup returns the aggregate functions and the contexts of the next group, ok is false if there is no more group.
+func (e *HashAggExec) nextGroup() (aggFuncs []aggregation.Aggregation, aggCtxs []*aggregation.AggEvaluateContext, ok bool) {
+	if e.isParallel {
+		return e.nextParallelGroup()
+	}
+	groupKey, _ := e.groupIterator.Next()
+	if groupKey == nil {
+		return nil, nil, false
+	}
+	return e.AggFuncs, e.getContexts(groupKey), true
+}
+
+// NextChunk implements the Executor NextChunk interface.
+func (e *HashAggExec) NextChunk(chk *chunk.Chunk) error {
+	chk.Reset()
+	if !e.executed {
+		var err error
+		if e.isParallel {
+			err = e.parallelExec()
+		} else {
+			err = e.executeChunk()
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.finishExecute()
+	}
+	for chk.NumRows() < maxChunkSize {
+		aggFuncs, aggCtxs, ok := e.nextGroup()
+		if !ok {
+			return nil
+		}
+		if len(aggFuncs) == 0 {
+			chk.SetNumVirtualRows(chk.NumRows() + 1)
+			continue
+		}
+		for i, af := range aggFuncs {
+			d := af.GetResult(aggCtxs[i])
+			chk.AppendDatum(i, &d)
+		}
+	}
+	return nil
+}
+
+func (e *HashAggExec) supportChunk() bool {
+	return supportChunk(e.children[0])
+}
+
+// execute reads all the data from src by Next and updates each aggregate function.
+func (e *HashAggExec) execute() error {
+	for {
+		hasMore, err := e.innerNext()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !hasMore {
+			return nil
+		}
+	}
+}
+
+// finishExecute is called after all the data from src is aggregated.
+func (e *HashAggExec) finishExecute() {
+	e.executed = true
+	if e.hasGby {
+		return
+	}
+	// If no groupby and no data, we should add an empty group.
+	// For example:
+	// "select count(c) from t;" should return one row [0]
+	// "select count(c) from t group by c1;" should return empty result set.
+	if !e.isParallel && e.groupMap.Len() == 0 {
+		e.groupMap.Put([]byte{}, []byte{})
+	}
+	if e.isParallel && e.numParallelGroups() == 0 {
+		w := e.finalWorkers[0]
+		_, err := e.getWorkerContexts(w.aggCtxsMap, &w.groupKeys, w.aggFuncs, []byte{})
+		terror.Log(errors.Trace(err))
+	}
+}
+
+// executeChunk reads all the data from src by NextChunk and updates each aggregate function.
+// The group by items are evaluated on the whole chunk by the vectorized expression evaluation.
+func (e *HashAggExec) executeChunk() error {
+	childChunk := newChunk(e.children[0])
+	var groupKeyChunk *chunk.Chunk
+	vectorizedGby := e.hasGby && !(e.aggType == plan.FinalAgg && !plan.UseDAGPlanBuilder(e.ctx))
+	if vectorizedGby {
+		fields := make([]*types.FieldType, 0, len(e.GroupByItems))
+		for _, item := range e.GroupByItems {
+			fields = append(fields, item.GetType())
+		}
+		groupKeyChunk = chunk.NewChunk(fields)
+	}
+	for {
+		err := e.children[0].NextChunk(childChunk)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if childChunk.NumRows() == 0 {
+			return nil
+		}
+		if vectorizedGby {
+			groupKeyChunk.Reset()
+			err = expression.VectorizedExecute(e.ctx, e.GroupByItems, childChunk, groupKeyChunk)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		for i := 0; i < childChunk.NumRows(); i++ {
+			srcRow := Row(childChunk.GetRow(i).GetDatumRow())
+			var groupKey []byte
+			if vectorizedGby {
+				groupKey, err = e.encodeGroupKey(groupKeyChunk.GetRow(i).GetDatumRow())
+			} else {
+				groupKey, err = e.getGroupKey(e.GroupByItems, srcRow)
+			}
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if err = e.updateGroup(groupKey, srcRow); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+}
+
+// getGroupKey evaluates the group by items on the row and encodes the group key,
+// the items are passed in because every worker of the parallel execution evaluates its own copy.
+func (e *HashAggExec) getGroupKey(groupByItems []expression.Expression, row Row) ([]byte, error) {
 	if e.aggType == plan.FinalAgg && !plan.UseDAGPlanBuilder(e.ctx) {
-		val, err := e.GroupByItems[0].Eval(row)
+		val, err := groupByItems[0].Eval(row)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -105,14 +258,23 @@ func (e *HashAggExec) getGroupKey(row Row) ([]byte, error) {
 	if !e.hasGby {
 		return []byte{}, nil
 	}
-	vals := make([]types.Datum, 0, len(e.GroupByItems))
-	for _, item := range e.GroupByItems {
+	vals := make([]types.Datum, 0, len(groupByItems))
+	for _, item := range groupByItems {
 		v, err := item.Eval(row)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
 		vals = append(vals, v)
 	}
+	return e.encodeGroupKey(vals)
+}
+
+// encodeGroupKey encodes the values of the group by items into the group key.
+func (e *HashAggExec) encodeGroupKey(vals []types.Datum) ([]byte, error) {
+	for i, item := range e.GroupByItems {
+		// The strings equal by the collation are in the same group.
+		vals[i] = codec.CollationKey(vals[i], item.GetType().Collate)
+	}
 	bs, err := codec.EncodeValue([]byte{}, vals...)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -131,21 +293,53 @@ func (e *HashAggExec) innerNext() (ret bool, err error) {
 		return false, nil
 	}
 	e.executed = true
-	groupKey, err := e.getGroupKey(srcRow)
+	groupKey, err := e.getGroupKey(e.GroupByItems, srcRow)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
+	if err = e.updateGroup(groupKey, srcRow); err != nil {
+		return false, errors.Trace(err)
+	}
+	return true, nil
+}
+
+// updateGroup updates each aggregate function of the group with the row.
+func (e *HashAggExec) updateGroup(groupKey []byte, row Row) error {
 	if e.groupMap.Get(groupKey) == nil {
+		oldUsage := e.groupMap.MemoryUsage()
 		e.groupMap.Put(groupKey, []byte{})
+		// The group key is also held by the aggregation contexts map.
+		usage := e.groupMap.MemoryUsage() - oldUsage + int64(len(groupKey))
+		usage += aggCtxsMemoryUsage(e.getContexts(groupKey))
+		if err := e.memTracker.Consume(usage); err != nil {
+			return errors.Trace(err)
+		}
 	}
-	aggCtxs := e.getContexts(groupKey)
-	for i, af := range e.AggFuncs {
-		err = af.Update(aggCtxs[i], e.sc, srcRow)
-		if err != nil {
-			return false, errors.Trace(err)
+	return errors.Trace(e.updateAggCtxs(e.AggFuncs, e.getContexts(groupKey), row))
+}
+
+// updateAggCtxs updates the aggregation contexts with the row, and tracks the memory
+// grown by the contexts, such as the distinct keys and the buffer of group_concat.
+func (e *HashAggExec) updateAggCtxs(aggFuncs []aggregation.Aggregation, aggCtxs []*aggregation.AggEvaluateContext, row Row) error {
+	oldUsage := aggCtxsMemoryUsage(aggCtxs)
+	for i, af := range aggFuncs {
+		if err := af.Update(aggCtxs[i], e.sc, row); err != nil {
+			return errors.Trace(err)
 		}
 	}
-	return true, nil
+	if delta := aggCtxsMemoryUsage(aggCtxs) - oldUsage; delta != 0 {
+		return errors.Trace(e.memTracker.Consume(delta))
+	}
+	return nil
+}
+
+// aggCtxsMemoryUsage returns the estimated memory usage of the aggregation contexts of a group.
+func aggCtxsMemoryUsage(aggCtxs []*aggregation.AggEvaluateContext) int64 {
+	usage := int64(cap(aggCtxs)) * int64(unsafe.Sizeof(aggCtxs[0]))
+	for _, aggCtx := range aggCtxs {
+		usage += aggCtx.MemoryUsage()
+	}
+	return usage
 }
 
 func (e *HashAggExec) getContexts(groupKey []byte) []*aggregation.AggEvaluateContext {
@@ -254,6 +448,7 @@ func (e *StreamAggExec) meetNewGroup(row Row) (bool, error) {
 		if err != nil {
 			return false, errors.Trace(err)
 		}
+		v = codec.CollationKey(v, item.GetType().Collate)
 		if matched {
 			c, err := v.CompareDatum(e.StmtCtx, &e.curGroupKey[i])
 			if err != nil {
@@ -0,0 +1,350 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/expression/aggregation"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// The parallel hash aggregation runs in two phases:
+// 1. The input rows are fetched by the HashAggExec and dispatched to the partial workers in batches, every partial
+//    worker aggregates its shard of the input and produces a partial result for each group, like the coprocessor does
+//    for a pushed down aggregation.
+// 2. The partial results are shuffled to the final workers by the hash of the group key, so all the partial results
+//    of a group are merged by the same final worker with the aggregate functions in FinalMode.
+
+// hashAggPartialResult is a batch of partial results sent from a partial worker to a final worker.
+type hashAggPartialResult struct {
+	groupKeys [][]byte
+	rows      []Row
+}
+
+// hashAggPartialWorker aggregates a shard of the input rows in one goroutine.
+type hashAggPartialWorker struct {
+	aggFuncs     []aggregation.Aggregation
+	groupByItems []expression.Expression
+	inputCh      chan *execResult
+	aggCtxsMap   aggCtxsMapper
+	// groupKeys keeps the group keys in the order they are inserted.
+	groupKeys [][]byte
+}
+
+// hashAggFinalWorker merges the partial results of the groups whose group key hashes to it in one goroutine.
+type hashAggFinalWorker struct {
+	aggFuncs   []aggregation.Aggregation
+	inputCh    chan *hashAggPartialResult
+	aggCtxsMap aggCtxsMapper
+	// groupKeys keeps the group keys in the order they are inserted.
+	groupKeys [][]byte
+}
+
+// canParallel checks whether the aggregation can be executed by the partial and final workers.
+// The distinct aggregate functions can't be merged from the partial results.
+func (e *HashAggExec) canParallel() bool {
+	sessVars := e.ctx.GetSessionVars()
+	if sessVars.HashAggPartialConcurrency <= 1 && sessVars.HashAggFinalConcurrency <= 1 {
+		return false
+	}
+	for _, af := range e.AggFuncs {
+		if af.IsDistinct() {
+			return false
+		}
+	}
+	return true
+}
+
+// buildFinalAggFuncs builds the aggregate functions in FinalMode whose arguments are the columns of the partial result.
+// The partial result of a group is the concatenation of the partial results of all the aggregate functions,
+// "count" has a count column, "avg" has a count column and a sum column, the others have a value column.
+func buildFinalAggFuncs(aggFuncs []aggregation.Aggregation) []aggregation.Aggregation {
+	finalAggFuncs := make([]aggregation.Aggregation, 0, len(aggFuncs))
+	cursor := 0
+	for _, af := range aggFuncs {
+		var args []expression.Expression
+		name := af.GetName()
+		if name == ast.AggFuncCount || name == ast.AggFuncAvg {
+			args = append(args, &expression.Column{Index: cursor, RetType: types.NewFieldType(mysql.TypeLonglong)})
+			cursor++
+		}
+		if name != ast.AggFuncCount {
+			args = append(args, &expression.Column{Index: cursor, RetType: af.GetType()})
+			cursor++
+		}
+		fun := aggregation.NewAggFunction(name, args, false)
+		fun.SetMode(aggregation.FinalMode)
+		finalAggFuncs = append(finalAggFuncs, fun)
+	}
+	return finalAggFuncs
+}
+
+// finalWorkerIndex returns the final worker which merges the partial results of the group.
+func finalWorkerIndex(groupKey []byte, concurrency int) int {
+	h := fnv.New32a()
+	_, err := h.Write(groupKey)
+	terror.Log(errors.Trace(err))
+	return int(h.Sum32() % uint32(concurrency))
+}
+
+// parallelExec reads all the data from src and aggregates it by the partial and final workers,
+// it returns after all the workers exit.
+func (e *HashAggExec) parallelExec() error {
+	sessVars := e.ctx.GetSessionVars()
+	e.finishCh = make(chan struct{})
+	e.partialWorkers = make([]*hashAggPartialWorker, sessVars.HashAggPartialConcurrency)
+	e.finalWorkers = make([]*hashAggFinalWorker, sessVars.HashAggFinalConcurrency)
+	for i := range e.partialWorkers {
+		w := &hashAggPartialWorker{
+			aggFuncs:     make([]aggregation.Aggregation, 0, len(e.AggFuncs)),
+			groupByItems: make([]expression.Expression, 0, len(e.GroupByItems)),
+			inputCh:      make(chan *execResult, 1),
+			aggCtxsMap:   make(aggCtxsMapper),
+		}
+		// The expressions are cloned because they are evaluated concurrently.
+		for _, af := range e.AggFuncs {
+			w.aggFuncs = append(w.aggFuncs, af.Clone())
+		}
+		for _, item := range e.GroupByItems {
+			w.groupByItems = append(w.groupByItems, item.Clone())
+		}
+		e.partialWorkers[i] = w
+	}
+	for i := range e.finalWorkers {
+		e.finalWorkers[i] = &hashAggFinalWorker{
+			aggFuncs:   buildFinalAggFuncs(e.AggFuncs),
+			inputCh:    make(chan *hashAggPartialResult, len(e.partialWorkers)),
+			aggCtxsMap: make(aggCtxsMapper),
+		}
+	}
+
+	// errCh holds the first error of the workers.
+	errCh := make(chan error, 1)
+	var finishOnce sync.Once
+	onError := func(err error) {
+		select {
+		case errCh <- err:
+		default:
+		}
+		finishOnce.Do(func() { close(e.finishCh) })
+	}
+	var partialWG, finalWG sync.WaitGroup
+	for _, w := range e.finalWorkers {
+		finalWG.Add(1)
+		go e.runFinalWorker(w, &finalWG, onError)
+	}
+	for _, w := range e.partialWorkers {
+		partialWG.Add(1)
+		go e.runPartialWorker(w, &partialWG, onError)
+	}
+	if err := e.fetchInput(); err != nil {
+		onError(err)
+	}
+	partialWG.Wait()
+	for _, w := range e.finalWorkers {
+		close(w.inputCh)
+	}
+	finalWG.Wait()
+	finishOnce.Do(func() { close(e.finishCh) })
+	select {
+	case err := <-errCh:
+		return errors.Trace(err)
+	default:
+	}
+	return nil
+}
+
+// fetchInput reads all the rows from src and dispatches them to the partial workers in batches.
+func (e *HashAggExec) fetchInput() error {
+	defer func() {
+		for _, w := range e.partialWorkers {
+			close(w.inputCh)
+		}
+	}()
+	for workerIdx := 0; ; workerIdx = (workerIdx + 1) % len(e.partialWorkers) {
+		batch := &execResult{rows: make([]Row, 0, maxChunkSize)}
+		for len(batch.rows) < maxChunkSize {
+			row, err := e.children[0].Next()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if row == nil {
+				break
+			}
+			batch.rows = append(batch.rows, row)
+		}
+		if len(batch.rows) == 0 {
+			return nil
+		}
+		select {
+		case <-e.finishCh:
+			return nil
+		case e.partialWorkers[workerIdx].inputCh <- batch:
+		}
+		if len(batch.rows) < maxChunkSize {
+			return nil
+		}
+	}
+}
+
+// runPartialWorker aggregates the input rows of the partial worker,
+// and sends the partial results to the final workers when the input is exhausted.
+func (e *HashAggExec) runPartialWorker(w *hashAggPartialWorker, wg *sync.WaitGroup, onError func(error)) {
+	defer wg.Done()
+	for {
+		var batch *execResult
+		select {
+		case <-e.finishCh:
+			return
+		case result, ok := <-w.inputCh:
+			if !ok {
+				if err := e.shufflePartialResults(w); err != nil {
+					onError(err)
+				}
+				return
+			}
+			batch = result
+		}
+		for _, row := range batch.rows {
+			groupKey, err := e.getGroupKey(w.groupByItems, row)
+			if err != nil {
+				onError(err)
+				return
+			}
+			aggCtxs, err := e.getWorkerContexts(w.aggCtxsMap, &w.groupKeys, w.aggFuncs, groupKey)
+			if err != nil {
+				onError(err)
+				return
+			}
+			if err = e.updateAggCtxs(w.aggFuncs, aggCtxs, row); err != nil {
+				onError(err)
+				return
+			}
+		}
+	}
+}
+
+// shufflePartialResults sends the partial results of the partial worker to the final workers by the hash of the group key.
+func (e *HashAggExec) shufflePartialResults(w *hashAggPartialWorker) error {
+	batches := make([]*hashAggPartialResult, len(e.finalWorkers))
+	send := func(idx int) bool {
+		select {
+		case <-e.finishCh:
+			return false
+		case e.finalWorkers[idx].inputCh <- batches[idx]:
+			batches[idx] = nil
+			return true
+		}
+	}
+	for _, groupKey := range w.groupKeys {
+		aggCtxs := w.aggCtxsMap[string(groupKey)]
+		row := make([]types.Datum, 0, len(w.aggFuncs))
+		for i, af := range w.aggFuncs {
+			row = append(row, af.GetPartialResult(aggCtxs[i])...)
+		}
+		idx := finalWorkerIndex(groupKey, len(e.finalWorkers))
+		if batches[idx] == nil {
+			batches[idx] = &hashAggPartialResult{}
+		}
+		batches[idx].groupKeys = append(batches[idx].groupKeys, groupKey)
+		batches[idx].rows = append(batches[idx].rows, row)
+		if len(batches[idx].rows) >= maxChunkSize && !send(idx) {
+			return nil
+		}
+	}
+	for idx, batch := range batches {
+		if batch != nil && !send(idx) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// runFinalWorker merges the partial results sent to the final worker.
+func (e *HashAggExec) runFinalWorker(w *hashAggFinalWorker, wg *sync.WaitGroup, onError func(error)) {
+	defer wg.Done()
+	for {
+		var batch *hashAggPartialResult
+		select {
+		case <-e.finishCh:
+			return
+		case result, ok := <-w.inputCh:
+			if !ok {
+				return
+			}
+			batch = result
+		}
+		for i, groupKey := range batch.groupKeys {
+			aggCtxs, err := e.getWorkerContexts(w.aggCtxsMap, &w.groupKeys, w.aggFuncs, groupKey)
+			if err != nil {
+				onError(err)
+				return
+			}
+			if err = e.updateAggCtxs(w.aggFuncs, aggCtxs, batch.rows[i]); err != nil {
+				onError(err)
+				return
+			}
+		}
+	}
+}
+
+// getWorkerContexts gets the aggregation contexts of the group from the map of a worker,
+// the contexts are created and the memory usage is tracked if the group is new.
+func (e *HashAggExec) getWorkerContexts(aggCtxsMap aggCtxsMapper, groupKeys *[][]byte, aggFuncs []aggregation.Aggregation,
+	groupKey []byte) ([]*aggregation.AggEvaluateContext, error) {
+	aggCtxs, ok := aggCtxsMap[string(groupKey)]
+	if ok {
+		return aggCtxs, nil
+	}
+	aggCtxs = make([]*aggregation.AggEvaluateContext, 0, len(aggFuncs))
+	for _, af := range aggFuncs {
+		aggCtxs = append(aggCtxs, af.CreateContext())
+	}
+	aggCtxsMap[string(groupKey)] = aggCtxs
+	*groupKeys = append(*groupKeys, groupKey)
+	// The group key is held by both the map and the slice.
+	usage := int64(2*len(groupKey)) + aggCtxsMemoryUsage(aggCtxs)
+	return aggCtxs, errors.Trace(e.memTracker.Consume(usage))
+}
+
+// nextParallelGroup returns the aggregate functions and the contexts of the next group merged by the final workers.
+func (e *HashAggExec) nextParallelGroup() ([]aggregation.Aggregation, []*aggregation.AggEvaluateContext, bool) {
+	for e.finalWorkerIdx < len(e.finalWorkers) {
+		w := e.finalWorkers[e.finalWorkerIdx]
+		if e.finalGroupIdx < len(w.groupKeys) {
+			groupKey := w.groupKeys[e.finalGroupIdx]
+			e.finalGroupIdx++
+			return w.aggFuncs, w.aggCtxsMap[string(groupKey)], true
+		}
+		e.finalWorkerIdx++
+		e.finalGroupIdx = 0
+	}
+	return nil, nil, false
+}
+
+// numParallelGroups returns the number of groups merged by the final workers.
+func (e *HashAggExec) numParallelGroups() int {
+	num := 0
+	for _, w := range e.finalWorkers {
+		num += len(w.groupKeys)
+	}
+	return num
+}
@@ -14,11 +14,15 @@
 package executor_test
 
 import (
+	"fmt"
+	"strings"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/testkit"
 )
 
@@ -46,6 +50,16 @@ func (m *MockExec) Next() (executor.Row, error) {
 	return r, nil
 }
 
+func (m *MockExec) NextChunk(chk *chunk.Chunk) error {
+	chk.Reset()
+	for ; m.curRowIdx < len(m.Rows) && chk.NumRows() < 1024; m.curRowIdx++ {
+		for i := range m.Rows[m.curRowIdx] {
+			chk.AppendDatum(i, &m.Rows[m.curRowIdx][i])
+		}
+	}
+	return nil
+}
+
 func (m *MockExec) Close() error {
 	m.curRowIdx = 0
 	return nil
@@ -275,7 +289,7 @@ func (s *testSuite) TestAggregation(c *C) {
 
 	result = tk.MustQuery("select count(*) from information_schema.columns")
 	// When adding new memory table in information_schema, please update this variable.
-	columnCountOfAllInformationSchemaTables := "742"
+	columnCountOfAllInformationSchemaTables := "783"
 	result.Check(testkit.Rows(columnCountOfAllInformationSchemaTables))
 
 	tk.MustExec("drop table if exists t1")
@@ -337,6 +351,47 @@ func (s *testSuite) TestSelectDistinct(c *C) {
 
 }
 
+func (s *testSuite) TestParallelHashAgg(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int, c double, d varchar(10))")
+	// The rows are dispatched to the partial workers in several batches.
+	values := make([]string, 0, 5000)
+	for i := 0; i < 5000; i++ {
+		if i%50 == 49 {
+			values = append(values, fmt.Sprintf("(%d, null, null, null)", i%7))
+			continue
+		}
+		values = append(values, fmt.Sprintf("(%d, %d, %d.5, 'd%d')", i%7, i, i%13, i%3))
+	}
+	tk.MustExec("insert into t values " + strings.Join(values, ", "))
+
+	queries := []string{
+		"select a, count(*), count(b), sum(b), avg(c), max(d), min(b) from t group by a",
+		"select count(*), sum(b), avg(b), max(c) from t",
+		"select d, b % 10 as m, count(*), sum(c) from t group by d, m",
+		"select a, count(b), avg(c) from (select * from t union all select * from t) k group by a",
+		"select count(*), sum(b) from (select * from t union all select * from t) k",
+		"select count(distinct a), count(distinct d), sum(distinct a) from t",
+		"select count(*), sum(b) from t where b > 10000",
+		"select a, count(*) from t where b > 10000 group by a",
+	}
+	expected := make([][][]interface{}, 0, len(queries))
+	tk.MustExec("set @@tidb_hashagg_partial_concurrency = 1")
+	tk.MustExec("set @@tidb_hashagg_final_concurrency = 1")
+	for _, sql := range queries {
+		expected = append(expected, tk.MustQuery(sql).Sort().Rows())
+	}
+	tk.MustExec("set @@tidb_hashagg_partial_concurrency = 4")
+	tk.MustExec("set @@tidb_hashagg_final_concurrency = 3")
+	for i, sql := range queries {
+		tk.MustQuery(sql).Sort().Check(expected[i])
+	}
+	tk.MustQuery("select a, count(*), sum(b) from t group by a").Sort().Check(testkit.Rows(
+		"0 715 1749300", "1 715 1749265", "2 714 1749279", "3 714 1749293", "4 714 1749307", "5 714 1749321", "6 714 1749335"))
+	tk.MustQuery("select count(*), sum(b) from t where b > 10000").Check(testkit.Rows("0 <nil>"))
+}
+
 func (s *testSuite) TestAggPushDown(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
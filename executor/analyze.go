@@ -19,6 +19,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
@@ -26,6 +27,7 @@ import (
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/sessionctx/varsutil"
 	"github.com/pingcap/tidb/statistics"
+	"github.com/pingcap/tidb/util/chunk"
 )
 
 var _ Executor = &AnalyzeExec{}
@@ -41,6 +43,8 @@ const (
 	maxRegionSampleSize = 1000
 	maxSketchSize       = 10000
 	maxBucketSize       = 256
+	cmSketchDepth       = statistics.DefaultCMSketchDepth
+	cmSketchWidth       = statistics.DefaultCMSketchWidth
 )
 
 // Schema implements the Executor Schema interface.
@@ -105,8 +109,8 @@ func (e *AnalyzeExec) Next() (Row, error) {
 		return nil, errors.Trace(err1)
 	}
 	for _, result := range results {
-		for _, hg := range result.Hist {
-			err = hg.SaveToStorage(e.ctx, result.TableID, result.Count, result.IsIndex)
+		for i, hg := range result.Hist {
+			err = statistics.SaveStatsToStorage(e.ctx, result.TableID, result.Count, result.IsIndex, hg, result.Cms[i])
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
@@ -119,6 +123,11 @@ func (e *AnalyzeExec) Next() (Row, error) {
 	return nil, nil
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *AnalyzeExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 func getBuildStatsConcurrency(ctx context.Context) (int, error) {
 	sessionVars := ctx.GetSessionVars()
 	concurrency, err := varsutil.GetSessionSystemVar(sessionVars, variable.TiDBBuildStatsConcurrency)
@@ -171,12 +180,21 @@ func (e *AnalyzeExec) analyzeColumns(task *analyzeTask) statistics.AnalyzeResult
 		return statistics.AnalyzeResult{Err: e}
 	}
 	pkID := int64(-1)
+	rs := &cmSketchRecordSet{
+		RecordSet: &recordSet{executor: task.src},
+		sc:        e.ctx.GetSessionVars().StmtCtx,
+		cms:       make([]*statistics.CMSketch, len(task.Columns)),
+	}
 	if task.PKInfo != nil {
 		pkID = task.PKInfo.ID
+		rs.offset = 1
+	}
+	for i := range rs.cms {
+		rs.cms[i] = statistics.NewCMSketch(cmSketchDepth, cmSketchWidth)
 	}
 	builder := statistics.SampleBuilder{
 		Sc:            e.ctx.GetSessionVars().StmtCtx,
-		RecordSet:     &recordSet{executor: task.src},
+		RecordSet:     rs,
 		ColLen:        len(task.Columns),
 		PkID:          pkID,
 		MaxBucketSize: maxBucketSize,
@@ -194,10 +212,12 @@ func (e *AnalyzeExec) analyzeColumns(task *analyzeTask) statistics.AnalyzeResult
 	if task.PKInfo != nil {
 		result.Count = pkBuilder.Count
 		result.Hist = []*statistics.Histogram{pkBuilder.Hist()}
+		result.Cms = []*statistics.CMSketch{nil}
 	} else {
 		result.Count = collectors[0].Count + collectors[0].NullCount
 	}
 	for i, col := range task.Columns {
+		result.Cms = append(result.Cms, rs.cms[i])
 		hg, err := statistics.BuildColumn(e.ctx, maxBucketSize, col.ID, collectors[i])
 		result.Hist = append(result.Hist, hg)
 		if err != nil && result.Err == nil {
@@ -207,13 +227,41 @@ func (e *AnalyzeExec) analyzeColumns(task *analyzeTask) statistics.AnalyzeResult
 	return result
 }
 
+// cmSketchRecordSet inserts the values of the columns into the CM sketches when the rows are read,
+// so the CM sketches are built from every row rather than the samples.
+type cmSketchRecordSet struct {
+	ast.RecordSet
+	sc *variable.StatementContext
+	// offset is the offset of the first column in the row, the PK handle comes first if it's analyzed.
+	offset int
+	cms    []*statistics.CMSketch
+}
+
+// Next implements the ast.RecordSet Next interface.
+func (rs *cmSketchRecordSet) Next() (*ast.Row, error) {
+	row, err := rs.RecordSet.Next()
+	if err != nil || row == nil {
+		return row, errors.Trace(err)
+	}
+	for i, cms := range rs.cms {
+		val := row.Data[rs.offset+i]
+		if val.IsNull() {
+			continue
+		}
+		if err = cms.InsertValue(rs.sc, val); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return row, nil
+}
+
 func (e *AnalyzeExec) analyzeIndex(task *analyzeTask) statistics.AnalyzeResult {
 	if e := task.src.Open(); e != nil {
 		return statistics.AnalyzeResult{Err: e}
 	}
-	count, hg, err := statistics.BuildIndex(e.ctx, maxBucketSize, task.indexInfo.ID, &recordSet{executor: task.src})
+	count, hg, cms, err := statistics.BuildIndex(e.ctx, maxBucketSize, task.indexInfo.ID, &recordSet{executor: task.src}, cmSketchDepth, cmSketchWidth)
 	if e := task.src.Close(); e != nil {
 		return statistics.AnalyzeResult{Err: e}
 	}
-	return statistics.AnalyzeResult{TableID: task.tableInfo.ID, Hist: []*statistics.Histogram{hg}, Count: count, IsIndex: 1, Err: err}
+	return statistics.AnalyzeResult{TableID: task.tableInfo.ID, Hist: []*statistics.Histogram{hg}, Cms: []*statistics.CMSketch{cms}, Count: count, IsIndex: 1, Err: err}
 }
@@ -19,6 +19,7 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/distsql"
 	"github.com/pingcap/tidb/expression"
@@ -30,6 +31,7 @@ import (
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/util/admin"
+	"github.com/pingcap/tidb/util/execdetails"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
 	goctx "golang.org/x/net/context"
@@ -43,6 +45,8 @@ type executorBuilder struct {
 	priority int
 	// err is set when there is error happened during Executor building process.
 	err error
+	// cteStorages maps the ID of a recursive common table expression to the storage shared with its CTETables.
+	cteStorages map[int]*cteStorage
 }
 
 func newExecutorBuilder(ctx context.Context, is infoschema.InfoSchema, priority int) *executorBuilder {
@@ -54,6 +58,16 @@ func newExecutorBuilder(ctx context.Context, is infoschema.InfoSchema, priority
 }
 
 func (b *executorBuilder) build(p plan.Plan) Executor {
+	// The collection is set by EXPLAIN ANALYZE when its target plan is built.
+	runtimeStatsColl := b.ctx.GetSessionVars().StmtCtx.RuntimeStatsColl
+	e := b.buildPlan(p)
+	if runtimeStatsColl == nil || e == nil || b.err != nil {
+		return e
+	}
+	return newRuntimeStatsExec(e, runtimeStatsColl.GetRootStats(p.ExplainID()))
+}
+
+func (b *executorBuilder) buildPlan(p plan.Plan) Executor {
 	switch v := p.(type) {
 	case nil:
 		return nil
@@ -93,10 +107,16 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildSet(v)
 	case *plan.Sort:
 		return b.buildSort(v)
+	case *plan.PhysicalWindow:
+		return b.buildWindow(v)
 	case *plan.TopN:
 		return b.buildTopN(v)
 	case *plan.Union:
 		return b.buildUnion(v)
+	case *plan.RecursiveCTE:
+		return b.buildRecursiveCTE(v)
+	case *plan.CTETable:
+		return b.buildCTETable(v)
 	case *plan.Update:
 		return b.buildUpdate(v)
 	case *plan.PhysicalUnionScan:
@@ -314,6 +334,7 @@ func (b *executorBuilder) buildInsert(v *plan.Insert) Executor {
 		GenColumns:            v.GenCols.Columns,
 		GenExprs:              v.GenCols.Exprs,
 		needFillDefaultValues: v.NeedFillDefaultValue,
+		fkChecker:             newFKChecker(b.ctx, b.is),
 	}
 	if len(v.Children()) > 0 {
 		ivs.SelectExec = b.build(v.Children()[0])
@@ -343,6 +364,7 @@ func (b *executorBuilder) buildLoadData(v *plan.LoadData) Executor {
 		Columns:    v.Columns,
 		GenColumns: v.GenCols.Columns,
 		GenExprs:   v.GenCols.Exprs,
+		fkChecker:  newFKChecker(b.ctx, b.is),
 	}
 	tableCols := tbl.Cols()
 	columns, err := insertVal.getColumns(tableCols)
@@ -396,12 +418,21 @@ func (b *executorBuilder) buildRevoke(revoke *ast.RevokeStmt) Executor {
 }
 
 func (b *executorBuilder) buildDDL(v *plan.DDL) Executor {
-	return &DDLExec{Statement: v.Statement, ctx: b.ctx, is: b.is}
+	return &DDLExec{Statement: v.Statement, ctx: b.ctx, is: b.is, viewSchema: v.ViewSchema, viewSelectText: v.ViewSelectText}
 }
 
 func (b *executorBuilder) buildExplain(v *plan.Explain) Executor {
 	exec := &ExplainExec{
 		baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+		explain:      v,
+	}
+	if v.Analyze {
+		b.ctx.GetSessionVars().StmtCtx.RuntimeStatsColl = execdetails.NewRuntimeStatsColl()
+		exec.analyzeExec = b.build(v.StmtPlan)
+		if b.err != nil {
+			return nil
+		}
+		return exec
 	}
 	exec.rows = make([]Row, 0, len(v.Rows))
 	for _, row := range v.Rows {
@@ -536,6 +567,8 @@ func (b *executorBuilder) buildHashJoin(v *plan.PhysicalHashJoin) Executor {
 		ctx:           b.ctx,
 		concurrency:   v.Concurrency,
 		defaultValues: v.DefaultValues,
+		memQuota:      b.ctx.GetSessionVars().MemQuotaHashJoin,
+		tmpDir:        config.GetGlobalConfig().TmpStoragePath,
 	}
 	if v.SmallTable == 1 {
 		e.smallFilter = v.RightConditions
@@ -676,24 +709,25 @@ func (b *executorBuilder) buildTableScan(v *plan.PhysicalTableScan) Executor {
 		handleCol = v.Schema().TblID2Handle[v.Table.ID][0]
 	}
 	e := &XSelectTableExec{
-		tableInfo:   v.Table,
-		ctx:         b.ctx,
-		startTS:     startTS,
-		supportDesc: supportDesc,
-		table:       tbl,
-		schema:      v.Schema(),
-		Columns:     v.Columns,
-		ranges:      v.Ranges,
-		desc:        v.Desc,
-		limitCount:  v.LimitCount,
-		keepOrder:   v.KeepOrder,
-		where:       v.TableConditionPBExpr,
-		aggregate:   v.Aggregated,
-		aggFuncs:    v.AggFuncsPB,
-		byItems:     v.GbyItemsPB,
-		orderByList: v.SortItemsPB,
-		handleCol:   handleCol,
-		priority:    b.priority,
+		tableInfo:    v.Table,
+		ctx:          b.ctx,
+		startTS:      startTS,
+		supportDesc:  supportDesc,
+		table:        tbl,
+		schema:       v.Schema(),
+		Columns:      v.Columns,
+		ranges:       v.Ranges,
+		desc:         v.Desc,
+		limitCount:   v.LimitCount,
+		keepOrder:    v.KeepOrder,
+		where:        v.TableConditionPBExpr,
+		aggregate:    v.Aggregated,
+		aggFuncs:     v.AggFuncsPB,
+		byItems:      v.GbyItemsPB,
+		orderByList:  v.SortItemsPB,
+		handleCol:    handleCol,
+		partitionIDs: v.PartitionIDs,
+		priority:     b.priority,
 	}
 	return e
 }
@@ -731,6 +765,7 @@ func (b *executorBuilder) buildIndexScan(v *plan.PhysicalIndexScan) Executor {
 		aggFuncs:             v.AggFuncsPB,
 		byItems:              v.GbyItemsPB,
 		handleCol:            handleCol,
+		partitionIDs:         v.PartitionIDs,
 		priority:             b.priority,
 	}
 	vars := b.ctx.GetSessionVars()
@@ -768,6 +803,8 @@ func (b *executorBuilder) buildSort(v *plan.Sort) Executor {
 		baseExecutor: newBaseExecutor(v.Schema(), b.ctx, b.build(v.Children()[0])),
 		ByItems:      v.ByItems,
 		schema:       v.Schema(),
+		memQuota:     b.ctx.GetSessionVars().MemQuotaSort,
+		tmpDir:       config.GetGlobalConfig().TmpStoragePath,
 	}
 	if v.ExecLimit != nil {
 		return &TopNExec{
@@ -778,11 +815,24 @@ func (b *executorBuilder) buildSort(v *plan.Sort) Executor {
 	return &sortExec
 }
 
+func (b *executorBuilder) buildWindow(v *plan.PhysicalWindow) Executor {
+	return &WindowExec{
+		baseExecutor:    newBaseExecutor(v.Schema(), b.ctx, b.build(v.Children()[0])),
+		StmtCtx:         b.ctx.GetSessionVars().StmtCtx,
+		WindowFuncDescs: v.WindowFuncDescs,
+		PartitionBy:     v.PartitionBy,
+		OrderBy:         v.OrderBy,
+		Frame:           v.Frame,
+	}
+}
+
 func (b *executorBuilder) buildTopN(v *plan.TopN) Executor {
 	sortExec := SortExec{
 		baseExecutor: newBaseExecutor(v.Schema(), b.ctx, b.build(v.Children()[0])),
 		ByItems:      v.ByItems,
 		schema:       v.Schema(),
+		memQuota:     b.ctx.GetSessionVars().MemQuotaSort,
+		tmpDir:       config.GetGlobalConfig().TmpStoragePath,
 	}
 	return &TopNExec{
 		SortExec: sortExec,
@@ -868,6 +918,38 @@ func (b *executorBuilder) buildUnion(v *plan.Union) Executor {
 	return e
 }
 
+func (b *executorBuilder) buildRecursiveCTE(v *plan.RecursiveCTE) Executor {
+	storage := &cteStorage{}
+	if b.cteStorages == nil {
+		b.cteStorages = make(map[int]*cteStorage)
+	}
+	b.cteStorages[v.ID()] = storage
+	seedExec := b.build(v.Children()[0])
+	recursiveExec := b.build(v.Children()[1])
+	if b.err != nil {
+		return nil
+	}
+	e := &RecursiveCTEExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx, seedExec, recursiveExec),
+		distinct:     v.Distinct,
+		maxRecursion: b.ctx.GetSessionVars().CTEMaxRecursionDepth,
+		storage:      storage,
+	}
+	return e
+}
+
+func (b *executorBuilder) buildCTETable(v *plan.CTETable) Executor {
+	storage, ok := b.cteStorages[v.CTEID]
+	if !ok {
+		b.err = errors.Errorf("recursive common table expression %d not found", v.CTEID)
+		return nil
+	}
+	return &CTETableExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+		storage:      storage,
+	}
+}
+
 func (b *executorBuilder) buildUpdate(v *plan.Update) Executor {
 	tblID2table := make(map[int64]table.Table)
 	for id := range v.Schema().TblID2Handle {
@@ -878,6 +960,7 @@ func (b *executorBuilder) buildUpdate(v *plan.Update) Executor {
 		SelectExec:   b.build(v.Children()[0]),
 		OrderedList:  v.OrderedList,
 		tblID2table:  tblID2table,
+		fkChecker:    newFKChecker(b.ctx, b.is),
 		IgnoreErr:    v.IgnoreErr,
 	}
 }
@@ -893,6 +976,7 @@ func (b *executorBuilder) buildDelete(v *plan.Delete) Executor {
 		Tables:       v.Tables,
 		IsMultiTable: v.IsMultiTable,
 		tblID2Table:  tblID2table,
+		fkChecker:    newFKChecker(b.ctx, b.is),
 	}
 }
 
@@ -1041,6 +1125,7 @@ func (b *executorBuilder) buildAnalyzeIndexPushdown(task plan.AnalyzeIndexTask)
 		BucketSize: maxBucketSize,
 		NumColumns: int32(len(task.IndexInfo.Columns)),
 	}
+	e.cmsSrc = b.buildIndexScanForAnalyze(task.TableInfo, task.IndexInfo)
 	return e
 }
 
@@ -1073,6 +1158,9 @@ func (b *executorBuilder) buildAnalyzeColumnsPushdown(task plan.AnalyzeColumnsTa
 		ColumnsInfo: distsql.ColumnsToProto(cols, task.TableInfo.PKIsHandle),
 	}
 	b.err = setPBColumnsDefaultValue(b.ctx, e.analyzePB.ColReq.ColumnsInfo, cols)
+	if b.err == nil && len(task.ColsInfo) > 0 {
+		e.cmsSrc = b.buildTableScanForAnalyze(task.TableInfo, nil, task.ColsInfo)
+	}
 	return e
 }
 
@@ -1159,16 +1247,18 @@ func (b *executorBuilder) buildTableReader(v *plan.PhysicalTableReader) Executor
 	ts := v.TablePlans[0].(*plan.PhysicalTableScan)
 	table, _ := b.is.TableByID(ts.Table.ID)
 	e := &TableReaderExecutor{
-		ctx:       b.ctx,
-		schema:    v.Schema(),
-		dagPB:     dagReq,
-		tableID:   ts.Table.ID,
-		table:     table,
-		keepOrder: ts.KeepOrder,
-		desc:      ts.Desc,
-		ranges:    ts.Ranges,
-		columns:   ts.Columns,
-		priority:  b.priority,
+		ctx:          b.ctx,
+		schema:       v.Schema(),
+		dagPB:        dagReq,
+		tableID:      ts.Table.ID,
+		partitionIDs: ts.PartitionIDs,
+		table:        table,
+		keepOrder:    ts.KeepOrder,
+		desc:         ts.Desc,
+		ranges:       ts.Ranges,
+		columns:      ts.Columns,
+		priority:     b.priority,
+		copStats:     b.copRuntimeStats(v.TablePlans),
 	}
 
 	for i := range v.Schema().Columns {
@@ -1186,17 +1276,19 @@ func (b *executorBuilder) buildIndexReader(v *plan.PhysicalIndexReader) Executor
 	is := v.IndexPlans[0].(*plan.PhysicalIndexScan)
 	table, _ := b.is.TableByID(is.Table.ID)
 	e := &IndexReaderExecutor{
-		ctx:       b.ctx,
-		schema:    v.Schema(),
-		dagPB:     dagReq,
-		tableID:   is.Table.ID,
-		table:     table,
-		index:     is.Index,
-		keepOrder: !is.OutOfOrder,
-		desc:      is.Desc,
-		ranges:    is.Ranges,
-		columns:   is.Columns,
-		priority:  b.priority,
+		ctx:          b.ctx,
+		schema:       v.Schema(),
+		dagPB:        dagReq,
+		tableID:      is.Table.ID,
+		partitionIDs: is.PartitionIDs,
+		table:        table,
+		index:        is.Index,
+		keepOrder:    !is.OutOfOrder,
+		desc:         is.Desc,
+		ranges:       is.Ranges,
+		columns:      is.Columns,
+		priority:     b.priority,
+		copStats:     b.copRuntimeStats(v.IndexPlans),
 	}
 
 	for _, col := range v.OutputColumns {
@@ -1251,6 +1343,7 @@ func (b *executorBuilder) buildIndexLookUpReader(v *plan.PhysicalIndexLookUpRead
 		schema:            v.Schema(),
 		dagPB:             indexReq,
 		tableID:           is.Table.ID,
+		partitionIDs:      is.PartitionIDs,
 		table:             table,
 		index:             is.Index,
 		keepOrder:         !is.OutOfOrder,
@@ -1261,6 +1354,18 @@ func (b *executorBuilder) buildIndexLookUpReader(v *plan.PhysicalIndexLookUpRead
 		handleCol:         handleCol,
 		priority:          b.priority,
 		tableReaderSchema: tableReaderSchema,
+		indexCopStats:     b.copRuntimeStats(v.IndexPlans),
+		tableCopStats:     b.copRuntimeStats(v.TablePlans),
 	}
 	return e
 }
+
+// copRuntimeStats returns the statistics to record the coprocessor tasks of the pushed down plans,
+// which are attached to the top plan. It returns nil if the runtime statistics are not collected.
+func (b *executorBuilder) copRuntimeStats(plans []plan.PhysicalPlan) *execdetails.CopRuntimeStats {
+	runtimeStatsColl := b.ctx.GetSessionVars().StmtCtx.RuntimeStatsColl
+	if runtimeStatsColl == nil {
+		return nil
+	}
+	return runtimeStatsColl.GetCopStats(plans[len(plans)-1].ExplainID())
+}
@@ -0,0 +1,186 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// cteStorage holds the rows produced by the last iteration of a recursive common table expression.
+// It is shared by the RecursiveCTEExec and the CTETableExecs in its recursive part.
+type cteStorage struct {
+	rows []Row
+}
+
+// RecursiveCTEExec evaluates a recursive common table expression. It returns the rows of the seed part first,
+// then runs the recursive part on the rows produced by the last iteration repeatedly, until an iteration
+// produces no row.
+type RecursiveCTEExec struct {
+	baseExecutor
+
+	distinct     bool
+	maxRecursion int
+	storage      *cteStorage
+
+	inRecursive bool
+	// iteration is the number of the iterations of the recursive part started so far, an error is returned
+	// if the iteration exceeding maxRecursion produces any row.
+	iteration int
+	// iterRows are the rows produced by the current iteration.
+	iterRows []Row
+	// seen stores the encoded rows returned so far, it's used to remove duplicated rows for UNION DISTINCT.
+	seen map[string]struct{}
+}
+
+// Open implements the Executor Open interface.
+func (e *RecursiveCTEExec) Open() error {
+	e.inRecursive = false
+	e.iteration = 0
+	e.iterRows = nil
+	e.storage.rows = nil
+	if e.distinct {
+		e.seen = make(map[string]struct{})
+	}
+	return errors.Trace(e.baseExecutor.Open())
+}
+
+// Close implements the Executor Close interface.
+func (e *RecursiveCTEExec) Close() error {
+	e.iterRows = nil
+	e.storage.rows = nil
+	e.seen = nil
+	return errors.Trace(e.baseExecutor.Close())
+}
+
+// Next implements the Executor Next interface.
+func (e *RecursiveCTEExec) Next() (Row, error) {
+	for {
+		child := e.children[0]
+		if e.inRecursive {
+			child = e.children[1]
+		}
+		row, err := child.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			if err = e.nextIteration(); err != nil {
+				return nil, errors.Trace(err)
+			}
+			if len(e.storage.rows) == 0 {
+				return nil, nil
+			}
+			continue
+		}
+		if e.iteration > e.maxRecursion {
+			return nil, ErrCTEMaxRecursionDepth.GenByArgs(e.iteration)
+		}
+		row, err = e.convertRow(row)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if e.distinct {
+			duplicated, err := e.checkDuplicated(row)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if duplicated {
+				continue
+			}
+		}
+		e.iterRows = append(e.iterRows, row)
+		return row, nil
+	}
+}
+
+// nextIteration makes the rows of current iteration as the input of the recursive part and restarts it.
+func (e *RecursiveCTEExec) nextIteration() error {
+	e.inRecursive = true
+	e.storage.rows, e.iterRows = e.iterRows, nil
+	if len(e.storage.rows) == 0 {
+		return nil
+	}
+	e.iteration++
+	if err := e.children[1].Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.children[1].Open())
+}
+
+// convertRow converts the row to the field types of the seed part, because the recursive part
+// may produce values of different types. Like writing the values into the columns of a table,
+// the truncation errors are returned in strict sql_mode and treated as warnings otherwise.
+func (e *RecursiveCTEExec) convertRow(row Row) (Row, error) {
+	sessVars := e.ctx.GetSessionVars()
+	sc := sessVars.StmtCtx
+	// The statement context of the select statement treats the truncation errors as warnings,
+	// so a strict one is used to get them.
+	strictSC := &variable.StatementContext{TimeZone: sc.TimeZone}
+	newRow := make(Row, len(row))
+	for i := range row {
+		val, err := row[i].ConvertTo(strictSC, e.schema.Columns[i].RetType)
+		if err != nil {
+			if sessVars.StrictSQLMode || !isTruncateError(err) {
+				return nil, errors.Trace(err)
+			}
+			sc.AppendWarning(err)
+		}
+		newRow[i] = val
+	}
+	return newRow, nil
+}
+
+func isTruncateError(err error) bool {
+	return terror.ErrorEqual(err, types.ErrDataTooLong) || terror.ErrorEqual(err, types.ErrTruncated)
+}
+
+func (e *RecursiveCTEExec) checkDuplicated(row Row) (bool, error) {
+	key, err := codec.EncodeValue([]byte{}, []types.Datum(row)...)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if _, ok := e.seen[string(key)]; ok {
+		return true, nil
+	}
+	e.seen[string(key)] = struct{}{}
+	return false, nil
+}
+
+// CTETableExec returns the rows produced by the last iteration of a recursive common table expression.
+type CTETableExec struct {
+	baseExecutor
+
+	storage *cteStorage
+	cursor  int
+}
+
+// Open implements the Executor Open interface.
+func (e *CTETableExec) Open() error {
+	e.cursor = 0
+	return nil
+}
+
+// Next implements the Executor Next interface.
+func (e *CTETableExec) Next() (Row, error) {
+	if e.cursor >= len(e.storage.rows) {
+		return nil, nil
+	}
+	row := e.storage.rows[e.cursor]
+	e.cursor++
+	return row, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor_test
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/testkit"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func (s *testSuite) TestCommonTableExpression(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int, parent int, name varchar(10))")
+	tk.MustExec("insert t values (1, NULL, 'root'), (2, 1, 'a'), (3, 1, 'b'), (4, 2, 'c'), (5, 4, 'd'), (6, NULL, 'other')")
+
+	// Non-recursive common table expressions.
+	result := tk.MustQuery("with cte as (select id, name from t where parent = 1) select name from cte order by id")
+	result.Check(testkit.Rows("a", "b"))
+	result = tk.MustQuery("with cte (x, y) as (select id, parent from t) select x from cte where y is null order by x")
+	result.Check(testkit.Rows("1", "6"))
+	result = tk.MustQuery("with c1 as (select id from t where id < 4), c2 as (select id * 10 as id from c1) select * from c2 order by id")
+	result.Check(testkit.Rows("10", "20", "30"))
+	result = tk.MustQuery("with cte as (select id from t) select a.id from cte a join cte b on a.id = b.id + 4 order by a.id")
+	result.Check(testkit.Rows("5", "6"))
+	result = tk.MustQuery("select (with cte as (select max(id) as m from t) select m from cte)")
+	result.Check(testkit.Rows("6"))
+	result = tk.MustQuery("select * from (with cte as (select 1 as a) select a from cte union all select 2) s order by a")
+	result.Check(testkit.Rows("1", "2"))
+	tk.MustExec("with cte as (select 1) select * from cte")
+
+	// Recursive common table expressions.
+	result = tk.MustQuery("with recursive cte (n) as (select 1 union all select n + 1 from cte where n < 5) select * from cte")
+	result.Check(testkit.Rows("1", "2", "3", "4", "5"))
+	result = tk.MustQuery(`with recursive tree (id, name, depth) as (
+		select id, name, 0 from t where parent is null and id = 1
+		union all
+		select t.id, t.name, tree.depth + 1 from t join tree on t.parent = tree.id)
+		select name, depth from tree order by depth, name`)
+	result.Check(testkit.Rows("root 0", "a 1", "b 1", "c 2", "d 3"))
+	result = tk.MustQuery("with recursive cte (n) as (select 1 union select n % 3 + 1 from cte) select * from cte order by n")
+	result.Check(testkit.Rows("1", "2", "3"))
+	result = tk.MustQuery("with recursive cte (n, s) as (select 1, 1 union all select n + 1, s * 2 from cte where n < 4) select max(n), sum(s) from cte")
+	result.Check(testkit.Rows("4 15"))
+	result = tk.MustQuery("with recursive cte (n) as (select 1 union all select n + 1 from cte where n < 3) select a.n, b.n from cte a join cte b on a.n = b.n order by a.n")
+	result.Check(testkit.Rows("1 1", "2 2", "3 3"))
+
+	// The iterations of the recursive part are limited by cte_max_recursion_depth.
+	tk.MustExec("set @@cte_max_recursion_depth = 10")
+	result = tk.MustQuery("with recursive cte (n) as (select 1 union all select n + 1 from cte where n < 11) select count(*) from cte")
+	result.Check(testkit.Rows("11"))
+	rs, err := tk.Exec("with recursive cte (n) as (select 1 union all select n + 1 from cte where n < 12) select count(*) from cte")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(terror.ErrorEqual(err, executor.ErrCTEMaxRecursionDepth), IsTrue, Commentf("err %v", err))
+	rs, err = tk.Exec("with recursive cte (n) as (select 1 union all select n + 1 from cte) select * from cte")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(terror.ErrorEqual(err, executor.ErrCTEMaxRecursionDepth), IsTrue, Commentf("err %v", err))
+	tk.MustExec("set @@cte_max_recursion_depth = 1000")
+
+	// The rows of the recursive part are converted to the types of the seed part.
+	rs, err = tk.Exec("with recursive r (n) as (select 'a' union all select concat(n, 'b') from r where length(n) < 4) select * from r")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(terror.ErrorEqual(err, types.ErrDataTooLong), IsTrue, Commentf("err %v", err))
+	tk.MustExec("set sql_mode = ''")
+	result = tk.MustQuery("with recursive r (n) as (select 'a' union all select concat(n, 'b') from r where length(n) < 4) select * from r limit 3")
+	result.Check(testkit.Rows("a", "a", "a"))
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Greater, uint16(0))
+	tk.MustExec("set sql_mode = 'STRICT_TRANS_TABLES,NO_ENGINE_SUBSTITUTION'")
+	result = tk.MustQuery("with recursive r (n) as (select cast('a' as char(4)) union all select concat(n, 'b') from r where length(n) < 4) select * from r")
+	result.Check(testkit.Rows("a", "ab", "abb", "abbb"))
+
+	_, err = tk.Exec("with recursive cte (n) as (select n from cte) select * from cte")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("with cte (a, b) as (select 1) select * from cte")
+	c.Assert(err, NotNil)
+}
@@ -23,9 +23,13 @@ import (
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/perfschema"
+	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/util/auth"
+	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -36,6 +40,10 @@ type DDLExec struct {
 	ctx       context.Context
 	is        infoschema.InfoSchema
 	done      bool
+	// viewSchema is the schema of the SELECT statement in CREATE VIEW.
+	viewSchema *expression.Schema
+	// viewSelectText is the text of the SELECT statement in CREATE VIEW with the wildcards expanded.
+	viewSelectText string
 }
 
 // Schema implements the Executor Schema interface.
@@ -56,6 +64,8 @@ func (e *DDLExec) Next() (Row, error) {
 		err = e.executeCreateDatabase(x)
 	case *ast.CreateTableStmt:
 		err = e.executeCreateTable(x)
+	case *ast.CreateViewStmt:
+		err = e.executeCreateView(x)
 	case *ast.CreateIndexStmt:
 		err = e.executeCreateIndex(x)
 	case *ast.DropDatabaseStmt:
@@ -85,6 +95,11 @@ func (e *DDLExec) Next() (Row, error) {
 	return nil, nil
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *DDLExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 // Close implements the Executor Close interface.
 func (e *DDLExec) Close() error {
 	return nil
@@ -97,6 +112,13 @@ func (e *DDLExec) Open() error {
 
 func (e *DDLExec) executeTruncateTable(s *ast.TruncateTableStmt) error {
 	ident := ast.Ident{Schema: s.Table.Schema, Name: s.Table.Name}
+	if ident.Schema.L == "" {
+		ident.Schema = model.NewCIStr(e.ctx.GetSessionVars().CurrentDB)
+	}
+	// The in-memory events tables of performance_schema are reset by TRUNCATE TABLE, same as MySQL.
+	if strings.EqualFold(ident.Schema.L, perfschema.Name) && perfschema.TruncateTable(ident.Name.O) {
+		return nil
+	}
 	err := sessionctx.GetDomain(e.ctx).DDL().TruncateTable(e.ctx, ident)
 	return errors.Trace(err)
 }
@@ -138,7 +160,7 @@ func (e *DDLExec) executeCreateTable(s *ast.CreateTableStmt) error {
 	ident := ast.Ident{Schema: s.Table.Schema, Name: s.Table.Name}
 	var err error
 	if s.ReferTable == nil {
-		err = sessionctx.GetDomain(e.ctx).DDL().CreateTable(e.ctx, ident, s.Cols, s.Constraints, s.Options)
+		err = sessionctx.GetDomain(e.ctx).DDL().CreateTable(e.ctx, ident, s.Cols, s.Constraints, s.Options, s.Partition)
 	} else {
 		referIdent := ast.Ident{Schema: s.ReferTable.Schema, Name: s.ReferTable.Name}
 		err = sessionctx.GetDomain(e.ctx).DDL().CreateTableWithLike(e.ctx, ident, referIdent)
@@ -152,6 +174,58 @@ func (e *DDLExec) executeCreateTable(s *ast.CreateTableStmt) error {
 	return errors.Trace(err)
 }
 
+func (e *DDLExec) executeCreateView(s *ast.CreateViewStmt) error {
+	ident := ast.Ident{Schema: s.ViewName.Schema, Name: s.ViewName.Name}
+	definer := s.Definer
+	if definer == nil && e.ctx.GetSessionVars().User != nil {
+		user := *e.ctx.GetSessionVars().User
+		definer = &user
+	}
+	if err := e.checkViewDefiner(definer); err != nil {
+		return errors.Trace(err)
+	}
+	selectCols := make([]model.CIStr, 0, e.viewSchema.Len())
+	for _, col := range e.viewSchema.Columns {
+		selectCols = append(selectCols, col.ColName)
+	}
+	cols := s.Cols
+	if len(cols) == 0 {
+		cols = selectCols
+	}
+	fieldTypes := make([]*types.FieldType, 0, e.viewSchema.Len())
+	for _, col := range e.viewSchema.Columns {
+		fieldTypes = append(fieldTypes, col.RetType)
+	}
+	viewInfo := &model.ViewInfo{
+		Algorithm:   s.Algorithm,
+		Definer:     definer,
+		Security:    s.Security,
+		SelectStmt:  e.viewSelectText,
+		CheckOption: s.CheckOption,
+		Cols:        cols,
+		SelectCols:  selectCols,
+	}
+	err := sessionctx.GetDomain(e.ctx).DDL().CreateView(e.ctx, ident, viewInfo, fieldTypes, s.OrReplace)
+	return errors.Trace(err)
+}
+
+// checkViewDefiner checks that the current user can create a view with the definer. As MySQL does, a definer
+// other than the current user requires the SUPER privilege, otherwise any user could create a
+// SQL SECURITY DEFINER view reading the tables of a more privileged account.
+func (e *DDLExec) checkViewDefiner(definer *auth.UserIdentity) error {
+	user := e.ctx.GetSessionVars().User
+	if definer == nil || user == nil {
+		return nil
+	}
+	if definer.Username == user.Username && strings.EqualFold(definer.Hostname, user.Hostname) {
+		return nil
+	}
+	if pm := privilege.GetPrivilegeManager(e.ctx); pm != nil && !pm.RequestVerification("", "", "", mysql.SuperPriv) {
+		return ErrSpecificAccessDenied.GenByArgs("SUPER")
+	}
+	return nil
+}
+
 func (e *DDLExec) executeCreateIndex(s *ast.CreateIndexStmt) error {
 	ident := ast.Ident{Schema: s.Table.Schema, Name: s.Table.Name}
 	err := sessionctx.GetDomain(e.ctx).DDL().CreateIndex(e.ctx, ident, s.Unique, model.NewCIStr(s.IndexName), s.IndexColNames, s.IndexOption)
@@ -194,7 +268,7 @@ func (e *DDLExec) executeDropTable(s *ast.DropTableStmt) error {
 			notExistTables = append(notExistTables, fullti.String())
 			continue
 		}
-		_, err := e.is.TableByName(tn.Schema, tn.Name)
+		tbl, err := e.is.TableByName(tn.Schema, tn.Name)
 		if err != nil && infoschema.ErrTableNotExists.Equal(err) {
 			notExistTables = append(notExistTables, fullti.String())
 			continue
@@ -202,7 +276,19 @@ func (e *DDLExec) executeDropTable(s *ast.DropTableStmt) error {
 			return errors.Trace(err)
 		}
 
-		err = sessionctx.GetDomain(e.ctx).DDL().DropTable(e.ctx, fullti)
+		if s.IsView {
+			if !tbl.Meta().IsView() {
+				return infoschema.ErrWrongObject.GenByArgs(tn.Schema, tn.Name, "VIEW")
+			}
+			err = sessionctx.GetDomain(e.ctx).DDL().DropView(e.ctx, fullti)
+		} else {
+			if tbl.Meta().IsView() {
+				// Like MySQL, a view is an unknown table for DROP TABLE.
+				notExistTables = append(notExistTables, fullti.String())
+				continue
+			}
+			err = sessionctx.GetDomain(e.ctx).DDL().DropTable(e.ctx, fullti)
+		}
 		if infoschema.ErrDatabaseNotExists.Equal(err) || infoschema.ErrTableNotExists.Equal(err) {
 			notExistTables = append(notExistTables, fullti.String())
 		} else if err != nil {
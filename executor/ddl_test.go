@@ -18,9 +18,12 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ddl"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -122,6 +125,204 @@ func (s *testSuite) TestCreateDropTable(c *C) {
 	tk.MustExec("drop table drop_test")
 }
 
+func (s *testSuite) TestCreateView(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists view_t")
+	tk.MustExec("create table view_t (a int, b int)")
+	tk.MustExec("insert view_t values (1, 2), (3, 4)")
+	tk.MustExec("create view view_v as select a, a + b from view_t where a > 1")
+	tk.MustQuery("select * from view_v").Check(testkit.Rows("3 7"))
+	tk.MustQuery("select `a + b` from view_v").Check(testkit.Rows("7"))
+	tk.MustExec("create view view_v2 (x, y) as select * from view_t")
+	tk.MustQuery("select y from view_v2 where x = 1").Check(testkit.Rows("2"))
+	tk.MustQuery("select view_v.a, view_v2.y from view_v join view_v2 on view_v.a = view_v2.x").Check(testkit.Rows("3 4"))
+
+	// The view column list must match the select list.
+	_, err := tk.Exec("create view view_v3 (x) as select a, b from view_t")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("create view view_v3 (x, x) as select a, b from view_t")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("create view view_v as select 1")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("create view view_t as select 1")
+	c.Assert(err, NotNil)
+
+	// Views are not updatable.
+	_, err = tk.Exec("insert into view_v values (1, 1)")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("update view_v2 set x = 1")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("delete from view_v2")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("delete view_v2 from view_v2, view_t")
+	c.Assert(err, NotNil)
+	// Views which are only read by an UPDATE or DELETE are allowed.
+	tk.MustExec("create table view_t2 (a int)")
+	tk.MustExec("insert into view_t2 values (1), (2), (3)")
+	tk.MustExec("update view_t2 set a = a + 10 where a in (select x from view_v2)")
+	tk.MustQuery("select a from view_t2").Sort().Check(testkit.Rows("11", "13", "2"))
+	tk.MustExec("delete from view_t2 where a in (select x + 10 from view_v2)")
+	tk.MustQuery("select a from view_t2").Check(testkit.Rows("2"))
+	tk.MustExec("delete view_t2 from view_t2, view_v2 where view_t2.a = view_v2.y")
+	tk.MustQuery("select a from view_t2").Check(testkit.Rows())
+	tk.MustExec("drop table view_t2")
+
+	tk.MustExec("create or replace definer = 'root'@'%' sql security invoker view view_v as select b from view_t")
+	tk.MustQuery("select * from view_v").Check(testkit.Rows("2", "4"))
+	tk.MustQuery("show create view view_v").Check(testkit.Rows(
+		"view_v CREATE ALGORITHM=UNDEFINED DEFINER=`root`@`%` SQL SECURITY INVOKER VIEW `view_v` (`b`) AS select b from view_t  "))
+	tk.MustQuery("select table_name, table_type from information_schema.tables where table_schema = 'test' and table_name like 'view_%'").Sort().Check(testkit.Rows(
+		"view_t BASE TABLE", "view_v VIEW", "view_v2 VIEW"))
+	tk.MustQuery("select table_name, view_definition, check_option from information_schema.views where table_schema = 'test'").Sort().Check(testkit.Rows(
+		"view_v select b from view_t NONE", "view_v2 select `view_t`.`a`, `view_t`.`b` from view_t NONE"))
+
+	// A view is not a base table and vice versa.
+	_, err = tk.Exec("drop view view_t")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("drop table view_v")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("truncate table view_v")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("alter table view_v add column c int")
+	c.Assert(err, NotNil)
+
+	// Recursive views are detected on expansion.
+	tk.MustExec("create view view_r1 as select 1 as a")
+	tk.MustExec("create view view_r2 as select a from view_r1")
+	tk.MustExec("create or replace view view_r1 as select a from view_r2")
+	_, err = tk.Exec("select * from view_r1")
+	c.Assert(err, NotNil)
+	tk.MustExec("drop view view_r1, view_r2")
+
+	// The wildcards are expanded when the view is created, so the view is unchanged by the new columns.
+	tk.MustExec("create view view_w as select * from view_t")
+	tk.MustExec("create view view_w2 as select t.*, 1 as c from view_t as t join view_t as s on t.a = s.a")
+	tk.MustQuery("select view_definition from information_schema.views where table_name = 'view_w2'").Check(testkit.Rows(
+		"select `t`.`a`, `t`.`b`, 1 as c from view_t as t join view_t as s on t.a = s.a"))
+	tk.MustExec("alter table view_t add column c int default 5")
+	tk.MustQuery("select * from view_w where a = 1").Check(testkit.Rows("1 2"))
+	tk.MustQuery("select * from view_w2 where a = 1").Check(testkit.Rows("1 2 1"))
+	// A view which refers to a dropped column becomes invalid instead of returning another column.
+	tk.MustExec("alter table view_t drop column b")
+	_, err = tk.Exec("select * from view_w")
+	c.Assert(terror.ErrorEqual(err, plan.ErrViewInvalid), IsTrue, Commentf("err %v", err))
+	tk.MustExec("drop view view_w, view_w2")
+
+	// A view which refers to a dropped table becomes invalid.
+	tk.MustExec("drop table view_t")
+	_, err = tk.Exec("select * from view_v")
+	c.Assert(err, NotNil)
+	tk.MustExec("drop view view_v, view_v2")
+	tk.MustExec("drop view if exists view_v")
+	_, err = tk.Exec("drop view view_v")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestPartitionTable(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists part_r, part_h, part_n")
+	tk.MustExec(`create table part_r (a int primary key, b int) partition by range (a) (
+		partition p0 values less than (10),
+		partition p1 values less than (20),
+		partition p2 values less than (30))`)
+	tk.MustQuery("show create table part_r").Check(testkit.Rows("part_r CREATE TABLE `part_r` (\n" +
+		"  `a` int(11) NOT NULL,\n" +
+		"  `b` int(11) DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`a`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin\n" +
+		"PARTITION BY RANGE (a) (\n" +
+		"  PARTITION `p0` VALUES LESS THAN (10),\n" +
+		"  PARTITION `p1` VALUES LESS THAN (20),\n" +
+		"  PARTITION `p2` VALUES LESS THAN (30)\n" +
+		")"))
+	tk.MustExec("insert part_r values (1, 1), (11, 2), (21, 3), (5, 4)")
+	tk.MustQuery("select * from part_r order by a").Check(testkit.Rows("1 1", "5 4", "11 2", "21 3"))
+	_, err := tk.Exec("insert part_r values (30, 5)")
+	c.Assert(table.ErrNoPartitionForGivenValue.Equal(err), IsTrue)
+
+	// The partitions which can't contain the rows are pruned.
+	tk.MustQuery("select a from part_r where a >= 11 and a < 25").Check(testkit.Rows("11", "21"))
+	tk.MustQuery("select a from part_r where a > 100").Check(testkit.Rows())
+	tk.MustQuery("explain select * from part_r where a = 15").Check(testkit.Rows(
+		"TableScan_4   cop table:part_r, partition:p1, range:[15,15], keep order:false 10",
+		"TableReader_5   root data:TableScan_4 10",
+	))
+	// The rows are read from the partitions out of order.
+	tk.MustQuery("select a from part_r order by a desc limit 2").Check(testkit.Rows("21", "11"))
+
+	// A row is moved to another partition when its partition column is updated.
+	tk.MustExec("update part_r set a = 25 where a = 1")
+	tk.MustQuery("select a from part_r where a > 20 order by a").Check(testkit.Rows("21", "25"))
+	tk.MustQuery("select b from part_r where a < 10").Check(testkit.Rows("4"))
+	tk.MustExec("delete from part_r where a = 21")
+	tk.MustQuery("select a from part_r order by a").Check(testkit.Rows("5", "11", "25"))
+
+	tk.MustExec("alter table part_r add partition (partition p3 values less than (40), partition p4 values less than maxvalue)")
+	tk.MustExec("insert part_r values (35, 5), (1000, 6)")
+	tk.MustQuery("select a from part_r where a >= 30 order by a").Check(testkit.Rows("35", "1000"))
+	_, err = tk.Exec("alter table part_r add partition (partition p5 values less than (50))")
+	c.Assert(ddl.ErrPartitionMaxvalue.Equal(err), IsTrue)
+	tk.MustExec("alter table part_r truncate partition p2")
+	tk.MustQuery("select a from part_r order by a").Check(testkit.Rows("5", "11", "35", "1000"))
+	tk.MustExec("alter table part_r drop partition p3, p4")
+	tk.MustQuery("select a from part_r order by a").Check(testkit.Rows("5", "11"))
+	_, err = tk.Exec("alter table part_r add partition (partition p3 values less than (25))")
+	c.Assert(ddl.ErrRangeNotIncreasing.Equal(err), IsTrue)
+	_, err = tk.Exec("alter table part_r add partition (partition p0 values less than (50))")
+	c.Assert(ddl.ErrSameNamePartition.Equal(err), IsTrue)
+	_, err = tk.Exec("alter table part_r drop partition p9")
+	c.Assert(ddl.ErrDropPartitionNonExistent.Equal(err), IsTrue)
+	tk.MustExec("alter table part_r drop partition p1, p2")
+	_, err = tk.Exec("alter table part_r drop partition p0")
+	c.Assert(ddl.ErrDropLastPartition.Equal(err), IsTrue)
+	tk.MustExec("truncate table part_r")
+	tk.MustQuery("select * from part_r").Check(testkit.Rows())
+
+	tk.MustExec("create table part_h (a int, b int, unique key (a)) partition by hash (a) partitions 3")
+	tk.MustQuery("show create table part_h").Check(testkit.Rows("part_h CREATE TABLE `part_h` (\n" +
+		"  `a` int(11) DEFAULT NULL,\n" +
+		"  `b` int(11) DEFAULT NULL,\n" +
+		"  UNIQUE KEY `a` (`a`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin\n" +
+		"PARTITION BY HASH (a) PARTITIONS 3"))
+	tk.MustExec("insert part_h values (1, 1), (2, 2), (3, 3), (-4, 4), (null, 5)")
+	tk.MustQuery("select b from part_h where a = -4").Check(testkit.Rows("4"))
+	tk.MustQuery("select b from part_h where a is null").Check(testkit.Rows("5"))
+	tk.MustQuery("select b from part_h where a in (1, 3) order by b").Check(testkit.Rows("1", "3"))
+	tk.MustQuery("select a from part_h order by a").Check(testkit.Rows("<nil>", "-4", "1", "2", "3"))
+	_, err = tk.Exec("insert part_h values (1, 5)")
+	c.Assert(err, NotNil)
+	tk.MustExec("alter table part_h truncate partition p1")
+	tk.MustQuery("select a from part_h order by a").Check(testkit.Rows("<nil>", "2", "3"))
+	_, err = tk.Exec("alter table part_h add partition (partition p3 values less than (10))")
+	c.Assert(ddl.ErrOnlyOnRangeListPartition.Equal(err), IsTrue)
+	// The index is backfilled in every partition.
+	tk.MustExec("create index idx_b on part_h (b)")
+	tk.MustQuery("select a from part_h use index (idx_b) where b >= 2 order by b").Check(testkit.Rows("2", "3", "<nil>"))
+	tk.MustExec("alter table part_h add unique index idx_ab (a, b)")
+	tk.MustQuery("select b from part_h use index (idx_ab) where a = 3").Check(testkit.Rows("3"))
+	_, err = tk.Exec("create unique index idx_ub on part_h (b)")
+	c.Assert(ddl.ErrUniqueKeyNeedAllFieldsInPf.Equal(err), IsTrue)
+
+	// KEY partitioning isn't supported, the table is created as a normal table.
+	tk.MustExec("create table part_k (a int) partition by key (a) partitions 2")
+	tk.MustQuery("show warnings").Check(testkit.Rows("Warning 1105 unsupported partition type KEY, treat as normal table"))
+	tk.MustExec("drop table part_k")
+
+	_, err = tk.Exec("create table part_n (a int, b int, unique key (b)) partition by hash (a) partitions 2")
+	c.Assert(ddl.ErrUniqueKeyNeedAllFieldsInPf.Equal(err), IsTrue)
+	_, err = tk.Exec("create table part_n (a int) partition by range (a) (partition p0 values less than (10), partition p1 values less than (5))")
+	c.Assert(ddl.ErrRangeNotIncreasing.Equal(err), IsTrue)
+	_, err = tk.Exec("create table part_n (a varchar(10)) partition by hash (a) partitions 2")
+	c.Assert(ddl.ErrPartitionFuncNotAllowed.Equal(err), IsTrue)
+	tk.MustExec("create table part_n (a int)")
+	_, err = tk.Exec("alter table part_n drop partition p0")
+	c.Assert(ddl.ErrPartitionMgmtOnNonpartitioned.Equal(err), IsTrue)
+	tk.MustExec("drop table part_r, part_h, part_n")
+}
+
 func (s *testSuite) TestCreateDropIndex(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
@@ -180,11 +381,14 @@ func (s *testSuite) TestAlterTableModifyColumn(c *C) {
 	c.Assert(err, NotNil)
 	tk.MustExec("alter table mc modify column c1 bigint")
 
-	_, err = tk.Exec("alter table mc modify column c2 blob")
-	c.Assert(err, NotNil)
+	tk.MustExec("insert into mc values (1, 'abcdefghij')")
+	// The column data is converted if the type can't be modified in place.
+	tk.MustExec("alter table mc modify column c2 blob")
+	tk.MustExec("alter table mc modify column c2 varchar(10)")
 
 	_, err = tk.Exec("alter table mc modify column c2 varchar(8)")
 	c.Assert(err, NotNil)
+	tk.MustQuery("select * from mc").Check(testkit.Rows("1 abcdefghij"))
 	tk.MustExec("alter table mc modify column c2 varchar(11)")
 	tk.MustExec("alter table mc modify column c2 text(13)")
 	tk.MustExec("alter table mc modify column c2 text")
@@ -34,6 +34,7 @@ import (
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/goroutine_pool"
 	"github.com/pingcap/tidb/util/types"
@@ -106,6 +107,15 @@ func (s *rowsSorter) Swap(i, j int) {
 	s.rows[i], s.rows[j] = s.rows[j], s.rows[i]
 }
 
+// physicalTableIDs returns the IDs which the data of the table is encoded with,
+// they are the partition IDs if the table is partitioned.
+func physicalTableIDs(tableID int64, partitionIDs []int64) []int64 {
+	if len(partitionIDs) > 0 {
+		return partitionIDs
+	}
+	return []int64{tableID}
+}
+
 func tableRangesToKVRanges(tid int64, tableRanges []types.IntColumnRange) []kv.KeyRange {
 	krs := make([]kv.KeyRange, 0, len(tableRanges))
 	for _, tableRange := range tableRanges {
@@ -420,6 +430,8 @@ type XSelectIndexExec struct {
 	indexConditionPBExpr *tipb.Expr
 	// This is the column that represent the handle, we can use handleCol.Index to know its position.
 	handleCol *expression.Column
+	// partitionIDs are the IDs of the partitions to read if the table is partitioned.
+	partitionIDs []int64
 
 	/*
 	   The following attributes are used for aggregation push down.
@@ -481,6 +493,11 @@ func (e *XSelectIndexExec) Next() (Row, error) {
 	return e.nextForDoubleRead()
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *XSelectIndexExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 func (e *XSelectIndexExec) nextForSingleRead() (Row, error) {
 	if e.result == nil {
 		e.execStart = time.Now()
@@ -715,9 +732,13 @@ func (e *XSelectIndexExec) doIndexRequest() (distsql.SelectResult, error) {
 	}
 	sv := e.ctx.GetSessionVars()
 	sc := sv.StmtCtx
-	keyRanges, err := indexRangesToKVRanges(sc, e.table.Meta().ID, e.index.ID, e.ranges, fieldTypes)
-	if err != nil {
-		return nil, errors.Trace(err)
+	var keyRanges []kv.KeyRange
+	for _, tid := range physicalTableIDs(e.table.Meta().ID, e.partitionIDs) {
+		krs, err := indexRangesToKVRanges(sc, tid, e.index.ID, e.ranges, fieldTypes)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		keyRanges = append(keyRanges, krs...)
 	}
 	return distsql.Select(e.ctx.GetClient(), e.ctx.GoCtx(), selIdxReq, keyRanges, e.scanConcurrency, !e.outOfOrder, getIsolationLevel(sv), e.priority)
 }
@@ -890,7 +911,10 @@ func (e *XSelectIndexExec) doTableRequest(handles []int64) (distsql.SelectResult
 	// Aggregate Info
 	selTableReq.Aggregates = e.aggFuncs
 	selTableReq.GroupBy = e.byItems
-	keyRanges := tableHandlesToKVRanges(e.table.Meta().ID, handles)
+	var keyRanges []kv.KeyRange
+	for _, tid := range physicalTableIDs(e.table.Meta().ID, e.partitionIDs) {
+		keyRanges = append(keyRanges, tableHandlesToKVRanges(tid, handles)...)
+	}
 	// Use the table scan concurrency variable to do table request.
 	concurrency := e.ctx.GetSessionVars().DistSQLScanConcurrency
 	resp, err := distsql.Select(e.ctx.GetClient(), goctx.Background(), selTableReq, keyRanges, concurrency, false, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
@@ -926,6 +950,8 @@ type XSelectTableExec struct {
 	orderByList  []*tipb.ByItem
 	// This is the column that represent the handle, we can use handleCol.Index to know its position.
 	handleCol *expression.Column
+	// partitionIDs are the IDs of the partitions to read if the table is partitioned.
+	partitionIDs []int64
 
 	/*
 	   The following attributes are used for aggregation push down.
@@ -975,7 +1001,10 @@ func (e *XSelectTableExec) doRequest() error {
 	selReq.Aggregates = e.aggFuncs
 	selReq.GroupBy = e.byItems
 
-	kvRanges := tableRangesToKVRanges(e.table.Meta().ID, e.ranges)
+	var kvRanges []kv.KeyRange
+	for _, tid := range physicalTableIDs(e.table.Meta().ID, e.partitionIDs) {
+		kvRanges = append(kvRanges, tableRangesToKVRanges(tid, e.ranges)...)
+	}
 	e.result, err = distsql.Select(e.ctx.GetClient(), goctx.Background(), selReq, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
 	if err != nil {
 		return errors.Trace(err)
@@ -1069,6 +1098,11 @@ func (e *XSelectTableExec) Next() (Row, error) {
 	}
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *XSelectTableExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 func (e *XSelectTableExec) slowQueryInfo(duration time.Duration) string {
 	return fmt.Sprintf("time: %v, table: %s(%d), partials: %d, concurrency: %d, start_ts: %d, rows: %d",
 		duration, e.tableInfo.Name, e.tableInfo.ID, e.partialCount, e.ctx.GetSessionVars().DistSQLScanConcurrency,
@@ -31,6 +31,7 @@ import (
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/admin"
+	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -67,6 +68,17 @@ var (
 	ErrBuildExecutor        = terror.ClassExecutor.New(codeErrBuildExec, "Failed to build executor")
 	ErrBatchInsertFail      = terror.ClassExecutor.New(codeBatchInsertFail, "Batch insert failed, please clean the table and try again.")
 	ErrWrongValueCountOnRow = terror.ClassExecutor.New(codeWrongValueCountOnRow, "Column count doesn't match value count at row %d")
+	ErrCTEMaxRecursionDepth = terror.ClassExecutor.New(codeCTEMaxRecursionDepth, mysql.MySQLErrName[mysql.ErrCTEMaxRecursionDepth])
+	ErrUnknownAuthID        = terror.ClassExecutor.New(codeUnknownAuthID, mysql.MySQLErrName[mysql.ErrUnknownAuthID])
+	ErrRoleNotGranted       = terror.ClassExecutor.New(codeRoleNotGranted, mysql.MySQLErrName[mysql.ErrRoleNotGranted])
+	ErrNoReferencedRow      = terror.ClassExecutor.New(codeNoReferencedRow, mysql.MySQLErrName[mysql.ErrNoReferencedRow2])
+	ErrRowIsReferenced      = terror.ClassExecutor.New(codeRowIsReferenced, mysql.MySQLErrName[mysql.ErrRowIsReferenced2])
+	ErrFkDepthExceeded      = terror.ClassExecutor.New(codeFkDepthExceeded, mysql.MySQLErrName[mysql.ErrFkDepthExceeded])
+	ErrPluginIsNotLoaded    = terror.ClassExecutor.New(codePluginIsNotLoaded, mysql.MySQLErrName[mysql.ErrPluginIsNotLoaded])
+	ErrSpecificAccessDenied = terror.ClassExecutor.New(codeSpecificAccessDenied, mysql.MySQLErrName[mysql.ErrSpecificAccessDenied])
+
+	// errNoForeignKeyIndex is returned if there is no index to find the rows of a foreign key.
+	errNoForeignKeyIndex = terror.ClassExecutor.New(codeNoForeignKeyIndex, "No index for the foreign key")
 )
 
 // Error codes.
@@ -81,9 +93,18 @@ const (
 	codeResultIsEmpty        terror.ErrCode = 8
 	codeErrBuildExec         terror.ErrCode = 9
 	codeBatchInsertFail      terror.ErrCode = 10
+	codeNoForeignKeyIndex    terror.ErrCode = 11
 	CodePasswordNoMatch      terror.ErrCode = 1133 // MySQL error code
 	CodeCannotUser           terror.ErrCode = 1396 // MySQL error code
 	codeWrongValueCountOnRow terror.ErrCode = 1136 // MySQL error code
+	codeCTEMaxRecursionDepth terror.ErrCode = 3636 // MySQL error code
+	codeUnknownAuthID        terror.ErrCode = 3523 // MySQL error code
+	codeRoleNotGranted       terror.ErrCode = 3530 // MySQL error code
+	codeRowIsReferenced      terror.ErrCode = 1451 // MySQL error code
+	codeNoReferencedRow      terror.ErrCode = 1452 // MySQL error code
+	codeFkDepthExceeded      terror.ErrCode = 3008 // MySQL error code
+	codePluginIsNotLoaded    terror.ErrCode = 1524 // MySQL error code
+	codeSpecificAccessDenied terror.ErrCode = 1227 // MySQL error code
 )
 
 // Row represents a result set row, it may be returned from a table, a join, or a projection.
@@ -97,6 +118,11 @@ const (
 // Otherwise the executor's returned rows don't need to store the handle information.
 type Row []types.Datum
 
+// maxChunkSize is the max number of rows in a Chunk returned by NextChunk.
+const maxChunkSize = 1024
+
+var errNextChunkNotSupported = errors.New("NextChunk is not supported by the executor")
+
 type baseExecutor struct {
 	children []Executor
 	ctx      context.Context
@@ -133,6 +159,11 @@ func (e *baseExecutor) Schema() *expression.Schema {
 	return e.schema
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *baseExecutor) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 func newBaseExecutor(schema *expression.Schema, ctx context.Context, children ...Executor) baseExecutor {
 	return baseExecutor{
 		children: children,
@@ -144,11 +175,35 @@ func newBaseExecutor(schema *expression.Schema, ctx context.Context, children ..
 // Executor executes a query.
 type Executor interface {
 	Next() (Row, error)
+	// NextChunk fills the Chunk with at most maxChunkSize rows, an empty Chunk means there is no more data.
+	// It can only be called if supportChunk returns true for the executor, and it can't be mixed with Next.
+	NextChunk(chk *chunk.Chunk) error
 	Close() error
 	Open() error
 	Schema() *expression.Schema
 }
 
+// chunkExecutor is implemented by the executors which can return data by NextChunk.
+type chunkExecutor interface {
+	// supportChunk returns whether the executor can return data by NextChunk, it usually depends on its children.
+	supportChunk() bool
+}
+
+// supportChunk checks whether the data of the executor can be fetched by NextChunk.
+func supportChunk(e Executor) bool {
+	ce, ok := e.(chunkExecutor)
+	return ok && ce.supportChunk()
+}
+
+// newChunk creates a Chunk to hold the data returned by the executor.
+func newChunk(e Executor) *chunk.Chunk {
+	fields := make([]*types.FieldType, 0, e.Schema().Len())
+	for _, col := range e.Schema().Columns {
+		fields = append(fields, col.RetType)
+	}
+	return chunk.NewChunk(fields)
+}
+
 // CancelDDLJobsExec represents a cancel DDL jobs executor.
 type CancelDDLJobsExec struct {
 	baseExecutor
@@ -387,6 +442,14 @@ func init() {
 		CodeCannotUser:           mysql.ErrCannotUser,
 		CodePasswordNoMatch:      mysql.ErrPasswordNoMatch,
 		codeWrongValueCountOnRow: mysql.ErrWrongValueCountOnRow,
+		codeCTEMaxRecursionDepth: mysql.ErrCTEMaxRecursionDepth,
+		codeUnknownAuthID:        mysql.ErrUnknownAuthID,
+		codeRoleNotGranted:       mysql.ErrRoleNotGranted,
+		codeRowIsReferenced:      mysql.ErrRowIsReferenced2,
+		codeNoReferencedRow:      mysql.ErrNoReferencedRow2,
+		codeFkDepthExceeded:      mysql.ErrFkDepthExceeded,
+		codePluginIsNotLoaded:    mysql.ErrPluginIsNotLoaded,
+		codeSpecificAccessDenied: mysql.ErrSpecificAccessDenied,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassExecutor] = tableMySQLErrCodes
 }
@@ -396,6 +459,8 @@ type ProjectionExec struct {
 	baseExecutor
 
 	exprs []expression.Expression
+	// childChunk is used to fetch the data of the child by NextChunk.
+	childChunk *chunk.Chunk
 }
 
 // Next implements the Executor Next interface.
@@ -418,6 +483,28 @@ func (e *ProjectionExec) Next() (retRow Row, err error) {
 	return row, nil
 }
 
+// Open implements the Executor Open interface.
+func (e *ProjectionExec) Open() error {
+	e.childChunk = nil
+	return errors.Trace(e.baseExecutor.Open())
+}
+
+// NextChunk implements the Executor NextChunk interface.
+func (e *ProjectionExec) NextChunk(chk *chunk.Chunk) error {
+	chk.Reset()
+	if e.childChunk == nil {
+		e.childChunk = newChunk(e.children[0])
+	}
+	if err := e.children[0].NextChunk(e.childChunk); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(expression.VectorizedExecute(e.ctx, e.exprs, e.childChunk, chk))
+}
+
+func (e *ProjectionExec) supportChunk() bool {
+	return supportChunk(e.children[0])
+}
+
 // TableDualExec represents a dual table executor.
 type TableDualExec struct {
 	baseExecutor
@@ -446,6 +533,19 @@ type SelectionExec struct {
 	baseExecutor
 
 	Conditions []expression.Expression
+
+	// childChunk is used to fetch the data of the child by NextChunk, selected indicates whether its rows
+	// pass the conditions, and inputRow is the index of the next row to be checked.
+	childChunk *chunk.Chunk
+	selected   []bool
+	inputRow   int
+}
+
+// Open implements the Executor Open interface.
+func (e *SelectionExec) Open() error {
+	e.childChunk = nil
+	e.inputRow = 0
+	return errors.Trace(e.baseExecutor.Open())
 }
 
 // Next implements the Executor Next interface.
@@ -468,6 +568,40 @@ func (e *SelectionExec) Next() (Row, error) {
 	}
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *SelectionExec) NextChunk(chk *chunk.Chunk) error {
+	chk.Reset()
+	if e.childChunk == nil {
+		e.childChunk = newChunk(e.children[0])
+	}
+	for {
+		for ; e.inputRow < e.childChunk.NumRows(); e.inputRow++ {
+			if chk.NumRows() >= maxChunkSize {
+				return nil
+			}
+			if e.selected[e.inputRow] {
+				chk.AppendRow(e.childChunk.GetRow(e.inputRow))
+			}
+		}
+		err := e.children[0].NextChunk(e.childChunk)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if e.childChunk.NumRows() == 0 {
+			return nil
+		}
+		e.selected, err = expression.VectorizedFilter(e.ctx, e.Conditions, e.childChunk, e.selected)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.inputRow = 0
+	}
+}
+
+func (e *SelectionExec) supportChunk() bool {
+	return supportChunk(e.children[0])
+}
+
 // TableScanExec is a table scan executor without result fields.
 type TableScanExec struct {
 	baseExecutor
@@ -586,7 +720,7 @@ func (e *TableScanExec) getRow(handle int64) (Row, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-
+	e.ctx.GetSessionVars().StmtCtx.AddExaminedRows(1)
 	return row, nil
 }
 
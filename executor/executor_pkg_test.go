@@ -14,6 +14,8 @@
 package executor
 
 import (
+	"fmt"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/expression"
@@ -84,6 +86,18 @@ func (msm *mockSessionManager) Kill(cid uint64, query bool) {
 
 }
 
+func (s *testExecSuite) TestPartitionIndex(c *C) {
+	// The join keys in the same partition are spread over the partitions of the next level.
+	subs := make(map[int]struct{})
+	for i := 0; len(subs) < graceHashJoinPartitions && i < 10000; i++ {
+		joinKey := []byte(fmt.Sprintf("key-%d", i))
+		if partitionIndex(joinKey, 0) == 0 {
+			subs[partitionIndex(joinKey, 1)] = struct{}{}
+		}
+	}
+	c.Assert(subs, HasLen, graceHashJoinPartitions)
+}
+
 func (s *testExecSuite) TestShowProcessList(c *C) {
 	// Compose schema.
 	names := []string{"Id", "User", "Host", "db", "Command", "Time", "State", "Info"}
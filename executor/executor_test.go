@@ -16,7 +16,9 @@ package executor_test
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -26,6 +28,7 @@ import (
 	pb "github.com/pingcap/kvproto/pkg/kvrpcpb"
 	"github.com/pingcap/pd/pkg/logutil"
 	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/executor"
@@ -41,6 +44,7 @@ import (
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/admin"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
@@ -105,10 +109,16 @@ func (s *testSuite) SetUpTest(c *C) {
 func (s *testSuite) TearDownTest(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
-	r := tk.MustQuery("show tables")
+	// The tables referenced by the foreign keys are dropped in any order.
+	tk.MustExec("set foreign_key_checks = 0")
+	r := tk.MustQuery("show full tables")
 	for _, tb := range r.Rows() {
 		tableName := tb[0]
-		tk.MustExec(fmt.Sprintf("drop table %v", tableName))
+		if tb[1] == "VIEW" {
+			tk.MustExec(fmt.Sprintf("drop view %v", tableName))
+		} else {
+			tk.MustExec(fmt.Sprintf("drop table %v", tableName))
+		}
 	}
 	testleak.AfterTest(c)()
 }
@@ -694,6 +704,137 @@ func (s *testSuite) TestOrderBy(c *C) {
 	tk.MustQuery("select c1, c2 from t order by binary c3").Check(testkit.Rows("1 2", "2 1"))
 }
 
+func (s *testSuite) TestSortSpill(c *C) {
+	tmpDir := c.MkDir()
+	cfg := config.GetGlobalConfig()
+	originTmpDir := cfg.TmpStoragePath
+	cfg.TmpStoragePath = tmpDir
+	defer func() {
+		cfg.TmpStoragePath = originTmpDir
+	}()
+
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b varchar(20), c datetime, d decimal(10, 2), e double, f enum('x', 'y', 'z'))")
+	for i := 0; i < 100; i++ {
+		tk.MustExec(fmt.Sprintf("insert into t values (%d, 'b%d', '2017-01-%02d 10:00:00', %d.5, %d.25, '%c')",
+			i%7, i, i%28+1, i, i, 'x'+i%3))
+	}
+	tk.MustExec("insert into t values (null, null, null, null, null, null)")
+
+	queries := []string{
+		"select * from t order by a, b",
+		"select * from t order by c desc, e",
+		"select a, d, f from t order by f, d desc",
+		"select b from t order by e limit 10",
+		"select * from t order by a desc, b limit 10, 20",
+		"select * from t order by d limit 90, 20",
+	}
+	expected := make([][][]interface{}, len(queries))
+	tk.MustExec("set @@tidb_mem_quota_sort = 0")
+	for i, sql := range queries {
+		expected[i] = tk.MustQuery(sql).Rows()
+	}
+	// Every row exceeds the quota, the rows are spilled to disk once the first row is buffered.
+	tk.MustExec("set @@tidb_mem_quota_sort = 1")
+	for i, sql := range queries {
+		tk.MustQuery(sql).Check(expected[i])
+	}
+	// The spilled files are removed when the sort is closed.
+	files, err := ioutil.ReadDir(tmpDir)
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 0)
+
+	tk.MustExec("set @@tidb_mem_quota_sort = 4096")
+	for i, sql := range queries {
+		tk.MustQuery(sql).Check(expected[i])
+	}
+}
+
+func (s *testSuite) TestMemQuotaQuery(c *C) {
+	cfg := config.GetGlobalConfig()
+	originOOMAction := cfg.OOMAction
+	defer func() {
+		cfg.OOMAction = originOOMAction
+	}()
+
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b varchar(20))")
+	for i := 0; i < 50; i++ {
+		tk.MustExec(fmt.Sprintf("insert into t values (%d, 'b%d')", i, i))
+	}
+	queries := []string{
+		"select * from t order by b",
+		"select b, count(*) from t group by b",
+		"select * from t t1 join t t2 on t1.b = t2.b",
+	}
+
+	// The queries are canceled when the quota is exceeded.
+	cfg.OOMAction = config.OOMActionCancel
+	tk.MustExec("set @@tidb_mem_quota_query = 100")
+	for _, sql := range queries {
+		rs, err := tk.Exec(sql)
+		c.Assert(err, IsNil)
+		_, err = tidb.GetRows(rs)
+		c.Assert(terror.ErrorEqual(err, memory.ErrMemExceedThreshold), IsTrue, Commentf("sql %s, err %v", sql, err))
+		c.Assert(rs.Close(), IsNil)
+	}
+
+	// The queries keep running when the action is log.
+	cfg.OOMAction = config.OOMActionLog
+	tk.MustQuery("select count(*) from (select * from t order by b) s").Check(testkit.Rows("50"))
+	tk.MustQuery("select count(*) from (select b, count(*) from t group by b) s").Check(testkit.Rows("50"))
+	tk.MustQuery("select count(*) from t t1 join t t2 on t1.b = t2.b").Check(testkit.Rows("50"))
+
+	// The queries are not canceled if the quota is large enough.
+	cfg.OOMAction = config.OOMActionCancel
+	tk.MustExec("set @@tidb_mem_quota_query = 1048576")
+	tk.MustQuery("select count(*) from t t1 join t t2 on t1.b = t2.b").Check(testkit.Rows("50"))
+
+	// The aggregation contexts of a single group are counted in both the serial and the parallel hash aggregation.
+	tk.MustExec("set @@tidb_mem_quota_query = 1024")
+	for _, concurrency := range []int{1, 4} {
+		tk.MustExec(fmt.Sprintf("set @@tidb_hashagg_partial_concurrency = %d", concurrency))
+		tk.MustExec(fmt.Sprintf("set @@tidb_hashagg_final_concurrency = %d", concurrency))
+		rs, err := tk.Exec("select count(distinct b), group_concat(b) from t")
+		c.Assert(err, IsNil)
+		_, err = tidb.GetRows(rs)
+		c.Assert(terror.ErrorEqual(err, memory.ErrMemExceedThreshold), IsTrue, Commentf("concurrency %d, err %v", concurrency, err))
+		c.Assert(rs.Close(), IsNil)
+	}
+}
+
+func (s *testSuite) TestChunkExecution(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b double, c varchar(10))")
+	// The rows span several chunks, every 100th row has null values.
+	values := make([]string, 0, 2500)
+	for i := 0; i < 2500; i++ {
+		if i%100 == 99 {
+			values = append(values, "(null, null, null)")
+			continue
+		}
+		values = append(values, fmt.Sprintf("(%d, %d.5, 'c%d')", i, i, i%5))
+	}
+	tk.MustExec("insert into t values " + strings.Join(values, ", "))
+
+	tk.MustQuery("select count(*), count(a), count(c) from t").Check(testkit.Rows("2500 2475 2475"))
+	// 1000 rows in (1000, 2001), 10 of them are null.
+	tk.MustQuery("select count(*) from t where a > 1000 and b < 2000.6").Check(testkit.Rows("990"))
+	tk.MustQuery("select count(*) from t where a is null or a < 10").Check(testkit.Rows("35"))
+	tk.MustQuery("select a * 2, b + a, c from t where a >= 1020 and a < 1028 and a != 1025").Check(testkit.Rows(
+		"2040 2040.5 c0", "2042 2042.5 c1", "2044 2044.5 c2", "2046 2046.5 c3", "2048 2048.5 c4",
+		"2052 2052.5 c1", "2054 2054.5 c2"))
+	tk.MustQuery("select c, count(*), sum(a), max(b) from t group by c order by c").Check(testkit.Rows(
+		"<nil> 25 <nil> <nil>", "c0 500 623750 2495.5", "c1 500 624250 2496.5", "c2 500 624750 2497.5",
+		"c3 500 625250 2498.5", "c4 475 593275 2494.5"))
+	tk.MustQuery("select count(*) from (select a - a % 1000 as k from t group by k) s").Check(testkit.Rows("4"))
+	tk.MustExec("set @x = 0")
+	tk.MustQuery("select a, @x := @x + a from t where a < 4").Check(testkit.Rows("0 0", "1 1", "2 3", "3 6"))
+}
+
 func (s *testSuite) TestSelectErrorRow(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
@@ -1533,6 +1674,113 @@ func (s *testSuite) TestTableScan(c *C) {
 	result.Check(testkit.Rows(rowStr1, rowStr2))
 }
 
+func (s *testSuite) TestPerfSchemaEvents(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.Se.SetConnectionID(10086)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int)")
+	tk.MustExec("insert into t values (1), (2), (3)")
+	tk.MustQuery("select * from t where a > 1").Check(testkit.Rows("2", "3"))
+	_, err := tk.Exec("insert into t values (1, 2)")
+	c.Assert(err, NotNil)
+
+	result := tk.MustQuery("select event_name, sql_text, mysql_errno, rows_affected, rows_sent, rows_examined " +
+		"from performance_schema.events_statements_history where thread_id = 10086 and sql_text != 'drop table if exists t' order by event_id")
+	result.Check(testkit.Rows(
+		"statement/sql/create_table create table t (a int) 0 0 0 0",
+		"statement/sql/insert insert into t values (1), (2), (3) 0 3 0 0",
+		"statement/sql/select select * from t where a > 1 0 0 2 2",
+		"statement/sql/insert insert into t values (1, 2) 1136 0 0 0",
+	))
+	tk.MustQuery("select event_name, sql_text, end_event_id is null from performance_schema.events_statements_current where thread_id = 10086").Check(
+		testkit.Rows("statement/sql/select select event_name, sql_text, end_event_id is null from performance_schema.events_statements_current where thread_id = 10086 1"))
+	tk.MustQuery("select count(*) from performance_schema.events_statements_history_long where thread_id = 10086").Check(testkit.Rows("7"))
+
+	tk.MustExec("begin")
+	tk.MustExec("insert into t values (4)")
+	tk.MustQuery("select state, autocommit from performance_schema.events_transactions_current where thread_id = 10086").Check(testkit.Rows("ACTIVE YES"))
+	tk.MustExec("commit")
+	tk.MustQuery("select t.event_name from performance_schema.events_statements_history s, performance_schema.events_transactions_history t " +
+		"where s.thread_id = 10086 and s.sql_text = 'insert into t values (4)' and s.nesting_event_id = t.event_id and t.thread_id = 10086").Check(testkit.Rows("transaction"))
+	tk.MustExec("begin")
+	tk.MustExec("insert into t values (5)")
+	tk.MustExec("rollback")
+	result = tk.MustQuery("select state, isolation_level from performance_schema.events_transactions_history where thread_id = 10086 order by event_id desc limit 3")
+	// The autocommit transaction of the query itself is committed before the rows are read.
+	result.Check(testkit.Rows("COMMITTED REPEATABLE READ", "ROLLED BACK REPEATABLE READ", "COMMITTED REPEATABLE READ"))
+
+	tk.Se.Close()
+	tk.MustQuery("select count(*) from performance_schema.events_statements_history where thread_id = 10086").Check(testkit.Rows("0"))
+}
+
+func (s *testSuite) TestPerfSchemaDigests(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.Se.SetConnectionID(10087)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b varchar(10))")
+	tk.MustExec("truncate table performance_schema.events_statements_summary_by_digest")
+	tk.MustExec("insert into t values (1, 'a')")
+	tk.MustExec("insert into t values (2, 'b'), (3, 'c')")
+	tk.MustQuery("select a from t where a > 1 and b in ('b', 'c')").Check(testkit.Rows("2", "3"))
+	tk.MustQuery("SELECT a FROM t WHERE a > 2 AND b IN ('c')").Check(testkit.Rows("3"))
+	_, err := tk.Exec("insert into t values (1)")
+	c.Assert(err, NotNil)
+
+	result := tk.MustQuery("select schema_name, digest_text, count_star, sum_errors, sum_rows_affected, sum_rows_sent, " +
+		"length(digest), sum_timer_wait >= max_timer_wait from performance_schema.events_statements_summary_by_digest " +
+		"where digest_text like 'INSERT%' or digest_text like 'SELECT `a`%' order by digest_text")
+	result.Check(testkit.Rows(
+		"test INSERT INTO `t` VALUES (...) 3 1 3 0 32 1",
+		"test SELECT `a` FROM `t` WHERE `a` > ? AND `b` IN (...) 2 0 0 3 32 1",
+	))
+	tk.MustQuery("select count(*) from performance_schema.events_statements_history h, performance_schema.events_statements_summary_by_digest d " +
+		"where h.thread_id = 10087 and h.digest = d.digest and h.sql_text = 'insert into t values (1)'").Check(testkit.Rows("1"))
+
+	tk.MustExec("truncate table performance_schema.events_statements_summary_by_digest")
+	// Only the TRUNCATE statement itself is recorded after the reset.
+	tk.MustQuery("select digest_text from performance_schema.events_statements_summary_by_digest").Check(
+		testkit.Rows("TRUNCATE TABLE `performance_schema`.`events_statements_summary_by_digest`"))
+}
+
+func (s *testSuite) TestExplainAnalyze(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1 (a int, b int)")
+	tk.MustExec("create table t2 (a int, b int)")
+	tk.MustExec("insert into t1 values (1, 3), (2, 2), (3, 1)")
+	tk.MustExec("insert into t2 values (1, 1), (2, 2)")
+
+	rows := tk.MustQuery("explain analyze select * from t1 order by b").Rows()
+	c.Assert(rows, HasLen, 3)
+	c.Assert(rows[0][0], Matches, "TableScan_.*")
+	c.Assert(rows[0][6], Matches, `cop_task:\{num:1, max:.*, total:.*, rows:3, bytes:.*\}`)
+	c.Assert(rows[1][0], Matches, "TableReader_.*")
+	c.Assert(rows[1][6], Matches, "time:.*, loops:1, rows:3")
+	c.Assert(rows[2][0], Matches, "Sort_.*")
+	c.Assert(rows[2][6], Matches, "time:.*, loops:1, rows:3, memory:.*")
+
+	rows = tk.MustQuery("explain analyze select * from t1 join t2 on t1.a = t2.a where t2.b > 1").Rows()
+	c.Assert(rows, HasLen, 6)
+	for _, row := range rows {
+		id := row[0].(string)
+		switch {
+		case strings.HasPrefix(id, "Selection_"):
+			c.Assert(row[6], Matches, `cop_task:\{num:1, .*, rows:1, .*\}`)
+		case strings.HasPrefix(id, "HashLeftJoin_"), strings.HasPrefix(id, "HashRightJoin_"):
+			c.Assert(row[6], Matches, "time:.*, loops:1, rows:1, memory:.*")
+		}
+	}
+
+	// The explained statement is executed.
+	rows = tk.MustQuery("explain analyze insert into t1 values (4, 4)").Rows()
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0][6], Matches, "time:.*, loops:1, rows:0")
+	tk.MustQuery("select count(*) from t1").Check(testkit.Rows("4"))
+}
+
 func (s *testSuite) TestAdapterStatement(c *C) {
 	se, err := tidb.CreateSession(s.store)
 	c.Check(err, IsNil)
@@ -2284,3 +2532,65 @@ func (s *testSuite) TestMaxInt64Handle(c *C) {
 	tk.MustExec("delete from t where id = 9223372036854775807")
 	tk.MustQuery("select * from t").Check(nil)
 }
+
+func (s *testSuite) TestCICollation(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int, a varchar(20) collate utf8mb4_general_ci, b varchar(20), key idx_a(a))")
+	tk.MustExec("insert into t values (1, 'abc', 'abc'), (2, 'ABC ', 'ABC'), (3, 'Abd', 'Abd'), (4, 'café', 'café'), (5, 'b', 'b')")
+
+	// Comparisons.
+	tk.MustQuery("select id from t where a = 'ABC' order by id").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select id from t where b = 'ABC' order by id").Check(testkit.Rows("2"))
+	tk.MustQuery("select id from t where a in ('abc', 'CAFE') order by id").Check(testkit.Rows("1", "2", "4"))
+	tk.MustQuery("select id from t where a > 'abc' order by id").Check(testkit.Rows("3", "4", "5"))
+	tk.MustQuery("select id from t where a like 'AB%' order by id").Check(testkit.Rows("1", "2", "3"))
+	tk.MustQuery("select id from t where a like 'ca_e' order by id").Check(testkit.Rows("4"))
+	tk.MustQuery("select id from t where b like 'AB%' order by id").Check(testkit.Rows("2"))
+	tk.MustQuery("select strcmp(a, 'ABC') from t where id = 1").Check(testkit.Rows("0"))
+
+	// ORDER BY, GROUP BY and aggregates.
+	tk.MustQuery("select id from t order by a, id").Check(testkit.Rows("1", "2", "3", "5", "4"))
+	tk.MustQuery("select count(*) from t group by a order by count(*) desc, min(id)").Check(testkit.Rows("2", "1", "1", "1"))
+	tk.MustQuery("select count(distinct a), count(distinct b) from t").Check(testkit.Rows("4 5"))
+	tk.MustQuery("select max(a), min(b) from t").Check(testkit.Rows("café ABC"))
+
+	// Joins.
+	tk.MustExec("drop table if exists t1")
+	tk.MustExec("create table t1 (a varchar(20) collate utf8mb4_general_ci)")
+	tk.MustExec("insert into t1 values ('ABC'), ('CAFE')")
+	tk.MustQuery("select t.id from t join t1 on t.a = t1.a order by t.id").Check(testkit.Rows("1", "2", "4"))
+	tk.MustQuery("select /*+ TIDB_INLJ(t) */ t.id from t1 join t on t.a = t1.a order by t.id").Check(testkit.Rows("1", "2", "4"))
+	tk.MustQuery("select /*+ TIDB_SMJ(t, t1) */ t.id from t join t1 on t.a = t1.a order by t.id").Check(testkit.Rows("1", "2", "4"))
+
+	// The COLLATE clause takes precedence over the collations of the columns.
+	tk.MustQuery("select 'a' = 'A', 'a' = 'A' collate utf8mb4_general_ci, 'a' collate utf8mb4_bin = 'A'").Check(testkit.Rows("0 1 0"))
+	tk.MustQuery("select id from t where a collate utf8mb4_bin = 'ABC ' order by id").Check(testkit.Rows("2"))
+	tk.MustQuery("select id from t where a = 'ABC' collate utf8mb4_bin order by id").Check(testkit.Rows())
+	tk.MustQuery("select id from t where b = 'abc' collate utf8mb4_general_ci order by id").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select id from t where b collate utf8mb4_general_ci like 'ab%' order by id").Check(testkit.Rows("1", "2", "3"))
+	tk.MustQuery("select id from t order by b collate utf8mb4_general_ci, id").Check(testkit.Rows("1", "2", "3", "5", "4"))
+	_, err := tk.Exec("select 'a' collate unknown_ci")
+	c.Assert(err.Error(), Equals, "[expression:1273]Unknown collation: 'unknown_ci'")
+
+	// The unique index rejects the strings equal by the collation.
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a varchar(20) collate utf8mb4_unicode_ci, unique key(a))")
+	tk.MustExec("insert into t values ('Straße')")
+	_, err = tk.Exec("insert into t values ('STRASSE')")
+	c.Assert(err, NotNil)
+	tk.MustQuery("select a from t where a = 'strasse'").Check(testkit.Rows("Straße"))
+	tk.MustExec("admin check table t")
+
+	// The collation of the column can be changed if the strings are compared in the same way.
+	tk.MustExec("alter table t modify a varchar(20) collate utf8mb4_unicode_ci")
+	_, err = tk.Exec("alter table t modify a varchar(20) collate utf8mb4_bin")
+	c.Assert(err, NotNil)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a varchar(20) charset latin1)")
+	tk.MustExec("alter table t modify a varchar(20) charset utf8mb4")
+	tk.MustExec("alter table t modify a varchar(20) collate utf8mb4_general_ci")
+	tk.MustExec("insert into t values ('a')")
+	tk.MustQuery("select a from t where a = 'A'").Check(testkit.Rows("a"))
+}
@@ -14,15 +14,27 @@
 package executor
 
 import (
+	"time"
+
+	"github.com/juju/errors"
 	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/execdetails"
+	"github.com/pingcap/tidb/util/types"
+	goctx "golang.org/x/net/context"
 )
 
 // ExplainExec represents an explain executor.
 type ExplainExec struct {
 	baseExecutor
 
-	rows   []Row
-	cursor int
+	explain *plan.Explain
+	// analyzeExec is the executor of the explained statement, it's only set for EXPLAIN ANALYZE.
+	analyzeExec Executor
+	rows        []Row
+	cursor      int
 }
 
 // Schema implements the Executor Schema interface.
@@ -30,6 +42,16 @@ func (e *ExplainExec) Schema() *expression.Schema {
 	return e.schema
 }
 
+// Open implements the Executor Open interface.
+func (e *ExplainExec) Open() error {
+	// For EXPLAIN ANALYZE, the statement is executed in Open, because the transaction of the statement
+	// may be committed before Next is called.
+	if e.analyzeExec != nil {
+		return errors.Trace(e.executeAnalyzeExec())
+	}
+	return nil
+}
+
 // Next implements Execution Next interface.
 func (e *ExplainExec) Next() (Row, error) {
 	if e.cursor >= len(e.rows) {
@@ -40,8 +62,123 @@ func (e *ExplainExec) Next() (Row, error) {
 	return row, nil
 }
 
+// executeAnalyzeExec executes the explained statement to collect the runtime statistics of the executors,
+// and then generates the explain rows with them.
+func (e *ExplainExec) executeAnalyzeExec() error {
+	exec := e.analyzeExec
+	if err := exec.Open(); err != nil {
+		return errors.Trace(err)
+	}
+	for {
+		row, err := exec.Next()
+		if err != nil {
+			terror.Call(exec.Close)
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+	}
+	if err := exec.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	e.explain.RenderResult(e.ctx.GetSessionVars().StmtCtx.RuntimeStatsColl)
+	for _, row := range e.explain.Rows {
+		e.rows = append(e.rows, row)
+	}
+	return nil
+}
+
 // Close implements the Executor Close interface.
 func (e *ExplainExec) Close() error {
 	e.rows = nil
 	return nil
 }
+
+// memoryUsageReporter is implemented by the executors which buffer rows in memory,
+// EXPLAIN ANALYZE records the memory usage before the executor is closed.
+type memoryUsageReporter interface {
+	memoryUsage() int64
+}
+
+// rowsMemUsage returns the estimated memory usage of the rows in bytes.
+func rowsMemUsage(rows ...Row) int64 {
+	var usage int64
+	for _, row := range rows {
+		for i := range row {
+			usage += row[i].MemUsage()
+		}
+	}
+	return usage
+}
+
+// runtimeStatsExec wraps an executor to record its runtime statistics for EXPLAIN ANALYZE.
+// The recorded time includes the time spent in the children of the executor.
+type runtimeStatsExec struct {
+	Executor
+
+	stats *execdetails.RuntimeStats
+}
+
+// newRuntimeStatsExec wraps the executor, the inner executors of index lookup join are wrapped
+// as a DataReader, because they are executed by doRequestForDatums instead of Open.
+func newRuntimeStatsExec(e Executor, stats *execdetails.RuntimeStats) Executor {
+	statsExec := runtimeStatsExec{Executor: e, stats: stats}
+	if reader, ok := e.(DataReader); ok {
+		return &runtimeStatsReader{runtimeStatsExec: statsExec, reader: reader}
+	}
+	return &statsExec
+}
+
+// Open implements the Executor Open interface.
+func (e *runtimeStatsExec) Open() error {
+	start := time.Now()
+	e.stats.RecordLoop()
+	err := e.Executor.Open()
+	e.stats.Record(time.Since(start), 0)
+	return errors.Trace(err)
+}
+
+// Next implements the Executor Next interface.
+func (e *runtimeStatsExec) Next() (Row, error) {
+	start := time.Now()
+	row, err := e.Executor.Next()
+	rowNum := 0
+	if row != nil {
+		rowNum = 1
+	}
+	e.stats.Record(time.Since(start), rowNum)
+	return row, errors.Trace(err)
+}
+
+// NextChunk implements the Executor NextChunk interface.
+func (e *runtimeStatsExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
+// Close implements the Executor Close interface.
+func (e *runtimeStatsExec) Close() error {
+	start := time.Now()
+	if reporter, ok := e.Executor.(memoryUsageReporter); ok {
+		e.stats.RecordMemory(reporter.memoryUsage())
+	}
+	err := e.Executor.Close()
+	e.stats.Record(time.Since(start), 0)
+	return errors.Trace(err)
+}
+
+// runtimeStatsReader wraps a DataReader to record its runtime statistics for EXPLAIN ANALYZE.
+type runtimeStatsReader struct {
+	runtimeStatsExec
+
+	reader DataReader
+}
+
+// doRequestForDatums implements the DataReader doRequestForDatums interface.
+func (e *runtimeStatsReader) doRequestForDatums(datums [][]types.Datum, goCtx goctx.Context) error {
+	start := time.Now()
+	e.stats.RecordLoop()
+	err := e.reader.doRequestForDatums(datums, goCtx)
+	e.stats.Record(time.Since(start), 0)
+	return errors.Trace(err)
+}
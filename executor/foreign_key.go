@@ -0,0 +1,504 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/ddl"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// maxForeignKeyCascadeDepth is the max depth of the cascading operations, it's the same as MySQL.
+const maxForeignKeyCascadeDepth = 15
+
+// fkChecker enforces the foreign key constraints when the rows are written.
+// The rows written into a child table must refer to the existing rows of the parent table,
+// the referential actions are applied to the rows referring to the deleted or updated rows of a parent table.
+// The constraints aren't checked when foreign_key_checks is disabled.
+// See https://dev.mysql.com/doc/refman/5.7/en/create-table-foreign-keys.html
+// The foreign keys are found by the table IDs in the InfoSchema.
+type fkChecker struct {
+	ctx context.Context
+	is  infoschema.InfoSchema
+}
+
+func newFKChecker(ctx context.Context, is infoschema.InfoSchema) *fkChecker {
+	return &fkChecker{ctx: ctx, is: is}
+}
+
+func (fkc *fkChecker) enabled() bool {
+	return fkc != nil && fkc.ctx.GetSessionVars().ForeignKeyChecks
+}
+
+// findColumns finds the columns by the names, it returns nil if any of them doesn't exist.
+func findColumns(t table.Table, names []model.CIStr) []*table.Column {
+	cols := make([]*table.Column, 0, len(names))
+	for _, name := range names {
+		col := table.FindCol(t.Cols(), name.O)
+		if col == nil {
+			return nil
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// fetchValues fetches the values of the columns `from` in the row, and casts them to the types of the columns `to`.
+// It returns nil if any of the values is null, which means the row doesn't need to be checked.
+func (fkc *fkChecker) fetchValues(row []types.Datum, from, to []*table.Column) ([]types.Datum, error) {
+	vals := make([]types.Datum, 0, len(from))
+	for i, col := range from {
+		if row[col.Offset].IsNull() {
+			return nil, nil
+		}
+		val, err := table.CastValue(fkc.ctx, row[col.Offset], to[i].ToInfo())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+func constraintDesc(ref *infoschema.ForeignKeyRef) string {
+	quote := func(names []model.CIStr) string {
+		strs := make([]string, 0, len(names))
+		for _, name := range names {
+			strs = append(strs, "`"+name.O+"`")
+		}
+		return strings.Join(strs, ", ")
+	}
+	fk := ref.FK
+	return fmt.Sprintf("`%s`.`%s`, CONSTRAINT `%s` FOREIGN KEY (%s) REFERENCES `%s` (%s)",
+		ref.ChildSchema.O, ref.Child.Name.O, fk.Name.O, quote(fk.Cols), fk.RefTable.O, quote(fk.RefCols))
+}
+
+// checkParentRows checks that the row written into the child table refers to the existing rows of the parent tables.
+// If modified is not nil, only the foreign keys on the modified columns are checked.
+func (fkc *fkChecker) checkParentRows(t table.Table, row []types.Datum, modified []bool) error {
+	if !fkc.enabled() {
+		return nil
+	}
+	for _, ref := range fkc.is.TableForeignKeys(t.Meta().ID) {
+		fk := ref.FK
+		cols := findColumns(t, fk.Cols)
+		if cols == nil || !columnsModified(cols, modified) {
+			continue
+		}
+		parent, err := fkc.is.TableByName(ref.ParentSchema, fk.RefTable)
+		if err != nil {
+			if infoschema.ErrTableNotExists.Equal(err) {
+				return ErrNoReferencedRow.GenByArgs(constraintDesc(ref))
+			}
+			return errors.Trace(err)
+		}
+		refCols := findColumns(parent, fk.RefCols)
+		if refCols == nil {
+			return ErrNoReferencedRow.GenByArgs(constraintDesc(ref))
+		}
+		vals, err := fkc.fetchValues(row, cols, refCols)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if vals == nil {
+			continue
+		}
+		if parent.Meta().ID == t.Meta().ID {
+			// The row may refer to itself.
+			match, err1 := fkc.matchValues(row, refCols, vals)
+			if err1 != nil {
+				return errors.Trace(err1)
+			}
+			if match {
+				continue
+			}
+		}
+		handles, _, err := fkc.findRows(parent, refCols, vals, true)
+		if errNoForeignKeyIndex.Equal(err) {
+			return ddl.ErrFkNoIndexParent.GenByArgs(fk.Name.O, parent.Meta().Name.O)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if len(handles) == 0 {
+			return ErrNoReferencedRow.GenByArgs(constraintDesc(ref))
+		}
+		// Lock the parent row, so the transaction deleting or updating it conflicts with this one.
+		if err = fkc.lockRows(parent, handles); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// lockRows locks the rows, the transactions writing them concurrently fail to commit.
+func (fkc *fkChecker) lockRows(t table.Table, handles []int64) error {
+	keys := make([]kv.Key, 0, len(handles))
+	for _, h := range handles {
+		keys = append(keys, t.RecordKey(h))
+	}
+	return errors.Trace(fkc.ctx.Txn().LockKeys(keys...))
+}
+
+func columnsModified(cols []*table.Column, modified []bool) bool {
+	if modified == nil {
+		return true
+	}
+	for _, col := range cols {
+		if modified[col.Offset] {
+			return true
+		}
+	}
+	return false
+}
+
+func (fkc *fkChecker) matchValues(row []types.Datum, cols []*table.Column, vals []types.Datum) (bool, error) {
+	sc := fkc.ctx.GetSessionVars().StmtCtx
+	for i, col := range cols {
+		d := codec.CollationKey(row[col.Offset], col.Collate)
+		val := codec.CollationKey(vals[i], col.Collate)
+		cmp, err := d.CompareDatum(sc, &val)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if cmp != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// findRows finds the rows whose values of the columns equal to vals, only the first row is found if onlyFirst is true.
+// The rows are found by the primary key or the index on the columns, it returns errNoForeignKeyIndex if there is none.
+func (fkc *fkChecker) findRows(t table.Table, cols []*table.Column, vals []types.Datum, onlyFirst bool) ([]int64, [][]types.Datum, error) {
+	if len(cols) == 1 && cols[0].IsPKHandleColumn(t.Meta()) {
+		h := vals[0].GetInt64()
+		row, err := t.RowWithCols(fkc.ctx, h, t.WritableCols())
+		if kv.IsErrNotFound(err) {
+			return nil, nil, nil
+		}
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		return []int64{h}, [][]types.Datum{row}, nil
+	}
+
+	var (
+		handles []int64
+		rows    [][]types.Datum
+	)
+	if idx := findIndexByColumns(t, cols); idx != nil {
+		var err error
+		handles, err = fkc.indexHandles(t, idx, vals, onlyFirst)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		for _, h := range handles {
+			row, err := t.RowWithCols(fkc.ctx, h, t.WritableCols())
+			if err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+			rows = append(rows, row)
+		}
+		return handles, rows, nil
+	}
+	return nil, nil, errNoForeignKeyIndex
+}
+
+// findIndexByColumns finds the public index whose leading columns are the columns.
+func findIndexByColumns(t table.Table, cols []*table.Column) table.Index {
+	for _, idx := range t.Indices() {
+		idxInfo := idx.Meta()
+		if idxInfo.State != model.StatePublic || len(idxInfo.Columns) < len(cols) {
+			continue
+		}
+		match := true
+		for i, col := range cols {
+			idxCol := idxInfo.Columns[i]
+			if idxCol.Offset != col.Offset || idxCol.Length != types.UnspecifiedLength {
+				match = false
+				break
+			}
+		}
+		if match {
+			return idx
+		}
+	}
+	return nil
+}
+
+// indexHandles returns the handles of the index entries whose leading values equal to vals.
+func (fkc *fkChecker) indexHandles(t table.Table, idx table.Index, vals []types.Datum, onlyFirst bool) ([]int64, error) {
+	// The index keeps the sort keys of the strings in case-insensitive collations unless it's of an old version.
+	var collations []string
+	if idx.Meta().HasCollationKeys() {
+		collations = make([]string, len(vals))
+		for i := range vals {
+			collations[i] = t.Cols()[idx.Meta().Columns[i].Offset].Collate
+		}
+	}
+	encoded, err := codec.EncodeKey(nil, codec.CollationKeys(vals, collations)...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	prefix := tablecodec.EncodeIndexSeekKey(t.Meta().ID, idx.Meta().ID, encoded)
+	it, err := fkc.ctx.Txn().Seek(prefix)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer it.Close()
+
+	var handles []int64
+	for it.Valid() && it.Key().HasPrefix(prefix) {
+		_, b, err := tablecodec.CutIndexKeyNew(it.Key(), len(idx.Meta().Columns))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var h int64
+		if len(b) > 0 {
+			// The handle is encoded in the key if the index isn't unique or the values contain null.
+			_, d, err := codec.DecodeOne(b)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			h = d.GetInt64()
+		} else {
+			h = int64(binary.BigEndian.Uint64(it.Value()))
+		}
+		handles = append(handles, h)
+		if onlyFirst {
+			break
+		}
+		if err = it.Next(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return handles, nil
+}
+
+// referringRows finds the rows of the child table which refer to the row of the parent table.
+func (fkc *fkChecker) referringRows(parent table.Table, h int64, row []types.Datum, ref *infoschema.ForeignKeyRef) (table.Table, []int64, [][]types.Datum, error) {
+	child, ok := fkc.is.TableByID(ref.Child.ID)
+	if !ok {
+		return nil, nil, nil, nil
+	}
+	cols := findColumns(child, ref.FK.Cols)
+	refCols := findColumns(parent, ref.FK.RefCols)
+	if cols == nil || refCols == nil {
+		return nil, nil, nil, nil
+	}
+	vals, err := fkc.fetchValues(row, refCols, cols)
+	if err != nil || vals == nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	handles, rows, err := fkc.findRows(child, cols, vals, false)
+	if errNoForeignKeyIndex.Equal(err) {
+		return nil, nil, nil, ddl.ErrFkNoIndexChild.GenByArgs(ref.FK.Name.O, child.Meta().Name.O)
+	}
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	if child.Meta().ID == parent.Meta().ID {
+		// The row referring to itself doesn't restrict the writing.
+		for i := range handles {
+			if handles[i] == h {
+				handles = append(handles[:i], handles[i+1:]...)
+				rows = append(rows[:i], rows[i+1:]...)
+				break
+			}
+		}
+	}
+	// Lock the child rows, so the transaction writing them concurrently conflicts with this one.
+	if err = fkc.lockRows(child, handles); err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	return child, handles, rows, nil
+}
+
+// onDeleteRow applies the ON DELETE actions of the foreign keys referring to the row deleted from the parent table.
+func (fkc *fkChecker) onDeleteRow(t table.Table, h int64, row []types.Datum, depth int) error {
+	if !fkc.enabled() {
+		return nil
+	}
+	for _, ref := range fkc.is.ReferredForeignKeys(t.Meta().ID) {
+		child, handles, rows, err := fkc.referringRows(t, h, row, ref)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if len(handles) == 0 {
+			continue
+		}
+		switch ast.ReferOptionType(ref.FK.OnDelete) {
+		case ast.ReferOptionCascade:
+			if depth >= maxForeignKeyCascadeDepth {
+				return ErrFkDepthExceeded.GenByArgs(maxForeignKeyCascadeDepth)
+			}
+			for i, ch := range handles {
+				if err = fkc.removeRow(child, ch, rows[i], depth+1); err != nil {
+					return errors.Trace(err)
+				}
+			}
+		case ast.ReferOptionSetNull:
+			if depth >= maxForeignKeyCascadeDepth {
+				return ErrFkDepthExceeded.GenByArgs(maxForeignKeyCascadeDepth)
+			}
+			cols := findColumns(child, ref.FK.Cols)
+			for i, ch := range handles {
+				newRow := make([]types.Datum, len(rows[i]))
+				copy(newRow, rows[i])
+				for _, col := range cols {
+					newRow[col.Offset].SetNull()
+				}
+				if err = fkc.updateRow(child, ch, rows[i], newRow, depth+1); err != nil {
+					return errors.Trace(err)
+				}
+			}
+		default:
+			// RESTRICT and NO ACTION are the same in MySQL.
+			return ErrRowIsReferenced.GenByArgs(constraintDesc(ref))
+		}
+	}
+	return nil
+}
+
+// onUpdateRow applies the ON UPDATE actions of the foreign keys referring to the row updated in the parent table.
+func (fkc *fkChecker) onUpdateRow(t table.Table, h int64, oldRow, newRow []types.Datum, depth int) error {
+	if !fkc.enabled() {
+		return nil
+	}
+	sc := fkc.ctx.GetSessionVars().StmtCtx
+	for _, ref := range fkc.is.ReferredForeignKeys(t.Meta().ID) {
+		refCols := findColumns(t, ref.FK.RefCols)
+		if refCols == nil {
+			continue
+		}
+		changed := false
+		for _, col := range refCols {
+			cmp, err := newRow[col.Offset].CompareDatum(sc, &oldRow[col.Offset])
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if cmp != 0 {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			continue
+		}
+		child, handles, rows, err := fkc.referringRows(t, h, oldRow, ref)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if len(handles) == 0 {
+			continue
+		}
+		action := ast.ReferOptionType(ref.FK.OnUpdate)
+		if action != ast.ReferOptionCascade && action != ast.ReferOptionSetNull {
+			return ErrRowIsReferenced.GenByArgs(constraintDesc(ref))
+		}
+		if depth >= maxForeignKeyCascadeDepth {
+			return ErrFkDepthExceeded.GenByArgs(maxForeignKeyCascadeDepth)
+		}
+		cols := findColumns(child, ref.FK.Cols)
+		for i, ch := range handles {
+			newChildRow := make([]types.Datum, len(rows[i]))
+			copy(newChildRow, rows[i])
+			for j, col := range cols {
+				if action == ast.ReferOptionSetNull {
+					newChildRow[col.Offset].SetNull()
+					continue
+				}
+				newChildRow[col.Offset], err = table.CastValue(fkc.ctx, newRow[refCols[j].Offset], col.ToInfo())
+				if err != nil {
+					return errors.Trace(err)
+				}
+			}
+			if err = fkc.updateRow(child, ch, rows[i], newChildRow, depth+1); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
+
+// removeRow removes the row of the child table by the cascading delete.
+func (fkc *fkChecker) removeRow(t table.Table, h int64, row []types.Datum, depth int) error {
+	if err := fkc.onDeleteRow(t, h, row, depth); err != nil {
+		return errors.Trace(err)
+	}
+	if err := t.RemoveRecord(fkc.ctx, h, row); err != nil {
+		return errors.Trace(err)
+	}
+	getDirtyDB(fkc.ctx).deleteRow(t.Meta().ID, h)
+	fkc.ctx.GetSessionVars().TxnCtx.UpdateDeltaForTable(t.Meta().ID, -1, 1)
+	return nil
+}
+
+// updateRow updates the row of the child table by the cascading update or set null.
+func (fkc *fkChecker) updateRow(t table.Table, h int64, oldRow, newRow []types.Datum, depth int) error {
+	if err := table.CheckNotNull(t.Cols(), newRow); err != nil {
+		return errors.Trace(err)
+	}
+	if err := fkc.onUpdateRow(t, h, oldRow, newRow, depth); err != nil {
+		return errors.Trace(err)
+	}
+
+	sc := fkc.ctx.GetSessionVars().StmtCtx
+	modified := make([]bool, len(newRow))
+	handleChanged := false
+	for i, col := range t.WritableCols() {
+		cmp, err := newRow[i].CompareDatum(sc, &oldRow[i])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if cmp != 0 {
+			modified[i] = true
+			if col.IsPKHandleColumn(t.Meta()) {
+				handleChanged = true
+			}
+		}
+	}
+
+	newHandle := h
+	var err error
+	if handleChanged {
+		newHandle, err = t.AddRecord(fkc.ctx, newRow)
+		if err == nil {
+			err = t.RemoveRecord(fkc.ctx, h, oldRow)
+		}
+	} else {
+		err = t.UpdateRecord(fkc.ctx, h, oldRow, newRow, modified)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	dirtyDB := getDirtyDB(fkc.ctx)
+	dirtyDB.deleteRow(t.Meta().ID, h)
+	dirtyDB.addRow(t.Meta().ID, newHandle, newRow)
+	fkc.ctx.GetSessionVars().TxnCtx.UpdateDeltaForTable(t.Meta().ID, 0, 1)
+	return nil
+}
@@ -0,0 +1,443 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/mvmap"
+)
+
+// graceHashJoinPartitions is the number of partitions of the grace hash join.
+// The rows of both sides are partitioned by the hash of the join key, so the rows with the same join key
+// are always in the same partition, and every partition is joined with a much smaller hash table.
+const graceHashJoinPartitions = 16
+
+// maxGraceHashJoinLevel is the max level of the partitions. If the hash table of a partition exceeds the memory quota,
+// the partition is split into the partitions of the next level by another hash seed, until the max level is reached.
+// The partitions of the max level aren't split any more, since the rows with the same join key are always in the same
+// partition, so the hash table of them may exceed the memory quota.
+const maxGraceHashJoinLevel = 3
+
+// joinPartition is a partition of the grace hash join, it holds the spilled rows of both sides in two files.
+// Every small row is stored as its join key followed by the encoded row, every big row is stored as the encoded row.
+type joinPartition struct {
+	name        string
+	smallFile   *os.File
+	bigFile     *os.File
+	smallWriter *bufio.Writer
+	bigWriter   *bufio.Writer
+}
+
+func newJoinPartition(dir string, name string) (*joinPartition, error) {
+	smallFile, err := os.Create(filepath.Join(dir, "small-"+name))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	bigFile, err := os.Create(filepath.Join(dir, "big-"+name))
+	if err != nil {
+		terror.Log(errors.Trace(smallFile.Close()))
+		return nil, errors.Trace(err)
+	}
+	return &joinPartition{
+		name:        name,
+		smallFile:   smallFile,
+		bigFile:     bigFile,
+		smallWriter: bufio.NewWriter(smallFile),
+		bigWriter:   bufio.NewWriter(bigFile),
+	}, nil
+}
+
+func (p *joinPartition) writeSmallRow(joinKey, row []byte) error {
+	if err := writeRecord(p.smallWriter, joinKey); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(writeRecord(p.smallWriter, row))
+}
+
+func (p *joinPartition) writeBigRow(row []byte) error {
+	return errors.Trace(writeRecord(p.bigWriter, row))
+}
+
+// finishWriting flushes the written rows and rewinds the files for reading.
+func (p *joinPartition) finishWriting() error {
+	for _, w := range []*bufio.Writer{p.smallWriter, p.bigWriter} {
+		if err := w.Flush(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for _, f := range []*os.File{p.smallFile, p.bigFile} {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+func (p *joinPartition) close() {
+	terror.Log(errors.Trace(p.smallFile.Close()))
+	terror.Log(errors.Trace(p.bigFile.Close()))
+}
+
+// remove closes and removes the files of the partition.
+func (p *joinPartition) remove() {
+	p.close()
+	terror.Log(errors.Trace(os.Remove(p.smallFile.Name())))
+	terror.Log(errors.Trace(os.Remove(p.bigFile.Name())))
+}
+
+// writeRecord writes the length of the data followed by the data.
+func writeRecord(w *bufio.Writer, data []byte) error {
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(data)))
+	if _, err := w.Write(lenBuf[:n]); err != nil {
+		return errors.Trace(err)
+	}
+	_, err := w.Write(data)
+	return errors.Trace(err)
+}
+
+// readRecord reads the data written by writeRecord, it returns nil at the end of the file.
+func readRecord(r *bufio.Reader) ([]byte, error) {
+	length, err := binary.ReadUvarint(r)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	data := make([]byte, length)
+	_, err = io.ReadFull(r, data)
+	return data, errors.Trace(err)
+}
+
+// partitionIndex returns the partition of the rows with the join key at the level. The level is used as the hash seed,
+// so the rows in the same partition are spread over the partitions of the next level.
+func partitionIndex(joinKey []byte, level int) int {
+	h := fnv.New64a()
+	_, err := h.Write(joinKey)
+	terror.Log(errors.Trace(err))
+	// The hash is mixed with the seed by the finalizer of MurmurHash3, so all the bits of the result are affected.
+	x := h.Sum64() ^ uint64(level)*0x9e3779b97f4a7c15
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return int(x % graceHashJoinPartitions)
+}
+
+// spillHashTable moves the rows in the hash table to the partitions on disk, the following small rows are written to
+// the partitions directly.
+func (e *HashJoinExec) spillHashTable() error {
+	log.Infof("[hash join] hash table memory usage %d exceeds the quota %d, switch to grace hash join",
+		e.hashTableMemUsage, e.memQuota)
+	dir, err := createSpillDir(e.tmpDir, "tidb-hashjoin")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.partitions = make([]*joinPartition, 0, graceHashJoinPartitions)
+	defer func() {
+		// The partitions are removed by Close, the directory is removed here if no partition is created.
+		if len(e.partitions) == 0 {
+			e.partitions = nil
+			terror.Log(errors.Trace(os.RemoveAll(dir)))
+		}
+	}()
+	for i := 0; i < graceHashJoinPartitions; i++ {
+		p, err := newJoinPartition(dir, fmt.Sprintf("%d", i))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.partitions = append(e.partitions, p)
+	}
+	iter := e.hashTable.NewIterator()
+	for joinKey, row := iter.Next(); joinKey != nil; joinKey, row = iter.Next() {
+		if err = e.partitions[partitionIndex(joinKey, 0)].writeSmallRow(joinKey, row); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	e.hashTable = mvmap.NewMVMap()
+	terror.Log(e.memTracker.Consume(-e.hashTableMemUsage))
+	e.hashTableMemUsage = 0
+	return nil
+}
+
+// removePartitions closes and removes the spilled partitions.
+func (e *HashJoinExec) removePartitions() {
+	if e.partitions == nil {
+		return
+	}
+	for _, p := range e.partitions {
+		p.close()
+	}
+	dir := filepath.Dir(e.partitions[0].smallFile.Name())
+	terror.Log(errors.Trace(os.RemoveAll(dir)))
+	e.partitions = nil
+}
+
+// runGraceHashJoin partitions the big table rows to disk, then joins the partitions one by one.
+// It runs in a goroutine and sends the results to the result channel like the join workers.
+func (e *HashJoinExec) runGraceHashJoin() {
+	defer func() {
+		terror.Log(errors.Trace(e.bigExec.Close()))
+		e.wg.Done()
+	}()
+	if err := e.partitionBigExec(); err != nil {
+		e.resultCh <- &execResult{err: errors.Trace(err)}
+		return
+	}
+	for _, p := range e.partitions {
+		if e.finished.Load().(bool) {
+			return
+		}
+		if err := e.joinPartition(p, 0); err != nil {
+			e.resultCh <- &execResult{err: errors.Trace(err)}
+			return
+		}
+	}
+}
+
+// partitionBigExec writes all the big table rows to the partitions.
+func (e *HashJoinExec) partitionBigExec() error {
+	ctx := e.hashJoinContexts[0]
+	var buffer []byte
+	for {
+		if e.finished.Load().(bool) {
+			return nil
+		}
+		row, err := e.bigExec.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		hasNull, joinKey, err := getJoinKey(e.bigHashKey, row, ctx.datumBuffer, ctx.hashKeyBuffer[0:0:cap(ctx.hashKeyBuffer)])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// The rows with null join key never match, they are put into the first partition for the outer join.
+		idx := 0
+		if !hasNull {
+			idx = partitionIndex(joinKey, 0)
+		}
+		buffer, err = e.encodeRow(buffer[:0], row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = e.partitions[idx].writeBigRow(buffer); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for _, p := range e.partitions {
+		if err := p.finishWriting(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// joinPartition builds the hash table with the small rows of the partition and probes it with the big rows.
+// If the hash table exceeds the memory quota, the partition is split and joined by the partitions of the next level.
+func (e *HashJoinExec) joinPartition(p *joinPartition, level int) error {
+	fits, err := e.buildPartitionHashTable(p, level)
+	if err != nil || !fits {
+		e.releaseHashTable()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(e.repartition(p, level+1))
+	}
+	defer e.releaseHashTable()
+
+	maxRowsCnt := 1000
+	result := &execResult{rows: make([]Row, 0, maxRowsCnt)}
+	bigReader := bufio.NewReader(p.bigFile)
+	for {
+		if e.finished.Load().(bool) {
+			return nil
+		}
+		data, err := readRecord(bigReader)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if data == nil {
+			break
+		}
+		bigRow, err := e.decodeRow(data, e.bigExec.Schema())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !e.joinOneBigRow(e.hashJoinContexts[0], bigRow, result) {
+			return errors.Trace(result.err)
+		}
+		if len(result.rows) >= maxRowsCnt {
+			e.resultCh <- result
+			result = &execResult{rows: make([]Row, 0, maxRowsCnt)}
+		}
+	}
+	if len(result.rows) > 0 {
+		e.resultCh <- result
+	}
+	return nil
+}
+
+// buildPartitionHashTable builds the hash table with the small rows of the partition.
+// It returns false if the hash table exceeds the memory quota and the partition can be split.
+func (e *HashJoinExec) buildPartitionHashTable(p *joinPartition, level int) (bool, error) {
+	e.hashTable = mvmap.NewMVMap()
+	e.hashTableMemUsage = 0
+	smallReader := bufio.NewReader(p.smallFile)
+	for {
+		joinKey, err := readRecord(smallReader)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if joinKey == nil {
+			return true, nil
+		}
+		row, err := readRecord(smallReader)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		e.hashTable.Put(joinKey, row)
+		memUsage := e.hashTable.MemoryUsage()
+		err = e.memTracker.Consume(memUsage - e.hashTableMemUsage)
+		e.hashTableMemUsage = memUsage
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if level < maxGraceHashJoinLevel && e.memQuota > 0 && e.hashTableMemUsage > e.memQuota {
+			return false, nil
+		}
+	}
+}
+
+// releaseHashTable releases the hash table of the partition.
+func (e *HashJoinExec) releaseHashTable() {
+	e.hashTable = mvmap.NewMVMap()
+	terror.Log(e.memTracker.Consume(-e.hashTableMemUsage))
+	e.hashTableMemUsage = 0
+}
+
+// repartition splits the rows of the partition into the partitions of the level, then joins them one by one.
+// The partitions are created when the first row is written, and removed after they are joined.
+func (e *HashJoinExec) repartition(p *joinPartition, level int) error {
+	log.Infof("[hash join] hash table of partition %s exceeds the quota %d, split it at level %d",
+		p.name, e.memQuota, level)
+	dir := filepath.Dir(p.smallFile.Name())
+	subs := make([]*joinPartition, graceHashJoinPartitions)
+	defer func() {
+		for _, sub := range subs {
+			if sub != nil {
+				sub.remove()
+			}
+		}
+	}()
+	getSub := func(idx int) (*joinPartition, error) {
+		if subs[idx] == nil {
+			sub, err := newJoinPartition(dir, fmt.Sprintf("%s-%d", p.name, idx))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			subs[idx] = sub
+		}
+		return subs[idx], nil
+	}
+
+	if _, err := p.smallFile.Seek(0, io.SeekStart); err != nil {
+		return errors.Trace(err)
+	}
+	smallReader := bufio.NewReader(p.smallFile)
+	for {
+		joinKey, err := readRecord(smallReader)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if joinKey == nil {
+			break
+		}
+		row, err := readRecord(smallReader)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		sub, err := getSub(partitionIndex(joinKey, level))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = sub.writeSmallRow(joinKey, row); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	ctx := e.hashJoinContexts[0]
+	bigReader := bufio.NewReader(p.bigFile)
+	for {
+		if e.finished.Load().(bool) {
+			return nil
+		}
+		data, err := readRecord(bigReader)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if data == nil {
+			break
+		}
+		bigRow, err := e.decodeRow(data, e.bigExec.Schema())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		hasNull, joinKey, err := getJoinKey(e.bigHashKey, bigRow, ctx.datumBuffer, ctx.hashKeyBuffer[0:0:cap(ctx.hashKeyBuffer)])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// The rows with null join key are kept in the first partition like partitionBigExec.
+		idx := 0
+		if !hasNull {
+			idx = partitionIndex(joinKey, level)
+		}
+		sub, err := getSub(idx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = sub.writeBigRow(data); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	for _, sub := range subs {
+		if sub == nil {
+			continue
+		}
+		if err := sub.finishWriting(); err != nil {
+			return errors.Trace(err)
+		}
+		if e.finished.Load().(bool) {
+			return nil
+		}
+		if err := e.joinPartition(sub, level); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
@@ -26,7 +26,7 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/table"
-	"github.com/pingcap/tidb/util/auth"
+	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/sqlexec"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -74,18 +74,14 @@ func (e *GrantExec) Next() (Row, error) {
 			return nil, errors.Trace(err)
 		}
 		if !exists {
-			pwd := ""
-			if user.AuthOpt != nil {
-				if user.AuthOpt.ByAuthString {
-					pwd = auth.EncodePassword(user.AuthOpt.AuthString)
-				} else {
-					pwd = auth.EncodePassword(user.AuthOpt.HashString)
-				}
+			pwd, plugin, err := encodePassword(user.AuthOpt, mysql.AuthNativePassword)
+			if err != nil {
+				return nil, errors.Trace(err)
 			}
 
-			user := fmt.Sprintf(`("%s", "%s", "%s")`, user.User.Hostname, user.User.Username, pwd)
-			sql := fmt.Sprintf(`INSERT INTO %s.%s (Host, User, Password) VALUES %s;`, mysql.SystemDB, mysql.UserTable, user)
-			_, err := e.ctx.(sqlexec.SQLExecutor).Execute(sql)
+			user := fmt.Sprintf(`("%s", "%s", "%s", "%s")`, user.User.Hostname, user.User.Username, pwd, plugin)
+			sql := fmt.Sprintf(`INSERT INTO %s.%s (Host, User, Password, plugin) VALUES %s;`, mysql.SystemDB, mysql.UserTable, user)
+			_, err = e.ctx.(sqlexec.SQLExecutor).Execute(sql)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
@@ -132,6 +128,11 @@ func (e *GrantExec) Next() (Row, error) {
 	return nil, nil
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *GrantExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 // Close implements the Executor Close interface.
 func (e *GrantExec) Close() error {
 	return nil
@@ -120,7 +120,8 @@ func (e *IndexLookUpJoin) Next() (Row, error) {
 					if err != nil {
 						return nil, errors.Trace(err)
 					}
-					joinDatums = append(joinDatums, innerDatum)
+					// The index of the inner table keeps the sort keys of the strings in case-insensitive collations.
+					joinDatums = append(joinDatums, codec.CollationKey(innerDatum, e.innerJoinKeys[i].GetType().Collate))
 				}
 				joinOuterEncodeKey, err := codec.EncodeKey(nil, joinDatums...)
 				if err != nil {
@@ -189,7 +190,7 @@ func (e *IndexLookUpJoin) doJoin() error {
 			if err2 != nil {
 				return errors.Trace(err2)
 			}
-			joinDatums = append(joinDatums, datum)
+			joinDatums = append(joinDatums, codec.CollationKey(datum, col.GetType().Collate))
 		}
 		joinKey, err1 := codec.EncodeKey(nil, joinDatums...)
 		if err1 != nil {
@@ -22,7 +22,9 @@ import (
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/mvmap"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -59,10 +61,21 @@ type HashJoinExec struct {
 	closeCh chan struct{}
 
 	rows []Row
+	// hashTableMemUsage is the memory usage of the hash table when it's built.
+	hashTableMemUsage int64
 	// concurrency is number of concurrent channels.
 	concurrency      int
 	bigTableResultCh []chan *execResult
 	hashJoinContexts []*hashJoinCtx
+	memTracker       *memory.Tracker
+
+	// memQuota is the memory quota of the hash table, the grace hash join is used when it's exceeded.
+	// 0 means the grace hash join is never used.
+	memQuota int64
+	// tmpDir is the directory where the spilled partitions are created.
+	tmpDir string
+	// partitions are the spilled partitions of the grace hash join, it's nil if the hash table fits in the memory.
+	partitions []*joinPartition
 
 	// Channels for output.
 	resultCh chan *execResult
@@ -77,6 +90,11 @@ type hashJoinCtx struct {
 	hashKeyBuffer []byte
 }
 
+// memoryUsage implements the memoryUsageReporter interface, only the hash table of the small table is counted.
+func (e *HashJoinExec) memoryUsage() int64 {
+	return e.hashTableMemUsage
+}
+
 // Close implements the Executor Close interface.
 func (e *HashJoinExec) Close() error {
 	e.finished.Store(true)
@@ -85,12 +103,16 @@ func (e *HashJoinExec) Close() error {
 		}
 		<-e.closeCh
 	}
+	e.removePartitions()
 	e.rows = nil
+	e.memTracker.Detach()
 	return nil
 }
 
 // Open implements the Executor Open interface.
 func (e *HashJoinExec) Open() error {
+	e.memTracker = memory.NewTracker("HashJoinExec", -1)
+	e.memTracker.AttachTo(e.ctx.GetSessionVars().StmtCtx.MemTracker)
 	e.closeCh = make(chan struct{})
 	e.finished.Store(false)
 	e.bigTableResultCh = make([]chan *execResult, e.concurrency)
@@ -127,6 +149,8 @@ func getJoinKey(cols []*expression.Column, row Row, vals []types.Datum, bytes []
 		if vals[i].IsNull() {
 			return true, nil, nil
 		}
+		// The strings equal by the collation must have the same hash key.
+		vals[i] = codec.CollationKey(vals[i], col.RetType.Collate)
 	}
 	if len(vals) == 0 {
 		return false, nil, nil
@@ -201,14 +225,12 @@ func (e *HashJoinExec) fetchBigExec() {
 	}
 }
 
-// prepare runs the first time when 'Next' is called, it starts one worker goroutine to fetch rows from the big table,
-// and reads all data from the small table to build a hash table, then starts multiple join worker goroutines.
+// prepare runs the first time when 'Next' is called, it reads all data from the small table to build a hash table,
+// then starts one worker goroutine to fetch rows from the big table and multiple join worker goroutines.
+// If the hash table exceeds the memory quota, the rows are spilled to disk and joined by the grace hash join.
 func (e *HashJoinExec) prepare() error {
-	// Start a worker to fetch big table rows.
-	e.wg.Add(1)
-	go e.fetchBigExec()
-
 	e.hashTable = mvmap.NewMVMap()
+	e.hashTableMemUsage = 0
 	e.cursor = 0
 	var buffer []byte
 	for {
@@ -240,14 +262,40 @@ func (e *HashJoinExec) prepare() error {
 		if err != nil {
 			return errors.Trace(err)
 		}
+		if e.partitions != nil {
+			err = e.partitions[partitionIndex(joinKey, 0)].writeSmallRow(joinKey, buffer)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			continue
+		}
 		e.hashTable.Put(joinKey, buffer)
+		memUsage := e.hashTable.MemoryUsage()
+		err = e.memTracker.Consume(memUsage - e.hashTableMemUsage)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.hashTableMemUsage = memUsage
+		if e.memQuota > 0 && e.hashTableMemUsage > e.memQuota {
+			if err = e.spillHashTable(); err != nil {
+				return errors.Trace(err)
+			}
+		}
 	}
 
 	e.resultCh = make(chan *execResult, e.concurrency)
 
-	for i := 0; i < e.concurrency; i++ {
+	if e.partitions != nil {
+		e.wg.Add(1)
+		go e.runGraceHashJoin()
+	} else {
+		// Start a worker to fetch big table rows.
 		e.wg.Add(1)
-		go e.runJoinWorker(i)
+		go e.fetchBigExec()
+		for i := 0; i < e.concurrency; i++ {
+			e.wg.Add(1)
+			go e.runJoinWorker(i)
+		}
 	}
 	go e.waitJoinWorkersAndCloseResultChan()
 
@@ -267,13 +315,13 @@ func (e *HashJoinExec) encodeRow(b []byte, row Row) ([]byte, error) {
 	return b, nil
 }
 
-func (e *HashJoinExec) decodeRow(data []byte) (Row, error) {
-	values := make([]types.Datum, e.smallExec.Schema().Len())
+func (e *HashJoinExec) decodeRow(data []byte, schema *expression.Schema) (Row, error) {
+	values := make([]types.Datum, schema.Len())
 	err := codec.SetRawValues(data, values)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	err = decodeRawValues(values, e.smallExec.Schema(), e.ctx.GetSessionVars().GetTimeZone())
+	err = decodeRawValues(values, schema, e.ctx.GetSessionVars().GetTimeZone())
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -375,7 +423,7 @@ func (e *HashJoinExec) constructMatchedRows(ctx *hashJoinCtx, bigRow Row) (match
 	// match eq condition
 	for _, value := range values {
 		var smallRow Row
-		smallRow, err = e.decodeRow(value)
+		smallRow, err = e.decodeRow(value, e.smallExec.Schema())
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -443,6 +491,11 @@ func (e *HashJoinExec) Next() (Row, error) {
 	return row, nil
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *HashJoinExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 // joinExec is the common interface of join algorithm except for hash join.
 type joinExec interface {
 	Executor
@@ -612,6 +665,11 @@ func (e *NestedLoopJoinExec) Next() (Row, error) {
 	}
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *NestedLoopJoinExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 // HashSemiJoinExec implements the hash join algorithm for semi join.
 type HashSemiJoinExec struct {
 	hashTable    map[string][]Row
@@ -802,6 +860,11 @@ func (e *HashSemiJoinExec) Next() (Row, error) {
 	}
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *HashSemiJoinExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 // ApplyJoinExec is the new logic of apply.
 type ApplyJoinExec struct {
 	join        joinExec
@@ -854,3 +917,8 @@ func (e *ApplyJoinExec) Next() (Row, error) {
 		e.cursor = 0
 	}
 }
+
+// NextChunk implements the Executor NextChunk interface.
+func (e *ApplyJoinExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
@@ -15,11 +15,13 @@ package executor_test
 
 import (
 	"fmt"
+	"io/ioutil"
 	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/mysql"
@@ -239,6 +241,50 @@ func (s *testSuite) TestJoin(c *C) {
 	tk.MustQuery("select /*+ TIDB_INLJ(t1) */ t1.a from t1, t where t.a = 5 and t.b = t1.a").Check(testkit.Rows("3"))
 }
 
+func (s *testSuite) TestGraceHashJoin(c *C) {
+	tmpDir := c.MkDir()
+	cfg := config.GetGlobalConfig()
+	originTmpDir := cfg.TmpStoragePath
+	cfg.TmpStoragePath = tmpDir
+	defer func() {
+		cfg.TmpStoragePath = originTmpDir
+	}()
+
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1 (a int, b varchar(20), c datetime)")
+	tk.MustExec("create table t2 (a int, b varchar(20), d decimal(10, 2))")
+	for i := 0; i < 60; i++ {
+		tk.MustExec(fmt.Sprintf("insert into t1 values (%d, 'b%d', '2017-01-%02d 10:00:00')", i%20, i%15, i%28+1))
+		tk.MustExec(fmt.Sprintf("insert into t2 values (%d, 'b%d', %d.5)", i%30, i%10, i))
+	}
+	tk.MustExec("insert into t1 values (null, null, null)")
+	tk.MustExec("insert into t2 values (null, null, null)")
+
+	queries := []string{
+		"select * from t1 join t2 on t1.a = t2.a order by t1.a, t1.b, t1.c, t2.b, t2.d",
+		"select * from t1 join t2 on t1.a = t2.a and t1.b = t2.b order by t1.a, t1.c, t2.d",
+		"select * from t1 left join t2 on t1.a = t2.a and t2.d > 20 order by t1.a, t1.b, t1.c, t2.b, t2.d",
+		"select * from t1 right join t2 on t1.b = t2.b order by t2.a, t2.b, t2.d, t1.a, t1.c",
+		"select t1.a, t2.d from t1 join t2 on t1.a = t2.a and t1.c < t2.d order by t1.a, t2.d",
+	}
+	expected := make([][][]interface{}, len(queries))
+	tk.MustExec("set @@tidb_mem_quota_hashjoin = 0")
+	for i, sql := range queries {
+		expected[i] = tk.MustQuery(sql).Rows()
+	}
+	// The hash table exceeds the quota once the first row is put, so the grace hash join is used.
+	tk.MustExec("set @@tidb_mem_quota_hashjoin = 1")
+	for i, sql := range queries {
+		tk.MustQuery(sql).Check(expected[i])
+	}
+	// The spilled partitions are removed when the join is closed.
+	files, err := ioutil.ReadDir(tmpDir)
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 0)
+}
+
 func (s *testSuite) TestJoinCast(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 
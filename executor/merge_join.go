@@ -20,6 +20,8 @@ import (
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -315,6 +317,9 @@ func compareKeys(stmtCtx *variable.StatementContext,
 			return 0, errors.Trace(err)
 		}
 
+		// The rows are sorted by the collations of the keys.
+		lVal = codec.CollationKey(lVal, leftKey.RetType.Collate)
+		rVal = codec.CollationKey(rVal, rightKeys[i].RetType.Collate)
 		ret, err := lVal.CompareDatum(stmtCtx, &rVal)
 		if err != nil {
 			return 0, errors.Trace(err)
@@ -521,3 +526,8 @@ func (e *MergeJoinExec) Next() (Row, error) {
 	e.cursor++
 	return row, nil
 }
+
+// NextChunk implements the Executor NextChunk interface.
+func (e *MergeJoinExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
@@ -149,7 +149,7 @@ func StatementLabel(node ast.StmtNode, p plan.Plan, isExpensive *bool) string {
 		return RollBack
 	case *ast.SelectStmt:
 		return getSelectStmtLabel(x, p, isExpensive)
-	case *ast.SetStmt, *ast.SetPwdStmt:
+	case *ast.SetStmt, *ast.SetPwdStmt, *ast.SetRoleStmt, *ast.SetDefaultRoleStmt:
 		return Set
 	case *ast.ShowStmt:
 		return Show
@@ -157,9 +157,9 @@ func StatementLabel(node ast.StmtNode, p plan.Plan, isExpensive *bool) string {
 		return TruncateTable
 	case *ast.UpdateStmt:
 		return getUpdateStmtLabel(x, p, isExpensive)
-	case *ast.GrantStmt:
+	case *ast.GrantStmt, *ast.GrantRoleStmt:
 		return Grant
-	case *ast.RevokeStmt:
+	case *ast.RevokeStmt, *ast.RevokeRoleStmt:
 		return Revoke
 	case *ast.DeallocateStmt, *ast.ExecuteStmt, *ast.PrepareStmt, *ast.UseStmt:
 		return IGNORE
@@ -23,18 +23,21 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/statistics"
 	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
 )
 
 func analyzeIndexPushdown(idxExec *AnalyzeIndexExec) statistics.AnalyzeResult {
-	hist, err := idxExec.buildHistogram()
+	hist, cms, err := idxExec.buildStats()
 	if err != nil {
 		return statistics.AnalyzeResult{Err: err}
 	}
 	result := statistics.AnalyzeResult{
 		TableID: idxExec.tblInfo.ID,
 		Hist:    []*statistics.Histogram{hist},
+		Cms:     []*statistics.CMSketch{cms},
 		IsIndex: 1,
 	}
 	if len(hist.Buckets) > 0 {
@@ -52,6 +55,9 @@ type AnalyzeIndexExec struct {
 	priority    int
 	analyzePB   *tipb.AnalyzeReq
 	result      distsql.NewSelectResult
+	// cmsSrc scans the index in TiDB to build the CM sketch from every row,
+	// because the analyze responses of the coprocessor don't carry it.
+	cmsSrc Executor
 }
 
 func (e *AnalyzeIndexExec) open() error {
@@ -61,7 +67,7 @@ func (e *AnalyzeIndexExec) open() error {
 	}
 	idxRange := &types.IndexRange{LowVal: []types.Datum{types.MinNotNullDatum()}, HighVal: []types.Datum{types.MaxValueDatum()}}
 	var builder requestBuilder
-	kvReq, err := builder.SetIndexRanges(e.ctx.GetSessionVars().StmtCtx, e.tblInfo.ID, e.idxInfo.ID, []*types.IndexRange{idxRange}, fieldTypes).
+	kvReq, err := builder.SetIndexRanges(e.ctx.GetSessionVars().StmtCtx, []int64{e.tblInfo.ID}, e.idxInfo.ID, []*types.IndexRange{idxRange}, fieldTypes).
 		SetAnalyzeRequest(e.analyzePB).
 		SetKeepOrder(true).
 		SetPriority(e.priority).
@@ -76,13 +82,14 @@ func (e *AnalyzeIndexExec) open() error {
 	return nil
 }
 
-func (e *AnalyzeIndexExec) buildHistogram() (hist *statistics.Histogram, err error) {
+func (e *AnalyzeIndexExec) buildStats() (hist *statistics.Histogram, cms *statistics.CMSketch, err error) {
 	if err = e.open(); err != nil {
-		return nil, errors.Trace(err)
+		return nil, nil, errors.Trace(err)
 	}
 	defer func() {
 		if err1 := e.result.Close(); err1 != nil {
 			hist = nil
+			cms = nil
 			err = errors.Trace(err1)
 		}
 	}()
@@ -90,7 +97,7 @@ func (e *AnalyzeIndexExec) buildHistogram() (hist *statistics.Histogram, err err
 	for {
 		data, err := e.result.NextRaw()
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, nil, errors.Trace(err)
 		}
 		if data == nil {
 			break
@@ -98,25 +105,56 @@ func (e *AnalyzeIndexExec) buildHistogram() (hist *statistics.Histogram, err err
 		resp := &tipb.AnalyzeIndexResp{}
 		err = resp.Unmarshal(data)
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, nil, errors.Trace(err)
 		}
 		hist, err = statistics.MergeHistograms(e.ctx.GetSessionVars().StmtCtx, hist, statistics.HistogramFromProto(resp.Hist), maxBucketSize)
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, nil, errors.Trace(err)
 		}
 	}
 	hist.ID = e.idxInfo.ID
-	return hist, nil
+	cms, err = e.buildCMSketch()
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return hist, cms, nil
+}
+
+// buildCMSketch builds the CM sketch from the index values encoded in the same way as BuildIndex.
+func (e *AnalyzeIndexExec) buildCMSketch() (*statistics.CMSketch, error) {
+	if err := e.cmsSrc.Open(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	cms := statistics.NewCMSketch(cmSketchDepth, cmSketchWidth)
+	rs := &recordSet{executor: e.cmsSrc}
+	for {
+		row, err := rs.Next()
+		if err != nil {
+			terror.Log(errors.Trace(e.cmsSrc.Close()))
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		bytes, err := codec.EncodeKey(nil, row.Data...)
+		if err != nil {
+			terror.Log(errors.Trace(e.cmsSrc.Close()))
+			return nil, errors.Trace(err)
+		}
+		cms.InsertBytes(bytes)
+	}
+	return cms, errors.Trace(e.cmsSrc.Close())
 }
 
 func analyzeColumnsPushdown(colExec *AnalyzeColumnsExec) statistics.AnalyzeResult {
-	hists, err := colExec.buildHistograms()
+	hists, cms, err := colExec.buildStats()
 	if err != nil {
 		return statistics.AnalyzeResult{Err: err}
 	}
 	result := statistics.AnalyzeResult{
 		TableID: colExec.tblInfo.ID,
 		Hist:    hists,
+		Cms:     cms,
 	}
 	hist := hists[0]
 	result.Count = hist.NullCount
@@ -137,12 +175,15 @@ type AnalyzeColumnsExec struct {
 	keepOrder   bool
 	analyzePB   *tipb.AnalyzeReq
 	result      distsql.NewSelectResult
+	// cmsSrc scans the columns in TiDB to build the CM sketches from every row,
+	// because the analyze responses of the coprocessor only carry the samples. It's nil if only the PK is analyzed.
+	cmsSrc Executor
 }
 
 func (e *AnalyzeColumnsExec) open() error {
 	ranges := []types.IntColumnRange{{LowVal: math.MinInt64, HighVal: math.MaxInt64}}
 	var builder requestBuilder
-	kvReq, err := builder.SetTableRanges(e.tblInfo.ID, ranges).
+	kvReq, err := builder.SetTableRanges([]int64{e.tblInfo.ID}, ranges).
 		SetAnalyzeRequest(e.analyzePB).
 		SetKeepOrder(e.keepOrder).
 		SetPriority(e.priority).
@@ -160,13 +201,14 @@ func (e *AnalyzeColumnsExec) open() error {
 	return nil
 }
 
-func (e *AnalyzeColumnsExec) buildHistograms() (hists []*statistics.Histogram, err error) {
+func (e *AnalyzeColumnsExec) buildStats() (hists []*statistics.Histogram, cms []*statistics.CMSketch, err error) {
 	if err = e.open(); err != nil {
-		return nil, errors.Trace(err)
+		return nil, nil, errors.Trace(err)
 	}
 	defer func() {
 		if err1 := e.result.Close(); err1 != nil {
 			hists = nil
+			cms = nil
 			err = errors.Trace(err1)
 		}
 	}()
@@ -182,7 +224,7 @@ func (e *AnalyzeColumnsExec) buildHistograms() (hists []*statistics.Histogram, e
 	for {
 		data, err1 := e.result.NextRaw()
 		if err1 != nil {
-			return nil, errors.Trace(err1)
+			return nil, nil, errors.Trace(err1)
 		}
 		if data == nil {
 			break
@@ -190,45 +232,80 @@ func (e *AnalyzeColumnsExec) buildHistograms() (hists []*statistics.Histogram, e
 		resp := &tipb.AnalyzeColumnsResp{}
 		err = resp.Unmarshal(data)
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, nil, errors.Trace(err)
 		}
 		if e.pkInfo != nil {
 			pkHist, err = statistics.MergeHistograms(e.ctx.GetSessionVars().StmtCtx, pkHist, statistics.HistogramFromProto(resp.PkHist), maxBucketSize)
 			if err != nil {
-				return nil, errors.Trace(err)
+				return nil, nil, errors.Trace(err)
 			}
 		}
 		for i, rc := range resp.Collectors {
 			collectors[i].MergeSampleCollector(statistics.SampleCollectorFromProto(rc))
 		}
 	}
+	colsCMS, err := e.buildCMSketches()
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
 	timeZone := e.ctx.GetSessionVars().GetTimeZone()
 	if e.pkInfo != nil {
 		pkHist.ID = e.pkInfo.ID
 		for i, bkt := range pkHist.Buckets {
 			pkHist.Buckets[i].LowerBound, err = tablecodec.DecodeColumnValue(bkt.LowerBound.GetBytes(), &e.pkInfo.FieldType, timeZone)
 			if err != nil {
-				return nil, errors.Trace(err)
+				return nil, nil, errors.Trace(err)
 			}
 			pkHist.Buckets[i].UpperBound, err = tablecodec.DecodeColumnValue(bkt.UpperBound.GetBytes(), &e.pkInfo.FieldType, timeZone)
 			if err != nil {
-				return nil, errors.Trace(err)
+				return nil, nil, errors.Trace(err)
 			}
 		}
 		hists = append(hists, pkHist)
+		cms = append(cms, nil)
 	}
 	for i, col := range e.colsInfo {
 		for j, s := range collectors[i].Samples {
 			collectors[i].Samples[j], err = tablecodec.DecodeColumnValue(s.GetBytes(), &col.FieldType, timeZone)
 			if err != nil {
-				return nil, errors.Trace(err)
+				return nil, nil, errors.Trace(err)
 			}
 		}
 		hg, err := statistics.BuildColumn(e.ctx, maxBucketSize, col.ID, collectors[i])
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, nil, errors.Trace(err)
 		}
 		hists = append(hists, hg)
+		cms = append(cms, colsCMS[i])
+	}
+	return hists, cms, nil
+}
+
+// buildCMSketches builds the CM sketches of the columns, the PK handle isn't scanned.
+func (e *AnalyzeColumnsExec) buildCMSketches() ([]*statistics.CMSketch, error) {
+	if len(e.colsInfo) == 0 {
+		return nil, nil
+	}
+	if err := e.cmsSrc.Open(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	rs := &cmSketchRecordSet{
+		RecordSet: &recordSet{executor: e.cmsSrc},
+		sc:        e.ctx.GetSessionVars().StmtCtx,
+		cms:       make([]*statistics.CMSketch, len(e.colsInfo)),
+	}
+	for i := range rs.cms {
+		rs.cms[i] = statistics.NewCMSketch(cmSketchDepth, cmSketchWidth)
+	}
+	for {
+		row, err := rs.Next()
+		if err != nil {
+			terror.Log(errors.Trace(e.cmsSrc.Close()))
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
 	}
-	return hists, nil
+	return rs.cms, errors.Trace(e.cmsSrc.Close())
 }
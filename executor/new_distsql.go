@@ -28,6 +28,8 @@ import (
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/execdetails"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
 	goctx "golang.org/x/net/context"
@@ -54,6 +56,28 @@ func handleIsExtra(col *expression.Column) bool {
 	return false
 }
 
+// fillChunkByRows fills the Chunk with the decoded rows returned by the reader.
+func fillChunkByRows(reader Executor, chk *chunk.Chunk) error {
+	chk.Reset()
+	for chk.NumRows() < maxChunkSize {
+		row, err := reader.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			return nil
+		}
+		if chk.NumCols() == 0 {
+			chk.SetNumVirtualRows(chk.NumRows() + 1)
+			continue
+		}
+		for i := range row {
+			chk.AppendDatum(i, &row[i])
+		}
+	}
+	return nil
+}
+
 // TableReaderExecutor sends dag request and reads table data from kv layer.
 type TableReaderExecutor struct {
 	table     table.Table
@@ -64,6 +88,8 @@ type TableReaderExecutor struct {
 	dagPB     *tipb.DAGRequest
 	ctx       context.Context
 	schema    *expression.Schema
+	// partitionIDs are the IDs of the partitions to read if the table is partitioned.
+	partitionIDs []int64
 	// columns are only required by union scan.
 	columns []*model.ColumnInfo
 
@@ -71,6 +97,8 @@ type TableReaderExecutor struct {
 	result        distsql.NewSelectResult
 	partialResult distsql.NewPartialResult
 	priority      int
+	// copStats records the information of the coprocessor tasks for EXPLAIN ANALYZE, it may be nil.
+	copStats *execdetails.CopRuntimeStats
 }
 
 // Schema implements the Executor Schema interface.
@@ -117,14 +145,24 @@ func (e *TableReaderExecutor) Next() (Row, error) {
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		e.ctx.GetSessionVars().StmtCtx.AddExaminedRows(1)
 		return rowData, nil
 	}
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *TableReaderExecutor) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(fillChunkByRows(e, chk))
+}
+
+func (e *TableReaderExecutor) supportChunk() bool {
+	return true
+}
+
 // Open implements the Executor Open interface.
 func (e *TableReaderExecutor) Open() error {
 	var builder requestBuilder
-	kvReq, err := builder.SetTableRanges(e.tableID, e.ranges).
+	kvReq, err := builder.SetTableRanges(physicalTableIDs(e.tableID, e.partitionIDs), e.ranges).
 		SetDAGRequest(e.dagPB).
 		SetDesc(e.desc).
 		SetKeepOrder(e.keepOrder).
@@ -138,6 +176,7 @@ func (e *TableReaderExecutor) Open() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	e.result.SetCopRuntimeStats(e.copStats)
 	e.result.Fetch(e.ctx.GoCtx())
 	return nil
 }
@@ -146,7 +185,7 @@ func (e *TableReaderExecutor) Open() error {
 func (e *TableReaderExecutor) doRequestForHandles(handles []int64, goCtx goctx.Context) error {
 	sort.Sort(int64Slice(handles))
 	var builder requestBuilder
-	kvReq, err := builder.SetTableHandles(e.tableID, handles).
+	kvReq, err := builder.SetTableHandles(physicalTableIDs(e.tableID, e.partitionIDs), handles).
 		SetDAGRequest(e.dagPB).
 		SetDesc(e.desc).
 		SetKeepOrder(e.keepOrder).
@@ -160,6 +199,7 @@ func (e *TableReaderExecutor) doRequestForHandles(handles []int64, goCtx goctx.C
 	if err != nil {
 		return errors.Trace(err)
 	}
+	e.result.SetCopRuntimeStats(e.copStats)
 	e.result.Fetch(goCtx)
 	return nil
 }
@@ -185,6 +225,8 @@ type IndexReaderExecutor struct {
 	dagPB     *tipb.DAGRequest
 	ctx       context.Context
 	schema    *expression.Schema
+	// partitionIDs are the IDs of the partitions to read if the table is partitioned.
+	partitionIDs []int64
 
 	// result returns one or more distsql.PartialResult and each PartialResult is returned by one region.
 	result        distsql.NewSelectResult
@@ -192,6 +234,8 @@ type IndexReaderExecutor struct {
 	// columns are only required by union scan.
 	columns  []*model.ColumnInfo
 	priority int
+	// copStats records the information of the coprocessor tasks for EXPLAIN ANALYZE, it may be nil.
+	copStats *execdetails.CopRuntimeStats
 }
 
 // Schema implements the Executor Schema interface.
@@ -238,10 +282,20 @@ func (e *IndexReaderExecutor) Next() (Row, error) {
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		e.ctx.GetSessionVars().StmtCtx.AddExaminedRows(1)
 		return rowData, nil
 	}
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *IndexReaderExecutor) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(fillChunkByRows(e, chk))
+}
+
+func (e *IndexReaderExecutor) supportChunk() bool {
+	return true
+}
+
 // Open implements the Executor Open interface.
 func (e *IndexReaderExecutor) Open() error {
 	fieldTypes := make([]*types.FieldType, len(e.index.Columns))
@@ -249,7 +303,7 @@ func (e *IndexReaderExecutor) Open() error {
 		fieldTypes[i] = &(e.table.Cols()[v.Offset].FieldType)
 	}
 	var builder requestBuilder
-	kvReq, err := builder.SetIndexRanges(e.ctx.GetSessionVars().StmtCtx, e.tableID, e.index.ID, e.ranges, fieldTypes).
+	kvReq, err := builder.SetIndexRanges(e.ctx.GetSessionVars().StmtCtx, physicalTableIDs(e.tableID, e.partitionIDs), e.index.ID, e.ranges, fieldTypes).
 		SetDAGRequest(e.dagPB).
 		SetDesc(e.desc).
 		SetKeepOrder(e.keepOrder).
@@ -263,6 +317,7 @@ func (e *IndexReaderExecutor) Open() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	e.result.SetCopRuntimeStats(e.copStats)
 	e.result.Fetch(e.ctx.GoCtx())
 	return nil
 }
@@ -270,7 +325,7 @@ func (e *IndexReaderExecutor) Open() error {
 // doRequestForDatums constructs kv ranges by datums. It is used by index look up executor.
 func (e *IndexReaderExecutor) doRequestForDatums(values [][]types.Datum, goCtx goctx.Context) error {
 	var builder requestBuilder
-	kvReq, err := builder.SetIndexValues(e.tableID, e.index.ID, values).
+	kvReq, err := builder.SetIndexValues(physicalTableIDs(e.tableID, e.partitionIDs), e.index.ID, values).
 		SetDAGRequest(e.dagPB).
 		SetDesc(e.desc).
 		SetKeepOrder(e.keepOrder).
@@ -284,6 +339,7 @@ func (e *IndexReaderExecutor) doRequestForDatums(values [][]types.Datum, goCtx g
 	if err != nil {
 		return errors.Trace(err)
 	}
+	e.result.SetCopRuntimeStats(e.copStats)
 	e.result.Fetch(goCtx)
 	return nil
 }
@@ -299,6 +355,8 @@ type IndexLookUpExecutor struct {
 	dagPB     *tipb.DAGRequest
 	ctx       context.Context
 	schema    *expression.Schema
+	// partitionIDs are the IDs of the partitions to read if the table is partitioned.
+	partitionIDs []int64
 	// This is the column that represent the handle, we can use handleCol.Index to know its position.
 	handleCol    *expression.Column
 	tableRequest *tipb.DAGRequest
@@ -309,6 +367,10 @@ type IndexLookUpExecutor struct {
 	// columns are only required by union scan.
 	columns  []*model.ColumnInfo
 	priority int
+	// indexCopStats and tableCopStats record the information of the coprocessor tasks for EXPLAIN ANALYZE,
+	// they may be nil.
+	indexCopStats *execdetails.CopRuntimeStats
+	tableCopStats *execdetails.CopRuntimeStats
 	// All fields above is immutable.
 
 	indexWorker
@@ -342,6 +404,7 @@ func (e *IndexLookUpExecutor) startIndexWorker(kvRanges []kv.KeyRange, workCh ch
 	if err != nil {
 		return errors.Trace(err)
 	}
+	result.SetCopRuntimeStats(e.indexCopStats)
 	result.Fetch(e.ctx.GoCtx())
 	worker := &e.indexWorker
 	worker.wg.Add(1)
@@ -467,14 +530,26 @@ func (e *IndexLookUpExecutor) indexRangesToKVRanges() ([]kv.KeyRange, error) {
 	for i, v := range e.index.Columns {
 		fieldTypes[i] = &(e.table.Cols()[v.Offset].FieldType)
 	}
-	return indexRangesToKVRanges(e.ctx.GetSessionVars().StmtCtx, e.tableID, e.index.ID, e.ranges, fieldTypes)
+	var kvRanges []kv.KeyRange
+	for _, tid := range physicalTableIDs(e.tableID, e.partitionIDs) {
+		krs, err := indexRangesToKVRanges(e.ctx.GetSessionVars().StmtCtx, tid, e.index.ID, e.ranges, fieldTypes)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		kvRanges = append(kvRanges, krs...)
+	}
+	return kvRanges, nil
 }
 
 // doRequestForDatums constructs kv ranges by datums. It is used by index look up join.
 func (e *IndexLookUpExecutor) doRequestForDatums(values [][]types.Datum, goCtx goctx.Context) error {
-	kvRanges, err := indexValuesToKVRanges(e.tableID, e.index.ID, values)
-	if err != nil {
-		return errors.Trace(err)
+	var kvRanges []kv.KeyRange
+	for _, tid := range physicalTableIDs(e.tableID, e.partitionIDs) {
+		krs, err := indexValuesToKVRanges(tid, e.index.ID, values)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		kvRanges = append(kvRanges, krs...)
 	}
 	return e.open(kvRanges)
 }
@@ -493,11 +568,13 @@ func (e *IndexLookUpExecutor) executeTask(task *lookupTableTask, goCtx goctx.Con
 		schema = e.schema
 	}
 	tableReader := &TableReaderExecutor{
-		table:   e.table,
-		tableID: e.tableID,
-		dagPB:   e.tableRequest,
-		schema:  schema,
-		ctx:     e.ctx,
+		table:        e.table,
+		tableID:      e.tableID,
+		partitionIDs: e.partitionIDs,
+		dagPB:        e.tableRequest,
+		schema:       schema,
+		ctx:          e.ctx,
+		copStats:     e.tableCopStats,
 	}
 	err = tableReader.doRequestForHandles(task.handles, goCtx)
 	if err != nil {
@@ -600,6 +677,11 @@ func (e *IndexLookUpExecutor) Next() (Row, error) {
 	}
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *IndexLookUpExecutor) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 type requestBuilder struct {
 	kv.Request
 	err error
@@ -609,29 +691,41 @@ func (builder *requestBuilder) Build() (*kv.Request, error) {
 	return &builder.Request, errors.Trace(builder.err)
 }
 
-func (builder *requestBuilder) SetTableRanges(tid int64, tableRanges []types.IntColumnRange) *requestBuilder {
-	builder.Request.KeyRanges = tableRangesToKVRanges(tid, tableRanges)
+func (builder *requestBuilder) SetTableRanges(tids []int64, tableRanges []types.IntColumnRange) *requestBuilder {
+	for _, tid := range tids {
+		builder.Request.KeyRanges = append(builder.Request.KeyRanges, tableRangesToKVRanges(tid, tableRanges)...)
+	}
 	return builder
 }
 
-func (builder *requestBuilder) SetIndexRanges(sc *variable.StatementContext, tid, idxID int64, ranges []*types.IndexRange, fieldTypes []*types.FieldType) *requestBuilder {
-	if builder.err != nil {
-		return builder
+func (builder *requestBuilder) SetIndexRanges(sc *variable.StatementContext, tids []int64, idxID int64, ranges []*types.IndexRange, fieldTypes []*types.FieldType) *requestBuilder {
+	for _, tid := range tids {
+		if builder.err != nil {
+			return builder
+		}
+		var krs []kv.KeyRange
+		krs, builder.err = indexRangesToKVRanges(sc, tid, idxID, ranges, fieldTypes)
+		builder.Request.KeyRanges = append(builder.Request.KeyRanges, krs...)
 	}
-	builder.Request.KeyRanges, builder.err = indexRangesToKVRanges(sc, tid, idxID, ranges, fieldTypes)
 	return builder
 }
 
-func (builder *requestBuilder) SetTableHandles(tid int64, handles []int64) *requestBuilder {
-	builder.Request.KeyRanges = tableHandlesToKVRanges(tid, handles)
+func (builder *requestBuilder) SetTableHandles(tids []int64, handles []int64) *requestBuilder {
+	for _, tid := range tids {
+		builder.Request.KeyRanges = append(builder.Request.KeyRanges, tableHandlesToKVRanges(tid, handles)...)
+	}
 	return builder
 }
 
-func (builder *requestBuilder) SetIndexValues(tid, idxID int64, values [][]types.Datum) *requestBuilder {
-	if builder.err != nil {
-		return builder
+func (builder *requestBuilder) SetIndexValues(tids []int64, idxID int64, values [][]types.Datum) *requestBuilder {
+	for _, tid := range tids {
+		if builder.err != nil {
+			return builder
+		}
+		var krs []kv.KeyRange
+		krs, builder.err = indexValuesToKVRanges(tid, idxID, values)
+		builder.Request.KeyRanges = append(builder.Request.KeyRanges, krs...)
 	}
-	builder.Request.KeyRanges, builder.err = indexValuesToKVRanges(tid, idxID, values)
 	return builder
 }
 
@@ -19,6 +19,7 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
@@ -26,6 +27,8 @@ import (
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/sqlexec"
 )
 
@@ -98,6 +101,11 @@ func (e *PrepareExec) Next() (Row, error) {
 	return nil, e.Err
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *PrepareExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 // Close implements the Executor Close interface.
 func (e *PrepareExec) Close() error {
 	return nil
@@ -207,6 +215,11 @@ func (e *ExecuteExec) Next() (Row, error) {
 	return nil, nil
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *ExecuteExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 // Open implements the Executor Open interface.
 func (e *ExecuteExec) Open() error {
 	return nil
@@ -300,6 +313,11 @@ func (e *DeallocateExec) Next() (Row, error) {
 	return nil, nil
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *DeallocateExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 // Close implements Executor Close interface.
 func (e *DeallocateExec) Close() error {
 	return nil
@@ -324,6 +342,7 @@ func CompileExecutePreparedStmt(ctx context.Context, ID uint32, args ...interfac
 	}
 	if prepared, ok := ctx.GetSessionVars().PreparedStmts[ID].(*Prepared); ok {
 		stmt.Text = prepared.Stmt.Text()
+		stmt.StmtNode = prepared.Stmt
 	}
 	return stmt
 }
@@ -334,6 +353,12 @@ func ResetStmtCtx(ctx context.Context, s ast.StmtNode) {
 	sessVars := ctx.GetSessionVars()
 	sc := new(variable.StatementContext)
 	sc.TimeZone = sessVars.GetTimeZone()
+	sc.MemTracker = memory.NewTracker("query", sessVars.MemQuotaQuery)
+	if config.GetGlobalConfig().OOMAction == config.OOMActionCancel {
+		sc.MemTracker.SetActionOnExceed(&memory.CancelOnExceed{})
+	} else {
+		sc.MemTracker.SetActionOnExceed(&memory.LogOnExceed{ConnID: sessVars.ConnectionID})
+	}
 
 	switch stmt := s.(type) {
 	case *ast.UpdateStmt:
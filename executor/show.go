@@ -98,6 +98,8 @@ func (e *ShowExec) fetchAll() error {
 		return e.fetchShowCreateTable()
 	case ast.ShowCreateDatabase:
 		return e.fetchShowCreateDatabase()
+	case ast.ShowCreateView:
+		return e.fetchShowCreateView()
 	case ast.ShowDatabases:
 		return e.fetchShowDatabases()
 	case ast.ShowEngines:
@@ -199,6 +201,7 @@ func (e *ShowExec) fetchShowTables() error {
 	checker := privilege.GetPrivilegeManager(e.ctx)
 	// sort for tables
 	var tableNames []string
+	views := make(map[string]struct{})
 	for _, v := range e.is.SchemaTables(e.DBName) {
 		// Test with mysql.AllPrivMask means any privilege would be OK.
 		// TODO: Should consider column privileges, which also make a table visible.
@@ -206,14 +209,19 @@ func (e *ShowExec) fetchShowTables() error {
 			continue
 		}
 		tableNames = append(tableNames, v.Meta().Name.O)
+		if v.Meta().IsView() {
+			views[v.Meta().Name.O] = struct{}{}
+		}
 	}
 	sort.Strings(tableNames)
 	for _, v := range tableNames {
 		data := types.MakeDatums(v)
 		if e.Full {
-			// TODO: support "VIEW" later if we have supported view feature.
-			// now, just use "BASE TABLE".
-			data = append(data, types.NewDatum("BASE TABLE"))
+			if _, ok := views[v]; ok {
+				data = append(data, types.NewDatum("VIEW"))
+			} else {
+				data = append(data, types.NewDatum("BASE TABLE"))
+			}
 		}
 		e.rows = append(e.rows, data)
 	}
@@ -409,6 +417,11 @@ func (e *ShowExec) fetchShowCreateTable() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if tb.Meta().IsView() {
+		data := types.MakeDatums(tb.Meta().Name.O, e.composeCreateView(tb.Meta()))
+		e.rows = append(e.rows, data)
+		return nil
+	}
 
 	// TODO: let the result more like MySQL.
 	var buf bytes.Buffer
@@ -527,7 +540,12 @@ func (e *ShowExec) fetchShowCreateTable() error {
 		}
 
 		buf.WriteString(fmt.Sprintf("  CONSTRAINT `%s` FOREIGN KEY (`%s`)", fk.Name.O, strings.Join(cols, "`,`")))
-		buf.WriteString(fmt.Sprintf(" REFERENCES `%s` (`%s`)", fk.RefTable.O, strings.Join(refCols, "`,`")))
+		refTable := fmt.Sprintf("`%s`", fk.RefTable.O)
+		if fk.RefSchema.L != "" && fk.RefSchema.L != e.Table.Schema.L {
+			// The schema of the referenced table is shown if it's different from the table, like MySQL.
+			refTable = fmt.Sprintf("`%s`.`%s`", fk.RefSchema.O, fk.RefTable.O)
+		}
+		buf.WriteString(fmt.Sprintf(" REFERENCES %s (`%s`)", refTable, strings.Join(refCols, "`,`")))
 
 		if ast.ReferOptionType(fk.OnDelete) != ast.ReferOptionNoOption {
 			buf.WriteString(fmt.Sprintf(" ON DELETE %s", ast.ReferOptionType(fk.OnDelete)))
@@ -560,11 +578,68 @@ func (e *ShowExec) fetchShowCreateTable() error {
 		buf.WriteString(fmt.Sprintf(" COMMENT='%s'", format.OutputFormat(tb.Meta().Comment)))
 	}
 
+	if pi := tb.Meta().GetPartitionInfo(); pi != nil {
+		appendPartitionInfo(&buf, pi)
+	}
+
 	data := types.MakeDatums(tb.Meta().Name.O, buf.String())
 	e.rows = append(e.rows, data)
 	return nil
 }
 
+// appendPartitionInfo appends the partition clause of a partitioned table to the show create table result.
+func appendPartitionInfo(buf *bytes.Buffer, pi *model.PartitionInfo) {
+	fmt.Fprintf(buf, "\nPARTITION BY %s (%s)", pi.Type, pi.Expr)
+	if pi.Type == model.PartitionTypeHash {
+		fmt.Fprintf(buf, " PARTITIONS %d", len(pi.Definitions))
+		return
+	}
+	buf.WriteString(" (\n")
+	for i, def := range pi.Definitions {
+		fmt.Fprintf(buf, "  PARTITION `%s` VALUES LESS THAN (%s)", def.Name.O, strings.Join(def.LessThan, ","))
+		if i < len(pi.Definitions)-1 {
+			buf.WriteString(",\n")
+		}
+	}
+	buf.WriteString("\n)")
+}
+
+// fetchShowCreateView composes show create view result.
+func (e *ShowExec) fetchShowCreateView() error {
+	tb, err := e.getTable()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !tb.Meta().IsView() {
+		return infoschema.ErrWrongObject.GenByArgs(e.DBName.O, tb.Meta().Name.O, "VIEW")
+	}
+	charset, collation := e.ctx.GetSessionVars().GetCharsetInfo()
+	data := types.MakeDatums(tb.Meta().Name.O, e.composeCreateView(tb.Meta()), charset, collation)
+	e.rows = append(e.rows, data)
+	return nil
+}
+
+func (e *ShowExec) composeCreateView(tblInfo *model.TableInfo) string {
+	view := tblInfo.View
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "CREATE ALGORITHM=%s ", view.Algorithm)
+	if view.Definer != nil {
+		fmt.Fprintf(&buf, "DEFINER=`%s`@`%s` ", view.Definer.Username, view.Definer.Hostname)
+	}
+	fmt.Fprintf(&buf, "SQL SECURITY %s VIEW `%s` (", view.Security, tblInfo.Name.O)
+	for i, col := range view.Cols {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "`%s`", col.O)
+	}
+	fmt.Fprintf(&buf, ") AS %s", view.SelectStmt)
+	if view.CheckOption != model.CheckOptionNone {
+		fmt.Fprintf(&buf, " WITH %s CHECK OPTION", view.CheckOption)
+	}
+	return buf.String()
+}
+
 // fetchShowCreateDatabase composes show create database result.
 func (e *ShowExec) fetchShowCreateDatabase() error {
 	db, ok := e.is.SchemaByName(e.DBName)
@@ -348,12 +348,17 @@ func (s *testSuite) TestForeignKeyInShowCreateTable(c *C) {
 		"CREATE TABLE `pilot_languages` (",
 		"  `pilot_id` int(11) NOT NULL,",
 		"  `language_id` int(11) NOT NULL,",
+		"  KEY `pilot_language_fkey` (`pilot_id`),",
+		"  KEY `languages_fkey` (`language_id`),",
 		"  CONSTRAINT `pilot_language_fkey` FOREIGN KEY (`pilot_id`) REFERENCES `pilots` (`pilot_id`),",
 		"  CONSTRAINT `languages_fkey` FOREIGN KEY (`language_id`) REFERENCES `languages` (`language_id`)",
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin",
 	}
 	testSQL = strings.Join(sqlLines, "\n")
+	// The referenced tables don't exist.
+	tk.MustExec("set foreign_key_checks = 0")
 	tk.MustExec(testSQL)
+	tk.MustExec("set foreign_key_checks = 1")
 	result = tk.MustQuery("show create table pilot_languages;")
 	c.Check(result.Rows(), HasLen, 1)
 	row = result.Rows()[0]
@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
@@ -70,6 +71,14 @@ func (e *SimpleExec) Next() (Row, error) {
 		err = e.executeDropUser(x)
 	case *ast.SetPwdStmt:
 		err = e.executeSetPwd(x)
+	case *ast.GrantRoleStmt:
+		err = e.executeGrantRole(x)
+	case *ast.RevokeRoleStmt:
+		err = e.executeRevokeRole(x)
+	case *ast.SetRoleStmt:
+		err = e.executeSetRole(x)
+	case *ast.SetDefaultRoleStmt:
+		err = e.executeSetDefaultRole(x)
 	case *ast.KillStmt:
 		err = e.executeKillStmt(x)
 	case *ast.BinlogStmt:
@@ -145,21 +154,22 @@ func (e *SimpleExec) executeCreateUser(s *ast.CreateUserStmt) error {
 			}
 			continue
 		}
-		pwd := ""
-		if spec.AuthOpt != nil {
-			if spec.AuthOpt.ByAuthString {
-				pwd = auth.EncodePassword(spec.AuthOpt.AuthString)
-			} else {
-				pwd = auth.EncodePassword(spec.AuthOpt.HashString)
-			}
+		pwd, plugin, err1 := encodePassword(spec.AuthOpt, mysql.AuthNativePassword)
+		if err1 != nil {
+			return errors.Trace(err1)
+		}
+		// Roles are stored as locked accounts, so they can not be used to connect.
+		accountLocked := "N"
+		if s.IsCreateRole {
+			accountLocked = "Y"
 		}
-		user := fmt.Sprintf(`("%s", "%s", "%s")`, spec.User.Hostname, spec.User.Username, pwd)
+		user := fmt.Sprintf(`("%s", "%s", "%s", "%s", "%s")`, spec.User.Hostname, spec.User.Username, pwd, accountLocked, plugin)
 		users = append(users, user)
 	}
 	if len(users) == 0 {
 		return nil
 	}
-	sql := fmt.Sprintf(`INSERT INTO %s.%s (Host, User, Password) VALUES %s;`, mysql.SystemDB, mysql.UserTable, strings.Join(users, ", "))
+	sql := fmt.Sprintf(`INSERT INTO %s.%s (Host, User, Password, Account_locked, plugin) VALUES %s;`, mysql.SystemDB, mysql.UserTable, strings.Join(users, ", "))
 	_, err := e.ctx.(sqlexec.SQLExecutor).Execute(sql)
 	if err != nil {
 		return errors.Trace(err)
@@ -194,16 +204,17 @@ func (e *SimpleExec) executeAlterUser(s *ast.AlterUserStmt) error {
 			}
 			continue
 		}
-		pwd := ""
-		if spec.AuthOpt != nil {
-			if spec.AuthOpt.ByAuthString {
-				pwd = auth.EncodePassword(spec.AuthOpt.AuthString)
-			} else {
-				pwd = auth.EncodePassword(spec.AuthOpt.HashString)
-			}
+		// Keep the authentication plugin of the user if it's not specified.
+		plugin, err := userAuthPlugin(e.ctx, spec.User.Username, spec.User.Hostname)
+		if err != nil {
+			return errors.Trace(err)
 		}
-		sql := fmt.Sprintf(`UPDATE %s.%s SET Password = "%s" WHERE Host = "%s" and User = "%s";`,
-			mysql.SystemDB, mysql.UserTable, pwd, spec.User.Hostname, spec.User.Username)
+		pwd, plugin, err := encodePassword(spec.AuthOpt, plugin)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		sql := fmt.Sprintf(`UPDATE %s.%s SET Password = "%s", plugin = "%s" WHERE Host = "%s" and User = "%s";`,
+			mysql.SystemDB, mysql.UserTable, pwd, plugin, spec.User.Hostname, spec.User.Username)
 		_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
 		if err != nil {
 			failedUsers = append(failedUsers, spec.User.String())
@@ -234,10 +245,20 @@ func (e *SimpleExec) executeDropUser(s *ast.DropUserStmt) error {
 			}
 			continue
 		}
-		sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE Host = "%s" and User = "%s";`, mysql.SystemDB, mysql.UserTable, user.Hostname, user.Username)
-		_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
-		if err != nil {
-			failedUsers = append(failedUsers, user.String())
+		// The roles granted to the user, the grants of the role, and the default roles related are removed too.
+		sqls := []string{
+			fmt.Sprintf(`DELETE FROM %s.%s WHERE Host = "%s" and User = "%s";`, mysql.SystemDB, mysql.UserTable, user.Hostname, user.Username),
+			fmt.Sprintf(`DELETE FROM %s.%s WHERE TO_HOST = "%s" and TO_USER = "%s";`, mysql.SystemDB, mysql.RoleEdgeTable, user.Hostname, user.Username),
+			fmt.Sprintf(`DELETE FROM %s.%s WHERE FROM_HOST = "%s" and FROM_USER = "%s";`, mysql.SystemDB, mysql.RoleEdgeTable, user.Hostname, user.Username),
+			fmt.Sprintf(`DELETE FROM %s.%s WHERE HOST = "%s" and USER = "%s";`, mysql.SystemDB, mysql.DefaultRoleTable, user.Hostname, user.Username),
+			fmt.Sprintf(`DELETE FROM %s.%s WHERE DEFAULT_ROLE_HOST = "%s" and DEFAULT_ROLE_USER = "%s";`, mysql.SystemDB, mysql.DefaultRoleTable, user.Hostname, user.Username),
+		}
+		for _, sql := range sqls {
+			_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+			if err != nil {
+				failedUsers = append(failedUsers, user.String())
+				break
+			}
 		}
 	}
 	if len(failedUsers) > 0 {
@@ -246,12 +267,196 @@ func (e *SimpleExec) executeDropUser(s *ast.DropUserStmt) error {
 		if err != nil {
 			return errors.Trace(err)
 		}
-		errMsg := "Operation DROP USER failed for " + strings.Join(failedUsers, ",")
+		op := "DROP USER"
+		if s.IsDropRole {
+			op = "DROP ROLE"
+		}
+		errMsg := "Operation " + op + " failed for " + strings.Join(failedUsers, ",")
 		return terror.ClassExecutor.New(CodeCannotUser, errMsg)
 	}
 	return nil
 }
 
+func (e *SimpleExec) executeGrantRole(s *ast.GrantRoleStmt) error {
+	err := e.checkRolesAndUsersExist(s.Roles, s.Users)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, user := range s.Users {
+		for _, role := range s.Roles {
+			sql := fmt.Sprintf(`REPLACE INTO %s.%s (FROM_HOST, FROM_USER, TO_HOST, TO_USER) VALUES ("%s", "%s", "%s", "%s");`,
+				mysql.SystemDB, mysql.RoleEdgeTable, role.Hostname, role.Username, user.Hostname, user.Username)
+			_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	sessionctx.GetDomain(e.ctx).NotifyUpdatePrivilege(e.ctx)
+	return nil
+}
+
+func (e *SimpleExec) executeRevokeRole(s *ast.RevokeRoleStmt) error {
+	err := e.checkRolesAndUsersExist(s.Roles, s.Users)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, user := range s.Users {
+		for _, role := range s.Roles {
+			sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE FROM_HOST = "%s" and FROM_USER = "%s" and TO_HOST = "%s" and TO_USER = "%s";`,
+				mysql.SystemDB, mysql.RoleEdgeTable, role.Hostname, role.Username, user.Hostname, user.Username)
+			_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			// A role which is not granted any more can not be a default role.
+			sql = fmt.Sprintf(`DELETE FROM %s.%s WHERE HOST = "%s" and USER = "%s" and DEFAULT_ROLE_HOST = "%s" and DEFAULT_ROLE_USER = "%s";`,
+				mysql.SystemDB, mysql.DefaultRoleTable, user.Hostname, user.Username, role.Hostname, role.Username)
+			_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	sessionctx.GetDomain(e.ctx).NotifyUpdatePrivilege(e.ctx)
+	return nil
+}
+
+func (e *SimpleExec) checkRolesAndUsersExist(roles []*auth.RoleIdentity, users []*auth.UserIdentity) error {
+	for _, role := range roles {
+		exists, err := userExists(e.ctx, role.Username, role.Hostname)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !exists {
+			return ErrUnknownAuthID.GenByArgs(role.Username, role.Hostname)
+		}
+	}
+	for _, user := range users {
+		exists, err := userExists(e.ctx, user.Username, user.Hostname)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !exists {
+			return ErrUnknownAuthID.GenByArgs(user.Username, user.Hostname)
+		}
+	}
+	return nil
+}
+
+func (e *SimpleExec) executeSetRole(s *ast.SetRoleStmt) error {
+	pm := privilege.GetPrivilegeManager(e.ctx)
+	if pm == nil {
+		return nil
+	}
+	var roles []*auth.RoleIdentity
+	user := e.ctx.GetSessionVars().User
+	switch s.SetRoleOpt {
+	case ast.SetRoleDefault:
+		if user != nil {
+			roles = pm.GetDefaultRoles(user.Username, user.Hostname)
+		}
+	case ast.SetRoleAll, ast.SetRoleAllExcept:
+		if user != nil {
+			roles = pm.GetAllRoles(user.Username, user.Hostname)
+		}
+		roles = filterRoles(roles, s.RoleList)
+	case ast.SetRoleRegular:
+		roles = s.RoleList
+	}
+	if role, ok := pm.ActivateRoles(roles); !ok {
+		return ErrRoleNotGranted.GenByArgs(role.Username, role.Hostname, user.Username, user.Hostname)
+	}
+	return nil
+}
+
+// filterRoles returns the roles which are not in the excepted list.
+func filterRoles(roles, except []*auth.RoleIdentity) []*auth.RoleIdentity {
+	ret := make([]*auth.RoleIdentity, 0, len(roles))
+	for _, role := range roles {
+		excepted := false
+		for _, r := range except {
+			if r.Username == role.Username && r.Hostname == role.Hostname {
+				excepted = true
+				break
+			}
+		}
+		if !excepted {
+			ret = append(ret, role)
+		}
+	}
+	return ret
+}
+
+func (e *SimpleExec) executeSetDefaultRole(s *ast.SetDefaultRoleStmt) error {
+	err := e.checkRolesAndUsersExist(s.RoleList, s.UserList)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, user := range s.UserList {
+		sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE HOST = "%s" and USER = "%s";`, mysql.SystemDB, mysql.DefaultRoleTable, user.Hostname, user.Username)
+		_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		var roles []*auth.RoleIdentity
+		switch s.SetRoleOpt {
+		case ast.SetRoleAll:
+			roles, err = grantedRoles(e.ctx, user)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		case ast.SetRoleRegular:
+			roles = s.RoleList
+			for _, role := range roles {
+				granted, err1 := roleGranted(e.ctx, role, user)
+				if err1 != nil {
+					return errors.Trace(err1)
+				}
+				if !granted {
+					return ErrRoleNotGranted.GenByArgs(role.Username, role.Hostname, user.Username, user.Hostname)
+				}
+			}
+		}
+		for _, role := range roles {
+			sql = fmt.Sprintf(`INSERT INTO %s.%s (HOST, USER, DEFAULT_ROLE_HOST, DEFAULT_ROLE_USER) VALUES ("%s", "%s", "%s", "%s");`,
+				mysql.SystemDB, mysql.DefaultRoleTable, user.Hostname, user.Username, role.Hostname, role.Username)
+			_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	sessionctx.GetDomain(e.ctx).NotifyUpdatePrivilege(e.ctx)
+	return nil
+}
+
+// grantedRoles reads the roles granted to the user from the mysql.role_edges table.
+// The privilege cache can not be used, because it may be not updated yet.
+func grantedRoles(ctx context.Context, user *auth.UserIdentity) ([]*auth.RoleIdentity, error) {
+	sql := fmt.Sprintf(`SELECT FROM_HOST, FROM_USER FROM %s.%s WHERE TO_HOST = "%s" and TO_USER = "%s";`,
+		mysql.SystemDB, mysql.RoleEdgeTable, user.Hostname, user.Username)
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	roles := make([]*auth.RoleIdentity, 0, len(rows))
+	for _, row := range rows {
+		roles = append(roles, &auth.RoleIdentity{Hostname: row.Data[0].GetString(), Username: row.Data[1].GetString()})
+	}
+	return roles, nil
+}
+
+func roleGranted(ctx context.Context, role *auth.RoleIdentity, user *auth.UserIdentity) (bool, error) {
+	sql := fmt.Sprintf(`SELECT * FROM %s.%s WHERE FROM_HOST = "%s" and FROM_USER = "%s" and TO_HOST = "%s" and TO_USER = "%s";`,
+		mysql.SystemDB, mysql.RoleEdgeTable, role.Hostname, role.Username, user.Hostname, user.Username)
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return len(rows) > 0, nil
+}
+
 func userExists(ctx context.Context, name string, host string) (bool, error) {
 	sql := fmt.Sprintf(`SELECT * FROM %s.%s WHERE User="%s" AND Host="%s";`, mysql.SystemDB, mysql.UserTable, name, host)
 	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
@@ -261,6 +466,47 @@ func userExists(ctx context.Context, name string, host string) (bool, error) {
 	return len(rows) > 0, nil
 }
 
+// userAuthPlugin returns the authentication plugin of the user, the user must exist.
+func userAuthPlugin(ctx context.Context, name string, host string) (string, error) {
+	sql := fmt.Sprintf(`SELECT plugin FROM %s.%s WHERE User="%s" AND Host="%s";`, mysql.SystemDB, mysql.UserTable, name, host)
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if len(rows) == 0 || rows[0].Data[0].IsNull() {
+		return mysql.AuthNativePassword, nil
+	}
+	return rows[0].Data[0].GetString(), nil
+}
+
+// encodePassword hashes the password of the auth option by its authentication plugin, defaultPlugin is
+// used if the plugin isn't specified. It returns the password hash and the plugin stored in mysql.user.
+func encodePassword(opt *ast.AuthOption, defaultPlugin string) (string, string, error) {
+	if opt == nil {
+		return "", defaultPlugin, nil
+	}
+	plugin := strings.ToLower(opt.AuthPlugin)
+	if plugin == "" {
+		plugin = defaultPlugin
+	}
+	switch plugin {
+	case mysql.AuthNativePassword, mysql.AuthCachingSha2Password, mysql.AuthSha256Password:
+	default:
+		return "", "", ErrPluginIsNotLoaded.GenByArgs(opt.AuthPlugin)
+	}
+	switch {
+	case opt.ByAuthString && plugin == mysql.AuthNativePassword:
+		return auth.EncodePassword(opt.AuthString), plugin, nil
+	case opt.ByAuthString:
+		return auth.NewSha2Password(opt.AuthString), plugin, nil
+	case opt.AuthPlugin != "":
+		// IDENTIFIED WITH plugin AS 'hash' stores the hash as it is.
+		return opt.HashString, plugin, nil
+	default:
+		return auth.EncodePassword(opt.HashString), plugin, nil
+	}
+}
+
 func (e *SimpleExec) executeSetPwd(s *ast.SetPwdStmt) error {
 	if s.User == nil {
 		vars := e.ctx.GetSessionVars()
@@ -277,8 +523,17 @@ func (e *SimpleExec) executeSetPwd(s *ast.SetPwdStmt) error {
 		return errors.Trace(ErrPasswordNoMatch)
 	}
 
+	plugin, err := userAuthPlugin(e.ctx, s.User.Username, s.User.Hostname)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	pwd, _, err := encodePassword(&ast.AuthOption{AuthString: s.Password, ByAuthString: true}, plugin)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
 	// update mysql.user
-	sql := fmt.Sprintf(`UPDATE %s.%s SET password="%s" WHERE User="%s" AND Host="%s";`, mysql.SystemDB, mysql.UserTable, auth.EncodePassword(s.Password), s.User.Username, s.User.Hostname)
+	sql := fmt.Sprintf(`UPDATE %s.%s SET password="%s" WHERE User="%s" AND Host="%s";`, mysql.SystemDB, mysql.UserTable, pwd, s.User.Username, s.User.Hostname)
 	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
 	sessionctx.GetDomain(e.ctx).NotifyUpdatePrivilege(e.ctx)
 	return errors.Trace(err)
@@ -14,6 +14,8 @@
 package executor_test
 
 import (
+	"fmt"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/context"
@@ -170,6 +172,60 @@ func (s *testSuite) TestUser(c *C) {
 	tk.MustExec(dropUserSQL)
 }
 
+func (s *testSuite) TestRole(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	// Roles are stored as locked accounts.
+	tk.MustExec(`CREATE ROLE 'r_1'@'localhost', 'r_2'`)
+	result := tk.MustQuery(`SELECT Host, Account_locked FROM mysql.User WHERE User like "r\_%" order by User`)
+	result.Check(testkit.Rows("localhost Y", "% Y"))
+	_, err := tk.Exec(`CREATE ROLE 'r_2'`)
+	c.Check(err, NotNil)
+	tk.MustExec(`CREATE ROLE IF NOT EXISTS 'r_2'`)
+	tk.MustExec(`CREATE USER 'test_role'@'localhost'`)
+
+	// Grant roles.
+	tk.MustExec(`GRANT 'r_1'@'localhost', r_2 TO 'test_role'@'localhost'`)
+	tk.MustExec(`GRANT r_2 TO 'r_1'@'localhost'`)
+	result = tk.MustQuery(`SELECT FROM_USER, FROM_HOST, TO_USER, TO_HOST FROM mysql.role_edges order by TO_USER, FROM_USER`)
+	result.Check(testkit.Rows("r_2 % r_1 localhost", "r_1 localhost test_role localhost", "r_2 % test_role localhost"))
+	_, err = tk.Exec(`GRANT r_not_exist TO 'test_role'@'localhost'`)
+	c.Check(terror.ErrorEqual(err, executor.ErrUnknownAuthID), IsTrue)
+	_, err = tk.Exec(`GRANT r_2 TO 'user_not_exist'@'localhost'`)
+	c.Check(terror.ErrorEqual(err, executor.ErrUnknownAuthID), IsTrue)
+
+	// Set default roles.
+	tk.MustExec(`SET DEFAULT ROLE ALL TO 'test_role'@'localhost'`)
+	result = tk.MustQuery(`SELECT DEFAULT_ROLE_USER FROM mysql.default_roles WHERE USER="test_role" order by DEFAULT_ROLE_USER`)
+	result.Check(testkit.Rows("r_1", "r_2"))
+	tk.MustExec(`SET DEFAULT ROLE r_2 TO 'test_role'@'localhost'`)
+	result = tk.MustQuery(`SELECT DEFAULT_ROLE_USER FROM mysql.default_roles WHERE USER="test_role"`)
+	result.Check(testkit.Rows("r_2"))
+	_, err = tk.Exec(`SET DEFAULT ROLE 'r_1'@'localhost' TO 'r_2'`)
+	c.Check(terror.ErrorEqual(err, executor.ErrRoleNotGranted), IsTrue)
+	tk.MustExec(`SET DEFAULT ROLE NONE TO 'test_role'@'localhost'`)
+	result = tk.MustQuery(`SELECT DEFAULT_ROLE_USER FROM mysql.default_roles WHERE USER="test_role"`)
+	result.Check(nil)
+
+	// Revoke roles, the default roles are removed too.
+	tk.MustExec(`SET DEFAULT ROLE ALL TO 'test_role'@'localhost'`)
+	tk.MustExec(`REVOKE r_2 FROM 'test_role'@'localhost'`)
+	result = tk.MustQuery(`SELECT FROM_USER FROM mysql.role_edges WHERE TO_USER="test_role"`)
+	result.Check(testkit.Rows("r_1"))
+	result = tk.MustQuery(`SELECT DEFAULT_ROLE_USER FROM mysql.default_roles WHERE USER="test_role"`)
+	result.Check(testkit.Rows("r_1"))
+
+	// Drop roles, the related role edges and default roles are removed too.
+	tk.MustExec(`DROP ROLE 'r_1'@'localhost'`)
+	result = tk.MustQuery(`SELECT * FROM mysql.role_edges`)
+	result.Check(nil)
+	result = tk.MustQuery(`SELECT * FROM mysql.default_roles`)
+	result.Check(nil)
+	_, err = tk.Exec(`DROP ROLE 'r_1'@'localhost'`)
+	c.Check(err, NotNil)
+	tk.MustExec(`DROP ROLE IF EXISTS 'r_1'@'localhost', r_2`)
+	tk.MustExec(`DROP USER 'test_role'@'localhost'`)
+}
+
 func (s *testSuite) TestSetPwd(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 
@@ -202,6 +258,41 @@ func (s *testSuite) TestSetPwd(c *C) {
 	result.Check(testkit.Rows(auth.EncodePassword("pwd")))
 }
 
+func (s *testSuite) TestUserAuthPlugin(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec(`CREATE USER 'test_native'@'localhost' IDENTIFIED BY 'pwd'`)
+	tk.MustExec(`CREATE USER 'test_sha2'@'localhost' IDENTIFIED WITH caching_sha2_password BY 'pwd'`)
+	result := tk.MustQuery(`SELECT User, plugin FROM mysql.User WHERE User like "test\_%" order by User`)
+	result.Check(testkit.Rows("test_native mysql_native_password", "test_sha2 caching_sha2_password"))
+	checkSha2 := func(user, pwd string) {
+		rows := tk.MustQuery(fmt.Sprintf(`SELECT Password FROM mysql.User WHERE User="%s"`, user)).Rows()
+		ok, err := auth.CheckSha2Password(rows[0][0].(string), pwd)
+		c.Assert(err, IsNil)
+		c.Assert(ok, IsTrue)
+	}
+	checkSha2("test_sha2", "pwd")
+
+	// The plugin of the user is kept when the password is changed.
+	tk.MustExec(`SET PASSWORD FOR 'test_sha2'@'localhost' = 'new_pwd'`)
+	checkSha2("test_sha2", "new_pwd")
+	tk.MustExec(`ALTER USER 'test_sha2'@'localhost' IDENTIFIED BY 'pwd'`)
+	checkSha2("test_sha2", "pwd")
+
+	// The plugin is changed by IDENTIFIED WITH.
+	tk.MustExec(`ALTER USER 'test_native'@'localhost' IDENTIFIED WITH 'sha256_password' BY 'pwd'`)
+	result = tk.MustQuery(`SELECT plugin FROM mysql.User WHERE User="test_native"`)
+	result.Check(testkit.Rows("sha256_password"))
+	checkSha2("test_native", "pwd")
+	tk.MustExec(`ALTER USER 'test_native'@'localhost' IDENTIFIED WITH mysql_native_password AS '*975B2CD4FF9AE554FE8AD33168FBFC326D2021DD'`)
+	result = tk.MustQuery(`SELECT Password, plugin FROM mysql.User WHERE User="test_native"`)
+	result.Check(testkit.Rows("*975B2CD4FF9AE554FE8AD33168FBFC326D2021DD mysql_native_password"))
+
+	_, err := tk.Exec(`CREATE USER 'test_unknown'@'localhost' IDENTIFIED WITH unknown_plugin BY 'pwd'`)
+	c.Check(terror.ErrorEqual(err, executor.ErrPluginIsNotLoaded), IsTrue)
+	tk.MustExec(`DROP USER 'test_native'@'localhost', 'test_sha2'@'localhost'`)
+}
+
 func (s *testSuite) TestFlushPrivileges(c *C) {
 	// Global variables is really bad, when the test cases run concurrently.
 	save := privileges.Enable
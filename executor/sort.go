@@ -15,14 +15,26 @@ package executor
 
 import (
 	"container/heap"
+	"io/ioutil"
+	"os"
 	"sort"
+	"time"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/filesort"
+	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/types"
 )
 
+// sortSpillWorkers is the number of workers which sort and write the spilled rows to files concurrently.
+const sortSpillWorkers = 4
+
 // orderByRow binds a row to its order values, so it can be sorted.
 type orderByRow struct {
 	key []*types.Datum
@@ -39,19 +51,48 @@ type SortExec struct {
 	fetched bool
 	err     error
 	schema  *expression.Schema
+
+	// memQuota is the memory quota of the buffered rows, the rows are spilled to disk when it's exceeded.
+	// 0 means the rows are never spilled.
+	memQuota int64
+	memUsage int64
+	// tmpDir is the directory where the spilled files are created.
+	tmpDir     string
+	fileSorter *filesort.FileSorter
+	memTracker *memory.Tracker
 }
 
 // Close implements the Executor Close interface.
 func (e *SortExec) Close() error {
 	e.Rows = nil
+	if e.fileSorter != nil {
+		terror.Log(errors.Trace(e.fileSorter.Close()))
+		e.fileSorter = nil
+	}
+	e.memTracker.Detach()
 	return errors.Trace(e.children[0].Close())
 }
 
+// memoryUsage implements the memoryUsageReporter interface.
+func (e *SortExec) memoryUsage() int64 {
+	var usage int64
+	for _, row := range e.Rows {
+		for _, key := range row.key {
+			usage += key.MemUsage()
+		}
+		usage += rowsMemUsage(row.row)
+	}
+	return usage
+}
+
 // Open implements the Executor Open interface.
 func (e *SortExec) Open() error {
 	e.fetched = false
 	e.Idx = 0
 	e.Rows = nil
+	e.memUsage = 0
+	e.memTracker = memory.NewTracker("SortExec", -1)
+	e.memTracker.AttachTo(e.ctx.GetSessionVars().StmtCtx.MemTracker)
 	return errors.Trace(e.children[0].Open())
 }
 
@@ -92,6 +133,119 @@ func (e *SortExec) Less(i, j int) bool {
 	return false
 }
 
+// buildOrderByRow evaluates the order values of the row.
+func (e *SortExec) buildOrderByRow(srcRow Row) (*orderByRow, error) {
+	orderRow := &orderByRow{
+		row: srcRow,
+		key: make([]*types.Datum, len(e.ByItems)),
+	}
+	for i, byItem := range e.ByItems {
+		key, err := byItem.Expr.Eval(srcRow)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		// The strings are sorted by the collation of the expression.
+		key = codec.CollationKey(key, byItem.Expr.GetType().Collate)
+		orderRow.key[i] = &key
+	}
+	return orderRow, nil
+}
+
+// consumeMemory adds the memory usage of the row and reports whether the memory quota of the sort is exceeded.
+func (e *SortExec) consumeMemory(orderRow *orderByRow) (bool, error) {
+	usage := rowsMemUsage(orderRow.row)
+	for _, key := range orderRow.key {
+		usage += key.MemUsage()
+	}
+	e.memUsage += usage
+	if err := e.memTracker.Consume(usage); err != nil {
+		return false, errors.Trace(err)
+	}
+	return e.memQuota > 0 && e.memUsage > e.memQuota, nil
+}
+
+// createSpillDir creates a new directory in tmpDir for the files spilled by an executor.
+// The temporary directory of the OS is used if tmpDir is empty.
+func createSpillDir(tmpDir, prefix string) (string, error) {
+	if tmpDir == "" {
+		tmpDir = os.TempDir()
+	}
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return "", errors.Trace(err)
+	}
+	dir, err := ioutil.TempDir(tmpDir, prefix)
+	return dir, errors.Trace(err)
+}
+
+// spill moves the buffered rows to a FileSorter, the following rows are sorted by the FileSorter.
+func (e *SortExec) spill() error {
+	log.Infof("[sort] memory usage %d exceeds the quota %d, spill %d rows to disk", e.memUsage, e.memQuota, len(e.Rows))
+	dir, err := createSpillDir(e.tmpDir, "tidb-sort")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	byDesc := make([]bool, len(e.ByItems))
+	for i, by := range e.ByItems {
+		byDesc[i] = by.Desc
+	}
+	// Every file contains the rows buffered before spilling at most, so the memory usage is still limited.
+	bufSize := len(e.Rows)
+	if bufSize < sortSpillWorkers {
+		bufSize = sortSpillWorkers
+	}
+	e.fileSorter, err = new(filesort.Builder).SetSC(e.ctx.GetSessionVars().StmtCtx).
+		SetSchema(len(e.ByItems), e.schema.Len()).SetBuf(bufSize).SetWorkers(sortSpillWorkers).
+		SetDesc(byDesc).SetDir(dir).Build()
+	if err != nil {
+		terror.Log(errors.Trace(os.RemoveAll(dir)))
+		return errors.Trace(err)
+	}
+	for _, orderRow := range e.Rows {
+		if err = e.spillRow(orderRow); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	e.Rows = nil
+	terror.Log(e.memTracker.Consume(-e.memUsage))
+	e.memUsage = 0
+	return nil
+}
+
+// spillRow inputs the row to the FileSorter. The FileSorter encodes the datums in a memory comparable format
+// which loses their types, so the row values are encoded in the same way as the table rows and decoded
+// by the column types later.
+func (e *SortExec) spillRow(orderRow *orderByRow) error {
+	key := make([]types.Datum, len(orderRow.key))
+	for i, k := range orderRow.key {
+		key[i] = *k
+	}
+	val := make([]types.Datum, len(orderRow.row))
+	for i, d := range orderRow.row {
+		b, err := tablecodec.EncodeValue(d, time.UTC)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		val[i].SetBytes(b)
+	}
+	return errors.Trace(e.fileSorter.Input(key, val, 0))
+}
+
+// nextSpilledRow returns the next sorted row from the FileSorter.
+func (e *SortExec) nextSpilledRow() (Row, error) {
+	_, val, _, err := e.fileSorter.Output()
+	if err != nil || val == nil {
+		return nil, errors.Trace(err)
+	}
+	row := make(Row, len(val))
+	for i, v := range val {
+		row[i], err = tablecodec.DecodeColumnValue(v.GetBytes(), e.schema.Columns[i].RetType, time.UTC)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return row, nil
+}
+
 // Next implements the Executor Next interface.
 func (e *SortExec) Next() (Row, error) {
 	if !e.fetched {
@@ -103,25 +257,38 @@ func (e *SortExec) Next() (Row, error) {
 			if srcRow == nil {
 				break
 			}
-			orderRow := &orderByRow{
-				row: srcRow,
-				key: make([]*types.Datum, len(e.ByItems)),
+			orderRow, err := e.buildOrderByRow(srcRow)
+			if err != nil {
+				return nil, errors.Trace(err)
 			}
-			for i, byItem := range e.ByItems {
-				key, err := byItem.Expr.Eval(srcRow)
-				if err != nil {
+			if e.fileSorter != nil {
+				if err = e.spillRow(orderRow); err != nil {
 					return nil, errors.Trace(err)
 				}
-				orderRow.key[i] = &key
+				continue
 			}
 			e.Rows = append(e.Rows, orderRow)
+			exceeded, err := e.consumeMemory(orderRow)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if exceeded {
+				if err = e.spill(); err != nil {
+					return nil, errors.Trace(err)
+				}
+			}
+		}
+		if e.fileSorter == nil {
+			sort.Sort(e)
 		}
-		sort.Sort(e)
 		e.fetched = true
 	}
 	if e.err != nil {
 		return nil, errors.Trace(e.err)
 	}
+	if e.fileSorter != nil {
+		return e.nextSpilledRow()
+	}
 	if e.Idx >= len(e.Rows) {
 		return nil, nil
 	}
@@ -202,17 +369,15 @@ func (e *TopNExec) Next() (Row, error) {
 			if srcRow == nil {
 				break
 			}
-			// build orderRow from srcRow.
-			orderRow := &orderByRow{
-				row: srcRow,
-				key: make([]*types.Datum, len(e.ByItems)),
+			orderRow, err := e.buildOrderByRow(srcRow)
+			if err != nil {
+				return nil, errors.Trace(err)
 			}
-			for i, byItem := range e.ByItems {
-				key, err := byItem.Expr.Eval(srcRow)
-				if err != nil {
+			if e.fileSorter != nil {
+				if err = e.spillRow(orderRow); err != nil {
 					return nil, errors.Trace(err)
 				}
-				orderRow.key[i] = &key
+				continue
 			}
 			if e.totalCount == e.heapSize {
 				// An equivalent of Push and Pop. We don't use the standard Push and Pop
@@ -225,17 +390,36 @@ func (e *TopNExec) Next() (Row, error) {
 				e.Rows = e.Rows[:e.heapSize]
 			} else {
 				heap.Push(e, orderRow)
+				// The heap only grows before it's full, so a huge limit may exceed the memory quota,
+				// then all the rows are sorted by the FileSorter and the limit is applied on the output.
+				exceeded, err := e.consumeMemory(orderRow)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				if exceeded {
+					if err = e.spill(); err != nil {
+						return nil, errors.Trace(err)
+					}
+					e.heapSize = 0
+					e.Idx = 0
+				}
 			}
 		}
-		if e.limit.Offset == 0 {
-			sort.Sort(&e.SortExec)
-		} else {
-			for i := 0; i < int(e.limit.Count) && e.Len() > 0; i++ {
-				heap.Pop(e)
+		// The spilled rows are sorted by the FileSorter when they are output.
+		if e.fileSorter == nil {
+			if e.limit.Offset == 0 {
+				sort.Sort(&e.SortExec)
+			} else {
+				for i := 0; i < int(e.limit.Count) && e.Len() > 0; i++ {
+					heap.Pop(e)
+				}
 			}
 		}
 		e.fetched = true
 	}
+	if e.fileSorter != nil {
+		return e.nextSpilledRowInLimit()
+	}
 	if e.Idx >= len(e.Rows) {
 		return nil, nil
 	}
@@ -243,3 +427,19 @@ func (e *TopNExec) Next() (Row, error) {
 	e.Idx++
 	return row, nil
 }
+
+// nextSpilledRowInLimit returns the next sorted row from the FileSorter, skipping the rows before the offset
+// and stopping after the limit count.
+func (e *TopNExec) nextSpilledRowInLimit() (Row, error) {
+	for ; e.Idx < int(e.limit.Offset); e.Idx++ {
+		row, err := e.nextSpilledRow()
+		if err != nil || row == nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if e.Idx >= e.totalCount {
+		return nil, nil
+	}
+	e.Idx++
+	return e.nextSpilledRow()
+}
@@ -0,0 +1,411 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"math"
+
+	"github.com/cznic/mathutil"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression/aggregation"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// WindowExec is the executor for window functions. Its child must be sorted by the partition by items
+// and then the order by items. It buffers the rows of one partition, evaluates all the window functions
+// over the partition and returns the child rows with the window function results appended.
+type WindowExec struct {
+	baseExecutor
+
+	StmtCtx         *variable.StatementContext
+	WindowFuncDescs []*plan.WindowFuncDesc
+	PartitionBy     []*plan.ByItems
+	OrderBy         []*plan.ByItems
+	Frame           *plan.WindowFrame
+
+	executed     bool
+	partition    []Row
+	results      [][]types.Datum
+	cursor       int
+	nextRow      Row
+	partitionKey []types.Datum
+	// peerStart and peerEnd are the bounds of the peer group of every row in the partition.
+	peerStart []int
+	peerEnd   []int
+}
+
+// Open implements the Executor Open interface.
+func (e *WindowExec) Open() error {
+	e.executed = false
+	e.partition = nil
+	e.results = nil
+	e.cursor = 0
+	e.nextRow = nil
+	e.partitionKey = nil
+	return errors.Trace(e.children[0].Open())
+}
+
+// Close implements the Executor Close interface.
+func (e *WindowExec) Close() error {
+	e.partition = nil
+	e.results = nil
+	e.nextRow = nil
+	return errors.Trace(e.children[0].Close())
+}
+
+// Next implements the Executor Next interface.
+func (e *WindowExec) Next() (Row, error) {
+	if e.cursor >= len(e.partition) {
+		if e.executed {
+			return nil, nil
+		}
+		if err := e.fetchPartition(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(e.partition) == 0 {
+			return nil, nil
+		}
+		if err := e.evalPartition(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	row := e.partition[e.cursor]
+	retRow := make(Row, 0, len(row)+len(e.WindowFuncDescs))
+	retRow = append(retRow, row...)
+	for i := range e.WindowFuncDescs {
+		retRow = append(retRow, e.results[i][e.cursor])
+	}
+	e.cursor++
+	return retRow, nil
+}
+
+// fetchPartition reads all the rows of the next partition from the child.
+func (e *WindowExec) fetchPartition() error {
+	e.partition = e.partition[:0]
+	e.cursor = 0
+	if e.nextRow != nil {
+		e.partition = append(e.partition, e.nextRow)
+		e.nextRow = nil
+	}
+	for {
+		row, err := e.children[0].Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			e.executed = true
+			return nil
+		}
+		newPartition, err := e.meetNewPartition(row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if newPartition {
+			e.nextRow = row
+			return nil
+		}
+		e.partition = append(e.partition, row)
+	}
+}
+
+// meetNewPartition returns a value that represents if the row belongs to a different partition from the last row.
+func (e *WindowExec) meetNewPartition(row Row) (bool, error) {
+	if len(e.PartitionBy) == 0 {
+		return false, nil
+	}
+	key, err := evalByItems(e.PartitionBy, row)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if e.partitionKey == nil {
+		e.partitionKey = key
+		return false, nil
+	}
+	cmp, err := compareDatums(e.StmtCtx, key, e.partitionKey)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	e.partitionKey = key
+	return cmp != 0, nil
+}
+
+// evalPartition evaluates all the window functions over the buffered partition.
+func (e *WindowExec) evalPartition() error {
+	if err := e.buildPeers(); err != nil {
+		return errors.Trace(err)
+	}
+	e.results = make([][]types.Datum, len(e.WindowFuncDescs))
+	for i, desc := range e.WindowFuncDescs {
+		var err error
+		switch desc.Name {
+		case ast.WindowFuncRowNumber, ast.WindowFuncRank, ast.WindowFuncDenseRank:
+			e.results[i] = e.evalRanking(desc.Name)
+		case ast.WindowFuncLag, ast.WindowFuncLead:
+			e.results[i], err = e.evalLeadLag(desc)
+		default:
+			e.results[i], err = e.evalAggregation(desc)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// buildPeers splits the partition into peer groups, the rows in one peer group have the same order by values.
+func (e *WindowExec) buildPeers() error {
+	n := len(e.partition)
+	e.peerStart = make([]int, n)
+	e.peerEnd = make([]int, n)
+	start := 0
+	var lastKey []types.Datum
+	for i, row := range e.partition {
+		key, err := evalByItems(e.OrderBy, row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if i > 0 {
+			cmp, err := compareDatums(e.StmtCtx, key, lastKey)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if cmp != 0 {
+				for j := start; j < i; j++ {
+					e.peerEnd[j] = i
+				}
+				start = i
+			}
+		}
+		e.peerStart[i] = start
+		lastKey = key
+	}
+	for j := start; j < n; j++ {
+		e.peerEnd[j] = n
+	}
+	return nil
+}
+
+func (e *WindowExec) evalRanking(name string) []types.Datum {
+	results := make([]types.Datum, len(e.partition))
+	denseRank := int64(0)
+	for i := range e.partition {
+		if e.peerStart[i] == i {
+			denseRank++
+		}
+		switch name {
+		case ast.WindowFuncRowNumber:
+			results[i].SetInt64(int64(i + 1))
+		case ast.WindowFuncRank:
+			results[i].SetInt64(int64(e.peerStart[i] + 1))
+		case ast.WindowFuncDenseRank:
+			results[i].SetInt64(denseRank)
+		}
+	}
+	return results
+}
+
+func (e *WindowExec) evalLeadLag(desc *plan.WindowFuncDesc) ([]types.Datum, error) {
+	results := make([]types.Datum, len(e.partition))
+	for i, row := range e.partition {
+		offset := int64(1)
+		if len(desc.Args) > 1 {
+			v, err := desc.Args[1].Eval(row)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			offset, err = v.ToInt64(e.StmtCtx)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if desc.Name == ast.WindowFuncLag {
+			offset = -offset
+		}
+		var err error
+		if j := int64(i) + offset; j >= 0 && j < int64(len(e.partition)) {
+			results[i], err = desc.Args[0].Eval(e.partition[j])
+		} else if len(desc.Args) > 2 {
+			results[i], err = desc.Args[2].Eval(row)
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return results, nil
+}
+
+func (e *WindowExec) evalAggregation(desc *plan.WindowFuncDesc) ([]types.Datum, error) {
+	results := make([]types.Datum, len(e.partition))
+	agg := aggregation.NewAggFunction(desc.Name, desc.Args, false)
+	positions, err := e.orderPositions()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// When the frame starts from the first row of the partition, the frame only grows,
+	// so we can update the aggregation context incrementally.
+	incremental := e.Frame.Start.UnBounded
+	aggCtx := agg.CreateContext()
+	updated := 0
+	for i := range e.partition {
+		start, end := e.frameBounds(i, positions)
+		if !incremental {
+			aggCtx = agg.CreateContext()
+			updated = start
+		}
+		for ; updated < end; updated++ {
+			if err = agg.Update(aggCtx, e.StmtCtx, e.partition[updated]); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		results[i] = types.CopyDatum(agg.GetResult(aggCtx))
+	}
+	return results, nil
+}
+
+// orderPositions returns the positions of the rows for the RANGE frame with offsets. The order by value is
+// negated for descending order and NULL is placed at the end that it is sorted, so the positions are always
+// in ascending order.
+func (e *WindowExec) orderPositions() ([]float64, error) {
+	if e.Frame.Type != ast.Ranges || len(e.OrderBy) != 1 {
+		return nil, nil
+	}
+	if !isOffsetBound(e.Frame.Start) && !isOffsetBound(e.Frame.End) {
+		return nil, nil
+	}
+	desc := e.OrderBy[0].Desc
+	positions := make([]float64, len(e.partition))
+	for i, row := range e.partition {
+		v, err := e.OrderBy[0].Expr.Eval(row)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if v.IsNull() {
+			positions[i] = math.Inf(-1)
+			if desc {
+				positions[i] = math.Inf(1)
+			}
+			continue
+		}
+		f, err := v.ToFloat64(e.StmtCtx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if desc {
+			f = -f
+		}
+		positions[i] = f
+	}
+	return positions, nil
+}
+
+func isOffsetBound(bound *plan.FrameBound) bool {
+	return !bound.UnBounded && bound.Type != ast.CurrentRow
+}
+
+// frameBounds returns the frame of the i-th row as the range [start, end) of the partition.
+func (e *WindowExec) frameBounds(i int, positions []float64) (start, end int) {
+	n := len(e.partition)
+	start = e.frameBound(i, e.Frame.Start, true, positions)
+	end = e.frameBound(i, e.Frame.End, false, positions)
+	if start > n {
+		start = n
+	}
+	if end < start {
+		end = start
+	}
+	return start, end
+}
+
+func (e *WindowExec) frameBound(i int, bound *plan.FrameBound, isStart bool, positions []float64) int {
+	n := len(e.partition)
+	if bound.UnBounded {
+		if bound.Type == ast.Preceding {
+			return 0
+		}
+		return n
+	}
+	if e.Frame.Type == ast.Rows {
+		idx := i
+		switch bound.Type {
+		case ast.Preceding:
+			idx = i - int(mathutil.MinUint64(bound.Num, uint64(n)))
+		case ast.Following:
+			idx = i + int(mathutil.MinUint64(bound.Num, uint64(n)))
+		}
+		if !isStart {
+			idx++
+		}
+		if idx < 0 {
+			return 0
+		}
+		if idx > n {
+			return n
+		}
+		return idx
+	}
+	// RANGE frame, the current row means the peer group of the row.
+	if bound.Type == ast.CurrentRow || math.IsInf(positions[i], 0) {
+		if isStart {
+			return e.peerStart[i]
+		}
+		return e.peerEnd[i]
+	}
+	limit := positions[i] - float64(bound.Num)
+	if bound.Type == ast.Following {
+		limit = positions[i] + float64(bound.Num)
+	}
+	if isStart {
+		// The first row whose position is not less than the limit.
+		idx := 0
+		for idx < n && positions[idx] < limit {
+			idx++
+		}
+		return idx
+	}
+	// The row after the last row whose position is not greater than the limit.
+	idx := n
+	for idx > 0 && positions[idx-1] > limit {
+		idx--
+	}
+	return idx
+}
+
+func evalByItems(items []*plan.ByItems, row Row) ([]types.Datum, error) {
+	key := make([]types.Datum, 0, len(items))
+	for _, item := range items {
+		v, err := item.Expr.Eval(row)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		key = append(key, codec.CollationKey(v, item.Expr.GetType().Collate))
+	}
+	return key, nil
+}
+
+func compareDatums(sc *variable.StatementContext, a, b []types.Datum) (int, error) {
+	for i := range a {
+		cmp, err := a[i].CompareDatum(sc, &b[i])
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if cmp != 0 {
+			return cmp, nil
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor_test
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testkit"
+)
+
+func (s *testSuite) TestWindowFunctions(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int, c int)")
+	tk.MustExec("insert t values (1, 1, 1), (1, 2, 2), (1, 2, 3), (2, 1, 4), (2, 3, 5), (3, NULL, 6)")
+
+	result := tk.MustQuery("select c, row_number() over () from t order by c")
+	result.Check(testkit.Rows("1 1", "2 2", "3 3", "4 4", "5 5", "6 6"))
+	result = tk.MustQuery("select a, b, rank() over (partition by a order by b), dense_rank() over (order by b) from t order by c")
+	result.Check(testkit.Rows("1 1 1 2", "1 2 2 3", "1 2 2 3", "2 1 1 2", "2 3 2 4", "3 <nil> 1 1"))
+	result = tk.MustQuery("select c, lag(c) over (order by c), lead(c, 2, -1) over (order by c) from t order by c")
+	result.Check(testkit.Rows("1 <nil> 3", "2 1 4", "3 2 5", "4 3 6", "5 4 -1", "6 5 -1"))
+
+	// Aggregate functions with the default frames.
+	result = tk.MustQuery("select a, c, sum(c) over (partition by a), sum(c) over (partition by a order by b) from t order by c")
+	result.Check(testkit.Rows("1 1 6 1", "1 2 6 6", "1 3 6 6", "2 4 9 4", "2 5 9 9", "3 6 6 6"))
+	result = tk.MustQuery("select c, count(b) over (order by c rows between 1 preceding and 1 following) from t order by c")
+	result.Check(testkit.Rows("1 2", "2 3", "3 3", "4 3", "5 2", "6 1"))
+	result = tk.MustQuery("select c, max(c) over (order by c rows 2 preceding), avg(c) over (order by c desc rows between current row and unbounded following) from t order by c")
+	result.Check(testkit.Rows("1 1 1.0000", "2 2 1.5000", "3 3 2.0000", "4 4 2.5000", "5 5 3.0000", "6 6 3.5000"))
+	result = tk.MustQuery("select c, sum(c) over (order by c range between 1 preceding and 1 following) from t order by c")
+	result.Check(testkit.Rows("1 3", "2 6", "3 9", "4 12", "5 15", "6 11"))
+	result = tk.MustQuery("select c, sum(c) over (order by c desc range 2 preceding) from t order by c")
+	result.Check(testkit.Rows("1 6", "2 9", "3 12", "4 15", "5 11", "6 6"))
+
+	// Window functions together with group by, order by and limit.
+	result = tk.MustQuery("select a, sum(c), rank() over (order by sum(c) desc) from t group by a order by a")
+	result.Check(testkit.Rows("1 6 2", "2 9 1", "3 6 2"))
+	result = tk.MustQuery("select c from t order by row_number() over (order by c desc) limit 2")
+	result.Check(testkit.Rows("6", "5"))
+	result = tk.MustQuery("select * from (select c, row_number() over (partition by a order by c) as rn from t) s where rn = 1 order by c")
+	result.Check(testkit.Rows("1 1", "4 1", "6 1"))
+
+	_, err := tk.Exec("select c from t where row_number() over () > 1")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("select sum(c) over (rows between unbounded following and current row) from t")
+	c.Assert(err, NotNil)
+}
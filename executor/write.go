@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -40,7 +41,8 @@ var (
 // updateRecord updates the row specified by the handle `h`, from `oldData` to `newData`.
 // `modified` means which columns are really modified. It's used for secondary indices.
 // Length of `oldData` and `newData` equals to length of `t.WritableCols()`.
-func updateRecord(ctx context.Context, h int64, oldData, newData []types.Datum, modified []bool, t table.Table, onDup bool) (bool, error) {
+// The foreign key constraints are enforced by `fkc` if it's not nil.
+func updateRecord(ctx context.Context, h int64, oldData, newData []types.Datum, modified []bool, t table.Table, fkc *fkChecker, onDup bool) (bool, error) {
 	var sc = ctx.GetSessionVars().StmtCtx
 	var changed, handleChanged = false, false
 	// onUpdateSpecified is for "UPDATE SET ts_field = old_value", the
@@ -118,6 +120,13 @@ func updateRecord(ctx context.Context, h int64, oldData, newData []types.Datum,
 		}
 	}
 
+	if err = fkc.checkParentRows(t, newData, modified); err != nil {
+		return false, errors.Trace(err)
+	}
+	if err = fkc.onUpdateRow(t, h, oldData, newData, 0); err != nil {
+		return false, errors.Trace(err)
+	}
+
 	if handleChanged {
 		_, err = t.AddRecord(ctx, newData)
 		if err != nil {
@@ -157,6 +166,7 @@ type DeleteExec struct {
 	Tables       []*ast.TableName
 	IsMultiTable bool
 	tblID2Table  map[int64]table.Table
+	fkChecker    *fkChecker
 	// Table ID may not be unique for deleting multiple tables, for statements like
 	// `delete from t as t1, t as t2`, the same table has two alias, we have to identify a table
 	// by its alias instead of ID, so the table map value is an array which contains table aliases.
@@ -303,7 +313,11 @@ func (e *DeleteExec) deleteSingleTable() error {
 }
 
 func (e *DeleteExec) removeRow(ctx context.Context, t table.Table, h int64, data []types.Datum) error {
-	err := t.RemoveRecord(ctx, h, data)
+	err := e.fkChecker.onDeleteRow(t, h, data, 0)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = t.RemoveRecord(ctx, h, data)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -587,7 +601,10 @@ func (e *LoadDataInfo) insertData(cols []string) {
 		e.insertVal.handleLoadDataWarnings(err, warnLog)
 		return
 	}
-	_, err = e.Table.AddRecord(e.insertVal.ctx, row)
+	err = e.insertVal.fkChecker.checkParentRows(e.Table, row, nil)
+	if err == nil {
+		_, err = e.Table.AddRecord(e.insertVal.ctx, row)
+	}
 	if err != nil {
 		warnLog := fmt.Sprintf("Load Data: insert data:%v failed:%v", row, errors.ErrorStack(err))
 		e.insertVal.handleLoadDataWarnings(err, warnLog)
@@ -642,6 +659,11 @@ func (e *LoadData) Next() (Row, error) {
 	return nil, nil
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *LoadData) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 // Schema implements the Executor Schema interface.
 func (e *LoadData) Schema() *expression.Schema {
 	return expression.NewSchema()
@@ -664,6 +686,7 @@ type InsertValues struct {
 	lastInsertID          uint64
 	ctx                   context.Context
 	needFillDefaultValues bool
+	fkChecker             *fkChecker
 
 	SelectExec Executor
 
@@ -736,6 +759,13 @@ func (e *InsertExec) Next() (Row, error) {
 			txn = e.ctx.Txn()
 			rowCount = 0
 		}
+		if err = e.fkChecker.checkParentRows(e.Table, row, nil); err != nil {
+			if e.IgnoreErr && ErrNoReferencedRow.Equal(err) {
+				e.ctx.GetSessionVars().StmtCtx.AppendWarning(err)
+				continue
+			}
+			return nil, errors.Trace(err)
+		}
 		if len(e.OnDuplicate) == 0 && !e.IgnoreErr {
 			txn.SetOption(kv.PresumeKeyNotExists, nil)
 		}
@@ -773,6 +803,11 @@ func (e *InsertExec) Next() (Row, error) {
 	return nil, nil
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *InsertExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 // Close implements the Executor Close interface.
 func (e *InsertExec) Close() error {
 	e.ctx.GetSessionVars().CurrInsertValues = nil
@@ -1165,7 +1200,7 @@ func (e *InsertExec) onDuplicateUpdate(row []types.Datum, h int64, cols []*expre
 		newData[col.Col.Index] = val
 		assignFlag[col.Col.Index] = true
 	}
-	if _, err = updateRecord(e.ctx, h, data, newData, assignFlag, e.Table, true); err != nil {
+	if _, err = updateRecord(e.ctx, h, data, newData, assignFlag, e.Table, e.fkChecker, true); err != nil {
 		return errors.Trace(err)
 	}
 	return nil
@@ -1251,6 +1286,9 @@ func (e *ReplaceExec) Next() (Row, error) {
 			break
 		}
 		row := rows[idx]
+		if err1 := e.fkChecker.checkParentRows(e.Table, row, nil); err1 != nil {
+			return nil, errors.Trace(err1)
+		}
 		h, err1 := e.Table.AddRecord(e.ctx, row)
 		if err1 == nil {
 			getDirtyDB(e.ctx).addRow(e.Table.Meta().ID, h, row)
@@ -1275,6 +1313,10 @@ func (e *ReplaceExec) Next() (Row, error) {
 			continue
 		}
 		// Remove current row and try replace again.
+		err1 = e.fkChecker.onDeleteRow(e.Table, h, oldRow, 0)
+		if err1 != nil {
+			return nil, errors.Trace(err1)
+		}
 		err1 = e.Table.RemoveRecord(e.ctx, h, oldRow)
 		if err1 != nil {
 			return nil, errors.Trace(err1)
@@ -1290,6 +1332,11 @@ func (e *ReplaceExec) Next() (Row, error) {
 	return nil, nil
 }
 
+// NextChunk implements the Executor NextChunk interface.
+func (e *ReplaceExec) NextChunk(chk *chunk.Chunk) error {
+	return errors.Trace(errNextChunkNotSupported)
+}
+
 // UpdateExec represents a new update executor.
 type UpdateExec struct {
 	baseExecutor
@@ -1301,6 +1348,7 @@ type UpdateExec struct {
 	// updatedRowKeys is a map for unique (Table, handle) pair.
 	updatedRowKeys map[int64]map[int64]struct{}
 	tblID2table    map[int64]table.Table
+	fkChecker      *fkChecker
 
 	rows        []Row           // The rows fetched from TableExec.
 	newRowsData [][]types.Datum // The new values to be set.
@@ -1348,7 +1396,7 @@ func (e *UpdateExec) Next() (Row, error) {
 				continue
 			}
 			// Update row
-			changed, err1 := updateRecord(e.ctx, handle, oldData, newTableData, flags, tbl, false)
+			changed, err1 := updateRecord(e.ctx, handle, oldData, newTableData, flags, tbl, e.fkChecker, false)
 			if err1 == nil {
 				if changed {
 					e.updatedRowKeys[id][handle] = struct{}{}
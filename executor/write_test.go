@@ -21,7 +21,9 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/ddl"
 	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/sessionctx"
@@ -1312,3 +1314,152 @@ func (s *testSuite) TestInsertCalculatedValue(c *C) {
 	tk.MustExec("insert t set b = a, a = 4")
 	tk.MustQuery("select * from t").Check(testkit.Rows("4 0 2"))
 }
+
+func (s *testSuite) TestForeignKey(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists fk_child, fk_parent")
+	tk.MustExec("create table fk_parent (id int primary key, name varchar(10))")
+	tk.MustExec(`create table fk_child (id int, pid int, index idx_pid(pid),
+		foreign key fk_pid (pid) references fk_parent(id) on delete cascade on update cascade)`)
+	tk.MustExec("insert into fk_parent values (1, 'a'), (2, 'b'), (3, 'c')")
+
+	// The child rows must refer to the existing parent rows.
+	tk.MustExec("insert into fk_child values (1, 1), (2, 2), (3, null)")
+	_, err := tk.Exec("insert into fk_child values (4, 4)")
+	c.Assert(executor.ErrNoReferencedRow.Equal(err), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("update fk_child set pid = 5 where id = 1")
+	c.Assert(executor.ErrNoReferencedRow.Equal(err), IsTrue, Commentf("err %v", err))
+	tk.MustExec("insert ignore into fk_child values (4, 4), (5, 3)")
+	tk.MustQuery("show warnings").Check(testkit.Rows("Warning 1452 Cannot add or update a child row: a foreign key constraint fails " +
+		"(`test`.`fk_child`, CONSTRAINT `fk_pid` FOREIGN KEY (`pid`) REFERENCES `fk_parent` (`id`))"))
+	tk.MustQuery("select * from fk_child order by id").Check(testkit.Rows("1 1", "2 2", "3 <nil>", "5 3"))
+
+	// CASCADE.
+	tk.MustExec("update fk_parent set id = 10 where id = 1")
+	tk.MustQuery("select * from fk_child where id = 1").Check(testkit.Rows("1 10"))
+	tk.MustExec("delete from fk_parent where id = 3")
+	tk.MustQuery("select * from fk_child order by id").Check(testkit.Rows("1 10", "2 2", "3 <nil>"))
+	tk.MustExec("admin check table fk_child")
+
+	// SET NULL, the index on the foreign key is added automatically.
+	tk.MustExec("drop table if exists fk_null")
+	tk.MustExec("create table fk_null (id int, pid int, foreign key (pid) references fk_parent(id) on delete set null on update set null)")
+	tk.MustExec("insert into fk_null values (1, 10), (2, 2)")
+	tk.MustExec("update fk_parent set id = 20 where id = 10")
+	tk.MustQuery("select * from fk_null order by id").Check(testkit.Rows("1 <nil>", "2 2"))
+	tk.MustQuery("select * from fk_child where id = 1").Check(testkit.Rows("1 20"))
+	tk.MustExec("delete from fk_parent where id = 2")
+	tk.MustQuery("select * from fk_null order by id").Check(testkit.Rows("1 <nil>", "2 <nil>"))
+	tk.MustQuery("select * from fk_child order by id").Check(testkit.Rows("1 20", "3 <nil>"))
+
+	// RESTRICT.
+	tk.MustExec("drop table if exists fk_restrict")
+	tk.MustExec("create table fk_restrict (id int, pid int, foreign key (pid) references fk_parent(id))")
+	tk.MustExec("insert into fk_restrict values (1, 20)")
+	_, err = tk.Exec("delete from fk_parent where id = 20")
+	c.Assert(executor.ErrRowIsReferenced.Equal(err), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("update fk_parent set id = 30 where id = 20")
+	c.Assert(executor.ErrRowIsReferenced.Equal(err), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("replace into fk_parent values (20, 'd')")
+	c.Assert(executor.ErrRowIsReferenced.Equal(err), IsTrue, Commentf("err %v", err))
+	tk.MustExec("update fk_parent set name = 'd' where id = 20")
+	tk.MustQuery("select * from fk_parent").Check(testkit.Rows("20 d"))
+
+	// The constraints aren't checked if foreign_key_checks is disabled.
+	tk.MustExec("set foreign_key_checks = 0")
+	tk.MustExec("insert into fk_child values (6, 6)")
+	tk.MustExec("delete from fk_parent")
+	tk.MustQuery("select count(*) from fk_child").Check(testkit.Rows("3"))
+	tk.MustExec("set foreign_key_checks = 1")
+	_, err = tk.Exec("insert into fk_child values (7, 7)")
+	c.Assert(executor.ErrNoReferencedRow.Equal(err), IsTrue, Commentf("err %v", err))
+
+	// The row may refer to itself.
+	tk.MustExec("drop table if exists fk_self")
+	tk.MustExec("create table fk_self (id int primary key, pid int, foreign key (pid) references fk_self(id) on delete cascade)")
+	tk.MustExec("insert into fk_self values (1, 1), (2, 1), (3, 2)")
+	tk.MustExec("delete from fk_self where id = 1")
+	tk.MustQuery("select count(*) from fk_self").Check(testkit.Rows("0"))
+
+	// The indexes used by the foreign keys are required.
+	tk.MustQuery("select index_name from information_schema.statistics where table_name = 'fk_null'").Check(testkit.Rows("pid"))
+	_, err = tk.Exec("alter table fk_null drop index pid")
+	c.Assert(ddl.ErrDropIndexFk.Equal(err), IsTrue, Commentf("err %v", err))
+	tk.MustExec("drop table if exists fk_noindex")
+	tk.MustExec("create table fk_noindex (id int, name varchar(10))")
+	_, err = tk.Exec("create table fk_noindex_child (id int, name varchar(10), foreign key (name) references fk_noindex(name))")
+	c.Assert(ddl.ErrFkNoIndexParent.Equal(err), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("alter table fk_noindex add foreign key fk_name (name) references fk_parent(name)")
+	c.Assert(ddl.ErrFkNoIndexChild.Equal(err), IsTrue, Commentf("err %v", err))
+
+	// The referenced table can't be truncated or dropped.
+	_, err = tk.Exec("truncate table fk_parent")
+	c.Assert(ddl.ErrTruncateIllegalFk.Equal(err), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("drop table fk_parent")
+	c.Assert(ddl.ErrFkCannotDropParent.Equal(err), IsTrue, Commentf("err %v", err))
+	tk.MustExec("drop table fk_noindex, fk_self, fk_restrict, fk_null, fk_child, fk_parent")
+
+	// The referenced table must exist unless foreign_key_checks is disabled.
+	_, err = tk.Exec("create table fk_orphan (id int, pid int, foreign key (pid) references fk_missing(id))")
+	c.Assert(infoschema.ErrCannotAddForeign.Equal(err), IsTrue, Commentf("err %v", err))
+	tk.MustExec("set foreign_key_checks = 0")
+	tk.MustExec("create table fk_orphan (id int, pid int, foreign key (pid) references fk_missing(id))")
+	tk.MustExec("set foreign_key_checks = 1")
+	// The foreign key refers to the table once it's created.
+	tk.MustExec("create table fk_missing (id int primary key)")
+	_, err = tk.Exec("insert into fk_orphan values (1, 1)")
+	c.Assert(executor.ErrNoReferencedRow.Equal(err), IsTrue, Commentf("err %v", err))
+	tk.MustExec("insert into fk_missing values (1)")
+	tk.MustExec("insert into fk_orphan values (1, 1)")
+	_, err = tk.Exec("delete from fk_missing")
+	c.Assert(executor.ErrRowIsReferenced.Equal(err), IsTrue, Commentf("err %v", err))
+	tk.MustExec("drop table fk_orphan, fk_missing")
+
+	// The referenced table may be in another schema.
+	tk.MustExec("drop database if exists fk_db")
+	tk.MustExec("create database fk_db")
+	tk.MustExec("create table fk_db.fk_parent (id int primary key)")
+	tk.MustExec("create table fk_parent (id int primary key)")
+	tk.MustExec("create table fk_child (id int, pid int, foreign key (pid) references fk_db.fk_parent(id) on delete cascade)")
+	tk.MustExec("insert into fk_db.fk_parent values (1), (2)")
+	tk.MustExec("insert into fk_child values (1, 1), (2, 2)")
+	// The table with the same name in the current schema isn't referenced.
+	tk.MustExec("insert into fk_parent values (3)")
+	_, err = tk.Exec("insert into fk_child values (3, 3)")
+	c.Assert(executor.ErrNoReferencedRow.Equal(err), IsTrue, Commentf("err %v", err))
+	tk.MustExec("delete from fk_db.fk_parent where id = 1")
+	tk.MustQuery("select * from fk_child").Check(testkit.Rows("2 2"))
+	tk.MustExec("delete from fk_parent")
+	tk.MustQuery("select * from fk_child").Check(testkit.Rows("2 2"))
+	_, err = tk.Exec("drop table fk_db.fk_parent")
+	c.Assert(ddl.ErrFkCannotDropParent.Equal(err), IsTrue, Commentf("err %v", err))
+	tk.MustQuery("show create table fk_child").Check(testkit.Rows("fk_child CREATE TABLE `fk_child` (\n" +
+		"  `id` int(11) DEFAULT NULL,\n" +
+		"  `pid` int(11) DEFAULT NULL,\n" +
+		"  KEY `pid` (`pid`),\n" +
+		"  CONSTRAINT `pid` FOREIGN KEY (`pid`) REFERENCES `fk_db`.`fk_parent` (`id`) ON DELETE CASCADE\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin"))
+	tk.MustExec("drop table fk_child, fk_parent")
+	tk.MustExec("drop database fk_db")
+}
+
+func (s *testSuite) TestForeignKeyConcurrentWrite(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists fk_child, fk_parent")
+	tk.MustExec("create table fk_parent (id int primary key)")
+	tk.MustExec("create table fk_child (id int, pid int, foreign key (pid) references fk_parent(id))")
+	tk.MustExec("insert into fk_parent values (1)")
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use test")
+
+	// The parent row is locked by the child row referring to it.
+	tk.MustExec("begin")
+	tk.MustExec("insert into fk_child values (1, 1)")
+	tk1.MustExec("delete from fk_parent where id = 1")
+	_, err := tk.Exec("commit")
+	c.Assert(err, NotNil)
+	tk.MustQuery("select * from fk_child").Check(testkit.Rows())
+	tk.MustExec("drop table fk_child, fk_parent")
+}
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"unsafe"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
@@ -138,6 +139,19 @@ type AggEvaluateContext struct {
 	GotFirstRow     bool          // It will check if the agg has met the first row key.
 }
 
+// MemoryUsage returns the estimated memory usage of the context in bytes,
+// the distinct keys and the buffer of group_concat are included.
+func (ctx *AggEvaluateContext) MemoryUsage() int64 {
+	usage := int64(unsafe.Sizeof(*ctx)) + int64(cap(ctx.Value.GetBytes()))
+	if ctx.DistinctChecker != nil {
+		usage += ctx.DistinctChecker.memoryUsage()
+	}
+	if ctx.Buffer != nil {
+		usage += int64(ctx.Buffer.Cap())
+	}
+	return usage
+}
+
 // AggFunctionMode stands for the aggregation function's mode.
 type AggFunctionMode int
 
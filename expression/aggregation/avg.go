@@ -15,6 +15,7 @@ package aggregation
 
 import (
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
@@ -28,6 +29,7 @@ type avgFunction struct {
 // Clone implements Aggregation interface.
 func (af *avgFunction) Clone() Aggregation {
 	nf := *af
+	nf.Args = make([]expression.Expression, len(af.Args))
 	for i, arg := range af.Args {
 		nf.Args[i] = arg.Clone()
 	}
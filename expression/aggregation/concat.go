@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/types"
@@ -30,6 +31,7 @@ type concatFunction struct {
 // Clone implements Aggregation interface.
 func (cf *concatFunction) Clone() Aggregation {
 	nf := *cf
+	nf.Args = make([]expression.Expression, len(cf.Args))
 	for i, arg := range cf.Args {
 		nf.Args[i] = arg.Clone()
 	}
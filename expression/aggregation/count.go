@@ -20,6 +20,7 @@ import (
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -30,6 +31,7 @@ type countFunction struct {
 // Clone implements Aggregation interface.
 func (cf *countFunction) Clone() Aggregation {
 	nf := *cf
+	nf.Args = make([]expression.Expression, len(cf.Args))
 	for i, arg := range cf.Args {
 		nf.Args[i] = arg.Clone()
 	}
@@ -81,7 +83,8 @@ func (cf *countFunction) Update(ctx *AggEvaluateContext, sc *variable.StatementC
 			ctx.Count += value.GetInt64()
 		}
 		if cf.Distinct {
-			datumBuf = append(datumBuf, value)
+			// The strings equal by the collation are counted once.
+			datumBuf = append(datumBuf, codec.CollationKey(value, a.GetType().Collate))
 		}
 	}
 	if cf.Distinct {
@@ -29,6 +29,7 @@ type firstRowFunction struct {
 // Clone implements Aggregation interface.
 func (ff *firstRowFunction) Clone() Aggregation {
 	nf := *ff
+	nf.Args = make([]expression.Expression, len(ff.Args))
 	for i, arg := range ff.Args {
 		nf.Args[i] = arg.Clone()
 	}
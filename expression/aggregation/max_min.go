@@ -19,6 +19,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -30,6 +31,7 @@ type maxMinFunction struct {
 // Clone implements Aggregation interface.
 func (mmf *maxMinFunction) Clone() Aggregation {
 	nf := *mmf
+	nf.Args = make([]expression.Expression, len(mmf.Args))
 	for i, arg := range mmf.Args {
 		nf.Args[i] = arg.Clone()
 	}
@@ -82,9 +84,13 @@ func (mmf *maxMinFunction) Update(ctx *AggEvaluateContext, sc *variable.Statemen
 		return nil
 	}
 	var c int
-	c, err = ctx.Value.CompareDatum(sc, &value)
-	if err != nil {
-		return errors.Trace(err)
+	if collate := a.GetType().Collate; charset.IsCICollation(collate) && ctx.Value.Kind() == types.KindString && value.Kind() == types.KindString {
+		c = charset.GetCollator(collate).Compare(ctx.Value.GetString(), value.GetString())
+	} else {
+		c, err = ctx.Value.CompareDatum(sc, &value)
+		if err != nil {
+			return errors.Trace(err)
+		}
 	}
 	if (mmf.isMax && c == -1) || (!mmf.isMax && c == 1) {
 		ctx.Value = value
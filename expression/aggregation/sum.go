@@ -29,6 +29,7 @@ type sumFunction struct {
 // Clone implements Aggregation interface.
 func (sf *sumFunction) Clone() Aggregation {
 	nf := *sf
+	nf.Args = make([]expression.Expression, len(sf.Args))
 	for i, arg := range sf.Args {
 		nf.Args[i] = arg.Clone()
 	}
@@ -21,6 +21,11 @@ func createDistinctChecker() *distinctChecker {
 	}
 }
 
+// memoryUsage returns the estimated memory usage of the distinct checker in bytes.
+func (d *distinctChecker) memoryUsage() int64 {
+	return d.existingKeys.MemoryUsage() + int64(cap(d.buf))
+}
+
 // Check checks if values is distinct.
 func (d *distinctChecker) Check(values []types.Datum) (bool, error) {
 	d.buf = d.buf[:0]
@@ -34,6 +34,8 @@ type baseBuiltinFunc struct {
 	ctx    context.Context
 	tp     *types.FieldType
 	pbCode tipb.ScalarFuncSig
+	// collator is used to compare the string arguments, see deriveCollator.
+	collator charset.Collator
 }
 
 func (b *baseBuiltinFunc) PbCode() tipb.ScalarFuncSig {
@@ -46,12 +48,52 @@ func (b *baseBuiltinFunc) setPbCode(c tipb.ScalarFuncSig) {
 
 func newBaseBuiltinFunc(ctx context.Context, args []Expression) baseBuiltinFunc {
 	return baseBuiltinFunc{
-		args: args,
-		ctx:  ctx,
-		tp:   types.NewFieldType(mysql.TypeUnspecified),
+		args:     args,
+		ctx:      ctx,
+		tp:       types.NewFieldType(mysql.TypeUnspecified),
+		collator: deriveCollator(args),
 	}
 }
 
+// deriveCollator returns the collator to compare the string arguments by.
+// The explicit COLLATE clauses take precedence over the collations of the columns, which take precedence
+// over the ones of the constants and the function results. The strings are compared in binary if the
+// arguments of the same precedence have different collations.
+func deriveCollator(args []Expression) charset.Collator {
+	var collator charset.Collator
+	for _, arg := range args {
+		sf, ok := arg.(*ScalarFunction)
+		if !ok || sf.FuncName.L != ast.SetCollation {
+			continue
+		}
+		c := charset.GetCollator(sf.RetType.Collate)
+		if collator == nil {
+			collator = c
+		} else if collator != c {
+			return charset.GetCollator(charset.CollationBin)
+		}
+	}
+	if collator != nil {
+		return collator
+	}
+	for _, arg := range args {
+		col, ok := arg.(*Column)
+		if !ok || col.RetType == nil || col.RetType.EvalType() != types.ETString {
+			continue
+		}
+		c := charset.GetCollator(col.RetType.Collate)
+		if collator == nil {
+			collator = c
+		} else if collator != c {
+			return charset.GetCollator(charset.CollationBin)
+		}
+	}
+	if collator == nil {
+		return charset.GetCollator(charset.CollationBin)
+	}
+	return collator
+}
+
 // newBaseBuiltinFuncWithTp creates a built-in function signature with specified types of arguments and the return type of the function.
 // argTps indicates the types of the args, retType indicates the return type of the built-in function.
 // Every built-in function needs determined argTps and retType when we create it.
@@ -145,9 +187,10 @@ func newBaseBuiltinFuncWithTp(ctx context.Context, args []Expression, retType ty
 		fieldType.Charset, fieldType.Collate = charset.CharsetUTF8, charset.CharsetUTF8
 	}
 	return baseBuiltinFunc{
-		args: args,
-		ctx:  ctx,
-		tp:   fieldType,
+		args:     args,
+		ctx:      ctx,
+		tp:       fieldType,
+		collator: deriveCollator(args),
 	}
 }
 
@@ -420,6 +463,7 @@ var funcs = map[string]functionClass{
 	ast.CharLength:      &charLengthFunctionClass{baseFunctionClass{ast.CharLength, 1, 1}},
 	ast.CharacterLength: &charLengthFunctionClass{baseFunctionClass{ast.CharacterLength, 1, 1}},
 	ast.FindInSet:       &findInSetFunctionClass{baseFunctionClass{ast.FindInSet, 2, 2}},
+	ast.SetCollation:    &setCollationFunctionClass{baseFunctionClass{ast.SetCollation, 2, 2}},
 
 	// information functions
 	ast.ConnectionID: &connectionIDFunctionClass{baseFunctionClass{ast.ConnectionID, 0, 0}},
@@ -15,6 +15,7 @@ package expression
 
 import (
 	"regexp"
+	"unicode/utf8"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
@@ -56,7 +57,7 @@ type builtinLikeSig struct {
 
 // evalInt evals a builtinLikeSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/string-comparison-functions.html#operator_like
-// NOTE: Currently tikv's like function is case sensitive, so we keep its behavior here.
+// The characters are matched by the collation, so it's case-insensitive for the *_ci collations.
 func (b *builtinLikeSig) evalInt(row []types.Datum) (int64, bool, error) {
 	sc := b.ctx.GetSessionVars().StmtCtx
 	valStr, isNull, err := b.args[0].EvalString(row, sc)
@@ -74,6 +75,10 @@ func (b *builtinLikeSig) evalInt(row []types.Datum) (int64, bool, error) {
 		return 0, isNull, errors.Trace(err)
 	}
 	escape := byte(val)
+	if escape < utf8.RuneSelf {
+		escape = b.collator.Fold(string(escape))[0]
+	}
+	valStr, patternStr = b.collator.Fold(valStr), b.collator.Fold(patternStr)
 	patChars, patTypes := stringutil.CompilePattern(patternStr, escape)
 	match := stringutil.DoMatch(valStr, patChars, patTypes)
 	return boolToInt64(match), false, nil
@@ -67,6 +67,7 @@ var (
 	_ functionClass = &charFunctionClass{}
 	_ functionClass = &charLengthFunctionClass{}
 	_ functionClass = &findInSetFunctionClass{}
+	_ functionClass = &setCollationFunctionClass{}
 	_ functionClass = &fieldFunctionClass{}
 	_ functionClass = &makeSetFunctionClass{}
 	_ functionClass = &octFunctionClass{}
@@ -126,6 +127,7 @@ var (
 	_ builtinFunc = &builtinCharSig{}
 	_ builtinFunc = &builtinCharLengthSig{}
 	_ builtinFunc = &builtinFindInSetSig{}
+	_ builtinFunc = &builtinSetCollationSig{}
 	_ builtinFunc = &builtinMakeSetSig{}
 	_ builtinFunc = &builtinOctIntSig{}
 	_ builtinFunc = &builtinOctStringSig{}
@@ -738,7 +740,7 @@ func (b *builtinStrcmpSig) evalInt(row []types.Datum) (int64, bool, error) {
 	if isNull || err != nil {
 		return 0, isNull, errors.Trace(err)
 	}
-	res := types.CompareString(left, right)
+	res := b.collator.Compare(left, right)
 	return int64(res), false, nil
 }
 
@@ -1946,6 +1948,40 @@ func (b *builtinFindInSetSig) evalInt(row []types.Datum) (int64, bool, error) {
 	return 0, false, nil
 }
 
+type setCollationFunctionClass struct {
+	baseFunctionClass
+}
+
+// getFunction builds the function of the COLLATE clause, the second argument is the constant collation name.
+func (c *setCollationFunctionClass) getFunction(ctx context.Context, args []Expression) (builtinFunc, error) {
+	if err := c.verifyArgs(args); err != nil {
+		return nil, errors.Trace(err)
+	}
+	con, ok := args[1].(*Constant)
+	if !ok {
+		return nil, errIncorrectArgs.GenByArgs(c.funcName)
+	}
+	collation, err := charset.GetCollationByName(con.Value.GetString())
+	if err != nil {
+		return nil, errUnknownCollation.GenByArgs(con.Value.GetString())
+	}
+	bf := newBaseBuiltinFuncWithTp(ctx, args, types.ETString, types.ETString, types.ETString)
+	bf.tp.Flen = args[0].GetType().Flen
+	bf.tp.Charset, bf.tp.Collate = collation.CharsetName, collation.Name
+	sig := &builtinSetCollationSig{bf}
+	return sig, nil
+}
+
+type builtinSetCollationSig struct {
+	baseBuiltinFunc
+}
+
+// evalString evals `expr COLLATE collation_name`, the value is unchanged, only the collation to compare it by is set.
+// See https://dev.mysql.com/doc/refman/5.7/en/charset-collate.html
+func (b *builtinSetCollationSig) evalString(row []types.Datum) (string, bool, error) {
+	return b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+}
+
 type fieldFunctionClass struct {
 	baseFunctionClass
 }
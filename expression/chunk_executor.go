@@ -0,0 +1,572 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// VectorizedExecute evaluates a list of expressions on all the rows of the input Chunk column by column,
+// and appends the results to the output Chunk, the i-th expression is appended to the i-th column.
+func VectorizedExecute(ctx context.Context, exprs []Expression, input, output *chunk.Chunk) error {
+	if len(exprs) == 0 {
+		output.SetNumVirtualRows(output.NumRows() + input.NumRows())
+		return nil
+	}
+	e := newVecEvaluator(ctx, input)
+	if hasUserVarFunc(exprs) {
+		// The user variables are assigned and read in the order of rows, so the expressions are evaluated row by row.
+		for i := 0; i < input.NumRows(); i++ {
+			for colIdx, expr := range exprs {
+				d, err := expr.Eval(e.row(i))
+				if err != nil {
+					return errors.Trace(err)
+				}
+				output.AppendDatum(colIdx, &d)
+			}
+		}
+		return nil
+	}
+	for colIdx, expr := range exprs {
+		if err := e.evalToColumn(expr, output, colIdx); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// hasUserVarFunc checks whether the expressions assign or read user variables.
+func hasUserVarFunc(exprs []Expression) bool {
+	for _, expr := range exprs {
+		sf, ok := expr.(*ScalarFunction)
+		if !ok {
+			continue
+		}
+		if sf.FuncName.L == ast.SetVar || sf.FuncName.L == ast.GetVar || hasUserVarFunc(sf.GetArgs()) {
+			return true
+		}
+	}
+	return false
+}
+
+// VectorizedFilter applies a list of filters to all the rows of the input Chunk, and returns a bool slice which
+// indicates whether a row passes all the filters. The "selected" slice is reused to store the result if possible.
+// Like EvalBool, a filter is only evaluated on the rows which pass the previous filters.
+func VectorizedFilter(ctx context.Context, filters []Expression, input *chunk.Chunk, selected []bool) ([]bool, error) {
+	selected = selected[:0]
+	for i, numRows := 0, input.NumRows(); i < numRows; i++ {
+		selected = append(selected, true)
+	}
+	e := newVecEvaluator(ctx, input)
+	if hasUserVarFunc(filters) {
+		for i := range selected {
+			match, err := EvalBool(filters, e.row(i), ctx)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			selected[i] = match
+		}
+		return selected, nil
+	}
+	for _, filter := range filters {
+		if _, ok := filter.(*ScalarFunction); ok && filter.GetType().EvalType() == types.ETInt {
+			vals, nulls, err := e.evalInt(filter, selected)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			for i := range selected {
+				selected[i] = selected[i] && !nulls[i] && vals[i] != 0
+			}
+			continue
+		}
+		for i := range selected {
+			if !selected[i] {
+				continue
+			}
+			data, err := filter.Eval(e.row(i))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if data.IsNull() {
+				selected[i] = false
+				continue
+			}
+			b, err := data.ToBool(e.sc)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			selected[i] = b != 0
+		}
+	}
+	return selected, nil
+}
+
+// vecEvaluator evaluates expressions on all the rows of a Chunk.
+// The common builtin functions on int and real values are evaluated column by column, the other expressions are
+// evaluated row by row on the Datum rows converted from the Chunk.
+// A "sel" slice is passed down to the evaluation, the rows whose "sel" value is false are not evaluated and
+// their results are undefined, so the short-circuit evaluation of the row based functions is kept. A nil "sel"
+// means all the rows are evaluated.
+type vecEvaluator struct {
+	sc    *variable.StatementContext
+	input *chunk.Chunk
+	// rows are the Datum rows of the input, they are only built when needed.
+	rows [][]types.Datum
+}
+
+func newVecEvaluator(ctx context.Context, input *chunk.Chunk) *vecEvaluator {
+	return &vecEvaluator{
+		sc:    ctx.GetSessionVars().StmtCtx,
+		input: input,
+	}
+}
+
+func (e *vecEvaluator) numRows() int {
+	return e.input.NumRows()
+}
+
+func (e *vecEvaluator) row(rowIdx int) []types.Datum {
+	if e.rows == nil {
+		e.rows = make([][]types.Datum, e.numRows())
+	}
+	if e.rows[rowIdx] == nil {
+		e.rows[rowIdx] = e.input.GetRow(rowIdx).GetDatumRow()
+	}
+	return e.rows[rowIdx]
+}
+
+func (e *vecEvaluator) evalToColumn(expr Expression, output *chunk.Chunk, colIdx int) error {
+	numRows := e.numRows()
+	switch x := expr.(type) {
+	case *Column:
+		output.AppendColumn(colIdx, e.input, x.Index)
+		return nil
+	case *Constant:
+		for i := 0; i < numRows; i++ {
+			output.AppendDatum(colIdx, &x.Value)
+		}
+		return nil
+	}
+	var d types.Datum
+	switch tp := expr.GetType(); tp.EvalType() {
+	case types.ETInt:
+		vals, nulls, err := e.evalInt(expr, nil)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		unsigned := mysql.HasUnsignedFlag(tp.Flag)
+		for i := 0; i < numRows; i++ {
+			switch {
+			case nulls[i]:
+				d.SetNull()
+			case unsigned:
+				d.SetUint64(uint64(vals[i]))
+			default:
+				d.SetInt64(vals[i])
+			}
+			output.AppendDatum(colIdx, &d)
+		}
+	case types.ETReal:
+		vals, nulls, err := e.evalReal(expr, nil)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for i := 0; i < numRows; i++ {
+			if nulls[i] {
+				d.SetNull()
+			} else {
+				d.SetFloat64(vals[i])
+			}
+			output.AppendDatum(colIdx, &d)
+		}
+	default:
+		for i := 0; i < numRows; i++ {
+			val, err := expr.Eval(e.row(i))
+			if err != nil {
+				return errors.Trace(err)
+			}
+			output.AppendDatum(colIdx, &val)
+		}
+	}
+	return nil
+}
+
+// selectNotNull returns the rows which are selected and whose value is not null.
+func selectNotNull(sel []bool, nulls []bool) []bool {
+	res := make([]bool, len(nulls))
+	for i := range res {
+		res[i] = (sel == nil || sel[i]) && !nulls[i]
+	}
+	return res
+}
+
+func (e *vecEvaluator) evalInt(expr Expression, sel []bool) ([]int64, []bool, error) {
+	switch x := expr.(type) {
+	case *Column:
+		if !x.GetType().Hybrid() && e.input.Fields()[x.Index].EvalType() == types.ETInt {
+			if ints, ok := e.input.Column(x.Index).Int64s(); ok {
+				return ints, e.columnNulls(x.Index), nil
+			}
+		}
+	case *Constant:
+		if !x.GetType().Hybrid() && x.Value.Kind() != types.KindBinaryLiteral {
+			val, isNull, err := x.EvalInt(nil, e.sc)
+			if err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+			vals, nulls := make([]int64, e.numRows()), make([]bool, e.numRows())
+			for i := range vals {
+				vals[i], nulls[i] = val, isNull
+			}
+			return vals, nulls, nil
+		}
+	case *ScalarFunction:
+		switch f := x.Function.(type) {
+		case *builtinLTIntSig:
+			return e.compareInt(x, sel, func(cmp int) bool { return cmp < 0 })
+		case *builtinLEIntSig:
+			return e.compareInt(x, sel, func(cmp int) bool { return cmp <= 0 })
+		case *builtinGTIntSig:
+			return e.compareInt(x, sel, func(cmp int) bool { return cmp > 0 })
+		case *builtinGEIntSig:
+			return e.compareInt(x, sel, func(cmp int) bool { return cmp >= 0 })
+		case *builtinEQIntSig:
+			return e.compareInt(x, sel, func(cmp int) bool { return cmp == 0 })
+		case *builtinNEIntSig:
+			return e.compareInt(x, sel, func(cmp int) bool { return cmp != 0 })
+		case *builtinLTRealSig:
+			return e.compareReal(f.args, sel, func(cmp int) bool { return cmp < 0 })
+		case *builtinLERealSig:
+			return e.compareReal(f.args, sel, func(cmp int) bool { return cmp <= 0 })
+		case *builtinGTRealSig:
+			return e.compareReal(f.args, sel, func(cmp int) bool { return cmp > 0 })
+		case *builtinGERealSig:
+			return e.compareReal(f.args, sel, func(cmp int) bool { return cmp >= 0 })
+		case *builtinEQRealSig:
+			return e.compareReal(f.args, sel, func(cmp int) bool { return cmp == 0 })
+		case *builtinNERealSig:
+			return e.compareReal(f.args, sel, func(cmp int) bool { return cmp != 0 })
+		case *builtinLogicAndSig:
+			return e.logicAnd(f.args, sel)
+		case *builtinLogicOrSig:
+			return e.logicOr(f.args, sel)
+		case *builtinIntIsNullSig:
+			return e.isNull(f.args[0], types.ETInt, sel)
+		case *builtinRealIsNullSig:
+			return e.isNull(f.args[0], types.ETReal, sel)
+		case *builtinArithmeticPlusIntSig:
+			return e.arithmeticInt(x, sel, "+", func(a, b int64) (int64, bool) {
+				return a + b, (a > 0 && b > math.MaxInt64-a) || (a < 0 && b < math.MinInt64-a)
+			})
+		case *builtinArithmeticMinusIntSig:
+			return e.arithmeticInt(x, sel, "-", func(a, b int64) (int64, bool) {
+				return a - b, (a > 0 && -b > math.MaxInt64-a) || (a < 0 && -b < math.MinInt64-a)
+			})
+		case *builtinArithmeticMultiplyIntSig:
+			return e.arithmeticInt(x, sel, "*", func(a, b int64) (int64, bool) {
+				res := a * b
+				return res, a != 0 && res/a != b
+			})
+		}
+	}
+	return e.evalIntByRow(expr, sel)
+}
+
+func (e *vecEvaluator) evalReal(expr Expression, sel []bool) ([]float64, []bool, error) {
+	switch x := expr.(type) {
+	case *Column:
+		if !x.GetType().Hybrid() {
+			switch e.input.Fields()[x.Index].Tp {
+			case mysql.TypeDouble:
+				if reals, ok := e.input.Column(x.Index).Float64s(); ok {
+					return reals, e.columnNulls(x.Index), nil
+				}
+			case mysql.TypeFloat:
+				if float32s, ok := e.input.Column(x.Index).Float32s(); ok {
+					reals := make([]float64, len(float32s))
+					for i, f := range float32s {
+						reals[i] = float64(f)
+					}
+					return reals, e.columnNulls(x.Index), nil
+				}
+			}
+		}
+	case *Constant:
+		if !x.GetType().Hybrid() && x.Value.Kind() != types.KindBinaryLiteral {
+			val, isNull, err := x.EvalReal(nil, e.sc)
+			if err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+			vals, nulls := make([]float64, e.numRows()), make([]bool, e.numRows())
+			for i := range vals {
+				vals[i], nulls[i] = val, isNull
+			}
+			return vals, nulls, nil
+		}
+	case *ScalarFunction:
+		switch f := x.Function.(type) {
+		case *builtinArithmeticPlusRealSig:
+			return e.arithmeticReal(f.args, sel, "+", func(a, b float64) (float64, bool) {
+				return a + b, (a > 0 && b > math.MaxFloat64-a) || (a < 0 && b < -math.MaxFloat64-a)
+			})
+		case *builtinArithmeticMinusRealSig:
+			return e.arithmeticReal(f.args, sel, "-", func(a, b float64) (float64, bool) {
+				return a - b, (a > 0 && -b > math.MaxFloat64-a) || (a < 0 && -b < -math.MaxFloat64-a)
+			})
+		case *builtinArithmeticMultiplyRealSig:
+			return e.arithmeticReal(f.args, sel, "*", func(a, b float64) (float64, bool) {
+				res := a * b
+				return res, math.IsInf(res, 0)
+			})
+		}
+	}
+	return e.evalRealByRow(expr, sel)
+}
+
+func (e *vecEvaluator) columnNulls(colIdx int) []bool {
+	col := e.input.Column(colIdx)
+	nulls := make([]bool, e.numRows())
+	if col.NullCount() > 0 {
+		for i := range nulls {
+			nulls[i] = col.IsNull(i)
+		}
+	}
+	return nulls
+}
+
+func (e *vecEvaluator) evalIntByRow(expr Expression, sel []bool) ([]int64, []bool, error) {
+	vals, nulls := make([]int64, e.numRows()), make([]bool, e.numRows())
+	for i := range vals {
+		if sel != nil && !sel[i] {
+			continue
+		}
+		var err error
+		vals[i], nulls[i], err = expr.EvalInt(e.row(i), e.sc)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+	return vals, nulls, nil
+}
+
+func (e *vecEvaluator) evalRealByRow(expr Expression, sel []bool) ([]float64, []bool, error) {
+	vals, nulls := make([]float64, e.numRows()), make([]bool, e.numRows())
+	for i := range vals {
+		if sel != nil && !sel[i] {
+			continue
+		}
+		var err error
+		vals[i], nulls[i], err = expr.EvalReal(e.row(i), e.sc)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+	return vals, nulls, nil
+}
+
+// evalIntArgs evaluates the two int arguments, the second argument is only evaluated on the rows whose first
+// argument is not null, like the row based functions do.
+func (e *vecEvaluator) evalIntArgs(args []Expression, sel []bool) (vals0, vals1 []int64, nulls []bool, err error) {
+	vals0, nulls0, err := e.evalInt(args[0], sel)
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	sel1 := selectNotNull(sel, nulls0)
+	vals1, nulls1, err := e.evalInt(args[1], sel1)
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	nulls = make([]bool, len(sel1))
+	for i, selected := range sel1 {
+		nulls[i] = !selected || nulls1[i]
+	}
+	return vals0, vals1, nulls, nil
+}
+
+func (e *vecEvaluator) evalRealArgs(args []Expression, sel []bool) (vals0, vals1 []float64, nulls []bool, err error) {
+	vals0, nulls0, err := e.evalReal(args[0], sel)
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	sel1 := selectNotNull(sel, nulls0)
+	vals1, nulls1, err := e.evalReal(args[1], sel1)
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	nulls = make([]bool, len(sel1))
+	for i, selected := range sel1 {
+		nulls[i] = !selected || nulls1[i]
+	}
+	return vals0, vals1, nulls, nil
+}
+
+// hasUnsignedArg checks whether any argument of the int function is unsigned, the vectorized evaluation of the
+// int functions only deals with the signed arguments.
+func hasUnsignedArg(args []Expression) bool {
+	return mysql.HasUnsignedFlag(args[0].GetType().Flag) || mysql.HasUnsignedFlag(args[1].GetType().Flag)
+}
+
+func (e *vecEvaluator) compareInt(sf *ScalarFunction, sel []bool, judge func(cmp int) bool) ([]int64, []bool, error) {
+	args := sf.GetArgs()
+	if hasUnsignedArg(args) {
+		return e.evalIntByRow(sf, sel)
+	}
+	vals0, vals1, nulls, err := e.evalIntArgs(args, sel)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	res := make([]int64, len(nulls))
+	for i := range res {
+		if !nulls[i] {
+			res[i] = boolToInt64(judge(types.CompareInt64(vals0[i], vals1[i])))
+		}
+	}
+	return res, nulls, nil
+}
+
+func (e *vecEvaluator) compareReal(args []Expression, sel []bool, judge func(cmp int) bool) ([]int64, []bool, error) {
+	vals0, vals1, nulls, err := e.evalRealArgs(args, sel)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	res := make([]int64, len(nulls))
+	for i := range res {
+		if !nulls[i] {
+			res[i] = boolToInt64(judge(types.CompareFloat64(vals0[i], vals1[i])))
+		}
+	}
+	return res, nulls, nil
+}
+
+func (e *vecEvaluator) logicAnd(args []Expression, sel []bool) ([]int64, []bool, error) {
+	vals0, nulls0, err := e.evalInt(args[0], sel)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	// The second argument isn't evaluated if the first one is false.
+	sel1 := make([]bool, len(vals0))
+	for i := range sel1 {
+		sel1[i] = (sel == nil || sel[i]) && (nulls0[i] || vals0[i] != 0)
+	}
+	vals1, nulls1, err := e.evalInt(args[1], sel1)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	res, nulls := make([]int64, len(vals0)), make([]bool, len(vals0))
+	for i := range res {
+		if !sel1[i] || (!nulls1[i] && vals1[i] == 0) {
+			continue
+		}
+		if nulls0[i] || nulls1[i] {
+			nulls[i] = true
+			continue
+		}
+		res[i] = 1
+	}
+	return res, nulls, nil
+}
+
+func (e *vecEvaluator) logicOr(args []Expression, sel []bool) ([]int64, []bool, error) {
+	vals0, nulls0, err := e.evalInt(args[0], sel)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	// The second argument isn't evaluated if the first one is true.
+	sel1 := make([]bool, len(vals0))
+	for i := range sel1 {
+		sel1[i] = (sel == nil || sel[i]) && (nulls0[i] || vals0[i] == 0)
+	}
+	vals1, nulls1, err := e.evalInt(args[1], sel1)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	res, nulls := make([]int64, len(vals0)), make([]bool, len(vals0))
+	for i := range res {
+		if !sel1[i] || (!nulls1[i] && vals1[i] != 0) {
+			res[i] = 1
+			continue
+		}
+		nulls[i] = nulls0[i] || nulls1[i]
+	}
+	return res, nulls, nil
+}
+
+func (e *vecEvaluator) isNull(arg Expression, argTp types.EvalType, sel []bool) ([]int64, []bool, error) {
+	var argNulls []bool
+	var err error
+	if argTp == types.ETInt {
+		_, argNulls, err = e.evalInt(arg, sel)
+	} else {
+		_, argNulls, err = e.evalReal(arg, sel)
+	}
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	res := make([]int64, len(argNulls))
+	for i := range res {
+		res[i] = boolToInt64(argNulls[i])
+	}
+	return res, make([]bool, len(argNulls)), nil
+}
+
+func (e *vecEvaluator) arithmeticInt(sf *ScalarFunction, sel []bool, op string, calc func(a, b int64) (int64, bool)) ([]int64, []bool, error) {
+	args := sf.GetArgs()
+	if hasUnsignedArg(args) {
+		return e.evalIntByRow(sf, sel)
+	}
+	vals0, vals1, nulls, err := e.evalIntArgs(args, sel)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	res := make([]int64, len(nulls))
+	for i := range res {
+		if nulls[i] {
+			continue
+		}
+		var overflow bool
+		res[i], overflow = calc(vals0[i], vals1[i])
+		if overflow {
+			return nil, nil, types.ErrOverflow.GenByArgs("BIGINT", fmt.Sprintf("(%s %s %s)", args[0].String(), op, args[1].String()))
+		}
+	}
+	return res, nulls, nil
+}
+
+func (e *vecEvaluator) arithmeticReal(args []Expression, sel []bool, op string, calc func(a, b float64) (float64, bool)) ([]float64, []bool, error) {
+	vals0, vals1, nulls, err := e.evalRealArgs(args, sel)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	res := make([]float64, len(nulls))
+	for i := range res {
+		if nulls[i] {
+			continue
+		}
+		var overflow bool
+		res[i], overflow = calc(vals0[i], vals1[i])
+		if overflow {
+			return nil, nil, types.ErrOverflow.GenByArgs("DOUBLE", fmt.Sprintf("(%s %s %s)", args[0].String(), op, args[1].String()))
+		}
+	}
+	return res, nulls, nil
+}
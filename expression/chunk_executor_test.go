@@ -0,0 +1,138 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"fmt"
+	"math"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func (s *testEvaluatorSuite) newChunkForVecTest() (*chunk.Chunk, []*Column) {
+	fields := []*types.FieldType{
+		types.NewFieldType(mysql.TypeLonglong),
+		types.NewFieldType(mysql.TypeDouble),
+		types.NewFieldType(mysql.TypeVarchar),
+	}
+	fields[0].Flen, fields[2].Flen = mysql.MaxIntWidth, 10
+	cols := make([]*Column, 0, len(fields))
+	for i, ft := range fields {
+		cols = append(cols, &Column{RetType: ft, Index: i, ColName: model.NewCIStr(fmt.Sprintf("c%d", i))})
+	}
+	chk := chunk.NewChunk(fields)
+	for i := 0; i < 10; i++ {
+		if i%4 == 3 {
+			chk.AppendNull(0)
+			chk.AppendNull(1)
+			chk.AppendNull(2)
+			continue
+		}
+		chk.AppendInt64(0, int64(i))
+		chk.AppendFloat64(1, float64(i)+0.5)
+		chk.AppendString(2, fmt.Sprintf("str%d", i))
+	}
+	return chk, cols
+}
+
+func (s *testEvaluatorSuite) newVecTestFunc(c *C, funcName string, args ...Expression) Expression {
+	f, err := NewFunction(s.ctx, funcName, types.NewFieldType(mysql.TypeUnspecified), args...)
+	c.Assert(err, IsNil)
+	return f
+}
+
+func (s *testEvaluatorSuite) TestVectorizedExecute(c *C) {
+	defer testleak.AfterTest(c)()
+	chk, cols := s.newChunkForVecTest()
+	intCon := &Constant{Value: types.NewIntDatum(5), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	realCon := &Constant{Value: types.NewFloat64Datum(2.5), RetType: types.NewFieldType(mysql.TypeDouble)}
+	maxCon := &Constant{Value: types.NewIntDatum(math.MaxInt64), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	exprs := []Expression{
+		cols[0],
+		cols[2],
+		intCon,
+		s.newVecTestFunc(c, ast.LT, cols[0], intCon),
+		s.newVecTestFunc(c, ast.GE, cols[1], realCon),
+		s.newVecTestFunc(c, ast.NE, cols[0], cols[1]),
+		s.newVecTestFunc(c, ast.Plus, cols[0], intCon),
+		s.newVecTestFunc(c, ast.Minus, cols[0], cols[0]),
+		s.newVecTestFunc(c, ast.Mul, cols[0], intCon),
+		s.newVecTestFunc(c, ast.Mul, cols[1], realCon),
+		s.newVecTestFunc(c, ast.Plus, cols[1], cols[0]),
+		s.newVecTestFunc(c, ast.LogicAnd, s.newVecTestFunc(c, ast.GT, cols[0], intCon), s.newVecTestFunc(c, ast.LT, cols[1], realCon)),
+		s.newVecTestFunc(c, ast.LogicOr, s.newVecTestFunc(c, ast.GT, cols[0], intCon), s.newVecTestFunc(c, ast.LT, cols[1], realCon)),
+		s.newVecTestFunc(c, ast.IsNull, cols[0]),
+		s.newVecTestFunc(c, ast.Concat, cols[2], cols[0]),
+		// The multiplication never overflows because it's only evaluated when "c0 < 2".
+		s.newVecTestFunc(c, ast.LogicAnd, s.newVecTestFunc(c, ast.LT, cols[0], &Constant{Value: types.NewIntDatum(2), RetType: types.NewFieldType(mysql.TypeLonglong)}),
+			s.newVecTestFunc(c, ast.GT, s.newVecTestFunc(c, ast.Mul, cols[0], maxCon), intCon)),
+	}
+	fields := make([]*types.FieldType, 0, len(exprs))
+	for _, expr := range exprs {
+		fields = append(fields, expr.GetType())
+	}
+	output := chunk.NewChunk(fields)
+	c.Assert(VectorizedExecute(s.ctx, exprs, chk, output), IsNil)
+	c.Assert(output.NumRows(), Equals, chk.NumRows())
+	for i := 0; i < chk.NumRows(); i++ {
+		row := chk.GetRow(i).GetDatumRow()
+		for j, expr := range exprs {
+			expected, err := expr.Eval(row)
+			c.Assert(err, IsNil)
+			got := output.GetRow(i).GetDatum(j)
+			cmp, err := got.CompareDatum(s.ctx.GetSessionVars().StmtCtx, &expected)
+			c.Assert(err, IsNil)
+			c.Assert(cmp, Equals, 0, Commentf("row %d expr %s, got %v, expected %v", i, expr, got.GetValue(), expected.GetValue()))
+		}
+	}
+
+	// The overflow error is returned like the row based evaluation.
+	output = chunk.NewChunk([]*types.FieldType{types.NewFieldType(mysql.TypeLonglong)})
+	err := VectorizedExecute(s.ctx, []Expression{s.newVecTestFunc(c, ast.Mul, cols[0], maxCon)}, chk, output)
+	c.Assert(terror.ErrorEqual(err, types.ErrOverflow), IsTrue, Commentf("err %v", err))
+}
+
+func (s *testEvaluatorSuite) TestVectorizedFilter(c *C) {
+	defer testleak.AfterTest(c)()
+	chk, cols := s.newChunkForVecTest()
+	intCon := &Constant{Value: types.NewIntDatum(2), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	maxCon := &Constant{Value: types.NewIntDatum(math.MaxInt64), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	tests := [][]Expression{
+		{s.newVecTestFunc(c, ast.GT, cols[0], intCon)},
+		{s.newVecTestFunc(c, ast.GT, cols[0], intCon), s.newVecTestFunc(c, ast.LT, cols[1], &Constant{Value: types.NewFloat64Datum(7), RetType: types.NewFieldType(mysql.TypeDouble)})},
+		{s.newVecTestFunc(c, ast.LogicOr, s.newVecTestFunc(c, ast.IsNull, cols[0]), s.newVecTestFunc(c, ast.EQ, cols[0], intCon))},
+		{cols[1]},
+		// The second filter is only evaluated on the rows which pass the first one, so it never overflows.
+		{s.newVecTestFunc(c, ast.LT, cols[0], intCon), s.newVecTestFunc(c, ast.GT, s.newVecTestFunc(c, ast.Mul, cols[0], maxCon), intCon)},
+	}
+	var selected []bool
+	for _, filters := range tests {
+		var err error
+		selected, err = VectorizedFilter(s.ctx, filters, chk, selected)
+		c.Assert(err, IsNil)
+		c.Assert(selected, HasLen, chk.NumRows())
+		for i := 0; i < chk.NumRows(); i++ {
+			expected, err := EvalBool(filters, chk.GetRow(i).GetDatumRow(), s.ctx)
+			c.Assert(err, IsNil)
+			c.Assert(selected[i], Equals, expected, Commentf("row %d filters %v", i, filters))
+		}
+	}
+}
@@ -342,12 +342,14 @@ func indexCol2Col(cols []*Column, col *model.IndexColumn) *Column {
 // together with a []int containing their lengths.
 // If this index has three IndexColumn that the 1st and 3rd IndexColumn has corresponding *Column,
 // the return value will be only the 1st corresponding *Column and its length.
+// The columns in case-insensitive collations end the result as well if the index keeps the raw strings,
+// because the ranges and the order of the index don't follow the collations.
 func IndexInfo2Cols(cols []*Column, index *model.IndexInfo) ([]*Column, []int) {
 	retCols := make([]*Column, 0, len(index.Columns))
 	lengths := make([]int, 0, len(index.Columns))
 	for _, c := range index.Columns {
 		col := indexCol2Col(cols, c)
-		if col == nil {
+		if col == nil || !index.ComparedByCollation(col.RetType.Collate) {
 			return retCols, lengths
 		}
 		retCols = append(retCols, col)
@@ -30,6 +30,7 @@ var (
 	errIncorrectArgs       = terror.ClassExpression.New(mysql.ErrWrongArguments, mysql.MySQLErrName[mysql.ErrWrongArguments])
 	errUnknownCharacterSet = terror.ClassExpression.New(mysql.ErrUnknownCharacterSet, mysql.MySQLErrName[mysql.ErrUnknownCharacterSet])
 	errDefaultValue        = terror.ClassExpression.New(mysql.ErrInvalidDefault, "invalid default value")
+	errUnknownCollation    = terror.ClassExpression.New(mysql.ErrUnknownCollation, mysql.MySQLErrName[mysql.ErrUnknownCollation])
 )
 
 func init() {
@@ -41,6 +42,7 @@ func init() {
 		mysql.ErrWrongArguments:             mysql.ErrWrongArguments,
 		mysql.ErrUnknownCharacterSet:        mysql.ErrUnknownCharacterSet,
 		mysql.ErrInvalidDefault:             mysql.ErrInvalidDefault,
+		mysql.ErrUnknownCollation:           mysql.ErrUnknownCollation,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassExpression] = expressionMySQLErrCodes
 }
@@ -48,4 +48,6 @@ var unFoldableFunctions = map[string]struct{}{
 	ast.Values:    {},
 	ast.SetVar:    {},
 	ast.GetVar:    {},
+	// The COLLATE clause is kept to compare the constants by its collation.
+	ast.SetCollation: {},
 }
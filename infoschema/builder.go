@@ -55,13 +55,30 @@ func (b *Builder) ApplyDiff(m *meta.Meta, diff *model.SchemaDiff) ([]int64, erro
 	case model.ActionCreateTable:
 		newTableID = diff.TableID
 		tblIDs = append(tblIDs, newTableID)
-	case model.ActionDropTable:
+	case model.ActionDropTable, model.ActionDropView:
 		oldTableID = diff.TableID
 		tblIDs = append(tblIDs, oldTableID)
 	case model.ActionTruncateTable:
 		oldTableID = diff.OldTableID
 		newTableID = diff.TableID
 		tblIDs = append(tblIDs, oldTableID, newTableID)
+	case model.ActionAddPrimaryKey, model.ActionDropPrimaryKey:
+		// OldTableID is not 0 if the table is replaced by the rebuilt table.
+		oldTableID = diff.TableID
+		newTableID = diff.TableID
+		if tableIDIsValid(diff.OldTableID) {
+			oldTableID = diff.OldTableID
+			tblIDs = append(tblIDs, oldTableID)
+		}
+		tblIDs = append(tblIDs, newTableID)
+	case model.ActionCreateView:
+		// OldTableID is not 0 if the view replaces an old view.
+		oldTableID = diff.OldTableID
+		newTableID = diff.TableID
+		tblIDs = append(tblIDs, newTableID)
+		if tableIDIsValid(oldTableID) {
+			tblIDs = append(tblIDs, oldTableID)
+		}
 	default:
 		oldTableID = diff.TableID
 		newTableID = diff.TableID
@@ -317,6 +334,7 @@ func (b *Builder) createSchemaTablesForInfoSchemaDB() {
 
 // Build sets new InfoSchema to the handle in the Builder.
 func (b *Builder) Build() {
+	b.is.buildForeignKeys()
 	b.handle.value.Store(b.is)
 }
 
@@ -54,6 +54,8 @@ var (
 	ErrMultiplePriKey = terror.ClassSchema.New(codeMultiplePriKey, "Multiple primary key defined")
 	// ErrTooManyKeyParts returns for too many key parts.
 	ErrTooManyKeyParts = terror.ClassSchema.New(codeTooManyKeyParts, "Too many key parts specified; max %d parts allowed")
+	// ErrWrongObject returns for a table which is not the expected kind of object, such as dropping a base table with DROP VIEW.
+	ErrWrongObject = terror.ClassSchema.New(codeWrongObject, mysql.MySQLErrName[mysql.ErrWrongObject])
 )
 
 // InfoSchema is the interface used to retrieve the schema information.
@@ -73,6 +75,18 @@ type InfoSchema interface {
 	Clone() (result []*model.DBInfo)
 	SchemaTables(schema model.CIStr) []table.Table
 	SchemaMetaVersion() int64
+	// TableForeignKeys returns the public foreign keys of the table.
+	TableForeignKeys(tableID int64) []*ForeignKeyRef
+	// ReferredForeignKeys returns the public foreign keys referring to the table, including the ones of itself.
+	ReferredForeignKeys(tableID int64) []*ForeignKeyRef
+}
+
+// ForeignKeyRef is a public foreign key of a child table, the schema of the referenced table is resolved.
+type ForeignKeyRef struct {
+	ChildSchema  model.CIStr
+	Child        *model.TableInfo
+	ParentSchema model.CIStr
+	FK           *model.FKInfo
 }
 
 // Information Schema Name.
@@ -119,6 +133,11 @@ type infoSchema struct {
 
 	// schemaMetaVersion is the version of schema, and we should check version when change schema.
 	schemaMetaVersion int64
+
+	// foreignKeys maps the table ID to its foreign keys, referredForeignKeys maps the table ID to the foreign keys
+	// referring to it. They are built with the InfoSchema, so the statements don't iterate all the tables to find them.
+	foreignKeys         map[int64][]*ForeignKeyRef
+	referredForeignKeys map[int64][]*ForeignKeyRef
 }
 
 // MockInfoSchema only serves for test.
@@ -141,6 +160,7 @@ func MockInfoSchema(tbList []*model.TableInfo) InfoSchema {
 	for i := range result.sortedTablesBuckets {
 		sort.Sort(result.sortedTablesBuckets[i])
 	}
+	result.buildForeignKeys()
 	return result
 }
 
@@ -232,6 +252,42 @@ func (is *infoSchema) SchemaTables(schema model.CIStr) (tables []table.Table) {
 	return
 }
 
+func (is *infoSchema) TableForeignKeys(tableID int64) []*ForeignKeyRef {
+	return is.foreignKeys[tableID]
+}
+
+func (is *infoSchema) ReferredForeignKeys(tableID int64) []*ForeignKeyRef {
+	return is.referredForeignKeys[tableID]
+}
+
+// buildForeignKeys builds the maps of the public foreign keys by the table IDs. The referenced tables are found by
+// their names, so the foreign keys refer to the tables created after them once the InfoSchema is rebuilt.
+func (is *infoSchema) buildForeignKeys() {
+	is.foreignKeys = make(map[int64][]*ForeignKeyRef)
+	is.referredForeignKeys = make(map[int64][]*ForeignKeyRef)
+	for _, v := range is.schemaMap {
+		for _, tbl := range v.tables {
+			tblInfo := tbl.Meta()
+			for _, fk := range tblInfo.ForeignKeys {
+				if fk.State != model.StatePublic {
+					continue
+				}
+				ref := &ForeignKeyRef{ChildSchema: v.dbInfo.Name, Child: tblInfo, ParentSchema: fk.RefSchema, FK: fk}
+				if ref.ParentSchema.L == "" {
+					// The foreign keys created before the schema of the referenced table is recorded refer to
+					// the tables in the same schema.
+					ref.ParentSchema = v.dbInfo.Name
+				}
+				is.foreignKeys[tblInfo.ID] = append(is.foreignKeys[tblInfo.ID], ref)
+				if parent, err := is.TableByName(ref.ParentSchema, fk.RefTable); err == nil {
+					parentID := parent.Meta().ID
+					is.referredForeignKeys[parentID] = append(is.referredForeignKeys[parentID], ref)
+				}
+			}
+		}
+	}
+}
+
 func (is *infoSchema) Clone() (result []*model.DBInfo) {
 	for _, v := range is.schemaMap {
 		result = append(result, v.dbInfo.Clone())
@@ -286,6 +342,7 @@ const (
 	codeIndexExists     = 1831
 	codeMultiplePriKey  = 1068
 	codeTooManyKeyParts = 1070
+	codeWrongObject     = 1347
 )
 
 func init() {
@@ -304,6 +361,7 @@ func init() {
 		codeIndexExists:         mysql.ErrDupIndex,
 		codeMultiplePriKey:      mysql.ErrMultiplePriKey,
 		codeTooManyKeyParts:     mysql.ErrTooManyKeyParts,
+		codeWrongObject:         mysql.ErrWrongObject,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassSchema] = schemaMySQLErrCodes
 	initInfoSchemaDB()
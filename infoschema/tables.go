@@ -633,11 +633,15 @@ func dataForTables(schemas []*model.DBInfo) [][]types.Datum {
 	rows := [][]types.Datum{}
 	for _, schema := range schemas {
 		for _, table := range schema.Tables {
+			tableType := "BASE TABLE"
+			if table.IsView() {
+				tableType = "VIEW"
+			}
 			record := types.MakeDatums(
 				catalogVal,      // TABLE_CATALOG
 				schema.Name.O,   // TABLE_SCHEMA
 				table.Name.O,    // TABLE_NAME
-				"BASE TABLE",    // TABLE_TYPE
+				tableType,       // TABLE_TYPE
 				"InnoDB",        // ENGINE
 				uint64(10),      // VERSION
 				"Compact",       // ROW_FORMAT
@@ -662,6 +666,36 @@ func dataForTables(schemas []*model.DBInfo) [][]types.Datum {
 	return rows
 }
 
+func dataForViews(schemas []*model.DBInfo) [][]types.Datum {
+	rows := [][]types.Datum{}
+	for _, schema := range schemas {
+		for _, table := range schema.Tables {
+			if !table.IsView() {
+				continue
+			}
+			view := table.View
+			definer := ""
+			if view.Definer != nil {
+				definer = view.Definer.String()
+			}
+			record := types.MakeDatums(
+				catalogVal,                // TABLE_CATALOG
+				schema.Name.O,             // TABLE_SCHEMA
+				table.Name.O,              // TABLE_NAME
+				view.SelectStmt,           // VIEW_DEFINITION
+				view.CheckOption.String(), // CHECK_OPTION
+				"NO",                      // IS_UPDATABLE
+				definer,                   // DEFINER
+				view.Security.String(),    // SECURITY_TYPE
+				table.Charset,             // CHARACTER_SET_CLIENT
+				table.Collate,             // COLLATION_CONNECTION
+			)
+			rows = append(rows, record)
+		}
+	}
+	return rows
+}
+
 func dataForColumns(schemas []*model.DBInfo) [][]types.Datum {
 	rows := [][]types.Datum{}
 	for _, schema := range schemas {
@@ -917,6 +951,10 @@ func keyColumnUsageInTable(schema *model.DBInfo, table *model.TableInfo) [][]typ
 		if len(fk.RefCols) > 0 {
 			fkRefCol = fk.RefCols[0].O
 		}
+		fkRefSchema := fk.RefSchema.O
+		if fkRefSchema == "" {
+			fkRefSchema = schema.Name.O
+		}
 		for i, key := range fk.Cols {
 			col := nameToCol[key.L]
 			record := types.MakeDatums(
@@ -929,7 +967,7 @@ func keyColumnUsageInTable(schema *model.DBInfo, table *model.TableInfo) [][]typ
 				col.Name.O,    // COLUMN_NAME
 				i+1,           // ORDINAL_POSITION,
 				1,             // POSITION_IN_UNIQUE_CONSTRAINT
-				schema.Name.O, // REFERENCED_TABLE_SCHEMA
+				fkRefSchema,   // REFERENCED_TABLE_SCHEMA
 				fk.RefTable.O, // REFERENCED_TABLE_NAME
 				fkRefCol,      // REFERENCED_COLUMN_NAME
 			)
@@ -1039,6 +1077,7 @@ func (it *infoschemaTable) getRows(ctx context.Context, cols []*table.Column) (f
 	case tableEngines:
 		fullRows = dataForEngines()
 	case tableViews:
+		fullRows = dataForViews(dbs)
 	case tableRoutines:
 	// TODO: Fill the following tables.
 	case tableSchemaPrivileges:
@@ -17,8 +17,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
 )
 
@@ -42,6 +44,14 @@ const (
 	ActionModifyColumn
 	ActionRenameTable
 	ActionSetDefaultValue
+	ActionCreateView
+	ActionDropView
+	ActionAddTablePartition
+	ActionDropTablePartition
+	ActionTruncateTablePartition
+	ActionAddPrimaryKey
+	ActionDropPrimaryKey
+	ActionMultiSchemaChange
 )
 
 func (action ActionType) String() string {
@@ -74,6 +84,22 @@ func (action ActionType) String() string {
 		return "rename table"
 	case ActionSetDefaultValue:
 		return "set default value"
+	case ActionCreateView:
+		return "create view"
+	case ActionDropView:
+		return "drop view"
+	case ActionAddTablePartition:
+		return "add partition"
+	case ActionDropTablePartition:
+		return "drop partition"
+	case ActionTruncateTablePartition:
+		return "truncate partition"
+	case ActionAddPrimaryKey:
+		return "add primary key"
+	case ActionDropPrimaryKey:
+		return "drop primary key"
+	case ActionMultiSchemaChange:
+		return "multi schema change"
 	default:
 		return "none"
 	}
@@ -135,6 +161,86 @@ type Job struct {
 
 	// Version indicates the DDL job version. For old jobs, it will be 0.
 	Version int64 `json:"version"`
+
+	// MultiSchemaInfo keeps the sub-jobs of an ActionMultiSchemaChange job.
+	MultiSchemaInfo *MultiSchemaInfo `json:"multi_schema_info,omitempty"`
+
+	// ReorgMeta keeps the session context of the job which reorganizes the data.
+	ReorgMeta *DDLReorgMeta `json:"reorg_meta,omitempty"`
+}
+
+// DDLReorgMeta is the sql_mode and the time zone of the session which submits the job,
+// the data is converted with them when it's reorganized.
+type DDLReorgMeta struct {
+	SQLMode  mysql.SQLMode     `json:"sql_mode"`
+	TimeZone *TimeZoneLocation `json:"time_zone"`
+}
+
+// TimeZoneLocation is the time zone saved in the job, it can be loaded by any server.
+type TimeZoneLocation struct {
+	Name string `json:"name"`
+	// Offset is the offset of the time zone in seconds east of UTC when the job is submitted.
+	Offset int `json:"offset"`
+
+	location *time.Location
+}
+
+// NewTimeZoneLocation creates a TimeZoneLocation from the time.Location.
+func NewTimeZoneLocation(loc *time.Location) *TimeZoneLocation {
+	_, offset := time.Now().In(loc).Zone()
+	return &TimeZoneLocation{Name: loc.String(), Offset: offset, location: loc}
+}
+
+// GetLocation returns the time zone, it's a fixed zone with the offset if the named time zone
+// can't be loaded or it's a fixed zone like "+08:00".
+func (tz *TimeZoneLocation) GetLocation() *time.Location {
+	if tz.location != nil {
+		return tz.location
+	}
+	loc, err := time.LoadLocation(tz.Name)
+	if err == nil {
+		if _, offset := time.Now().In(loc).Zone(); offset == tz.Offset {
+			tz.location = loc
+			return loc
+		}
+	}
+	tz.location = time.FixedZone(tz.Name, tz.Offset)
+	return tz.location
+}
+
+// SubJob is a part of an ActionMultiSchemaChange job, it describes one of the changes of the ALTER TABLE statement.
+type SubJob struct {
+	Type        ActionType      `json:"type"`
+	Args        []interface{}   `json:"-"`
+	RawArgs     json.RawMessage `json:"raw_args"`
+	SchemaState SchemaState     `json:"schema_state"`
+	// Ready means the added column or index is ready to be public, it becomes public with the other changes.
+	Ready    bool  `json:"ready"`
+	RowCount int64 `json:"row_count"`
+}
+
+// NewSubJob creates a sub-job with the arguments encoded.
+func NewSubJob(tp ActionType, args ...interface{}) (*SubJob, error) {
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &SubJob{Type: tp, Args: args, RawArgs: raw}, nil
+}
+
+// DecodeArgs decodes the sub-job args.
+func (sub *SubJob) DecodeArgs(args ...interface{}) error {
+	sub.Args = args
+	err := json.Unmarshal(sub.RawArgs, &sub.Args)
+	return errors.Trace(err)
+}
+
+// MultiSchemaInfo is the information of the changes applied by an ActionMultiSchemaChange job.
+// The sub-jobs go through the schema states one by one, then the changes become public in the same schema version.
+type MultiSchemaInfo struct {
+	SubJobs []*SubJob `json:"sub_jobs"`
+	// Revertible means the job can be rolled back, it becomes false once the changes are public.
+	Revertible bool `json:"revertible"`
 }
 
 // SetRowCount sets the number of rows. Make sure it can pass `make race`.
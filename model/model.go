@@ -17,6 +17,8 @@ import (
 	"strings"
 
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/auth"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -70,6 +72,16 @@ type ColumnInfo struct {
 	types.FieldType     `json:"type"`
 	State               SchemaState `json:"state"`
 	Comment             string      `json:"comment"`
+	// ChangeStateInfo is set when the column is the hidden column added by a modify column job
+	// which changes the data of the origin column.
+	ChangeStateInfo *ChangeStateInfo `json:"change_state_info"`
+}
+
+// ChangeStateInfo is used to record the information of the column whose data is being changed.
+type ChangeStateInfo struct {
+	// DependencyColumnOffset is the offset of the origin column, the value of the changing column
+	// is converted from the value of it.
+	DependencyColumnOffset int `json:"dependency_column_offset"`
 }
 
 // Clone clones ColumnInfo.
@@ -108,6 +120,24 @@ type TableInfo struct {
 	// We need to save original schemaID to keep autoID unchanged
 	// while renaming a table from one database to another.
 	OldSchemaID int64 `json:"old_schema_id,omitempty"`
+
+	// View is not nil if the table is a view.
+	View *ViewInfo `json:"view"`
+	// Partition is not nil if the table is partitioned.
+	Partition *PartitionInfo `json:"partition"`
+	// RebuildInfo is not nil if the rows of the table are being rebuilt with other handles.
+	RebuildInfo *TableRebuildInfo `json:"rebuild_info,omitempty"`
+}
+
+// TableRebuildInfo provides meta data describing the table which is rebuilt from the rows of a table,
+// when the primary key is added or dropped and the handles of the rows are changed.
+type TableRebuildInfo struct {
+	// NewTableID is the ID of the table after it's rebuilt, the rebuilt rows and indices are written with this ID.
+	NewTableID int64 `json:"new_table_id"`
+	// PKIsHandle is the PKIsHandle of the table after it's rebuilt.
+	PKIsHandle bool `json:"pk_is_handle"`
+	// State is the state of the rebuilt table, the rows are written into it in write only state.
+	State SchemaState `json:"state"`
 }
 
 // Clone clones TableInfo.
@@ -129,9 +159,203 @@ func (t *TableInfo) Clone() *TableInfo {
 		nt.ForeignKeys[i] = t.ForeignKeys[i].Clone()
 	}
 
+	if t.View != nil {
+		nt.View = t.View.Clone()
+	}
+
+	if t.Partition != nil {
+		nt.Partition = t.Partition.Clone()
+	}
+
+	if t.RebuildInfo != nil {
+		rebuildInfo := *t.RebuildInfo
+		nt.RebuildInfo = &rebuildInfo
+	}
+
 	return &nt
 }
 
+// RebuildingTableInfo returns the table info of the table which is rebuilt from the rows of the table.
+// It returns nil if the table isn't being rebuilt.
+func (t *TableInfo) RebuildingTableInfo() *TableInfo {
+	if t.RebuildInfo == nil {
+		return nil
+	}
+	nt := t.Clone()
+	nt.ID = t.RebuildInfo.NewTableID
+	nt.PKIsHandle = t.RebuildInfo.PKIsHandle
+	nt.RebuildInfo = nil
+	if !nt.PKIsHandle {
+		return nt
+	}
+	// The primary key becomes the handle, so the primary index is not needed.
+	indices := make([]*IndexInfo, 0, len(nt.Indices))
+	for _, idx := range nt.Indices {
+		if !idx.Primary {
+			indices = append(indices, idx)
+			continue
+		}
+		for _, col := range idx.Columns {
+			nt.Columns[col.Offset].Flag |= mysql.PriKeyFlag | mysql.NotNullFlag
+		}
+	}
+	nt.Indices = indices
+	return nt
+}
+
+// IsView checks if the table is a view.
+func (t *TableInfo) IsView() bool {
+	return t.View != nil
+}
+
+// ViewAlgorithm is the ALGORITHM characteristic of a view.
+type ViewAlgorithm int
+
+// View algorithms.
+const (
+	AlgorithmUndefined ViewAlgorithm = iota
+	AlgorithmMerge
+	AlgorithmTemptable
+)
+
+func (v ViewAlgorithm) String() string {
+	switch v {
+	case AlgorithmMerge:
+		return "MERGE"
+	case AlgorithmTemptable:
+		return "TEMPTABLE"
+	default:
+		return "UNDEFINED"
+	}
+}
+
+// ViewSecurity is the SQL SECURITY characteristic of a view.
+type ViewSecurity int
+
+// View securities.
+const (
+	SecurityDefiner ViewSecurity = iota
+	SecurityInvoker
+)
+
+func (v ViewSecurity) String() string {
+	switch v {
+	case SecurityInvoker:
+		return "INVOKER"
+	default:
+		return "DEFINER"
+	}
+}
+
+// ViewCheckOption is the WITH CHECK OPTION clause of a view.
+type ViewCheckOption int
+
+// View check options.
+const (
+	CheckOptionNone ViewCheckOption = iota
+	CheckOptionCascaded
+	CheckOptionLocal
+)
+
+func (v ViewCheckOption) String() string {
+	switch v {
+	case CheckOptionCascaded:
+		return "CASCADED"
+	case CheckOptionLocal:
+		return "LOCAL"
+	default:
+		return "NONE"
+	}
+}
+
+// ViewInfo provides meta data describing a view.
+type ViewInfo struct {
+	Algorithm   ViewAlgorithm      `json:"view_algorithm"`
+	Definer     *auth.UserIdentity `json:"view_definer"`
+	Security    ViewSecurity       `json:"view_security"`
+	SelectStmt  string             `json:"view_select"`
+	CheckOption ViewCheckOption    `json:"view_checkoption"`
+	// Cols are the column names of the view, they are the same as the columns of the view table.
+	Cols []CIStr `json:"view_cols"`
+	// SelectCols are the names of the columns of the SELECT statement when the view is created,
+	// the view columns are matched to the columns of the SELECT statement by them.
+	SelectCols []CIStr `json:"view_select_cols"`
+}
+
+// Clone clones ViewInfo.
+func (v *ViewInfo) Clone() *ViewInfo {
+	nv := *v
+	if v.Definer != nil {
+		definer := *v.Definer
+		nv.Definer = &definer
+	}
+	nv.Cols = make([]CIStr, len(v.Cols))
+	copy(nv.Cols, v.Cols)
+	nv.SelectCols = make([]CIStr, len(v.SelectCols))
+	copy(nv.SelectCols, v.SelectCols)
+	return &nv
+}
+
+// GetPartitionInfo returns the partition information, it's nil if the table is not partitioned.
+func (t *TableInfo) GetPartitionInfo() *PartitionInfo {
+	return t.Partition
+}
+
+// PartitionType is the type for PartitionInfo.
+type PartitionType int
+
+// Partition types.
+const (
+	PartitionTypeRange PartitionType = iota + 1
+	PartitionTypeHash
+	// PartitionTypeKey is parsed but not supported, the table is created as a normal table.
+	PartitionTypeKey
+)
+
+func (p PartitionType) String() string {
+	switch p {
+	case PartitionTypeRange:
+		return "RANGE"
+	case PartitionTypeHash:
+		return "HASH"
+	case PartitionTypeKey:
+		return "KEY"
+	default:
+		return ""
+	}
+}
+
+// PartitionDefinition defines a single partition.
+type PartitionDefinition struct {
+	// ID is the physical table ID of the partition, its data is encoded with this ID.
+	ID   int64 `json:"id"`
+	Name CIStr `json:"name"`
+	// LessThan is the upper bound of a range partition, "MAXVALUE" means no bound.
+	LessThan []string `json:"less_than"`
+}
+
+// PartitionInfo provides meta data describing the partitions of a table.
+type PartitionInfo struct {
+	Type PartitionType `json:"type"`
+	// Expr is the partition expression, it's restored from the original SQL text.
+	Expr        string                `json:"expr"`
+	Definitions []PartitionDefinition `json:"definitions"`
+	// Num is the number of partitions.
+	Num uint64 `json:"num"`
+}
+
+// Clone clones PartitionInfo.
+func (pi *PartitionInfo) Clone() *PartitionInfo {
+	npi := *pi
+	npi.Definitions = make([]PartitionDefinition, len(pi.Definitions))
+	for i, def := range pi.Definitions {
+		npi.Definitions[i] = def
+		npi.Definitions[i].LessThan = make([]string, len(def.LessThan))
+		copy(npi.Definitions[i].LessThan, def.LessThan)
+	}
+	return &npi
+}
+
 // GetPkName will return the pk name if pk exists.
 func (t *TableInfo) GetPkName() CIStr {
 	if t.PKIsHandle {
@@ -205,6 +429,21 @@ const (
 	IndexTypeHash
 )
 
+// IndexVersion is the version of the key format of an index.
+type IndexVersion byte
+
+const (
+	// IndexVersionRaw is the version of the indexes created before the case-insensitive collations are supported,
+	// the strings are encoded as they are.
+	IndexVersionRaw IndexVersion = iota
+	// IndexVersionCollationKey encodes the strings in case-insensitive collations by their sort keys,
+	// so the keys are compared by the collations.
+	IndexVersionCollationKey
+
+	// CurrentIndexVersion is the version of the newly created indexes.
+	CurrentIndexVersion = IndexVersionCollationKey
+)
+
 // IndexInfo provides meta data describing a DB index.
 // It corresponds to the statement `CREATE INDEX Name ON Table (Column);`
 // See https://dev.mysql.com/doc/refman/5.7/en/create-index.html
@@ -218,6 +457,7 @@ type IndexInfo struct {
 	State   SchemaState    `json:"state"`
 	Comment string         `json:"comment"`    // Comment
 	Tp      IndexType      `json:"index_type"` // Index type: Btree or Hash
+	Version IndexVersion   `json:"version"`    // The key format version, the indexes of the old versions are kept as they are.
 }
 
 // Clone clones IndexInfo.
@@ -230,6 +470,18 @@ func (index *IndexInfo) Clone() *IndexInfo {
 	return &ni
 }
 
+// HasCollationKeys returns whether the strings in case-insensitive collations are encoded by their sort keys.
+func (index *IndexInfo) HasCollationKeys() bool {
+	return index.Version >= IndexVersionCollationKey
+}
+
+// ComparedByCollation returns whether the values of the column in the collation are compared by the collation
+// in the index keys, the old indexes keep the raw strings, which can't be used to match or sort the strings
+// in case-insensitive collations.
+func (index *IndexInfo) ComparedByCollation(collate string) bool {
+	return index.HasCollationKeys() || !charset.IsCICollation(collate)
+}
+
 // HasPrefixIndex returns whether any columns of this index uses prefix length.
 func (index *IndexInfo) HasPrefixIndex() bool {
 	for _, ic := range index.Columns {
@@ -242,14 +494,17 @@ func (index *IndexInfo) HasPrefixIndex() bool {
 
 // FKInfo provides meta data describing a foreign key constraint.
 type FKInfo struct {
-	ID       int64       `json:"id"`
-	Name     CIStr       `json:"fk_name"`
-	RefTable CIStr       `json:"ref_table"`
-	RefCols  []CIStr     `json:"ref_cols"`
-	Cols     []CIStr     `json:"cols"`
-	OnDelete int         `json:"on_delete"`
-	OnUpdate int         `json:"on_update"`
-	State    SchemaState `json:"state"`
+	ID   int64 `json:"id"`
+	Name CIStr `json:"fk_name"`
+	// RefSchema is the schema of the referenced table. It's empty for the foreign keys created before it's recorded,
+	// which refer to the tables in the same schema.
+	RefSchema CIStr       `json:"ref_schema"`
+	RefTable  CIStr       `json:"ref_table"`
+	RefCols   []CIStr     `json:"ref_cols"`
+	Cols      []CIStr     `json:"cols"`
+	OnDelete  int         `json:"on_delete"`
+	OnUpdate  int         `json:"on_update"`
+	State     SchemaState `json:"state"`
 }
 
 // Clone clones FKInfo.
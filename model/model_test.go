@@ -15,6 +15,7 @@ package model
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/mysql"
@@ -121,6 +122,20 @@ func (*testModelSuite) TestModelBasic(c *C) {
 	c.Assert(no, Equals, false)
 }
 
+func (*testModelSuite) TestTimeZoneLocation(c *C) {
+	// The fixed time zone set by "+08:00" is named UTC.
+	for _, loc := range []*time.Location{time.UTC, time.FixedZone("UTC", 8*3600), time.FixedZone("", -3600)} {
+		job := &Job{ReorgMeta: &DDLReorgMeta{SQLMode: mysql.ModeStrictTransTables, TimeZone: NewTimeZoneLocation(loc)}}
+		b, err := job.Encode(false)
+		c.Assert(err, IsNil)
+		newJob := &Job{}
+		c.Assert(newJob.Decode(b), IsNil)
+		c.Assert(newJob.ReorgMeta.SQLMode, Equals, mysql.ModeStrictTransTables)
+		now := time.Now()
+		c.Assert(now.In(newJob.ReorgMeta.TimeZone.GetLocation()).String(), Equals, now.In(loc).String())
+	}
+}
+
 func (*testModelSuite) TestJobCodec(c *C) {
 	type A struct {
 		Name string
@@ -231,6 +246,9 @@ func (testModelSuite) TestString(c *C) {
 		{ActionDropIndex, "drop index"},
 		{ActionAddColumn, "add column"},
 		{ActionDropColumn, "drop column"},
+		{ActionAddPrimaryKey, "add primary key"},
+		{ActionDropPrimaryKey, "drop primary key"},
+		{ActionMultiSchemaChange, "multi schema change"},
 	}
 
 	for _, v := range acts {
@@ -36,10 +36,28 @@ var ServerVersion = fmt.Sprintf("5.7.1-TiDB-%s", TiDBReleaseVersion)
 
 // Header information.
 const (
-	OKHeader          byte = 0x00
-	ErrHeader         byte = 0xff
-	EOFHeader         byte = 0xfe
-	LocalInFileHeader byte = 0xfb
+	OKHeader           byte = 0x00
+	ErrHeader          byte = 0xff
+	EOFHeader          byte = 0xfe
+	LocalInFileHeader  byte = 0xfb
+	AuthSwitchHeader   byte = 0xfe
+	AuthMoreDataHeader byte = 0x01
+)
+
+// Authentication plugins.
+const (
+	AuthNativePassword      = "mysql_native_password"
+	AuthCachingSha2Password = "caching_sha2_password"
+	AuthSha256Password      = "sha256_password"
+)
+
+// The status sent in AuthMoreData packets and the requests of clients during the authentication
+// of caching_sha2_password and sha256_password.
+const (
+	CachingSha2FastAuthSuccess    byte = 0x03
+	CachingSha2FullAuthentication byte = 0x04
+	CachingSha2RequestPublicKey   byte = 0x02
+	Sha256RequestPublicKey        byte = 0x01
 )
 
 // Server information.
@@ -58,6 +76,15 @@ const (
 	ServerPSOutParams              uint16 = 0x1000
 )
 
+// Cursor types for COM_STMT_EXECUTE.
+// See https://dev.mysql.com/doc/internals/en/com-stmt-execute.html
+const (
+	CursorTypeNoCursor   byte = 0x00
+	CursorTypeReadOnly   byte = 0x01
+	CursorTypeForUpdate  byte = 0x02
+	CursorTypeScrollable byte = 0x04
+)
+
 // Identifier length limitations.
 // See https://dev.mysql.com/doc/refman/5.7/en/identifiers.html
 const (
@@ -174,6 +201,10 @@ const (
 	GlobalStatusTable = "GLOBAL_STATUS"
 	// TiDBTable is the table contains tidb info.
 	TiDBTable = "tidb"
+	// RoleEdgeTable is the table contains the roles granted to users and roles.
+	RoleEdgeTable = "role_edges"
+	// DefaultRoleTable is the table contains the default roles of users.
+	DefaultRoleTable = "default_roles"
 )
 
 // PrivilegeType  privilege
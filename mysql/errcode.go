@@ -882,6 +882,7 @@ const (
 	ErrMustChangePasswordLogin                                      = 1862
 	ErrRowInWrongPartition                                          = 1863
 	ErrErrorLast                                                    = 1863
+	ErrFkDepthExceeded                                              = 3008
 	ErrBadGeneratedColumn                                           = 3105
 	ErrUnsupportedOnGeneratedColumn                                 = 3106
 	ErrGeneratedColumnNonPrior                                      = 3107
@@ -890,6 +891,21 @@ const (
 	ErrInvalidJSONPath                                              = 3143
 	ErrInvalidJSONData                                              = 3146
 	ErrJSONUsedAsKey                                                = 3152
+	ErrUnknownAuthID                                                = 3523
+	ErrRoleNotGranted                                               = 3530
+	ErrCTERecursiveRequiresUnion                                    = 3573
+	ErrCTERecursiveRequiresNonRecursiveFirst                        = 3574
+	ErrCTERecursiveForbidsAggregation                               = 3575
+	ErrWindowFrameStartIllegal                                      = 3584
+	ErrWindowFrameEndIllegal                                        = 3585
+	ErrWindowFrameIllegal                                           = 3586
+	ErrWindowRangeFrameOrderType                                    = 3587
+	ErrWindowInvalidWindowFuncUse                                   = 3593
+	ErrCTEMaxRecursionDepth                                         = 3636
+	ErrFkCannotDropParent                                           = 3730
+
+	// TiDB errors.
+	ErrMemExceedThreshold = 8001
 
 	// TiKV/PD errors.
 	ErrPDServerTimeout    = 9001
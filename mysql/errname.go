@@ -879,6 +879,7 @@ var MySQLErrName = map[uint16]string{
 	ErrAlterOperationNotSupportedReasonNotNull:               "cannot silently convert NULL values, as required in this SQLMODE",
 	ErrMustChangePasswordLogin:                               "Your password has expired. To log in you must change it using a client that supports expired passwords.",
 	ErrRowInWrongPartition:                                   "Found a row in wrong partition %s",
+	ErrFkDepthExceeded:                                       "Foreign key cascade delete/update exceeds max depth of %d.",
 	ErrBadGeneratedColumn:                                    "The value specified for generated column '%s' in table '%s' is not allowed.",
 	ErrUnsupportedOnGeneratedColumn:                          "'%s' is not supported for generated columns.",
 	ErrGeneratedColumnNonPrior:                               "Generated column can refer only to generated columns defined prior to it.",
@@ -887,6 +888,21 @@ var MySQLErrName = map[uint16]string{
 	ErrInvalidJSONPath:                                       "Invalid JSON path expression %s.",
 	ErrInvalidJSONData:                                       "Invalid data type for JSON data",
 	ErrJSONUsedAsKey:                                         "JSON column '%-.192s' cannot be used in key specification.",
+	ErrUnknownAuthID:                                         "Unknown authorization ID `%s`@`%s`",
+	ErrRoleNotGranted:                                        "`%s`@`%s` is not granted to `%s`@`%s`",
+	ErrCTERecursiveRequiresUnion:                             "Recursive Common Table Expression '%s' should contain a UNION",
+	ErrCTERecursiveRequiresNonRecursiveFirst:                 "Recursive Common Table Expression '%s' should have one or more non-recursive query blocks followed by one or more recursive ones",
+	ErrCTERecursiveForbidsAggregation:                        "Recursive Common Table Expression '%s' can contain neither aggregation nor window functions in recursive query block",
+	ErrWindowFrameStartIllegal:                               "Window '%s': frame start cannot be UNBOUNDED FOLLOWING.",
+	ErrWindowFrameEndIllegal:                                 "Window '%s': frame end cannot be UNBOUNDED PRECEDING.",
+	ErrWindowFrameIllegal:                                    "Window '%s': frame start or end is negative, NULL or of non-integral type",
+	ErrWindowRangeFrameOrderType:                             "Window '%s' with RANGE N PRECEDING/FOLLOWING frame requires exactly one ORDER BY expression, of numeric or temporal type",
+	ErrWindowInvalidWindowFuncUse:                            "You cannot use the window function '%s' in this context.'",
+	ErrCTEMaxRecursionDepth:                                  "Recursive query aborted after %d iterations. Try increasing @@cte_max_recursion_depth to a larger value.",
+	ErrFkCannotDropParent:                                    "Cannot drop table '%s' referenced by a foreign key constraint '%s' on table '%s'.",
+
+	// TiDB errors.
+	ErrMemExceedThreshold: "Out Of Memory Quota! %s holds %d bytes memory, exceeds the quota %d bytes.",
 
 	// TiKV/PD errors.
 	ErrPDServerTimeout:    "PD server timeout",
@@ -968,6 +968,8 @@ func (s *testSessionSuite) TestIgnoreForeignKey(c *C) {
 		CONSTRAINT FK_7rod8a71yep5vxasb0ms3osbg FOREIGN KEY (user_id) REFERENCES waimaiqa.user (id),
 		INDEX FK_7rod8a71yep5vxasb0ms3osbg (user_id) comment ''
 		) ENGINE=InnoDB AUTO_INCREMENT=30 DEFAULT CHARACTER SET utf8 COLLATE utf8_general_ci ROW_FORMAT=COMPACT COMMENT='' CHECKSUM=0 DELAY_KEY_WRITE=0;`
+	// The referenced table doesn't exist, like in a dump loaded with the checks off.
+	tk.MustExec("set foreign_key_checks = 0")
 	tk.MustExec(sqlText)
 }
 
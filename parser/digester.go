@@ -0,0 +1,110 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"strings"
+)
+
+// Normalize generates the normalized statement of a SQL, which is used to group the statements
+// of the same shape: the literals are replaced by '?', the keywords are converted to upper case,
+// the identifiers are quoted by '`', the comments and the redundant spaces are removed,
+// and the lists of literals like `IN (1, 2, 3)` or `VALUES (1, 2), (3, 4)` are collapsed to `(...)`.
+// For example: "select * from t where a = 1 and b in (2, 3)" is normalized to
+// "SELECT * FROM `t` WHERE `a` = ? AND `b` IN (...)".
+func Normalize(sql string) string {
+	s := NewScanner(sql)
+	var v yySymType
+	tokens := make([]string, 0, 16)
+	for {
+		tok := s.Lex(&v)
+		if tok == 0 {
+			break
+		}
+		switch tok {
+		case intLit, floatLit, decLit, hexLit, bitLit, stringLit, paramMarker:
+			tokens = append(tokens, "?")
+		case underscoreCS:
+			// The character set introducer of a string literal, the literal itself is replaced by '?'.
+		case identifier:
+			tokens = append(tokens, "`"+strings.Replace(v.ident, "`", "``", -1)+"`")
+		case ')':
+			tokens = appendRightParen(tokens)
+		default:
+			lit := v.ident
+			if _, ok := tokenMap[strings.ToUpper(lit)]; ok {
+				lit = strings.ToUpper(lit)
+			}
+			tokens = append(tokens, lit)
+		}
+	}
+	// Remove the statement terminators.
+	for len(tokens) > 0 && tokens[len(tokens)-1] == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return joinTokens(tokens)
+}
+
+// appendRightParen appends ')' to the tokens, and collapses the list of literals like `(?, ?)` to `(...)`,
+// the following lists like `(...), (...)` are also collapsed to a single `(...)`.
+func appendRightParen(tokens []string) []string {
+	i := len(tokens) - 1
+	for ; i >= 0; i-- {
+		expected := "?"
+		if (len(tokens)-1-i)%2 == 1 {
+			expected = ","
+		}
+		if tokens[i] != expected {
+			break
+		}
+	}
+	if i == len(tokens)-1 || i < 0 || tokens[i] != "(" || tokens[i+1] != "?" {
+		return append(tokens, ")")
+	}
+	tokens = tokens[:i]
+	if n := len(tokens); n >= 2 && tokens[n-1] == "," && tokens[n-2] == "(...)" {
+		return tokens[:n-1]
+	}
+	return append(tokens, "(...)")
+}
+
+func joinTokens(tokens []string) string {
+	var buf bytes.Buffer
+	for i, token := range tokens {
+		if i > 0 {
+			prev := tokens[i-1]
+			noSpace := prev == "(" || prev == "." || token == "," || token == ")" || token == "."
+			if !noSpace {
+				buf.WriteByte(' ')
+			}
+		}
+		buf.WriteString(token)
+	}
+	return buf.String()
+}
+
+// DigestHash generates the digest of a normalized statement.
+func DigestHash(normalized string) string {
+	hash := md5.Sum([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
+
+// NormalizeDigest normalizes a SQL and generates the digest of it.
+func NormalizeDigest(sql string) (normalized, digest string) {
+	normalized = Normalize(sql)
+	return normalized, DigestHash(normalized)
+}
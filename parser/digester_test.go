@@ -0,0 +1,57 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testDigesterSuite{})
+
+type testDigesterSuite struct {
+}
+
+func (s *testDigesterSuite) TestNormalize(c *C) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"select 1", "SELECT ?"},
+		{"SELECT * from t where a = 1 and b = 'x' -- comment", "SELECT * FROM `t` WHERE `a` = ? AND `b` = ?"},
+		{"select  *  from `T` where c>=1.5e3 /* comment */;", "SELECT * FROM `T` WHERE `c` >= ?"},
+		{"select t.a, count(*) from t group by t.a", "SELECT `t`.`a`, COUNT (*) FROM `t` GROUP BY `t`.`a`"},
+		{"select * from t where a in (1, 2, 3)", "SELECT * FROM `t` WHERE `a` IN (...)"},
+		{"select * from t where a in (?)", "SELECT * FROM `t` WHERE `a` IN (...)"},
+		{"select * from t where a in (b, 1)", "SELECT * FROM `t` WHERE `a` IN (`b`, ?)"},
+		{"insert into t values (1, 'a'), (2, 'b'), (3, 'c')", "INSERT INTO `t` VALUES (...)"},
+		{"insert into t (a, b) values (0x01, _utf8'x')", "INSERT INTO `t` (`a`, `b`) VALUES (...)"},
+		{"update t set a = b'1' where id = 1.0", "UPDATE `t` SET `a` = ? WHERE `id` = ?"},
+		{"select @a, @@global.autocommit, null", "SELECT @a, @@global.autocommit, NULL"},
+		{"select * from t where a = `b``c`", "SELECT * FROM `t` WHERE `a` = `b``c`"},
+	}
+	for _, t := range tests {
+		c.Assert(Normalize(t.input), Equals, t.expect, Commentf("%s", t.input))
+	}
+}
+
+func (s *testDigesterSuite) TestDigest(c *C) {
+	normalized1, digest1 := NormalizeDigest("select * from t where a = 1 and b in (1, 2)")
+	normalized2, digest2 := NormalizeDigest("SELECT *\nFROM t WHERE a = 'x' AND b IN (3)")
+	c.Assert(normalized1, Equals, normalized2)
+	c.Assert(digest1, Equals, digest2)
+	c.Assert(digest1, HasLen, 32)
+
+	_, digest3 := NormalizeDigest("select * from t where a = 1 or b in (1, 2)")
+	c.Assert(digest1, Not(Equals), digest3)
+}
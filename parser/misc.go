@@ -140,6 +140,7 @@ var tokenMap = map[string]int{
 	"ADDDATE":             addDate,
 	"ADMIN":               admin,
 	"AFTER":               after,
+	"ALGORITHM":           algorithm,
 	"ALL":                 all,
 	"ALTER":               alter,
 	"ALWAYS":              always,
@@ -168,6 +169,7 @@ var tokenMap = map[string]int{
 	"BYTE":                byteType,
 	"CANCEL":              cancel,
 	"CASCADE":             cascade,
+	"CASCADED":            cascaded,
 	"CASE":                caseKwd,
 	"CAST":                cast,
 	"CHANGE":              change,
@@ -194,6 +196,7 @@ var tokenMap = map[string]int{
 	"COUNT":               count,
 	"CREATE":              create,
 	"CROSS":               cross,
+	"CURRENT":             current,
 	"CURRENT_DATE":        currentDate,
 	"CURRENT_TIME":        currentTime,
 	"CURRENT_TIMESTAMP":   currentTs,
@@ -216,9 +219,11 @@ var tokenMap = map[string]int{
 	"DEC":                 decimalType,
 	"DECIMAL":             decimalType,
 	"DEFAULT":             defaultKwd,
+	"DEFINER":             definer,
 	"DELAY_KEY_WRITE":     delayKeyWrite,
 	"DELAYED":             delayed,
 	"DELETE":              deleteKwd,
+	"DENSE_RANK":          denseRank,
 	"DESC":                desc,
 	"DESCRIBE":            describe,
 	"DISABLE":             disable,
@@ -241,6 +246,7 @@ var tokenMap = map[string]int{
 	"ESCAPE":              escape,
 	"ESCAPED":             escaped,
 	"EVENTS":              events,
+	"EXCEPT":              except,
 	"EXCLUSIVE":           exclusive,
 	"EXECUTE":             execute,
 	"EXISTS":              exists,
@@ -252,6 +258,7 @@ var tokenMap = map[string]int{
 	"FIXED":               fixed,
 	"FLOAT":               floatType,
 	"FLUSH":               flush,
+	"FOLLOWING":           following,
 	"FOR":                 forKwd,
 	"FORCE":               force,
 	"FOREIGN":             foreign,
@@ -292,6 +299,7 @@ var tokenMap = map[string]int{
 	"INTEGER":             integerType,
 	"INTERVAL":            interval,
 	"INTO":                into,
+	"INVOKER":             invoker,
 	"IS":                  is,
 	"ISOLATION":           isolation,
 	"JOBS":                jobs,
@@ -301,6 +309,8 @@ var tokenMap = map[string]int{
 	"KEY_BLOCK_SIZE":      keyBlockSize,
 	"KEYS":                keys,
 	"KILL":                kill,
+	"LAG":                 lag,
+	"LEAD":                lead,
 	"LEADING":             leading,
 	"LEFT":                left,
 	"LESS":                less,
@@ -322,6 +332,7 @@ var tokenMap = map[string]int{
 	"MEDIUMBLOB":          mediumblobType,
 	"MEDIUMINT":           mediumIntType,
 	"MEDIUMTEXT":          mediumtextType,
+	"MERGE":               merge,
 	"MICROSECOND":         microsecond,
 	"MIN":                 min,
 	"MIN_ROWS":            minRows,
@@ -350,11 +361,13 @@ var tokenMap = map[string]int{
 	"OR":                  or,
 	"ORDER":               order,
 	"OUTER":               outer,
+	"OVER":                over,
 	"PARTITION":           partition,
 	"PARTITIONS":          partitions,
 	"PASSWORD":            password,
 	"PLUGINS":             plugins,
 	"POSITION":            position,
+	"PRECEDING":           preceding,
 	"PRECISION":           precisionType,
 	"PREPARE":             prepare,
 	"PRIMARY":             primary,
@@ -367,8 +380,10 @@ var tokenMap = map[string]int{
 	"QUERY":               query,
 	"QUICK":               quick,
 	"RANGE":               rangeKwd,
+	"RANK":                rank,
 	"READ":                read,
 	"REAL":                realType,
+	"RECURSIVE":           recursive,
 	"REDUNDANT":           redundant,
 	"REFERENCES":          references,
 	"REGEXP":              regexpKwd,
@@ -381,14 +396,18 @@ var tokenMap = map[string]int{
 	"REVOKE":              revoke,
 	"RIGHT":               right,
 	"RLIKE":               rlike,
+	"ROLE":                role,
 	"ROLLBACK":            rollback,
 	"ROW":                 row,
+	"ROWS":                rows,
 	"ROW_COUNT":           rowCount,
 	"ROW_FORMAT":          rowFormat,
+	"ROW_NUMBER":          rowNumber,
 	"SCHEMA":              database,
 	"SCHEMAS":             databases,
 	"SECOND":              second,
 	"SECOND_MICROSECOND":  secondMicrosecond,
+	"SECURITY":            security,
 	"SELECT":              selectKwd,
 	"SERIALIZABLE":        serializable,
 	"SESSION":             session,
@@ -400,6 +419,7 @@ var tokenMap = map[string]int{
 	"SMALLINT":            smallIntType,
 	"SNAPSHOT":            snapshot,
 	"SOME":                some,
+	"SQL":                 sql,
 	"SQL_CACHE":           sqlCache,
 	"SQL_CALC_FOUND_ROWS": sqlCalcFoundRows,
 	"SQL_NO_CACHE":        sqlNoCache,
@@ -419,6 +439,7 @@ var tokenMap = map[string]int{
 	"SUPER":               super,
 	"TABLE":               tableKwd,
 	"TABLES":              tables,
+	"TEMPTABLE":           temptable,
 	"TERMINATED":          terminated,
 	"TEXT":                textType,
 	"THAN":                than,
@@ -441,7 +462,9 @@ var tokenMap = map[string]int{
 	"TRIM":                trim,
 	"TRUE":                trueKwd,
 	"TRUNCATE":            truncate,
+	"UNBOUNDED":           unbounded,
 	"UNCOMMITTED":         uncommitted,
+	"UNDEFINED":           undefined,
 	"UNION":               union,
 	"UNIQUE":              unique,
 	"UNKNOWN":             unknown,
@@ -177,6 +177,7 @@ import (
 	or			"OR"
 	order			"ORDER"
 	outer			"OUTER"
+	over			"OVER"
 	partition		"PARTITION"
 	precisionType		"PRECISION"
 	primary			"PRIMARY"
@@ -184,6 +185,7 @@ import (
 	rangeKwd		"RANGE"
 	read			"READ"
 	realType		"REAL"
+	recursive		"RECURSIVE"
 	references		"REFERENCES"
 	regexpKwd		"REGEXP"
 	rename         		"RENAME"
@@ -198,6 +200,7 @@ import (
 	set			"SET"
 	show			"SHOW"
 	smallIntType		"SMALLINT"
+	sql			"SQL"
 	sqlCalcFoundRows	"SQL_CALC_FOUND_ROWS"
 	starting		"STARTING"
 	tableKwd		"TABLE"
@@ -237,6 +240,7 @@ import (
 	/* The following tokens belong to UnReservedKeyword. */
 	action		"ACTION"
 	after		"AFTER"
+	algorithm	"ALGORITHM"
 	always		"ALWAYS"
 	any 		"ANY"
 	ascii		"ASCII"
@@ -250,6 +254,7 @@ import (
 	boolType	"BOOL"
 	btree		"BTREE"
 	byteType	"BYTE"
+	cascaded	"CASCADED"
 	charsetKwd	"CHARSET"
 	checksum	"CHECKSUM"
 	coalesce	"COALESCE"
@@ -258,6 +263,7 @@ import (
 	comment 	"COMMENT"
 	commit		"COMMIT"
 	committed	"COMMITTED"
+	current		"CURRENT"
 	compact		"COMPACT"
 	compressed	"COMPRESSED"
 	compression	"COMPRESSION"
@@ -268,6 +274,7 @@ import (
 	dateType	"DATE"
 	datetimeType	"DATETIME"
 	deallocate	"DEALLOCATE"
+	definer		"DEFINER"
 	delayKeyWrite	"DELAY_KEY_WRITE"
 	disable		"DISABLE"
 	do		"DO"
@@ -280,11 +287,13 @@ import (
 	enum 		"ENUM"
 	events		"EVENTS"
 	escape 		"ESCAPE"
+	except		"EXCEPT"
 	exclusive       "EXCLUSIVE"
 	execute		"EXECUTE"
 	fields		"FIELDS"
 	first		"FIRST"
 	fixed		"FIXED"
+	following	"FOLLOWING"
 	flush		"FLUSH"
 	format		"FORMAT"
 	full		"FULL"
@@ -293,6 +302,7 @@ import (
 	hash		"HASH"
 	hour		"HOUR"
 	identified	"IDENTIFIED"
+	invoker		"INVOKER"
 	isolation	"ISOLATION"
 	indexes		"INDEXES"
 	jsonType	"JSON"
@@ -301,6 +311,7 @@ import (
 	less		"LESS"
 	level		"LEVEL"
 	microsecond	"MICROSECOND"
+	merge		"MERGE"
 	minute		"MINUTE"
 	mode		"MODE"
 	modify		"MODIFY"
@@ -316,6 +327,7 @@ import (
 	password	"PASSWORD"
 	partitions	"PARTITIONS"
 	plugins		"PLUGINS"
+	preceding	"PRECEDING"
 	prepare		"PREPARE"
 	privileges	"PRIVILEGES"
 	process		"PROCESS"
@@ -327,11 +339,14 @@ import (
 	redundant	"REDUNDANT"
 	repeatable	"REPEATABLE"
 	reverse		"REVERSE"
+	role		"ROLE"
 	rollback	"ROLLBACK"
 	row 		"ROW"
+	rows		"ROWS"
 	rowCount	"ROW_COUNT"
 	rowFormat	"ROW_FORMAT"
 	second		"SECOND"
+	security	"SECURITY"
 	serializable	"SERIALIZABLE"
 	session		"SESSION"
 	share		"SHARE"
@@ -347,6 +362,7 @@ import (
 	some 		"SOME"
 	global		"GLOBAL"
 	tables		"TABLES"
+	temptable	"TEMPTABLE"
 	textType	"TEXT"
 	than		"THAN"
 	timeType	"TIME"
@@ -354,7 +370,9 @@ import (
 	transaction	"TRANSACTION"
 	triggers	"TRIGGERS"
 	truncate	"TRUNCATE"
+	unbounded	"UNBOUNDED"
 	uncommitted	"UNCOMMITTED"
+	undefined	"UNDEFINED"
 	unknown 	"UNKNOWN"
 	user		"USER"
 	value		"VALUE"
@@ -372,13 +390,18 @@ import (
 	curTime		"CURTIME"
 	dateAdd		"DATE_ADD"
 	dateSub		"DATE_SUB"
+	denseRank	"DENSE_RANK"
 	extract		"EXTRACT"
 	getFormat	"GET_FORMAT"
 	groupConcat	"GROUP_CONCAT"
+	lag		"LAG"
+	lead		"LEAD"
 	min		"MIN"
 	max		"MAX"
 	now		"NOW"
 	position	"POSITION"
+	rank		"RANK"
+	rowNumber	"ROW_NUMBER"
 	subDate		"SUBDATE"
 	sum		"SUM"
 	substring	"SUBSTRING"
@@ -431,6 +454,7 @@ import (
 	SimpleExpr			"simple expression"
 	SimpleIdent			"Simple Identifier expression"
 	SumExpr				"aggregate functions"
+	WindowFuncCall			"window function call"
 	FunctionCallGeneric		"Function call with Identifier"
 	FunctionCallKeyword		"Function call with keyword as function name"
 	FunctionCallNonKeyword		"Function call with nonkeyword as function name"
@@ -457,6 +481,7 @@ import (
 	CreateUserStmt			"CREATE User statement"
 	CreateDatabaseStmt		"Create Database Statement"
 	CreateIndexStmt			"CREATE INDEX statement"
+	CreateViewStmt			"CREATE VIEW statement"
 	DoStmt				"Do statement"
 	DropDatabaseStmt		"DROP DATABASE statement"
 	DropIndexStmt			"DROP INDEX statement"
@@ -471,6 +496,7 @@ import (
 	ExplainStmt			"EXPLAIN statement"
 	FlushStmt			"Flush statement"
 	GrantStmt			"Grant statement"
+	GrantRoleStmt			"Grant role statement"
 	InsertIntoStmt			"INSERT INTO statement"
 	KillStmt			"Kill statement"
 	LoadDataStmt			"Load data statement"
@@ -480,6 +506,7 @@ import (
 	RenameTableStmt         	"rename table statement"
 	ReplaceIntoStmt			"REPLACE INTO statement"
 	RevokeStmt			"Revoke statement"
+	RevokeRoleStmt			"Revoke role statement"
 	RollbackStmt			"ROLLBACK statement"
 	SetStmt				"Set variable statement"
 	ShowStmt			"Show engines/databases/tables/columns/warnings/status statement"
@@ -490,6 +517,8 @@ import (
 	UpdateStmt			"UPDATE statement"
 	UnionStmt			"Union select state ment"
 	UseStmt				"USE statement"
+	ViewSelectStmt			"SELECT statement of a VIEW"
+	WithSelectStmt			"SELECT or UNION statement with WITH clause"
 
 %type   <item>
 	AlterTableSpec			"Alter table specification"
@@ -586,6 +615,15 @@ import (
 	OrderBy				"ORDER BY clause"
 	ByItem				"BY item"
 	OrderByOptional			"Optional ORDER BY clause optional"
+	WindowSpec			"Window specification"
+	WindowingClause			"Window clause following a window function"
+	PartitionByClauseOpt		"Optional PARTITION BY clause of a window"
+	FrameClauseOpt			"Optional frame clause of a window"
+	FrameUnits			"ROWS or RANGE frame units"
+	FrameExtent			"Frame extent"
+	FrameStart			"Frame start bound"
+	FrameBound			"Frame bound"
+	LeadLagInfoOpt			"Optional offset and default value of LEAD/LAG"
 	ByList				"BY list"
 	QuickOptional			"QUICK or empty"
 	PartitionDefinition		"Partition definition"
@@ -595,12 +633,12 @@ import (
 	PartitionNumOpt			"PARTITION NUM option"
 	PartDefValuesOpt		"VALUES {LESS THAN {(expr | value_list) | MAXVALUE} | IN {value_list}"
 	PartDefStorageOpt		"ENGINE = xxx or empty"
+	PartitionNameList		"Partition name list"
 	PasswordOpt			"Password option"
 	ColumnPosition			"Column position [First|After ColumnName]"
 	PrepareSQL			"Prepare statement sql string"
 	Priority			"insert statement priority"
 	PrivElem			"Privilege element"
-	PrivElemList			"Privilege element list"
 	PrivLevel			"Privilege scope"
 	PrivType			"Privilege type"
 	ReferDef			"Reference definition"
@@ -653,6 +691,10 @@ import (
 	UnionSelect		"Union (select) item"
 	Username		"Username"
 	UsernameList		"UsernameList"
+	Rolename		"Rolename"
+	RolenameList		"RolenameList"
+	RoleOrPrivElem		"Role or privilege element"
+	RoleOrPrivElemList	"Role or privilege element list"
 	UserSpec		"Username and auth option"
 	UserSpecList		"Username and auth option list"
 	UserVariableList	"User defined variable name list"
@@ -666,6 +708,17 @@ import (
 	WhenClause		"When clause"
 	WhenClauseList		"When clause list"
 	WithReadLockOpt		"With Read Lock opt"
+	WithClause		"With clause"
+	WithList		"With list"
+	CommonTableExpr		"Common table expression"
+	CTEColumnListOpt	"Common table expression column list opt"
+	OrReplace		"OR REPLACE or empty"
+	RestrictOrCascadeOpt	"RESTRICT or CASCADE or empty"
+	ViewAlgorithm		"VIEW algorithm"
+	ViewDefiner		"VIEW definer"
+	ViewSQLSecurity		"VIEW SQL SECURITY"
+	ViewFieldList		"VIEW field list"
+	ViewCheckOption		"VIEW WITH CHECK OPTION"
 	WithGrantOptionOpt	"With Grant Option opt"
 	ElseOpt			"Optional else clause"
 	Type			"Types"
@@ -950,6 +1003,27 @@ AlterTableSpec:
 			LockType:   $1.(ast.LockType),
 		}
 	}
+|	"ADD" "PARTITION" '(' PartitionDefinitionList ')'
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:			ast.AlterTableAddPartitions,
+			PartDefinitions:	$4.([]*ast.PartitionDefinition),
+		}
+	}
+|	"DROP" "PARTITION" PartitionNameList %prec lowerThanComma
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:		ast.AlterTableDropPartition,
+			PartitionNames:	$3.([]model.CIStr),
+		}
+	}
+|	"TRUNCATE" "PARTITION" PartitionNameList %prec lowerThanComma
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:		ast.AlterTableTruncatePartition,
+			PartitionNames:	$3.([]model.CIStr),
+		}
+	}
 
 LockClause:
 	"LOCK" eq "NONE"
@@ -1609,13 +1683,17 @@ CreateTableStmt:
 			yylex.Errorf("Column Definition List can't be empty.")
 			return 1
 		}
-		$$ = &ast.CreateTableStmt{
+		stmt := &ast.CreateTableStmt{
 			Table:          $4.(*ast.TableName),
 			IfNotExists:    $3.(bool),
 			Cols:           columnDefs,
 			Constraints:    constraints,
 			Options:        $8.([]*ast.TableOption),
 		}
+		if $9 != nil {
+			stmt.Partition = $9.(*ast.PartitionOptions)
+		}
+		$$ = stmt
 	}
 |	"CREATE" "TABLE" IfNotExists TableName "LIKE" TableName
 	{
@@ -1626,51 +1704,253 @@ CreateTableStmt:
 		}
 	}
 
+/*******************************************************************
+ *
+ *  Create View Statement
+ *
+ *  Example:
+ *      CREATE OR REPLACE ALGORITHM = MERGE DEFINER = 'root'@'localhost' SQL SECURITY DEFINER VIEW view_name (col1, col2)
+ *          AS SELECT col1, col2 FROM t WITH LOCAL CHECK OPTION
+ *******************************************************************/
+CreateViewStmt:
+	"CREATE" OrReplace ViewAlgorithm ViewDefiner ViewSQLSecurity "VIEW" TableName ViewFieldList "AS" ViewSelectStmt ViewCheckOption
+	{
+		startOffset := parser.startOffset(&yyS[yypt-1])
+		// The offset of an empty ViewCheckOption is undefined, use the offset of the lookahead token instead.
+		endOffset := parser.endOffset(&parser.yylval)
+		if $11.(model.ViewCheckOption) != model.CheckOptionNone {
+			endOffset = parser.endOffset(&yyS[yypt])
+		}
+		if st, ok := $10.(*ast.SelectStmt); ok {
+			parser.setLastSelectFieldText(st, endOffset)
+		}
+		selStmt := $10.(ast.ResultSetNode)
+		selStmt.SetText(strings.TrimSpace(parser.src[startOffset:endOffset]))
+		var definer *auth.UserIdentity
+		if $4 != nil {
+			definer = $4.(*auth.UserIdentity)
+		}
+		$$ = &ast.CreateViewStmt{
+			OrReplace:	$2.(bool),
+			ViewName:	$7.(*ast.TableName),
+			Cols:		$8.([]model.CIStr),
+			Select:		selStmt,
+			SelectOffset:	startOffset,
+			Algorithm:	$3.(model.ViewAlgorithm),
+			Definer:	definer,
+			Security:	$5.(model.ViewSecurity),
+			CheckOption:	$11.(model.ViewCheckOption),
+		}
+	}
+
+OrReplace:
+	{
+		$$ = false
+	}
+|	"OR" "REPLACE"
+	{
+		$$ = true
+	}
+
+ViewAlgorithm:
+	{
+		$$ = model.AlgorithmUndefined
+	}
+|	"ALGORITHM" eq "UNDEFINED"
+	{
+		$$ = model.AlgorithmUndefined
+	}
+|	"ALGORITHM" eq "MERGE"
+	{
+		$$ = model.AlgorithmMerge
+	}
+|	"ALGORITHM" eq "TEMPTABLE"
+	{
+		$$ = model.AlgorithmTemptable
+	}
+
+ViewDefiner:
+	{
+		$$ = nil
+	}
+|	"DEFINER" eq "CURRENT_USER" OptionalBraces
+	{
+		$$ = nil
+	}
+|	"DEFINER" eq Username
+	{
+		$$ = $3
+	}
+
+ViewSQLSecurity:
+	{
+		$$ = model.SecurityDefiner
+	}
+|	"SQL" "SECURITY" "DEFINER"
+	{
+		$$ = model.SecurityDefiner
+	}
+|	"SQL" "SECURITY" "INVOKER"
+	{
+		$$ = model.SecurityInvoker
+	}
+
+ViewFieldList:
+	{
+		$$ = []model.CIStr{}
+	}
+|	'(' ColumnNameList ')'
+	{
+		cols := $2.([]*ast.ColumnName)
+		names := make([]model.CIStr, 0, len(cols))
+		for _, col := range cols {
+			names = append(names, col.Name)
+		}
+		$$ = names
+	}
+
+ViewSelectStmt:
+	SelectStmt
+|	UnionStmt
+|	WithSelectStmt
+
+ViewCheckOption:
+	{
+		$$ = model.CheckOptionNone
+	}
+|	"WITH" "CASCADED" "CHECK" "OPTION"
+	{
+		$$ = model.CheckOptionCascaded
+	}
+|	"WITH" "LOCAL" "CHECK" "OPTION"
+	{
+		$$ = model.CheckOptionLocal
+	}
+|	"WITH" "CHECK" "OPTION"
+	{
+		$$ = model.CheckOptionCascaded
+	}
+
 DefaultKwdOpt:
 	{}
 |	"DEFAULT"
 
 PartitionOpt:
-	{}
+	{
+		$$ = nil
+	}
 |	"PARTITION" "BY" "KEY" '(' ColumnNameList ')' PartitionNumOpt PartitionDefinitionListOpt
-	{}
+	{
+		// KEY partitioning is not supported yet, DDL creates the table as a normal table with a warning.
+		$$ = &ast.PartitionOptions{
+			Tp:		model.PartitionTypeKey,
+			ColumnNames:	$5.([]*ast.ColumnName),
+			Num:		$7.(uint64),
+			Definitions:	$8.([]*ast.PartitionDefinition),
+		}
+	}
 |	"PARTITION" "BY" "HASH" '(' Expression ')' PartitionNumOpt PartitionDefinitionListOpt
-	{}
+	{
+		expr := $5.(ast.ExprNode)
+		startOffset := parser.startOffset(&yyS[yypt-3])
+		endOffset := parser.endOffset(&yyS[yypt-2])
+		expr.SetText(parser.src[startOffset:endOffset])
+		$$ = &ast.PartitionOptions{
+			Tp:		model.PartitionTypeHash,
+			Expr:		expr,
+			Num:		$7.(uint64),
+			Definitions:	$8.([]*ast.PartitionDefinition),
+		}
+	}
 |	"PARTITION" "BY" "RANGE" '(' Expression ')' PartitionNumOpt  PartitionDefinitionListOpt
-	{}
+	{
+		expr := $5.(ast.ExprNode)
+		startOffset := parser.startOffset(&yyS[yypt-3])
+		endOffset := parser.endOffset(&yyS[yypt-2])
+		expr.SetText(parser.src[startOffset:endOffset])
+		$$ = &ast.PartitionOptions{
+			Tp:		model.PartitionTypeRange,
+			Expr:		expr,
+			Num:		$7.(uint64),
+			Definitions:	$8.([]*ast.PartitionDefinition),
+		}
+	}
 
 PartitionNumOpt:
-	{}
+	{
+		$$ = uint64(0)
+	}
 |	"PARTITIONS" NUM
-	{}
+	{
+		$$ = getUint64FromNUM($2)
+	}
 
 PartitionDefinitionListOpt:
-	{}
+	{
+		$$ = []*ast.PartitionDefinition(nil)
+	}
 |	'(' PartitionDefinitionList ')'
-	{}
+	{
+		$$ = $2.([]*ast.PartitionDefinition)
+	}
 
 PartitionDefinitionList:
 	PartitionDefinition
-	{}
+	{
+		$$ = []*ast.PartitionDefinition{$1.(*ast.PartitionDefinition)}
+	}
 |	PartitionDefinitionList ',' PartitionDefinition
-	{}
+	{
+		$$ = append($1.([]*ast.PartitionDefinition), $3.(*ast.PartitionDefinition))
+	}
 
 PartitionDefinition:
 	"PARTITION" Identifier PartDefValuesOpt PartDefStorageOpt
-	{}
+	{
+		partDef := &ast.PartitionDefinition{
+			Name: model.NewCIStr($2),
+		}
+		switch x := $3.(type) {
+		case []ast.ExprNode:
+			partDef.LessThan = x
+		case bool:
+			partDef.MaxValue = x
+		}
+		$$ = partDef
+	}
 
 PartDefValuesOpt:
-	{}
+	{
+		$$ = nil
+	}
 |	"VALUES" "LESS" "THAN" "MAXVALUE"
-	{}
+	{
+		$$ = true
+	}
+|	"VALUES" "LESS" "THAN" '(' "MAXVALUE" ')'
+	{
+		$$ = true
+	}
 |	"VALUES" "LESS" "THAN" '(' ExpressionList ')'
-	{}
+	{
+		$$ = $5.([]ast.ExprNode)
+	}
 
 PartDefStorageOpt:
 	{}
 |	"ENGINE" eq Identifier
 	{}
 
+PartitionNameList:
+	Identifier
+	{
+		$$ = []model.CIStr{model.NewCIStr($1)}
+	}
+|	PartitionNameList ',' Identifier
+	{
+		$$ = append($1.([]model.CIStr), model.NewCIStr($3))
+	}
+
 /******************************************************************
  * Do statement
  * See https://dev.mysql.com/doc/refman/5.7/en/do.html
@@ -1771,11 +2051,22 @@ DropTableStmt:
 	}
 
 DropViewStmt:
-	"DROP" "VIEW" "IF" "EXISTS" TableNameList
+	"DROP" "VIEW" TableNameList RestrictOrCascadeOpt
+	{
+		$$ = &ast.DropTableStmt{Tables: $3.([]*ast.TableName), IsView: true}
+	}
+|	"DROP" "VIEW" "IF" "EXISTS" TableNameList RestrictOrCascadeOpt
 	{
-		$$ = &ast.DoStmt{}
+		$$ = &ast.DropTableStmt{IfExists: true, Tables: $5.([]*ast.TableName), IsView: true}
 	}
 
+RestrictOrCascadeOpt:
+	{}
+|	"RESTRICT"
+	{}
+|	"CASCADE"
+	{}
+
 DropUserStmt:
 	"DROP" "USER" UsernameList
 	{
@@ -1785,6 +2076,14 @@ DropUserStmt:
 	{
 		$$ = &ast.DropUserStmt{IfExists: true, UserList: $5.([]*auth.UserIdentity)}
 	}
+|	"DROP" "ROLE" RolenameList
+	{
+		$$ = &ast.DropUserStmt{IfExists: false, IsDropRole: true, UserList: rolesToUsers($3.([]*auth.RoleIdentity))}
+	}
+|	"DROP" "ROLE" "IF" "EXISTS" RolenameList
+	{
+		$$ = &ast.DropUserStmt{IfExists: true, IsDropRole: true, UserList: rolesToUsers($5.([]*auth.RoleIdentity))}
+	}
 
 DropStatsStmt:
 	"DROP" "STATS" TableName
@@ -1843,6 +2142,14 @@ ExplainStmt:
 			Format: $4,
 		}
 	}
+|	ExplainSym "ANALYZE" ExplainableStmt
+	{
+		$$ = &ast.ExplainStmt{
+			Stmt:		$3,
+			Format:		"row",
+			Analyze:	true,
+		}
+	}
 
 LengthNum:
 	NUM
@@ -2319,14 +2626,16 @@ UnReservedKeyword:
 | "REPEATABLE" | "COMMITTED" | "UNCOMMITTED" | "ONLY" | "SERIALIZABLE" | "LEVEL" | "VARIABLES" | "SQL_CACHE" | "INDEXES" | "PROCESSLIST"
 | "SQL_NO_CACHE" | "DISABLE"  | "ENABLE" | "REVERSE" | "PRIVILEGES" | "NO" | "BINLOG" | "FUNCTION" | "VIEW" | "MODIFY" | "EVENTS" | "PARTITIONS"
 | "NONE" | "SUPER" | "EXCLUSIVE" | "STATS_PERSISTENT" | "ROW_COUNT" | "COALESCE" | "MONTH" | "PROCESS" | "PROFILES"
-| "MICROSECOND" | "MINUTE" | "PLUGINS" | "QUERY" | "SECOND" | "SHARE" | "SHARED"
+| "MICROSECOND" | "MINUTE" | "PLUGINS" | "QUERY" | "SECOND" | "SHARE" | "SHARED" | "CURRENT" | "FOLLOWING" | "PRECEDING" | "ROWS" | "UNBOUNDED"
+| "ALGORITHM" | "CASCADED" | "DEFINER" | "INVOKER" | "MERGE" | "SECURITY" | "TEMPTABLE" | "UNDEFINED"
+| "ROLE" | "EXCEPT"
 
 TiDBKeyword:
 "ADMIN" | "CANCEL" | "DDL" | "JOBS" | "STATS" | "STATS_META" | "STATS_HISTOGRAMS" | "STATS_BUCKETS" | "TIDB" | "TIDB_SMJ" | "TIDB_INLJ"
 
 NotKeywordToken:
  "ADDDATE" | "BIT_XOR" | "CAST" | "COUNT" | "CURTIME" | "DATE_ADD" | "DATE_SUB" | "EXTRACT" | "GET_FORMAT" | "GROUP_CONCAT" | "MIN" | "MAX" | "NOW" | "POSITION"
-| "SUBDATE" | "SUBSTRING" | "SUM" | "TIMESTAMPADD" | "TIMESTAMPDIFF" | "TRIM"
+| "SUBDATE" | "SUBSTRING" | "SUM" | "TIMESTAMPADD" | "TIMESTAMPDIFF" | "TRIM" | "DENSE_RANK" | "LAG" | "LEAD" | "RANK" | "ROW_NUMBER"
 
 /************************************************************************************
  *
@@ -2369,6 +2678,10 @@ InsertValues:
 	{
 		$$ = &ast.InsertStmt{Columns: $2.([]*ast.ColumnName), Select: $4.(*ast.UnionStmt)}
 	}
+|	'(' ColumnNameListOpt ')' WithSelectStmt
+	{
+		$$ = &ast.InsertStmt{Columns: $2.([]*ast.ColumnName), Select: $4.(ast.ResultSetNode)}
+	}
 |	ValueSym ValuesList %prec insertValues
 	{
 		$$ = &ast.InsertStmt{Lists:  $2.([][]ast.ExprNode)}
@@ -2381,6 +2694,10 @@ InsertValues:
 	{
 		$$ = &ast.InsertStmt{Select: $1.(*ast.UnionStmt)}
 	}
+|	WithSelectStmt
+	{
+		$$ = &ast.InsertStmt{Select: $1.(ast.ResultSetNode)}
+	}
 |	"SET" ColumnSetValueList
 	{
 		$$ = &ast.InsertStmt{Setlist: $2.([]*ast.Assignment)}
@@ -2736,8 +3053,7 @@ SimpleExpr:
 |	FunctionCallGeneric
 |	SimpleExpr "COLLATE" StringName %prec neg
 	{
-		// TODO: Create a builtin function hold expr and collation. When do evaluation, convert expr result using the collation.
-		$$ = $1
+		$$ = &ast.SetCollationExpr{Expr: $1, Collate: $3.(string)}
 	}
 |	Literal
 |	paramMarker
@@ -2748,6 +3064,7 @@ SimpleExpr:
 	}
 |	Variable
 |	SumExpr
+|	WindowFuncCall
 |	'!' SimpleExpr %prec neg
 	{
 		$$ = &ast.UnaryOperationExpr{Op: opcode.Not, V: $2}
@@ -3235,6 +3552,136 @@ SumExpr:
 		$$ = &ast.AggregateFuncExpr{F: $1, Args: []ast.ExprNode{$4}, Distinct: $3.(bool)}
 	}
 
+WindowFuncCall:
+	"ROW_NUMBER" '(' ')' WindowingClause
+	{
+		$$ = &ast.WindowFuncExpr{F: $1, Spec: $4.(ast.WindowSpec)}
+	}
+|	"RANK" '(' ')' WindowingClause
+	{
+		$$ = &ast.WindowFuncExpr{F: $1, Spec: $4.(ast.WindowSpec)}
+	}
+|	"DENSE_RANK" '(' ')' WindowingClause
+	{
+		$$ = &ast.WindowFuncExpr{F: $1, Spec: $4.(ast.WindowSpec)}
+	}
+|	"LAG" '(' Expression LeadLagInfoOpt ')' WindowingClause
+	{
+		args := append([]ast.ExprNode{$3}, $4.([]ast.ExprNode)...)
+		$$ = &ast.WindowFuncExpr{F: $1, Args: args, Spec: $6.(ast.WindowSpec)}
+	}
+|	"LEAD" '(' Expression LeadLagInfoOpt ')' WindowingClause
+	{
+		args := append([]ast.ExprNode{$3}, $4.([]ast.ExprNode)...)
+		$$ = &ast.WindowFuncExpr{F: $1, Args: args, Spec: $6.(ast.WindowSpec)}
+	}
+|	SumExpr WindowingClause
+	{
+		agg := $1.(*ast.AggregateFuncExpr)
+		$$ = &ast.WindowFuncExpr{F: agg.F, Args: agg.Args, Distinct: agg.Distinct, Spec: $2.(ast.WindowSpec)}
+	}
+
+LeadLagInfoOpt:
+	{
+		$$ = []ast.ExprNode{}
+	}
+|	',' NumLiteral
+	{
+		$$ = []ast.ExprNode{ast.NewValueExpr($2)}
+	}
+|	',' NumLiteral ',' Expression
+	{
+		$$ = []ast.ExprNode{ast.NewValueExpr($2), $4}
+	}
+
+WindowingClause:
+	"OVER" WindowSpec
+	{
+		$$ = $2
+	}
+
+WindowSpec:
+	'(' PartitionByClauseOpt OrderByOptional FrameClauseOpt ')'
+	{
+		spec := ast.WindowSpec{}
+		if $2 != nil {
+			spec.PartitionBy = $2.(*ast.PartitionByClause)
+		}
+		if $3 != nil {
+			spec.OrderBy = $3.(*ast.OrderByClause)
+		}
+		if $4 != nil {
+			spec.Frame = $4.(*ast.FrameClause)
+		}
+		$$ = spec
+	}
+
+PartitionByClauseOpt:
+	{
+		$$ = nil
+	}
+|	"PARTITION" "BY" ByList
+	{
+		$$ = &ast.PartitionByClause{Items: $3.([]*ast.ByItem)}
+	}
+
+FrameClauseOpt:
+	{
+		$$ = nil
+	}
+|	FrameUnits FrameExtent
+	{
+		$$ = &ast.FrameClause{Type: $1.(ast.FrameType), Extent: $2.(ast.FrameExtent)}
+	}
+
+FrameUnits:
+	"ROWS"
+	{
+		$$ = ast.Rows
+	}
+|	"RANGE"
+	{
+		$$ = ast.Ranges
+	}
+
+FrameExtent:
+	FrameStart
+	{
+		$$ = ast.FrameExtent{
+			Start: $1.(ast.FrameBound),
+			End:   ast.FrameBound{Type: ast.CurrentRow},
+		}
+	}
+|	"BETWEEN" FrameBound "AND" FrameBound
+	{
+		$$ = ast.FrameExtent{Start: $2.(ast.FrameBound), End: $4.(ast.FrameBound)}
+	}
+
+FrameStart:
+	"UNBOUNDED" "PRECEDING"
+	{
+		$$ = ast.FrameBound{Type: ast.Preceding, UnBounded: true}
+	}
+|	NumLiteral "PRECEDING"
+	{
+		$$ = ast.FrameBound{Type: ast.Preceding, Expr: ast.NewValueExpr($1)}
+	}
+|	"CURRENT" "ROW"
+	{
+		$$ = ast.FrameBound{Type: ast.CurrentRow}
+	}
+
+FrameBound:
+	FrameStart
+|	"UNBOUNDED" "FOLLOWING"
+	{
+		$$ = ast.FrameBound{Type: ast.Following, UnBounded: true}
+	}
+|	NumLiteral "FOLLOWING"
+	{
+		$$ = ast.FrameBound{Type: ast.Following, Expr: ast.NewValueExpr($1)}
+	}
+
 FunctionCallGeneric:
 	identifier '(' ExpressionListOpt ')'
 	{
@@ -3819,6 +4266,14 @@ TableFactor:
 	{
 		$$ = &ast.TableSource{Source: $2.(*ast.UnionStmt), AsName: $4.(model.CIStr)}
 	}
+|	'(' WithSelectStmt ')' TableAsName
+	{
+		if st, ok := $2.(*ast.SelectStmt); ok {
+			endOffset := parser.endOffset(&yyS[yypt-1])
+			parser.setLastSelectFieldText(st, endOffset)
+		}
+		$$ = &ast.TableSource{Source: $2.(ast.ResultSetNode), AsName: $4.(model.CIStr)}
+	}
 |	'(' TableRefs ')'
 	{
 		$$ = $2
@@ -4129,6 +4584,77 @@ SubSelect:
 		s.SetText(src[yyS[yypt-1].offset-1:yyS[yypt].offset-1])
 		$$ = &ast.SubqueryExpr{Query: s}
 	}
+|	'(' WithSelectStmt ')'
+	{
+		if st, ok := $2.(*ast.SelectStmt); ok {
+			endOffset := parser.endOffset(&yyS[yypt])
+			parser.setLastSelectFieldText(st, endOffset)
+		}
+		s := $2.(ast.ResultSetNode)
+		src := parser.src
+		// See the implementation of yyParse function
+		s.SetText(src[yyS[yypt-1].offset-1:yyS[yypt].offset-1])
+		$$ = &ast.SubqueryExpr{Query: s}
+	}
+
+// See https://dev.mysql.com/doc/refman/8.0/en/with.html
+WithSelectStmt:
+	WithClause SelectStmt
+	{
+		st := $2.(*ast.SelectStmt)
+		st.With = $1.(*ast.WithClause)
+		$$ = st
+	}
+|	WithClause UnionStmt
+	{
+		st := $2.(*ast.UnionStmt)
+		st.With = $1.(*ast.WithClause)
+		$$ = st
+	}
+
+WithClause:
+	"WITH" WithList
+	{
+		$$ = &ast.WithClause{CTEs: $2.([]*ast.CommonTableExpression)}
+	}
+|	"WITH" "RECURSIVE" WithList
+	{
+		$$ = &ast.WithClause{IsRecursive: true, CTEs: $3.([]*ast.CommonTableExpression)}
+	}
+
+WithList:
+	CommonTableExpr
+	{
+		$$ = []*ast.CommonTableExpression{$1.(*ast.CommonTableExpression)}
+	}
+|	WithList ',' CommonTableExpr
+	{
+		$$ = append($1.([]*ast.CommonTableExpression), $3.(*ast.CommonTableExpression))
+	}
+
+CommonTableExpr:
+	Identifier CTEColumnListOpt "AS" SubSelect
+	{
+		$$ = &ast.CommonTableExpression{
+			Name:        model.NewCIStr($1),
+			ColNameList: $2.([]model.CIStr),
+			Query:       $4.(*ast.SubqueryExpr),
+		}
+	}
+
+CTEColumnListOpt:
+	{
+		$$ = []model.CIStr{}
+	}
+|	'(' ColumnNameList ')'
+	{
+		cols := $2.([]*ast.ColumnName)
+		names := make([]model.CIStr, 0, len(cols))
+		for _, col := range cols {
+			names = append(names, col.Name)
+		}
+		$$ = names
+	}
 
 // See https://dev.mysql.com/doc/refman/5.7/en/innodb-locking-reads.html
 SelectLockOpt:
@@ -4239,6 +4765,41 @@ SetStmt:
 	{
 		$$ = &ast.SetStmt{Variables: $4.([]*ast.VariableAssignment)}
 	}
+|	"SET" "ROLE" "DEFAULT"
+	{
+		$$ = &ast.SetRoleStmt{SetRoleOpt: ast.SetRoleDefault}
+	}
+|	"SET" "ROLE" "ALL"
+	{
+		$$ = &ast.SetRoleStmt{SetRoleOpt: ast.SetRoleAll}
+	}
+|	"SET" "ROLE" "ALL" "EXCEPT" RolenameList
+	{
+		$$ = &ast.SetRoleStmt{SetRoleOpt: ast.SetRoleAllExcept, RoleList: $5.([]*auth.RoleIdentity)}
+	}
+|	"SET" "ROLE" RolenameList
+	{
+		// "NONE" is not reserved, so it is parsed as a role name.
+		roles := $3.([]*auth.RoleIdentity)
+		if isRoleNone(roles) {
+			$$ = &ast.SetRoleStmt{SetRoleOpt: ast.SetRoleNone}
+		} else {
+			$$ = &ast.SetRoleStmt{SetRoleOpt: ast.SetRoleRegular, RoleList: roles}
+		}
+	}
+|	"SET" "DEFAULT" "ROLE" "ALL" "TO" UsernameList
+	{
+		$$ = &ast.SetDefaultRoleStmt{SetRoleOpt: ast.SetRoleAll, UserList: $6.([]*auth.UserIdentity)}
+	}
+|	"SET" "DEFAULT" "ROLE" RolenameList "TO" UsernameList
+	{
+		roles := $4.([]*auth.RoleIdentity)
+		if isRoleNone(roles) {
+			$$ = &ast.SetDefaultRoleStmt{SetRoleOpt: ast.SetRoleNone, UserList: $6.([]*auth.UserIdentity)}
+		} else {
+			$$ = &ast.SetDefaultRoleStmt{SetRoleOpt: ast.SetRoleRegular, RoleList: roles, UserList: $6.([]*auth.UserIdentity)}
+		}
+	}
 
 TransactionChars:
 	TransactionChar
@@ -4444,6 +5005,30 @@ UsernameList:
 		$$ = append($1.([]*auth.UserIdentity), $3.(*auth.UserIdentity))
 	}
 
+Rolename:
+	StringName
+	{
+		$$ = &auth.RoleIdentity{Username: $1.(string), Hostname: "%"}
+	}
+|	StringName '@' StringName
+	{
+		$$ = &auth.RoleIdentity{Username: $1.(string), Hostname: $3.(string)}
+	}
+|	StringName singleAtIdentifier
+	{
+		$$ = &auth.RoleIdentity{Username: $1.(string), Hostname: strings.TrimPrefix($2, "@")}
+	}
+
+RolenameList:
+	Rolename
+	{
+		$$ = []*auth.RoleIdentity{$1.(*auth.RoleIdentity)}
+	}
+|	RolenameList ',' Rolename
+	{
+		$$ = append($1.([]*auth.RoleIdentity), $3.(*auth.RoleIdentity))
+	}
+
 PasswordOpt:
 	stringLit
 	{
@@ -4517,6 +5102,13 @@ ShowStmt:
 			Table:	$4.(*ast.TableName),
 		}
 	}
+|	"SHOW" "CREATE" "VIEW" TableName
+	{
+		$$ = &ast.ShowStmt{
+			Tp:	ast.ShowCreateView,
+			Table:	$4.(*ast.TableName),
+		}
+	}
 |	"SHOW" "CREATE" "DATABASE" DBName
 	{
 		$$ = &ast.ShowStmt{
@@ -4855,6 +5447,7 @@ Statement:
 |	CreateDatabaseStmt
 |	CreateIndexStmt
 |	CreateTableStmt
+|	CreateViewStmt
 |	CreateUserStmt
 |	DoStmt
 |	DropDatabaseStmt
@@ -4865,6 +5458,7 @@ Statement:
 |	DropStatsStmt
 |	FlushStmt
 |	GrantStmt
+|	GrantRoleStmt
 |	InsertIntoStmt
 |	KillStmt
 |	LoadDataStmt
@@ -4873,8 +5467,10 @@ Statement:
 |	RenameTableStmt
 |	ReplaceIntoStmt
 |	RevokeStmt
+|	RevokeRoleStmt
 |	SelectStmt
 |	UnionStmt
+|	WithSelectStmt
 |	SetStmt
 |	ShowStmt
 |	SubSelect
@@ -4896,6 +5492,7 @@ ExplainableStmt:
 |	InsertIntoStmt
 |	ReplaceIntoStmt
 |	UnionStmt
+|	WithSelectStmt
 
 StatementList:
 	Statement
@@ -5653,6 +6250,19 @@ CreateUserStmt:
 			Specs: $4.([]*ast.UserSpec),
 		}
 	}
+|	"CREATE" "ROLE" IfNotExists RolenameList
+	{
+		// See https://dev.mysql.com/doc/refman/8.0/en/create-role.html
+		var specs []*ast.UserSpec
+		for _, user := range rolesToUsers($4.([]*auth.RoleIdentity)) {
+			specs = append(specs, &ast.UserSpec{User: user})
+		}
+		$$ = &ast.CreateUserStmt{
+			IsCreateRole: true,
+			IfNotExists: $3.(bool),
+			Specs: specs,
+		}
+	}
 
 /* See http://dev.mysql.com/doc/refman/5.7/en/alter-user.html */
 AlterUserStmt:
@@ -5714,6 +6324,27 @@ AuthOption:
 			HashString: $4.(string),
 		}
 	}
+|	"IDENTIFIED" "WITH" StringName
+	{
+		$$ = &ast.AuthOption{
+			AuthPlugin: $3.(string),
+		}
+	}
+|	"IDENTIFIED" "WITH" StringName "BY" AuthString
+	{
+		$$ = &ast.AuthOption{
+			AuthPlugin: $3.(string),
+			AuthString: $5.(string),
+			ByAuthString: true,
+		}
+	}
+|	"IDENTIFIED" "WITH" StringName "AS" HashString
+	{
+		$$ = &ast.AuthOption{
+			AuthPlugin: $3.(string),
+			HashString: $5.(string),
+		}
+	}
 
 HashString:
 	stringLit
@@ -5726,10 +6357,14 @@ HashString:
  * See https://dev.mysql.com/doc/refman/5.7/en/grant.html
  *************************************************************************************/
 GrantStmt:
-	 "GRANT" PrivElemList "ON" ObjectType PrivLevel "TO" UserSpecList WithGrantOptionOpt
+	 "GRANT" RoleOrPrivElemList "ON" ObjectType PrivLevel "TO" UserSpecList WithGrantOptionOpt
 	 {
+		privs, ok := convertToPrivs(yylex, $2.([]*roleOrPriv))
+		if !ok {
+			return 1
+		}
 		$$ = &ast.GrantStmt{
-			Privs: $2.([]*ast.PrivElem),
+			Privs: privs,
 			ObjectType: $4.(ast.ObjectTypeType),
 			Level: $5.(*ast.GrantLevel),
 			Users: $7.([]*ast.UserSpec),
@@ -5737,6 +6372,19 @@ GrantStmt:
 		}
 	 }
 
+GrantRoleStmt:
+	 "GRANT" RoleOrPrivElemList "TO" UsernameList
+	 {
+		roles, ok := convertToRoles(yylex, $2.([]*roleOrPriv))
+		if !ok {
+			return 1
+		}
+		$$ = &ast.GrantRoleStmt{
+			Roles: roles,
+			Users: $4.([]*auth.UserIdentity),
+		}
+	 }
+
 WithGrantOptionOpt:
 	{
 		$$ = false
@@ -5761,14 +6409,40 @@ PrivElem:
 		}
 	}
 
-PrivElemList:
+/*
+ * The privileges and the roles can not be told apart before the keyword after them is seen,
+ * because a role name may be the same as a privilege whose name is not reserved, such as EXECUTE.
+ */
+RoleOrPrivElem:
 	PrivElem
 	{
-		$$ = []*ast.PrivElem{$1.(*ast.PrivElem)}
+		$$ = &roleOrPriv{priv: $1.(*ast.PrivElem)}
 	}
-|	PrivElemList ',' PrivElem
+|	Identifier
+	{
+		$$ = &roleOrPriv{symbol: $1}
+	}
+|	stringLit
+	{
+		$$ = &roleOrPriv{role: &auth.RoleIdentity{Username: $1, Hostname: "%"}}
+	}
+|	StringName '@' StringName
 	{
-		$$ = append($1.([]*ast.PrivElem), $3.(*ast.PrivElem))
+		$$ = &roleOrPriv{role: &auth.RoleIdentity{Username: $1.(string), Hostname: $3.(string)}}
+	}
+|	StringName singleAtIdentifier
+	{
+		$$ = &roleOrPriv{role: &auth.RoleIdentity{Username: $1.(string), Hostname: strings.TrimPrefix($2, "@")}}
+	}
+
+RoleOrPrivElemList:
+	RoleOrPrivElem
+	{
+		$$ = []*roleOrPriv{$1.(*roleOrPriv)}
+	}
+|	RoleOrPrivElemList ',' RoleOrPrivElem
+	{
+		$$ = append($1.([]*roleOrPriv), $3.(*roleOrPriv))
 	}
 
 PrivType:
@@ -5804,14 +6478,6 @@ PrivType:
 	{
 		$$ = mysql.DropPriv
 	}
-|	"PROCESS"
-	{
-		$$ = mysql.ProcessPriv
-	}
-|	"EXECUTE"
-	{
-		$$ = mysql.ExecutePriv
-	}
 |	"INDEX"
 	{
 		$$ = mysql.IndexPriv
@@ -5824,10 +6490,6 @@ PrivType:
 	{
 		$$ = mysql.SelectPriv
 	}
-|	"SUPER"
-	{
-		$$ = mysql.SuperPriv
-	}
 |	"SHOW" "DATABASES"
 	{
 		$$ = mysql.ShowDBPriv
@@ -5894,16 +6556,33 @@ PrivLevel:
  * See https://dev.mysql.com/doc/refman/5.7/en/revoke.html
  *******************************************************************************************/
 RevokeStmt:
-	 "REVOKE" PrivElemList "ON" ObjectType PrivLevel "FROM" UserSpecList
+	 "REVOKE" RoleOrPrivElemList "ON" ObjectType PrivLevel "FROM" UserSpecList
 	 {
+		privs, ok := convertToPrivs(yylex, $2.([]*roleOrPriv))
+		if !ok {
+			return 1
+		}
 		$$ = &ast.RevokeStmt{
-			Privs: $2.([]*ast.PrivElem),
+			Privs: privs,
 			ObjectType: $4.(ast.ObjectTypeType),
 			Level: $5.(*ast.GrantLevel),
 			Users: $7.([]*ast.UserSpec),
 		}
 	 }
 
+RevokeRoleStmt:
+	 "REVOKE" RoleOrPrivElemList "FROM" UsernameList
+	 {
+		roles, ok := convertToRoles(yylex, $2.([]*roleOrPriv))
+		if !ok {
+			return 1
+		}
+		$$ = &ast.RevokeRoleStmt{
+			Roles: roles,
+			Users: $4.([]*auth.UserIdentity),
+		}
+	 }
+
 /**************************************LoadDataStmt*****************************************
  * See https://dev.mysql.com/doc/refman/5.7/en/load-data.html
  *******************************************************************************************/
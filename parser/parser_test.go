@@ -22,8 +22,10 @@ import (
 	"github.com/juju/errors"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/auth"
 	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/testleak"
 )
@@ -56,8 +58,8 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"localtime", "localtimestamp", "lock", "longblob", "longtext", "mediumblob", "maxvalue", "mediumint", "mediumtext",
 		"minute_microsecond", "minute_second", "mod", "not", "no_write_to_binlog", "null", "numeric",
 		"on", "option", "or", "order", "outer", "partition", "precision", "primary", "procedure", "range", "read", "real",
-		"references", "regexp", "rename", "repeat", "replace", "revoke", "restrict", "right", "rlike",
-		"schema", "schemas", "second_microsecond", "select", "set", "show", "smallint",
+		"recursive", "references", "regexp", "rename", "repeat", "replace", "revoke", "restrict", "right", "rlike",
+		"schema", "schemas", "second_microsecond", "select", "set", "show", "smallint", "sql",
 		"starting", "table", "terminated", "then", "tinyblob", "tinyint", "tinytext", "to",
 		"trailing", "true", "union", "unique", "unlock", "unsigned",
 		"update", "use", "using", "utc_date", "values", "varbinary", "varchar",
@@ -96,6 +98,7 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"binlog", "hex", "unhex", "function", "indexes", "from_unixtime", "processlist", "events", "less", "than", "timediff",
 		"ln", "log", "log2", "log10", "timestampdiff", "pi", "quote", "none", "super", "shared", "exclusive",
 		"always", "stats", "stats_meta", "stats_histogram", "stats_buckets", "tidb_version",
+		"algorithm", "cascaded", "definer", "invoker", "merge", "security", "temptable", "undefined",
 	}
 	for _, kw := range unreservedKws {
 		src := fmt.Sprintf("SELECT %s FROM tbl;", kw)
@@ -461,6 +464,7 @@ func (s *testParserSuite) TestDBAStmt(c *C) {
 		// for show create table
 		{"show create table test.t", true},
 		{"show create table t", true},
+		{"show create view test.v", true},
 		// for show stats_meta.
 		{"show stats_meta", true},
 		{"show stats_meta where table_name = 't'", true},
@@ -1320,6 +1324,22 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"drop table if exists xxx", true},
 		{"drop table if not exists xxx", false},
 		{"drop view if exists xxx", true},
+		{"drop view xxx", true},
+		{"drop view xxx, yyy restrict", true},
+		{"drop view if exists xxx cascade", true},
+		{"create view v as select * from t", true},
+		{"create view v (a, b) as select c, d from t", true},
+		{"create or replace view v as select 1", true},
+		{"create algorithm = merge definer = 'root'@'localhost' sql security invoker view v as select 1", true},
+		{"create definer = current_user() view v as select 1", true},
+		{"create view v as select 1 union select 2", true},
+		{"create view v as with cte as (select 1) select * from cte", true},
+		{"create view v as select * from t with check option", true},
+		{"create view v as select * from t with cascaded check option", true},
+		{"create view v as select * from t with local check option", true},
+		{"create view v as (select 1)", false},
+		{"create view v", false},
+		{"create algorithm = fast view v as select 1", false},
 		{"drop stats t", true},
 		// for issue 974
 		{`CREATE TABLE address (
@@ -1601,6 +1621,11 @@ func (s *testParserSuite) TestPrivilege(c *C) {
 		{`ALTER USER IF EXISTS 'root'@'localhost' IDENTIFIED BY 'new-password'`, true},
 		{`ALTER USER 'root'@'localhost' IDENTIFIED BY 'new-password'`, true},
 		{`ALTER USER 'root'@'localhost' IDENTIFIED BY PASSWORD 'hashstring'`, true},
+		{`CREATE USER 'root'@'localhost' IDENTIFIED WITH caching_sha2_password BY 'new-password'`, true},
+		{`CREATE USER 'root'@'localhost' IDENTIFIED WITH 'sha256_password' AS 'hashstring'`, true},
+		{`CREATE USER 'root'@'localhost' IDENTIFIED WITH mysql_native_password`, true},
+		{`ALTER USER 'root'@'localhost' IDENTIFIED WITH caching_sha2_password BY 'new-password'`, true},
+		{`CREATE USER 'root'@'localhost' IDENTIFIED WITH BY 'new-password'`, false},
 		{`ALTER USER 'root'@'localhost' IDENTIFIED BY 'new-password', 'root'@'127.0.0.1' IDENTIFIED BY PASSWORD 'hashstring'`, true},
 		{`ALTER USER USER() IDENTIFIED BY 'new-password'`, true},
 		{`ALTER USER IF EXISTS USER() IDENTIFIED BY 'new-password'`, true},
@@ -1632,10 +1657,84 @@ func (s *testParserSuite) TestPrivilege(c *C) {
 		{"REVOKE SELECT, INSERT ON mydb.mytbl FROM 'someuser'@'somehost';", true},
 		{"REVOKE SELECT (col1), INSERT (col1,col2) ON mydb.mytbl FROM 'someuser'@'somehost';", true},
 		{"REVOKE all privileges on zabbix.* FROM 'zabbix'@'localhost' identified by 'password';", true},
+		{"REVOKE EXECUTE, SUPER ON *.* FROM 'someuser'@'somehost';", true},
+
+		// for role statements
+		{"CREATE ROLE 'admin', 'dev'@'localhost'", true},
+		{"CREATE ROLE IF NOT EXISTS app_read, `app_write`", true},
+		{"DROP ROLE 'admin'", true},
+		{"DROP ROLE IF EXISTS 'admin', dev@localhost", true},
+		{"GRANT 'admin' TO 'root'@'localhost'", true},
+		{"GRANT admin, 'dev'@'localhost' TO 'u1', 'u2'@'%'", true},
+		{"GRANT execute, process TO u1", true},
+		{"GRANT EXECUTE, SUPER ON *.* TO 'someuser'@'somehost'", true},
+		{"GRANT SELECT TO u1", false},
+		{"GRANT admin ON *.* TO u1", false},
+		{"REVOKE 'admin' FROM 'root'@'localhost'", true},
+		{"REVOKE admin, dev FROM u1, u2", true},
+		{"REVOKE SELECT FROM u1", false},
+		{"SET ROLE DEFAULT", true},
+		{"SET ROLE NONE", true},
+		{"SET ROLE ALL", true},
+		{"SET ROLE ALL EXCEPT 'admin', dev", true},
+		{"SET ROLE 'admin', 'dev'@'localhost'", true},
+		{"SET DEFAULT ROLE ALL TO 'root'@'localhost'", true},
+		{"SET DEFAULT ROLE NONE TO u1, u2", true},
+		{"SET DEFAULT ROLE admin, dev TO u1", true},
+		{"SET DEFAULT ROLE admin", false},
+		{"SET role = 1", true},
+		{"CREATE TABLE role (role int, except int)", true},
 	}
 	s.RunTest(c, table)
 }
 
+func (s *testParserSuite) TestRole(c *C) {
+	defer testleak.AfterTest(c)()
+	parser := New()
+
+	stmt, err := parser.ParseOneStmt("GRANT execute, 'r2'@'localhost' TO u1", "", "")
+	c.Assert(err, IsNil)
+	grantRole := stmt.(*ast.GrantRoleStmt)
+	c.Assert(grantRole.Roles, HasLen, 2)
+	c.Assert(*grantRole.Roles[0], Equals, auth.RoleIdentity{Username: "execute", Hostname: "%"})
+	c.Assert(*grantRole.Roles[1], Equals, auth.RoleIdentity{Username: "r2", Hostname: "localhost"})
+	c.Assert(*grantRole.Users[0], Equals, auth.UserIdentity{Username: "u1", Hostname: "%"})
+
+	stmt, err = parser.ParseOneStmt("GRANT execute, select ON *.* TO u1", "", "")
+	c.Assert(err, IsNil)
+	grant := stmt.(*ast.GrantStmt)
+	c.Assert(grant.Privs, HasLen, 2)
+	c.Assert(grant.Privs[0].Priv, Equals, mysql.ExecutePriv)
+	c.Assert(grant.Privs[1].Priv, Equals, mysql.SelectPriv)
+
+	stmt, err = parser.ParseOneStmt("CREATE ROLE r1", "", "")
+	c.Assert(err, IsNil)
+	createRole := stmt.(*ast.CreateUserStmt)
+	c.Assert(createRole.IsCreateRole, IsTrue)
+	c.Assert(*createRole.Specs[0].User, Equals, auth.UserIdentity{Username: "r1", Hostname: "%"})
+
+	stmt, err = parser.ParseOneStmt("DROP ROLE r1", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.DropUserStmt).IsDropRole, IsTrue)
+
+	stmt, err = parser.ParseOneStmt("SET ROLE none", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.SetRoleStmt).SetRoleOpt, Equals, ast.SetRoleNone)
+
+	stmt, err = parser.ParseOneStmt("SET ROLE ALL EXCEPT r1", "", "")
+	c.Assert(err, IsNil)
+	setRole := stmt.(*ast.SetRoleStmt)
+	c.Assert(setRole.SetRoleOpt, Equals, ast.SetRoleAllExcept)
+	c.Assert(setRole.RoleList, HasLen, 1)
+
+	stmt, err = parser.ParseOneStmt("SET DEFAULT ROLE r1, r2 TO u1", "", "")
+	c.Assert(err, IsNil)
+	setDefault := stmt.(*ast.SetDefaultRoleStmt)
+	c.Assert(setDefault.SetRoleOpt, Equals, ast.SetRoleRegular)
+	c.Assert(setDefault.RoleList, HasLen, 2)
+	c.Assert(setDefault.UserList, HasLen, 1)
+}
+
 func (s *testParserSuite) TestComment(c *C) {
 	defer testleak.AfterTest(c)()
 	table := []testCase{
@@ -1826,6 +1925,9 @@ func (s *testParserSuite) TestExplain(c *C) {
 		{"explain update t set id = id + 1 order by id desc;", true},
 		{"explain select c1 from t1 union (select c2 from t2) limit 1, 1", true},
 		{`explain format = "row" select c1 from t1 union (select c2 from t2) limit 1, 1`, true},
+		{"explain analyze select c1 from t1", true},
+		{"desc analyze insert into t values (1)", true},
+		{"explain analyze t1", false},
 	}
 	s.RunTest(c, table)
 }
@@ -2005,3 +2107,158 @@ func (s *testParserSuite) TestSetTransaction(c *C) {
 		c.Assert(vars.Value.GetValue(), Equals, t.value)
 	}
 }
+
+func (s *testParserSuite) TestWindowFunctions(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"SELECT ROW_NUMBER() OVER () FROM t", true},
+		{"SELECT ROW_NUMBER() OVER (PARTITION BY a ORDER BY b DESC) FROM t", true},
+		{"SELECT RANK() OVER (ORDER BY a), DENSE_RANK() OVER (ORDER BY a) FROM t", true},
+		{"SELECT LAG(a) OVER (ORDER BY b), LEAD(a, 2, 0) OVER (ORDER BY b) FROM t", true},
+		{"SELECT SUM(a) OVER (PARTITION BY b, c ORDER BY d ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING) FROM t", true},
+		{"SELECT AVG(a) OVER (ORDER BY b RANGE BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) FROM t", true},
+		{"SELECT COUNT(*) OVER (ROWS UNBOUNDED PRECEDING) FROM t", true},
+		{"SELECT MAX(a) OVER (ROWS BETWEEN CURRENT ROW AND UNBOUNDED FOLLOWING) FROM t", true},
+		{"SELECT MIN(a) OVER (ROWS 2 PRECEDING) FROM t", true},
+		{"SELECT a, SUM(b) OVER (PARTITION BY a) AS s FROM t ORDER BY s", true},
+		// window function names are not reserved.
+		{"SELECT rank, row_number, lag, lead, dense_rank FROM t", true},
+		{"SELECT rows, current, preceding, following, unbounded FROM t", true},
+		{"SELECT ROW_NUMBER() FROM t", false},
+		{"SELECT RANK() OVER FROM t", false},
+		{"SELECT SUM(a) OVER (ROWS 1 FOLLOWING) FROM t", false},
+		{"SELECT over FROM t", false},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("SELECT LEAD(a, 1, 2) OVER (PARTITION BY b ORDER BY c ROWS BETWEEN 1 PRECEDING AND UNBOUNDED FOLLOWING) FROM t", "", "")
+	c.Assert(err, IsNil)
+	expr := stmt.(*ast.SelectStmt).Fields.Fields[0].Expr.(*ast.WindowFuncExpr)
+	c.Assert(expr.F, Equals, "LEAD")
+	c.Assert(expr.Args, HasLen, 3)
+	c.Assert(expr.Spec.PartitionBy.Items, HasLen, 1)
+	c.Assert(expr.Spec.OrderBy.Items, HasLen, 1)
+	c.Assert(expr.Spec.Frame.Type, Equals, ast.Rows)
+	c.Assert(expr.Spec.Frame.Extent.Start.Type, Equals, ast.Preceding)
+	c.Assert(expr.Spec.Frame.Extent.Start.UnBounded, IsFalse)
+	c.Assert(expr.Spec.Frame.Extent.End.Type, Equals, ast.Following)
+	c.Assert(expr.Spec.Frame.Extent.End.UnBounded, IsTrue)
+}
+
+func (s *testParserSuite) TestCommonTableExpression(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"WITH cte AS (SELECT 1) SELECT * FROM cte", true},
+		{"WITH cte (a, b) AS (SELECT 1, 2) SELECT a, b FROM cte", true},
+		{"WITH c1 AS (SELECT 1), c2 AS (SELECT * FROM c1) SELECT * FROM c2", true},
+		{"WITH RECURSIVE cte (n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM cte WHERE n < 5) SELECT * FROM cte", true},
+		{"WITH cte AS (SELECT 1) SELECT * FROM cte UNION SELECT 2", true},
+		{"SELECT * FROM (WITH cte AS (SELECT 1) SELECT * FROM cte) t", true},
+		{"SELECT (WITH cte AS (SELECT 1) SELECT * FROM cte)", true},
+		{"INSERT INTO t WITH cte AS (SELECT 1) SELECT * FROM cte", true},
+		{"EXPLAIN WITH cte AS (SELECT 1) SELECT * FROM cte", true},
+		{"WITH cte AS SELECT 1 SELECT * FROM cte", false},
+		{"WITH RECURSIVE AS (SELECT 1) SELECT 1", false},
+		{"WITH cte () AS (SELECT 1) SELECT * FROM cte", false},
+		{"SELECT recursive FROM t", false},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("WITH RECURSIVE cte (n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM cte) SELECT * FROM cte", "", "")
+	c.Assert(err, IsNil)
+	with := stmt.(*ast.SelectStmt).With
+	c.Assert(with.IsRecursive, IsTrue)
+	c.Assert(with.CTEs, HasLen, 1)
+	c.Assert(with.CTEs[0].Name.L, Equals, "cte")
+	c.Assert(with.CTEs[0].ColNameList, HasLen, 1)
+	c.Assert(with.CTEs[0].ColNameList[0].L, Equals, "n")
+	_, ok := with.CTEs[0].Query.Query.(*ast.UnionStmt)
+	c.Assert(ok, IsTrue)
+}
+
+func (s *testParserSuite) TestCreateView(c *C) {
+	defer testleak.AfterTest(c)()
+	parser := New()
+	stmt, err := parser.ParseOneStmt("CREATE OR REPLACE ALGORITHM = TEMPTABLE DEFINER = 'root'@'%' SQL SECURITY INVOKER "+
+		"VIEW test.v (x, y) AS SELECT a, b FROM t WHERE a > 1  WITH LOCAL CHECK OPTION", "", "")
+	c.Assert(err, IsNil)
+	v := stmt.(*ast.CreateViewStmt)
+	c.Assert(v.OrReplace, IsTrue)
+	c.Assert(v.ViewName.Schema.L, Equals, "test")
+	c.Assert(v.ViewName.Name.L, Equals, "v")
+	c.Assert(v.Cols, HasLen, 2)
+	c.Assert(v.Cols[1].L, Equals, "y")
+	c.Assert(v.Algorithm, Equals, model.AlgorithmTemptable)
+	c.Assert(v.Definer.String(), Equals, "root@%")
+	c.Assert(v.Security, Equals, model.SecurityInvoker)
+	c.Assert(v.CheckOption, Equals, model.CheckOptionLocal)
+	c.Assert(v.Select.Text(), Equals, "SELECT a, b FROM t WHERE a > 1")
+
+	stmts, err := parser.Parse("create view v as select 1 union select 2; select 3", "", "")
+	c.Assert(err, IsNil)
+	v = stmts[0].(*ast.CreateViewStmt)
+	c.Assert(v.Definer, IsNil)
+	c.Assert(v.Cols, HasLen, 0)
+	c.Assert(v.Select.Text(), Equals, "select 1 union select 2")
+	c.Assert(v.SelectOffset, Equals, len("create view v as "))
+
+	stmt, err = parser.ParseOneStmt("drop view if exists v1, v2", "", "")
+	c.Assert(err, IsNil)
+	drop := stmt.(*ast.DropTableStmt)
+	c.Assert(drop.IsView, IsTrue)
+	c.Assert(drop.IfExists, IsTrue)
+	c.Assert(drop.Tables, HasLen, 2)
+}
+
+func (s *testParserSuite) TestPartition(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"create table t (a int) partition by range (a) (partition p0 values less than (10), partition p1 values less than (maxvalue))", true},
+		{"create table t (a int) partition by hash (a + 1)", true},
+		{"alter table t add partition (partition p2 values less than (20), partition p3 values less than maxvalue)", true},
+		{"alter table t drop partition p0", true},
+		{"alter table t drop partition p0, p1", true},
+		{"alter table t truncate partition p0, p1", true},
+		{"alter table t add partition p2 values less than (20)", false},
+		{"alter table t drop partition", false},
+		{"alter table t truncate partition", false},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("create table t (a int, b int) partition by range ( a * 2 ) "+
+		"(partition p0 values less than (10), partition p1 values less than (20, 30), partition p2 values less than maxvalue)", "", "")
+	c.Assert(err, IsNil)
+	part := stmt.(*ast.CreateTableStmt).Partition
+	c.Assert(part, NotNil)
+	c.Assert(part.Tp, Equals, model.PartitionTypeRange)
+	c.Assert(part.Expr.Text(), Equals, "a * 2")
+	c.Assert(part.Definitions, HasLen, 3)
+	c.Assert(part.Definitions[0].Name.L, Equals, "p0")
+	c.Assert(part.Definitions[0].LessThan, HasLen, 1)
+	c.Assert(part.Definitions[1].LessThan, HasLen, 2)
+	c.Assert(part.Definitions[2].MaxValue, IsTrue)
+
+	stmt, err = parser.ParseOneStmt("create table t (a int) partition by hash (a) partitions 4", "", "")
+	c.Assert(err, IsNil)
+	part = stmt.(*ast.CreateTableStmt).Partition
+	c.Assert(part.Tp, Equals, model.PartitionTypeHash)
+	c.Assert(part.Num, Equals, uint64(4))
+	c.Assert(part.Definitions, HasLen, 0)
+
+	stmt, err = parser.ParseOneStmt("create table t (a int) partition by key (a) partitions 4", "", "")
+	c.Assert(err, IsNil)
+	part = stmt.(*ast.CreateTableStmt).Partition
+	c.Assert(part.Tp, Equals, model.PartitionTypeKey)
+	c.Assert(part.ColumnNames, HasLen, 1)
+	c.Assert(part.ColumnNames[0].Name.L, Equals, "a")
+	c.Assert(part.Num, Equals, uint64(4))
+
+	stmt, err = parser.ParseOneStmt("alter table t drop partition p0, P1", "", "")
+	c.Assert(err, IsNil)
+	spec := stmt.(*ast.AlterTableStmt).Specs[0]
+	c.Assert(spec.Tp, Equals, ast.AlterTableDropPartition)
+	c.Assert(spec.PartitionNames, DeepEquals, []model.CIStr{model.NewCIStr("p0"), model.NewCIStr("P1")})
+}
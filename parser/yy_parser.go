@@ -17,12 +17,14 @@ import (
 	"math"
 	"regexp"
 	"strconv"
+	"strings"
 	"unicode"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/auth"
 	"github.com/pingcap/tidb/util/hack"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -240,3 +242,80 @@ func getUint64FromNUM(num interface{}) uint64 {
 	}
 	return 0
 }
+
+// roleOrPriv is an element of the list following GRANT or REVOKE. Whether the list
+// is made of privileges or roles can only be decided by the keyword after it: "ON"
+// means privileges and "TO"/"FROM" means roles.
+type roleOrPriv struct {
+	// symbol is set for a bare identifier, which is either a role or a privilege
+	// whose name is not a reserved keyword.
+	symbol string
+	priv   *ast.PrivElem
+	role   *auth.RoleIdentity
+}
+
+// privNotReserved is the privileges whose names are not reserved keywords.
+var privNotReserved = map[string]mysql.PrivilegeType{
+	"EXECUTE": mysql.ExecutePriv,
+	"PROCESS": mysql.ProcessPriv,
+	"SUPER":   mysql.SuperPriv,
+}
+
+func (r *roleOrPriv) toPriv() (*ast.PrivElem, bool) {
+	if r.priv != nil {
+		return r.priv, true
+	}
+	if priv, ok := privNotReserved[strings.ToUpper(r.symbol)]; ok {
+		return &ast.PrivElem{Priv: priv}, true
+	}
+	return nil, false
+}
+
+func (r *roleOrPriv) toRole() (*auth.RoleIdentity, bool) {
+	if r.role != nil {
+		return r.role, true
+	}
+	if r.symbol != "" {
+		return &auth.RoleIdentity{Username: r.symbol, Hostname: "%"}, true
+	}
+	return nil, false
+}
+
+func convertToPrivs(l yyLexer, list []*roleOrPriv) ([]*ast.PrivElem, bool) {
+	privs := make([]*ast.PrivElem, 0, len(list))
+	for _, r := range list {
+		priv, ok := r.toPriv()
+		if !ok {
+			l.Errorf("Unknown privilege type.")
+			return nil, false
+		}
+		privs = append(privs, priv)
+	}
+	return privs, true
+}
+
+func convertToRoles(l yyLexer, list []*roleOrPriv) ([]*auth.RoleIdentity, bool) {
+	roles := make([]*auth.RoleIdentity, 0, len(list))
+	for _, r := range list {
+		role, ok := r.toRole()
+		if !ok {
+			l.Errorf("Privileges can not be granted to or revoked from users without ON clause.")
+			return nil, false
+		}
+		roles = append(roles, role)
+	}
+	return roles, true
+}
+
+func rolesToUsers(roles []*auth.RoleIdentity) []*auth.UserIdentity {
+	users := make([]*auth.UserIdentity, 0, len(roles))
+	for _, role := range roles {
+		users = append(users, &auth.UserIdentity{Username: role.Username, Hostname: role.Hostname})
+	}
+	return users
+}
+
+// isRoleNone checks whether the role list is the single keyword NONE.
+func isRoleNone(roles []*auth.RoleIdentity) bool {
+	return len(roles) == 1 && strings.EqualFold(roles[0].Username, "NONE") && roles[0].Hostname == "%"
+}
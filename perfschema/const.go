@@ -38,6 +38,7 @@ const (
 	TableStagesCurrent          = "EVENTS_STAGES_CURRENT"
 	TableStagesHistory          = "EVENTS_STAGES_HISTORY"
 	TableStagesHistoryLong      = "EVENTS_STAGES_HISTORY_LONG"
+	TableStmtsSummaryByDigest   = "EVENTS_STATEMENTS_SUMMARY_BY_DIGEST"
 )
 
 // PerfSchemaTables is a shortcut to involve all table names.
@@ -59,6 +60,7 @@ var PerfSchemaTables = []string{
 	TableStagesCurrent,
 	TableStagesHistory,
 	TableStagesHistoryLong,
+	TableStmtsSummaryByDigest,
 }
 
 // ColumnGlobalStatus contains the column name definitions for table global_status, same as MySQL.
@@ -708,3 +710,67 @@ var ColumnStagesHistoryLong = []string{
 	"NESTING_EVENT_ID",
 	"NESTING_EVENT_TYPE",
 }
+
+// ColumnStmtsSummaryByDigest contains the column name definitions for table events_statements_summary_by_digest, same as MySQL.
+//
+// CREATE TABLE if not exists performance_schema.events_statements_summary_by_digest (
+// 		SCHEMA_NAME		VARCHAR(64),
+// 		DIGEST		VARCHAR(32),
+// 		DIGEST_TEXT		LONGTEXT,
+// 		COUNT_STAR		BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_TIMER_WAIT		BIGINT(20) UNSIGNED NOT NULL,
+// 		MIN_TIMER_WAIT		BIGINT(20) UNSIGNED NOT NULL,
+// 		AVG_TIMER_WAIT		BIGINT(20) UNSIGNED NOT NULL,
+// 		MAX_TIMER_WAIT		BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_LOCK_TIME		BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_ERRORS		BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_WARNINGS		BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_ROWS_AFFECTED	BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_ROWS_SENT		BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_ROWS_EXAMINED	BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_CREATED_TMP_DISK_TABLES	BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_CREATED_TMP_TABLES	BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_SELECT_FULL_JOIN	BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_SELECT_FULL_RANGE_JOIN	BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_SELECT_RANGE	BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_SELECT_RANGE_CHECK	BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_SELECT_SCAN		BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_SORT_MERGE_PASSES	BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_SORT_RANGE		BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_SORT_ROWS		BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_SORT_SCAN		BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_NO_INDEX_USED	BIGINT(20) UNSIGNED NOT NULL,
+// 		SUM_NO_GOOD_INDEX_USED	BIGINT(20) UNSIGNED NOT NULL,
+// 		FIRST_SEEN		TIMESTAMP NOT NULL,
+// 		LAST_SEEN		TIMESTAMP NOT NULL);
+var ColumnStmtsSummaryByDigest = []string{
+	"SCHEMA_NAME",
+	"DIGEST",
+	"DIGEST_TEXT",
+	"COUNT_STAR",
+	"SUM_TIMER_WAIT",
+	"MIN_TIMER_WAIT",
+	"AVG_TIMER_WAIT",
+	"MAX_TIMER_WAIT",
+	"SUM_LOCK_TIME",
+	"SUM_ERRORS",
+	"SUM_WARNINGS",
+	"SUM_ROWS_AFFECTED",
+	"SUM_ROWS_SENT",
+	"SUM_ROWS_EXAMINED",
+	"SUM_CREATED_TMP_DISK_TABLES",
+	"SUM_CREATED_TMP_TABLES",
+	"SUM_SELECT_FULL_JOIN",
+	"SUM_SELECT_FULL_RANGE_JOIN",
+	"SUM_SELECT_RANGE",
+	"SUM_SELECT_RANGE_CHECK",
+	"SUM_SELECT_SCAN",
+	"SUM_SORT_MERGE_PASSES",
+	"SUM_SORT_RANGE",
+	"SUM_SORT_ROWS",
+	"SUM_SORT_SCAN",
+	"SUM_NO_INDEX_USED",
+	"SUM_NO_GOOD_INDEX_USED",
+	"FIRST_SEEN",
+	"LAST_SEEN",
+}
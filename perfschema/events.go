@@ -0,0 +1,617 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package perfschema
+
+import (
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// The sizes of the history and summary tables, same as the default values of MySQL.
+const (
+	eventsHistorySize     = 10
+	eventsHistoryLongSize = 10000
+	eventsDigestsSize     = 10000
+)
+
+// The max lengths in bytes of SQL_TEXT and DIGEST_TEXT, same as the default values of
+// performance_schema_max_sql_text_length and performance_schema_max_digest_length in MySQL.
+const (
+	maxSQLTextLength = 1024
+	maxDigestLength  = 1024
+)
+
+// truncatedMark is appended to the truncated texts.
+const truncatedMark = "..."
+
+// truncateText truncates s to at most maxLen bytes without splitting a character,
+// the truncated text is marked by truncatedMark.
+func truncateText(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	end := maxLen - len(truncatedMark)
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + truncatedMark
+}
+
+// Nesting event types.
+const (
+	NestingEventTransaction = "TRANSACTION"
+	NestingEventStatement   = "STATEMENT"
+)
+
+// Transaction states.
+const (
+	TxnStateActive     = "ACTIVE"
+	TxnStateCommitted  = "COMMITTED"
+	TxnStateRolledBack = "ROLLED BACK"
+)
+
+// StatementEvent is a row of the events_statements_* tables.
+type StatementEvent struct {
+	ThreadID      uint64
+	EventID       uint64
+	EndEventID    uint64
+	EventName     string
+	TimerStart    uint64
+	TimerEnd      uint64
+	SQLText       string
+	Digest        string
+	DigestText    string
+	CurrentSchema string
+	ErrNo         uint16
+	SQLState      string
+	MessageText   string
+	Errors        uint64
+	Warnings      uint64
+	RowsAffected  uint64
+	RowsSent      uint64
+	RowsExamined  uint64
+
+	NestingEventID   uint64
+	NestingEventType string
+}
+
+// TransactionEvent is a row of the events_transactions_* tables.
+type TransactionEvent struct {
+	ThreadID       uint64
+	EventID        uint64
+	EndEventID     uint64
+	State          string
+	TrxID          uint64
+	TimerStart     uint64
+	TimerEnd       uint64
+	IsolationLevel string
+	Autocommit     bool
+
+	NestingEventID   uint64
+	NestingEventType string
+}
+
+type digestKey struct {
+	schema string
+	digest string
+}
+
+// digestSummary is a row of the events_statements_summary_by_digest table.
+type digestSummary struct {
+	digestKey
+	digestText      string
+	count           uint64
+	sumTimerWait    uint64
+	minTimerWait    uint64
+	maxTimerWait    uint64
+	sumErrors       uint64
+	sumWarnings     uint64
+	sumRowsAffected uint64
+	sumRowsSent     uint64
+	sumRowsExamined uint64
+	firstSeen       time.Time
+	lastSeen        time.Time
+}
+
+func (s *digestSummary) add(ev *StatementEvent) {
+	now := time.Now()
+	timerWait := ev.TimerEnd - ev.TimerStart
+	if s.count == 0 {
+		s.firstSeen = now
+		s.minTimerWait = timerWait
+	}
+	s.count++
+	s.sumTimerWait += timerWait
+	if timerWait < s.minTimerWait {
+		s.minTimerWait = timerWait
+	}
+	if timerWait > s.maxTimerWait {
+		s.maxTimerWait = timerWait
+	}
+	s.sumErrors += ev.Errors
+	s.sumWarnings += ev.Warnings
+	s.sumRowsAffected += ev.RowsAffected
+	s.sumRowsSent += ev.RowsSent
+	s.sumRowsExamined += ev.RowsExamined
+	s.lastSeen = now
+}
+
+// serverStart is the start point of the event timers.
+var serverStart = time.Now()
+
+// timerNow returns the picoseconds elapsed since the server started, which is the unit of MySQL event timers.
+func timerNow() uint64 {
+	return uint64(time.Since(serverStart).Nanoseconds()) * 1000
+}
+
+// eventRing is a bounded buffer which keeps the latest added events.
+type eventRing struct {
+	events []interface{}
+	next   int
+}
+
+func newEventRing(size int) *eventRing {
+	return &eventRing{events: make([]interface{}, 0, size)}
+}
+
+func (r *eventRing) add(ev interface{}) {
+	if len(r.events) < cap(r.events) {
+		r.events = append(r.events, ev)
+		return
+	}
+	r.events[r.next] = ev
+	r.next = (r.next + 1) % len(r.events)
+}
+
+// all returns the events from the oldest to the latest.
+func (r *eventRing) all() []interface{} {
+	evs := make([]interface{}, 0, len(r.events))
+	evs = append(evs, r.events[r.next:]...)
+	return append(evs, r.events[:r.next]...)
+}
+
+// threadEvents holds the events of a thread, which is a connection in TiDB.
+// It's only updated by its own thread, so the statements of different threads don't contend for a lock.
+type threadEvents struct {
+	mu          sync.Mutex
+	lastEventID uint64
+	stmtCurrent *StatementEvent
+	stmtHistory *eventRing
+	txnCurrent  *TransactionEvent
+	txnHistory  *eventRing
+}
+
+// eventsRecorder records the statement and transaction events. The events kept in it are copies,
+// so the callers can go on changing their own events.
+// The current and history events are kept in the threads, mu protects the threads map and the
+// events shared by all the threads. mu is always acquired before the lock of a thread.
+type eventsRecorder struct {
+	mu              sync.RWMutex
+	threads         map[uint64]*threadEvents
+	stmtHistoryLong *eventRing
+	txnHistoryLong  *eventRing
+	digests         map[digestKey]*digestSummary
+	// digestsOverflow aggregates the statements whose digests can't be kept because the digests are full.
+	digestsOverflow *digestSummary
+}
+
+func newEventsRecorder() *eventsRecorder {
+	return &eventsRecorder{
+		threads:         make(map[uint64]*threadEvents),
+		stmtHistoryLong: newEventRing(eventsHistoryLongSize),
+		txnHistoryLong:  newEventRing(eventsHistoryLongSize),
+		digests:         make(map[digestKey]*digestSummary),
+	}
+}
+
+var recorder = newEventsRecorder()
+
+func (r *eventsRecorder) getThread(threadID uint64) *threadEvents {
+	r.mu.RLock()
+	t, ok := r.threads[threadID]
+	r.mu.RUnlock()
+	if ok {
+		return t
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok = r.threads[threadID]
+	if !ok {
+		t = &threadEvents{
+			stmtHistory: newEventRing(eventsHistorySize),
+			txnHistory:  newEventRing(eventsHistorySize),
+		}
+		r.threads[threadID] = t
+	}
+	return t
+}
+
+func (r *eventsRecorder) startStatement(ev *StatementEvent) {
+	ev.SQLText = truncateText(ev.SQLText, maxSQLTextLength)
+	t := r.getThread(ev.ThreadID)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastEventID++
+	ev.EventID = t.lastEventID
+	ev.EndEventID = 0
+	ev.TimerStart = timerNow()
+	ev.TimerEnd = 0
+	if t.txnCurrent != nil && t.txnCurrent.State == TxnStateActive {
+		ev.NestingEventID = t.txnCurrent.EventID
+		ev.NestingEventType = NestingEventTransaction
+	}
+	cpy := *ev
+	t.stmtCurrent = &cpy
+}
+
+func (r *eventsRecorder) endStatement(ev *StatementEvent) {
+	ev.SQLText = truncateText(ev.SQLText, maxSQLTextLength)
+	ev.DigestText = truncateText(ev.DigestText, maxDigestLength)
+	t := r.getThread(ev.ThreadID)
+	t.mu.Lock()
+	t.lastEventID++
+	ev.EndEventID = t.lastEventID
+	ev.TimerEnd = timerNow()
+	cpy := *ev
+	t.stmtCurrent = &cpy
+	t.stmtHistory.add(&cpy)
+	t.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stmtHistoryLong.add(&cpy)
+	if ev.Digest != "" {
+		r.getDigestSummary(ev).add(ev)
+	}
+}
+
+func (r *eventsRecorder) getDigestSummary(ev *StatementEvent) *digestSummary {
+	key := digestKey{schema: ev.CurrentSchema, digest: ev.Digest}
+	if s, ok := r.digests[key]; ok {
+		return s
+	}
+	if len(r.digests) >= eventsDigestsSize {
+		if r.digestsOverflow == nil {
+			r.digestsOverflow = &digestSummary{}
+		}
+		return r.digestsOverflow
+	}
+	s := &digestSummary{digestKey: key, digestText: ev.DigestText}
+	r.digests[key] = s
+	return s
+}
+
+func (r *eventsRecorder) startTransaction(ev *TransactionEvent) {
+	t := r.getThread(ev.ThreadID)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastEventID++
+	ev.EventID = t.lastEventID
+	ev.EndEventID = 0
+	ev.State = TxnStateActive
+	ev.TimerStart = timerNow()
+	ev.TimerEnd = 0
+	if t.stmtCurrent != nil && t.stmtCurrent.EndEventID == 0 {
+		ev.NestingEventID = t.stmtCurrent.EventID
+		ev.NestingEventType = NestingEventStatement
+	}
+	cpy := *ev
+	t.txnCurrent = &cpy
+}
+
+func (r *eventsRecorder) endTransaction(ev *TransactionEvent, committed bool) {
+	t := r.getThread(ev.ThreadID)
+	t.mu.Lock()
+	t.lastEventID++
+	ev.EndEventID = t.lastEventID
+	ev.TimerEnd = timerNow()
+	ev.State = TxnStateRolledBack
+	if committed {
+		ev.State = TxnStateCommitted
+	}
+	cpy := *ev
+	t.txnCurrent = &cpy
+	t.txnHistory.add(&cpy)
+	t.mu.Unlock()
+
+	r.mu.Lock()
+	r.txnHistoryLong.add(&cpy)
+	r.mu.Unlock()
+}
+
+func (r *eventsRecorder) closeThread(threadID uint64) {
+	r.mu.Lock()
+	delete(r.threads, threadID)
+	r.mu.Unlock()
+}
+
+// truncate removes all the rows of a events table, it returns false if the table can't be truncated.
+func (r *eventsRecorder) truncate(tableName string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch tableName {
+	case TableStmtsCurrent:
+		for _, t := range r.threads {
+			t.mu.Lock()
+			t.stmtCurrent = nil
+			t.mu.Unlock()
+		}
+	case TableStmtsHistory:
+		for _, t := range r.threads {
+			t.mu.Lock()
+			t.stmtHistory = newEventRing(eventsHistorySize)
+			t.mu.Unlock()
+		}
+	case TableStmtsHistoryLong:
+		r.stmtHistoryLong = newEventRing(eventsHistoryLongSize)
+	case TableTransCurrent:
+		for _, t := range r.threads {
+			t.mu.Lock()
+			t.txnCurrent = nil
+			t.mu.Unlock()
+		}
+	case TableTransHistory:
+		for _, t := range r.threads {
+			t.mu.Lock()
+			t.txnHistory = newEventRing(eventsHistorySize)
+			t.mu.Unlock()
+		}
+	case TableTransHistoryLong:
+		r.txnHistoryLong = newEventRing(eventsHistoryLongSize)
+	case TableStmtsSummaryByDigest:
+		r.digests = make(map[digestKey]*digestSummary)
+		r.digestsOverflow = nil
+	default:
+		return false
+	}
+	return true
+}
+
+// digestRows returns the rows of table events_statements_summary_by_digest.
+func (r *eventsRecorder) digestRows() [][]types.Datum {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rows := make([][]types.Datum, 0, len(r.digests)+1)
+	for _, s := range r.digests {
+		rows = append(rows, s.toDatums())
+	}
+	if r.digestsOverflow != nil {
+		rows = append(rows, r.digestsOverflow.toDatums())
+	}
+	return rows
+}
+
+// statementRows returns the rows of table events_statements_current, events_statements_history or
+// events_statements_history_long.
+func (r *eventsRecorder) statementRows(tableName string) [][]types.Datum {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var events []interface{}
+	switch tableName {
+	case TableStmtsCurrent:
+		for _, t := range r.threads {
+			t.mu.Lock()
+			if t.stmtCurrent != nil {
+				events = append(events, t.stmtCurrent)
+			}
+			t.mu.Unlock()
+		}
+	case TableStmtsHistory:
+		for _, t := range r.threads {
+			t.mu.Lock()
+			events = append(events, t.stmtHistory.all()...)
+			t.mu.Unlock()
+		}
+	case TableStmtsHistoryLong:
+		events = r.stmtHistoryLong.all()
+	}
+	rows := make([][]types.Datum, 0, len(events))
+	for _, ev := range events {
+		rows = append(rows, ev.(*StatementEvent).toDatums())
+	}
+	return rows
+}
+
+// transactionRows returns the rows of table events_transactions_current, events_transactions_history or
+// events_transactions_history_long.
+func (r *eventsRecorder) transactionRows(tableName string) [][]types.Datum {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var events []interface{}
+	switch tableName {
+	case TableTransCurrent:
+		for _, t := range r.threads {
+			t.mu.Lock()
+			if t.txnCurrent != nil {
+				events = append(events, t.txnCurrent)
+			}
+			t.mu.Unlock()
+		}
+	case TableTransHistory:
+		for _, t := range r.threads {
+			t.mu.Lock()
+			events = append(events, t.txnHistory.all()...)
+			t.mu.Unlock()
+		}
+	case TableTransHistoryLong:
+		events = r.txnHistoryLong.all()
+	}
+	rows := make([][]types.Datum, 0, len(events))
+	for _, ev := range events {
+		rows = append(rows, ev.(*TransactionEvent).toDatums())
+	}
+	return rows
+}
+
+func uintOrNull(v uint64) types.Datum {
+	if v == 0 {
+		return types.Datum{}
+	}
+	return types.NewUintDatum(v)
+}
+
+func stringOrNull(s string) types.Datum {
+	if s == "" {
+		return types.Datum{}
+	}
+	return types.NewStringDatum(s)
+}
+
+func enumOrNull(elems []string, name string) types.Datum {
+	var d types.Datum
+	if e, err := types.ParseEnumName(elems, name); err == nil {
+		d.SetMysqlEnum(e)
+	}
+	return d
+}
+
+var (
+	nestingEventTypes = []string{NestingEventTransaction, NestingEventStatement, "STAGE"}
+	txnStates         = []string{TxnStateActive, TxnStateCommitted, TxnStateRolledBack}
+	txnAccessModes    = []string{"READ ONLY", "READ WRITE"}
+	yesOrNo           = []string{"YES", "NO"}
+)
+
+func (ev *StatementEvent) toDatums() []types.Datum {
+	timerWait := uint64(0)
+	if ev.EndEventID != 0 {
+		timerWait = ev.TimerEnd - ev.TimerStart
+	}
+	var errNo types.Datum
+	if ev.EndEventID != 0 {
+		errNo = types.NewIntDatum(int64(ev.ErrNo))
+	}
+	return []types.Datum{
+		types.NewUintDatum(ev.ThreadID),
+		types.NewUintDatum(ev.EventID),
+		uintOrNull(ev.EndEventID),
+		types.NewStringDatum(ev.EventName),
+		{}, // SOURCE
+		types.NewUintDatum(ev.TimerStart),
+		uintOrNull(ev.TimerEnd),
+		uintOrNull(timerWait),
+		types.NewUintDatum(0), // LOCK_TIME
+		types.NewStringDatum(ev.SQLText),
+		stringOrNull(ev.Digest),
+		stringOrNull(ev.DigestText),
+		stringOrNull(ev.CurrentSchema),
+		{}, // OBJECT_TYPE
+		{}, // OBJECT_SCHEMA
+		{}, // OBJECT_NAME
+		{}, // OBJECT_INSTANCE_BEGIN
+		errNo,
+		stringOrNull(ev.SQLState),
+		stringOrNull(ev.MessageText),
+		types.NewUintDatum(ev.Errors),
+		types.NewUintDatum(ev.Warnings),
+		types.NewUintDatum(ev.RowsAffected),
+		types.NewUintDatum(ev.RowsSent),
+		types.NewUintDatum(ev.RowsExamined),
+		types.NewUintDatum(0), // CREATED_TMP_DISK_TABLES
+		types.NewUintDatum(0), // CREATED_TMP_TABLES
+		types.NewUintDatum(0), // SELECT_FULL_JOIN
+		types.NewUintDatum(0), // SELECT_FULL_RANGE_JOIN
+		types.NewUintDatum(0), // SELECT_RANGE
+		types.NewUintDatum(0), // SELECT_RANGE_CHECK
+		types.NewUintDatum(0), // SELECT_SCAN
+		types.NewUintDatum(0), // SORT_MERGE_PASSES
+		types.NewUintDatum(0), // SORT_RANGE
+		types.NewUintDatum(0), // SORT_ROWS
+		types.NewUintDatum(0), // SORT_SCAN
+		types.NewUintDatum(0), // NO_INDEX_USED
+		types.NewUintDatum(0), // NO_GOOD_INDEX_USED
+		uintOrNull(ev.NestingEventID),
+		enumOrNull(nestingEventTypes, ev.NestingEventType),
+		types.NewIntDatum(0), // NESTING_EVENT_LEVEL
+	}
+}
+
+func timestampDatum(t time.Time) types.Datum {
+	return types.NewTimeDatum(types.Time{Time: types.FromGoTime(t), Type: mysql.TypeTimestamp})
+}
+
+func (s *digestSummary) toDatums() []types.Datum {
+	return []types.Datum{
+		stringOrNull(s.schema),
+		stringOrNull(s.digest),
+		stringOrNull(s.digestText),
+		types.NewUintDatum(s.count),
+		types.NewUintDatum(s.sumTimerWait),
+		types.NewUintDatum(s.minTimerWait),
+		types.NewUintDatum(s.sumTimerWait / s.count),
+		types.NewUintDatum(s.maxTimerWait),
+		types.NewUintDatum(0), // SUM_LOCK_TIME
+		types.NewUintDatum(s.sumErrors),
+		types.NewUintDatum(s.sumWarnings),
+		types.NewUintDatum(s.sumRowsAffected),
+		types.NewUintDatum(s.sumRowsSent),
+		types.NewUintDatum(s.sumRowsExamined),
+		types.NewUintDatum(0), // SUM_CREATED_TMP_DISK_TABLES
+		types.NewUintDatum(0), // SUM_CREATED_TMP_TABLES
+		types.NewUintDatum(0), // SUM_SELECT_FULL_JOIN
+		types.NewUintDatum(0), // SUM_SELECT_FULL_RANGE_JOIN
+		types.NewUintDatum(0), // SUM_SELECT_RANGE
+		types.NewUintDatum(0), // SUM_SELECT_RANGE_CHECK
+		types.NewUintDatum(0), // SUM_SELECT_SCAN
+		types.NewUintDatum(0), // SUM_SORT_MERGE_PASSES
+		types.NewUintDatum(0), // SUM_SORT_RANGE
+		types.NewUintDatum(0), // SUM_SORT_ROWS
+		types.NewUintDatum(0), // SUM_SORT_SCAN
+		types.NewUintDatum(0), // SUM_NO_INDEX_USED
+		types.NewUintDatum(0), // SUM_NO_GOOD_INDEX_USED
+		timestampDatum(s.firstSeen),
+		timestampDatum(s.lastSeen),
+	}
+}
+
+func (ev *TransactionEvent) toDatums() []types.Datum {
+	timerWait := uint64(0)
+	if ev.EndEventID != 0 {
+		timerWait = ev.TimerEnd - ev.TimerStart
+	}
+	autocommit := "NO"
+	if ev.Autocommit {
+		autocommit = "YES"
+	}
+	return []types.Datum{
+		types.NewUintDatum(ev.ThreadID),
+		types.NewUintDatum(ev.EventID),
+		uintOrNull(ev.EndEventID),
+		types.NewStringDatum("transaction"),
+		enumOrNull(txnStates, ev.State),
+		uintOrNull(ev.TrxID),
+		{}, // GTID
+		{}, // XID_FORMAT_ID
+		{}, // XID_GTRID
+		{}, // XID_BQUAL
+		{}, // XA_STATE
+		{}, // SOURCE
+		types.NewUintDatum(ev.TimerStart),
+		uintOrNull(ev.TimerEnd),
+		uintOrNull(timerWait),
+		enumOrNull(txnAccessModes, "READ WRITE"),
+		stringOrNull(ev.IsolationLevel),
+		enumOrNull(yesOrNo, autocommit),
+		types.NewUintDatum(0), // NUMBER_OF_SAVEPOINTS
+		types.NewUintDatum(0), // NUMBER_OF_ROLLBACK_TO_SAVEPOINT
+		types.NewUintDatum(0), // NUMBER_OF_RELEASE_SAVEPOINT
+		{},                    // OBJECT_INSTANCE_BEGIN
+		uintOrNull(ev.NestingEventID),
+		enumOrNull(nestingEventTypes, ev.NestingEventType),
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package perfschema
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/pingcap/check"
+)
+
+func (*testSuite) TestEventRing(c *C) {
+	r := newEventRing(3)
+	c.Assert(r.all(), HasLen, 0)
+	for i := 1; i <= 5; i++ {
+		r.add(i)
+	}
+	c.Assert(r.all(), DeepEquals, []interface{}{3, 4, 5})
+}
+
+func (*testSuite) TestEventsRecorder(c *C) {
+	r := newEventsRecorder()
+	txn := &TransactionEvent{ThreadID: 1, TrxID: 100}
+	r.startTransaction(txn)
+	c.Assert(txn.EventID, Equals, uint64(1))
+
+	for i := 0; i < eventsHistorySize+1; i++ {
+		stmt := &StatementEvent{ThreadID: 1, SQLText: "select 1"}
+		r.startStatement(stmt)
+		c.Assert(stmt.NestingEventID, Equals, txn.EventID)
+		c.Assert(r.statementRows(TableStmtsCurrent)[0][2].IsNull(), IsTrue)
+		r.endStatement(stmt)
+		c.Assert(stmt.EndEventID, Equals, stmt.EventID+1)
+	}
+	c.Assert(r.statementRows(TableStmtsCurrent), HasLen, 1)
+	c.Assert(r.statementRows(TableStmtsHistory), HasLen, eventsHistorySize)
+	c.Assert(r.statementRows(TableStmtsHistoryLong), HasLen, eventsHistorySize+1)
+
+	r.endTransaction(txn, false)
+	rows := r.transactionRows(TableTransHistory)
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0][4].GetMysqlEnum().String(), Equals, TxnStateRolledBack)
+	c.Assert(rows[0][5].GetUint64(), Equals, uint64(100))
+
+	r.closeThread(1)
+	c.Assert(r.statementRows(TableStmtsHistory), HasLen, 0)
+	c.Assert(r.transactionRows(TableTransHistoryLong), HasLen, 1)
+}
+
+func (*testSuite) TestDigestSummary(c *C) {
+	r := newEventsRecorder()
+	for i := 0; i < 3; i++ {
+		stmt := &StatementEvent{ThreadID: 1, CurrentSchema: "test", Digest: "d1", DigestText: "SELECT ?", RowsSent: 1}
+		r.startStatement(stmt)
+		r.endStatement(stmt)
+	}
+	rows := r.digestRows()
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0][1].GetString(), Equals, "d1")
+	c.Assert(rows[0][3].GetUint64(), Equals, uint64(3))
+	c.Assert(rows[0][12].GetUint64(), Equals, uint64(3))
+
+	// The statements are aggregated into the row with NULL digest when the digests are full.
+	for i := 0; i < eventsDigestsSize+1; i++ {
+		r.endStatement(&StatementEvent{ThreadID: 1, Digest: fmt.Sprintf("%d", i)})
+	}
+	rows = r.digestRows()
+	c.Assert(rows, HasLen, eventsDigestsSize+1)
+	c.Assert(rows[len(rows)-1][1].IsNull(), IsTrue)
+	c.Assert(rows[len(rows)-1][3].GetUint64(), Equals, uint64(2))
+
+	c.Assert(r.truncate(TableStmtsSummaryByDigest), IsTrue)
+	c.Assert(r.digestRows(), HasLen, 0)
+	c.Assert(r.truncate(TableSetupActors), IsFalse)
+}
+
+func (*testSuite) TestTruncateText(c *C) {
+	c.Assert(truncateText("select 1", 10), Equals, "select 1")
+	c.Assert(truncateText("select 12345", 10), Equals, "select ...")
+	// The characters aren't split.
+	c.Assert(truncateText("select '中文'", 12), Equals, "select '...")
+
+	r := newEventsRecorder()
+	sql := "select '" + strings.Repeat("a", maxSQLTextLength) + "'"
+	stmt := &StatementEvent{ThreadID: 1, SQLText: sql, Digest: "d1", DigestText: sql}
+	r.startStatement(stmt)
+	r.endStatement(stmt)
+	rows := r.statementRows(TableStmtsHistory)
+	c.Assert(rows, HasLen, 1)
+	text := rows[0][9].GetString()
+	c.Assert(text, HasLen, maxSQLTextLength)
+	c.Assert(strings.HasSuffix(text, truncatedMark), IsTrue)
+	text = r.digestRows()[0][2].GetString()
+	c.Assert(text, HasLen, maxDigestLength)
+	c.Assert(strings.HasSuffix(text, truncatedMark), IsTrue)
+}
@@ -197,6 +197,38 @@ var stagesCurrentCols = []columnInfo{
 	{mysql.TypeEnum, -1, 0, nil, []string{"TRANSACTION", "STATEMENT", "STAGE"}},
 }
 
+var stmtsSummaryByDigestCols = []columnInfo{
+	{mysql.TypeVarchar, 64, 0, nil, nil},
+	{mysql.TypeVarchar, 32, 0, nil, nil},
+	{mysql.TypeLongBlob, -1, 0, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeTimestamp, 19, mysql.NotNullFlag, nil, nil},
+	{mysql.TypeTimestamp, 19, mysql.NotNullFlag, nil, nil},
+}
+
 func (ps *perfSchema) buildTables() {
 	tbls := make([]*model.TableInfo, 0, len(ps.tables))
 	dbID := autoid.GenLocalSchemaID()
@@ -211,7 +243,8 @@ func (ps *perfSchema) buildTables() {
 		var tbl table.Table
 		switch name {
 		//@TODO in the future, we need to add many VirtualTable, we may need to add new type for these tables.
-		case TableSessionStatus, TableGlobalStatus:
+		case TableSessionStatus, TableGlobalStatus, TableStmtsCurrent, TableStmtsHistory, TableStmtsHistoryLong,
+			TableTransCurrent, TableTransHistory, TableTransHistoryLong, TableStmtsSummaryByDigest:
 			tbl = createVirtualTable(meta, name)
 		default:
 			tbl = tables.MemoryTableFromMeta(alloc, meta)
@@ -320,6 +353,7 @@ func (ps *perfSchema) initialize() {
 		stagesCurrentCols,
 		stagesCurrentCols, // same as above
 		stagesCurrentCols, // same as above
+		stmtsSummaryByDigestCols,
 	}
 
 	allColNames := [][]string{
@@ -334,12 +368,13 @@ func (ps *perfSchema) initialize() {
 		ColumnStmtsHistory,
 		ColumnStmtsHistoryLong,
 		ColumnPreparedStmtsInstances,
-		ColumnStmtsCurrent,
-		ColumnStmtsHistory,
-		ColumnStmtsHistoryLong,
+		ColumnTransCurrent,
+		ColumnTransHistory,
+		ColumnTransHistoryLong,
 		ColumnStagesCurrent,
 		ColumnStagesHistory,
 		ColumnStagesHistoryLong,
+		ColumnStmtsSummaryByDigest,
 	}
 
 	// initialize all table, column and result field definitions
@@ -14,6 +14,8 @@
 package perfschema
 
 import (
+	"strings"
+
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/table"
 )
@@ -42,3 +44,34 @@ func GetDBMeta() *model.DBInfo {
 func GetTable(name string) (table.Table, bool) {
 	return handle.GetTable(name)
 }
+
+// StartStatement records the start of a statement event, the event ID and start timer of ev are set.
+func StartStatement(ev *StatementEvent) {
+	recorder.startStatement(ev)
+}
+
+// EndStatement records the end of a statement event into the history tables.
+func EndStatement(ev *StatementEvent) {
+	recorder.endStatement(ev)
+}
+
+// StartTransaction records the start of a transaction event, the event ID and start timer of ev are set.
+func StartTransaction(ev *TransactionEvent) {
+	recorder.startTransaction(ev)
+}
+
+// EndTransaction records the end of a transaction event into the history tables.
+func EndTransaction(ev *TransactionEvent, committed bool) {
+	recorder.endTransaction(ev, committed)
+}
+
+// CloseThread removes the current and history events of a thread when its connection is closed.
+func CloseThread(threadID uint64) {
+	recorder.closeThread(threadID)
+}
+
+// TruncateTable removes all the rows of a performance_schema table which records events,
+// it returns false if the table can't be truncated.
+func TruncateTable(name string) bool {
+	return recorder.truncate(strings.ToUpper(name))
+}
@@ -73,6 +73,71 @@ func (ds *statusDataSource) Cols() []*table.Column {
 	return ds.cols
 }
 
+// statementsDataSource is the data source of the events_statements_* tables.
+type statementsDataSource struct {
+	meta      *model.TableInfo
+	cols      []*table.Column
+	tableName string
+}
+
+// GetRows implements the interface of VirtualDataSource.
+func (ds *statementsDataSource) GetRows(ctx context.Context) ([][]types.Datum, error) {
+	return recorder.statementRows(ds.tableName), nil
+}
+
+// Meta implements the interface of VirtualDataSource.
+func (ds *statementsDataSource) Meta() *model.TableInfo {
+	return ds.meta
+}
+
+// Cols implements the interface of VirtualDataSource.
+func (ds *statementsDataSource) Cols() []*table.Column {
+	return ds.cols
+}
+
+// transactionsDataSource is the data source of the events_transactions_* tables.
+type transactionsDataSource struct {
+	meta      *model.TableInfo
+	cols      []*table.Column
+	tableName string
+}
+
+// GetRows implements the interface of VirtualDataSource.
+func (ds *transactionsDataSource) GetRows(ctx context.Context) ([][]types.Datum, error) {
+	return recorder.transactionRows(ds.tableName), nil
+}
+
+// Meta implements the interface of VirtualDataSource.
+func (ds *transactionsDataSource) Meta() *model.TableInfo {
+	return ds.meta
+}
+
+// Cols implements the interface of VirtualDataSource.
+func (ds *transactionsDataSource) Cols() []*table.Column {
+	return ds.cols
+}
+
+// digestsDataSource is the data source of the events_statements_summary_by_digest table.
+type digestsDataSource struct {
+	meta *model.TableInfo
+	cols []*table.Column
+}
+
+// GetRows implements the interface of VirtualDataSource.
+func (ds *digestsDataSource) GetRows(ctx context.Context) ([][]types.Datum, error) {
+	return recorder.digestRows(), nil
+}
+
+// Meta implements the interface of VirtualDataSource.
+func (ds *digestsDataSource) Meta() *model.TableInfo {
+	return ds.meta
+}
+
+// Cols implements the interface of VirtualDataSource.
+func (ds *digestsDataSource) Cols() []*table.Column {
+	return ds.cols
+}
+
 func createVirtualDataSource(tableName string, meta *model.TableInfo) (tables.VirtualDataSource, error) {
 	columns := make([]*table.Column, 0, len(meta.Columns))
 	for _, colInfo := range meta.Columns {
@@ -85,6 +150,12 @@ func createVirtualDataSource(tableName string, meta *model.TableInfo) (tables.Vi
 		return &statusDataSource{meta: meta, cols: columns, globalScope: false}, nil
 	case TableGlobalStatus:
 		return &statusDataSource{meta: meta, cols: columns, globalScope: true}, nil
+	case TableStmtsCurrent, TableStmtsHistory, TableStmtsHistoryLong:
+		return &statementsDataSource{meta: meta, cols: columns, tableName: tableName}, nil
+	case TableTransCurrent, TableTransHistory, TableTransHistoryLong:
+		return &transactionsDataSource{meta: meta, cols: columns, tableName: tableName}, nil
+	case TableStmtsSummaryByDigest:
+		return &digestsDataSource{meta: meta, cols: columns}, nil
 	default:
 		return nil, errors.New("can't find table named by " + tableName)
 	}
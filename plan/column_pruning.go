@@ -119,6 +119,39 @@ func (p *LogicalAggregation) PruneColumns(parentUsedCols []*expression.Column) {
 	child.PruneColumns(selfUsedCols)
 }
 
+// PruneColumns implements LogicalPlan interface.
+func (p *LogicalWindow) PruneColumns(parentUsedCols []*expression.Column) {
+	child := p.children[0].(LogicalPlan)
+	childLen := child.Schema().Len()
+	used := getUsedList(parentUsedCols, p.schema)
+	for i := len(used) - 1; i >= childLen; i-- {
+		if !used[i] {
+			p.schema.Columns = append(p.schema.Columns[:i], p.schema.Columns[i+1:]...)
+			p.WindowFuncDescs = append(p.WindowFuncDescs[:i-childLen], p.WindowFuncDescs[i-childLen+1:]...)
+		}
+	}
+	var selfUsedCols []*expression.Column
+	for _, col := range parentUsedCols {
+		if child.Schema().Contains(col) {
+			selfUsedCols = append(selfUsedCols, col)
+		}
+	}
+	for _, desc := range p.WindowFuncDescs {
+		for _, arg := range desc.Args {
+			selfUsedCols = append(selfUsedCols, expression.ExtractColumns(arg)...)
+		}
+	}
+	for _, item := range p.sortItems() {
+		selfUsedCols = append(selfUsedCols, expression.ExtractColumns(item.Expr)...)
+	}
+	child.PruneColumns(selfUsedCols)
+	schema := child.Schema().Clone()
+	for _, col := range p.schema.Columns[childLen:] {
+		schema.Append(col)
+	}
+	p.SetSchema(schema)
+}
+
 // PruneColumns implements LogicalPlan interface.
 func (p *Sort) PruneColumns(parentUsedCols []*expression.Column) {
 	child := p.children[0].(LogicalPlan)
@@ -200,6 +233,19 @@ func (p *DataSource) pruneUnionScanSchema(usedMask []bool) {
 func (p *TableDual) PruneColumns(_ []*expression.Column) {
 }
 
+// PruneColumns implements LogicalPlan interface.
+// The rows of the recursive part are fed back to CTETable by position, so no column can be pruned.
+func (p *RecursiveCTE) PruneColumns(_ []*expression.Column) {
+	for _, c := range p.Children() {
+		child := c.(LogicalPlan)
+		child.PruneColumns(child.Schema().Columns)
+	}
+}
+
+// PruneColumns implements LogicalPlan interface.
+func (p *CTETable) PruneColumns(_ []*expression.Column) {
+}
+
 // PruneColumns implements LogicalPlan interface.
 func (p *Exists) PruneColumns(parentUsedCols []*expression.Column) {
 	p.children[0].(LogicalPlan).PruneColumns(nil)
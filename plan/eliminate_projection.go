@@ -122,6 +122,8 @@ func (pe *projectionEliminater) eliminate(p LogicalPlan, replace map[string]*exp
 	childFlag := canEliminate
 	if _, isUnion := p.(*Union); isUnion {
 		childFlag = false
+	} else if _, isCTE := p.(*RecursiveCTE); isCTE {
+		childFlag = false
 	} else if _, isAgg := p.(*LogicalAggregation); isAgg || isProj {
 		childFlag = true
 	}
@@ -133,6 +135,13 @@ func (pe *projectionEliminater) eliminate(p LogicalPlan, replace map[string]*exp
 	switch p.(type) {
 	case *Sort, *TopN, *Limit, *Selection, *MaxOneRow, *Update, *SelectLock:
 		p.SetSchema(p.Children()[0].Schema())
+	case *LogicalWindow:
+		window := p.(*LogicalWindow)
+		schema := window.children[0].Schema().Clone()
+		for _, col := range window.schema.Columns[window.schema.Len()-len(window.WindowFuncDescs):] {
+			schema.Append(col)
+		}
+		window.SetSchema(schema)
 	case *LogicalJoin, *LogicalApply:
 		var joinTp JoinType
 		if _, isApply := p.(*LogicalApply); isApply {
@@ -226,6 +235,17 @@ func (p *Sort) replaceExprColumns(replace map[string]*expression.Column) {
 	}
 }
 
+func (p *LogicalWindow) replaceExprColumns(replace map[string]*expression.Column) {
+	for _, desc := range p.WindowFuncDescs {
+		for _, arg := range desc.Args {
+			resolveExprAndReplace(arg, replace)
+		}
+	}
+	for _, item := range p.sortItems() {
+		resolveExprAndReplace(item.Expr, replace)
+	}
+}
+
 func (p *TopN) replaceExprColumns(replace map[string]*expression.Column) {
 	for _, byItem := range p.ByItems {
 		resolveExprAndReplace(byItem.Expr, replace)
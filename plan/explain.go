@@ -19,6 +19,7 @@ import (
 
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/expression/aggregation"
+	"github.com/pingcap/tidb/model"
 )
 
 func setParents4FinalPlan(plan PhysicalPlan) {
@@ -70,6 +71,7 @@ func (p *PhysicalIndexScan) ExplainInfo() string {
 		tblName = p.TableAsName.O
 	}
 	buffer.WriteString(fmt.Sprintf("table:%s", tblName))
+	explainPartitions(buffer, p.Table, p.PartitionIDs)
 	if len(p.Index.Columns) > 0 {
 		buffer.WriteString(", index:")
 		for i, idxCol := range p.Index.Columns {
@@ -103,6 +105,7 @@ func (p *PhysicalTableScan) ExplainInfo() string {
 		tblName = p.TableAsName.O
 	}
 	buffer.WriteString(fmt.Sprintf("table:%s", tblName))
+	explainPartitions(buffer, p.Table, p.PartitionIDs)
 	if p.pkCol != nil {
 		buffer.WriteString(fmt.Sprintf(", pk col:%s", p.pkCol.ExplainInfo()))
 	}
@@ -122,6 +125,25 @@ func (p *PhysicalTableScan) ExplainInfo() string {
 	return buffer.String()
 }
 
+// explainPartitions writes the names of the partitions to read if the table is partitioned.
+func explainPartitions(buffer *bytes.Buffer, tbl *model.TableInfo, partitionIDs []int64) {
+	if tbl.Partition == nil {
+		return
+	}
+	buffer.WriteString(", partition:")
+	for i, id := range partitionIDs {
+		for _, def := range tbl.Partition.Definitions {
+			if def.ID == id {
+				buffer.WriteString(def.Name.O)
+				break
+			}
+		}
+		if i+1 < len(partitionIDs) {
+			buffer.WriteString(",")
+		}
+	}
+}
+
 // ExplainInfo implements PhysicalPlan interface.
 func (p *PhysicalTableReader) ExplainInfo() string {
 	return fmt.Sprintf("data:%s", p.tablePlan.ExplainID())
@@ -160,17 +182,21 @@ func (p *TableDual) ExplainInfo() string {
 // ExplainInfo implements PhysicalPlan interface.
 func (p *Sort) ExplainInfo() string {
 	buffer := bytes.NewBufferString("")
-	for i, item := range p.ByItems {
+	explainByItems(buffer, p.ByItems)
+	return buffer.String()
+}
+
+func explainByItems(buffer *bytes.Buffer, byItems []*ByItems) {
+	for i, item := range byItems {
 		order := "asc"
 		if item.Desc {
 			order = "desc"
 		}
 		buffer.WriteString(fmt.Sprintf("%s:%s", item.Expr.ExplainInfo(), order))
-		if i+1 < len(p.ByItems) {
+		if i+1 < len(byItems) {
 			buffer.WriteString(", ")
 		}
 	}
-	return buffer.String()
 }
 
 // ExplainInfo implements PhysicalPlan interface.
@@ -195,6 +221,39 @@ func (p *PhysicalAggregation) ExplainInfo() string {
 	return buffer.String()
 }
 
+// ExplainInfo implements PhysicalPlan interface.
+func (p *RecursiveCTE) ExplainInfo() string {
+	return fmt.Sprintf("distinct:%v", p.Distinct)
+}
+
+// ExplainInfo implements PhysicalPlan interface.
+func (p *CTETable) ExplainInfo() string {
+	return fmt.Sprintf("cte:%s_%d", TypeRecursiveCTE, p.CTEID)
+}
+
+// ExplainInfo implements PhysicalPlan interface.
+func (p *PhysicalWindow) ExplainInfo() string {
+	buffer := bytes.NewBufferString("funcs:")
+	for i, desc := range p.WindowFuncDescs {
+		buffer.WriteString(fmt.Sprintf("%s(%s)", desc.Name, expression.ExplainExpressionList(desc.Args)))
+		if i+1 < len(p.WindowFuncDescs) {
+			buffer.WriteString(", ")
+		}
+	}
+	if len(p.PartitionBy) > 0 {
+		buffer.WriteString(", partition by:")
+		explainByItems(buffer, p.PartitionBy)
+	}
+	if len(p.OrderBy) > 0 {
+		buffer.WriteString(", order by:")
+		explainByItems(buffer, p.OrderBy)
+	}
+	if p.Frame != nil {
+		buffer.WriteString(fmt.Sprintf(", frame:%s", p.Frame))
+	}
+	return buffer.String()
+}
+
 // ExplainInfo implements PhysicalPlan interface.
 func (p *PhysicalApply) ExplainInfo() string {
 	buffer := bytes.NewBufferString(p.PhysicalJoin.ExplainInfo())
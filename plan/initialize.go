@@ -31,6 +31,8 @@ const (
 	TypeStreamAgg = "StreamAgg"
 	// TypeHashAgg is the type of HashAgg.
 	TypeHashAgg = "HashAgg"
+	// TypeWindow is the type of Window.
+	TypeWindow = "Window"
 	// TypeCache is the type of cache.
 	TypeCache = "Cache"
 	// TypeShow is the type of show.
@@ -39,6 +41,10 @@ const (
 	TypeJoin = "Join"
 	// TypeUnion is the type of Union.
 	TypeUnion = "Union"
+	// TypeRecursiveCTE is the type of RecursiveCTE.
+	TypeRecursiveCTE = "RecursiveCTE"
+	// TypeCTETable is the type of CTETable.
+	TypeCTETable = "CTETable"
 	// TypeTableScan is the type of TableScan.
 	TypeTableScan = "TableScan"
 	// TypeMemTableScan is the type of TableScan.
@@ -93,6 +99,12 @@ func (p LogicalAggregation) init(allocator *idAllocator, ctx context.Context) *L
 	return &p
 }
 
+func (p LogicalWindow) init(allocator *idAllocator, ctx context.Context) *LogicalWindow {
+	p.basePlan = newBasePlan(TypeWindow, allocator, ctx, &p)
+	p.baseLogicalPlan = newBaseLogicalPlan(p.basePlan)
+	return &p
+}
+
 func (p LogicalJoin) init(allocator *idAllocator, ctx context.Context) *LogicalJoin {
 	p.basePlan = newBasePlan(TypeJoin, allocator, ctx, &p)
 	p.baseLogicalPlan = newBaseLogicalPlan(p.basePlan)
@@ -132,6 +144,20 @@ func (p Union) init(allocator *idAllocator, ctx context.Context) *Union {
 	return &p
 }
 
+func (p RecursiveCTE) init(allocator *idAllocator, ctx context.Context) *RecursiveCTE {
+	p.basePlan = newBasePlan(TypeRecursiveCTE, allocator, ctx, &p)
+	p.baseLogicalPlan = newBaseLogicalPlan(p.basePlan)
+	p.basePhysicalPlan = newBasePhysicalPlan(p.basePlan)
+	return &p
+}
+
+func (p CTETable) init(allocator *idAllocator, ctx context.Context) *CTETable {
+	p.basePlan = newBasePlan(TypeCTETable, allocator, ctx, &p)
+	p.baseLogicalPlan = newBaseLogicalPlan(p.basePlan)
+	p.basePhysicalPlan = newBasePhysicalPlan(p.basePlan)
+	return &p
+}
+
 func (p Sort) init(allocator *idAllocator, ctx context.Context) *Sort {
 	p.basePlan = newBasePlan(TypeSort, allocator, ctx, &p)
 	p.baseLogicalPlan = newBaseLogicalPlan(p.basePlan)
@@ -249,6 +275,12 @@ func (p PhysicalMergeJoin) init(allocator *idAllocator, ctx context.Context) *Ph
 	return &p
 }
 
+func (p PhysicalWindow) init(allocator *idAllocator, ctx context.Context) *PhysicalWindow {
+	p.basePlan = newBasePlan(TypeWindow, allocator, ctx, &p)
+	p.basePhysicalPlan = newBasePhysicalPlan(p.basePlan)
+	return &p
+}
+
 func (p PhysicalAggregation) init(allocator *idAllocator, ctx context.Context) *PhysicalAggregation {
 	tp := TypeHashAgg
 	if p.AggType == StreamedAgg {
@@ -14,6 +14,7 @@
 package plan
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 	"unicode"
@@ -123,7 +124,13 @@ func (b *planBuilder) buildResultSetNode(node ast.ResultSetNode) LogicalPlan {
 		case *ast.UnionStmt:
 			p = b.buildUnion(v)
 		case *ast.TableName:
-			p = b.buildDataSource(v)
+			if cte := b.findCTE(v); cte >= 0 {
+				p = b.buildCTE(cte)
+			} else if v.TableInfo != nil && v.TableInfo.IsView() {
+				p = b.buildView(v)
+			} else {
+				p = b.buildDataSource(v)
+			}
 		default:
 			b.err = ErrUnsupportedType.Gen("unsupported table source type %T", v)
 			return nil
@@ -551,6 +558,12 @@ func joinFieldType(a, b *types.FieldType) *types.FieldType {
 }
 
 func (b *planBuilder) buildUnion(union *ast.UnionStmt) LogicalPlan {
+	if union.With != nil {
+		defer func(ctes []*cteInfo) {
+			b.ctes = ctes
+		}(b.ctes)
+		b.pushWith(union.With)
+	}
 	u := Union{}.init(b.allocator, b.ctx)
 	u.children = make([]Plan, len(union.SelectList.Selects))
 	for i, sel := range union.SelectList.Selects {
@@ -613,6 +626,181 @@ func (b *planBuilder) buildUnion(union *ast.UnionStmt) LogicalPlan {
 	return p
 }
 
+// cteInfo stores a common table expression visible to the query being built.
+type cteInfo struct {
+	def       *ast.CommonTableExpression
+	recursive bool
+	// recursiveCTE is set when the recursive part of the common table expression is being built,
+	// the references to itself are built as CTETables which read the rows of the last iteration.
+	recursiveCTE *RecursiveCTE
+}
+
+func (b *planBuilder) pushWith(with *ast.WithClause) {
+	for _, cte := range with.CTEs {
+		b.ctes = append(b.ctes, &cteInfo{def: cte, recursive: with.IsRecursive})
+	}
+}
+
+// findCTE returns the index of the common table expression referenced by the table name, or -1 if not found.
+func (b *planBuilder) findCTE(tn *ast.TableName) int {
+	if tn.Schema.L != "" {
+		return -1
+	}
+	for i := len(b.ctes) - 1; i >= 0; i-- {
+		if b.ctes[i].def.Name.L == tn.Name.L {
+			return i
+		}
+	}
+	return -1
+}
+
+// buildCTE builds the common table expression at idx of b.ctes. The common table expression is expanded
+// at every place it is referenced.
+func (b *planBuilder) buildCTE(idx int) LogicalPlan {
+	cte := b.ctes[idx]
+	if cte.recursiveCTE != nil {
+		return b.buildCTETable(cte)
+	}
+	// Only the common table expressions defined before it are visible in its definition,
+	// a recursive common table expression is also visible to itself.
+	visible := idx
+	if cte.recursive {
+		visible++
+	}
+	defer func(ctes []*cteInfo) {
+		b.ctes = ctes
+	}(b.ctes)
+	b.ctes = append(make([]*cteInfo, 0, visible), b.ctes[:visible]...)
+
+	var p LogicalPlan
+	if cte.recursive {
+		p = b.buildRecursiveCTE(cte)
+	} else {
+		p = b.buildResultSetNode(cte.def.Query.Query)
+	}
+	if b.err != nil {
+		return nil
+	}
+	if len(cte.def.ColNameList) > 0 && len(cte.def.ColNameList) != p.Schema().Len() {
+		b.err = ErrViewWrongList.GenByArgs()
+		return nil
+	}
+	for i, col := range p.Schema().Columns {
+		if len(cte.def.ColNameList) > 0 {
+			col.ColName = cte.def.ColNameList[i]
+		}
+		col.TblName = cte.def.Name
+		col.DBName = model.NewCIStr("")
+	}
+	return p
+}
+
+// buildRecursiveCTE builds a common table expression defined in WITH RECURSIVE. The query blocks of the union
+// which don't reference the common table expression are the seed part, and the following ones are the recursive part.
+func (b *planBuilder) buildRecursiveCTE(cte *cteInfo) LogicalPlan {
+	union, ok := cte.def.Query.Query.(*ast.UnionStmt)
+	if !ok {
+		return b.buildResultSetNode(cte.def.Query.Query)
+	}
+	var seedSelects, recursiveSelects []*ast.SelectStmt
+	for _, sel := range union.SelectList.Selects {
+		detector := &cteReferenceDetector{name: cte.def.Name}
+		sel.Accept(detector)
+		if !detector.referenced {
+			if len(recursiveSelects) > 0 {
+				b.err = ErrCTERecursiveRequiresNonRecursiveFirst.GenByArgs(cte.def.Name.O)
+				return nil
+			}
+			seedSelects = append(seedSelects, sel)
+			continue
+		}
+		if b.detectSelectAgg(sel) || b.detectSelectWindow(sel) {
+			b.err = ErrCTERecursiveForbidsAggregation.GenByArgs(cte.def.Name.O)
+			return nil
+		}
+		recursiveSelects = append(recursiveSelects, sel)
+	}
+	if len(recursiveSelects) == 0 {
+		return b.buildUnion(union)
+	}
+	if len(seedSelects) == 0 {
+		b.err = ErrCTERecursiveRequiresNonRecursiveFirst.GenByArgs(cte.def.Name.O)
+		return nil
+	}
+
+	seed := b.buildCTEPart(seedSelects)
+	if b.err != nil {
+		return nil
+	}
+	colNames := cte.def.ColNameList
+	if len(colNames) > 0 && len(colNames) != seed.Schema().Len() {
+		b.err = ErrViewWrongList.GenByArgs()
+		return nil
+	}
+	rcte := RecursiveCTE{Distinct: union.Distinct}.init(b.allocator, b.ctx)
+	schema := expression.NewSchema(make([]*expression.Column, 0, seed.Schema().Len())...)
+	for i, col := range seed.Schema().Columns {
+		colName := col.ColName
+		if len(colNames) > 0 {
+			colName = colNames[i]
+		}
+		schema.Append(&expression.Column{
+			FromID:   rcte.id,
+			ColName:  colName,
+			TblName:  cte.def.Name,
+			RetType:  col.RetType,
+			Position: i,
+		})
+	}
+	rcte.SetSchema(schema)
+
+	cte.recursiveCTE = rcte
+	recursive := b.buildCTEPart(recursiveSelects)
+	cte.recursiveCTE = nil
+	if b.err != nil {
+		return nil
+	}
+	if recursive.Schema().Len() != schema.Len() {
+		b.err = errors.New("The used SELECT statements have a different number of columns")
+		return nil
+	}
+	setParentAndChildren(rcte, seed, recursive)
+
+	var p LogicalPlan = rcte
+	if union.OrderBy != nil {
+		p = b.buildSort(p, union.OrderBy.Items, nil)
+	}
+	if union.Limit != nil {
+		p = b.buildLimit(p, union.Limit)
+	}
+	return p
+}
+
+// buildCTEPart builds the seed part or the recursive part of a recursive common table expression.
+func (b *planBuilder) buildCTEPart(selects []*ast.SelectStmt) LogicalPlan {
+	if len(selects) == 1 {
+		return b.buildSelect(selects[0])
+	}
+	return b.buildUnion(&ast.UnionStmt{SelectList: &ast.UnionSelectList{Selects: selects}})
+}
+
+// buildCTETable builds the reference to a recursive common table expression in its recursive part.
+func (b *planBuilder) buildCTETable(cte *cteInfo) LogicalPlan {
+	table := CTETable{CTEID: cte.recursiveCTE.id}.init(b.allocator, b.ctx)
+	schema := expression.NewSchema(make([]*expression.Column, 0, cte.recursiveCTE.Schema().Len())...)
+	for i, col := range cte.recursiveCTE.Schema().Columns {
+		schema.Append(&expression.Column{
+			FromID:   table.id,
+			ColName:  col.ColName,
+			TblName:  cte.def.Name,
+			RetType:  col.RetType,
+			Position: i,
+		})
+	}
+	table.SetSchema(schema)
+	return table
+}
+
 // ByItems wraps a "by" item.
 type ByItems struct {
 	Expr expression.Expression
@@ -650,6 +838,247 @@ func (b *planBuilder) buildSort(p LogicalPlan, byItems []*ast.ByItem, aggMapper
 	return sort
 }
 
+// windowGroup is a group of window functions that share the same window specification,
+// they are evaluated by the same LogicalWindow.
+type windowGroup struct {
+	key         string
+	funcs       []*ast.WindowFuncExpr
+	partitionBy []*ByItems
+	orderBy     []*ByItems
+	frame       *WindowFrame
+}
+
+// buildWindowFunctions builds a LogicalWindow for every group of window functions in select fields,
+// and a Sort under it if the window has partition by or order by items.
+func (b *planBuilder) buildWindowFunctions(p LogicalPlan, fields []*ast.SelectField, aggMapper map[*ast.AggregateFuncExpr]int) LogicalPlan {
+	extractor := &windowFuncExtractor{}
+	for _, f := range fields {
+		f.Expr.Accept(extractor)
+	}
+	var groups []*windowGroup
+	for _, wf := range extractor.windowFuncs {
+		partitionBy, orderBy, np := b.buildWindowSpecItems(p, &wf.Spec, aggMapper)
+		if b.err != nil {
+			return nil
+		}
+		p = np
+		frame, err := buildWindowFrame(wf.Spec.Frame, orderBy)
+		if err != nil {
+			b.err = errors.Trace(err)
+			return nil
+		}
+		key := windowGroupKey(partitionBy, orderBy, frame)
+		var group *windowGroup
+		for _, g := range groups {
+			if g.key == key {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = &windowGroup{key: key, partitionBy: partitionBy, orderBy: orderBy, frame: frame}
+			groups = append(groups, group)
+		}
+		group.funcs = append(group.funcs, wf)
+	}
+	b.windowMapper = make(map[*ast.WindowFuncExpr]int, len(extractor.windowFuncs))
+	for _, g := range groups {
+		descs := make([]*WindowFuncDesc, 0, len(g.funcs))
+		for _, wf := range g.funcs {
+			var desc *WindowFuncDesc
+			desc, p = b.buildWindowFuncDesc(p, wf, aggMapper)
+			if b.err != nil {
+				return nil
+			}
+			descs = append(descs, desc)
+		}
+		sortItems := make([]*ByItems, 0, len(g.partitionBy)+len(g.orderBy))
+		sortItems = append(sortItems, g.partitionBy...)
+		sortItems = append(sortItems, g.orderBy...)
+		if len(sortItems) > 0 {
+			sort := Sort{}.init(b.allocator, b.ctx)
+			for _, item := range sortItems {
+				sort.ByItems = append(sort.ByItems, item.Clone())
+			}
+			setParentAndChildren(sort, p)
+			sort.SetSchema(p.Schema().Clone())
+			p = sort
+		}
+		window := LogicalWindow{
+			WindowFuncDescs: descs,
+			PartitionBy:     g.partitionBy,
+			OrderBy:         g.orderBy,
+			Frame:           g.frame,
+		}.init(b.allocator, b.ctx)
+		schema := p.Schema().Clone()
+		for i, desc := range descs {
+			schema.Append(&expression.Column{
+				FromID:      window.id,
+				ColName:     model.NewCIStr(fmt.Sprintf("%d_window_%d", window.id, i)),
+				Position:    i,
+				IsAggOrSubq: true,
+				RetType:     desc.RetTp,
+			})
+			b.windowMapper[g.funcs[i]] = schema.Len() - 1
+		}
+		setParentAndChildren(window, p)
+		window.SetSchema(schema)
+		p = window
+	}
+	return p
+}
+
+// buildWindowSpecItems rewrites the partition by and order by items of a window specification.
+func (b *planBuilder) buildWindowSpecItems(p LogicalPlan, spec *ast.WindowSpec, aggMapper map[*ast.AggregateFuncExpr]int) (
+	partitionBy, orderBy []*ByItems, _ LogicalPlan) {
+	if spec.PartitionBy != nil {
+		for _, item := range spec.PartitionBy.Items {
+			expr, np, err := b.rewrite(item.Expr, p, aggMapper, true)
+			if err != nil {
+				b.err = errors.Trace(err)
+				return nil, nil, nil
+			}
+			p = np
+			partitionBy = append(partitionBy, &ByItems{Expr: expr, Desc: item.Desc})
+		}
+	}
+	if spec.OrderBy != nil {
+		for _, item := range spec.OrderBy.Items {
+			expr, np, err := b.rewrite(item.Expr, p, aggMapper, true)
+			if err != nil {
+				b.err = errors.Trace(err)
+				return nil, nil, nil
+			}
+			p = np
+			orderBy = append(orderBy, &ByItems{Expr: expr, Desc: item.Desc})
+		}
+	}
+	return partitionBy, orderBy, p
+}
+
+// buildWindowFuncDesc rewrites the arguments of a window function and infers its return type.
+func (b *planBuilder) buildWindowFuncDesc(p LogicalPlan, wf *ast.WindowFuncExpr, aggMapper map[*ast.AggregateFuncExpr]int) (*WindowFuncDesc, LogicalPlan) {
+	name := strings.ToLower(wf.F)
+	if wf.Distinct {
+		b.err = ErrNotSupportedYet.GenByArgs("<window function>(DISTINCT ..)")
+		return nil, nil
+	}
+	args := make([]expression.Expression, 0, len(wf.Args))
+	for _, arg := range wf.Args {
+		expr, np, err := b.rewrite(arg, p, aggMapper, true)
+		if err != nil {
+			b.err = errors.Trace(err)
+			return nil, nil
+		}
+		p = np
+		args = append(args, expr)
+	}
+	desc := &WindowFuncDesc{Name: name, Args: args}
+	switch name {
+	case ast.WindowFuncRowNumber, ast.WindowFuncRank, ast.WindowFuncDenseRank:
+		desc.RetTp = types.NewFieldType(mysql.TypeLonglong)
+		desc.RetTp.Flen = 21
+		types.SetBinChsClnFlag(desc.RetTp)
+	case ast.WindowFuncLag, ast.WindowFuncLead:
+		if len(wf.Args) > 1 {
+			if _, err := getUintForLimitOffset(b.ctx.GetSessionVars().StmtCtx, wf.Args[1].GetValue()); err != nil {
+				b.err = ErrNotSupportedYet.GenByArgs(fmt.Sprintf("non-integral offset of %s", name))
+				return nil, nil
+			}
+		}
+		tp := *args[0].GetType()
+		tp.Flag &= ^mysql.NotNullFlag
+		desc.RetTp = &tp
+	case ast.AggFuncSum, ast.AggFuncCount, ast.AggFuncAvg, ast.AggFuncMax, ast.AggFuncMin:
+		desc.RetTp = aggregation.NewAggFunction(name, args, false).GetType()
+	default:
+		b.err = ErrNotSupportedYet.GenByArgs(fmt.Sprintf("%s as window function", name))
+		return nil, nil
+	}
+	return desc, p
+}
+
+// buildWindowFrame builds and checks the frame of a window. If the frame is not specified, the default
+// frame is RANGE BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW when the window has order by items,
+// otherwise the frame contains the whole partition.
+func buildWindowFrame(frame *ast.FrameClause, orderBy []*ByItems) (*WindowFrame, error) {
+	if frame == nil {
+		if len(orderBy) > 0 {
+			return &WindowFrame{
+				Type:  ast.Ranges,
+				Start: &FrameBound{Type: ast.Preceding, UnBounded: true},
+				End:   &FrameBound{Type: ast.CurrentRow},
+			}, nil
+		}
+		return &WindowFrame{
+			Type:  ast.Rows,
+			Start: &FrameBound{Type: ast.Preceding, UnBounded: true},
+			End:   &FrameBound{Type: ast.Following, UnBounded: true},
+		}, nil
+	}
+	start, end := &frame.Extent.Start, &frame.Extent.End
+	if start.Type == ast.Following && start.UnBounded {
+		return nil, ErrWindowFrameStartIllegal.GenByArgs(unnamedWindow)
+	}
+	if end.Type == ast.Preceding && end.UnBounded {
+		return nil, ErrWindowFrameEndIllegal.GenByArgs(unnamedWindow)
+	}
+	result := &WindowFrame{Type: frame.Type}
+	for _, bound := range []*ast.FrameBound{start, end} {
+		fb := &FrameBound{Type: bound.Type, UnBounded: bound.UnBounded}
+		if bound.Type != ast.CurrentRow && !bound.UnBounded {
+			v, ok := bound.Expr.(*ast.ValueExpr)
+			if !ok {
+				return nil, ErrWindowFrameIllegal.GenByArgs(unnamedWindow)
+			}
+			switch x := v.GetValue().(type) {
+			case int64:
+				if x < 0 {
+					return nil, ErrWindowFrameIllegal.GenByArgs(unnamedWindow)
+				}
+				fb.Num = uint64(x)
+			case uint64:
+				fb.Num = x
+			default:
+				return nil, ErrWindowFrameIllegal.GenByArgs(unnamedWindow)
+			}
+			if frame.Type == ast.Ranges {
+				if len(orderBy) != 1 {
+					return nil, ErrWindowRangeFrameOrderType.GenByArgs(unnamedWindow)
+				}
+				switch orderBy[0].Expr.GetType().EvalType() {
+				case types.ETInt, types.ETReal, types.ETDecimal:
+				default:
+					return nil, ErrWindowRangeFrameOrderType.GenByArgs(unnamedWindow)
+				}
+			}
+		}
+		if result.Start == nil {
+			result.Start = fb
+		} else {
+			result.End = fb
+		}
+	}
+	return result, nil
+}
+
+// unnamedWindow is the window name used in error messages, named windows are not supported.
+const unnamedWindow = "<unnamed window>"
+
+// windowGroupKey encodes the window specification, window functions with the same key can share one LogicalWindow.
+func windowGroupKey(partitionBy, orderBy []*ByItems, frame *WindowFrame) string {
+	var buffer bytes.Buffer
+	for _, items := range [][]*ByItems{partitionBy, orderBy} {
+		for _, item := range items {
+			buffer.Write(item.Expr.HashCode())
+			fmt.Fprintf(&buffer, ":%v,", item.Desc)
+		}
+		buffer.WriteString("|")
+	}
+	buffer.WriteString(frame.String())
+	return buffer.String()
+}
+
 // getUintForLimitOffset gets uint64 value for limit/offset.
 // For ordinary statement, limit/offset should be uint64 constant value.
 // For prepared statement, limit/offset is string. We should convert it to uint64.
@@ -762,15 +1191,22 @@ type havingAndOrderbyExprResolver struct {
 	selectFields []*ast.SelectField
 	aggMapper    map[*ast.AggregateFuncExpr]int
 	colMapper    map[*ast.ColumnNameExpr]int
+	windowMapper map[*ast.WindowFuncExpr]int
 	gbyItems     []*ast.ByItem
 	outerSchemas []*expression.Schema
 }
 
 // Enter implements Visitor interface.
 func (a *havingAndOrderbyExprResolver) Enter(n ast.Node) (node ast.Node, skipChildren bool) {
-	switch n.(type) {
+	switch v := n.(type) {
 	case *ast.AggregateFuncExpr:
 		a.inAggFunc = true
+	case *ast.WindowFuncExpr:
+		// Window functions are evaluated before the projection, so they can't be used in having clause.
+		if !a.orderBy {
+			a.err = ErrWindowInvalidWindowFuncUse.GenByArgs(strings.ToLower(v.F))
+		}
+		return n, true
 	case *ast.ParamMarkerExpr, *ast.ColumnNameExpr, *ast.ColumnName:
 	case *ast.SubqueryExpr, *ast.ExistsSubqueryExpr:
 		// Enter a new context, skip it.
@@ -820,6 +1256,16 @@ func (a *havingAndOrderbyExprResolver) Leave(n ast.Node) (node ast.Node, ok bool
 			Expr:      v,
 			AsName:    model.NewCIStr(fmt.Sprintf("sel_agg_%d", len(a.selectFields))),
 		})
+	case *ast.WindowFuncExpr:
+		if a.err != nil {
+			return node, false
+		}
+		a.windowMapper[v] = len(a.selectFields)
+		a.selectFields = append(a.selectFields, &ast.SelectField{
+			Auxiliary: true,
+			Expr:      v,
+			AsName:    model.NewCIStr(fmt.Sprintf("sel_window_%d", len(a.selectFields))),
+		})
 	case *ast.ColumnNameExpr:
 		resolveFieldsFirst := true
 		if a.inAggFunc || (a.orderBy && a.inExpr) {
@@ -886,13 +1332,16 @@ func (a *havingAndOrderbyExprResolver) Leave(n ast.Node) (node ast.Node, ok bool
 // resolveHavingAndOrderBy will process aggregate functions and resolve the columns that don't exist in select fields.
 // If we found some columns that are not in select fields, we will append it to select fields and update the colMapper.
 // When we rewrite the order by / having expression, we will find column in map at first.
+// The window functions in order by clause are appended to select fields too, the returned window mapper
+// records their positions.
 func (b *planBuilder) resolveHavingAndOrderBy(sel *ast.SelectStmt, p LogicalPlan) (
-	map[*ast.AggregateFuncExpr]int, map[*ast.AggregateFuncExpr]int) {
+	map[*ast.AggregateFuncExpr]int, map[*ast.AggregateFuncExpr]int, map[*ast.WindowFuncExpr]int) {
 	extractor := &havingAndOrderbyExprResolver{
 		p:            p,
 		selectFields: sel.Fields.Fields,
 		aggMapper:    make(map[*ast.AggregateFuncExpr]int),
 		colMapper:    b.colMapper,
+		windowMapper: make(map[*ast.WindowFuncExpr]int),
 		outerSchemas: b.outerSchemas,
 	}
 	if sel.GroupBy != nil {
@@ -903,7 +1352,7 @@ func (b *planBuilder) resolveHavingAndOrderBy(sel *ast.SelectStmt, p LogicalPlan
 		n, ok := sel.Having.Expr.Accept(extractor)
 		if !ok {
 			b.err = errors.Trace(extractor.err)
-			return nil, nil
+			return nil, nil, nil
 		}
 		sel.Having.Expr = n.(ast.ExprNode)
 	}
@@ -917,13 +1366,13 @@ func (b *planBuilder) resolveHavingAndOrderBy(sel *ast.SelectStmt, p LogicalPlan
 			n, ok := item.Expr.Accept(extractor)
 			if !ok {
 				b.err = errors.Trace(extractor.err)
-				return nil, nil
+				return nil, nil, nil
 			}
 			item.Expr = n.(ast.ExprNode)
 		}
 	}
 	sel.Fields.Fields = extractor.selectFields
-	return havingAggMapper, extractor.aggMapper
+	return havingAggMapper, extractor.aggMapper, extractor.windowMapper
 }
 
 func (b *planBuilder) extractAggFuncs(fields []*ast.SelectField) ([]*ast.AggregateFuncExpr, map[*ast.AggregateFuncExpr]int) {
@@ -1023,10 +1472,15 @@ func (b *planBuilder) unfoldWildStar(p LogicalPlan, selectFields []*ast.SelectFi
 		}
 		dbName := field.WildCard.Schema
 		tblName := field.WildCard.Table
+		_, recordExpansion := b.wildCardExpansions[field]
+		var expansion []string
 		for _, col := range p.Schema().Columns {
 			if (dbName.L == "" || dbName.L == col.DBName.L) &&
 				(tblName.L == "" || tblName.L == col.TblName.L) &&
 				col.ID != model.ExtraHandleID {
+				if recordExpansion {
+					expansion = append(expansion, qualifiedColumnName(col))
+				}
 				colName := &ast.ColumnNameExpr{
 					Name: &ast.ColumnName{
 						Schema: col.DBName,
@@ -1039,10 +1493,27 @@ func (b *planBuilder) unfoldWildStar(p LogicalPlan, selectFields []*ast.SelectFi
 				resultList = append(resultList, field)
 			}
 		}
+		if recordExpansion {
+			b.wildCardExpansions[field] = expansion
+		}
 	}
 	return
 }
 
+// qualifiedColumnName returns the quoted column name qualified by the table name, it refers to the
+// column in the scope of the select fields.
+func qualifiedColumnName(col *expression.Column) string {
+	name := quoteIdentifier(col.ColName.O)
+	if col.TblName.O != "" {
+		name = quoteIdentifier(col.TblName.O) + "." + name
+	}
+	return name
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
 func (b *planBuilder) pushTableHints(hints []*ast.TableOptimizerHint) bool {
 	var sortMergeTables, INLJTables []model.CIStr
 	for _, hint := range hints {
@@ -1089,11 +1560,26 @@ func (b *planBuilder) buildSelect(sel *ast.SelectStmt) LogicalPlan {
 		b.needColHandle++
 	}
 
+	if sel.With != nil {
+		defer func(ctes []*cteInfo) {
+			b.ctes = ctes
+		}(b.ctes)
+		b.pushWith(sel.With)
+	}
+
+	// The window functions belong to the current select, restore the outer mapper when we leave it.
+	defer func(windowMapper map[*ast.WindowFuncExpr]int) {
+		b.windowMapper = windowMapper
+	}(b.windowMapper)
+	b.windowMapper = nil
+
 	hasAgg := b.detectSelectAgg(sel)
+	hasWindow := b.detectSelectWindow(sel)
 	var (
 		p                             LogicalPlan
 		aggFuncs                      []*ast.AggregateFuncExpr
 		havingMap, orderMap, totalMap map[*ast.AggregateFuncExpr]int
+		orderWindowMap                map[*ast.WindowFuncExpr]int
 		gbyCols                       []expression.Expression
 	)
 	if sel.From != nil {
@@ -1118,7 +1604,10 @@ func (b *planBuilder) buildSelect(sel *ast.SelectStmt) LogicalPlan {
 	// We must resolve having and order by clause before build projection,
 	// because when the query is "select a+1 as b from t having sum(b) < 0", we must replace sum(b) to sum(a+1),
 	// which only can be done before building projection and extracting Agg functions.
-	havingMap, orderMap = b.resolveHavingAndOrderBy(sel, p)
+	havingMap, orderMap, orderWindowMap = b.resolveHavingAndOrderBy(sel, p)
+	if b.err != nil {
+		return nil
+	}
 	if sel.Where != nil {
 		p = b.buildSelection(p, sel.Where, nil)
 		if b.err != nil {
@@ -1142,6 +1631,12 @@ func (b *planBuilder) buildSelect(sel *ast.SelectStmt) LogicalPlan {
 			return nil
 		}
 	}
+	if hasWindow {
+		p = b.buildWindowFunctions(p, sel.Fields.Fields, totalMap)
+		if b.err != nil {
+			return nil
+		}
+	}
 	var oldLen int
 	p, oldLen = b.buildProjection(p, sel.Fields.Fields, totalMap)
 	if b.err != nil {
@@ -1160,6 +1655,8 @@ func (b *planBuilder) buildSelect(sel *ast.SelectStmt) LogicalPlan {
 		}
 	}
 	if sel.OrderBy != nil {
+		// The window functions in order by clause have been resolved to the projection columns.
+		b.windowMapper = orderWindowMap
 		p = b.buildSort(p, sel.OrderBy.Items, orderMap)
 		if b.err != nil {
 			return nil
@@ -1195,6 +1692,120 @@ func (b *planBuilder) buildTableDual() LogicalPlan {
 	return dual
 }
 
+// buildView expands a view in place. The SELECT statement of the view is parsed and resolved in the schema
+// of the view, and its output columns are renamed to the columns of the view.
+func (b *planBuilder) buildView(tn *ast.TableName) LogicalPlan {
+	viewInfo := tn.TableInfo.View
+	for _, id := range b.expandingViews {
+		if id == tn.TableInfo.ID {
+			b.err = ErrViewRecursive.GenByArgs(tn.Schema.O, tn.Name.O)
+			return nil
+		}
+	}
+	b.expandingViews = append(b.expandingViews, tn.TableInfo.ID)
+	// The common table expressions of the outer query are invisible in the view.
+	defer func(ctes []*cteInfo) {
+		b.expandingViews = b.expandingViews[:len(b.expandingViews)-1]
+		b.ctes = ctes
+	}(b.ctes)
+	b.ctes = nil
+
+	stmt, err := parser.New().ParseOneStmt(viewInfo.SelectStmt, "", "")
+	if err != nil {
+		b.err = errors.Trace(err)
+		return nil
+	}
+	sel, ok := stmt.(ast.ResultSetNode)
+	if !ok {
+		b.err = ErrViewInvalid.GenByArgs(tn.Schema.O, tn.Name.O)
+		return nil
+	}
+	if err = resolveNameInSchema(sel, b.is, tn.Schema, b.ctx); err != nil {
+		b.err = ErrViewInvalid.GenByArgs(tn.Schema.O, tn.Name.O)
+		return nil
+	}
+	visitInfo := b.visitInfo
+	p := b.buildResultSetNode(sel)
+	if b.err != nil {
+		return nil
+	}
+	if viewInfo.Security == model.SecurityDefiner && viewInfo.Definer != nil {
+		// The underlying tables of a SQL SECURITY DEFINER view are checked against the privileges of the definer
+		// instead of the invoker. The nested definer views have moved their own visits already.
+		for _, v := range b.visitInfo[len(visitInfo):] {
+			b.definerVisitInfo = append(b.definerVisitInfo, definerVisitInfo{visitInfo: v, definer: viewInfo.Definer})
+		}
+		b.visitInfo = visitInfo
+	}
+	b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SelectPriv, tn.Schema.L, tn.Name.L, "")
+	return b.buildViewColumns(p, tn, viewInfo)
+}
+
+// buildViewColumns matches the columns of the view to the columns of its SELECT statement by name, the view
+// is invalid if any of them is missing. The columns are projected in the order of the view columns.
+func (b *planBuilder) buildViewColumns(p LogicalPlan, tn *ast.TableName, viewInfo *model.ViewInfo) LogicalPlan {
+	selectCols := viewInfo.SelectCols
+	if len(selectCols) == 0 {
+		// The views created without the select column names are matched by position.
+		for _, col := range p.Schema().Columns {
+			selectCols = append(selectCols, col.ColName)
+		}
+	}
+	if len(selectCols) != len(viewInfo.Cols) {
+		b.err = ErrViewInvalid.GenByArgs(tn.Schema.O, tn.Name.O)
+		return nil
+	}
+	cols := make([]*expression.Column, 0, len(selectCols))
+	inOrder := len(selectCols) == p.Schema().Len()
+	for i, name := range selectCols {
+		col := findViewSelectColumn(p.Schema(), name, i)
+		if col == nil {
+			b.err = ErrViewInvalid.GenByArgs(tn.Schema.O, tn.Name.O)
+			return nil
+		}
+		inOrder = inOrder && col == p.Schema().Columns[i]
+		cols = append(cols, col)
+	}
+	if inOrder {
+		for i, col := range p.Schema().Columns {
+			col.ColName = viewInfo.Cols[i]
+			col.TblName = tn.Name
+			col.DBName = tn.Schema
+		}
+		return p
+	}
+	proj := Projection{Exprs: make([]expression.Expression, 0, len(cols))}.init(b.allocator, b.ctx)
+	schema := expression.NewSchema(make([]*expression.Column, 0, len(cols))...)
+	for i, col := range cols {
+		proj.Exprs = append(proj.Exprs, col)
+		schema.Append(&expression.Column{
+			FromID:   proj.id,
+			Position: i + 1,
+			TblName:  tn.Name,
+			ColName:  viewInfo.Cols[i],
+			DBName:   tn.Schema,
+			RetType:  col.RetType,
+		})
+	}
+	proj.SetSchema(schema)
+	setParentAndChildren(proj, p)
+	return proj
+}
+
+// findViewSelectColumn finds the column of the SELECT statement of a view by name, the column at the
+// same position is preferred since the select fields may have duplicated names.
+func findViewSelectColumn(schema *expression.Schema, name model.CIStr, pos int) *expression.Column {
+	if pos < schema.Len() && schema.Columns[pos].ColName.L == name.L {
+		return schema.Columns[pos]
+	}
+	for _, col := range schema.Columns {
+		if col.ColName.L == name.L {
+			return col
+		}
+	}
+	return nil
+}
+
 func (b *planBuilder) buildDataSource(tn *ast.TableName) LogicalPlan {
 	handle := sessionctx.GetDomain(b.ctx).StatsHandle()
 	var statisticTable *statistics.Table
@@ -1225,6 +1836,10 @@ func (b *planBuilder) buildDataSource(tn *ast.TableName) LogicalPlan {
 		NeedColHandle:  b.needColHandle > 0,
 	}.init(b.allocator, b.ctx)
 	b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SelectPriv, schemaName.L, tableInfo.Name.L, "")
+	if pt, ok := tbl.(table.PartitionedTable); ok {
+		p.partitionExpr = pt.PartitionExpr()
+		p.partitionIDs = getPartitionIDs(tableInfo.Partition)
+	}
 
 	var columns []*table.Column
 	if b.inUpdateStmt {
@@ -1435,6 +2050,9 @@ func (b *planBuilder) buildSemiJoin(outerPlan, innerPlan LogicalPlan, onConditio
 func (b *planBuilder) buildUpdate(update *ast.UpdateStmt) LogicalPlan {
 	b.inUpdateStmt = true
 	b.needColHandle++
+	if b.err = checkUpdatableTables(extractTableList(update.TableRefs.TableRefs, nil), "UPDATE"); b.err != nil {
+		return nil
+	}
 	sel := &ast.SelectStmt{Fields: &ast.FieldList{}, From: update.TableRefs, Where: update.Where, OrderBy: update.Order, Limit: update.Limit}
 	p := b.buildResultSetNode(sel.From.TableRefs)
 	if b.err != nil {
@@ -1596,6 +2214,13 @@ func extractTableAsNameForUpdate(p Plan, asNames map[*model.TableInfo][]*model.C
 
 func (b *planBuilder) buildDelete(delete *ast.DeleteStmt) LogicalPlan {
 	b.needColHandle++
+	targets := extractTableList(delete.TableRefs.TableRefs, nil)
+	if delete.Tables != nil {
+		targets = delete.Tables.Tables
+	}
+	if b.err = checkUpdatableTables(targets, "DELETE"); b.err != nil {
+		return nil
+	}
 	sel := &ast.SelectStmt{Fields: &ast.FieldList{}, From: delete.TableRefs, Where: delete.Where, OrderBy: delete.Order, Limit: delete.Limit}
 	p := b.buildResultSetNode(sel.From.TableRefs)
 	if b.err != nil {
@@ -1655,6 +2280,17 @@ func (b *planBuilder) buildDelete(delete *ast.DeleteStmt) LogicalPlan {
 	return del
 }
 
+// checkUpdatableTables returns an error if any of the target tables of an UPDATE or DELETE is a view.
+// Views referenced elsewhere in the statement, e.g. in a subquery, are only read and are allowed.
+func checkUpdatableTables(tables []*ast.TableName, stmt string) error {
+	for _, tn := range tables {
+		if tn.TableInfo != nil && tn.TableInfo.IsView() {
+			return ErrNonUpdatableTable.GenByArgs(tn.Name.O, stmt)
+		}
+	}
+	return nil
+}
+
 func extractTableList(node ast.ResultSetNode, input []*ast.TableName) []*ast.TableName {
 	switch x := node.(type) {
 	case *ast.Join:
@@ -1654,3 +1654,209 @@ func (s *testPlanSuite) TestTopNPushDown(c *C) {
 		c.Assert(ToString(p), Equals, tt.best, comment)
 	}
 }
+
+func (s *testPlanSuite) TestWindowFunction(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		sql    string
+		result string
+	}{
+		{
+			sql:    "select a, row_number() over () from t",
+			result: "DataScan(t)->Window([row_number()])->Projection",
+		},
+		{
+			sql:    "select a, rank() over (partition by b order by c), sum(d) over (partition by b order by c) from t",
+			result: "DataScan(t)->Sort->Window([rank() sum(test.t.d)])->Projection",
+		},
+		{
+			sql:    "select a, rank() over (order by c), sum(d) over (partition by b) from t",
+			result: "DataScan(t)->Sort->Window([rank()])->Sort->Window([sum(test.t.d)])->Projection",
+		},
+		{
+			sql:    "select sum(a) over (partition by b), count(c) from t group by b",
+			result: "DataScan(t)->Aggr(count(test.t.c),firstrow(test.t.a),firstrow(test.t.b))->Sort->Window([sum(test.t.a)])->Projection",
+		},
+		{
+			sql:    "select a from t order by row_number() over (order by b)",
+			result: "DataScan(t)->Sort->Window([row_number()])->Projection->Sort->Projection",
+		},
+		{
+			sql:    "select a, (select row_number() over () from t s where s.a = t.a) from t",
+			result: "Apply{DataScan(t)->DataScan(s)->Selection->Window([row_number()])->Projection->MaxOneRow}->Projection",
+		},
+	}
+	for _, tt := range tests {
+		comment := Commentf("for %s", tt.sql)
+		stmt, err := s.ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		is, err := MockResolve(stmt)
+		c.Assert(err, IsNil, comment)
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+			is:        is,
+		}
+		p := builder.build(stmt)
+		c.Assert(builder.err, IsNil, comment)
+		p, err = logicalOptimize(flagPrunColumns, p.(LogicalPlan), builder.ctx, builder.allocator)
+		c.Assert(err, IsNil, comment)
+		c.Assert(ToString(p), Equals, tt.result, comment)
+	}
+
+	errTests := []struct {
+		sql string
+		err *terror.Error
+	}{
+		{
+			sql: "select a from t where row_number() over () > 1",
+			err: ErrWindowInvalidWindowFuncUse,
+		},
+		{
+			sql: "select a from t group by b having rank() over () > 1",
+			err: ErrWindowInvalidWindowFuncUse,
+		},
+		{
+			sql: "select sum(row_number() over ()) from t",
+			err: ErrWindowInvalidWindowFuncUse,
+		},
+		{
+			sql: "select sum(a) over (rows between unbounded following and current row) from t",
+			err: ErrWindowFrameStartIllegal,
+		},
+		{
+			sql: "select sum(a) over (rows between current row and unbounded preceding) from t",
+			err: ErrWindowFrameEndIllegal,
+		},
+		{
+			sql: "select sum(a) over (rows 1.5 preceding) from t",
+			err: ErrWindowFrameIllegal,
+		},
+		{
+			sql: "select sum(a) over (order by a, b range 1 preceding) from t",
+			err: ErrWindowRangeFrameOrderType,
+		},
+		{
+			sql: "select count(distinct a) over () from t",
+			err: ErrNotSupportedYet,
+		},
+	}
+	for _, tt := range errTests {
+		comment := Commentf("for %s", tt.sql)
+		stmt, err := s.ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		is, err := MockResolve(stmt)
+		c.Assert(err, IsNil, comment)
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+			is:        is,
+		}
+		builder.build(stmt)
+		c.Assert(tt.err.Equal(builder.err), IsTrue, Commentf("for %s, got %v", tt.sql, builder.err))
+	}
+}
+
+func (s *testPlanSuite) TestCommonTableExpression(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		sql    string
+		result string
+	}{
+		{
+			sql:    "with cte as (select a, b from t) select * from cte",
+			result: "DataScan(t)->Projection->Projection",
+		},
+		{
+			sql:    "with cte (x, y) as (select a, b from t) select x from cte where y > 1",
+			result: "DataScan(t)->Selection->Projection->Projection",
+		},
+		{
+			sql:    "with c1 as (select a from t), c2 as (select a from c1) select * from c2 join c1 on c2.a = c1.a",
+			result: "Join{DataScan(t)->Projection->Projection->DataScan(t)->Projection}(c2.a,c1.a)->Projection",
+		},
+		{
+			sql:    "with recursive cte (n) as (select 1 union all select n + 1 from cte where n < 5) select n from cte",
+			result: "RecursiveCTE{Dual->Projection->CTETable->Selection->Projection}->Projection",
+		},
+		{
+			sql:    "with recursive cte as (select a from t union select a from t) select * from cte",
+			result: "UnionAll{DataScan(t)->Projection->DataScan(t)->Projection}->Aggr(firstrow(t.a))->Projection",
+		},
+		{
+			sql:    "select * from t where a in (with cte as (select b from t) select b from cte)",
+			result: "Join{DataScan(t)->DataScan(t)->Projection->Projection}(test.t.a,cte.b)->Projection",
+		},
+	}
+	for _, tt := range tests {
+		comment := Commentf("for %s", tt.sql)
+		stmt, err := s.ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		is, err := MockResolve(stmt)
+		c.Assert(err, IsNil, comment)
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+			is:        is,
+		}
+		p := builder.build(stmt)
+		c.Assert(builder.err, IsNil, comment)
+		p, err = logicalOptimize(flagPrunColumns|flagPredicatePushDown|flagDecorrelate, p.(LogicalPlan), builder.ctx, builder.allocator)
+		c.Assert(err, IsNil, comment)
+		c.Assert(ToString(p), Equals, tt.result, comment)
+	}
+
+	errTests := []struct {
+		sql string
+		err *terror.Error
+	}{
+		{
+			sql: "with cte as (select 1), cte as (select 2) select * from cte",
+			err: ErrNonUniqTable,
+		},
+		{
+			sql: "with cte (a, b) as (select 1) select * from cte",
+			err: ErrViewWrongList,
+		},
+		{
+			sql: "with recursive cte as (select a from cte) select * from cte",
+			err: ErrCTERecursiveRequiresUnion,
+		},
+		{
+			sql: "with recursive cte as (select a from cte union all select 1) select * from cte",
+			err: ErrCTERecursiveRequiresNonRecursiveFirst,
+		},
+		{
+			sql: "with recursive cte (n) as (select 1 union all select n + 1 from cte union all select 2) select * from cte",
+			err: ErrCTERecursiveRequiresNonRecursiveFirst,
+		},
+		{
+			sql: "with recursive cte (n) as (select 1 union all select count(n) from cte) select * from cte",
+			err: ErrCTERecursiveForbidsAggregation,
+		},
+		{
+			sql: "with recursive cte (n) as (select 1 union all select row_number() over () from cte) select * from cte",
+			err: ErrCTERecursiveForbidsAggregation,
+		},
+	}
+	for _, tt := range errTests {
+		comment := Commentf("for %s", tt.sql)
+		stmt, err := s.ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		is, err := MockResolve(stmt)
+		if err == nil {
+			builder := &planBuilder{
+				allocator: new(idAllocator),
+				ctx:       mockContext(),
+				colMapper: make(map[*ast.ColumnNameExpr]int),
+				is:        is,
+			}
+			builder.build(stmt)
+			err = builder.err
+		}
+		c.Assert(tt.err.Equal(err), IsTrue, Commentf("for %s, got %v", tt.sql, err))
+	}
+}
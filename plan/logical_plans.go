@@ -14,6 +14,8 @@
 package plan
 
 import (
+	"fmt"
+
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/expression"
@@ -27,6 +29,7 @@ import (
 var (
 	_ LogicalPlan = &LogicalJoin{}
 	_ LogicalPlan = &LogicalAggregation{}
+	_ LogicalPlan = &LogicalWindow{}
 	_ LogicalPlan = &Projection{}
 	_ LogicalPlan = &Selection{}
 	_ LogicalPlan = &LogicalApply{}
@@ -35,6 +38,8 @@ var (
 	_ LogicalPlan = &TableDual{}
 	_ LogicalPlan = &DataSource{}
 	_ LogicalPlan = &Union{}
+	_ LogicalPlan = &RecursiveCTE{}
+	_ LogicalPlan = &CTETable{}
 	_ LogicalPlan = &Sort{}
 	_ LogicalPlan = &Update{}
 	_ LogicalPlan = &Delete{}
@@ -200,6 +205,110 @@ func (p *LogicalAggregation) extractCorrelatedCols() []*expression.CorrelatedCol
 	return corCols
 }
 
+// WindowFuncDesc describes a function evaluated by the window operator.
+type WindowFuncDesc struct {
+	// Name is the lower case function name, e.g. "row_number" or "sum".
+	Name  string
+	Args  []expression.Expression
+	RetTp *types.FieldType
+}
+
+// Clone copies a WindowFuncDesc.
+func (desc *WindowFuncDesc) Clone() *WindowFuncDesc {
+	args := make([]expression.Expression, 0, len(desc.Args))
+	for _, arg := range desc.Args {
+		args = append(args, arg.Clone())
+	}
+	return &WindowFuncDesc{Name: desc.Name, Args: args, RetTp: desc.RetTp}
+}
+
+// String implements fmt.Stringer interface.
+func (desc *WindowFuncDesc) String() string {
+	result := desc.Name + "("
+	for i, arg := range desc.Args {
+		result += arg.String()
+		if i+1 != len(desc.Args) {
+			result += ", "
+		}
+	}
+	return result + ")"
+}
+
+// FrameBound is the boundary of a window frame.
+type FrameBound struct {
+	Type      ast.BoundType
+	UnBounded bool
+	// Num is the offset of "N PRECEDING" and "N FOLLOWING".
+	Num uint64
+}
+
+// String implements fmt.Stringer interface.
+func (b *FrameBound) String() string {
+	if b.Type == ast.CurrentRow {
+		return "current row"
+	}
+	dir := "preceding"
+	if b.Type == ast.Following {
+		dir = "following"
+	}
+	if b.UnBounded {
+		return "unbounded " + dir
+	}
+	return fmt.Sprintf("%d %s", b.Num, dir)
+}
+
+// WindowFrame represents the frame of a window. A frame of ROWS type is
+// counted in rows, a frame of RANGE type is counted in the value of the order by item.
+type WindowFrame struct {
+	Type  ast.FrameType
+	Start *FrameBound
+	End   *FrameBound
+}
+
+// String implements fmt.Stringer interface.
+func (f *WindowFrame) String() string {
+	tp := "rows"
+	if f.Type == ast.Ranges {
+		tp = "range"
+	}
+	return fmt.Sprintf("%s between %s and %s", tp, f.Start, f.End)
+}
+
+// LogicalWindow represents a window function plan. Its child must be sorted by
+// the partition by items and then the order by items.
+type LogicalWindow struct {
+	*basePlan
+	baseLogicalPlan
+
+	WindowFuncDescs []*WindowFuncDesc
+	PartitionBy     []*ByItems
+	OrderBy         []*ByItems
+	Frame           *WindowFrame
+}
+
+func (p *LogicalWindow) extractCorrelatedCols() []*expression.CorrelatedColumn {
+	corCols := p.basePlan.extractCorrelatedCols()
+	for _, desc := range p.WindowFuncDescs {
+		for _, arg := range desc.Args {
+			corCols = append(corCols, extractCorColumns(arg)...)
+		}
+	}
+	for _, item := range p.PartitionBy {
+		corCols = append(corCols, extractCorColumns(item.Expr)...)
+	}
+	for _, item := range p.OrderBy {
+		corCols = append(corCols, extractCorColumns(item.Expr)...)
+	}
+	return corCols
+}
+
+// sortItems returns the order that the child of the window must satisfy.
+func (p *LogicalWindow) sortItems() []*ByItems {
+	items := make([]*ByItems, 0, len(p.PartitionBy)+len(p.OrderBy))
+	items = append(items, p.PartitionBy...)
+	return append(items, p.OrderBy...)
+}
+
 // Selection means a filter.
 type Selection struct {
 	*basePlan
@@ -295,6 +404,11 @@ type DataSource struct {
 
 	// This is schema the PhysicalUnionScan should be.
 	unionScanSchema *expression.Schema
+
+	// partitionExpr is the partition expression of a partitioned table.
+	partitionExpr expression.Expression
+	// partitionIDs are the IDs of the partitions to read, they are pruned by the pushed down predicates.
+	partitionIDs []int64
 }
 
 func (p *DataSource) getPKIsHandleCol() *expression.Column {
@@ -329,6 +443,28 @@ type Union struct {
 	basePhysicalPlan
 }
 
+// RecursiveCTE represents a recursive common table expression. Its first child is the seed part,
+// and its second child is the recursive part which reads the rows produced by the last iteration
+// through CTETable.
+type RecursiveCTE struct {
+	*basePlan
+	baseLogicalPlan
+	basePhysicalPlan
+
+	// Distinct means the rows produced by all the parts are deduplicated.
+	Distinct bool
+}
+
+// CTETable represents the reference to a recursive common table expression in its recursive part.
+type CTETable struct {
+	*basePlan
+	baseLogicalPlan
+	basePhysicalPlan
+
+	// CTEID is the ID of the RecursiveCTE which the table belongs to.
+	CTEID int
+}
+
 // Sort stands for the order by plan.
 type Sort struct {
 	*basePlan
@@ -37,7 +37,8 @@ func (ts *PhysicalTableScan) matchProperty(prop *requiredProperty, infos ...*phy
 			count:    infos[0].count,
 			reliable: infos[0].reliable})
 	}
-	if len(prop.props) == 1 && ts.pkCol != nil && ts.pkCol.Equal(prop.props[0].col, ts.ctx) {
+	// The rows of different partitions can't be read in the order of handle.
+	if len(prop.props) == 1 && ts.pkCol != nil && ts.pkCol.Equal(prop.props[0].col, ts.ctx) && len(ts.PartitionIDs) <= 1 {
 		sortedTS := ts.Copy().(*PhysicalTableScan)
 		sortedTS.Desc = prop.props[0].desc
 		sortedTS.KeepOrder = true
@@ -124,7 +125,8 @@ func (is *PhysicalIndexScan) matchProperty(prop *requiredProperty, infos ...*phy
 	matchedIdx := 0
 	matchedList := make([]bool, len(prop.props))
 	for i, idxCol := range is.Index.Columns {
-		if idxCol.Length != types.UnspecifiedLength {
+		// The index keeping the raw strings isn't in the order of the case-insensitive collations.
+		if idxCol.Length != types.UnspecifiedLength || !is.Index.ComparedByCollation(is.Table.Columns[idxCol.Offset].Collate) {
 			break
 		}
 		if idx := matchPropColumn(prop, matchedIdx, idxCol); idx >= 0 {
@@ -134,7 +136,8 @@ func (is *PhysicalIndexScan) matchProperty(prop *requiredProperty, infos ...*phy
 			break
 		}
 	}
-	if allMatch(matchedList) {
+	// The rows of different partitions can't be read in the order of index.
+	if allMatch(matchedList) && len(is.PartitionIDs) <= 1 {
 		allDesc, allAsc := true, true
 		for i := 0; i < prop.sortKeyLen; i++ {
 			if prop.props[i].desc {
@@ -246,6 +249,21 @@ func (p *Union) matchProperty(_ *requiredProperty, childPlanInfo ...*physicalPla
 	return &physicalPlanInfo{p: np, cost: cost, count: count, reliable: reliable}
 }
 
+// matchProperty implements PhysicalPlan matchProperty interface.
+func (p *RecursiveCTE) matchProperty(_ *requiredProperty, childPlanInfo ...*physicalPlanInfo) *physicalPlanInfo {
+	np := p.Copy()
+	children := make([]Plan, 0, len(childPlanInfo))
+	cost := float64(0)
+	count := float64(0)
+	for _, res := range childPlanInfo {
+		children = append(children, res.p)
+		cost += res.cost
+		count += res.count
+	}
+	np.SetChildren(children...)
+	return &physicalPlanInfo{p: np, cost: cost, count: count}
+}
+
 // matchProperty implements PhysicalPlan matchProperty interface.
 func (p *Selection) matchProperty(prop *requiredProperty, childPlanInfo ...*physicalPlanInfo) *physicalPlanInfo {
 	if p.onTable {
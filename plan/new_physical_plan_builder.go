@@ -90,7 +90,8 @@ func joinKeysMatchIndex(keys []*expression.Column, index *model.IndexInfo) []int
 		}
 		found := false
 		for j, key := range keys {
-			if idxCol.Name.L == key.ColName.L {
+			// The index keeping the raw strings can't be used to look up the strings in case-insensitive collations.
+			if idxCol.Name.L == key.ColName.L && index.ComparedByCollation(key.RetType.Collate) {
 				matchOffsets[i] = j
 				found = true
 				break
@@ -596,8 +597,17 @@ func (p *DataSource) tryToGetMemTask(prop *requiredProp) (task task, err error)
 	return task, nil
 }
 
-// tryToGetDualTask will check if the push down predicate has false constant. If so, it will return table dual.
+// tryToGetDualTask will check if the push down predicate has false constant or all the partitions are pruned.
+// If so, it will return table dual.
 func (p *DataSource) tryToGetDualTask() (task, error) {
+	if p.partitionExpr != nil && len(p.partitionIDs) == 0 {
+		dual := TableDual{}.init(p.allocator, p.ctx)
+		dual.SetSchema(p.schema)
+		dual.profile = p.profile
+		return &rootTask{
+			p: dual,
+		}, nil
+	}
 	for _, cond := range p.pushedDownConds {
 		if _, ok := cond.(*expression.Constant); ok {
 			result, err := expression.EvalBool([]expression.Expression{cond}, nil, p.ctx)
@@ -675,7 +685,7 @@ func (p *DataSource) forceToIndexScan(idx *model.IndexInfo) PhysicalPlan {
 		Columns:             p.Columns,
 		Index:               idx,
 		dataSourceSchema:    p.schema,
-		physicalTableSource: physicalTableSource{NeedColHandle: p.NeedColHandle},
+		physicalTableSource: physicalTableSource{NeedColHandle: p.NeedColHandle, PartitionIDs: p.partitionIDs},
 		Ranges:              ranger.FullIndexRange(),
 		OutOfOrder:          true,
 	}.init(p.allocator, p.ctx)
@@ -686,7 +696,9 @@ func (p *DataSource) forceToIndexScan(idx *model.IndexInfo) PhysicalPlan {
 	}
 	if !isCoveringIndex(is.Columns, is.Index.Columns, is.Table.PKIsHandle) {
 		// On this way, it's double read case.
-		cop.tablePlan = PhysicalTableScan{Columns: p.Columns, Table: is.Table}.init(p.allocator, p.ctx)
+		ts := PhysicalTableScan{Columns: p.Columns, Table: is.Table}.init(p.allocator, p.ctx)
+		ts.PartitionIDs = p.partitionIDs
+		cop.tablePlan = ts
 		cop.tablePlan.SetSchema(is.dataSourceSchema)
 	}
 	is.initSchema(p.id, idx, cop.tablePlan != nil)
@@ -706,6 +718,7 @@ func (p *DataSource) convertToIndexScan(prop *requiredProp, idx *model.IndexInfo
 		dataSourceSchema:    p.schema,
 		physicalTableSource: physicalTableSource{NeedColHandle: p.NeedColHandle || p.unionScanSchema != nil},
 	}.init(p.allocator, p.ctx)
+	is.PartitionIDs = p.partitionIDs
 	statsTbl := p.statisticTable
 	rowCount := float64(statsTbl.Count)
 	sc := p.ctx.GetSessionVars().StmtCtx
@@ -739,7 +752,9 @@ func (p *DataSource) convertToIndexScan(prop *requiredProp, idx *model.IndexInfo
 	}
 	if !isCoveringIndex(is.Columns, is.Index.Columns, is.Table.PKIsHandle) {
 		// On this way, it's double read case.
-		cop.tablePlan = PhysicalTableScan{Columns: p.Columns, Table: is.Table}.init(p.allocator, p.ctx)
+		ts := PhysicalTableScan{Columns: p.Columns, Table: is.Table}.init(p.allocator, p.ctx)
+		ts.PartitionIDs = p.partitionIDs
+		cop.tablePlan = ts
 		cop.tablePlan.SetSchema(is.dataSourceSchema.Clone())
 		// If it's parent requires single read task, return max cost.
 		if prop.taskTp == copSingleReadTaskType {
@@ -752,11 +767,12 @@ func (p *DataSource) convertToIndexScan(prop *requiredProp, idx *model.IndexInfo
 	is.initSchema(p.id, idx, cop.tablePlan != nil)
 	// Check if this plan matches the property.
 	matchProperty := false
-	if !prop.isEmpty() {
+	// The rows of different partitions can't be read in the order of index.
+	if !prop.isEmpty() && len(p.partitionIDs) <= 1 {
 		for i, col := range idx.Columns {
 			// not matched
 			if col.Name.L == prop.cols[0].ColName.L {
-				matchProperty = matchIndicesProp(idx.Columns[i:], prop.cols)
+				matchProperty = matchIndicesProp(idx, idx.Columns[i:], prop.cols)
 				break
 			} else if i >= len(is.AccessCondition) {
 				break
@@ -866,7 +882,7 @@ func (is *PhysicalIndexScan) addPushedDownSelection(copTask *copTask, p *DataSou
 	}
 }
 
-func matchIndicesProp(idxCols []*model.IndexColumn, propCols []*expression.Column) bool {
+func matchIndicesProp(idx *model.IndexInfo, idxCols []*model.IndexColumn, propCols []*expression.Column) bool {
 	if len(idxCols) < len(propCols) {
 		return false
 	}
@@ -874,6 +890,10 @@ func matchIndicesProp(idxCols []*model.IndexColumn, propCols []*expression.Colum
 		if idxCols[i].Length != types.UnspecifiedLength || col.ColName.L != idxCols[i].Name.L {
 			return false
 		}
+		// The index keeping the raw strings isn't in the order of the case-insensitive collations.
+		if !idx.ComparedByCollation(col.RetType.Collate) {
+			return false
+		}
 	}
 	return true
 }
@@ -884,7 +904,7 @@ func (p *DataSource) forceToTableScan() PhysicalPlan {
 		Columns:             p.Columns,
 		TableAsName:         p.TableAsName,
 		DBName:              p.DBName,
-		physicalTableSource: physicalTableSource{NeedColHandle: p.NeedColHandle},
+		physicalTableSource: physicalTableSource{NeedColHandle: p.NeedColHandle, PartitionIDs: p.partitionIDs},
 		Ranges:              ranger.FullIntRange(),
 	}.init(p.allocator, p.ctx)
 	ts.SetSchema(p.schema)
@@ -911,6 +931,7 @@ func (p *DataSource) convertToTableScan(prop *requiredProp) (task task, err erro
 		DBName:              p.DBName,
 		physicalTableSource: physicalTableSource{NeedColHandle: p.NeedColHandle || p.unionScanSchema != nil},
 	}.init(p.allocator, p.ctx)
+	ts.PartitionIDs = p.partitionIDs
 	ts.SetSchema(p.schema)
 	sc := p.ctx.GetSessionVars().StmtCtx
 	ts.Ranges = ranger.FullIntRange()
@@ -952,7 +973,8 @@ func (p *DataSource) convertToTableScan(prop *requiredProp) (task task, err erro
 		indexPlanFinished: true,
 	}
 	task = copTask
-	matchProperty := len(prop.cols) == 1 && pkCol != nil && prop.cols[0].Equal(pkCol, nil)
+	// The rows of different partitions can't be read in the order of handle.
+	matchProperty := len(prop.cols) == 1 && pkCol != nil && prop.cols[0].Equal(pkCol, nil) && len(p.partitionIDs) <= 1
 	if matchProperty && prop.expectedCnt < math.MaxFloat64 {
 		selectivity, err := p.statisticTable.Selectivity(p.ctx, ts.filterCondition)
 		if err != nil {
@@ -1071,6 +1093,18 @@ func (p *PhysicalApply) getChildrenPossibleProps(prop *requiredProp) [][]*requir
 	return [][]*requiredProp{{lProp, &requiredProp{taskTp: rootTaskType, expectedCnt: math.MaxFloat64}}}
 }
 
+func (p *RecursiveCTE) getChildrenPossibleProps(prop *requiredProp) [][]*requiredProp {
+	p.expectedCnt = prop.expectedCnt
+	if !prop.isEmpty() {
+		return nil
+	}
+	props := make([]*requiredProp, 0, len(p.children))
+	for range p.children {
+		props = append(props, &requiredProp{taskTp: rootTaskType, expectedCnt: math.MaxFloat64})
+	}
+	return [][]*requiredProp{props}
+}
+
 func (p *Limit) getChildrenPossibleProps(prop *requiredProp) [][]*requiredProp {
 	p.expectedCnt = prop.expectedCnt
 	if !prop.isEmpty() {
@@ -1171,3 +1205,49 @@ func (p *PhysicalAggregation) getChildrenPossibleProps(prop *requiredProp) [][]*
 	}
 	return [][]*requiredProp{{reqProp}}
 }
+
+func (p *LogicalWindow) generatePhysicalPlans() []PhysicalPlan {
+	window := PhysicalWindow{
+		WindowFuncDescs: p.WindowFuncDescs,
+		PartitionBy:     p.PartitionBy,
+		OrderBy:         p.OrderBy,
+		Frame:           p.Frame,
+	}.init(p.allocator, p.ctx)
+	window.SetSchema(p.schema)
+	window.profile = p.profile
+	return []PhysicalPlan{window}
+}
+
+// getChildrenPossibleProps implements PhysicalPlan interface.
+// The window keeps the order of its child and its child is sorted by the partition by and order by items,
+// so the required property can be satisfied if it is a prefix of them. The window function needs
+// the whole partition, so we never expect its child to stop early.
+func (p *PhysicalWindow) getChildrenPossibleProps(prop *requiredProp) [][]*requiredProp {
+	p.expectedCnt = prop.expectedCnt
+	childProp := &requiredProp{taskTp: rootTaskType, expectedCnt: math.MaxFloat64}
+	if prop.isEmpty() {
+		return [][]*requiredProp{{childProp}}
+	}
+	sortItems := make([]*ByItems, 0, len(p.PartitionBy)+len(p.OrderBy))
+	sortItems = append(append(sortItems, p.PartitionBy...), p.OrderBy...)
+	if len(sortItems) == 0 {
+		// The window columns are appended after the columns of the child.
+		childLen := p.schema.Len() - len(p.WindowFuncDescs)
+		for _, col := range prop.cols {
+			if idx := p.schema.ColumnIndex(col); idx < 0 || idx >= childLen {
+				return nil
+			}
+		}
+		childProp.cols, childProp.desc = prop.cols, prop.desc
+		return [][]*requiredProp{{childProp}}
+	}
+	windowProp, ok := getPropByOrderByItems(sortItems)
+	if !ok {
+		return nil
+	}
+	windowProp.taskTp = rootTaskType
+	if !prop.isPrefix(windowProp) {
+		return nil
+	}
+	return [][]*requiredProp{{childProp}}
+}
@@ -75,6 +75,9 @@ func Optimize(ctx context.Context, node ast.Node, is infoschema.InfoSchema) (Pla
 		if !checkPrivilege(pm, builder.visitInfo) {
 			return nil, errors.New("privilege check fail")
 		}
+		if !checkDefinerPrivilege(pm, builder.definerVisitInfo) {
+			return nil, errors.New("privilege check fail")
+		}
 	}
 
 	if logic, ok := p.(LogicalPlan); ok {
@@ -107,6 +110,15 @@ func checkPrivilege(pm privilege.Manager, vs []visitInfo) bool {
 	return true
 }
 
+func checkDefinerPrivilege(pm privilege.Manager, vs []definerVisitInfo) bool {
+	for _, v := range vs {
+		if !pm.RequestVerificationWithUser(v.db, v.table, v.column, v.privilege, v.definer) {
+			return false
+		}
+	}
+	return true
+}
+
 func doOptimize(flag uint64, logic LogicalPlan, ctx context.Context, allocator *idAllocator) (PhysicalPlan, error) {
 	logic, err := logicalOptimize(flag, logic, ctx, allocator)
 	if err != nil {
@@ -205,30 +217,76 @@ const (
 	CodeIllegalReference    terror.ErrCode = 6
 
 	// MySQL error code.
-	CodeNoDB                 terror.ErrCode = mysql.ErrNoDB
-	CodeUnknownExplainFormat terror.ErrCode = mysql.ErrUnknownExplainFormat
+	CodeNoDB                                  terror.ErrCode = mysql.ErrNoDB
+	CodeUnknownExplainFormat                  terror.ErrCode = mysql.ErrUnknownExplainFormat
+	CodeNotSupportedYet                       terror.ErrCode = mysql.ErrNotSupportedYet
+	CodeWindowFrameStartIllegal               terror.ErrCode = mysql.ErrWindowFrameStartIllegal
+	CodeWindowFrameEndIllegal                 terror.ErrCode = mysql.ErrWindowFrameEndIllegal
+	CodeWindowFrameIllegal                    terror.ErrCode = mysql.ErrWindowFrameIllegal
+	CodeWindowRangeFrameOrderType             terror.ErrCode = mysql.ErrWindowRangeFrameOrderType
+	CodeWindowInvalidWindowFuncUse            terror.ErrCode = mysql.ErrWindowInvalidWindowFuncUse
+	CodeNonUniqTable                          terror.ErrCode = mysql.ErrNonuniqTable
+	CodeViewWrongList                         terror.ErrCode = mysql.ErrViewWrongList
+	CodeCTERecursiveRequiresUnion             terror.ErrCode = mysql.ErrCTERecursiveRequiresUnion
+	CodeCTERecursiveRequiresNonRecursiveFirst terror.ErrCode = mysql.ErrCTERecursiveRequiresNonRecursiveFirst
+	CodeCTERecursiveForbidsAggregation        terror.ErrCode = mysql.ErrCTERecursiveForbidsAggregation
+	CodeViewRecursive                         terror.ErrCode = mysql.ErrViewRecursive
+	CodeViewInvalid                           terror.ErrCode = mysql.ErrViewInvalid
+	CodeNonUpdatableTable                     terror.ErrCode = mysql.ErrNonUpdatableTable
+	CodeNonInsertableTable                    terror.ErrCode = mysql.ErrNonInsertableTable
 )
 
 // Optimizer base errors.
 var (
-	ErrOperandColumns              = terror.ClassOptimizer.New(CodeOperandColumns, "Operand should contain %d column(s)")
-	ErrInvalidWildCard             = terror.ClassOptimizer.New(CodeInvalidWildCard, "Wildcard fields without any table name appears in wrong place")
-	ErrCartesianProductUnsupported = terror.ClassOptimizer.New(CodeUnsupported, "Cartesian product is unsupported")
-	ErrInvalidGroupFuncUse         = terror.ClassOptimizer.New(CodeInvalidGroupFuncUse, "Invalid use of group function")
-	ErrIllegalReference            = terror.ClassOptimizer.New(CodeIllegalReference, "Illegal reference")
-	ErrNoDB                        = terror.ClassOptimizer.New(CodeNoDB, "No database selected")
-	ErrUnknownExplainFormat        = terror.ClassOptimizer.New(CodeUnknownExplainFormat, mysql.MySQLErrName[mysql.ErrUnknownExplainFormat])
+	ErrOperandColumns                        = terror.ClassOptimizer.New(CodeOperandColumns, "Operand should contain %d column(s)")
+	ErrInvalidWildCard                       = terror.ClassOptimizer.New(CodeInvalidWildCard, "Wildcard fields without any table name appears in wrong place")
+	ErrCartesianProductUnsupported           = terror.ClassOptimizer.New(CodeUnsupported, "Cartesian product is unsupported")
+	ErrInvalidGroupFuncUse                   = terror.ClassOptimizer.New(CodeInvalidGroupFuncUse, "Invalid use of group function")
+	ErrIllegalReference                      = terror.ClassOptimizer.New(CodeIllegalReference, "Illegal reference")
+	ErrNoDB                                  = terror.ClassOptimizer.New(CodeNoDB, "No database selected")
+	ErrUnknownExplainFormat                  = terror.ClassOptimizer.New(CodeUnknownExplainFormat, mysql.MySQLErrName[mysql.ErrUnknownExplainFormat])
+	ErrNotSupportedYet                       = terror.ClassOptimizer.New(CodeNotSupportedYet, mysql.MySQLErrName[mysql.ErrNotSupportedYet])
+	ErrWindowFrameStartIllegal               = terror.ClassOptimizer.New(CodeWindowFrameStartIllegal, mysql.MySQLErrName[mysql.ErrWindowFrameStartIllegal])
+	ErrWindowFrameEndIllegal                 = terror.ClassOptimizer.New(CodeWindowFrameEndIllegal, mysql.MySQLErrName[mysql.ErrWindowFrameEndIllegal])
+	ErrWindowFrameIllegal                    = terror.ClassOptimizer.New(CodeWindowFrameIllegal, mysql.MySQLErrName[mysql.ErrWindowFrameIllegal])
+	ErrWindowRangeFrameOrderType             = terror.ClassOptimizer.New(CodeWindowRangeFrameOrderType, mysql.MySQLErrName[mysql.ErrWindowRangeFrameOrderType])
+	ErrWindowInvalidWindowFuncUse            = terror.ClassOptimizer.New(CodeWindowInvalidWindowFuncUse, mysql.MySQLErrName[mysql.ErrWindowInvalidWindowFuncUse])
+	ErrNonUniqTable                          = terror.ClassOptimizer.New(CodeNonUniqTable, mysql.MySQLErrName[mysql.ErrNonuniqTable])
+	ErrViewWrongList                         = terror.ClassOptimizer.New(CodeViewWrongList, mysql.MySQLErrName[mysql.ErrViewWrongList])
+	ErrCTERecursiveRequiresUnion             = terror.ClassOptimizer.New(CodeCTERecursiveRequiresUnion, mysql.MySQLErrName[mysql.ErrCTERecursiveRequiresUnion])
+	ErrCTERecursiveRequiresNonRecursiveFirst = terror.ClassOptimizer.New(CodeCTERecursiveRequiresNonRecursiveFirst, mysql.MySQLErrName[mysql.ErrCTERecursiveRequiresNonRecursiveFirst])
+	ErrCTERecursiveForbidsAggregation        = terror.ClassOptimizer.New(CodeCTERecursiveForbidsAggregation, mysql.MySQLErrName[mysql.ErrCTERecursiveForbidsAggregation])
+	ErrViewRecursive                         = terror.ClassOptimizer.New(CodeViewRecursive, mysql.MySQLErrName[mysql.ErrViewRecursive])
+	ErrViewInvalid                           = terror.ClassOptimizer.New(CodeViewInvalid, mysql.MySQLErrName[mysql.ErrViewInvalid])
+	ErrNonUpdatableTable                     = terror.ClassOptimizer.New(CodeNonUpdatableTable, mysql.MySQLErrName[mysql.ErrNonUpdatableTable])
+	ErrNonInsertableTable                    = terror.ClassOptimizer.New(CodeNonInsertableTable, mysql.MySQLErrName[mysql.ErrNonInsertableTable])
 )
 
 func init() {
 	mySQLErrCodes := map[terror.ErrCode]uint16{
-		CodeOperandColumns:       mysql.ErrOperandColumns,
-		CodeInvalidWildCard:      mysql.ErrParse,
-		CodeInvalidGroupFuncUse:  mysql.ErrInvalidGroupFuncUse,
-		CodeIllegalReference:     mysql.ErrIllegalReference,
-		CodeNoDB:                 mysql.ErrNoDB,
-		CodeUnknownExplainFormat: mysql.ErrUnknownExplainFormat,
+		CodeOperandColumns:                        mysql.ErrOperandColumns,
+		CodeInvalidWildCard:                       mysql.ErrParse,
+		CodeInvalidGroupFuncUse:                   mysql.ErrInvalidGroupFuncUse,
+		CodeIllegalReference:                      mysql.ErrIllegalReference,
+		CodeNoDB:                                  mysql.ErrNoDB,
+		CodeUnknownExplainFormat:                  mysql.ErrUnknownExplainFormat,
+		CodeNotSupportedYet:                       mysql.ErrNotSupportedYet,
+		CodeWindowFrameStartIllegal:               mysql.ErrWindowFrameStartIllegal,
+		CodeWindowFrameEndIllegal:                 mysql.ErrWindowFrameEndIllegal,
+		CodeWindowFrameIllegal:                    mysql.ErrWindowFrameIllegal,
+		CodeWindowRangeFrameOrderType:             mysql.ErrWindowRangeFrameOrderType,
+		CodeWindowInvalidWindowFuncUse:            mysql.ErrWindowInvalidWindowFuncUse,
+		CodeNonUniqTable:                          mysql.ErrNonuniqTable,
+		CodeViewWrongList:                         mysql.ErrViewWrongList,
+		CodeCTERecursiveRequiresUnion:             mysql.ErrCTERecursiveRequiresUnion,
+		CodeCTERecursiveRequiresNonRecursiveFirst: mysql.ErrCTERecursiveRequiresNonRecursiveFirst,
+		CodeCTERecursiveForbidsAggregation:        mysql.ErrCTERecursiveForbidsAggregation,
+		CodeViewRecursive:                         mysql.ErrViewRecursive,
+		CodeViewInvalid:                           mysql.ErrViewInvalid,
+		CodeNonUpdatableTable:                     mysql.ErrNonUpdatableTable,
+		CodeNonInsertableTable:                    mysql.ErrNonInsertableTable,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassOptimizer] = mySQLErrCodes
 	expression.EvalAstExpr = evalAstExpr
+	expression.RewriteAstExpr = rewriteAstExpr
 }
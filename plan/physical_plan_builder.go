@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/ranger"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -56,6 +57,7 @@ func (p *DataSource) convert2TableScan(prop *requiredProperty) (*physicalPlanInf
 		physicalTableSource: physicalTableSource{
 			client:          client,
 			NeedColHandle:   p.NeedColHandle,
+			PartitionIDs:    p.partitionIDs,
 			unionScanSchema: p.unionScanSchema,
 		},
 	}.init(p.allocator, p.ctx)
@@ -124,6 +126,7 @@ func (p *DataSource) convert2IndexScan(prop *requiredProperty, index *model.Inde
 		physicalTableSource: physicalTableSource{
 			client:          client,
 			NeedColHandle:   p.NeedColHandle,
+			PartitionIDs:    p.partitionIDs,
 			unionScanSchema: p.unionScanSchema,
 		},
 	}.init(p.allocator, p.ctx)
@@ -186,6 +189,10 @@ func isCoveringIndex(columns []*model.ColumnInfo, indexColumns []*model.IndexCol
 		if colInfo.ID == model.ExtraHandleID {
 			continue
 		}
+		// The index keeps the sort keys instead of the strings in case-insensitive collations.
+		if charset.IsCICollation(colInfo.Collate) {
+			return false
+		}
 		isIndexColumn := false
 		for _, indexCol := range indexColumns {
 			if colInfo.Name.L == indexCol.Name.L && indexCol.Length == types.UnspecifiedLength {
@@ -264,8 +271,15 @@ func (p *DataSource) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlan
 }
 
 // tryToConvert2DummyScan is an optimization which checks if its parent is a selection with a constant condition
-// that evaluates to false. If it is, there is no need for a real physical scan, a dummy scan will do.
+// that evaluates to false, or all the partitions of the table are pruned. If it is, there is no need for a real
+// physical scan, a dummy scan will do.
 func (p *DataSource) tryToConvert2DummyScan(prop *requiredProperty) (*physicalPlanInfo, error) {
+	if p.partitionExpr != nil && len(p.partitionIDs) == 0 {
+		dual := TableDual{}.init(p.allocator, p.ctx)
+		dual.SetSchema(p.schema)
+		info := &physicalPlanInfo{p: dual}
+		return info, errors.Trace(p.storePlanInfo(prop, info))
+	}
 	if len(p.Parents()) == 0 {
 		return nil, nil
 	}
@@ -1185,6 +1199,65 @@ func (p *LogicalAggregation) convert2PhysicalPlan(prop *requiredProperty) (*phys
 	return planInfo, errors.Trace(p.storePlanInfo(prop, planInfo))
 }
 
+// convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
+func (p *LogicalWindow) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
+	info, err := p.getPlanInfo(prop)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if info != nil {
+		return info, nil
+	}
+	window := PhysicalWindow{
+		WindowFuncDescs: p.WindowFuncDescs,
+		PartitionBy:     p.PartitionBy,
+		OrderBy:         p.OrderBy,
+		Frame:           p.Frame,
+	}.init(p.allocator, p.ctx)
+	window.SetSchema(p.schema)
+	child := p.children[0].(LogicalPlan)
+	sortItems := p.sortItems()
+	if len(sortItems) == 0 && p.childContainsProp(prop) {
+		// The window keeps the order of its child, so we can pass the sort property to the child directly.
+		// But the limit can't be pushed down, because every window function needs the whole partition.
+		info, err = child.convert2PhysicalPlan(removeLimit(prop))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		info = addPlanToResponse(window, info)
+		info.cost += info.count * cpuFactor
+		info = enforceProperty(limitProperty(prop.limit), info)
+		return info, errors.Trace(p.storePlanInfo(prop, info))
+	}
+	info, err = child.convert2PhysicalPlan(&requiredProperty{})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	info = addPlanToResponse(window, info)
+	info.cost += info.count * cpuFactor
+	// The output of the window is ordered by its sort items, a property that is a prefix of them needn't be enforced.
+	matched := len(prop.props) <= len(sortItems)
+	for i := 0; matched && i < len(prop.props); i++ {
+		col, ok := sortItems[i].Expr.(*expression.Column)
+		matched = ok && col.Equal(prop.props[i].col, p.ctx) && sortItems[i].Desc == prop.props[i].desc
+	}
+	if matched {
+		info = enforceProperty(limitProperty(prop.limit), info)
+	} else {
+		info = enforceProperty(prop, info)
+	}
+	return info, errors.Trace(p.storePlanInfo(prop, info))
+}
+
+func (p *LogicalWindow) childContainsProp(prop *requiredProperty) bool {
+	for _, c := range prop.props {
+		if !p.children[0].Schema().Contains(c.col) {
+			return false
+		}
+	}
+	return true
+}
+
 // convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
 func (p *Union) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
 	info, err := p.getPlanInfo(prop)
@@ -1217,6 +1290,28 @@ func (p *Union) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo,
 	return info, errors.Trace(p.storePlanInfo(prop, info))
 }
 
+// convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
+func (p *RecursiveCTE) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
+	info, err := p.getPlanInfo(prop)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if info != nil {
+		return info, nil
+	}
+	childInfos := make([]*physicalPlanInfo, 0, len(p.children))
+	for _, child := range p.Children() {
+		childInfo, err := child.(LogicalPlan).convert2PhysicalPlan(&requiredProperty{})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		childInfos = append(childInfos, childInfo)
+	}
+	info = p.matchProperty(prop, childInfos...)
+	info = enforceProperty(prop, info)
+	return info, errors.Trace(p.storePlanInfo(prop, info))
+}
+
 // makeScanController will try to build a selection that controls the below scan's filter condition,
 // and return a physicalPlanInfo. If the onlyCheck is true, it will only check whether this selection
 // can become a scan controller without building the physical plan.
@@ -1245,7 +1340,7 @@ func (p *Selection) makeScanController() *physicalPlanInfo {
 			Table:               ds.tableInfo,
 			Columns:             ds.Columns,
 			DBName:              ds.DBName,
-			physicalTableSource: physicalTableSource{client: ds.ctx.GetClient()},
+			physicalTableSource: physicalTableSource{client: ds.ctx.GetClient(), PartitionIDs: ds.partitionIDs},
 		}.init(p.allocator, p.ctx)
 		ts.SetSchema(ds.schema)
 		if ds.ctx.Txn() != nil {
@@ -1272,7 +1367,7 @@ func (p *Selection) makeScanController() *physicalPlanInfo {
 					Columns:             ds.Columns,
 					OutOfOrder:          true,
 					DBName:              ds.DBName,
-					physicalTableSource: physicalTableSource{client: ds.ctx.GetClient()},
+					physicalTableSource: physicalTableSource{client: ds.ctx.GetClient(), PartitionIDs: ds.partitionIDs},
 				}.init(p.allocator, p.ctx)
 				is.SetSchema(ds.schema)
 				if is.ctx.Txn() != nil {
@@ -50,6 +50,8 @@ var (
 	_ PhysicalPlan = &MaxOneRow{}
 	_ PhysicalPlan = &TableDual{}
 	_ PhysicalPlan = &Union{}
+	_ PhysicalPlan = &RecursiveCTE{}
+	_ PhysicalPlan = &CTETable{}
 	_ PhysicalPlan = &Sort{}
 	_ PhysicalPlan = &Update{}
 	_ PhysicalPlan = &Delete{}
@@ -63,6 +65,7 @@ var (
 	_ PhysicalPlan = &PhysicalIndexReader{}
 	_ PhysicalPlan = &PhysicalIndexLookUpReader{}
 	_ PhysicalPlan = &PhysicalAggregation{}
+	_ PhysicalPlan = &PhysicalWindow{}
 	_ PhysicalPlan = &PhysicalApply{}
 	_ PhysicalPlan = &PhysicalIndexJoin{}
 	_ PhysicalPlan = &PhysicalHashJoin{}
@@ -263,6 +266,9 @@ type physicalTableSource struct {
 	// NeedColHandle is used in execution phase.
 	NeedColHandle bool
 
+	// PartitionIDs are the IDs of the partitions to read if the table is partitioned.
+	PartitionIDs []int64
+
 	// TODO: This should be removed after old planner was removed.
 	unionScanSchema *expression.Schema
 }
@@ -585,6 +591,17 @@ type PhysicalAggregation struct {
 	inputCount float64 // inputCount is the input count of this plan.
 }
 
+// PhysicalWindow is the physical operator of window function.
+type PhysicalWindow struct {
+	*basePlan
+	basePhysicalPlan
+
+	WindowFuncDescs []*WindowFuncDesc
+	PartitionBy     []*ByItems
+	OrderBy         []*ByItems
+	Frame           *WindowFrame
+}
+
 // PhysicalUnionScan represents a union scan operator.
 type PhysicalUnionScan struct {
 	*basePlan
@@ -1038,6 +1055,24 @@ func (p *Union) Copy() PhysicalPlan {
 	return &np
 }
 
+// Copy implements the PhysicalPlan Copy interface.
+func (p *RecursiveCTE) Copy() PhysicalPlan {
+	np := *p
+	np.basePlan = p.basePlan.copy()
+	np.baseLogicalPlan = newBaseLogicalPlan(np.basePlan)
+	np.basePhysicalPlan = newBasePhysicalPlan(np.basePlan)
+	return &np
+}
+
+// Copy implements the PhysicalPlan Copy interface.
+func (p *CTETable) Copy() PhysicalPlan {
+	np := *p
+	np.basePlan = p.basePlan.copy()
+	np.baseLogicalPlan = newBaseLogicalPlan(np.basePlan)
+	np.basePhysicalPlan = newBasePhysicalPlan(np.basePlan)
+	return &np
+}
+
 // Copy implements the PhysicalPlan Copy interface.
 func (p *Sort) Copy() PhysicalPlan {
 	np := *p
@@ -1121,6 +1156,53 @@ func (p *PhysicalAggregation) MarshalJSON() ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// Copy implements the PhysicalPlan Copy interface.
+func (p *PhysicalWindow) Copy() PhysicalPlan {
+	np := *p
+	np.basePlan = p.basePlan.copy()
+	np.basePhysicalPlan = newBasePhysicalPlan(np.basePlan)
+	return &np
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p *PhysicalWindow) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	funcs, err := json.Marshal(p.WindowFuncDescs)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	partitionBy, err := json.Marshal(p.PartitionBy)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	orderBy, err := json.Marshal(p.OrderBy)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	buffer.WriteString(fmt.Sprintf(
+		"\"WindowFuncs\": %s,\n"+
+			"\"PartitionBy\": %s,\n"+
+			"\"OrderBy\": %s,\n"+
+			"\"child\": \"%s\"}", funcs, partitionBy, orderBy, p.children[0].ExplainID()))
+	return buffer.Bytes(), nil
+}
+
+func (p *PhysicalWindow) extractCorrelatedCols() []*expression.CorrelatedColumn {
+	corCols := p.basePlan.extractCorrelatedCols()
+	for _, desc := range p.WindowFuncDescs {
+		for _, arg := range desc.Args {
+			corCols = append(corCols, extractCorColumns(arg)...)
+		}
+	}
+	for _, item := range p.PartitionBy {
+		corCols = append(corCols, extractCorColumns(item.Expr)...)
+	}
+	for _, item := range p.OrderBy {
+		corCols = append(corCols, extractCorColumns(item.Expr)...)
+	}
+	return corCols
+}
+
 // Copy implements the PhysicalPlan Copy interface.
 func (p *Update) Copy() PhysicalPlan {
 	np := *p
@@ -1181,7 +1263,13 @@ func buildSchema(p PhysicalPlan) {
 		} else {
 			x.SetSchema(x.children[0].Schema().Clone())
 		}
-	case *Union:
+	case *PhysicalWindow:
+		schema := x.children[0].Schema().Clone()
+		for _, col := range x.schema.Columns[x.schema.Len()-len(x.WindowFuncDescs):] {
+			schema.Append(col)
+		}
+		x.SetSchema(schema)
+	case *Union, *RecursiveCTE:
 		panic("Union shouldn't rebuild schema")
 	}
 }
@@ -1198,7 +1286,7 @@ func rebuildSchema(p PhysicalPlan) bool {
 	switch p.(type) {
 	case *PhysicalIndexJoin, *PhysicalHashJoin, *PhysicalMergeJoin:
 		needRebuild = true
-	case *Projection, *PhysicalAggregation:
+	case *Projection, *PhysicalAggregation, *RecursiveCTE:
 		needRebuild = false
 	}
 	return needRebuild
@@ -15,6 +15,7 @@ package plan
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/juju/errors"
@@ -28,6 +29,7 @@ import (
 	"github.com/pingcap/tidb/parser/opcode"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/auth"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -75,6 +77,13 @@ type visitInfo struct {
 	column    string
 }
 
+// definerVisitInfo is the visit information of the underlying tables of a SQL SECURITY DEFINER view,
+// the privileges of the definer are checked instead of the current user.
+type definerVisitInfo struct {
+	visitInfo
+	definer *auth.UserIdentity
+}
+
 type tableHintInfo struct {
 	indexNestedLoopJoinTables []model.CIStr
 	sortMergeJoinTables       []model.CIStr
@@ -127,10 +136,21 @@ type planBuilder struct {
 	needColHandle int
 	// colMapper stores the column that must be pre-resolved.
 	colMapper map[*ast.ColumnNameExpr]int
+	// windowMapper stores the column index of the window functions in the select being built.
+	windowMapper map[*ast.WindowFuncExpr]int
+	// ctes stores the common table expressions which are visible to the query being built.
+	ctes []*cteInfo
+	// expandingViews stores the IDs of the views being expanded, it's used to detect view recursion.
+	expandingViews []int64
+	// wildCardExpansions stores the qualified column names the wildcard fields are expanded to, it's only
+	// collected when building CREATE VIEW, so the view doesn't change when the columns of the tables change.
+	wildCardExpansions map[*ast.SelectField][]string
 	// Collect the visit information for privilege check.
-	visitInfo     []visitInfo
-	tableHintInfo []tableHintInfo
-	optFlag       uint64
+	visitInfo []visitInfo
+	// definerVisitInfo collects the visit information which is checked against the definer of a view.
+	definerVisitInfo []definerVisitInfo
+	tableHintInfo    []tableHintInfo
+	optFlag          uint64
 }
 
 func (b *planBuilder) build(node ast.Node) Plan {
@@ -168,7 +188,8 @@ func (b *planBuilder) build(node ast.Node) Plan {
 		return b.buildAnalyze(x)
 	case *ast.BinlogStmt, *ast.FlushStmt, *ast.UseStmt,
 		*ast.BeginStmt, *ast.CommitStmt, *ast.RollbackStmt, *ast.CreateUserStmt, *ast.SetPwdStmt,
-		*ast.GrantStmt, *ast.DropUserStmt, *ast.AlterUserStmt, *ast.RevokeStmt, *ast.KillStmt, *ast.DropStatsStmt:
+		*ast.GrantStmt, *ast.DropUserStmt, *ast.AlterUserStmt, *ast.RevokeStmt, *ast.KillStmt, *ast.DropStatsStmt,
+		*ast.GrantRoleStmt, *ast.RevokeRoleStmt, *ast.SetRoleStmt, *ast.SetDefaultRoleStmt:
 		return b.buildSimple(node.(ast.StmtNode))
 	case ast.DDLNode:
 		return b.buildDDL(x)
@@ -265,6 +286,22 @@ func (b *planBuilder) detectSelectAgg(sel *ast.SelectStmt) bool {
 	return false
 }
 
+func (b *planBuilder) detectSelectWindow(sel *ast.SelectStmt) bool {
+	for _, f := range sel.GetResultFields() {
+		if ast.HasWindowFlag(f.Expr) {
+			return true
+		}
+	}
+	if sel.OrderBy != nil {
+		for _, item := range sel.OrderBy.Items {
+			if ast.HasWindowFlag(item.Expr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func availableIndices(hints []*ast.IndexHint, tableInfo *model.TableInfo) (indices []*model.IndexInfo, includeTableScan bool) {
 	var usableHints []*ast.IndexHint
 	for _, hint := range hints {
@@ -592,11 +629,11 @@ func (b *planBuilder) buildSimple(node ast.StmtNode) Plan {
 	p.SetSchema(expression.NewSchema())
 
 	switch raw := node.(type) {
-	case *ast.CreateUserStmt, *ast.DropUserStmt, *ast.AlterUserStmt:
+	case *ast.CreateUserStmt, *ast.DropUserStmt, *ast.AlterUserStmt, *ast.SetDefaultRoleStmt:
 		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.CreateUserPriv, "", "", "")
 	case *ast.GrantStmt:
 		b.visitInfo = collectVisitInfoFromGrantStmt(b.visitInfo, raw)
-	case *ast.SetPwdStmt, *ast.RevokeStmt, *ast.KillStmt:
+	case *ast.SetPwdStmt, *ast.RevokeStmt, *ast.KillStmt, *ast.GrantRoleStmt, *ast.RevokeRoleStmt:
 		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SuperPriv, "", "", "")
 	}
 	return p
@@ -700,6 +737,10 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 		return nil
 	}
 	tableInfo := tn.TableInfo
+	if tableInfo.IsView() {
+		b.err = ErrNonInsertableTable.GenByArgs(tn.Name.O, "INSERT")
+		return nil
+	}
 	// Build Schema with DBName otherwise ColumnRef with DBName cannot match any Column in Schema.
 	schema := expression.TableInfo2SchemaWithDBName(tn.Schema, tableInfo)
 	tableInPlan, ok := b.is.TableByID(tableInfo.ID)
@@ -898,6 +939,10 @@ func (b *planBuilder) buildLoadData(ld *ast.LoadDataStmt) Plan {
 		LinesInfo:  ld.LinesInfo,
 	}
 	tableInfo := p.Table.TableInfo
+	if tableInfo.IsView() {
+		b.err = ErrNonInsertableTable.GenByArgs(tableInfo.Name.O, "LOAD")
+		return nil
+	}
 	tableInPlan, ok := b.is.TableByID(tableInfo.ID)
 	if !ok {
 		db := b.ctx.GetSessionVars().CurrentDB
@@ -944,6 +989,29 @@ func (b *planBuilder) buildDDL(node ast.DDLNode) Plan {
 				table:     v.ReferTable.Name.L,
 			})
 		}
+	case *ast.CreateViewStmt:
+		b.visitInfo = append(b.visitInfo, visitInfo{
+			privilege: mysql.CreatePriv,
+			db:        v.ViewName.Schema.L,
+			table:     v.ViewName.Name.L,
+		})
+		// The SELECT statement is built to check its validity and get the schema of the view.
+		b.wildCardExpansions = collectWildCardFields(v.Select)
+		selPlan := b.buildResultSetNode(v.Select)
+		if b.err != nil {
+			return nil
+		}
+		if len(v.Cols) > 0 && len(v.Cols) != selPlan.Schema().Len() {
+			b.err = ErrViewWrongList.GenByArgs()
+			return nil
+		}
+		p := &DDL{
+			Statement:      node,
+			ViewSchema:     selPlan.Schema(),
+			ViewSelectText: expandViewWildCards(v, b.wildCardExpansions),
+		}
+		p.SetSchema(expression.NewSchema())
+		return p
 	case *ast.DropDatabaseStmt:
 		b.visitInfo = append(b.visitInfo, visitInfo{
 			privilege: mysql.DropPriv,
@@ -997,7 +1065,7 @@ func (b *planBuilder) buildExplain(explain *ast.ExplainStmt) Plan {
 		return nil
 	}
 	setParents4FinalPlan(targetPlan.(PhysicalPlan))
-	p := &Explain{StmtPlan: targetPlan}
+	p := &Explain{StmtPlan: targetPlan, Format: explain.Format, Analyze: explain.Analyze}
 	if UseDAGPlanBuilder(b.ctx) {
 		switch strings.ToLower(explain.Format) {
 		case ast.ExplainFormatROW:
@@ -1007,9 +1075,10 @@ func (b *planBuilder) buildExplain(explain *ast.ExplainStmt) Plan {
 				schema.Append(buildColumn("", fieldName, mysql.TypeString, mysql.MaxBlobWidth))
 			}
 			schema.Append(buildColumn("", "count", mysql.TypeDouble, mysql.MaxRealWidth))
+			if explain.Analyze {
+				schema.Append(buildColumn("", "execution info", mysql.TypeString, mysql.MaxBlobWidth))
+			}
 			p.SetSchema(schema)
-			p.explainedPlans = map[int]bool{}
-			p.prepareRootTaskInfo(p.StmtPlan.(PhysicalPlan))
 		case ast.ExplainFormatDOT:
 			retFields := []string{"dot contents"}
 			schema := expression.NewSchema(make([]*expression.Column, 0, len(retFields))...)
@@ -1017,10 +1086,16 @@ func (b *planBuilder) buildExplain(explain *ast.ExplainStmt) Plan {
 				schema.Append(buildColumn("", fieldName, mysql.TypeString, mysql.MaxBlobWidth))
 			}
 			p.SetSchema(schema)
-			p.prepareDotInfo(p.StmtPlan.(PhysicalPlan))
 		default:
 			b.err = errors.Errorf("explain format '%s' is not supported now", explain.Format)
 		}
+		// The rows of EXPLAIN ANALYZE are generated after the statement is executed.
+		if !explain.Analyze {
+			p.RenderResult(nil)
+		}
+	} else if explain.Analyze {
+		b.err = errors.New("explain analyze is not supported now")
+		return nil
 	} else {
 		schema := expression.NewSchema(make([]*expression.Column, 0, 3)...)
 		schema.Append(buildColumn("", "ID", mysql.TypeString, mysql.MaxBlobWidth))
@@ -1141,6 +1216,8 @@ func buildShowSchema(s *ast.ShowStmt) (schema *expression.Schema) {
 		names = []string{"Table", "Create Table"}
 	case ast.ShowCreateDatabase:
 		names = []string{"Database", "Create Database"}
+	case ast.ShowCreateView:
+		names = []string{"View", "Create View", "character_set_client", "collation_connection"}
 	case ast.ShowGrants:
 		names = []string{fmt.Sprintf("Grants for %s", s.User)}
 	case ast.ShowIndex:
@@ -1194,3 +1271,74 @@ func buildShowSchema(s *ast.ShowStmt) (schema *expression.Schema) {
 	}
 	return schema
 }
+
+// wildCardCollector collects the wildcard fields of a statement.
+type wildCardCollector struct {
+	fields map[*ast.SelectField][]string
+}
+
+// Enter implements Visitor interface.
+func (c *wildCardCollector) Enter(in ast.Node) (out ast.Node, skipChildren bool) {
+	if field, ok := in.(*ast.SelectField); ok && field.WildCard != nil {
+		c.fields[field] = nil
+	}
+	return in, false
+}
+
+// Leave implements Visitor interface.
+func (c *wildCardCollector) Leave(in ast.Node) (out ast.Node, ok bool) {
+	return in, true
+}
+
+func collectWildCardFields(node ast.Node) map[*ast.SelectField][]string {
+	c := &wildCardCollector{fields: make(map[*ast.SelectField][]string)}
+	node.Accept(c)
+	return c.fields
+}
+
+// expandViewWildCards replaces the wildcard fields in the text of the SELECT statement of a view with the
+// column names they are expanded to, as MySQL does, so adding or dropping the columns of the underlying
+// tables doesn't change the columns the view selects.
+func expandViewWildCards(v *ast.CreateViewStmt, expansions map[*ast.SelectField][]string) string {
+	text := v.Select.Text()
+	var fields []*ast.SelectField
+	for field := range expansions {
+		start := field.Offset - v.SelectOffset
+		if field.WildCard != nil && start >= 0 && start < len(text) {
+			fields = append(fields, field)
+		}
+	}
+	// Replace from the end of the text, so the offsets of the preceding fields are unchanged.
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Offset > fields[j].Offset })
+	for _, field := range fields {
+		start := field.Offset - v.SelectOffset
+		end := wildCardEnd(text, start)
+		if end < 0 {
+			continue
+		}
+		text = text[:start] + strings.Join(expansions[field], ", ") + text[end:]
+	}
+	return text
+}
+
+// wildCardEnd returns the end offset of the wildcard field which starts at start, it skips the quoted
+// identifiers before the '*'. It returns -1 if the '*' is not found.
+func wildCardEnd(text string, start int) int {
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '`':
+			for i++; i < len(text); i++ {
+				if text[i] == '`' {
+					if i+1 < len(text) && text[i+1] == '`' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case '*':
+			return i + 1
+		}
+	}
+	return -1
+}
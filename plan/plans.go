@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/util/auth"
+	"github.com/pingcap/tidb/util/execdetails"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -191,6 +192,10 @@ type DDL struct {
 	basePlan
 
 	Statement ast.DDLNode
+	// ViewSchema is the schema of the SELECT statement in CREATE VIEW.
+	ViewSchema *expression.Schema
+	// ViewSelectText is the text of the SELECT statement in CREATE VIEW with the wildcards expanded.
+	ViewSelectText string
 }
 
 // Explain represents a explain plan.
@@ -198,8 +203,26 @@ type Explain struct {
 	basePlan
 
 	StmtPlan       Plan
+	Format         string
+	Analyze        bool
 	Rows           [][]types.Datum
 	explainedPlans map[int]bool
+	// runtimeStatsColl holds the runtime statistics of the executed statement for EXPLAIN ANALYZE.
+	runtimeStatsColl *execdetails.RuntimeStatsColl
+}
+
+// RenderResult generates the explain rows of the DAG plan. For EXPLAIN ANALYZE, it's called after
+// the statement is executed, and the runtime statistics are attached to the rows.
+func (e *Explain) RenderResult(runtimeStatsColl *execdetails.RuntimeStatsColl) {
+	e.Rows = nil
+	e.runtimeStatsColl = runtimeStatsColl
+	switch strings.ToLower(e.Format) {
+	case ast.ExplainFormatROW:
+		e.explainedPlans = map[int]bool{}
+		e.prepareRootTaskInfo(e.StmtPlan.(PhysicalPlan))
+	case ast.ExplainFormatDOT:
+		e.prepareDotInfo(e.StmtPlan.(PhysicalPlan))
+	}
 }
 
 func (e *Explain) prepareExplainInfo(p Plan, parent Plan) error {
@@ -239,9 +262,27 @@ func (e *Explain) prepareExplainInfo4DAGTask(p PhysicalPlan, taskType string) {
 	operatorInfo := p.ExplainInfo()
 	count := p.statsProfile().count
 	row := types.MakeDatums(p.ExplainID(), parentInfo, childrenInfo, taskType, operatorInfo, count)
+	if e.Analyze {
+		row = append(row, types.NewStringDatum(e.executionInfo(p, taskType)))
+	}
 	e.Rows = append(e.Rows, row)
 }
 
+// executionInfo returns the runtime statistics of the plan. The coprocessor statistics are attached to
+// the top plan of the cop-task, because the rows of the cop-task are returned by it.
+func (e *Explain) executionInfo(p PhysicalPlan, taskType string) string {
+	if taskType == "cop" {
+		if e.runtimeStatsColl.ExistsCopStats(p.ExplainID()) {
+			return e.runtimeStatsColl.GetCopStats(p.ExplainID()).String()
+		}
+		return ""
+	}
+	if e.runtimeStatsColl.ExistsRootStats(p.ExplainID()) {
+		return e.runtimeStatsColl.GetRootStats(p.ExplainID()).String()
+	}
+	return "never executed"
+}
+
 // prepareCopTaskInfo generates explain information for cop-tasks.
 // Only PhysicalTableReader, PhysicalIndexReader and PhysicalIndexLookUpReader have cop-tasks currently.
 func (e *Explain) prepareCopTaskInfo(plans []PhysicalPlan) {
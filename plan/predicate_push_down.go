@@ -13,12 +13,17 @@
 package plan
 
 import (
+	"math"
+	"strconv"
+
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/table/tables"
+	"github.com/pingcap/tidb/util/ranger"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -55,12 +60,112 @@ func (p *Selection) PredicatePushDown(predicates []expression.Expression) ([]exp
 
 // PredicatePushDown implements LogicalPlan PredicatePushDown interface.
 func (p *DataSource) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
+	if p.partitionExpr != nil {
+		if err := p.prunePartitions(predicates); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
 	if UseDAGPlanBuilder(p.ctx) {
 		_, p.pushedDownConds, predicates = expression.ExpressionsToPB(p.ctx.GetSessionVars().StmtCtx, predicates, p.ctx.GetClient())
 	}
 	return predicates, p, nil
 }
 
+// prunePartitions removes the partitions which can't contain any row satisfying the predicates.
+// Only the tables partitioned by a single column can be pruned.
+func (p *DataSource) prunePartitions(predicates []expression.Expression) error {
+	partCol, ok := p.partitionExpr.(*expression.Column)
+	if !ok || mysql.HasUnsignedFlag(partCol.RetType.Flag) {
+		return nil
+	}
+	conds := make([]expression.Expression, 0, len(predicates))
+	for _, cond := range predicates {
+		conds = append(conds, cond.Clone())
+	}
+	accessConds, _ := ranger.DetachColumnConditions(conds, partCol.ColName)
+	if len(accessConds) == 0 {
+		return nil
+	}
+	ranges, err := ranger.BuildTableRange(accessConds, p.ctx.GetSessionVars().StmtCtx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	pi := p.tableInfo.Partition
+	if pi.Type == model.PartitionTypeHash {
+		p.partitionIDs = pruneHashPartitions(pi, ranges)
+		return nil
+	}
+	p.partitionIDs, err = pruneRangePartitions(pi, ranges)
+	return errors.Trace(err)
+}
+
+// pruneRangePartitions returns the IDs of the range partitions which overlap the ranges.
+// The NULL value is built as math.MinInt64 and it belongs to the first partition.
+func pruneRangePartitions(pi *model.PartitionInfo, ranges []types.IntColumnRange) ([]int64, error) {
+	ids := make([]int64, 0, len(pi.Definitions))
+	lower := int64(math.MinInt64)
+	for _, def := range pi.Definitions {
+		unbounded := def.LessThan[0] == tables.PartitionMaxValue
+		var upper int64
+		if !unbounded {
+			var err error
+			upper, err = strconv.ParseInt(def.LessThan[0], 10, 64)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		for _, ran := range ranges {
+			if ran.HighVal >= lower && (unbounded || ran.LowVal < upper) {
+				ids = append(ids, def.ID)
+				break
+			}
+		}
+		lower = upper
+	}
+	return ids, nil
+}
+
+// pruneHashPartitions returns the IDs of the hash partitions which the values in the ranges belong to.
+// If the ranges contain too many values, all the partitions are returned.
+func pruneHashPartitions(pi *model.PartitionInfo, ranges []types.IntColumnRange) []int64 {
+	num := int64(len(pi.Definitions))
+	hit := make([]bool, num)
+	for _, ran := range ranges {
+		if uint64(ran.HighVal-ran.LowVal) >= uint64(num) {
+			return getPartitionIDs(pi)
+		}
+		for v := ran.LowVal; ; v++ {
+			idx := v % num
+			if idx < 0 {
+				idx = -idx
+			}
+			hit[idx] = true
+			if v == ran.HighVal {
+				break
+			}
+		}
+		// The NULL value is built as math.MinInt64 and it belongs to the first partition.
+		if ran.LowVal == math.MinInt64 {
+			hit[0] = true
+		}
+	}
+	ids := make([]int64, 0, num)
+	for i, def := range pi.Definitions {
+		if hit[i] {
+			ids = append(ids, def.ID)
+		}
+	}
+	return ids
+}
+
+func getPartitionIDs(pi *model.PartitionInfo) []int64 {
+	ids := make([]int64, 0, len(pi.Definitions))
+	for _, def := range pi.Definitions {
+		ids = append(ids, def.ID)
+	}
+	return ids
+}
+
 // PredicatePushDown implements LogicalPlan PredicatePushDown interface.
 func (p *TableDual) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
 	return predicates, p, nil
@@ -423,6 +528,37 @@ func (p *LogicalAggregation) PredicatePushDown(predicates []expression.Expressio
 	return ret, retPlan, errors.Trace(err)
 }
 
+// PredicatePushDown implements LogicalPlan PredicatePushDown interface.
+// Only the predicates that merely reference the partition by columns can be pushed down, because
+// filtering a whole partition doesn't change the results of the other partitions.
+func (p *LogicalWindow) PredicatePushDown(predicates []expression.Expression) (ret []expression.Expression, retPlan LogicalPlan, err error) {
+	partitionCols := make([]*expression.Column, 0, len(p.PartitionBy))
+	for _, item := range p.PartitionBy {
+		if col, ok := item.Expr.(*expression.Column); ok {
+			partitionCols = append(partitionCols, col)
+		}
+	}
+	partitionSchema := expression.NewSchema(partitionCols...)
+	var condsToPush []expression.Expression
+	for _, cond := range predicates {
+		extractedCols := expression.ExtractColumns(cond)
+		ok := len(extractedCols) > 0
+		for _, col := range extractedCols {
+			if !partitionSchema.Contains(col) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			condsToPush = append(condsToPush, cond)
+		} else {
+			ret = append(ret, cond)
+		}
+	}
+	_, _, err = p.baseLogicalPlan.PredicatePushDown(condsToPush)
+	return ret, p, errors.Trace(err)
+}
+
 // PredicatePushDown implements LogicalPlan PredicatePushDown interface.
 func (p *Limit) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
 	// Limit forbids any condition to push down.
@@ -430,6 +566,18 @@ func (p *Limit) PredicatePushDown(predicates []expression.Expression) ([]express
 	return predicates, p, errors.Trace(err)
 }
 
+// PredicatePushDown implements LogicalPlan PredicatePushDown interface.
+func (p *RecursiveCTE) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
+	// The conditions can't be pushed down, because the filtered rows may produce new rows in the next iteration.
+	for _, child := range p.children {
+		_, _, err := child.(LogicalPlan).PredicatePushDown(nil)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+	return predicates, p, nil
+}
+
 // PredicatePushDown implements LogicalPlan PredicatePushDown interface.
 func (p *MaxOneRow) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
 	// MaxOneRow forbids any condition to push down.
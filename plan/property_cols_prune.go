@@ -45,6 +45,13 @@ func (p *baseLogicalPlan) preparePossibleProperties() [][]*expression.Column {
 	return nil
 }
 
+func (p *RecursiveCTE) preparePossibleProperties() [][]*expression.Column {
+	for _, child := range p.children {
+		child.(LogicalPlan).preparePossibleProperties()
+	}
+	return nil
+}
+
 func (p *LogicalJoin) preparePossibleProperties() [][]*expression.Column {
 	leftProperties := p.children[0].(LogicalPlan).preparePossibleProperties()
 	rightProperties := p.children[1].(LogicalPlan).preparePossibleProperties()
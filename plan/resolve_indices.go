@@ -193,6 +193,32 @@ func (p *PhysicalAggregation) ResolveIndices() {
 	}
 }
 
+// ResolveIndices implements Plan interface.
+func (p *LogicalWindow) ResolveIndices() {
+	p.basePlan.ResolveIndices()
+	resolveWindowIndices(p.children[0].Schema(), p.WindowFuncDescs, p.PartitionBy, p.OrderBy)
+}
+
+// ResolveIndices implements Plan interface.
+func (p *PhysicalWindow) ResolveIndices() {
+	p.basePlan.ResolveIndices()
+	resolveWindowIndices(p.children[0].Schema(), p.WindowFuncDescs, p.PartitionBy, p.OrderBy)
+}
+
+func resolveWindowIndices(schema *expression.Schema, descs []*WindowFuncDesc, partitionBy, orderBy []*ByItems) {
+	for _, desc := range descs {
+		for _, arg := range desc.Args {
+			arg.ResolveIndices(schema)
+		}
+	}
+	for _, item := range partitionBy {
+		item.Expr.ResolveIndices(schema)
+	}
+	for _, item := range orderBy {
+		item.Expr.ResolveIndices(schema)
+	}
+}
+
 // ResolveIndices implements Plan interface.
 func (p *Sort) ResolveIndices() {
 	p.basePlan.ResolveIndices()
@@ -41,7 +41,12 @@ const (
 // It generates ResultFields for ResultSetNode and resolves ColumnNameExpr to a ResultField.
 func ResolveName(node ast.Node, info infoschema.InfoSchema, ctx context.Context) error {
 	defaultSchema := ctx.GetSessionVars().CurrentDB
-	resolver := nameResolver{Info: info, Ctx: ctx, DefaultSchema: model.NewCIStr(defaultSchema)}
+	return resolveNameInSchema(node, info, model.NewCIStr(defaultSchema), ctx)
+}
+
+// resolveNameInSchema resolves table name and column name, unqualified table names refer to the tables in defaultSchema.
+func resolveNameInSchema(node ast.Node, info infoschema.InfoSchema, defaultSchema model.CIStr, ctx context.Context) error {
+	resolver := nameResolver{Info: info, Ctx: ctx, DefaultSchema: defaultSchema}
 	node.Accept(&resolver)
 	return errors.Trace(resolver.Err)
 }
@@ -86,6 +91,8 @@ type resolverContext struct {
 	fieldList []*ast.ResultField
 	// result fields collected in group by clause.
 	groupBy []*ast.ResultField
+	// common table expressions defined in the with clause.
+	cteMap map[string]*ast.CommonTableExpression
 
 	// The join node stack is used by on condition to find out
 	// available tables to reference. On condition can only
@@ -119,6 +126,9 @@ type resolverContext struct {
 	inShow bool
 	// When visiting create/alter table statement.
 	inColumnOption bool
+	// When visiting with clause with recursive keyword, a common table expression
+	// is available in its own definition.
+	inRecursiveWith bool
 }
 
 // currentContext gets the current resolverContext.
@@ -135,6 +145,7 @@ func (nr *nameResolver) pushContext() {
 	nr.contextStack = append(nr.contextStack, &resolverContext{
 		tableMap:        map[string]int{},
 		derivedTableMap: map[string]int{},
+		cteMap:          map[string]*ast.CommonTableExpression{},
 	})
 }
 
@@ -190,11 +201,18 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 				return inNode, true
 			}
 		}
+	case *ast.CommonTableExpression:
+		if nr.currentContext().inRecursiveWith {
+			nr.handleCommonTableExpression(v)
+		}
 	case *ast.CreateIndexStmt:
 		nr.pushContext()
 	case *ast.CreateTableStmt:
 		nr.pushContext()
 		nr.currentContext().inCreateOrDropTable = true
+	case *ast.CreateViewStmt:
+		nr.handleCreateView(v)
+		return inNode, true
 	case *ast.ColumnOption:
 		nr.currentContext().inColumnOption = true
 	case *ast.DeleteStmt:
@@ -249,6 +267,8 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 		nr.pushContext()
 	case *ast.UpdateStmt:
 		nr.pushContext()
+	case *ast.WithClause:
+		nr.currentContext().inRecursiveWith = v.IsRecursive
 	}
 	return inNode, false
 }
@@ -273,6 +293,10 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 		nr.handleTableName(v)
 	case *ast.ColumnNameExpr:
 		nr.handleColumnName(v)
+	case *ast.CommonTableExpression:
+		if !nr.currentContext().inRecursiveWith {
+			nr.handleCommonTableExpression(v)
+		}
 	case *ast.CreateIndexStmt:
 		nr.popContext()
 	case *ast.CreateTableStmt:
@@ -355,6 +379,8 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 		nr.popContext()
 	case *ast.UpdateStmt:
 		nr.popContext()
+	case *ast.WithClause:
+		nr.currentContext().inRecursiveWith = false
 	}
 	return inNode, nr.Err == nil
 }
@@ -362,8 +388,12 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 // handleTableName looks up and sets the schema information and result fields for table name.
 func (nr *nameResolver) handleTableName(tn *ast.TableName) {
 	if tn.Schema.L == "" {
-		sessionVars := nr.Ctx.GetSessionVars()
-		if sessionVars.CurrentDB == "" {
+		if cte := nr.findCommonTableExpression(tn.Name); cte != nil {
+			// The schema is left empty, so the plan builder knows it refers to a common table expression.
+			nr.handleCTEName(tn, cte)
+			return
+		}
+		if nr.DefaultSchema.L == "" {
 			nr.Err = errors.Trace(ErrNoDB)
 			return
 		}
@@ -430,6 +460,86 @@ func (nr *nameResolver) handleTableName(tn *ast.TableName) {
 	tn.SetResultFields(rfs)
 }
 
+// handleCreateView resolves the SELECT statement of a view in the schema of the view, so unqualified table names
+// refer to the same tables when the view is expanded.
+func (nr *nameResolver) handleCreateView(v *ast.CreateViewStmt) {
+	if v.ViewName.Schema.L == "" {
+		if nr.DefaultSchema.L == "" {
+			nr.Err = errors.Trace(ErrNoDB)
+			return
+		}
+		v.ViewName.Schema = nr.DefaultSchema
+	}
+	nr.Err = resolveNameInSchema(v.Select, nr.Info, v.ViewName.Schema, nr.Ctx)
+}
+
+// handleCommonTableExpression checks name duplication and puts the common table expression
+// in current resolverContext.
+func (nr *nameResolver) handleCommonTableExpression(cte *ast.CommonTableExpression) {
+	ctx := nr.currentContext()
+	if _, ok := ctx.cteMap[cte.Name.L]; ok {
+		nr.Err = ErrNonUniqTable.GenByArgs(cte.Name.O)
+		return
+	}
+	ctx.cteMap[cte.Name.L] = cte
+}
+
+// findCommonTableExpression looks up the common table expression from top to bottom in the context stack.
+func (nr *nameResolver) findCommonTableExpression(name model.CIStr) *ast.CommonTableExpression {
+	ctx := nr.currentContext()
+	if ctx == nil || ctx.inCreateOrDropTable || ctx.inDeleteTableList {
+		return nil
+	}
+	for i := len(nr.contextStack) - 1; i >= 0; i-- {
+		if cte, ok := nr.contextStack[i].cteMap[name.L]; ok {
+			return cte
+		}
+	}
+	return nil
+}
+
+// handleCTEName sets the result fields for a table name which refers to a common table expression.
+func (nr *nameResolver) handleCTEName(tn *ast.TableName, cte *ast.CommonTableExpression) {
+	srcFields := cte.Query.Query.GetResultFields()
+	if srcFields == nil {
+		// The common table expression is referenced in its own definition,
+		// the result fields come from the first query block of the union.
+		union, ok := cte.Query.Query.(*ast.UnionStmt)
+		if !ok {
+			nr.Err = ErrCTERecursiveRequiresUnion.GenByArgs(cte.Name.O)
+			return
+		}
+		srcFields = union.SelectList.Selects[0].GetResultFields()
+		if srcFields == nil {
+			nr.Err = ErrCTERecursiveRequiresNonRecursiveFirst.GenByArgs(cte.Name.O)
+			return
+		}
+	}
+	if len(cte.ColNameList) > 0 && len(cte.ColNameList) != len(srcFields) {
+		nr.Err = ErrViewWrongList.GenByArgs()
+		return
+	}
+	tn.TableInfo = &model.TableInfo{Name: cte.Name}
+	rfs := make([]*ast.ResultField, 0, len(srcFields))
+	for i, f := range srcFields {
+		col := *f.Column
+		if len(cte.ColNameList) > 0 {
+			col.Name = cte.ColNameList[i]
+		} else if f.ColumnAsName.L != "" {
+			col.Name = f.ColumnAsName
+		}
+		expr := &ast.ValueExpr{}
+		expr.SetType(&col.FieldType)
+		rfs = append(rfs, &ast.ResultField{
+			Column:    &col,
+			Table:     tn.TableInfo,
+			Expr:      expr,
+			TableName: tn,
+		})
+	}
+	tn.SetResultFields(rfs)
+}
+
 // handleTableSources checks name duplication
 // and puts the table source in current resolverContext.
 // Note:
@@ -962,6 +1072,8 @@ func (nr *nameResolver) fillShowFields(s *ast.ShowStmt) {
 		names = []string{"Table", "Create Table"}
 	case ast.ShowCreateDatabase:
 		names = []string{"Database", "Create Database"}
+	case ast.ShowCreateView:
+		names = []string{"View", "Create View", "character_set_client", "collation_connection"}
 	case ast.ShowGrants:
 		names = []string{fmt.Sprintf("Grants for %s", s.User)}
 	case ast.ShowTriggers:
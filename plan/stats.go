@@ -100,6 +100,20 @@ func (p *Selection) prepareStatsProfile() *statsProfile {
 	return p.profile
 }
 
+func (p *RecursiveCTE) prepareStatsProfile() *statsProfile {
+	p.profile = &statsProfile{
+		cardinality: make([]float64, p.schema.Len()),
+	}
+	for _, child := range p.children {
+		childProfile := child.(LogicalPlan).prepareStatsProfile()
+		p.profile.count += childProfile.count
+		for i := range p.profile.cardinality {
+			p.profile.cardinality[i] += childProfile.cardinality[i]
+		}
+	}
+	return p.profile
+}
+
 func (p *Union) prepareStatsProfile() *statsProfile {
 	p.profile = &statsProfile{
 		cardinality: make([]float64, p.schema.Len()),
@@ -181,6 +195,20 @@ func (p *Projection) prepareStatsProfile() *statsProfile {
 	return p.profile
 }
 
+func (p *LogicalWindow) prepareStatsProfile() *statsProfile {
+	childProfile := p.children[0].(LogicalPlan).prepareStatsProfile()
+	p.profile = &statsProfile{
+		count:       childProfile.count,
+		cardinality: make([]float64, p.schema.Len()),
+	}
+	copy(p.profile.cardinality, childProfile.cardinality)
+	// We cannot estimate the cardinality of window functions, so we use a conservative strategy.
+	for i := len(childProfile.cardinality); i < len(p.profile.cardinality); i++ {
+		p.profile.cardinality[i] = childProfile.count
+	}
+	return p.profile
+}
+
 func (p *LogicalAggregation) prepareStatsProfile() *statsProfile {
 	childProfile := p.children[0].(LogicalPlan).prepareStatsProfile()
 	var gbyCols []*expression.Column
@@ -404,6 +404,19 @@ func (p *Union) attach2Task(tasks ...task) task {
 	return newTask
 }
 
+func (p *RecursiveCTE) attach2Task(tasks ...task) task {
+	np := p.Copy()
+	newTask := &rootTask{p: np}
+	newChildren := make([]Plan, 0, len(p.children))
+	for _, task := range tasks {
+		task = finishCopTask(task, p.ctx, p.allocator)
+		newTask.cst += task.cost()
+		newChildren = append(newChildren, task.plan())
+	}
+	np.SetChildren(newChildren...)
+	return newTask
+}
+
 func (sel *Selection) attach2Task(tasks ...task) task {
 	t := finishCopTask(tasks[0].copy(), sel.ctx, sel.allocator)
 	t.addCost(t.count() * cpuFactor)
@@ -511,3 +524,10 @@ func (p *PhysicalAggregation) attach2Task(tasks ...task) task {
 	}
 	return task
 }
+
+func (p *PhysicalWindow) attach2Task(tasks ...task) task {
+	t := finishCopTask(tasks[0].copy(), p.ctx, p.allocator)
+	t.addCost(t.count() * cpuFactor)
+	t = attachPlan2Task(p.Copy(), t)
+	return t
+}
@@ -15,6 +15,7 @@ package plan
 
 import (
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
 )
 
 // AggregateFuncExtractor visits Expr tree.
@@ -45,3 +46,47 @@ func (a *AggregateFuncExtractor) Leave(n ast.Node) (ast.Node, bool) {
 	}
 	return n, true
 }
+
+// windowFuncExtractor visits Expr tree and collects the WindowFuncExprs.
+// It does not go into the window functions themselves, nested window functions are
+// reported as errors when the arguments are rewritten.
+type windowFuncExtractor struct {
+	windowFuncs []*ast.WindowFuncExpr
+}
+
+// Enter implements Visitor interface.
+func (w *windowFuncExtractor) Enter(n ast.Node) (ast.Node, bool) {
+	switch v := n.(type) {
+	case *ast.WindowFuncExpr:
+		w.windowFuncs = append(w.windowFuncs, v)
+		return n, true
+	case *ast.SelectStmt, *ast.UnionStmt:
+		return n, true
+	}
+	return n, false
+}
+
+// Leave implements Visitor interface.
+func (w *windowFuncExtractor) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+// cteReferenceDetector visits a query block and checks whether it references the common table expression.
+// The resolver leaves the schema of a table name empty if it refers to a common table expression.
+type cteReferenceDetector struct {
+	name       model.CIStr
+	referenced bool
+}
+
+// Enter implements Visitor interface.
+func (c *cteReferenceDetector) Enter(n ast.Node) (ast.Node, bool) {
+	if tn, ok := n.(*ast.TableName); ok && tn.Schema.L == "" && tn.Name.L == c.name.L {
+		c.referenced = true
+	}
+	return n, c.referenced
+}
+
+// Leave implements Visitor interface.
+func (c *cteReferenceDetector) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
@@ -35,14 +35,36 @@ type Manager interface {
 	// If table is "", only check global/db scope privileges.
 	// If table is not "", check global/db/table scope privileges.
 	RequestVerification(db, table, column string, priv mysql.PrivilegeType) bool
+	// RequestVerificationWithUser verifies the privilege of the specified user instead of the current user.
+	// It's used by the objects executed with the privileges of their definer, e.g. SQL SECURITY DEFINER views.
+	RequestVerificationWithUser(db, table, column string, priv mysql.PrivilegeType, user *auth.UserIdentity) bool
 	// ConnectionVerification verifies user privilege for connection.
+	// For the sha2 authentication plugins, auth is the plaintext password.
 	ConnectionVerification(host, user string, auth, salt []byte) bool
+	// FastAuthVerification verifies the scramble of caching_sha2_password against the cached
+	// password digest, it fails if the user hasn't passed the full authentication before.
+	FastAuthVerification(user, host string, scramble, salt []byte) bool
+	// GetAuthPlugin returns the authentication plugin of the account the user and host match.
+	GetAuthPlugin(user, host string) (string, bool)
+	// AuthWithoutVerification binds the user to the session without checking the password,
+	// the user must have been authenticated already. It's used to reset a connection.
+	AuthWithoutVerification(user, host string) bool
 
 	// DBIsVisible returns true is the database is visible to current user.
 	DBIsVisible(db string) bool
 
 	// UserPrivilegesTable provide data for INFORMATION_SCHEMA.USERS_PRIVILEGE table.
 	UserPrivilegesTable() [][]types.Datum
+
+	// ActiveRoles returns the roles activated in the current session.
+	ActiveRoles() []*auth.RoleIdentity
+	// ActivateRoles activates the roles for the current session. If any of the roles
+	// is not granted to the current user, nothing is changed and the role is returned with false.
+	ActivateRoles(roles []*auth.RoleIdentity) (*auth.RoleIdentity, bool)
+	// GetDefaultRoles returns the roles activated when the user connects.
+	GetDefaultRoles(user, host string) []*auth.RoleIdentity
+	// GetAllRoles returns the roles granted to the user directly.
+	GetAllRoles(user, host string) []*auth.RoleIdentity
 }
 
 const key keyType = 0
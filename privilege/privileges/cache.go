@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/auth"
 	"github.com/pingcap/tidb/util/sqlexec"
 	"github.com/pingcap/tidb/util/stringutil"
 	"github.com/pingcap/tidb/util/types"
@@ -48,8 +49,12 @@ func computePrivMask(privs []mysql.PrivilegeType) mysql.PrivilegeType {
 type userRecord struct {
 	Host       string // max length 60, primary key
 	User       string // max length 16, primary key
-	Password   string // max length 41
+	Password   string
 	Privileges mysql.PrivilegeType
+	// AccountLocked is true for roles, which can not be used to connect.
+	AccountLocked bool
+	// AuthPlugin is the authentication plugin of the user, the password is hashed by it.
+	AuthPlugin string
 
 	// patChars is compiled from Host, cached for pattern match performance.
 	patChars []byte
@@ -99,12 +104,37 @@ type columnsPrivRecord struct {
 	patTypes []byte
 }
 
+// roleEdgeRecord means the role FromUser@FromHost is granted to ToUser@ToHost.
+type roleEdgeRecord struct {
+	FromHost string
+	FromUser string
+	ToHost   string
+	ToUser   string
+
+	// patChars is compiled from ToHost, cached for pattern match performance.
+	patChars []byte
+	patTypes []byte
+}
+
+type defaultRoleRecord struct {
+	Host            string
+	User            string
+	DefaultRoleHost string
+	DefaultRoleUser string
+
+	// patChars is compiled from Host, cached for pattern match performance.
+	patChars []byte
+	patTypes []byte
+}
+
 // MySQLPrivilege is the in-memory cache of mysql privilege tables.
 type MySQLPrivilege struct {
-	User        []userRecord
-	DB          []dbRecord
-	TablesPriv  []tablesPrivRecord
-	ColumnsPriv []columnsPrivRecord
+	User         []userRecord
+	DB           []dbRecord
+	TablesPriv   []tablesPrivRecord
+	ColumnsPriv  []columnsPrivRecord
+	RoleEdges    []roleEdgeRecord
+	DefaultRoles []defaultRoleRecord
 }
 
 // LoadAll loads the tables from database to memory.
@@ -137,6 +167,22 @@ func (p *MySQLPrivilege) LoadAll(ctx context.Context) error {
 		}
 		log.Warn("mysql.columns_priv missing")
 	}
+
+	err = p.LoadRoleEdgesTable(ctx)
+	if err != nil {
+		if !noSuchTable(err) {
+			return errors.Trace(err)
+		}
+		log.Warn("mysql.role_edges missing")
+	}
+
+	err = p.LoadDefaultRolesTable(ctx)
+	if err != nil {
+		if !noSuchTable(err) {
+			return errors.Trace(err)
+		}
+		log.Warn("mysql.default_roles missing")
+	}
 	return nil
 }
 
@@ -150,9 +196,30 @@ func noSuchTable(err error) bool {
 	return false
 }
 
+func unknownColumn(err error) bool {
+	e1 := errors.Cause(err)
+	if e2, ok := e1.(*terror.Error); ok {
+		if e2.Code() == terror.ErrCode(mysql.ErrBadField) {
+			return true
+		}
+	}
+	return false
+}
+
 // LoadUserTable loads the mysql.user table from database.
 func (p *MySQLPrivilege) LoadUserTable(ctx context.Context) error {
-	return p.loadTable(ctx, "select Host,User,Password,Select_priv,Insert_priv,Update_priv,Delete_priv,Create_priv,Drop_priv,Process_priv,Grant_priv,References_priv,Alter_priv,Show_db_priv,Super_priv,Execute_priv,Index_priv,Create_user_priv,Trigger_priv from mysql.user order by host, user;", p.decodeUserTableRow)
+	const fields = "Host,User,Password,Select_priv,Insert_priv,Update_priv,Delete_priv,Create_priv,Drop_priv,Process_priv,Grant_priv,References_priv,Alter_priv,Show_db_priv,Super_priv,Execute_priv,Index_priv,Create_user_priv,Trigger_priv"
+	// The mysql.user table synchronized from MySQL of old versions doesn't have the Account_locked
+	// or the plugin column, fall back to the columns it has.
+	var err error
+	for _, extra := range []string{",Account_locked,plugin", ",Account_locked", ""} {
+		p.User = nil
+		err = p.loadTable(ctx, "select "+fields+extra+" from mysql.user order by host, user;", p.decodeUserTableRow)
+		if err == nil || !unknownColumn(err) {
+			break
+		}
+	}
+	return err
 }
 
 // LoadDBTable loads the mysql.db table from database.
@@ -170,6 +237,16 @@ func (p *MySQLPrivilege) LoadColumnsPrivTable(ctx context.Context) error {
 	return p.loadTable(ctx, "select Host,DB,User,Table_name,Column_name,Timestamp,Column_priv from mysql.columns_priv", p.decodeColumnsPrivTableRow)
 }
 
+// LoadRoleEdgesTable loads the mysql.role_edges table from database.
+func (p *MySQLPrivilege) LoadRoleEdgesTable(ctx context.Context) error {
+	return p.loadTable(ctx, "select FROM_HOST,FROM_USER,TO_HOST,TO_USER from mysql.role_edges", p.decodeRoleEdgesTableRow)
+}
+
+// LoadDefaultRolesTable loads the mysql.default_roles table from database.
+func (p *MySQLPrivilege) LoadDefaultRolesTable(ctx context.Context) error {
+	return p.loadTable(ctx, "select HOST,USER,DEFAULT_ROLE_HOST,DEFAULT_ROLE_USER from mysql.default_roles", p.decodeDefaultRolesTableRow)
+}
+
 func (p *MySQLPrivilege) loadTable(ctx context.Context, sql string,
 	decodeTableRow func(*ast.Row, []*ast.ResultField) error) error {
 	tmp, err := ctx.(sqlexec.SQLExecutor).Execute(sql)
@@ -212,6 +289,12 @@ func (p *MySQLPrivilege) decodeUserTableRow(row *ast.Row, fs []*ast.ResultField)
 			value.patChars, value.patTypes = stringutil.CompilePattern(value.Host, '\\')
 		case f.ColumnAsName.L == "password":
 			value.Password = d.GetString()
+		case f.ColumnAsName.L == "account_locked":
+			value.AccountLocked = d.GetMysqlEnum().String() == "Y"
+		case f.ColumnAsName.L == "plugin":
+			if !d.IsNull() {
+				value.AuthPlugin = d.GetString()
+			}
 		case d.Kind() == types.KindMysqlEnum:
 			ed := d.GetMysqlEnum()
 			if ed.String() != "Y" {
@@ -224,6 +307,9 @@ func (p *MySQLPrivilege) decodeUserTableRow(row *ast.Row, fs []*ast.ResultField)
 			value.Privileges |= priv
 		}
 	}
+	if value.AuthPlugin == "" {
+		value.AuthPlugin = mysql.AuthNativePassword
+	}
 	p.User = append(p.User, value)
 	return nil
 }
@@ -311,6 +397,46 @@ func (p *MySQLPrivilege) decodeColumnsPrivTableRow(row *ast.Row, fs []*ast.Resul
 	return nil
 }
 
+func (p *MySQLPrivilege) decodeRoleEdgesTableRow(row *ast.Row, fs []*ast.ResultField) error {
+	var value roleEdgeRecord
+	for i, f := range fs {
+		d := row.Data[i]
+		switch {
+		case f.ColumnAsName.L == "from_host":
+			value.FromHost = d.GetString()
+		case f.ColumnAsName.L == "from_user":
+			value.FromUser = d.GetString()
+		case f.ColumnAsName.L == "to_host":
+			value.ToHost = d.GetString()
+			value.patChars, value.patTypes = stringutil.CompilePattern(value.ToHost, '\\')
+		case f.ColumnAsName.L == "to_user":
+			value.ToUser = d.GetString()
+		}
+	}
+	p.RoleEdges = append(p.RoleEdges, value)
+	return nil
+}
+
+func (p *MySQLPrivilege) decodeDefaultRolesTableRow(row *ast.Row, fs []*ast.ResultField) error {
+	var value defaultRoleRecord
+	for i, f := range fs {
+		d := row.Data[i]
+		switch {
+		case f.ColumnAsName.L == "host":
+			value.Host = d.GetString()
+			value.patChars, value.patTypes = stringutil.CompilePattern(value.Host, '\\')
+		case f.ColumnAsName.L == "user":
+			value.User = d.GetString()
+		case f.ColumnAsName.L == "default_role_host":
+			value.DefaultRoleHost = d.GetString()
+		case f.ColumnAsName.L == "default_role_user":
+			value.DefaultRoleUser = d.GetString()
+		}
+	}
+	p.DefaultRoles = append(p.DefaultRoles, value)
+	return nil
+}
+
 func decodeSetToPrivilege(s types.Set) mysql.PrivilegeType {
 	var ret mysql.PrivilegeType
 	if s.Name == "" {
@@ -349,6 +475,14 @@ func (record *columnsPrivRecord) match(user, host, db, table, col string) bool {
 		patternMatch(host, record.patChars, record.patTypes)
 }
 
+func (record *roleEdgeRecord) match(user, host string) bool {
+	return record.ToUser == user && patternMatch(host, record.patChars, record.patTypes)
+}
+
+func (record *defaultRoleRecord) match(user, host string) bool {
+	return record.User == user && patternMatch(host, record.patChars, record.patTypes)
+}
+
 // patternMatch matches "%" the same way as ".*" in regular expression, for example,
 // "10.0.%" would match "10.0.1" "10.0.1.118" ...
 func patternMatch(str string, patChars, patTypes []byte) bool {
@@ -478,6 +612,71 @@ func (p *MySQLPrivilege) DBIsVisible(user, host, db string) bool {
 	return false
 }
 
+// FindRole checks whether the role is granted to the user directly.
+func (p *MySQLPrivilege) FindRole(user, host string, role *auth.RoleIdentity) bool {
+	for i := 0; i < len(p.RoleEdges); i++ {
+		record := &p.RoleEdges[i]
+		if record.match(user, host) && record.FromUser == role.Username && record.FromHost == role.Hostname {
+			return true
+		}
+	}
+	return false
+}
+
+// getGrantedRoles returns the roles granted to the user directly.
+func (p *MySQLPrivilege) getGrantedRoles(user, host string) []*auth.RoleIdentity {
+	var roles []*auth.RoleIdentity
+	for i := 0; i < len(p.RoleEdges); i++ {
+		record := &p.RoleEdges[i]
+		if record.match(user, host) {
+			roles = append(roles, &auth.RoleIdentity{Username: record.FromUser, Hostname: record.FromHost})
+		}
+	}
+	return roles
+}
+
+// getDefaultRoles returns the roles activated when the user connects.
+func (p *MySQLPrivilege) getDefaultRoles(user, host string) []*auth.RoleIdentity {
+	var roles []*auth.RoleIdentity
+	for i := 0; i < len(p.DefaultRoles); i++ {
+		record := &p.DefaultRoles[i]
+		if record.match(user, host) {
+			roles = append(roles, &auth.RoleIdentity{Username: record.DefaultRoleUser, Hostname: record.DefaultRoleHost})
+		}
+	}
+	return roles
+}
+
+// FindAllRole returns the active roles and all the roles granted to them,
+// since a role may be granted to another role.
+func (p *MySQLPrivilege) FindAllRole(activeRoles []*auth.RoleIdentity) []*auth.RoleIdentity {
+	visited := make(map[string]bool, len(activeRoles))
+	queue := make([]*auth.RoleIdentity, 0, len(activeRoles))
+	for _, role := range activeRoles {
+		key := role.String()
+		if !visited[key] {
+			visited[key] = true
+			queue = append(queue, role)
+		}
+	}
+	for head := 0; head < len(queue); head++ {
+		role := queue[head]
+		for i := 0; i < len(p.RoleEdges); i++ {
+			record := &p.RoleEdges[i]
+			if record.ToUser != role.Username || record.ToHost != role.Hostname {
+				continue
+			}
+			granted := &auth.RoleIdentity{Username: record.FromUser, Hostname: record.FromHost}
+			key := granted.String()
+			if !visited[key] {
+				visited[key] = true
+				queue = append(queue, granted)
+			}
+		}
+	}
+	return queue
+}
+
 func (p *MySQLPrivilege) showGrants(user, host string) []string {
 	var gs []string
 	// Show global grants
@@ -513,6 +712,18 @@ func (p *MySQLPrivilege) showGrants(user, host string) []string {
 			}
 		}
 	}
+
+	// Show granted roles
+	var roles []string
+	for _, record := range p.RoleEdges {
+		if record.ToUser == user && record.ToHost == host {
+			roles = append(roles, fmt.Sprintf(`'%s'@'%s'`, record.FromUser, record.FromHost))
+		}
+	}
+	if len(roles) > 0 {
+		s := fmt.Sprintf(`GRANT %s TO '%s'@'%s'`, strings.Join(roles, ","), user, host)
+		gs = append(gs, s)
+	}
 	return gs
 }
 
@@ -608,5 +819,7 @@ func (h *Handle) Update(ctx context.Context) error {
 	}
 
 	h.priv.Store(&priv)
+	// The digests of the users which are changed or dropped shouldn't be used any more.
+	sha2DigestCache.clear()
 	return nil
 }
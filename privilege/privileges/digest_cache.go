@@ -0,0 +1,92 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"container/list"
+	"sync"
+)
+
+// sha2DigestCacheCapacity is the max number of the digests in sha2DigestCache.
+const sha2DigestCacheCapacity = 1024
+
+// sha2DigestCache caches the digests of the passwords of caching_sha2_password users which passed
+// the full authentication. It's keyed by the user and the password hash so a changed password is
+// never matched, and it's cleared when the privileges are reloaded.
+var sha2DigestCache = newDigestCache(sha2DigestCacheCapacity)
+
+// digestEntry is the value of list.Element.
+type digestEntry struct {
+	key    string
+	digest []byte
+}
+
+// digestCache is a thread-safe least recently used cache of the password digests.
+type digestCache struct {
+	mu       sync.Mutex
+	capacity int
+	elements map[string]*list.Element
+	cache    *list.List
+}
+
+func newDigestCache(capacity int) *digestCache {
+	return &digestCache{
+		capacity: capacity,
+		elements: make(map[string]*list.Element),
+		cache:    list.New(),
+	}
+}
+
+func digestKey(user, authString string) string {
+	// The user name can't contain '\x00', so the key is unique.
+	return user + "\x00" + authString
+}
+
+// get returns the digest of the user whose password hash is authString.
+func (c *digestCache) get(user, authString string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.elements[digestKey(user, authString)]
+	if !ok {
+		return nil, false
+	}
+	c.cache.MoveToFront(element)
+	return element.Value.(*digestEntry).digest, true
+}
+
+// put puts the digest into the cache, the least recently used one is evicted if the cache is full.
+func (c *digestCache) put(user, authString string, digest []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := digestKey(user, authString)
+	if element, ok := c.elements[key]; ok {
+		element.Value.(*digestEntry).digest = digest
+		c.cache.MoveToFront(element)
+		return
+	}
+	c.elements[key] = c.cache.PushFront(&digestEntry{key: key, digest: digest})
+	for c.cache.Len() > c.capacity {
+		lru := c.cache.Back()
+		c.cache.Remove(lru)
+		delete(c.elements, lru.Value.(*digestEntry).key)
+	}
+}
+
+// clear removes all the digests.
+func (c *digestCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.elements = make(map[string]*list.Element)
+	c.cache.Init()
+}
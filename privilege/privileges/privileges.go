@@ -49,6 +49,9 @@ var _ privilege.Manager = (*UserPrivileges)(nil)
 type UserPrivileges struct {
 	user string
 	host string
+	// activeRoles is the roles activated in the session, it is initialized with
+	// the default roles of the user and changed by SET ROLE.
+	activeRoles []*auth.RoleIdentity
 	*Handle
 }
 
@@ -69,7 +72,42 @@ func (p *UserPrivileges) RequestVerification(db, table, column string, priv mysq
 	}
 
 	mysqlPriv := p.Handle.Get()
-	return mysqlPriv.RequestVerification(p.user, p.host, db, table, column, priv)
+	if mysqlPriv.RequestVerification(p.user, p.host, db, table, column, priv) {
+		return true
+	}
+	for _, role := range mysqlPriv.FindAllRole(p.grantedActiveRoles(mysqlPriv)) {
+		if mysqlPriv.RequestVerification(role.Username, role.Hostname, db, table, column, priv) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestVerificationWithUser implements the Manager interface.
+func (p *UserPrivileges) RequestVerificationWithUser(db, table, column string, priv mysql.PrivilegeType, user *auth.UserIdentity) bool {
+	if !Enable || SkipWithGrant {
+		return true
+	}
+
+	if user == nil {
+		return false
+	}
+
+	if strings.EqualFold(db, "INFORMATION_SCHEMA") {
+		return true
+	}
+
+	// The definer runs with its default roles activated, like MySQL does.
+	mysqlPriv := p.Handle.Get()
+	if mysqlPriv.RequestVerification(user.Username, user.Hostname, db, table, column, priv) {
+		return true
+	}
+	for _, role := range mysqlPriv.FindAllRole(mysqlPriv.getDefaultRoles(user.Username, user.Hostname)) {
+		if mysqlPriv.RequestVerification(role.Username, role.Hostname, db, table, column, priv) {
+			return true
+		}
+	}
+	return false
 }
 
 // ConnectionVerification implements the Manager interface.
@@ -86,46 +124,140 @@ func (p *UserPrivileges) ConnectionVerification(user, host string, authenticatio
 		log.Errorf("Get user privilege record fail: user %v, host %v", user, host)
 		return false
 	}
+	if record.AccountLocked {
+		log.Errorf("User account is locked: user %v, host %v", user, host)
+		return false
+	}
 
 	pwd := record.Password
-	if len(pwd) != 0 && len(pwd) != mysql.PWDHashLen+1 {
-		log.Errorf("User [%s] password from SystemDB not like a sha1sum", user)
-		return false
+	switch record.AuthPlugin {
+	case mysql.AuthCachingSha2Password, mysql.AuthSha256Password:
+		// The sha2 plugins only do the full authentication here, the authentication is the plaintext password.
+		if len(pwd) != 0 || len(authentication) != 0 {
+			ok, err := auth.CheckSha2Password(pwd, string(authentication))
+			if err != nil {
+				log.Errorf("Check sha2 password error %v", err)
+				return false
+			}
+			if !ok {
+				return false
+			}
+			sha2DigestCache.put(user, pwd, auth.Sha2Digest(string(authentication)))
+		}
+	default:
+		if len(pwd) != 0 && len(pwd) != mysql.PWDHashLen+1 {
+			log.Errorf("User [%s] password from SystemDB not like a sha1sum", user)
+			return false
+		}
+
+		// empty password
+		if len(pwd) == 0 && len(authentication) == 0 {
+			break
+		}
+
+		if len(pwd) == 0 || len(authentication) == 0 {
+			return false
+		}
+
+		hpwd, err := auth.DecodePassword(pwd)
+		if err != nil {
+			log.Errorf("Decode password string error %v", err)
+			return false
+		}
+
+		if !auth.CheckScrambledPassword(salt, hpwd, authentication) {
+			return false
+		}
 	}
 
-	// empty password
-	if len(pwd) == 0 && len(authentication) == 0 {
+	p.user = user
+	p.host = host
+	p.activeRoles = mysqlPriv.getDefaultRoles(user, host)
+	return true
+}
+
+// AuthWithoutVerification implements the Manager interface.
+func (p *UserPrivileges) AuthWithoutVerification(user, host string) bool {
+	if SkipWithGrant {
 		p.user = user
 		p.host = host
 		return true
 	}
 
-	if len(pwd) == 0 || len(authentication) == 0 {
+	mysqlPriv := p.Handle.Get()
+	record := mysqlPriv.connectionVerification(user, host)
+	if record == nil {
+		log.Errorf("Get user privilege record fail: user %v, host %v", user, host)
 		return false
 	}
-
-	hpwd, err := auth.DecodePassword(pwd)
-	if err != nil {
-		log.Errorf("Decode password string error %v", err)
+	if record.AccountLocked {
+		log.Errorf("User account is locked: user %v, host %v", user, host)
 		return false
 	}
 
-	if !auth.CheckScrambledPassword(salt, hpwd, authentication) {
+	p.user = user
+	p.host = host
+	p.activeRoles = mysqlPriv.getDefaultRoles(user, host)
+	return true
+}
+
+// FastAuthVerification implements the Manager interface.
+func (p *UserPrivileges) FastAuthVerification(user, host string, scramble, salt []byte) bool {
+	if SkipWithGrant {
+		p.user = user
+		p.host = host
+		return true
+	}
+
+	mysqlPriv := p.Handle.Get()
+	record := mysqlPriv.connectionVerification(user, host)
+	if record == nil || record.AccountLocked || record.AuthPlugin != mysql.AuthCachingSha2Password {
 		return false
 	}
+	if len(record.Password) == 0 {
+		if len(scramble) != 0 {
+			return false
+		}
+	} else {
+		digest, ok := sha2DigestCache.get(user, record.Password)
+		if !ok || !auth.CheckSha2Scramble(digest, salt, scramble) {
+			return false
+		}
+	}
 
 	p.user = user
 	p.host = host
+	p.activeRoles = mysqlPriv.getDefaultRoles(user, host)
 	return true
 }
 
+// GetAuthPlugin implements the Manager interface.
+func (p *UserPrivileges) GetAuthPlugin(user, host string) (string, bool) {
+	if SkipWithGrant {
+		return mysql.AuthNativePassword, true
+	}
+	record := p.Handle.Get().connectionVerification(user, host)
+	if record == nil {
+		return "", false
+	}
+	return record.AuthPlugin, true
+}
+
 // DBIsVisible implements the Manager interface.
 func (p *UserPrivileges) DBIsVisible(db string) bool {
 	if !Enable || SkipWithGrant {
 		return true
 	}
 	mysqlPriv := p.Handle.Get()
-	return mysqlPriv.DBIsVisible(p.user, p.host, db)
+	if mysqlPriv.DBIsVisible(p.user, p.host, db) {
+		return true
+	}
+	for _, role := range mysqlPriv.FindAllRole(p.grantedActiveRoles(mysqlPriv)) {
+		if mysqlPriv.DBIsVisible(role.Username, role.Hostname, db) {
+			return true
+		}
+	}
+	return false
 }
 
 // UserPrivilegesTable implements the Manager interface.
@@ -139,3 +271,47 @@ func (p *UserPrivileges) ShowGrants(ctx context.Context, user *auth.UserIdentity
 	mysqlPrivilege := p.Handle.Get()
 	return mysqlPrivilege.showGrants(user.Username, user.Hostname), nil
 }
+
+// grantedActiveRoles filters out the active roles which have been revoked from the user.
+func (p *UserPrivileges) grantedActiveRoles(mysqlPriv *MySQLPrivilege) []*auth.RoleIdentity {
+	roles := make([]*auth.RoleIdentity, 0, len(p.activeRoles))
+	for _, role := range p.activeRoles {
+		if mysqlPriv.FindRole(p.user, p.host, role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// ActiveRoles implements privilege.Manager ActiveRoles interface.
+func (p *UserPrivileges) ActiveRoles() []*auth.RoleIdentity {
+	return p.activeRoles
+}
+
+// ActivateRoles implements privilege.Manager ActivateRoles interface.
+func (p *UserPrivileges) ActivateRoles(roles []*auth.RoleIdentity) (*auth.RoleIdentity, bool) {
+	if SkipWithGrant || (p.user == "" && p.host == "") {
+		p.activeRoles = roles
+		return nil, true
+	}
+	mysqlPriv := p.Handle.Get()
+	for _, role := range roles {
+		if !mysqlPriv.FindRole(p.user, p.host, role) {
+			return role, false
+		}
+	}
+	p.activeRoles = roles
+	return nil, true
+}
+
+// GetDefaultRoles implements privilege.Manager GetDefaultRoles interface.
+func (p *UserPrivileges) GetDefaultRoles(user, host string) []*auth.RoleIdentity {
+	mysqlPriv := p.Handle.Get()
+	return mysqlPriv.getDefaultRoles(user, host)
+}
+
+// GetAllRoles implements privilege.Manager GetAllRoles interface.
+func (p *UserPrivileges) GetAllRoles(user, host string) []*auth.RoleIdentity {
+	mysqlPriv := p.Handle.Get()
+	return mysqlPriv.getGrantedRoles(user, host)
+}
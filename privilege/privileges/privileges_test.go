@@ -20,10 +20,12 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/privilege/privileges"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/auth"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
@@ -288,6 +290,138 @@ func (s *testPrivilegeSuite) TestCheckAuthenticate(c *C) {
 	c.Assert(se.Auth(&auth.UserIdentity{Username: "u4", Hostname: "localhost"}, nil, nil), IsFalse)
 }
 
+func (s *testPrivilegeSuite) TestCheckSha2Authenticate(c *C) {
+	defer testleak.AfterTest(c)()
+
+	se := newSession(c, s.store, s.dbName)
+	mustExec(c, se, `CREATE USER 'sha2'@'localhost' identified with caching_sha2_password by 'abc';`)
+	mustExec(c, se, `CREATE USER 'sha256'@'localhost' identified with sha256_password by 'abc';`)
+	mustExec(c, se, `FLUSH PRIVILEGES;`)
+	sha2 := &auth.UserIdentity{Username: "sha2", Hostname: "localhost"}
+	c.Assert(se.AuthPlugin(sha2), Equals, mysql.AuthCachingSha2Password)
+	c.Assert(se.AuthPlugin(&auth.UserIdentity{Username: "sha256", Hostname: "localhost"}), Equals, mysql.AuthSha256Password)
+	c.Assert(se.AuthPlugin(&auth.UserIdentity{Username: "not_exist", Hostname: "localhost"}), Equals, "")
+
+	// The fast authentication fails until the full authentication succeeds.
+	salt := []byte{85, 92, 45, 22, 58, 79, 107, 6, 122, 125, 58, 80, 12, 90, 103, 32, 90, 10, 74, 82}
+	c.Assert(se.FastAuth(sha2, auth.Sha2Scramble(salt, "abc"), salt), IsFalse)
+	c.Assert(se.Auth(sha2, []byte("abd"), salt), IsFalse)
+	c.Assert(se.Auth(sha2, []byte("abc"), salt), IsTrue)
+	c.Assert(se.FastAuth(sha2, auth.Sha2Scramble(salt, "abc"), salt), IsTrue)
+	c.Assert(se.FastAuth(sha2, auth.Sha2Scramble(salt, "abd"), salt), IsFalse)
+	c.Assert(se.Auth(&auth.UserIdentity{Username: "sha256", Hostname: "localhost"}, []byte("abc"), salt), IsTrue)
+
+	// The cached digests are cleared when the privileges are reloaded.
+	mustExec(c, se, `FLUSH PRIVILEGES;`)
+	c.Assert(se.FastAuth(sha2, auth.Sha2Scramble(salt, "abc"), salt), IsFalse)
+	c.Assert(se.Auth(sha2, []byte("abc"), salt), IsTrue)
+	c.Assert(se.FastAuth(sha2, auth.Sha2Scramble(salt, "abc"), salt), IsTrue)
+
+	// The cached digest is not used after the password is changed.
+	se1 := newSession(c, s.store, s.dbName)
+	mustExec(c, se1, `SET PASSWORD FOR 'sha2'@'localhost' = 'abd';`)
+	mustExec(c, se1, `FLUSH PRIVILEGES;`)
+	c.Assert(se.FastAuth(sha2, auth.Sha2Scramble(salt, "abc"), salt), IsFalse)
+	c.Assert(se.Auth(sha2, []byte("abd"), salt), IsTrue)
+	mustExec(c, se1, `DROP USER 'sha2'@'localhost', 'sha256'@'localhost';`)
+}
+
+func (s *testPrivilegeSuite) TestRole(c *C) {
+	defer testleak.AfterTest(c)()
+	rootSe := newSession(c, s.store, s.dbName)
+	mustExec(c, rootSe, `CREATE USER 'testrole'@'localhost';`)
+	mustExec(c, rootSe, `CREATE ROLE 'r_select', 'r_update', 'r_insert';`)
+	mustExec(c, rootSe, `GRANT Select ON test.* TO 'r_select';`)
+	mustExec(c, rootSe, `GRANT Update ON test.* TO 'r_update';`)
+	mustExec(c, rootSe, `GRANT Insert ON test.* TO 'r_insert';`)
+	mustExec(c, rootSe, `GRANT 'r_insert' TO 'r_update';`)
+	mustExec(c, rootSe, `GRANT 'r_select', 'r_update' TO 'testrole'@'localhost';`)
+	mustExec(c, rootSe, `SET DEFAULT ROLE 'r_select' TO 'testrole'@'localhost';`)
+	mustExec(c, rootSe, `FLUSH PRIVILEGES;`)
+
+	// Roles can not be used to connect.
+	se := newSession(c, s.store, s.dbName)
+	c.Assert(se.Auth(&auth.UserIdentity{Username: "r_select", Hostname: "localhost"}, nil, nil), IsFalse)
+
+	// The default roles are activated when the user connects.
+	c.Assert(se.Auth(&auth.UserIdentity{Username: "testrole", Hostname: "localhost"}, nil, nil), IsTrue)
+	pc := privilege.GetPrivilegeManager(se)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.SelectPriv), IsTrue)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.UpdatePriv), IsFalse)
+	c.Assert(pc.DBIsVisible("test"), IsTrue)
+	c.Assert(pc.DBIsVisible("test1"), IsFalse)
+
+	// The privileges of the roles granted to the active roles are available too.
+	mustExec(c, se, `SET ROLE 'r_update';`)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.SelectPriv), IsFalse)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.UpdatePriv), IsTrue)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.InsertPriv), IsTrue)
+
+	mustExec(c, se, `SET ROLE ALL EXCEPT 'r_update';`)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.SelectPriv), IsTrue)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.UpdatePriv), IsFalse)
+
+	mustExec(c, se, `SET ROLE ALL;`)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.SelectPriv), IsTrue)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.UpdatePriv), IsTrue)
+
+	mustExec(c, se, `SET ROLE NONE;`)
+	c.Assert(pc.ActiveRoles(), HasLen, 0)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.SelectPriv), IsFalse)
+
+	mustExec(c, se, `SET ROLE DEFAULT;`)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.SelectPriv), IsTrue)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.UpdatePriv), IsFalse)
+
+	// Only the roles granted to the user directly can be activated.
+	_, err := se.Execute(`SET ROLE 'r_insert';`)
+	c.Assert(err, NotNil)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.SelectPriv), IsTrue)
+
+	gs, err := pc.ShowGrants(se, &auth.UserIdentity{Username: "testrole", Hostname: "localhost"})
+	c.Assert(err, IsNil)
+	c.Assert(gs, HasLen, 1)
+	c.Assert(gs[0], Equals, `GRANT 'r_select'@'%','r_update'@'%' TO 'testrole'@'localhost'`)
+
+	// The privileges are gone after the role is revoked.
+	mustExec(c, rootSe, `REVOKE 'r_select' FROM 'testrole'@'localhost';`)
+	mustExec(c, rootSe, `FLUSH PRIVILEGES;`)
+	c.Assert(pc.RequestVerification("test", "test", "", mysql.SelectPriv), IsFalse)
+}
+
+func (s *testPrivilegeSuite) TestViewPrivilege(c *C) {
+	defer testleak.AfterTest(c)()
+	se := newSession(c, s.store, s.dbName)
+	mustExec(c, se, `CREATE TABLE view_base(a int);`)
+	mustExec(c, se, `CREATE USER 'view_definer'@'localhost', 'view_user'@'localhost';`)
+	mustExec(c, se, `GRANT Select ON test.view_base TO 'view_definer'@'localhost';`)
+	mustExec(c, se, `CREATE DEFINER = 'view_definer'@'localhost' SQL SECURITY DEFINER VIEW v_definer AS SELECT a FROM view_base;`)
+	mustExec(c, se, `CREATE DEFINER = 'view_definer'@'localhost' SQL SECURITY INVOKER VIEW v_invoker AS SELECT a FROM view_base;`)
+	mustExec(c, se, `CREATE DEFINER = 'view_user'@'localhost' SQL SECURITY DEFINER VIEW v_no_priv AS SELECT a FROM view_base;`)
+	mustExec(c, se, `GRANT Select ON test.v_definer TO 'view_user'@'localhost';`)
+	mustExec(c, se, `GRANT Select ON test.v_invoker TO 'view_user'@'localhost';`)
+	mustExec(c, se, `GRANT Select ON test.v_no_priv TO 'view_user'@'localhost';`)
+	mustExec(c, se, `GRANT Create ON test.* TO 'view_user'@'localhost';`)
+	mustExec(c, se, `FLUSH PRIVILEGES;`)
+
+	c.Assert(se.Auth(&auth.UserIdentity{Username: "view_user", Hostname: "localhost"}, nil, nil), IsTrue)
+	// The underlying table of a SQL SECURITY DEFINER view is checked against the definer.
+	mustExec(c, se, `SELECT * FROM v_definer;`)
+	_, err := se.Execute(`SELECT * FROM v_no_priv;`)
+	c.Assert(err, NotNil)
+	// The underlying table of a SQL SECURITY INVOKER view is checked against the current user.
+	_, err = se.Execute(`SELECT * FROM v_invoker;`)
+	c.Assert(err, NotNil)
+	_, err = se.Execute(`SELECT * FROM view_base;`)
+	c.Assert(err, NotNil)
+
+	// A definer other than the current user requires the SUPER privilege.
+	mustExec(c, se, `CREATE VIEW v_own AS SELECT 1;`)
+	mustExec(c, se, `CREATE DEFINER = 'view_user'@'localhost' VIEW v_own_definer AS SELECT 1;`)
+	_, err = se.Execute(`CREATE DEFINER = 'view_definer'@'localhost' SQL SECURITY DEFINER VIEW v_escalate AS SELECT 1;`)
+	c.Assert(terror.ErrorEqual(err, executor.ErrSpecificAccessDenied), IsTrue, Commentf("err %v", err))
+}
+
 func (s *testPrivilegeSuite) TestInformationSchema(c *C) {
 	defer testleak.AfterTest(c)()
 
@@ -51,10 +51,14 @@ import (
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/arena"
 	"github.com/pingcap/tidb/util/auth"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/hack"
+	"github.com/pingcap/tidb/util/types"
+	"golang.org/x/text/encoding"
 )
 
 // clientConn represents a connection between server and client, it maintains connection specific state,
@@ -105,12 +109,19 @@ func (cc *clientConn) handshake() error {
 	}
 
 	err := cc.writePacket(data)
-	cc.pkt.sequence = 0
+	cc.pkt.resetSequence()
 	if err != nil {
 		return errors.Trace(err)
 	}
 
-	return errors.Trace(cc.flush())
+	if err = cc.flush(); err != nil {
+		return errors.Trace(err)
+	}
+	// The compressed protocol takes effect after the handshake.
+	if cc.capability&mysql.ClientCompress > 0 {
+		cc.pkt.setCompressed()
+	}
+	return nil
 }
 
 func (cc *clientConn) Close() error {
@@ -163,7 +174,7 @@ func (cc *clientConn) writeInitialHandshake() error {
 	data = append(data, cc.salt[8:]...)
 	data = append(data, 0)
 	// auth-plugin name
-	data = append(data, []byte("mysql_native_password")...)
+	data = append(data, mysql.AuthNativePassword...)
 	data = append(data, 0)
 	err := cc.writePacket(data)
 	if err != nil {
@@ -186,6 +197,7 @@ type handshakeResponse41 struct {
 	User       string
 	DBName     string
 	Auth       []byte
+	AuthPlugin string
 	Attrs      map[string]string
 }
 
@@ -257,9 +269,10 @@ func parseHandshakeResponseBody(packet *handshakeResponse41, data []byte, offset
 	}
 
 	if packet.Capability&mysql.ClientPluginAuth > 0 {
-		// TODO: Support mysql.ClientPluginAuth, skip it now
-		idx := bytes.IndexByte(data[offset:], 0)
-		offset = offset + idx + 1
+		if idx := bytes.IndexByte(data[offset:], 0); idx >= 0 {
+			packet.AuthPlugin = string(data[offset : offset+idx])
+			offset = offset + idx + 1
+		}
 	}
 
 	if packet.Capability&mysql.ClientConnectAtts > 0 {
@@ -343,14 +356,13 @@ func (cc *clientConn) readOptionalSSLRequestAndHandshakeResponse() error {
 	cc.collation = resp.Collation
 	cc.attrs = resp.Attrs
 
-	// Open session and do auth.
-	var tlsStatePtr *tls.ConnectionState
-	if cc.tlsConn != nil {
-		tlsState := cc.tlsConn.ConnectionState()
-		tlsStatePtr = &tlsState
-	}
-	cc.ctx, err = cc.server.driver.OpenCtx(uint64(cc.connectionID), cc.capability, cc.collation, cc.dbname, tlsStatePtr)
-	if err != nil {
+	return errors.Trace(cc.openSessionAndDoAuth(resp.Auth, resp.AuthPlugin))
+}
+
+// openSessionAndDoAuth opens a new session for cc.user, authenticates it with authData generated by
+// the authentication plugin of the client and switches to cc.dbname. cc.ctx is set to the new session even if it fails.
+func (cc *clientConn) openSessionAndDoAuth(authData []byte, authPlugin string) error {
+	if err := cc.openSession(); err != nil {
 		return errors.Trace(err)
 	}
 	if !cc.server.skipAuth() {
@@ -360,13 +372,29 @@ func (cc *clientConn) readOptionalSSLRequestAndHandshakeResponse() error {
 		if err1 != nil {
 			return errors.Trace(errAccessDenied.GenByArgs(cc.user, addr, "YES"))
 		}
-		if !cc.ctx.Auth(&auth.UserIdentity{Username: cc.user, Hostname: host}, resp.Auth, cc.salt) {
-			return errors.Trace(errAccessDenied.GenByArgs(cc.user, host, "YES"))
+		if err := cc.authenticate(&auth.UserIdentity{Username: cc.user, Hostname: host}, authPlugin, authData); err != nil {
+			return errors.Trace(err)
 		}
 	}
+	return errors.Trace(cc.initSession())
+}
+
+// openSession opens a new session and sets it to cc.ctx.
+func (cc *clientConn) openSession() error {
+	var tlsStatePtr *tls.ConnectionState
+	if cc.tlsConn != nil {
+		tlsState := cc.tlsConn.ConnectionState()
+		tlsStatePtr = &tlsState
+	}
+	var err error
+	cc.ctx, err = cc.server.driver.OpenCtx(uint64(cc.connectionID), cc.capability, cc.collation, cc.dbname, tlsStatePtr)
+	return errors.Trace(err)
+}
+
+// initSession switches the authenticated session to cc.dbname.
+func (cc *clientConn) initSession() error {
 	if cc.dbname != "" {
-		err = cc.useDB(cc.dbname)
-		if err != nil {
+		if err := cc.useDB(cc.dbname); err != nil {
 			return errors.Trace(err)
 		}
 	}
@@ -374,6 +402,139 @@ func (cc *clientConn) readOptionalSSLRequestAndHandshakeResponse() error {
 	return nil
 }
 
+type changeUserPacket struct {
+	User       string
+	Auth       []byte
+	DBName     string
+	Collation  uint8
+	AuthPlugin string
+	Attrs      map[string]string
+}
+
+// parseChangeUserPacket parses the payload of COM_CHANGE_USER.
+// See https://dev.mysql.com/doc/internals/en/com-change-user.html
+func parseChangeUserPacket(packet *changeUserPacket, data []byte, capability uint32) (err error) {
+	defer func() {
+		// Check malformat packet cause out of range is disgusting, but don't panic!
+		if r := recover(); r != nil {
+			log.Errorf("change user panic, packet data: %v", data)
+			err = mysql.ErrMalformPacket
+		}
+	}()
+	offset := 0
+	// user name
+	packet.User = string(data[offset : offset+bytes.IndexByte(data[offset:], 0)])
+	offset += len(packet.User) + 1
+
+	if capability&mysql.ClientSecureConnection > 0 {
+		// auth length and auth
+		authLen := int(data[offset])
+		offset++
+		packet.Auth = data[offset : offset+authLen]
+		offset += authLen
+	} else {
+		packet.Auth = data[offset : offset+bytes.IndexByte(data[offset:], 0)]
+		offset += len(packet.Auth) + 1
+	}
+
+	// schema name
+	packet.DBName = string(data[offset : offset+bytes.IndexByte(data[offset:], 0)])
+	offset += len(packet.DBName) + 1
+
+	if len(data[offset:]) == 0 {
+		// The remaining fields are optional.
+		return nil
+	}
+	// character set, only the low byte is a valid collation id.
+	packet.Collation = data[offset]
+	offset += 2
+
+	if capability&mysql.ClientPluginAuth > 0 && len(data[offset:]) > 0 {
+		if idx := bytes.IndexByte(data[offset:], 0); idx >= 0 {
+			packet.AuthPlugin = string(data[offset : offset+idx])
+			offset = offset + idx + 1
+		}
+	}
+
+	if capability&mysql.ClientConnectAtts > 0 && len(data[offset:]) > 0 {
+		if num, null, off := parseLengthEncodedInt(data[offset:]); !null {
+			offset += off
+			kv := data[offset : offset+int(num)]
+			attrs, err := parseAttrs(kv)
+			if err != nil {
+				log.Warn("parse attrs error:", errors.ErrorStack(err))
+				return nil
+			}
+			packet.Attrs = attrs
+		}
+	}
+	return nil
+}
+
+// handleChangeUser re-authenticates the connection as another user in a brand new session,
+// so the session variables, prepared statements and current database are all reset.
+// If the authentication fails, the error is sent to the client and the connection is closed like MySQL,
+// so the passwords can't be guessed repeatedly on an established connection.
+func (cc *clientConn) handleChangeUser(data []byte) error {
+	var packet changeUserPacket
+	if err := parseChangeUserPacket(&packet, data, cc.capability); err != nil {
+		return errors.Trace(err)
+	}
+	collation := cc.collation
+	if packet.Collation != 0 {
+		collation = packet.Collation
+	}
+	err := cc.reopenSession(packet.User, packet.DBName, collation, func() error {
+		return cc.openSessionAndDoAuth(packet.Auth, packet.AuthPlugin)
+	})
+	if err != nil {
+		log.Warnf("[%d] change user to %s failed, close this connection %s",
+			cc.connectionID, packet.User, errors.ErrorStack(err))
+		terror.Log(errors.Trace(cc.writeError(err)))
+		return io.EOF
+	}
+	if packet.Attrs != nil {
+		cc.attrs = packet.Attrs
+	}
+	return errors.Trace(cc.writeOK())
+}
+
+// handleResetConnection resets the session state without re-authentication,
+// the user and the current database are kept.
+// See https://dev.mysql.com/doc/internals/en/com-reset-connection.html
+func (cc *clientConn) handleResetConnection() error {
+	user := cc.ctx.User()
+	err := cc.reopenSession(cc.user, cc.ctx.CurrentDB(), cc.collation, func() error {
+		if err := cc.openSession(); err != nil {
+			return errors.Trace(err)
+		}
+		if user != nil && !cc.ctx.AuthWithoutVerification(user) {
+			return errors.Trace(errAccessDenied.GenByArgs(user.Username, user.Hostname, "NO"))
+		}
+		return errors.Trace(cc.initSession())
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(cc.writeOK())
+}
+
+// reopenSession replaces cc.ctx with the session opened by open. The old session is closed on success,
+// otherwise the new session is discarded and the connection stays with the old one.
+func (cc *clientConn) reopenSession(user, dbname string, collation uint8, open func() error) error {
+	oldCtx, oldUser, oldDBName, oldCollation := cc.ctx, cc.user, cc.dbname, cc.collation
+	cc.user, cc.dbname, cc.collation = user, dbname, collation
+
+	if err := open(); err != nil {
+		if cc.ctx != nil && cc.ctx != oldCtx {
+			terror.Log(errors.Trace(cc.ctx.Close()))
+		}
+		cc.ctx, cc.user, cc.dbname, cc.collation = oldCtx, oldUser, oldDBName, oldCollation
+		return errors.Trace(err)
+	}
+	return errors.Trace(oldCtx.Close())
+}
+
 // Run reads client query and writes query result to client in for loop, if there is a panic during query handling,
 // it will be recovered and log the panic error.
 // This function returns and the connection is closed if there is an IO error or there is a panic.
@@ -430,7 +591,7 @@ func (cc *clientConn) Run() {
 			terror.Log(errors.Trace(err1))
 		}
 		cc.addMetrics(data[0], startTime, err)
-		cc.pkt.sequence = 0
+		cc.pkt.resetSequence()
 	}
 }
 
@@ -475,8 +636,14 @@ func (cc *clientConn) addMetrics(cmd byte, startTime time.Time, err error) {
 		label = "StmtSendLongData"
 	case mysql.ComStmtReset:
 		label = "StmtReset"
+	case mysql.ComStmtFetch:
+		label = "StmtFetch"
 	case mysql.ComSetOption:
 		label = "SetOption"
+	case mysql.ComChangeUser:
+		label = "ChangeUser"
+	case mysql.ComResetConnection:
+		label = "ResetConnection"
 	default:
 		label = strconv.Itoa(int(cmd))
 	}
@@ -516,7 +683,11 @@ func (cc *clientConn) dispatch(data []byte) error {
 		if len(data) > 0 && data[len(data)-1] == 0 {
 			data = data[:len(data)-1]
 		}
-		return cc.handleQuery(hack.String(data))
+		sql, err := cc.decodeClientString(data)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return cc.handleQuery(sql)
 	case mysql.ComPing:
 		return cc.writeOK()
 	case mysql.ComInitDB:
@@ -527,7 +698,11 @@ func (cc *clientConn) dispatch(data []byte) error {
 	case mysql.ComFieldList:
 		return cc.handleFieldList(hack.String(data))
 	case mysql.ComStmtPrepare:
-		return cc.handleStmtPrepare(hack.String(data))
+		sql, err := cc.decodeClientString(data)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return cc.handleStmtPrepare(sql)
 	case mysql.ComStmtExecute:
 		return cc.handleStmtExecute(data)
 	case mysql.ComStmtClose:
@@ -536,8 +711,14 @@ func (cc *clientConn) dispatch(data []byte) error {
 		return cc.handleStmtSendLongData(data)
 	case mysql.ComStmtReset:
 		return cc.handleStmtReset(data)
+	case mysql.ComStmtFetch:
+		return cc.handleStmtFetch(data)
 	case mysql.ComSetOption:
 		return cc.handleSetOption(data)
+	case mysql.ComChangeUser:
+		return cc.handleChangeUser(data)
+	case mysql.ComResetConnection:
+		return cc.handleResetConnection()
 	default:
 		return mysql.NewErrf(mysql.ErrUnknown, "command %d not supported now", cmd)
 	}
@@ -612,15 +793,22 @@ func (cc *clientConn) writeError(e error) error {
 // If "more" is true, a mysql.ServerMoreResultsExists bit would be set
 // in the packet.
 func (cc *clientConn) writeEOF(more bool) error {
+	var status uint16
+	if more {
+		status |= mysql.ServerMoreResultsExists
+	}
+	return cc.writeEOFWithStatus(status)
+}
+
+// writeEOFWithStatus writes an EOF packet, the serverStatus flags are
+// merged into the session status.
+func (cc *clientConn) writeEOFWithStatus(serverStatus uint16) error {
 	data := cc.alloc.AllocWithLen(4, 9)
 
 	data = append(data, mysql.EOFHeader)
 	if cc.capability&mysql.ClientProtocol41 > 0 {
 		data = append(data, dumpUint16(cc.ctx.WarningCount())...)
-		status := cc.ctx.Status()
-		if more {
-			status |= mysql.ServerMoreResultsExists
-		}
+		status := cc.ctx.Status() | serverStatus
 		data = append(data, dumpUint16(status)...)
 	}
 
@@ -773,6 +961,26 @@ func (cc *clientConn) handleFieldList(sql string) (err error) {
 	return errors.Trace(cc.flush())
 }
 
+// writeColumnInfo writes the column count, the column definitions and the
+// trailing EOF packet of a resultset. serverStatus is set in the EOF packet.
+func (cc *clientConn) writeColumnInfo(columns []*ColumnInfo, serverStatus uint16) error {
+	columnLen := dumpLengthEncodedInt(uint64(len(columns)))
+	data := cc.alloc.AllocWithLen(4, 1024)
+	data = append(data, columnLen...)
+	if err := cc.writePacket(data); err != nil {
+		return errors.Trace(err)
+	}
+
+	for _, v := range columns {
+		data = data[0:4]
+		data = append(data, v.Dump(cc.alloc)...)
+		if err := cc.writePacket(data); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(cc.writeEOFWithStatus(serverStatus))
+}
+
 // writeResultset writes a resultset.
 // If binary is true, the data would be encoded in BINARY format.
 // If more is true, a flag bit would be set to indicate there are more
@@ -791,25 +999,11 @@ func (cc *clientConn) writeResultset(rs ResultSet, binary bool, more bool) error
 		return errors.Trace(err)
 	}
 
-	columnLen := dumpLengthEncodedInt(uint64(len(columns)))
-	data := cc.alloc.AllocWithLen(4, 1024)
-	data = append(data, columnLen...)
-	if err = cc.writePacket(data); err != nil {
-		return errors.Trace(err)
-	}
-
-	for _, v := range columns {
-		data = data[0:4]
-		data = append(data, v.Dump(cc.alloc)...)
-		if err = cc.writePacket(data); err != nil {
-			return errors.Trace(err)
-		}
-	}
-
-	if err = cc.writeEOF(false); err != nil {
+	if err = cc.writeColumnInfo(columns, 0); err != nil {
 		return errors.Trace(err)
 	}
 
+	data := cc.alloc.AllocWithLen(4, 1024)
 	for {
 		if err != nil {
 			return errors.Trace(err)
@@ -818,6 +1012,7 @@ func (cc *clientConn) writeResultset(rs ResultSet, binary bool, more bool) error
 			break
 		}
 		data = data[0:4]
+		row = cc.encodeResultRow(columns, row)
 		if binary {
 			var rowData []byte
 			rowData, err = dumpRowValuesBinary(cc.alloc, columns, row)
@@ -854,6 +1049,65 @@ func (cc *clientConn) writeResultset(rs ResultSet, binary bool, more bool) error
 	return errors.Trace(cc.flush())
 }
 
+// clientDecoder returns the decoder from character_set_client to utf8, it's nil if no conversion is needed.
+func (cc *clientConn) clientDecoder() *encoding.Decoder {
+	enc := charset.Encoding(cc.ctx.GetSessionVars().Systems[variable.CharacterSetClient])
+	if enc == nil {
+		return nil
+	}
+	return enc.NewDecoder()
+}
+
+// decodeClientString converts the string sent by the client from character_set_client to utf8.
+func (cc *clientConn) decodeClientString(data []byte) (string, error) {
+	return decodeString(cc.clientDecoder(), data)
+}
+
+// decodeString converts data to utf8 with the decoder, data is returned as is if the decoder is nil.
+func decodeString(decoder *encoding.Decoder, data []byte) (string, error) {
+	if decoder == nil {
+		return hack.String(data), nil
+	}
+	data, err := decoder.Bytes(data)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return string(data), nil
+}
+
+// encodeResultRow converts the strings of the row from utf8 to character_set_results,
+// the characters which can't be represented are replaced. The row is copied if it's changed.
+func (cc *clientConn) encodeResultRow(columns []*ColumnInfo, row []types.Datum) []types.Datum {
+	enc := charset.Encoding(cc.ctx.GetSessionVars().Systems[variable.CharacterSetResults])
+	if enc == nil {
+		return row
+	}
+	encoder := encoding.ReplaceUnsupported(enc.NewEncoder())
+	var encoded []types.Datum
+	for i, d := range row {
+		if d.Kind() != types.KindString && d.Kind() != types.KindBytes || columns[i].Charset == mysql.BinaryCollationID {
+			continue
+		}
+		b, err := encoder.Bytes(d.GetBytes())
+		if err != nil {
+			continue
+		}
+		if encoded == nil {
+			encoded = make([]types.Datum, len(row))
+			copy(encoded, row)
+		}
+		if d.Kind() == types.KindString {
+			encoded[i].SetString(string(b))
+		} else {
+			encoded[i].SetBytes(b)
+		}
+	}
+	if encoded == nil {
+		return row
+	}
+	return encoded
+}
+
 func (cc *clientConn) writeMultiResultset(rss []ResultSet, binary bool) error {
 	for _, rs := range rss {
 		if err := cc.writeResultset(rs, binary, true); err != nil {
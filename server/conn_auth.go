@@ -0,0 +1,208 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/pem"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/auth"
+)
+
+// rsaKeyBits is the size of the RSA key used to encrypt the password when the connection is not secure.
+const rsaKeyBits = 2048
+
+// authPlugin is a server side authentication plugin.
+// See https://dev.mysql.com/doc/internals/en/authentication-method.html
+type authPlugin interface {
+	// authenticate verifies the user with authData, which is the first auth response of the client.
+	// The plugin may exchange more packets with the client through cc.
+	authenticate(cc *clientConn, user *auth.UserIdentity, authData []byte) error
+}
+
+var authPlugins = map[string]authPlugin{
+	mysql.AuthNativePassword:      nativePasswordAuth{},
+	mysql.AuthCachingSha2Password: cachingSha2PasswordAuth{},
+	mysql.AuthSha256Password:      sha256PasswordAuth{},
+}
+
+// authenticate authenticates the user with its authentication plugin, if the plugin is different from the one
+// used by the client, an AuthSwitchRequest is sent to ask the client for the auth data of the user's plugin.
+func (cc *clientConn) authenticate(user *auth.UserIdentity, clientPlugin string, authData []byte) error {
+	if clientPlugin == "" {
+		clientPlugin = mysql.AuthNativePassword
+	}
+	pluginName := cc.ctx.AuthPlugin(user)
+	if pluginName == "" {
+		// The user doesn't exist, go on with the plugin of the client so it fails as usual.
+		pluginName = clientPlugin
+	}
+	plugin, ok := authPlugins[pluginName]
+	if !ok {
+		log.Errorf("[%d] unsupported authentication plugin %s", cc.connectionID, pluginName)
+		return errors.Trace(errAccessDenied.GenByArgs(user.Username, user.Hostname, "YES"))
+	}
+	if pluginName != clientPlugin {
+		if cc.capability&mysql.ClientPluginAuth == 0 {
+			// The client only supports mysql_native_password.
+			return errors.Trace(errAccessDenied.GenByArgs(user.Username, user.Hostname, "YES"))
+		}
+		var err error
+		authData, err = cc.writeAuthSwitchRequest(pluginName)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(plugin.authenticate(cc, user, authData))
+}
+
+// writeAuthSwitchRequest asks the client to authenticate with the plugin and returns the auth data of the client.
+// See https://dev.mysql.com/doc/internals/en/connection-phase-packets.html#packet-Protocol::AuthSwitchRequest
+func (cc *clientConn) writeAuthSwitchRequest(pluginName string) ([]byte, error) {
+	data := cc.alloc.AllocWithLen(4, 4+1+len(pluginName)+1+len(cc.salt)+1)
+	data = append(data, mysql.AuthSwitchHeader)
+	data = append(data, pluginName...)
+	data = append(data, 0)
+	data = append(data, cc.salt...)
+	data = append(data, 0)
+	if err := cc.writePacket(data); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := cc.flush(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return cc.readPacket()
+}
+
+// writeAuthMoreData sends the extra auth data to the client.
+// See https://dev.mysql.com/doc/internals/en/connection-phase-packets.html#packet-Protocol::AuthMoreData
+func (cc *clientConn) writeAuthMoreData(moreData []byte) error {
+	data := cc.alloc.AllocWithLen(4, 4+1+len(moreData))
+	data = append(data, mysql.AuthMoreDataHeader)
+	data = append(data, moreData...)
+	if err := cc.writePacket(data); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(cc.flush())
+}
+
+// readSha2Password gets the plaintext password from the client response data of the sha2 plugins.
+// The password is sent in plaintext over TLS, otherwise it's encrypted by the RSA public key of the server,
+// the client sends requestKey to ask for the public key if it doesn't have it.
+func (cc *clientConn) readSha2Password(user *auth.UserIdentity, data []byte, requestKey byte) ([]byte, error) {
+	// The empty password is sent as a single NUL byte.
+	if len(data) == 0 || (len(data) == 1 && data[0] == 0) {
+		return nil, nil
+	}
+	if cc.tlsConn == nil {
+		key, err := cc.server.rsaKey()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(data) == 1 && data[0] == requestKey {
+			pubKey, err1 := x509.MarshalPKIXPublicKey(&key.PublicKey)
+			if err1 != nil {
+				return nil, errors.Trace(err1)
+			}
+			if err1 = cc.writeAuthMoreData(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubKey})); err1 != nil {
+				return nil, errors.Trace(err1)
+			}
+			if data, err1 = cc.readPacket(); err1 != nil {
+				return nil, errors.Trace(err1)
+			}
+		}
+		data, err = rsa.DecryptOAEP(sha1.New(), rand.Reader, key, data, nil)
+		if err != nil {
+			log.Warnf("[%d] decrypt password error %v", cc.connectionID, err)
+			return nil, errors.Trace(errAccessDenied.GenByArgs(user.Username, user.Hostname, "YES"))
+		}
+		// The client XORs the password with the salt before encrypting it.
+		for i := range data {
+			data[i] ^= cc.salt[i%len(cc.salt)]
+		}
+	}
+	if len(data) > 0 && data[len(data)-1] == 0 {
+		data = data[:len(data)-1]
+	}
+	return data, nil
+}
+
+// nativePasswordAuth implements mysql_native_password.
+type nativePasswordAuth struct{}
+
+func (nativePasswordAuth) authenticate(cc *clientConn, user *auth.UserIdentity, authData []byte) error {
+	if !cc.ctx.Auth(user, authData, cc.salt) {
+		return errors.Trace(errAccessDenied.GenByArgs(user.Username, user.Hostname, "YES"))
+	}
+	return nil
+}
+
+// cachingSha2PasswordAuth implements caching_sha2_password. The client sends a scramble first, which is
+// verified against the cached password digest, if there is no cached digest the client is asked for
+// the plaintext password to do the full authentication.
+// See https://dev.mysql.com/doc/dev/mysql-server/latest/page_caching_sha2_authentication_exchanges.html
+type cachingSha2PasswordAuth struct{}
+
+func (cachingSha2PasswordAuth) authenticate(cc *clientConn, user *auth.UserIdentity, authData []byte) error {
+	if len(authData) == 1 && authData[0] == 0 {
+		authData = nil
+	}
+	if cc.ctx.FastAuth(user, authData, cc.salt) {
+		return errors.Trace(cc.writeAuthMoreData([]byte{mysql.CachingSha2FastAuthSuccess}))
+	}
+	if err := cc.writeAuthMoreData([]byte{mysql.CachingSha2FullAuthentication}); err != nil {
+		return errors.Trace(err)
+	}
+	data, err := cc.readPacket()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	pwd, err := cc.readSha2Password(user, data, mysql.CachingSha2RequestPublicKey)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !cc.ctx.Auth(user, pwd, cc.salt) {
+		return errors.Trace(errAccessDenied.GenByArgs(user.Username, user.Hostname, "YES"))
+	}
+	return nil
+}
+
+// sha256PasswordAuth implements sha256_password, it always needs the plaintext password.
+type sha256PasswordAuth struct{}
+
+func (sha256PasswordAuth) authenticate(cc *clientConn, user *auth.UserIdentity, authData []byte) error {
+	pwd, err := cc.readSha2Password(user, authData, mysql.Sha256RequestPublicKey)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !cc.ctx.Auth(user, pwd, cc.salt) {
+		return errors.Trace(errAccessDenied.GenByArgs(user.Username, user.Hostname, "YES"))
+	}
+	return nil
+}
+
+// rsaKey returns the RSA key used to exchange the password over insecure connections,
+// it's generated when it's used for the first time.
+func (s *Server) rsaKey() (*rsa.PrivateKey, error) {
+	s.rsaKeyOnce.Do(func() {
+		s.rsaPrivateKey, s.rsaKeyErr = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	})
+	return s.rsaPrivateKey, errors.Trace(s.rsaKeyErr)
+}
@@ -41,7 +41,10 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/hack"
+	"github.com/pingcap/tidb/util/types"
+	"golang.org/x/text/encoding"
 )
 
 func (cc *clientConn) handleStmtPrepare(sql string) error {
@@ -118,8 +121,8 @@ func (cc *clientConn) handleStmtExecute(data []byte) (err error) {
 
 	flag := data[pos]
 	pos++
-	// Now we only support CURSOR_TYPE_NO_CURSOR flag.
-	if flag != 0 {
+	// Now we only support CURSOR_TYPE_NO_CURSOR and CURSOR_TYPE_READ_ONLY flags.
+	if flag != mysql.CursorTypeNoCursor && flag != mysql.CursorTypeReadOnly {
 		return mysql.NewErrf(mysql.ErrUnknown, "unsupported flag %d", flag)
 	}
 
@@ -158,11 +161,13 @@ func (cc *clientConn) handleStmtExecute(data []byte) (err error) {
 			paramValues = data[pos+1:]
 		}
 
-		err = parseStmtArgs(args, stmt.BoundParams(), nullBitmaps, stmt.GetParamsType(), paramValues)
+		err = parseStmtArgs(args, stmt.BoundParams(), nullBitmaps, stmt.GetParamsType(), paramValues, cc.clientDecoder())
 		if err != nil {
 			return errors.Trace(err)
 		}
 	}
+	// Executing the statement again closes the cursor opened by the last execution.
+	stmt.StoreResultSet(nil)
 	rs, err := stmt.Execute(args...)
 	if err != nil {
 		return errors.Trace(err)
@@ -171,10 +176,128 @@ func (cc *clientConn) handleStmtExecute(data []byte) (err error) {
 		return errors.Trace(cc.writeOK())
 	}
 
+	if flag == mysql.CursorTypeReadOnly {
+		return errors.Trace(cc.openCursor(stmt, rs))
+	}
 	return errors.Trace(cc.writeResultset(rs, true, false))
 }
 
-func parseStmtArgs(args []interface{}, boundParams [][]byte, nullBitmap, paramTypes, paramValues []byte) (err error) {
+// openCursor keeps rs open in stmt and only writes the column definitions,
+// the rows are sent later by COM_STMT_FETCH.
+func (cc *clientConn) openCursor(stmt PreparedStatement, rs ResultSet) error {
+	crs := &cursorResultSet{ResultSet: rs}
+	// We need to call Next before we get columns.
+	// Otherwise, we will get incorrect columns info.
+	if _, err := crs.hasNext(); err != nil {
+		terror.Call(rs.Close)
+		return errors.Trace(err)
+	}
+	columns, err := rs.Columns()
+	if err != nil {
+		terror.Call(rs.Close)
+		return errors.Trace(err)
+	}
+	stmt.StoreResultSet(crs)
+	if err = cc.writeColumnInfo(columns, mysql.ServerStatusCursorExists); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(cc.flush())
+}
+
+// See https://dev.mysql.com/doc/internals/en/com-stmt-fetch.html
+func (cc *clientConn) handleStmtFetch(data []byte) (err error) {
+	if len(data) < 8 {
+		return mysql.ErrMalformPacket
+	}
+
+	stmtID := binary.LittleEndian.Uint32(data[0:4])
+	fetchSize := binary.LittleEndian.Uint32(data[4:8])
+
+	stmt := cc.ctx.GetStatement(int(stmtID))
+	if stmt == nil {
+		return mysql.NewErr(mysql.ErrUnknownStmtHandler,
+			strconv.FormatUint(uint64(stmtID), 10), "stmt_fetch")
+	}
+	crs, ok := stmt.GetResultSet().(*cursorResultSet)
+	if !ok {
+		return mysql.NewErrf(mysql.ErrStmtHasNoOpenCursor, "The statement (%d) has no open cursor.", stmtID)
+	}
+
+	columns, err := crs.Columns()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data = cc.alloc.AllocWithLen(4, 1024)
+	for i := uint32(0); i < fetchSize; i++ {
+		row, err1 := crs.Next()
+		if err1 != nil {
+			return errors.Trace(err1)
+		}
+		if row == nil {
+			break
+		}
+		var rowData []byte
+		rowData, err = dumpRowValuesBinary(cc.alloc, columns, cc.encodeResultRow(columns, row))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		data = append(data[0:4], rowData...)
+		if err = cc.writePacket(data); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	more, err := crs.hasNext()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	serverStatus := mysql.ServerStatusCursorExists
+	if !more {
+		serverStatus |= mysql.ServerStatusLastRowSend
+		stmt.StoreResultSet(nil)
+	}
+	if err = cc.writeEOFWithStatus(serverStatus); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(cc.flush())
+}
+
+// cursorResultSet is the ResultSet kept open by a statement executed with a cursor.
+// It reads one row ahead so that the end of the cursor can be reported with the
+// last fetched batch.
+type cursorResultSet struct {
+	ResultSet
+	// next is the row which has been read but not sent yet.
+	next []types.Datum
+}
+
+// Next implements ResultSet Next method.
+func (crs *cursorResultSet) Next() ([]types.Datum, error) {
+	if crs.next != nil {
+		row := crs.next
+		crs.next = nil
+		return row, nil
+	}
+	row, err := crs.ResultSet.Next()
+	return row, errors.Trace(err)
+}
+
+// hasNext reports whether there are remaining rows in the cursor.
+func (crs *cursorResultSet) hasNext() (bool, error) {
+	if crs.next == nil {
+		row, err := crs.ResultSet.Next()
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		crs.next = row
+	}
+	return crs.next != nil, nil
+}
+
+// parseStmtArgs parses the parameters of COM_STMT_EXECUTE, the string parameters, including the ones
+// sent by COM_STMT_SEND_LONG_DATA, are converted from character_set_client to utf8 by the decoder.
+func parseStmtArgs(args []interface{}, boundParams [][]byte, nullBitmap, paramTypes, paramValues []byte,
+	decoder *encoding.Decoder) (err error) {
 	pos := 0
 	var v []byte
 	var n int
@@ -186,6 +309,14 @@ func parseStmtArgs(args []interface{}, boundParams [][]byte, nullBitmap, paramTy
 			continue
 		}
 		if boundParams[i] != nil {
+			// The long data is decoded once it's complete, a character may be split across the packets.
+			if (i<<1)+1 < len(paramTypes) && isCharParamType(paramTypes[i<<1]) {
+				args[i], err = decodeString(decoder, boundParams[i])
+				if err != nil {
+					return errors.Trace(err)
+				}
+				continue
+			}
 			args[i] = boundParams[i]
 			continue
 		}
@@ -296,10 +427,15 @@ func parseStmtArgs(args []interface{}, boundParams [][]byte, nullBitmap, paramTy
 				return
 			}
 
-			if !isNull {
-				args[i] = hack.String(v)
-			} else {
+			if isNull {
 				args[i] = nil
+			} else if isCharParamType(tp) {
+				args[i], err = decodeString(decoder, v)
+				if err != nil {
+					return errors.Trace(err)
+				}
+			} else {
+				args[i] = hack.String(v)
 			}
 			continue
 		default:
@@ -310,6 +446,16 @@ func parseStmtArgs(args []interface{}, boundParams [][]byte, nullBitmap, paramTy
 	return
 }
 
+// isCharParamType checks whether the parameter is a character string in character_set_client,
+// the blob parameters are binary and aren't converted.
+func isCharParamType(tp byte) bool {
+	switch tp {
+	case mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeString, mysql.TypeEnum, mysql.TypeSet:
+		return true
+	}
+	return false
+}
+
 func (cc *clientConn) handleStmtClose(data []byte) (err error) {
 	if len(data) < 4 {
 		return
@@ -337,6 +483,7 @@ func (cc *clientConn) handleStmtSendLongData(data []byte) (err error) {
 	}
 
 	paramID := int(binary.LittleEndian.Uint16(data[4:6]))
+	// The data is kept in character_set_client, it's decoded by parseStmtArgs when the statement is executed.
 	return stmt.AppendParam(paramID, data[6:])
 }
 
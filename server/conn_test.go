@@ -16,10 +16,22 @@ package server
 import (
 	"bufio"
 	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
 	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/arena"
+	"github.com/pingcap/tidb/util/auth"
 )
 
 type ConnTestSuite struct{}
@@ -162,3 +174,255 @@ func mapBelong(m1, m2 map[string]string) bool {
 	}
 	return true
 }
+
+func (ts ConnTestSuite) TestParseChangeUserPacket(c *C) {
+	c.Parallel()
+	capability := mysql.ClientProtocol41 | mysql.ClientSecureConnection | mysql.ClientPluginAuth
+	data := []byte("root\x00")
+	data = append(data, 3, 0x01, 0x02, 0x03)
+	data = append(data, "test\x00"...)
+	data = append(data, mysql.DefaultCollationID, 0)
+	data = append(data, "mysql_native_password\x00"...)
+	var p changeUserPacket
+	err := parseChangeUserPacket(&p, data, capability)
+	c.Assert(err, IsNil)
+	c.Assert(p.User, Equals, "root")
+	c.Assert(p.Auth, DeepEquals, []byte{0x01, 0x02, 0x03})
+	c.Assert(p.DBName, Equals, "test")
+	c.Assert(p.Collation, Equals, uint8(mysql.DefaultCollationID))
+
+	// The character set and the following fields are optional.
+	p = changeUserPacket{}
+	err = parseChangeUserPacket(&p, []byte("root\x00\x00\x00"), capability)
+	c.Assert(err, IsNil)
+	c.Assert(p.User, Equals, "root")
+	c.Assert(p.Auth, HasLen, 0)
+	c.Assert(p.DBName, Equals, "")
+
+	p = changeUserPacket{}
+	err = parseChangeUserPacket(&p, []byte("root\x00\x05"), capability)
+	c.Assert(err, NotNil)
+}
+
+// mockConn is a net.Conn which is only used to provide the remote address.
+type mockConn struct {
+	net.Conn
+}
+
+func (mc mockConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 3306}
+}
+
+func (ts ConnTestSuite) TestChangeUserAndResetConnection(c *C) {
+	store, err := tidb.NewStore("memory:///tmp/tidb_change_user")
+	c.Assert(err, IsNil)
+	defer store.Close()
+	_, err = tidb.BootstrapSession(store)
+	c.Assert(err, IsNil)
+
+	var outBuffer bytes.Buffer
+	cc := &clientConn{
+		connectionID: 1,
+		salt:         []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x10, 0x11, 0x12, 0x13, 0x14},
+		server: &Server{
+			cfg:               &config.Config{},
+			driver:            NewTiDBDriver(store),
+			concurrentLimiter: NewTokenLimiter(1),
+		},
+		bufReadConn: &bufferedReadConn{Conn: mockConn{}},
+		capability:  mysql.ClientProtocol41 | mysql.ClientSecureConnection,
+		collation:   mysql.DefaultCollationID,
+		user:        "root",
+		alloc:       arena.NewAllocator(1024),
+		pkt: &packetIO{
+			bufWriter: bufio.NewWriter(&outBuffer),
+		},
+	}
+	c.Assert(cc.openSessionAndDoAuth(nil, mysql.AuthNativePassword), IsNil)
+	mustExec := func(sql string) {
+		_, err1 := cc.ctx.Execute(sql)
+		c.Assert(err1, IsNil, Commentf("sql: %s", sql))
+	}
+	userVarIsNull := func() bool {
+		rs, err1 := cc.ctx.Execute("select @a")
+		c.Assert(err1, IsNil)
+		row, err1 := rs[0].Next()
+		c.Assert(err1, IsNil)
+		c.Assert(rs[0].Close(), IsNil)
+		return row[0].IsNull()
+	}
+	changeUserData := func(user, password, dbname string) []byte {
+		data := append([]byte(user), 0)
+		scramble := auth.ScramblePassword(cc.salt, password)
+		data = append(data, byte(len(scramble)))
+		data = append(data, scramble...)
+		data = append(data, dbname...)
+		return append(data, 0)
+	}
+	mustExec("create user 'change_user'@'%' identified by '123'")
+	mustExec("grant select on test.* to 'change_user'@'%'")
+	mustExec("flush privileges")
+	mustExec("create database change_user_db")
+
+	// COM_RESET_CONNECTION resets the session but keeps the user and the current database.
+	mustExec("use change_user_db")
+	mustExec("set @a = 1")
+	stmt, _, _, err := cc.ctx.Prepare("select 1")
+	c.Assert(err, IsNil)
+	c.Assert(userVarIsNull(), IsFalse)
+	c.Assert(cc.dispatch([]byte{mysql.ComResetConnection}), IsNil)
+	c.Assert(cc.ctx.CurrentDB(), Equals, "change_user_db")
+	c.Assert(cc.ctx.User().Username, Equals, "root")
+	c.Assert(userVarIsNull(), IsTrue)
+	c.Assert(cc.ctx.GetStatement(stmt.ID()), IsNil)
+	mustExec("create table t (a int)")
+
+	// COM_CHANGE_USER fails with a wrong password, the error is sent and the connection is closed.
+	mustExec("set @a = 1")
+	outBuffer.Reset()
+	err = cc.dispatch(append([]byte{mysql.ComChangeUser}, changeUserData("change_user", "456", "test")...))
+	c.Assert(err, Equals, io.EOF)
+	c.Assert(outBuffer.Bytes()[4], Equals, byte(mysql.ErrHeader))
+	// The old session is kept until the connection is closed.
+	c.Assert(cc.user, Equals, "root")
+	c.Assert(cc.ctx.User().Username, Equals, "root")
+	c.Assert(cc.ctx.CurrentDB(), Equals, "change_user_db")
+	c.Assert(userVarIsNull(), IsFalse)
+
+	// COM_CHANGE_USER re-authenticates and resets the session.
+	err = cc.dispatch(append([]byte{mysql.ComChangeUser}, changeUserData("change_user", "123", "test")...))
+	c.Assert(err, IsNil)
+	c.Assert(cc.user, Equals, "change_user")
+	c.Assert(cc.ctx.User().Username, Equals, "change_user")
+	c.Assert(cc.ctx.CurrentDB(), Equals, "test")
+	c.Assert(userVarIsNull(), IsTrue)
+	// The privileges of the new user are checked.
+	_, err = cc.ctx.Execute("create table change_user_db.t1 (a int)")
+	c.Assert(err, NotNil)
+
+	// The user is kept by COM_RESET_CONNECTION.
+	c.Assert(cc.dispatch([]byte{mysql.ComResetConnection}), IsNil)
+	c.Assert(cc.ctx.User().Username, Equals, "change_user")
+	_, err = cc.ctx.Execute("create table change_user_db.t1 (a int)")
+	c.Assert(err, NotNil)
+	c.Assert(cc.ctx.Close(), IsNil)
+}
+
+func (ts ConnTestSuite) TestAuthPlugins(c *C) {
+	store, err := tidb.NewStore("memory:///tmp/tidb_auth_plugins")
+	c.Assert(err, IsNil)
+	defer store.Close()
+	_, err = tidb.BootstrapSession(store)
+	c.Assert(err, IsNil)
+
+	salt := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x10, 0x11, 0x12, 0x13, 0x14}
+	server := &Server{
+		cfg:               &config.Config{},
+		driver:            NewTiDBDriver(store),
+		concurrentLimiter: NewTokenLimiter(1),
+	}
+	key, err := server.rsaKey()
+	c.Assert(err, IsNil)
+	// authAs authenticates the user with the auth data of the handshake response, the packets of the client
+	// are replied one by one after the packets of the server. It returns the payloads written by the server.
+	authAs := func(user, clientPlugin string, authData []byte, clientPackets ...[]byte) ([][]byte, error) {
+		var inBuffer, outBuffer bytes.Buffer
+		for i, pkt := range clientPackets {
+			header := []byte{byte(len(pkt)), byte(len(pkt) >> 8), byte(len(pkt) >> 16), byte(2*i + 1)}
+			inBuffer.Write(header)
+			inBuffer.Write(pkt)
+		}
+		bufReadConn := &bufferedReadConn{Conn: mockConn{}, rb: bufio.NewReader(&inBuffer)}
+		cc := &clientConn{
+			connectionID: 1,
+			salt:         salt,
+			server:       server,
+			bufReadConn:  bufReadConn,
+			capability:   mysql.ClientProtocol41 | mysql.ClientSecureConnection | mysql.ClientPluginAuth,
+			collation:    mysql.DefaultCollationID,
+			user:         user,
+			alloc:        arena.NewAllocator(1024),
+			pkt: &packetIO{
+				bufReadConn: bufReadConn,
+				bufWriter:   bufio.NewWriter(&outBuffer),
+			},
+		}
+		err1 := cc.openSessionAndDoAuth(authData, clientPlugin)
+		if cc.ctx != nil {
+			c.Assert(cc.ctx.Close(), IsNil)
+		}
+		var written [][]byte
+		for data := outBuffer.Bytes(); len(data) > 0; {
+			length := int(data[0]) | int(data[1])<<8 | int(data[2])<<16
+			written = append(written, data[4:4+length])
+			data = data[4+length:]
+		}
+		return written, err1
+	}
+	encrypt := func(pwd string) []byte {
+		data := append([]byte(pwd), 0)
+		for i := range data {
+			data[i] ^= salt[i%len(salt)]
+		}
+		encrypted, err1 := rsa.EncryptOAEP(sha1.New(), rand.Reader, &key.PublicKey, data, nil)
+		c.Assert(err1, IsNil)
+		return encrypted
+	}
+	switchRequest := append([]byte{mysql.AuthSwitchHeader}, mysql.AuthCachingSha2Password...)
+	switchRequest = append(append(append(switchRequest, 0), salt...), 0)
+	pubKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	c.Assert(err, IsNil)
+	pemKey := append([]byte{mysql.AuthMoreDataHeader}, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubKey})...)
+	fastAuthSuccess := []byte{mysql.AuthMoreDataHeader, mysql.CachingSha2FastAuthSuccess}
+	fullAuth := []byte{mysql.AuthMoreDataHeader, mysql.CachingSha2FullAuthentication}
+
+	se, err := tidb.CreateSession(store)
+	c.Assert(err, IsNil)
+	for _, sql := range []string{
+		"create user 'native'@'%' identified by '123'",
+		"create user 'sha2'@'%' identified with caching_sha2_password by '123'",
+		"create user 'sha256'@'%' identified with sha256_password by '123'",
+		"create user 'sha2_empty'@'%' identified with caching_sha2_password",
+		"flush privileges",
+	} {
+		_, err = se.Execute(sql)
+		c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	}
+	se.Close()
+
+	// The client is asked to switch to caching_sha2_password, there is no cached digest so the full
+	// authentication is done with the password encrypted by the public key of the server.
+	written, err := authAs("sha2", mysql.AuthNativePassword, auth.ScramblePassword(salt, "123"),
+		auth.Sha2Scramble(salt, "123"), []byte{mysql.CachingSha2RequestPublicKey}, encrypt("123"))
+	c.Assert(err, IsNil)
+	c.Assert(written, DeepEquals, [][]byte{switchRequest, fullAuth, pemKey})
+	// The wrong password fails the full authentication.
+	_, err = authAs("sha2", mysql.AuthCachingSha2Password, auth.Sha2Scramble(salt, "456"), encrypt("456"))
+	c.Assert(terror.ErrorEqual(err, errAccessDenied), IsTrue)
+	// The digest is cached now, the fast authentication succeeds.
+	written, err = authAs("sha2", mysql.AuthCachingSha2Password, auth.Sha2Scramble(salt, "123"))
+	c.Assert(err, IsNil)
+	c.Assert(written, DeepEquals, [][]byte{fastAuthSuccess})
+	written, err = authAs("sha2_empty", mysql.AuthCachingSha2Password, []byte{0})
+	c.Assert(err, IsNil)
+	c.Assert(written, DeepEquals, [][]byte{fastAuthSuccess})
+
+	// sha256_password always needs the password.
+	written, err = authAs("sha256", mysql.AuthSha256Password, []byte{mysql.Sha256RequestPublicKey}, encrypt("123"))
+	c.Assert(err, IsNil)
+	c.Assert(written, DeepEquals, [][]byte{pemKey})
+	_, err = authAs("sha256", mysql.AuthSha256Password, encrypt("456"))
+	c.Assert(terror.ErrorEqual(err, errAccessDenied), IsTrue)
+
+	// The client is asked to switch back to mysql_native_password.
+	nativeSwitchRequest := append([]byte{mysql.AuthSwitchHeader}, mysql.AuthNativePassword...)
+	nativeSwitchRequest = append(append(append(nativeSwitchRequest, 0), salt...), 0)
+	written, err = authAs("native", mysql.AuthCachingSha2Password, auth.Sha2Scramble(salt, "123"), auth.ScramblePassword(salt, "123"))
+	c.Assert(err, IsNil)
+	c.Assert(written, DeepEquals, [][]byte{nativeSwitchRequest})
+
+	// The user doesn't exist, it fails without switching the plugin.
+	written, err = authAs("not_exist", mysql.AuthNativePassword, auth.ScramblePassword(salt, "123"))
+	c.Assert(terror.ErrorEqual(err, errAccessDenied), IsTrue)
+	c.Assert(written, HasLen, 0)
+}
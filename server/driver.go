@@ -17,6 +17,7 @@ import (
 	"crypto/tls"
 	"fmt"
 
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/auth"
 	"github.com/pingcap/tidb/util/types"
@@ -78,6 +79,21 @@ type QueryCtx interface {
 	// Auth verifies user's authentication.
 	Auth(user *auth.UserIdentity, auth []byte, salt []byte) bool
 
+	// AuthWithoutVerification binds an already authenticated user to the QueryCtx.
+	AuthWithoutVerification(user *auth.UserIdentity) bool
+
+	// FastAuth verifies the scramble of caching_sha2_password against the cached password digest.
+	FastAuth(user *auth.UserIdentity, scramble []byte, salt []byte) bool
+
+	// AuthPlugin returns the authentication plugin of the user, it's empty if the user doesn't exist.
+	AuthPlugin(user *auth.UserIdentity) string
+
+	// User returns the authenticated user of the QueryCtx.
+	User() *auth.UserIdentity
+
+	// GetSessionVars returns the session variables.
+	GetSessionVars() *variable.SessionVars
+
 	// ShowProcess shows the information about the session.
 	ShowProcess() util.ProcessInfo
 
@@ -110,7 +126,13 @@ type PreparedStatement interface {
 	// GetParamsType returns the type for parameters.
 	GetParamsType() []byte
 
-	// Reset removes all bound parameters.
+	// StoreResultSet stores the result set opened by a cursor execution.
+	StoreResultSet(rs ResultSet)
+
+	// GetResultSet returns the result set stored by StoreResultSet.
+	GetResultSet() ResultSet
+
+	// Reset removes all bound parameters and closes the opened cursor.
 	Reset()
 
 	// Close closes the statement.
@@ -22,6 +22,8 @@ import (
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/auth"
 	"github.com/pingcap/tidb/util/types"
@@ -42,9 +44,8 @@ func NewTiDBDriver(store kv.Storage) *TiDBDriver {
 
 // TiDBContext implements QueryCtx.
 type TiDBContext struct {
-	session   tidb.Session
-	currentDB string
-	stmts     map[int]*TiDBStatement
+	session tidb.Session
+	stmts   map[int]*TiDBStatement
 }
 
 // TiDBStatement implements PreparedStatement.
@@ -54,6 +55,7 @@ type TiDBStatement struct {
 	boundParams [][]byte
 	paramsType  []byte
 	ctx         *TiDBContext
+	rs          ResultSet
 }
 
 // ID implements PreparedStatement ID method.
@@ -105,16 +107,32 @@ func (ts *TiDBStatement) GetParamsType() []byte {
 	return ts.paramsType
 }
 
+// StoreResultSet implements PreparedStatement StoreResultSet method.
+func (ts *TiDBStatement) StoreResultSet(rs ResultSet) {
+	// A statement has at most one open cursor, close the old one first.
+	if ts.rs != nil {
+		terror.Call(ts.rs.Close)
+	}
+	ts.rs = rs
+}
+
+// GetResultSet implements PreparedStatement GetResultSet method.
+func (ts *TiDBStatement) GetResultSet() ResultSet {
+	return ts.rs
+}
+
 // Reset implements PreparedStatement Reset method.
 func (ts *TiDBStatement) Reset() {
 	for i := range ts.boundParams {
 		ts.boundParams[i] = nil
 	}
+	ts.StoreResultSet(nil)
 }
 
 // Close implements PreparedStatement Close method.
 func (ts *TiDBStatement) Close() error {
 	//TODO close at tidb level
+	ts.StoreResultSet(nil)
 	err := ts.ctx.session.DropPreparedStmt(ts.id)
 	if err != nil {
 		return errors.Trace(err)
@@ -137,13 +155,17 @@ func (qd *TiDBDriver) OpenCtx(connID uint64, capability uint32, collation uint8,
 	session.SetClientCapability(capability)
 	session.SetConnectionID(connID)
 	tc := &TiDBContext{
-		session:   session,
-		currentDB: dbname,
-		stmts:     make(map[int]*TiDBStatement),
+		session: session,
+		stmts:   make(map[int]*TiDBStatement),
 	}
 	return tc, nil
 }
 
+// GetSessionVars implements QueryCtx GetSessionVars method.
+func (tc *TiDBContext) GetSessionVars() *variable.SessionVars {
+	return tc.session.GetSessionVars()
+}
+
 // Status implements QueryCtx Status method.
 func (tc *TiDBContext) Status() uint16 {
 	return tc.session.Status()
@@ -181,7 +203,7 @@ func (tc *TiDBContext) AffectedRows() uint64 {
 
 // CurrentDB implements QueryCtx CurrentDB method.
 func (tc *TiDBContext) CurrentDB() string {
-	return tc.currentDB
+	return tc.session.GetSessionVars().CurrentDB
 }
 
 // WarningCount implements QueryCtx WarningCount method.
@@ -219,6 +241,9 @@ func (tc *TiDBContext) SetClientCapability(flags uint32) {
 
 // Close implements QueryCtx Close method.
 func (tc *TiDBContext) Close() error {
+	for _, stmt := range tc.stmts {
+		stmt.StoreResultSet(nil)
+	}
 	tc.session.Close()
 	return nil
 }
@@ -228,6 +253,26 @@ func (tc *TiDBContext) Auth(user *auth.UserIdentity, auth []byte, salt []byte) b
 	return tc.session.Auth(user, auth, salt)
 }
 
+// AuthWithoutVerification implements QueryCtx AuthWithoutVerification method.
+func (tc *TiDBContext) AuthWithoutVerification(user *auth.UserIdentity) bool {
+	return tc.session.AuthWithoutVerification(user)
+}
+
+// FastAuth implements QueryCtx FastAuth method.
+func (tc *TiDBContext) FastAuth(user *auth.UserIdentity, scramble []byte, salt []byte) bool {
+	return tc.session.FastAuth(user, scramble, salt)
+}
+
+// AuthPlugin implements QueryCtx AuthPlugin method.
+func (tc *TiDBContext) AuthPlugin(user *auth.UserIdentity) string {
+	return tc.session.AuthPlugin(user)
+}
+
+// User implements QueryCtx User method.
+func (tc *TiDBContext) User() *auth.UserIdentity {
+	return tc.session.GetSessionVars().User
+}
+
 // FieldList implements QueryCtx FieldList method.
 func (tc *TiDBContext) FieldList(table string) (colums []*ColumnInfo, err error) {
 	rs, err := tc.Execute("SELECT * FROM `" + table + "` LIMIT 0")
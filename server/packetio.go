@@ -36,6 +36,8 @@ package server
 
 import (
 	"bufio"
+	"bytes"
+	"compress/zlib"
 	"io"
 
 	"github.com/juju/errors"
@@ -44,11 +46,25 @@ import (
 
 const defaultWriterSize = 16 * 1024
 
+// Payloads shorter than minCompressLength are sent without compression,
+// the same as MySQL does.
+const minCompressLength = 50
+
 // packetIO is a helper to read and write data in packet format.
 type packetIO struct {
 	bufReadConn *bufferedReadConn
 	bufWriter   *bufio.Writer
 	sequence    uint8
+
+	// The following fields are used by the compressed protocol.
+	// See https://dev.mysql.com/doc/internals/en/compressed-packet-header.html
+	compress           bool
+	compressedSequence uint8
+	// readBuf holds the uncompressed data which is not read yet.
+	readBuf []byte
+	// writeBuf holds the packets which are not compressed yet.
+	writeBuf bytes.Buffer
+	zw       *zlib.Writer
 }
 
 func newPacketIO(bufReadConn *bufferedReadConn) *packetIO {
@@ -62,24 +78,93 @@ func (p *packetIO) setBufferedReadConn(bufReadConn *bufferedReadConn) {
 	p.bufWriter = bufio.NewWriterSize(bufReadConn, defaultWriterSize)
 }
 
+// setCompressed enables the compressed protocol, it's called after the handshake succeeds.
+func (p *packetIO) setCompressed() {
+	p.compress = true
+	p.compressedSequence = 0
+}
+
+// resetSequence resets the packet sequences at the beginning of a command.
+func (p *packetIO) resetSequence() {
+	p.sequence = 0
+	p.compressedSequence = 0
+}
+
+// readFull reads exactly len(buf) bytes of uncompressed data.
+func (p *packetIO) readFull(buf []byte) error {
+	if !p.compress {
+		_, err := io.ReadFull(p.bufReadConn, buf)
+		return errors.Trace(err)
+	}
+	for len(buf) > 0 {
+		if len(p.readBuf) == 0 {
+			if err := p.readCompressedPacket(); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		n := copy(buf, p.readBuf)
+		buf = buf[n:]
+		p.readBuf = p.readBuf[n:]
+	}
+	return nil
+}
+
+// readCompressedPacket reads a compressed packet and saves the uncompressed payload in readBuf.
+func (p *packetIO) readCompressedPacket() error {
+	var header [7]byte
+	if _, err := io.ReadFull(p.bufReadConn, header[:]); err != nil {
+		return errors.Trace(err)
+	}
+
+	sequence := header[3]
+	if sequence != p.compressedSequence {
+		return errInvalidSequence.Gen("invalid compressed sequence %d != %d", sequence, p.compressedSequence)
+	}
+	p.compressedSequence++
+
+	length := int(uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16)
+	uncompressedLength := int(uint32(header[4]) | uint32(header[5])<<8 | uint32(header[6])<<16)
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(p.bufReadConn, data); err != nil {
+		return errors.Trace(err)
+	}
+	// Zero uncompressed length means the payload is not compressed.
+	if uncompressedLength == 0 {
+		p.readBuf = data
+		return nil
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	p.readBuf = make([]byte, uncompressedLength)
+	if _, err = io.ReadFull(zr, p.readBuf); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(zr.Close())
+}
+
 func (p *packetIO) readOnePacket() ([]byte, error) {
 	var header [4]byte
 
-	if _, err := io.ReadFull(p.bufReadConn, header[:]); err != nil {
+	if err := p.readFull(header[:]); err != nil {
 		return nil, errors.Trace(err)
 	}
 
 	sequence := header[3]
-	if sequence != p.sequence {
+	// Like MySQL, the sequence of the packets inside compressed packets is not checked.
+	if sequence != p.sequence && !p.compress {
 		return nil, errInvalidSequence.Gen("invalid sequence %d != %d", sequence, p.sequence)
 	}
 
-	p.sequence++
+	p.sequence = sequence + 1
 
 	length := int(uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16)
 
 	data := make([]byte, length)
-	if _, err := io.ReadFull(p.bufReadConn, data); err != nil {
+	if err := p.readFull(data); err != nil {
 		return nil, errors.Trace(err)
 	}
 	return data, nil
@@ -114,6 +199,11 @@ func (p *packetIO) readPacket() ([]byte, error) {
 
 // writePacket writes data that already have header
 func (p *packetIO) writePacket(data []byte) error {
+	var w io.Writer = p.bufWriter
+	if p.compress {
+		// The packets are buffered and compressed in batch.
+		w = &p.writeBuf
+	}
 	length := len(data) - 4
 
 	for length >= mysql.MaxPayloadLen {
@@ -123,7 +213,7 @@ func (p *packetIO) writePacket(data []byte) error {
 
 		data[3] = p.sequence
 
-		if n, err := p.bufWriter.Write(data[:4+mysql.MaxPayloadLen]); err != nil {
+		if n, err := w.Write(data[:4+mysql.MaxPayloadLen]); err != nil {
 			return mysql.ErrBadConn
 		} else if n != (4 + mysql.MaxPayloadLen) {
 			return mysql.ErrBadConn
@@ -139,16 +229,79 @@ func (p *packetIO) writePacket(data []byte) error {
 	data[2] = byte(length >> 16)
 	data[3] = p.sequence
 
-	if n, err := p.bufWriter.Write(data); err != nil {
+	if n, err := w.Write(data); err != nil {
 		return errors.Trace(mysql.ErrBadConn)
 	} else if n != len(data) {
 		return errors.Trace(mysql.ErrBadConn)
-	} else {
-		p.sequence++
-		return nil
 	}
+	p.sequence++
+	if p.compress {
+		return errors.Trace(p.writeCompressedPackets(false))
+	}
+	return nil
+}
+
+// writeCompressedPackets compresses the buffered packets into compressed packets.
+// Only full-sized compressed packets are written unless all is true.
+func (p *packetIO) writeCompressedPackets(all bool) error {
+	for p.writeBuf.Len() >= mysql.MaxPayloadLen || (all && p.writeBuf.Len() > 0) {
+		n := p.writeBuf.Len()
+		if n > mysql.MaxPayloadLen {
+			n = mysql.MaxPayloadLen
+		}
+		if err := p.writeCompressedPacket(p.writeBuf.Next(n)); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if p.writeBuf.Len() == 0 {
+		p.writeBuf.Reset()
+	}
+	return nil
+}
+
+func (p *packetIO) writeCompressedPacket(payload []byte) error {
+	data := payload
+	uncompressedLength := 0
+	if len(payload) >= minCompressLength {
+		var buf bytes.Buffer
+		if p.zw == nil {
+			p.zw = zlib.NewWriter(&buf)
+		} else {
+			p.zw.Reset(&buf)
+		}
+		if _, err := p.zw.Write(payload); err != nil {
+			return errors.Trace(err)
+		}
+		if err := p.zw.Close(); err != nil {
+			return errors.Trace(err)
+		}
+		// Send the original payload if it can't be compressed.
+		if buf.Len() < len(payload) {
+			data = buf.Bytes()
+			uncompressedLength = len(payload)
+		}
+	}
+
+	header := []byte{
+		byte(len(data)), byte(len(data) >> 8), byte(len(data) >> 16),
+		p.compressedSequence,
+		byte(uncompressedLength), byte(uncompressedLength >> 8), byte(uncompressedLength >> 16),
+	}
+	if _, err := p.bufWriter.Write(header); err != nil {
+		return errors.Trace(mysql.ErrBadConn)
+	}
+	if _, err := p.bufWriter.Write(data); err != nil {
+		return errors.Trace(mysql.ErrBadConn)
+	}
+	p.compressedSequence++
+	return nil
 }
 
 func (p *packetIO) flush() error {
+	if p.compress {
+		if err := p.writeCompressedPackets(true); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	return p.bufWriter.Flush()
 }
@@ -0,0 +1,87 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"io/ioutil"
+
+	. "github.com/pingcap/check"
+)
+
+type PacketIOTestSuite struct{}
+
+var _ = Suite(PacketIOTestSuite{})
+
+func (ts PacketIOTestSuite) TestCompressedPacketIO(c *C) {
+	c.Parallel()
+	var outBuffer bytes.Buffer
+	wp := &packetIO{bufWriter: bufio.NewWriter(&outBuffer)}
+	wp.setCompressed()
+
+	small := []byte("select 1")
+	large := bytes.Repeat([]byte("select 1;"), 100)
+	for _, payload := range [][]byte{small, large} {
+		data := make([]byte, 4, 4+len(payload))
+		data = append(data, payload...)
+		c.Assert(wp.writePacket(data), IsNil)
+	}
+	// The packets are not written until flush.
+	c.Assert(outBuffer.Len(), Equals, 0)
+	c.Assert(wp.flush(), IsNil)
+
+	// All the packets are compressed into a single compressed packet.
+	out := outBuffer.Bytes()
+	length := int(out[0]) | int(out[1])<<8 | int(out[2])<<16
+	uncompressedLength := int(out[4]) | int(out[5])<<8 | int(out[6])<<16
+	c.Assert(out[3], Equals, uint8(0))
+	c.Assert(len(out), Equals, 7+length)
+	c.Assert(uncompressedLength, Equals, 4+len(small)+4+len(large))
+	zr, err := zlib.NewReader(bytes.NewReader(out[7:]))
+	c.Assert(err, IsNil)
+	uncompressed, err := ioutil.ReadAll(zr)
+	c.Assert(err, IsNil)
+	c.Assert(uncompressed[:4], DeepEquals, []byte{byte(len(small)), 0, 0, 0})
+	c.Assert(uncompressed[4:4+len(small)], DeepEquals, small)
+
+	// Read the packets back.
+	rp := &packetIO{bufReadConn: &bufferedReadConn{rb: bufio.NewReader(bytes.NewReader(out))}}
+	rp.setCompressed()
+	data, err := rp.readPacket()
+	c.Assert(err, IsNil)
+	c.Assert(data, DeepEquals, small)
+	data, err = rp.readPacket()
+	c.Assert(err, IsNil)
+	c.Assert(data, DeepEquals, large)
+	c.Assert(rp.compressedSequence, Equals, uint8(1))
+
+	// Short payloads are sent without compression.
+	outBuffer.Reset()
+	wp.resetSequence()
+	c.Assert(wp.writePacket(append(make([]byte, 4), small...)), IsNil)
+	c.Assert(wp.flush(), IsNil)
+	out = outBuffer.Bytes()
+	c.Assert(out[:7], DeepEquals, []byte{byte(4 + len(small)), 0, 0, 0, 0, 0, 0})
+	c.Assert(out[7:11], DeepEquals, []byte{byte(len(small)), 0, 0, 0})
+	c.Assert(out[11:], DeepEquals, small)
+
+	// The compressed sequence is checked.
+	rp = &packetIO{bufReadConn: &bufferedReadConn{rb: bufio.NewReader(bytes.NewReader(out))}}
+	rp.setCompressed()
+	rp.compressedSequence = 1
+	_, err = rp.readPacket()
+	c.Assert(err, NotNil)
+}
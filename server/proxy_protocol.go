@@ -0,0 +1,248 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/config"
+)
+
+// The PROXY protocol lets a proxy like HAProxy pass the address of the real client to the server,
+// by sending a header before the data of the client.
+// See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt
+var (
+	proxyProtocolV1Prefix    = []byte("PROXY ")
+	proxyProtocolV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+const (
+	// proxyProtocolV1MaxLength is the max length of the v1 header, including the CRLF.
+	proxyProtocolV1MaxLength = 107
+
+	proxyProtocolV2Version     = 0x20
+	proxyProtocolV2CmdLocal    = 0x00
+	proxyProtocolV2CmdProxy    = 0x01
+	proxyProtocolV2FamilyTCP4  = 0x11
+	proxyProtocolV2FamilyTCP6  = 0x21
+	proxyProtocolV2HeaderLen   = 16
+	proxyProtocolV2TCP4AddrLen = 12
+	proxyProtocolV2TCP6AddrLen = 36
+)
+
+var errInvalidProxyProtocolHeader = errors.New("invalid PROXY protocol header")
+
+// proxyProtocolChecker parses the PROXY protocol header of the connections from the trusted proxies.
+type proxyProtocolChecker struct {
+	allowAll      bool
+	networks      []*net.IPNet
+	headerTimeout time.Duration
+}
+
+// newProxyProtocolChecker creates a proxyProtocolChecker from the config,
+// it returns nil if the PROXY protocol is disabled.
+func newProxyProtocolChecker(cfg config.ProxyProtocol) (*proxyProtocolChecker, error) {
+	if strings.TrimSpace(cfg.Networks) == "" {
+		return nil, nil
+	}
+	p := &proxyProtocolChecker{headerTimeout: time.Duration(cfg.HeaderTimeout) * time.Second}
+	for _, network := range strings.Split(cfg.Networks, ",") {
+		network = strings.TrimSpace(network)
+		if network == "*" {
+			p.allowAll = true
+			continue
+		}
+		if !strings.Contains(network, "/") {
+			ip := net.ParseIP(network)
+			if ip == nil {
+				return nil, errors.Errorf("invalid PROXY protocol network %s", network)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			p.networks = append(p.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, errors.Errorf("invalid PROXY protocol network %s", network)
+		}
+		p.networks = append(p.networks, ipNet)
+	}
+	return p, nil
+}
+
+// isTrusted checks whether the address is in the trusted proxy networks.
+func (p *proxyProtocolChecker) isTrusted(addr net.Addr) bool {
+	if p.allowAll {
+		return true
+	}
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, network := range p.networks {
+		if network.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// wrap reads the PROXY protocol header if the connection comes from a trusted proxy, the returned
+// connection reports the address in the header as its remote address.
+// The connections from other addresses are returned as they are, so their headers are never trusted.
+func (p *proxyProtocolChecker) wrap(conn net.Conn) (net.Conn, error) {
+	if !p.isTrusted(conn.RemoteAddr()) {
+		return conn, nil
+	}
+	if p.headerTimeout > 0 {
+		if err := conn.SetReadDeadline(time.Now().Add(p.headerTimeout)); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	pc := &proxyProtocolConn{Conn: conn, r: bufio.NewReader(conn)}
+	addr, err := readProxyProtocolHeader(pc.r)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	pc.remoteAddr = addr
+	if p.headerTimeout > 0 {
+		if err = conn.SetReadDeadline(time.Time{}); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return pc, nil
+}
+
+// proxyProtocolConn is a connection whose PROXY protocol header has been read.
+type proxyProtocolConn struct {
+	net.Conn
+	// r holds the data read after the header.
+	r *bufio.Reader
+	// remoteAddr is the address of the real client, it's nil if the header doesn't carry an address.
+	remoteAddr net.Addr
+}
+
+func (c *proxyProtocolConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// RemoteAddr returns the address of the real client.
+func (c *proxyProtocolConn) RemoteAddr() net.Addr {
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// readProxyProtocolHeader reads the v1 or v2 header and returns the source address in it,
+// the address is nil for the v1 UNKNOWN and the v2 LOCAL headers.
+func readProxyProtocolHeader(r *bufio.Reader) (net.Addr, error) {
+	prefix, err := r.Peek(len(proxyProtocolV2Signature))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if bytes.Equal(prefix, proxyProtocolV2Signature) {
+		return readProxyProtocolV2Header(r)
+	}
+	if bytes.HasPrefix(prefix, proxyProtocolV1Prefix) {
+		return readProxyProtocolV1Header(r)
+	}
+	return nil, errors.Trace(errInvalidProxyProtocolHeader)
+}
+
+// readProxyProtocolV1Header reads the human-readable header, e.g. "PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n".
+func readProxyProtocolV1Header(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < proxyProtocolV1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.Trace(errInvalidProxyProtocolHeader)
+	}
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errors.Trace(errInvalidProxyProtocolHeader)
+	}
+	ip := net.ParseIP(fields[2])
+	if ip == nil || (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return nil, errors.Trace(errInvalidProxyProtocolHeader)
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, errors.Trace(errInvalidProxyProtocolHeader)
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyProtocolV2Header reads the binary header, which is the signature, the version and command,
+// the address family, the length of the addresses and the addresses.
+func readProxyProtocolV2Header(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, proxyProtocolV2HeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errors.Trace(err)
+	}
+	verCmd, family := header[12], header[13]
+	length := int(binary.BigEndian.Uint16(header[14:]))
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if verCmd&0xf0 != proxyProtocolV2Version {
+		return nil, errors.Trace(errInvalidProxyProtocolHeader)
+	}
+	switch verCmd & 0x0f {
+	case proxyProtocolV2CmdLocal:
+		// The connection is established by the proxy itself, e.g. for health checks.
+		return nil, nil
+	case proxyProtocolV2CmdProxy:
+	default:
+		return nil, errors.Trace(errInvalidProxyProtocolHeader)
+	}
+	switch family {
+	case proxyProtocolV2FamilyTCP4:
+		if length < proxyProtocolV2TCP4AddrLen {
+			return nil, errors.Trace(errInvalidProxyProtocolHeader)
+		}
+		return &net.TCPAddr{IP: net.IP(data[:4]), Port: int(binary.BigEndian.Uint16(data[8:]))}, nil
+	case proxyProtocolV2FamilyTCP6:
+		if length < proxyProtocolV2TCP6AddrLen {
+			return nil, errors.Trace(errInvalidProxyProtocolHeader)
+		}
+		return &net.TCPAddr{IP: net.IP(data[:16]), Port: int(binary.BigEndian.Uint16(data[32:]))}, nil
+	default:
+		// Other families like unix sockets don't have a meaningful client address.
+		return nil, nil
+	}
+}
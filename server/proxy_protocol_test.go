@@ -0,0 +1,148 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
+)
+
+type ProxyProtocolTestSuite struct{}
+
+var _ = Suite(ProxyProtocolTestSuite{})
+
+// proxyMockConn is a net.Conn which reads the given data and comes from the given address.
+type proxyMockConn struct {
+	net.Conn
+	r    *bytes.Reader
+	addr net.Addr
+}
+
+func (c *proxyMockConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+func (c *proxyMockConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+func (c *proxyMockConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func newProxyMockConn(data []byte, ip string) *proxyMockConn {
+	return &proxyMockConn{r: bytes.NewReader(data), addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 3306}}
+}
+
+func (ts ProxyProtocolTestSuite) TestNetworks(c *C) {
+	c.Parallel()
+	p, err := newProxyProtocolChecker(config.ProxyProtocol{})
+	c.Assert(err, IsNil)
+	c.Assert(p, IsNil)
+
+	p, err = newProxyProtocolChecker(config.ProxyProtocol{Networks: "192.168.1.0/24, 10.0.0.1,::1"})
+	c.Assert(err, IsNil)
+	for _, t := range []struct {
+		ip      string
+		trusted bool
+	}{
+		{"192.168.1.1", true},
+		{"192.168.2.1", false},
+		{"10.0.0.1", true},
+		{"10.0.0.2", false},
+		{"::1", true},
+		{"::2", false},
+	} {
+		c.Assert(p.isTrusted(&net.TCPAddr{IP: net.ParseIP(t.ip)}), Equals, t.trusted, Commentf("ip: %s", t.ip))
+	}
+	c.Assert(p.isTrusted(&net.UnixAddr{Name: "/tmp/tidb.sock", Net: "unix"}), IsFalse)
+
+	p, err = newProxyProtocolChecker(config.ProxyProtocol{Networks: "*"})
+	c.Assert(err, IsNil)
+	c.Assert(p.isTrusted(&net.TCPAddr{IP: net.ParseIP("8.8.8.8")}), IsTrue)
+
+	_, err = newProxyProtocolChecker(config.ProxyProtocol{Networks: "192.168.1.0/33"})
+	c.Assert(err, NotNil)
+	_, err = newProxyProtocolChecker(config.ProxyProtocol{Networks: "localhost"})
+	c.Assert(err, NotNil)
+}
+
+func (ts ProxyProtocolTestSuite) TestReadHeader(c *C) {
+	c.Parallel()
+	v2Header := func(verCmd, family byte, addrs []byte) []byte {
+		data := append([]byte{}, proxyProtocolV2Signature...)
+		data = append(data, verCmd, family, byte(len(addrs)>>8), byte(len(addrs)))
+		return append(data, addrs...)
+	}
+	v2TCP4 := []byte{192, 168, 1, 1, 192, 168, 1, 2, 0xdc, 0x04, 0x0c, 0xea}
+	v2TCP6 := append(append(net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2")...), 0xdc, 0x04, 0x0c, 0xea)
+	tests := []struct {
+		header []byte
+		addr   string
+	}{
+		{[]byte("PROXY TCP4 192.168.1.1 192.168.1.2 56324 3306\r\n"), "192.168.1.1:56324"},
+		{[]byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 3306\r\n"), "[2001:db8::1]:56324"},
+		{[]byte("PROXY UNKNOWN\r\n"), "10.0.0.1:3306"},
+		{[]byte("PROXY UNKNOWN 192.168.1.1 192.168.1.2 56324 3306\r\n"), "10.0.0.1:3306"},
+		{v2Header(0x21, proxyProtocolV2FamilyTCP4, v2TCP4), "192.168.1.1:56324"},
+		{v2Header(0x21, proxyProtocolV2FamilyTCP6, v2TCP6), "[2001:db8::1]:56324"},
+		// The TLVs after the addresses are skipped.
+		{v2Header(0x21, proxyProtocolV2FamilyTCP4, append(v2TCP4, 0x04, 0x00, 0x01, 0x00)), "192.168.1.1:56324"},
+		{v2Header(0x20, proxyProtocolV2FamilyTCP4, v2TCP4), "10.0.0.1:3306"},
+		{v2Header(0x21, 0x31, make([]byte, 216)), "10.0.0.1:3306"},
+	}
+	p, err := newProxyProtocolChecker(config.ProxyProtocol{Networks: "10.0.0.0/8", HeaderTimeout: 5})
+	c.Assert(err, IsNil)
+	for _, t := range tests {
+		conn, err := p.wrap(newProxyMockConn(append(t.header, "data"...), "10.0.0.1"))
+		c.Assert(err, IsNil, Commentf("header: %q", t.header))
+		c.Assert(conn.RemoteAddr().String(), Equals, t.addr, Commentf("header: %q", t.header))
+		// The data after the header is kept.
+		data, err := ioutil.ReadAll(conn)
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, "data")
+	}
+
+	for _, header := range [][]byte{
+		[]byte("PROXY TCP4 192.168.1.1 192.168.1.2 56324\r\n"),
+		[]byte("PROXY TCP4 2001:db8::1 2001:db8::2 56324 3306\r\n"),
+		[]byte("PROXY TCP4 192.168.1.1 192.168.1.2 65536 3306\r\n"),
+		[]byte("PROXY TCP4 192.168.1.1 192.168.1.2 56324 3306\n"),
+		[]byte("PROXY UDP4 192.168.1.1 192.168.1.2 56324 3306\r\n"),
+		append([]byte("PROXY TCP4 "), bytes.Repeat([]byte{'1'}, proxyProtocolV1MaxLength)...),
+		v2Header(0x11, proxyProtocolV2FamilyTCP4, v2TCP4),
+		v2Header(0x22, proxyProtocolV2FamilyTCP4, v2TCP4),
+		v2Header(0x21, proxyProtocolV2FamilyTCP4, v2TCP4[:8]),
+		v2Header(0x21, proxyProtocolV2FamilyTCP4, v2TCP4)[:20],
+		// The client doesn't send the header.
+		{0x85, 0xa6, 0xff, 0x01, 0x00, 0x00, 0x00, 0x01, 0x21, 0x00, 0x00, 0x00, 0x00},
+	} {
+		_, err = p.wrap(newProxyMockConn(header, "10.0.0.1"))
+		c.Assert(err, NotNil, Commentf("header: %q", header))
+	}
+
+	// The header from an untrusted address is not parsed.
+	header := []byte("PROXY TCP4 192.168.1.1 192.168.1.2 56324 3306\r\n")
+	conn, err := p.wrap(newProxyMockConn(header, "192.168.2.1"))
+	c.Assert(err, IsNil)
+	c.Assert(conn.RemoteAddr().String(), Equals, "192.168.2.1:3306")
+	data, err := ioutil.ReadAll(conn)
+	c.Assert(err, IsNil)
+	c.Assert(data, DeepEquals, header)
+}
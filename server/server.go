@@ -29,6 +29,7 @@
 package server
 
 import (
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -83,6 +84,14 @@ type Server struct {
 	clients           map[uint32]*clientConn
 	capability        uint32
 
+	// The RSA key used by the sha2 authentication plugins, see rsaKey.
+	rsaKeyOnce    sync.Once
+	rsaPrivateKey *rsa.PrivateKey
+	rsaKeyErr     error
+
+	// proxyProtocol parses the PROXY protocol header, it's nil if the PROXY protocol is disabled.
+	proxyProtocol *proxyProtocolChecker
+
 	// When a critical error occurred, we don't want to exit the process, because there may be
 	// a supervisor automatically restart it, then new client connection will be created, but we can't server it.
 	// So we just stop the listener and store to force clients to chose other TiDB servers.
@@ -117,7 +126,11 @@ func (s *Server) newConn(conn net.Conn) *clientConn {
 	}
 	log.Infof("[%d] new connection %s", cc.connectionID, conn.RemoteAddr().String())
 	if s.cfg.Performance.TCPKeepAlive {
-		if tcpConn, ok := conn.(*net.TCPConn); ok {
+		rawConn := conn
+		if pc, ok := conn.(*proxyProtocolConn); ok {
+			rawConn = pc.Conn
+		}
+		if tcpConn, ok := rawConn.(*net.TCPConn); ok {
 			if err := tcpConn.SetKeepAlive(true); err != nil {
 				log.Error("failed to set tcp keep alive option:", err)
 			}
@@ -150,8 +163,14 @@ func NewServer(cfg *config.Config, driver IDriver) (*Server, error) {
 	if s.tlsConfig != nil {
 		s.capability |= mysql.ClientSSL
 	}
+	if s.cfg.Performance.EnableCompression {
+		s.capability |= mysql.ClientCompress
+	}
 
 	var err error
+	if s.proxyProtocol, err = newProxyProtocolChecker(cfg.ProxyProtocol); err != nil {
+		return nil, errors.Trace(err)
+	}
 	if cfg.Socket != "" {
 		if s.listener, err = net.Listen("unix", cfg.Socket); err == nil {
 			log.Infof("Server is running MySQL Protocol through Socket [%s]", cfg.Socket)
@@ -275,6 +294,16 @@ func (s *Server) Close() {
 
 // onConn runs in its own goroutine, handles queries from this connection.
 func (s *Server) onConn(c net.Conn) {
+	if s.proxyProtocol != nil {
+		// Read the PROXY protocol header here instead of the accept loop, so a slow client can't block others.
+		pc, err := s.proxyProtocol.wrap(c)
+		if err != nil {
+			log.Infof("read PROXY protocol header from %s error %s", c.RemoteAddr(), errors.ErrorStack(err))
+			terror.Log(errors.Trace(c.Close()))
+			return
+		}
+		c = pc
+	}
 	conn := s.newConn(c)
 	defer func() {
 		log.Infof("[%d] close connection", conn.connectionID)
@@ -22,6 +22,7 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -286,6 +287,47 @@ func runTestClientWithCollation(t *C) {
 	})
 }
 
+func runTestCharsetConversion(t *C) {
+	runTestsOnNewDB(t, func(config *mysql.Config) {
+		// The long parameters are sent with COM_STMT_SEND_LONG_DATA.
+		config.MaxAllowedPacket = 1024
+	}, "CharsetConversion", func(dbt *DBTest) {
+		// The session variables are set on the connection.
+		dbt.db.SetMaxOpenConns(1)
+		dbt.mustExec("create table test (a varchar(10))")
+		dbt.mustExec("set names gbk")
+		// "中文" in gbk.
+		gbkStr := "\xd6\xd0\xce\xc4"
+		dbt.mustExec("insert test values ('" + gbkStr + "')")
+		var a, hex string
+		err := dbt.db.QueryRow("select a, hex(a) from test").Scan(&a, &hex)
+		t.Assert(err, IsNil)
+		t.Assert(a, Equals, gbkStr)
+		// The string is stored in utf8.
+		t.Assert(hex, Equals, "E4B8ADE69687")
+
+		// The string parameters of the prepared statements are converted too.
+		dbt.mustExec("delete from test")
+		dbt.mustExec("insert test values (?)", gbkStr)
+		err = dbt.db.QueryRow("select hex(a) from test where a = ?", gbkStr).Scan(&hex)
+		t.Assert(err, IsNil)
+		t.Assert(hex, Equals, "E4B8ADE69687")
+		dbt.mustExec("create table long_test (a text)")
+		longStr := strings.Repeat(gbkStr, 500)
+		dbt.mustExec("insert long_test values (?)", longStr)
+		var length int
+		err = dbt.db.QueryRow("select char_length(a), a = ? from long_test", longStr).Scan(&length, &a)
+		t.Assert(err, IsNil)
+		t.Assert(length, Equals, 1000)
+		t.Assert(a, Equals, "1")
+
+		dbt.mustExec("set names utf8")
+		err = dbt.db.QueryRow("select a from test").Scan(&a)
+		t.Assert(err, IsNil)
+		t.Assert(a, Equals, "中文")
+	})
+}
+
 func runTestPreparedString(t *C) {
 	runTestsOnNewDB(t, nil, "PreparedString", func(dbt *DBTest) {
 		dbt.mustExec("create table test (a char(10), b char(10))")
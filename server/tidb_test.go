@@ -15,14 +15,18 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/binary"
 	"encoding/pem"
 	"io/ioutil"
 	"math/big"
+	"net"
 	"os"
 	"time"
 
@@ -32,6 +36,7 @@ import (
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/config"
 	tmysql "github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/arena"
 )
 
 type TidbTestSuite struct {
@@ -167,6 +172,56 @@ func (ts *TidbTestSuite) TestSocket(c *C) {
 	}, "SocketRegression")
 }
 
+func (ts *TidbTestSuite) TestProxyProtocol(c *C) {
+	cfg := &config.Config{
+		Port: 4005,
+		Status: config.Status{
+			StatusPort: 10091,
+		},
+		ProxyProtocol: config.ProxyProtocol{
+			Networks:      "127.0.0.1",
+			HeaderTimeout: 1,
+		},
+	}
+	server, err := NewServer(cfg, ts.tidbdrv)
+	c.Assert(err, IsNil)
+	go server.Run()
+	time.Sleep(time.Millisecond * 100)
+	defer server.Close()
+
+	// The proxy connects the server from 127.0.0.1, the real client is 192.168.1.1.
+	mysql.RegisterDial("proxy", func(addr string) (net.Conn, error) {
+		conn, err1 := net.Dial("tcp", addr)
+		if err1 != nil {
+			return nil, errors.Trace(err1)
+		}
+		if _, err1 = conn.Write([]byte("PROXY TCP4 192.168.1.1 127.0.0.1 56324 4005\r\n")); err1 != nil {
+			conn.Close()
+			return nil, errors.Trace(err1)
+		}
+		return conn, nil
+	})
+	runTests(c, func(config *mysql.Config) {
+		config.Net = "proxy"
+		config.Addr = "127.0.0.1:4005"
+	}, func(dbt *DBTest) {
+		rows := dbt.mustQuery("select user()")
+		c.Assert(rows.Next(), IsTrue)
+		var user string
+		c.Assert(rows.Scan(&user), IsNil)
+		c.Assert(user, Equals, "root@192.168.1.1")
+		c.Assert(rows.Close(), IsNil)
+	})
+
+	// The connection from a trusted proxy address is closed if the header isn't sent in time.
+	conn, err := net.Dial("tcp", "127.0.0.1:4005")
+	c.Assert(err, IsNil)
+	defer conn.Close()
+	data, err := ioutil.ReadAll(conn)
+	c.Assert(err, IsNil)
+	c.Assert(data, HasLen, 0)
+}
+
 // generateCert generates a private key and a certificate in PEM format based on parameters.
 // If parentCert and parentCertKey is specified, the new certificate will be signed by the parentCert.
 // Otherwise, the new certificate will be self-signed and is a CA.
@@ -373,6 +428,11 @@ func (ts *TidbTestSuite) TestClientWithCollation(c *C) {
 	runTestClientWithCollation(c)
 }
 
+func (ts *TidbTestSuite) TestCharsetConversion(c *C) {
+	c.Parallel()
+	runTestCharsetConversion(c)
+}
+
 func (ts *TidbTestSuite) TestShowCreateTableFlen(c *C) {
 	// issue #4540
 	ctx, err := ts.tidbdrv.OpenCtx(uint64(0), 0, uint8(tmysql.DefaultCollationID), "test", nil)
@@ -418,3 +478,89 @@ func (ts *TidbTestSuite) TestShowCreateTableFlen(c *C) {
 	c.Assert(int(cols[0].ColumnLength), Equals, 5*tmysql.MaxBytesOfCharacter)
 	c.Assert(int(cols[1].ColumnLength), Equals, len(row[1].GetString())*tmysql.MaxBytesOfCharacter)
 }
+
+func (ts *TidbTestSuite) TestStmtFetch(c *C) {
+	ctx, err := ts.tidbdrv.OpenCtx(uint64(0), 0, uint8(tmysql.DefaultCollationID), "test", nil)
+	c.Assert(err, IsNil)
+	defer ctx.Close()
+	_, err = ctx.Execute("use test;")
+	c.Assert(err, IsNil)
+	_, err = ctx.Execute("create table t_fetch (a int)")
+	c.Assert(err, IsNil)
+	_, err = ctx.Execute("insert into t_fetch values (1), (2), (3), (4), (5)")
+	c.Assert(err, IsNil)
+
+	var outBuffer bytes.Buffer
+	cc := &clientConn{
+		capability: tmysql.ClientProtocol41,
+		alloc:      arena.NewAllocator(1024),
+		ctx:        ctx,
+		pkt: &packetIO{
+			bufWriter: bufio.NewWriter(&outBuffer),
+		},
+	}
+	// readPackets returns the payloads written since the last call.
+	readPackets := func() [][]byte {
+		var packets [][]byte
+		data := outBuffer.Bytes()
+		for len(data) > 0 {
+			length := int(data[0]) | int(data[1])<<8 | int(data[2])<<16
+			packets = append(packets, data[4:4+length])
+			data = data[4+length:]
+		}
+		outBuffer.Reset()
+		return packets
+	}
+	eofStatus := func(packet []byte) uint16 {
+		c.Assert(packet[0], Equals, tmysql.EOFHeader)
+		return binary.LittleEndian.Uint16(packet[3:5])
+	}
+
+	stmt, _, _, err := ctx.Prepare("select a from t_fetch order by a")
+	c.Assert(err, IsNil)
+	stmtID := make([]byte, 4)
+	binary.LittleEndian.PutUint32(stmtID, uint32(stmt.ID()))
+	fetch := func(fetchSize uint32) error {
+		data := append([]byte{}, stmtID...)
+		data = append(data, dumpUint32(fetchSize)...)
+		return cc.handleStmtFetch(data)
+	}
+
+	// Fetching before the cursor is opened fails.
+	err = fetch(2)
+	c.Assert(errors.Cause(err).(*tmysql.SQLError).Code, Equals, uint16(tmysql.ErrStmtHasNoOpenCursor))
+
+	// Execute with CURSOR_TYPE_READ_ONLY only sends the column definitions.
+	execute := append(append([]byte{}, stmtID...), tmysql.CursorTypeReadOnly, 1, 0, 0, 0)
+	c.Assert(cc.handleStmtExecute(execute), IsNil)
+	packets := readPackets()
+	c.Assert(packets, HasLen, 3)
+	c.Assert(eofStatus(packets[2])&tmysql.ServerStatusCursorExists, Greater, uint16(0))
+
+	c.Assert(fetch(2), IsNil)
+	packets = readPackets()
+	c.Assert(packets, HasLen, 3)
+	status := eofStatus(packets[2])
+	c.Assert(status&tmysql.ServerStatusCursorExists, Greater, uint16(0))
+	c.Assert(status&tmysql.ServerStatusLastRowSend, Equals, uint16(0))
+
+	// The last batch is marked by SERVER_STATUS_LAST_ROW_SENT and closes the cursor.
+	c.Assert(fetch(3), IsNil)
+	packets = readPackets()
+	c.Assert(packets, HasLen, 4)
+	c.Assert(eofStatus(packets[3])&tmysql.ServerStatusLastRowSend, Greater, uint16(0))
+	c.Assert(stmt.GetResultSet(), IsNil)
+	err = fetch(1)
+	c.Assert(errors.Cause(err).(*tmysql.SQLError).Code, Equals, uint16(tmysql.ErrStmtHasNoOpenCursor))
+
+	// Reset closes the cursor.
+	c.Assert(cc.handleStmtExecute(execute), IsNil)
+	readPackets()
+	c.Assert(stmt.GetResultSet(), NotNil)
+	stmt.Reset()
+	c.Assert(stmt.GetResultSet(), IsNil)
+
+	// Other cursor types are not supported.
+	execute[4] = tmysql.CursorTypeScrollable
+	c.Assert(cc.handleStmtExecute(execute), NotNil)
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/perfschema"
 	"github.com/pingcap/tidb/plan/cache"
 	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/privilege/privileges"
@@ -79,6 +80,12 @@ type Session interface {
 	SetSessionManager(util.SessionManager)
 	Close()
 	Auth(user *auth.UserIdentity, auth []byte, salt []byte) bool
+	// AuthWithoutVerification binds an already authenticated user to the session.
+	AuthWithoutVerification(user *auth.UserIdentity) bool
+	// FastAuth verifies the scramble of caching_sha2_password against the cached password digest.
+	FastAuth(user *auth.UserIdentity, scramble []byte, salt []byte) bool
+	// AuthPlugin returns the authentication plugin of the user, it's empty if the user doesn't exist.
+	AuthPlugin(user *auth.UserIdentity) string
 	// Cancel the execution of current transaction.
 	Cancel()
 	ShowProcess() util.ProcessInfo
@@ -136,13 +143,19 @@ type session struct {
 	sessionManager util.SessionManager
 
 	statsCollector *statistics.SessionStatsCollector
+
+	// txnEvent is the performance_schema event of the current transaction.
+	txnEvent *perfschema.TransactionEvent
 }
 
 // Cancel cancels the execution of current transaction.
 func (s *session) Cancel() {
 	// TODO: How to wait for the resource to release and make sure
 	// it's not leak?
-	s.cancelFunc()
+	// The cancel function is nil if the session hasn't started a transaction yet.
+	if s.cancelFunc != nil {
+		s.cancelFunc()
+	}
 }
 
 // GoCtx returns the standard context.Context that bind with current transaction.
@@ -322,7 +335,10 @@ func (s *session) doCommitWithRetry() error {
 }
 
 func (s *session) CommitTxn() error {
+	// The transaction may have been rolled back by the ROLLBACK statement.
+	valid := s.txn != nil && s.txn.Valid()
 	err := s.doCommitWithRetry()
+	s.endTxnEvent(valid && err == nil)
 	label := "OK"
 	if err != nil {
 		label = "Error"
@@ -340,9 +356,38 @@ func (s *session) RollbackTxn() error {
 	s.txn = nil
 	s.txnFuture = nil
 	s.sessionVars.SetStatusFlag(mysql.ServerStatusInTrans, false)
+	s.endTxnEvent(false)
 	return errors.Trace(err)
 }
 
+// startTxnEvent records the start of the transaction into the performance_schema transaction event tables.
+func (s *session) startTxnEvent() {
+	// The retried transaction is still the same transaction.
+	if s.sessionVars.InRestrictedSQL || s.txnEvent != nil {
+		return
+	}
+	isolation := s.sessionVars.Systems[variable.TxnIsolation]
+	if isolation == "" {
+		isolation = variable.GetSysVar(variable.TxnIsolation).Value
+	}
+	s.txnEvent = &perfschema.TransactionEvent{
+		ThreadID:       s.sessionVars.ConnectionID,
+		TrxID:          s.txn.StartTS(),
+		IsolationLevel: strings.Replace(isolation, "-", " ", -1),
+		Autocommit:     s.sessionVars.IsAutocommit(),
+	}
+	perfschema.StartTransaction(s.txnEvent)
+}
+
+// endTxnEvent records the end of the transaction.
+func (s *session) endTxnEvent(committed bool) {
+	if s.txnEvent == nil {
+		return
+	}
+	perfschema.EndTransaction(s.txnEvent, committed)
+	s.txnEvent = nil
+}
+
 func (s *session) GetClient() kv.Client {
 	return s.store.GetClient()
 }
@@ -694,6 +739,7 @@ func (s *session) Execute(sql string) (recordSets []ast.RecordSet, err error) {
 			Plan:       cacheValue.(*cache.SQLCacheValue).Plan,
 			Expensive:  cacheValue.(*cache.SQLCacheValue).Expensive,
 			Text:       stmtNode.Text(),
+			StmtNode:   stmtNode,
 		}
 
 		s.PrepareTxnCtx()
@@ -848,6 +894,7 @@ func (s *session) NewTxn() error {
 	}
 	s.txn = txn
 	s.sessionVars.TxnCtx.StartTS = txn.StartTS()
+	s.startTxnEvent()
 	return nil
 }
 
@@ -878,6 +925,7 @@ func (s *session) Close() {
 	if err := s.RollbackTxn(); err != nil {
 		log.Error("session Close error:", errors.ErrorStack(err))
 	}
+	perfschema.CloseThread(s.sessionVars.ConnectionID)
 	return
 }
 
@@ -903,16 +951,29 @@ func (s *session) getPassword(name, host string) (string, error) {
 
 func (s *session) Auth(user *auth.UserIdentity, authentication []byte, salt []byte) bool {
 	pm := privilege.GetPrivilegeManager(s)
+	return s.verifyConnection(user, func(host string) bool {
+		return pm.ConnectionVerification(user.Username, host, authentication, salt)
+	})
+}
+
+func (s *session) FastAuth(user *auth.UserIdentity, scramble []byte, salt []byte) bool {
+	pm := privilege.GetPrivilegeManager(s)
+	return s.verifyConnection(user, func(host string) bool {
+		return pm.FastAuthVerification(user.Username, host, scramble, salt)
+	})
+}
 
+// verifyConnection verifies the user with the IP first, then the host names of the IP.
+func (s *session) verifyConnection(user *auth.UserIdentity, verify func(host string) bool) bool {
 	// Check IP.
-	if pm.ConnectionVerification(user.Username, user.Hostname, authentication, salt) {
+	if verify(user.Hostname) {
 		s.sessionVars.User = user
 		return true
 	}
 
 	// Check Hostname.
 	for _, addr := range getHostByIP(user.Hostname) {
-		if pm.ConnectionVerification(user.Username, addr, authentication, salt) {
+		if verify(addr) {
 			s.sessionVars.User = &auth.UserIdentity{
 				Username: user.Username,
 				Hostname: addr,
@@ -925,6 +986,29 @@ func (s *session) Auth(user *auth.UserIdentity, authentication []byte, salt []by
 	return false
 }
 
+func (s *session) AuthPlugin(user *auth.UserIdentity) string {
+	pm := privilege.GetPrivilegeManager(s)
+	if plugin, ok := pm.GetAuthPlugin(user.Username, user.Hostname); ok {
+		return plugin
+	}
+	for _, addr := range getHostByIP(user.Hostname) {
+		if plugin, ok := pm.GetAuthPlugin(user.Username, addr); ok {
+			return plugin
+		}
+	}
+	return ""
+}
+
+func (s *session) AuthWithoutVerification(user *auth.UserIdentity) bool {
+	pm := privilege.GetPrivilegeManager(s)
+	if pm.AuthWithoutVerification(user.Username, user.Hostname) {
+		s.sessionVars.User = user
+		return true
+	}
+	log.Errorf("User connection verification failed %s", user)
+	return false
+}
+
 func getHostByIP(ip string) []string {
 	if ip == "127.0.0.1" {
 		return []string{"localhost"}
@@ -1071,7 +1155,7 @@ func createSessionWithDomain(store kv.Storage, dom *domain.Domain) (*session, er
 
 const (
 	notBootstrapped         = 0
-	currentBootstrapVersion = 15
+	currentBootstrapVersion = 18
 )
 
 func getStoreBootstrapVersion(store kv.Storage) int64 {
@@ -1120,6 +1204,8 @@ const loadCommonGlobalVarsSQL = "select HIGH_PRIORITY * from mysql.global_variab
 	variable.AutocommitVar + quoteCommaQuote +
 	variable.SQLModeVar + quoteCommaQuote +
 	variable.MaxAllowedPacket + quoteCommaQuote +
+	variable.CTEMaxRecursionDepth + quoteCommaQuote +
+	variable.ForeignKeyChecks + quoteCommaQuote +
 	/* TiDB specific global variables: */
 	variable.TiDBSkipUTF8Check + quoteCommaQuote +
 	variable.TiDBIndexJoinBatchSize + quoteCommaQuote +
@@ -1127,6 +1213,11 @@ const loadCommonGlobalVarsSQL = "select HIGH_PRIORITY * from mysql.global_variab
 	variable.TiDBIndexLookupConcurrency + quoteCommaQuote +
 	variable.TiDBIndexSerialScanConcurrency + quoteCommaQuote +
 	variable.TiDBMaxRowCountForINLJ + quoteCommaQuote +
+	variable.TiDBMemQuotaSort + quoteCommaQuote +
+	variable.TiDBMemQuotaHashJoin + quoteCommaQuote +
+	variable.TiDBMemQuotaQuery + quoteCommaQuote +
+	variable.TiDBHashAggPartialConcurrency + quoteCommaQuote +
+	variable.TiDBHashAggFinalConcurrency + quoteCommaQuote +
 	variable.TiDBDistSQLScanConcurrency + "')"
 
 // loadCommonGlobalVariablesIfNeeded loads and applies commonly used global variables for the session.
@@ -1206,7 +1297,9 @@ func (s *session) PrepareTxnCtx() {
 
 // RefreshTxnCtx implements context.RefreshTxnCtx interface.
 func (s *session) RefreshTxnCtx() error {
-	if err := s.doCommit(); err != nil {
+	err := s.doCommit()
+	s.endTxnEvent(err == nil)
+	if err != nil {
 		return errors.Trace(err)
 	}
 
@@ -1236,6 +1329,7 @@ func (s *session) ActivePendingTxn() error {
 	if s.sessionVars.Systems[variable.TxnIsolation] == ast.ReadCommitted {
 		txn.SetOption(kv.IsolationLevel, kv.RC)
 	}
+	s.startTxnEvent()
 	return nil
 }
 
@@ -1258,6 +1352,7 @@ func (s *session) InitTxnWithStartTS(startTS uint64) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	s.startTxnEvent()
 	return nil
 }
 
@@ -1279,7 +1374,8 @@ func (s *session) ShowProcess() util.ProcessInfo {
 func logCrucialStmt(node ast.StmtNode, user *auth.UserIdentity) {
 	switch stmt := node.(type) {
 	case *ast.CreateUserStmt, *ast.DropUserStmt, *ast.AlterUserStmt, *ast.SetPwdStmt, *ast.GrantStmt,
-		*ast.RevokeStmt, *ast.AlterTableStmt, *ast.CreateDatabaseStmt, *ast.CreateIndexStmt, *ast.CreateTableStmt,
+		*ast.RevokeStmt, *ast.GrantRoleStmt, *ast.RevokeRoleStmt, *ast.SetDefaultRoleStmt,
+		*ast.AlterTableStmt, *ast.CreateDatabaseStmt, *ast.CreateIndexStmt, *ast.CreateTableStmt,
 		*ast.DropDatabaseStmt, *ast.DropIndexStmt, *ast.DropTableStmt, *ast.RenameTableStmt, *ast.TruncateTableStmt:
 		if ss, ok := node.(ast.SensitiveStmtNode); ok {
 			log.Infof("[CRUCIAL OPERATION] %s (by %s).", ss.SecureText(), user)
@@ -22,6 +22,8 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/auth"
+	"github.com/pingcap/tidb/util/execdetails"
+	"github.com/pingcap/tidb/util/memory"
 )
 
 const (
@@ -185,6 +187,9 @@ type SessionVars struct {
 
 	SQLMode mysql.SQLMode
 
+	// ForeignKeyChecks indicates whether the foreign key constraints are checked when the rows are written.
+	ForeignKeyChecks bool
+
 	/* TiDB system variables */
 
 	// SkipConstraintCheck is true when importing data.
@@ -211,6 +216,12 @@ type SessionVars struct {
 	// IndexSerialScanConcurrency is the number of concurrent index serial scan worker.
 	IndexSerialScanConcurrency int
 
+	// HashAggPartialConcurrency is the number of concurrent partial workers of a hash aggregation.
+	HashAggPartialConcurrency int
+
+	// HashAggFinalConcurrency is the number of concurrent final workers of a hash aggregation.
+	HashAggFinalConcurrency int
+
 	// BatchInsert indicates if we should split insert data into multiple batches.
 	BatchInsert bool
 
@@ -219,6 +230,20 @@ type SessionVars struct {
 
 	// MaxRowCountForINLJ defines max row count that the outer table of index nested loop join could be without force hint.
 	MaxRowCountForINLJ int
+
+	// MemQuotaSort is the memory quota in bytes of a sort executor, the sort spills to disk when it's exceeded.
+	MemQuotaSort int64
+
+	// MemQuotaHashJoin is the memory quota in bytes of the hash table of a hash join, the hash join spills to disk
+	// when it's exceeded. The spilled partitions exceeding the quota are split recursively up to 3 levels, a partition
+	// of the last level is built in memory even if it exceeds the quota, e.g. when most rows have the same join key.
+	MemQuotaHashJoin int64
+
+	// MemQuotaQuery is the memory quota in bytes of a query.
+	MemQuotaQuery int64
+
+	// CTEMaxRecursionDepth is the max number of iterations of a recursive common table expression.
+	CTEMaxRecursionDepth int
 }
 
 // NewSessionVars creates a session vars object.
@@ -231,6 +256,7 @@ func NewSessionVars() *SessionVars {
 		TxnCtx:                     &TransactionContext{},
 		RetryInfo:                  &RetryInfo{},
 		StrictSQLMode:              true,
+		ForeignKeyChecks:           true,
 		Status:                     mysql.ServerStatusAutocommit,
 		StmtCtx:                    new(StatementContext),
 		AllowAggPushDown:           false,
@@ -240,7 +266,13 @@ func NewSessionVars() *SessionVars {
 		IndexLookupConcurrency:     DefIndexLookupConcurrency,
 		IndexSerialScanConcurrency: DefIndexSerialScanConcurrency,
 		DistSQLScanConcurrency:     DefDistSQLScanConcurrency,
+		HashAggPartialConcurrency:  DefHashAggPartialConcurrency,
+		HashAggFinalConcurrency:    DefHashAggFinalConcurrency,
 		MaxRowCountForINLJ:         DefMaxRowCountForINLJ,
+		MemQuotaSort:               DefMemQuotaSort,
+		MemQuotaHashJoin:           DefMemQuotaHashJoin,
+		MemQuotaQuery:              DefMemQuotaQuery,
+		CTEMaxRecursionDepth:       DefCTEMaxRecursionDepth,
 	}
 }
 
@@ -308,14 +340,19 @@ func (s *SessionVars) GetTimeZone() *time.Location {
 
 // special session variables.
 const (
-	SQLModeVar          = "sql_mode"
-	AutocommitVar       = "autocommit"
-	CharacterSetResults = "character_set_results"
-	MaxAllowedPacket    = "max_allowed_packet"
-	TimeZone            = "time_zone"
-	TxnIsolation        = "tx_isolation"
+	SQLModeVar           = "sql_mode"
+	AutocommitVar        = "autocommit"
+	CharacterSetResults  = "character_set_results"
+	MaxAllowedPacket     = "max_allowed_packet"
+	TimeZone             = "time_zone"
+	TxnIsolation         = "tx_isolation"
+	CTEMaxRecursionDepth = "cte_max_recursion_depth"
+	ForeignKeyChecks     = "foreign_key_checks"
 )
 
+// DefCTEMaxRecursionDepth is the default value of 'cte_max_recursion_depth' system variable.
+const DefCTEMaxRecursionDepth = 1000
+
 // TableDelta stands for the changed count for one table.
 type TableDelta struct {
 	Delta int64
@@ -342,6 +379,7 @@ type StatementContext struct {
 		sync.Mutex
 		affectedRows uint64
 		foundRows    uint64
+		examinedRows uint64
 		warnings     []error
 	}
 
@@ -349,6 +387,11 @@ type StatementContext struct {
 	TimeZone     *time.Location
 	Priority     mysql.PriorityEnum
 	NotFillCache bool
+
+	// RuntimeStatsColl collects the runtime statistics of the executors, it's only set by EXPLAIN ANALYZE.
+	RuntimeStatsColl *execdetails.RuntimeStatsColl
+	// MemTracker tracks the memory usage of the executors of the statement.
+	MemTracker *memory.Tracker
 }
 
 // AddAffectedRows adds affected rows.
@@ -381,6 +424,21 @@ func (sc *StatementContext) AddFoundRows(rows uint64) {
 	sc.mu.Unlock()
 }
 
+// ExaminedRows gets the rows read from storage.
+func (sc *StatementContext) ExaminedRows() uint64 {
+	sc.mu.Lock()
+	rows := sc.mu.examinedRows
+	sc.mu.Unlock()
+	return rows
+}
+
+// AddExaminedRows adds the rows read from storage.
+func (sc *StatementContext) AddExaminedRows(rows uint64) {
+	sc.mu.Lock()
+	sc.mu.examinedRows += rows
+	sc.mu.Unlock()
+}
+
 // GetWarnings gets warnings.
 func (sc *StatementContext) GetWarnings() []error {
 	sc.mu.Lock()
@@ -452,6 +510,7 @@ func (sc *StatementContext) ResetForRetry() {
 	sc.mu.Lock()
 	sc.mu.affectedRows = 0
 	sc.mu.foundRows = 0
+	sc.mu.examinedRows = 0
 	sc.mu.warnings = nil
 	sc.mu.Unlock()
 }
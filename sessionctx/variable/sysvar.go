@@ -180,6 +180,8 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal, "innodb_log_write_ahead_size", ""},
 	{ScopeNone, "innodb_log_group_home_dir", "./"},
 	{ScopeNone, "performance_schema_events_statements_history_size", "10"},
+	{ScopeNone, "performance_schema_max_sql_text_length", "1024"},
+	{ScopeNone, "performance_schema_max_digest_length", "1024"},
 	{ScopeGlobal, "general_log", "OFF"},
 	{ScopeGlobal, "validate_password_dictionary_file", ""},
 	{ScopeGlobal, "binlog_order_commits", "ON"},
@@ -208,7 +210,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeNone, "innodb_autoinc_lock_mode", "1"},
 	{ScopeGlobal, "slave_net_timeout", "3600"},
 	{ScopeGlobal, "key_buffer_size", "8388608"},
-	{ScopeGlobal | ScopeSession, "foreign_key_checks", "ON"},
+	{ScopeGlobal | ScopeSession, ForeignKeyChecks, "ON"},
 	{ScopeGlobal, "host_cache_size", "279"},
 	{ScopeGlobal, "delay_key_write", "ON"},
 	{ScopeNone, "metadata_locks_cache_size", "1024"},
@@ -601,6 +603,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal | ScopeSession, "min_examined_row_limit", "0"},
 	{ScopeGlobal, "sync_frm", "ON"},
 	{ScopeGlobal, "innodb_online_alter_log_max_size", "134217728"},
+	{ScopeGlobal | ScopeSession, CTEMaxRecursionDepth, strconv.Itoa(DefCTEMaxRecursionDepth)},
 	/* TiDB specific variables */
 	{ScopeSession, TiDBSnapshot, ""},
 	{ScopeSession, TiDBSkipConstraintCheck, "0"},
@@ -612,7 +615,12 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal | ScopeSession, TiDBIndexLookupSize, strconv.Itoa(DefIndexLookupSize)},
 	{ScopeGlobal | ScopeSession, TiDBIndexLookupConcurrency, strconv.Itoa(DefIndexLookupConcurrency)},
 	{ScopeGlobal | ScopeSession, TiDBIndexSerialScanConcurrency, strconv.Itoa(DefIndexSerialScanConcurrency)},
+	{ScopeGlobal | ScopeSession, TiDBHashAggPartialConcurrency, strconv.Itoa(DefHashAggPartialConcurrency)},
+	{ScopeGlobal | ScopeSession, TiDBHashAggFinalConcurrency, strconv.Itoa(DefHashAggFinalConcurrency)},
 	{ScopeGlobal | ScopeSession, TiDBMaxRowCountForINLJ, strconv.Itoa(DefMaxRowCountForINLJ)},
+	{ScopeGlobal | ScopeSession, TiDBMemQuotaSort, strconv.Itoa(DefMemQuotaSort)},
+	{ScopeGlobal | ScopeSession, TiDBMemQuotaHashJoin, strconv.Itoa(DefMemQuotaHashJoin)},
+	{ScopeGlobal | ScopeSession, TiDBMemQuotaQuery, strconv.Itoa(DefMemQuotaQuery)},
 	{ScopeGlobal | ScopeSession, TiDBSkipUTF8Check, boolToIntStr(DefSkipUTF8Check)},
 	{ScopeSession, TiDBBatchInsert, boolToIntStr(DefBatchInsert)},
 	{ScopeSession, TiDBBatchDelete, boolToIntStr(DefBatchDelete)},
@@ -621,12 +629,14 @@ var defaultSysVars = []*SysVar{
 
 // SetNamesVariables is the system variable names related to set names statements.
 var SetNamesVariables = []string{
-	"character_set_client",
+	CharacterSetClient,
 	"character_set_connection",
 	"character_set_results",
 }
 
 const (
+	// CharacterSetClient is the name for character_set_client system variable.
+	CharacterSetClient = "character_set_client"
 	// CharacterSetConnection is the name for character_set_connection system variable.
 	CharacterSetConnection = "character_set_connection"
 	// CollationConnection is the name for collation_connection system variable.
@@ -84,6 +84,16 @@ const (
 	// when we need to keep the data output order the same as the order of index data.
 	TiDBIndexSerialScanConcurrency = "tidb_index_serial_scan_concurrency"
 
+	// tidb_hashagg_partial_concurrency is the number of partial workers of a hash aggregation.
+	// Every partial worker aggregates a shard of the input rows, the partial results are shuffled to the final workers
+	// by the hash of the group key. The hash aggregation runs in a single goroutine if both concurrencies are 1,
+	// which is the default, so the groups are returned in a deterministic order.
+	TiDBHashAggPartialConcurrency = "tidb_hashagg_partial_concurrency"
+
+	// tidb_hashagg_final_concurrency is the number of final workers of a hash aggregation.
+	// Every final worker merges the partial results of the groups whose group key hashes to it.
+	TiDBHashAggFinalConcurrency = "tidb_hashagg_final_concurrency"
+
 	// tidb_skip_utf8_check skips the UTF8 validate process, validate UTF8 has performance cost, if we can make sure
 	// the input string values are valid, we can skip the check.
 	TiDBSkipUTF8Check = "tidb_skip_utf8_check"
@@ -100,17 +110,37 @@ const (
 	// It controls the max row count of outer table when do index nested loop join without hint.
 	// After the row count of the inner table is accurate, this variable will be removed.
 	TiDBMaxRowCountForINLJ = "tidb_max_row_count_for_inlj"
+
+	// tidb_mem_quota_sort is the memory quota in bytes of a sort executor.
+	// When the rows buffered by a sort exceed this quota, the sort spills them to the temporary files
+	// in 'tmp-storage-path' and performs an external merge sort. 0 means the sort never spills.
+	TiDBMemQuotaSort = "tidb_mem_quota_sort"
+
+	// tidb_mem_quota_hashjoin is the memory quota in bytes of the hash table of a hash join.
+	// When the hash table exceeds this quota, the hash join spills the rows of both sides to the temporary files
+	// in 'tmp-storage-path' by partitions and joins the partitions pairwise. 0 means the hash join never spills.
+	TiDBMemQuotaHashJoin = "tidb_mem_quota_hashjoin"
+
+	// tidb_mem_quota_query is the memory quota in bytes of a query.
+	// The memory consumed by the executors of a query is tracked, when it exceeds this quota,
+	// the 'oom-action' in the config is taken, which logs a warning or cancels the query.
+	TiDBMemQuotaQuery = "tidb_mem_quota_query"
 )
 
 // Default TiDB system variable values.
 const (
 	DefIndexLookupConcurrency     = 4
 	DefIndexSerialScanConcurrency = 1
+	DefHashAggPartialConcurrency  = 1
+	DefHashAggFinalConcurrency    = 1
 	DefIndexJoinBatchSize         = 25000
 	DefIndexLookupSize            = 20000
 	DefDistSQLScanConcurrency     = 10
 	DefBuildStatsConcurrency      = 4
 	DefMaxRowCountForINLJ         = 128
+	DefMemQuotaSort               = 1 << 30  // 1GB
+	DefMemQuotaHashJoin           = 1 << 30  // 1GB
+	DefMemQuotaQuery              = 32 << 30 // 32GB
 	DefSkipUTF8Check              = false
 	DefOptAggPushDown             = false
 	DefOptInSubqUnfolding         = false
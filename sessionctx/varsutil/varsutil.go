@@ -121,6 +121,8 @@ func SetSessionSystemVar(vars *variable.SessionVars, name string, value types.Da
 		if isAutocommit {
 			vars.SetStatusFlag(mysql.ServerStatusInTrans, false)
 		}
+	case variable.ForeignKeyChecks:
+		vars.ForeignKeyChecks = tidbOptOn(sVal)
 	case variable.TiDBSkipConstraintCheck:
 		vars.SkipConstraintCheck = tidbOptOn(sVal)
 	case variable.TiDBSkipUTF8Check:
@@ -145,6 +147,18 @@ func SetSessionSystemVar(vars *variable.SessionVars, name string, value types.Da
 		vars.BatchDelete = tidbOptOn(sVal)
 	case variable.TiDBMaxRowCountForINLJ:
 		vars.MaxRowCountForINLJ = tidbOptPositiveInt(sVal, variable.DefMaxRowCountForINLJ)
+	case variable.TiDBMemQuotaSort:
+		vars.MemQuotaSort = optNonNegativeInt64(sVal, variable.DefMemQuotaSort)
+	case variable.TiDBMemQuotaHashJoin:
+		vars.MemQuotaHashJoin = optNonNegativeInt64(sVal, variable.DefMemQuotaHashJoin)
+	case variable.TiDBHashAggPartialConcurrency:
+		vars.HashAggPartialConcurrency = tidbOptPositiveInt(sVal, variable.DefHashAggPartialConcurrency)
+	case variable.TiDBHashAggFinalConcurrency:
+		vars.HashAggFinalConcurrency = tidbOptPositiveInt(sVal, variable.DefHashAggFinalConcurrency)
+	case variable.TiDBMemQuotaQuery:
+		vars.MemQuotaQuery = optNonNegativeInt64(sVal, variable.DefMemQuotaQuery)
+	case variable.CTEMaxRecursionDepth:
+		vars.CTEMaxRecursionDepth = optNonNegativeInt(sVal, variable.DefCTEMaxRecursionDepth)
 	case variable.TiDBCurrentTS:
 		return variable.ErrReadOnly
 	}
@@ -165,6 +179,22 @@ func tidbOptPositiveInt(opt string, defaultVal int) int {
 	return val
 }
 
+func optNonNegativeInt(opt string, defaultVal int) int {
+	val, err := strconv.Atoi(opt)
+	if err != nil || val < 0 {
+		return defaultVal
+	}
+	return val
+}
+
+func optNonNegativeInt64(opt string, defaultVal int64) int64 {
+	val, err := strconv.ParseInt(opt, 10, 64)
+	if err != nil || val < 0 {
+		return defaultVal
+	}
+	return val
+}
+
 func parseTimeZone(s string) (*time.Location, error) {
 	if s == "SYSTEM" {
 		// TODO: Support global time_zone variable, it should be set to global time_zone value.
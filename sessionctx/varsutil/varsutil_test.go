@@ -161,6 +161,44 @@ func (s *testVarsutilSuite) TestVarsutil(c *C) {
 	c.Assert(v.MaxRowCountForINLJ, Equals, 128)
 	SetSessionSystemVar(v, variable.TiDBMaxRowCountForINLJ, types.NewStringDatum("127"))
 	c.Assert(v.MaxRowCountForINLJ, Equals, 127)
+
+	// Test case for tidb_mem_quota_sort.
+	c.Assert(v.MemQuotaSort, Equals, int64(variable.DefMemQuotaSort))
+	SetSessionSystemVar(v, variable.TiDBMemQuotaSort, types.NewStringDatum("1024"))
+	c.Assert(v.MemQuotaSort, Equals, int64(1024))
+	SetSessionSystemVar(v, variable.TiDBMemQuotaSort, types.NewStringDatum("-1"))
+	c.Assert(v.MemQuotaSort, Equals, int64(variable.DefMemQuotaSort))
+
+	// Test case for tidb_mem_quota_hashjoin.
+	c.Assert(v.MemQuotaHashJoin, Equals, int64(variable.DefMemQuotaHashJoin))
+	SetSessionSystemVar(v, variable.TiDBMemQuotaHashJoin, types.NewStringDatum("0"))
+	c.Assert(v.MemQuotaHashJoin, Equals, int64(0))
+
+	// Test case for tidb_mem_quota_query.
+	c.Assert(v.MemQuotaQuery, Equals, int64(variable.DefMemQuotaQuery))
+	SetSessionSystemVar(v, variable.TiDBMemQuotaQuery, types.NewStringDatum("1024"))
+	c.Assert(v.MemQuotaQuery, Equals, int64(1024))
+
+	// Test case for tidb_hashagg_partial_concurrency and tidb_hashagg_final_concurrency.
+	c.Assert(v.HashAggPartialConcurrency, Equals, variable.DefHashAggPartialConcurrency)
+	SetSessionSystemVar(v, variable.TiDBHashAggPartialConcurrency, types.NewStringDatum("8"))
+	c.Assert(v.HashAggPartialConcurrency, Equals, 8)
+	SetSessionSystemVar(v, variable.TiDBHashAggFinalConcurrency, types.NewStringDatum("0"))
+	c.Assert(v.HashAggFinalConcurrency, Equals, variable.DefHashAggFinalConcurrency)
+
+	// Test case for cte_max_recursion_depth.
+	c.Assert(v.CTEMaxRecursionDepth, Equals, variable.DefCTEMaxRecursionDepth)
+	SetSessionSystemVar(v, variable.CTEMaxRecursionDepth, types.NewStringDatum("0"))
+	c.Assert(v.CTEMaxRecursionDepth, Equals, 0)
+	SetSessionSystemVar(v, variable.CTEMaxRecursionDepth, types.NewStringDatum("-1"))
+	c.Assert(v.CTEMaxRecursionDepth, Equals, variable.DefCTEMaxRecursionDepth)
+
+	// Test case for foreign_key_checks.
+	c.Assert(v.ForeignKeyChecks, IsTrue)
+	SetSessionSystemVar(v, variable.ForeignKeyChecks, types.NewStringDatum("0"))
+	c.Assert(v.ForeignKeyChecks, IsFalse)
+	SetSessionSystemVar(v, variable.ForeignKeyChecks, types.NewStringDatum("ON"))
+	c.Assert(v.ForeignKeyChecks, IsTrue)
 }
 
 type mockGlobalAccessor struct {
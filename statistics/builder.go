@@ -108,28 +108,30 @@ func (b *SortedBuilder) Iterate(data types.Datum) error {
 	return nil
 }
 
-// BuildIndex builds histogram for index.
-func BuildIndex(ctx context.Context, numBuckets, id int64, records ast.RecordSet) (int64, *Histogram, error) {
+// BuildIndex builds histogram and CM sketch for index.
+func BuildIndex(ctx context.Context, numBuckets, id int64, records ast.RecordSet, cmsDepth, cmsWidth int32) (int64, *Histogram, *CMSketch, error) {
 	b := NewSortedBuilder(ctx.GetSessionVars().StmtCtx, numBuckets, id)
+	cms := NewCMSketch(cmsDepth, cmsWidth)
 	for {
 		row, err := records.Next()
 		if err != nil {
-			return 0, nil, errors.Trace(err)
+			return 0, nil, nil, errors.Trace(err)
 		}
 		if row == nil {
 			break
 		}
 		bytes, err := codec.EncodeKey(nil, row.Data...)
 		if err != nil {
-			return 0, nil, errors.Trace(err)
+			return 0, nil, nil, errors.Trace(err)
 		}
 		data := types.NewBytesDatum(bytes)
 		err = b.Iterate(data)
 		if err != nil {
-			return 0, nil, errors.Trace(err)
+			return 0, nil, nil, errors.Trace(err)
 		}
+		cms.InsertBytes(bytes)
 	}
-	return b.Count, b.Hist(), nil
+	return b.Count, b.Hist(), cms, nil
 }
 
 // BuildColumn builds histogram from samples for column.
@@ -205,6 +207,8 @@ func BuildColumn(ctx context.Context, numBuckets, id int64, collector *SampleCol
 type AnalyzeResult struct {
 	TableID int64
 	Hist    []*Histogram
+	// Cms is the CM sketch of every histogram in Hist, an element is nil if the sketch is not built.
+	Cms     []*CMSketch
 	Count   int64
 	IsIndex int
 	Err     error
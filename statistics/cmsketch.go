@@ -0,0 +1,199 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/spaolacci/murmur3"
+)
+
+// CMSketch is used to estimate the count of equal values.
+// The inserted values are encoded by tablecodec.EncodeValue, which is also used to encode the queried values.
+// See https://en.wikipedia.org/wiki/Count%E2%80%93min_sketch
+type CMSketch struct {
+	depth int32
+	width int32
+	count uint64
+	table [][]uint32
+}
+
+const (
+	// DefaultCMSketchDepth is the depth of the CM sketches built by ANALYZE.
+	DefaultCMSketchDepth = 5
+	// DefaultCMSketchWidth is the width of the CM sketches built by ANALYZE.
+	DefaultCMSketchWidth = 2048
+)
+
+// NewCMSketch returns a new CM sketch.
+func NewCMSketch(d, w int32) *CMSketch {
+	tbl := make([][]uint32, d)
+	for i := range tbl {
+		tbl[i] = make([]uint32, w)
+	}
+	return &CMSketch{depth: d, width: w, table: tbl}
+}
+
+// InsertBytes inserts the bytes value into the CM sketch.
+func (c *CMSketch) InsertBytes(bytes []byte) {
+	c.count++
+	h1, h2 := murmur3.Sum128(bytes)
+	for i := range c.table {
+		j := (h1 + h2*uint64(i)) % uint64(c.width)
+		c.table[i][j]++
+	}
+}
+
+// TotalCount returns the number of values inserted into the sketch.
+func (c *CMSketch) TotalCount() uint64 {
+	return c.count
+}
+
+// InsertValue inserts the value into the CM sketch, it's encoded in the same way as the queried values.
+func (c *CMSketch) InsertValue(sc *variable.StatementContext, val types.Datum) error {
+	bytes, err := encodeValueForCMSketch(sc, val)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c.InsertBytes(bytes)
+	return nil
+}
+
+func encodeValueForCMSketch(sc *variable.StatementContext, val types.Datum) ([]byte, error) {
+	loc := sc.TimeZone
+	if loc == nil {
+		loc = time.UTC
+	}
+	bytes, err := tablecodec.EncodeValue(val, loc)
+	return bytes, errors.Trace(err)
+}
+
+func (c *CMSketch) queryValue(sc *variable.StatementContext, val types.Datum) (uint32, error) {
+	bytes, err := encodeValueForCMSketch(sc, val)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return c.queryBytes(bytes), nil
+}
+
+// queryBytes uses the count-mean-min estimation: the noise of every row is removed by
+// subtracting the average count of the other counters, and the median of the rows is
+// taken, which is then bounded by the classic count-min estimation.
+func (c *CMSketch) queryBytes(bytes []byte) uint32 {
+	h1, h2 := murmur3.Sum128(bytes)
+	vals := make([]uint32, c.depth)
+	min := uint32(math.MaxUint32)
+	for i := range c.table {
+		j := (h1 + h2*uint64(i)) % uint64(c.width)
+		if min > c.table[i][j] {
+			min = c.table[i][j]
+		}
+		noise := (c.count - uint64(c.table[i][j])) / (uint64(c.width) - 1)
+		if uint64(c.table[i][j]) < noise {
+			vals[i] = 0
+		} else {
+			vals[i] = c.table[i][j] - uint32(noise)
+		}
+	}
+	sort.Slice(vals, func(i, j int) bool { return vals[i] < vals[j] })
+	res := vals[(c.depth-1)/2] + (vals[c.depth/2]-vals[(c.depth-1)/2])/2
+	if res > min {
+		return min
+	}
+	return res
+}
+
+// MergeCMSketch merges two CM sketches, they must have the same depth and width.
+func (c *CMSketch) MergeCMSketch(rc *CMSketch) error {
+	if c.depth != rc.depth || c.width != rc.width {
+		return errors.New("Dimensions of Count-Min Sketch should be the same")
+	}
+	c.count += rc.count
+	for i := range c.table {
+		for j := range c.table[i] {
+			c.table[i][j] += rc.table[i][j]
+		}
+	}
+	return nil
+}
+
+// encodeCMSketch encodes the CM sketch as its depth, width and the counters of every row,
+// the total count is not stored because it is the sum of the counters of any row.
+func encodeCMSketch(c *CMSketch) ([]byte, error) {
+	if c == nil || c.count == 0 {
+		return nil, nil
+	}
+	data := make([]byte, 8, 8+4*int(c.depth)*int(c.width))
+	binary.BigEndian.PutUint32(data, uint32(c.depth))
+	binary.BigEndian.PutUint32(data[4:], uint32(c.width))
+	var buf [4]byte
+	for i := range c.table {
+		for _, counter := range c.table[i] {
+			binary.BigEndian.PutUint32(buf[:], counter)
+			data = append(data, buf[:]...)
+		}
+	}
+	return data, nil
+}
+
+func decodeCMSketch(data []byte) (*CMSketch, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if len(data) < 8 {
+		return nil, errors.New("invalid CM sketch data")
+	}
+	d, w := int32(binary.BigEndian.Uint32(data)), int32(binary.BigEndian.Uint32(data[4:]))
+	data = data[8:]
+	if d <= 0 || w <= 0 || len(data) != 4*int(d)*int(w) {
+		return nil, errors.New("invalid CM sketch data")
+	}
+	c := NewCMSketch(d, w)
+	for i := range c.table {
+		for j := range c.table[i] {
+			c.table[i][j] = binary.BigEndian.Uint32(data)
+			data = data[4:]
+			// Every inserted value increases exactly one counter of each row.
+			if i == 0 {
+				c.count += uint64(c.table[i][j])
+			}
+		}
+	}
+	return c, nil
+}
+
+// Equal tests if two CM sketches are equal, it is mainly used for test.
+func (c *CMSketch) Equal(rc *CMSketch) bool {
+	if c == nil || rc == nil {
+		return c == nil && rc == nil
+	}
+	if c.width != rc.width || c.depth != rc.depth || c.count != rc.count {
+		return false
+	}
+	for i := range c.table {
+		for j := range c.table[i] {
+			if c.table[i][j] != rc.table[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -39,6 +39,11 @@ func (h *Handle) HandleDDLEvent(t *ddl.Event) error {
 		return h.deleteHistStatsFromKV(t.TableInfo.ID, t.ColumnInfo.ID, 0)
 	case model.ActionDropIndex:
 		return h.deleteHistStatsFromKV(t.TableInfo.ID, t.IndexInfo.ID, 1)
+	case model.ActionAddPrimaryKey, model.ActionDropPrimaryKey:
+		// The table is rebuilt with a new table ID if the handle is changed.
+		if t.OldTableID != 0 {
+			return h.moveTableStats(t.OldTableID, t.TableInfo)
+		}
 	default:
 		log.Warnf("Unsupported ddl event for statistic %s", t)
 	}
@@ -187,3 +192,33 @@ func (h *Handle) deleteHistStatsFromKV(tableID int64, histID int64, isIndex int)
 	_, err = exec.Execute("commit")
 	return errors.Trace(err)
 }
+
+// moveTableStats moves the statistics of a table to the new table ID of it, the statistics of the indices
+// which don't exist in the new table are deleted.
+func (h *Handle) moveTableStats(oldID int64, info *model.TableInfo) error {
+	exec := h.ctx.(sqlexec.SQLExecutor)
+	_, err := exec.Execute("begin")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = exec.Execute(fmt.Sprintf("update mysql.stats_meta set version = %d, table_id = %d where table_id = %d", h.ctx.Txn().StartTS(), info.ID, oldID))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, tbl := range []string{"stats_histograms", "stats_buckets"} {
+		_, err = exec.Execute(fmt.Sprintf("update mysql.%s set table_id = %d where table_id = %d", tbl, info.ID, oldID))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		cond := fmt.Sprintf("table_id = %d and is_index = 1", info.ID)
+		for _, idx := range info.Indices {
+			cond += fmt.Sprintf(" and hist_id != %d", idx.ID)
+		}
+		_, err = exec.Execute(fmt.Sprintf("delete from mysql.%s where %s", tbl, cond))
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	_, err = exec.Execute("commit")
+	return errors.Trace(err)
+}
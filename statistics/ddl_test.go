@@ -190,3 +190,43 @@ func (s *testStatsCacheSuite) TestDDLHistogram(c *C) {
 	rs = testKit.MustQuery("select count(*) from mysql.stats_buckets where table_id = ? and hist_id = 1 and is_index = 1", tableInfo.ID)
 	rs.Check(testkit.Rows("0"))
 }
+
+func (s *testStatsCacheSuite) TestDDLRebuildTable(c *C) {
+	defer cleanEnv(c, s.store, s.do)
+	testKit := testkit.NewTestKit(c, s.store)
+	testKit.MustExec("use test")
+	testKit.MustExec("create table t (c1 int not null, c2 int, index idx(c2))")
+	do := s.do
+	h := do.StatsHandle()
+	err := h.HandleDDLEvent(<-h.DDLEventCh())
+	c.Assert(err, IsNil)
+	testKit.MustExec("insert into t values (1, 1), (2, 2), (3, 2)")
+	// Resolve the locks of the secondary keys before analyzing.
+	testKit.MustQuery("select count(c2) from t").Check(testkit.Rows("3"))
+	testKit.MustExec("analyze table t")
+	oldTbl, err := do.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+
+	// The table is rebuilt with c1 as the handle, the statistics are moved to the new table ID.
+	for _, sql := range []string{"alter table t add primary key(c1)", "alter table t drop primary key"} {
+		testKit.MustExec(sql)
+		err = h.HandleDDLEvent(<-h.DDLEventCh())
+		c.Assert(err, IsNil)
+		is := do.InfoSchema()
+		c.Assert(h.Update(is), IsNil)
+		tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+		c.Assert(err, IsNil)
+		tableInfo := tbl.Meta()
+		c.Assert(tableInfo.ID, Not(Equals), oldTbl.Meta().ID)
+		statsTbl := h.GetTableStats(tableInfo.ID)
+		c.Assert(statsTbl.Pseudo, IsFalse)
+		c.Assert(statsTbl.Count, Equals, int64(3))
+		sc := new(variable.StatementContext)
+		count, err := statsTbl.ColumnEqualRowCount(sc, types.NewIntDatum(2), tableInfo.Columns[0])
+		c.Assert(err, IsNil)
+		c.Assert(count, Equals, float64(1))
+		c.Assert(statsTbl.Indices[tableInfo.Indices[0].ID], NotNil)
+		testKit.MustQuery("select count(*) from mysql.stats_histograms where table_id = ?", oldTbl.Meta().ID).Check(testkit.Rows("0"))
+		oldTbl = tbl
+	}
+}
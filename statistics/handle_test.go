@@ -15,6 +15,7 @@ package statistics_test
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/juju/errors"
 	. "github.com/pingcap/check"
@@ -113,10 +114,12 @@ func assertTableEqual(c *C, a *statistics.Table, b *statistics.Table) {
 	c.Assert(len(a.Columns), Equals, len(b.Columns))
 	for i := range a.Columns {
 		assertHistogramEqual(c, a.Columns[i].Histogram, b.Columns[i].Histogram)
+		c.Assert(a.Columns[i].CMSketch.Equal(b.Columns[i].CMSketch), IsTrue)
 	}
 	c.Assert(len(a.Indices), Equals, len(b.Indices))
 	for i := range a.Indices {
 		assertHistogramEqual(c, a.Indices[i].Histogram, b.Indices[i].Histogram)
+		c.Assert(a.Indices[i].CMSketch.Equal(b.Indices[i].CMSketch), IsTrue)
 	}
 }
 
@@ -155,6 +158,56 @@ func (s *testStatsCacheSuite) TestStatsStoreAndLoad(c *C) {
 	c.Assert(statsTbl2.Count, Equals, int64(recordCount))
 
 	assertTableEqual(c, statsTbl1, statsTbl2)
+	c.Assert(statsTbl2.Columns[tableInfo.Columns[0].ID].CMSketch, NotNil)
+	c.Assert(statsTbl2.Indices[tableInfo.Indices[0].ID].CMSketch, NotNil)
+}
+
+func (s *testStatsCacheSuite) TestEqualRowCountWithCMSketch(c *C) {
+	defer cleanEnv(c, s.store, s.do)
+	testKit := testkit.NewTestKit(c, s.store)
+	testKit.MustExec("use test")
+	testKit.MustExec("create table t (c1 int, c2 int, index idx(c2))")
+	// The value 1 is skewed, the histogram can not tell its count because it is not a bucket bound.
+	for i := 0; i < 100; i++ {
+		testKit.MustExec("insert into t values (?, ?), (?, ?), (1, 1)", i+1000, i+1000, i+2000, i+2000)
+	}
+	testKit.MustExec("analyze table t")
+	do := s.do
+	is := do.InfoSchema()
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	tableInfo := tbl.Meta()
+	statsTbl := do.StatsHandle().GetTableStats(tableInfo.ID)
+	sc := new(variable.StatementContext)
+	count, err := statsTbl.ColumnEqualRowCount(sc, types.NewIntDatum(1), tableInfo.Columns[0])
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, 100.0)
+	count, err = statsTbl.ColumnEqualRowCount(sc, types.NewIntDatum(1500), tableInfo.Columns[0])
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, 0.0)
+	ran := []*types.IndexRange{{LowVal: []types.Datum{types.NewIntDatum(1)}, HighVal: []types.Datum{types.NewIntDatum(1)}}}
+	count, err = statsTbl.GetRowCountByIndexRanges(sc, tableInfo.Indices[0].ID, ran)
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, 100.0)
+
+	// The rows are more than the samples, the CM sketches are still built from every row.
+	values := make([]string, 0, 3000)
+	for i := 0; i < 1000; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d), (%d, %d), (2, 2)", i+3000, i+3000, i+4000, i+4000))
+	}
+	testKit.MustExec("insert into t values " + strings.Join(values, ", "))
+	// ANALYZE ignores the locks, the secondary locks of the insert are resolved by the snapshot reads.
+	testKit.MustQuery("select count(c1) from t").Check(testkit.Rows("3300"))
+	testKit.MustQuery("select count(c2) from t use index(idx)").Check(testkit.Rows("3300"))
+	testKit.MustExec("analyze table t")
+	statsTbl = do.StatsHandle().GetTableStats(tableInfo.ID)
+	count, err = statsTbl.ColumnEqualRowCount(sc, types.NewIntDatum(2), tableInfo.Columns[0])
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, 1000.0)
+	ran = []*types.IndexRange{{LowVal: []types.Datum{types.NewIntDatum(2)}, HighVal: []types.Datum{types.NewIntDatum(2)}}}
+	count, err = statsTbl.GetRowCountByIndexRanges(sc, tableInfo.Indices[0].ID, ran)
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, 1000.0)
 }
 
 func (s *testStatsCacheSuite) TestEmptyTable(c *C) {
@@ -14,6 +14,7 @@
 package statistics
 
 import (
+	"bytes"
 	"fmt"
 	"math"
 	"sort"
@@ -61,8 +62,8 @@ type Bucket struct {
 	commonPfxLen int // when the bucket value type is KindString or KindBytes, commonPfxLen is the common prefix length of the lower bound and upper bound.
 }
 
-// SaveToStorage saves the histogram to storage.
-func (hg *Histogram) SaveToStorage(ctx context.Context, tableID int64, count int64, isIndex int) error {
+// SaveStatsToStorage saves the histogram and the CM sketch of a column or index to storage.
+func SaveStatsToStorage(ctx context.Context, tableID int64, count int64, isIndex int, hg *Histogram, cms *CMSketch) error {
 	exec := ctx.(sqlexec.SQLExecutor)
 	_, err := exec.Execute("begin")
 	if err != nil {
@@ -75,7 +76,11 @@ func (hg *Histogram) SaveToStorage(ctx context.Context, tableID int64, count int
 	if err != nil {
 		return errors.Trace(err)
 	}
-	replaceSQL = fmt.Sprintf("replace into mysql.stats_histograms (table_id, is_index, hist_id, distinct_count, version, null_count) values (%d, %d, %d, %d, %d, %d)", tableID, isIndex, hg.ID, hg.NDV, version, hg.NullCount)
+	data, err := encodeCMSketch(cms)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	replaceSQL = fmt.Sprintf("replace into mysql.stats_histograms (table_id, is_index, hist_id, distinct_count, version, null_count, cm_sketch) values (%d, %d, %d, %d, %d, %d, X'%X')", tableID, isIndex, hg.ID, hg.NDV, version, hg.NullCount, data)
 	_, err = exec.Execute(replaceSQL)
 	if err != nil {
 		return errors.Trace(err)
@@ -443,6 +448,7 @@ func MergeHistograms(sc *variable.StatementContext, lh *Histogram, rh *Histogram
 // Column represents a column histogram.
 type Column struct {
 	Histogram
+	*CMSketch
 	Info *model.ColumnInfo
 }
 
@@ -450,6 +456,16 @@ func (c *Column) String() string {
 	return c.Histogram.toString(false)
 }
 
+// equalRowCount estimates the row count where the column equals to value.
+// The CM sketch is preferred because the histogram can only tell the repeats of bucket upper bounds.
+func (c *Column) equalRowCount(sc *variable.StatementContext, value types.Datum) (float64, error) {
+	if c.CMSketch == nil || value.IsNull() {
+		return c.Histogram.equalRowCount(sc, value)
+	}
+	count, err := c.CMSketch.queryValue(sc, value)
+	return float64(count), errors.Trace(err)
+}
+
 // getIntColumnRowCount estimates the row count by a slice of IntColumnRange.
 func (c *Column) getIntColumnRowCount(sc *variable.StatementContext, intRanges []types.IntColumnRange,
 	totalRowCount float64) (float64, error) {
@@ -536,6 +552,7 @@ func (c *Column) getColumnRowCount(sc *variable.StatementContext, ranges []*type
 // Index represents an index histogram.
 type Index struct {
 	Histogram
+	*CMSketch
 	Info *model.IndexInfo
 }
 
@@ -558,6 +575,11 @@ func (idx *Index) getRowCount(sc *variable.StatementContext, indexRanges []*type
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
+		if idx.CMSketch != nil && bytes.Equal(lb, rb) && !indexRange.LowExclude && !indexRange.HighExclude {
+			// The point case on all the index columns.
+			totalCount += float64(idx.CMSketch.queryBytes(lb))
+			continue
+		}
 		if !indexRange.HighExclude {
 			rb = append(rb, 0)
 		}
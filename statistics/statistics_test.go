@@ -223,7 +223,7 @@ func (s *testStatisticsSuite) TestBuild(c *C) {
 	c.Assert(err, IsNil)
 	checkRepeats(c, col)
 
-	tblCount, col, err := BuildIndex(ctx, bucketCount, 1, ast.RecordSet(s.rc))
+	tblCount, col, _, err := BuildIndex(ctx, bucketCount, 1, ast.RecordSet(s.rc), 5, 2048)
 	checkRepeats(c, col)
 	calculateScalar(col)
 	c.Check(err, IsNil)
@@ -276,7 +276,7 @@ func (s *testStatisticsSuite) TestBuild(c *C) {
 func (s *testStatisticsSuite) TestHistogramProtoConversion(c *C) {
 	ctx := mock.NewContext()
 	s.rc.Close()
-	tblCount, col, err := BuildIndex(ctx, 256, 1, ast.RecordSet(s.rc))
+	tblCount, col, _, err := BuildIndex(ctx, 256, 1, ast.RecordSet(s.rc), 5, 2048)
 	c.Check(err, IsNil)
 	c.Check(int(tblCount), Equals, 100000)
 
@@ -64,6 +64,9 @@ func (e *tableScanExec) Next() (value [][]byte, err error) {
 			}
 			e.seekKey = nil
 			e.cursor++
+			if value == nil {
+				continue
+			}
 			return value, nil
 		}
 
@@ -527,10 +530,11 @@ func (e *topNExec) evalTopN(value [][]byte) error {
 		return errors.Trace(err)
 	}
 	for i, expr := range e.orderByExprs {
-		newRow.key[i], err = expr.Eval(e.row)
+		v, err := expr.Eval(e.row)
 		if err != nil {
 			return errors.Trace(err)
 		}
+		newRow.key[i] = codec.CollationKey(v, expr.GetType().Collate)
 	}
 
 	if e.heap.tryToAddRow(newRow) {
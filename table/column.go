@@ -172,6 +172,37 @@ func CastValue(ctx context.Context, val types.Datum, col *model.ColumnInfo) (cas
 	return casted, errors.Trace(err)
 }
 
+// CastColumnValue casts the value of the origin column to the type of the new column when the column type is changed.
+// The value is converted by the cast functions if the evaluation types of the two columns are different,
+// then it's checked by CastValue as the value is going to be written into the new column.
+func CastColumnValue(ctx context.Context, val types.Datum, oldCol, newCol *model.ColumnInfo) (types.Datum, error) {
+	if val.IsNull() {
+		return val, nil
+	}
+	if isEnumOrSet(oldCol.Tp) && isEnumOrSet(newCol.Tp) {
+		// The enum and set values are converted by the names rather than the indexes.
+		str, err := val.ToString()
+		if err != nil {
+			return val, errors.Trace(err)
+		}
+		val = types.NewStringDatum(str)
+	} else if oldCol.EvalType() != newCol.EvalType() {
+		tp := newCol.FieldType
+		cast := expression.BuildCastFunction(ctx, &expression.Constant{Value: val, RetType: &oldCol.FieldType}, &tp)
+		var err error
+		val, err = cast.Eval(nil)
+		if err != nil {
+			return val, errors.Trace(err)
+		}
+	}
+	casted, err := CastValue(ctx, val, newCol)
+	return casted, errors.Trace(err)
+}
+
+func isEnumOrSet(tp byte) bool {
+	return tp == mysql.TypeEnum || tp == mysql.TypeSet
+}
+
 // ColDesc describes column information like MySQL desc and show columns do.
 type ColDesc struct {
 	Field        string
@@ -273,7 +304,7 @@ func CheckOnce(cols []*Column) error {
 // CheckNotNull checks if nil value set to a column with NotNull flag is set.
 func (c *Column) CheckNotNull(data types.Datum) error {
 	if mysql.HasNotNullFlag(c.Flag) && data.IsNull() {
-		return errColumnCantNull.Gen("Column %s can't be null.", c.Name)
+		return ErrColumnCantNull.Gen("Column %s can't be null.", c.Name)
 	}
 	return nil
 }
@@ -239,6 +239,34 @@ func (s *testTableSuite) TestCastValue(c *C) {
 	c.Assert(val, NotNil)
 }
 
+func (s *testTableSuite) TestCastColumnValue(c *C) {
+	ctx := mock.NewContext()
+	intCol := &model.ColumnInfo{FieldType: *types.NewFieldType(mysql.TypeLong)}
+	strCol := &model.ColumnInfo{FieldType: *types.NewFieldType(mysql.TypeVarchar)}
+	strCol.Flen = 3
+	strCol.Charset = mysql.UTF8Charset
+
+	val, err := CastColumnValue(ctx, types.Datum{}, intCol, strCol)
+	c.Assert(err, IsNil)
+	c.Assert(val.IsNull(), IsTrue)
+	val, err = CastColumnValue(ctx, types.NewIntDatum(123), intCol, strCol)
+	c.Assert(err, IsNil)
+	c.Assert(val.GetString(), Equals, "123")
+	_, err = CastColumnValue(ctx, types.NewIntDatum(1234), intCol, strCol)
+	c.Assert(err, NotNil)
+	_, err = CastColumnValue(ctx, types.NewStringDatum("abc"), strCol, intCol)
+	c.Assert(err, NotNil)
+
+	// The enum values are converted by the names.
+	enumCol1 := &model.ColumnInfo{FieldType: *types.NewFieldType(mysql.TypeEnum)}
+	enumCol1.Elems = []string{"a", "b"}
+	enumCol2 := &model.ColumnInfo{FieldType: *types.NewFieldType(mysql.TypeEnum)}
+	enumCol2.Elems = []string{"b", "a"}
+	val, err = CastColumnValue(ctx, types.NewDatum(types.Enum{Name: "b", Value: 2}), enumCol1, enumCol2)
+	c.Assert(err, IsNil)
+	c.Assert(val.GetMysqlEnum().Value, Equals, uint64(1))
+}
+
 func (s *testTableSuite) TestGetDefaultValue(c *C) {
 	ctx := mock.NewContext()
 	zeroTimestamp := types.ZeroTimestamp
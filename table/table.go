@@ -19,6 +19,7 @@ package table
 
 import (
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
@@ -40,12 +41,13 @@ const (
 )
 
 var (
-	errColumnCantNull  = terror.ClassTable.New(codeColumnCantNull, "column can not be null")
 	errUnknownColumn   = terror.ClassTable.New(codeUnknownColumn, "unknown column")
 	errDuplicateColumn = terror.ClassTable.New(codeDuplicateColumn, "duplicate column")
 
 	errGetDefaultFailed = terror.ClassTable.New(codeGetDefaultFailed, "get default value fail")
 
+	// ErrColumnCantNull is used when a NULL value is set to a column which can't be null.
+	ErrColumnCantNull = terror.ClassTable.New(codeColumnCantNull, "column can not be null")
 	// ErrNoDefaultValue is used when insert a row, the column value is not given, and the column has not null flag
 	// and it doesn't have a default value.
 	ErrNoDefaultValue = terror.ClassTable.New(codeNoDefaultValue, "field doesn't have a default value")
@@ -67,6 +69,8 @@ var (
 	ErrInvalidRecordKey = terror.ClassTable.New(codeInvalidRecordKey, "invalid record key")
 	// ErrTruncateWrongValue returns for truncate wrong value for field.
 	ErrTruncateWrongValue = terror.ClassTable.New(codeTruncateWrongValue, "Incorrect value")
+	// ErrNoPartitionForGivenValue returns when there is no partition for the row.
+	ErrNoPartitionForGivenValue = terror.ClassTable.New(codeNoPartitionForGivenValue, mysql.MySQLErrName[mysql.ErrNoPartitionForGivenValue])
 )
 
 // RecordIterFunc is used for low-level record iteration.
@@ -141,6 +145,25 @@ type Table interface {
 	Type() Type
 }
 
+// PhysicalTable is a table whose data is encoded with its own physical ID,
+// it's either a non-partitioned table or a partition of a partitioned table.
+type PhysicalTable interface {
+	Table
+	// GetPhysicalID returns the ID used to encode the data of the table.
+	GetPhysicalID() int64
+}
+
+// PartitionedTable is a table which is divided into partitions, each partition is a PhysicalTable.
+type PartitionedTable interface {
+	Table
+	// GetPartition returns the partition with the physical ID, it returns nil if the partition doesn't exist.
+	GetPartition(physicalID int64) PhysicalTable
+	// LocatePartition returns the ID of the partition which the row belongs to.
+	LocatePartition(ctx context.Context, r []types.Datum) (int64, error)
+	// PartitionExpr returns the partition expression built on the columns of the table.
+	PartitionExpr() expression.Expression
+}
+
 // TableFromMeta builds a table.Table from *model.TableInfo.
 // Currently, it is assigned to tables.TableFromMeta in tidb package's init function.
 var TableFromMeta func(alloc autoid.Allocator, tblInfo *model.TableInfo) (Table, error)
@@ -165,6 +188,8 @@ const (
 	codeDuplicateColumn    = 1110
 	codeNoDefaultValue     = 1364
 	codeTruncateWrongValue = 1366
+
+	codeNoPartitionForGivenValue = terror.ErrCode(mysql.ErrNoPartitionForGivenValue)
 )
 
 // Slice is used for table sorting.
@@ -185,6 +210,8 @@ func init() {
 		codeDuplicateColumn:    mysql.ErrFieldSpecifiedTwice,
 		codeNoDefaultValue:     mysql.ErrNoDefaultForField,
 		codeTruncateWrongValue: mysql.ErrTruncatedWrongValueForField,
+
+		codeNoPartitionForGivenValue: mysql.ErrNoPartitionForGivenValue,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassTable] = tableMySQLErrCodes
 }
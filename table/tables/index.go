@@ -97,32 +97,55 @@ type index struct {
 	tblInfo *model.TableInfo
 	idxInfo *model.IndexInfo
 	prefix  kv.Key
+	// collations are the collations of the index columns, the strings are encoded by their collations.
+	collations []string
 
 	buffer []byte // It's used reduce the number of new slice when multiple index keys are created.
 }
 
-// NewIndexWithBuffer builds a new Index object whit the buffer.
-func NewIndexWithBuffer(tableInfo *model.TableInfo, indexInfo *model.IndexInfo) table.Index {
-	idxPrefix := tablecodec.EncodeTableIndexPrefix(tableInfo.ID, indexInfo.ID)
+// NewIndexWithBuffer builds a new Index object whit the buffer, its keys are encoded with the physical table ID.
+func NewIndexWithBuffer(physicalID int64, tableInfo *model.TableInfo, indexInfo *model.IndexInfo) table.Index {
+	idxPrefix := tablecodec.EncodeTableIndexPrefix(physicalID, indexInfo.ID)
 	index := &index{
-		tblInfo: tableInfo,
-		idxInfo: indexInfo,
-		prefix:  idxPrefix,
-		buffer:  make([]byte, 0, len(idxPrefix)+len(indexInfo.Columns)*9+9),
+		tblInfo:    tableInfo,
+		idxInfo:    indexInfo,
+		prefix:     idxPrefix,
+		collations: indexCollations(tableInfo, indexInfo),
+		buffer:     make([]byte, 0, len(idxPrefix)+len(indexInfo.Columns)*9+9),
 	}
 	return index
 }
 
 // NewIndex builds a new Index object.
 func NewIndex(tableInfo *model.TableInfo, indexInfo *model.IndexInfo) table.Index {
+	return newIndex(tableInfo.ID, tableInfo, indexInfo)
+}
+
+// newIndex builds a new Index object whose keys are encoded with the physical table ID.
+func newIndex(physicalID int64, tableInfo *model.TableInfo, indexInfo *model.IndexInfo) table.Index {
 	index := &index{
-		tblInfo: tableInfo,
-		idxInfo: indexInfo,
-		prefix:  tablecodec.EncodeTableIndexPrefix(tableInfo.ID, indexInfo.ID),
+		tblInfo:    tableInfo,
+		idxInfo:    indexInfo,
+		prefix:     tablecodec.EncodeTableIndexPrefix(physicalID, indexInfo.ID),
+		collations: indexCollations(tableInfo, indexInfo),
 	}
 	return index
 }
 
+// indexCollations returns the collations of the index columns, it returns nil if the index keeps the raw strings.
+func indexCollations(tableInfo *model.TableInfo, indexInfo *model.IndexInfo) []string {
+	if !indexInfo.HasCollationKeys() {
+		return nil
+	}
+	collations := make([]string, len(indexInfo.Columns))
+	for i, ic := range indexInfo.Columns {
+		if ic.Offset < len(tableInfo.Columns) {
+			collations[i] = tableInfo.Columns[ic.Offset].Collate
+		}
+	}
+	return collations
+}
+
 // Meta returns index info.
 func (c *index) Meta() *model.IndexInfo {
 	return c.idxInfo
@@ -145,6 +168,11 @@ func (c *index) GenIndexKey(indexedValues []types.Datum, h int64) (key []byte, d
 		}
 	}
 
+	// The strings of the case-insensitive collations are encoded by their sort keys, so the unique index
+	// rejects the strings which are equal by the collation. The prefix of the sort key is kept for the prefix index.
+	// The indexes of the old versions keep the raw strings, their collations are nil.
+	indexedValues = codec.CollationKeys(indexedValues, c.collations)
+
 	// For string columns, indexes can be created that use only the leading part of column values,
 	// using col_name(length) syntax to specify an index prefix length.
 	for i := 0; i < len(indexedValues); i++ {
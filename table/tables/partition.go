@@ -0,0 +1,266 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tables
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// PartitionMaxValue is the upper bound string of the last range partition defined by VALUES LESS THAN MAXVALUE.
+const PartitionMaxValue = "MAXVALUE"
+
+// partition is a partition of a partitioned table, it shares the columns and meta of the table,
+// but its data and indices are encoded with the partition ID.
+type partition struct {
+	Table
+}
+
+// newPartition builds a partition from the logical table.
+func newPartition(tbl *Table, def model.PartitionDefinition) *partition {
+	p := &partition{Table: *tbl}
+	p.physicalID = def.ID
+	p.recordPrefix = tablecodec.GenTableRecordPrefix(def.ID)
+	p.indexPrefix = tablecodec.GenTableIndexPrefix(def.ID)
+	p.indices = make([]table.Index, 0, len(tbl.indices))
+	for _, idx := range tbl.indices {
+		p.indices = append(p.indices, newIndex(def.ID, tbl.meta, idx.Meta()))
+	}
+	return p
+}
+
+// partitionedTable implements the table.PartitionedTable interface.
+// The rows are stored in the partitions, the logical table itself has no data.
+// The handles are allocated by the logical table, so a handle is unique among all partitions.
+type partitionedTable struct {
+	*Table
+	partInfo   *model.PartitionInfo
+	partExpr   expression.Expression
+	partitions []*partition
+	// rangeBounds are the upper bounds of the range partitions, the last one is ignored if hasMaxValue is true.
+	rangeBounds []int64
+	hasMaxValue bool
+}
+
+func newPartitionedTable(tbl *Table, tblInfo *model.TableInfo) (table.Table, error) {
+	pi := tblInfo.Partition
+	expr, err := parseExpression(pi.Expr)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	schema := expression.NewSchema(expression.ColumnInfos2Columns(tblInfo.Name, tblInfo.Columns)...)
+	partExpr, err := expression.RewriteAstExpr(expr, schema, mock.NewContext())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	t := &partitionedTable{
+		Table:      tbl,
+		partInfo:   pi,
+		partExpr:   partExpr,
+		partitions: make([]*partition, 0, len(pi.Definitions)),
+	}
+	for i, def := range pi.Definitions {
+		t.partitions = append(t.partitions, newPartition(tbl, def))
+		if pi.Type != model.PartitionTypeRange {
+			continue
+		}
+		if len(def.LessThan) > 0 && def.LessThan[0] == PartitionMaxValue {
+			if i != len(pi.Definitions)-1 {
+				return nil, errors.Errorf("MAXVALUE can only be used in the last partition of %s", tblInfo.Name)
+			}
+			t.hasMaxValue = true
+			t.rangeBounds = append(t.rangeBounds, 0)
+			continue
+		}
+		if len(def.LessThan) != 1 {
+			return nil, errors.Errorf("invalid range partition %s of %s", def.Name, tblInfo.Name)
+		}
+		bound, err := strconv.ParseInt(def.LessThan[0], 10, 64)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		t.rangeBounds = append(t.rangeBounds, bound)
+	}
+	return t, nil
+}
+
+// GetPartition implements table.PartitionedTable GetPartition interface.
+func (t *partitionedTable) GetPartition(physicalID int64) table.PhysicalTable {
+	for _, p := range t.partitions {
+		if p.physicalID == physicalID {
+			return p
+		}
+	}
+	return nil
+}
+
+// LocatePartition implements table.PartitionedTable LocatePartition interface.
+func (t *partitionedTable) LocatePartition(ctx context.Context, r []types.Datum) (int64, error) {
+	p, err := t.locatePartition(ctx, r)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return p.physicalID, nil
+}
+
+// PartitionExpr implements table.PartitionedTable PartitionExpr interface.
+func (t *partitionedTable) PartitionExpr() expression.Expression {
+	return t.partExpr
+}
+
+func (t *partitionedTable) locatePartition(ctx context.Context, r []types.Datum) (*partition, error) {
+	v, isNull, err := t.partExpr.EvalInt(r, ctx.GetSessionVars().StmtCtx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if t.partInfo.Type == model.PartitionTypeHash {
+		if isNull {
+			return t.partitions[0], nil
+		}
+		idx := v % int64(len(t.partitions))
+		if idx < 0 {
+			idx = -idx
+		}
+		return t.partitions[idx], nil
+	}
+	// NULL is treated as a value less than any other value in range partitioning.
+	if isNull {
+		return t.partitions[0], nil
+	}
+	idx := sort.Search(len(t.rangeBounds), func(i int) bool {
+		return (t.hasMaxValue && i == len(t.rangeBounds)-1) || v < t.rangeBounds[i]
+	})
+	if idx >= len(t.partitions) {
+		return nil, table.ErrNoPartitionForGivenValue.GenByArgs(strconv.FormatInt(v, 10))
+	}
+	return t.partitions[idx], nil
+}
+
+// AddRecord implements table.Table AddRecord interface.
+func (t *partitionedTable) AddRecord(ctx context.Context, r []types.Datum) (recordID int64, err error) {
+	p, err := t.locatePartition(ctx, r)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return p.AddRecord(ctx, r)
+}
+
+// UpdateRecord implements table.Table UpdateRecord interface.
+// If the new row belongs to another partition, the row is moved to that partition with the same handle.
+func (t *partitionedTable) UpdateRecord(ctx context.Context, h int64, oldData, newData []types.Datum, touched []bool) error {
+	from, err := t.locatePartition(ctx, oldData)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	to, err := t.locatePartition(ctx, newData)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if from == to {
+		return errors.Trace(from.UpdateRecord(ctx, h, oldData, newData, touched))
+	}
+	if err = from.RemoveRecord(ctx, h, oldData); err != nil {
+		return errors.Trace(err)
+	}
+	_, err = to.addRecord(ctx, h, newData)
+	return errors.Trace(err)
+}
+
+// RemoveRecord implements table.Table RemoveRecord interface.
+func (t *partitionedTable) RemoveRecord(ctx context.Context, h int64, r []types.Datum) error {
+	p, err := t.locatePartition(ctx, r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(p.RemoveRecord(ctx, h, r))
+}
+
+// RowWithCols implements table.Table RowWithCols interface.
+func (t *partitionedTable) RowWithCols(ctx context.Context, h int64, cols []*table.Column) ([]types.Datum, error) {
+	for _, p := range t.partitions {
+		row, err := p.RowWithCols(ctx, h, cols)
+		if kv.ErrNotExist.Equal(err) {
+			continue
+		}
+		return row, errors.Trace(err)
+	}
+	return nil, errors.Trace(kv.ErrNotExist)
+}
+
+// Row implements table.Table Row interface.
+func (t *partitionedTable) Row(ctx context.Context, h int64) ([]types.Datum, error) {
+	r, err := t.RowWithCols(ctx, h, t.Cols())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return r, nil
+}
+
+// IterRecords implements table.Table IterRecords interface.
+// The partitions are iterated in order, the iteration starts from the partition which startKey belongs to.
+func (t *partitionedTable) IterRecords(ctx context.Context, startKey kv.Key, cols []*table.Column,
+	fn table.RecordIterFunc) error {
+	start := 0
+	for i, p := range t.partitions {
+		if startKey.HasPrefix(p.RecordPrefix()) {
+			start = i
+			break
+		}
+	}
+	stopped := false
+	wrapped := func(h int64, rec []types.Datum, cols []*table.Column) (bool, error) {
+		more, err := fn(h, rec, cols)
+		stopped = !more
+		return more, errors.Trace(err)
+	}
+	for i := start; i < len(t.partitions) && !stopped; i++ {
+		p := t.partitions[i]
+		key := p.FirstKey()
+		if i == start && startKey.HasPrefix(p.RecordPrefix()) {
+			key = startKey
+		}
+		if err := p.IterRecords(ctx, key, cols, wrapped); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// Seek implements table.Table Seek interface.
+func (t *partitionedTable) Seek(ctx context.Context, h int64) (int64, bool, error) {
+	var (
+		minHandle int64
+		found     bool
+	)
+	for _, p := range t.partitions {
+		handle, ok, err := p.Seek(ctx, h)
+		if err != nil {
+			return 0, false, errors.Trace(err)
+		}
+		if ok && (!found || handle < minHandle) {
+			minHandle, found = handle, true
+		}
+	}
+	return minHandle, found, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tables
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// The rows of a table are rebuilt into a new table when the primary key is added or dropped and the handles
+// of the rows are changed. The DDL job backfills the rows of the snapshot into the rebuilt table,
+// the following functions keep the rebuilt table consistent with the changes of the origin table.
+// In delete only state, the rows are only removed from the rebuilt table.
+// In write only and write reorganization state, the rows are also written into the rebuilt table.
+
+// rebuildingWritable checks whether the rows should be written into the rebuilt table.
+func (t *Table) rebuildingWritable() bool {
+	state := t.meta.RebuildInfo.State
+	return state == model.StateWriteOnly || state == model.StateWriteReorganization
+}
+
+// rebuildingHandle returns the handle of the row in the rebuilt table.
+func (t *Table) rebuildingHandle(h int64, r []types.Datum) int64 {
+	rt := t.rebuilding
+	if !rt.meta.PKIsHandle {
+		return h
+	}
+	for _, col := range rt.Cols() {
+		if col.IsPKHandleColumn(rt.meta) {
+			return r[col.Offset].GetInt64()
+		}
+	}
+	return h
+}
+
+// addRebuildingRecord writes the row and its index entries into the rebuilt table.
+func (t *Table) addRebuildingRecord(ctx context.Context, h int64, r []types.Datum) error {
+	if t.rebuilding == nil || !t.rebuildingWritable() {
+		return nil
+	}
+
+	rt := t.rebuilding
+	newHandle := t.rebuildingHandle(h, r)
+	txn := ctx.Txn()
+	colIDs := make([]int64, 0, len(r))
+	row := make([]types.Datum, 0, len(r))
+	for _, col := range rt.WritableCols() {
+		if rt.canSkip(col, r[col.Offset]) {
+			continue
+		}
+		colIDs = append(colIDs, col.ID)
+		row = append(row, r[col.Offset])
+	}
+	value, err := tablecodec.EncodeRow(row, colIDs, ctx.GetSessionVars().GetTimeZone())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = txn.Set(rt.RecordKey(newHandle), value); err != nil {
+		return errors.Trace(err)
+	}
+
+	for _, idx := range rt.WritableIndices() {
+		vals, err := idx.FetchValues(r)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// The constraints are checked by the origin table, the existing entry must belong to the same row.
+		dupHandle, err := idx.Create(txn, vals, newHandle)
+		if err != nil && !(kv.ErrKeyExists.Equal(err) && dupHandle == newHandle) {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// removeRebuildingRecord removes the row and its index entries from the rebuilt table.
+func (t *Table) removeRebuildingRecord(ctx context.Context, h int64, r []types.Datum) error {
+	if t.rebuilding == nil {
+		return nil
+	}
+
+	rt := t.rebuilding
+	newHandle := t.rebuildingHandle(h, r)
+	txn := ctx.Txn()
+	if err := txn.Delete(rt.RecordKey(newHandle)); err != nil {
+		return errors.Trace(err)
+	}
+	for _, idx := range rt.DeletableIndices() {
+		vals, err := idx.FetchValues(r)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// The row may have not been backfilled into the rebuilt table, so skip ErrNotExist error.
+		if err = idx.Delete(txn, vals, newHandle); err != nil && !kv.ErrNotExist.Equal(err) {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// updateRebuildingRecord updates the row in the rebuilt table, the handle of the row may be changed.
+func (t *Table) updateRebuildingRecord(ctx context.Context, h int64, oldData, newData []types.Datum) error {
+	if t.rebuilding == nil {
+		return nil
+	}
+	if err := t.removeRebuildingRecord(ctx, h, oldData); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(t.addRebuildingRecord(ctx, h, newData))
+}
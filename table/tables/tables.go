@@ -43,6 +43,9 @@ type Table struct {
 	Name    model.CIStr
 	Columns []*table.Column
 
+	// physicalID is the ID used to encode the data, it's the partition ID for a partition,
+	// and it equals to ID for a non-partitioned table.
+	physicalID      int64
 	publicColumns   []*table.Column
 	writableColumns []*table.Column
 	indices         []table.Index
@@ -50,6 +53,9 @@ type Table struct {
 	indexPrefix     kv.Key
 	alloc           autoid.Allocator
 	meta            *model.TableInfo
+
+	// rebuilding is the table which is rebuilt from the rows of this table, it's nil if the table isn't being rebuilt.
+	rebuilding *Table
 }
 
 // MockTableFromMeta only serves for test.
@@ -103,6 +109,16 @@ func TableFromMeta(alloc autoid.Allocator, tblInfo *model.TableInfo) (table.Tabl
 	}
 
 	t.meta = tblInfo
+	if tblInfo.RebuildInfo != nil {
+		rt, err := TableFromMeta(alloc, tblInfo.RebuildingTableInfo())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		t.rebuilding = rt.(*Table)
+	}
+	if tblInfo.Partition != nil {
+		return newPartitionedTable(t, tblInfo)
+	}
 	return t, nil
 }
 
@@ -110,6 +126,7 @@ func TableFromMeta(alloc autoid.Allocator, tblInfo *model.TableInfo) (table.Tabl
 func newTable(tableID int64, cols []*table.Column, alloc autoid.Allocator) *Table {
 	t := &Table{
 		ID:           tableID,
+		physicalID:   tableID,
 		recordPrefix: tablecodec.GenTableRecordPrefix(tableID),
 		indexPrefix:  tablecodec.GenTableIndexPrefix(tableID),
 		alloc:        alloc,
@@ -187,6 +204,11 @@ func (t *Table) WritableCols() []*table.Column {
 	return writableColumns[0 : maxOffset+1]
 }
 
+// GetPhysicalID implements table.PhysicalTable GetPhysicalID interface.
+func (t *Table) GetPhysicalID() int64 {
+	return t.physicalID
+}
+
 // RecordPrefix implements table.Table RecordPrefix interface.
 func (t *Table) RecordPrefix() kv.Key {
 	return t.recordPrefix
@@ -215,7 +237,15 @@ func (t *Table) UpdateRecord(ctx context.Context, h int64, oldData, newData []ty
 	bs := kv.NewBufferStore(txn)
 
 	// rebuild index
-	err := t.rebuildIndices(bs, h, touched, oldData, newData)
+	oldRow, err := t.fillWritableRow(ctx, oldData)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	newRow, err := t.fillWritableRow(ctx, newData)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = t.rebuildIndices(bs, h, touched, oldRow, newRow)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -232,7 +262,13 @@ func (t *Table) UpdateRecord(ctx context.Context, h int64, oldData, newData []ty
 
 	for _, col := range t.WritableCols() {
 		var value types.Datum
-		if col.State != model.StatePublic {
+		if col.ChangeStateInfo != nil && col.State != model.StatePublic {
+			// If col is the changing column of a modify column job, convert the value from the origin column.
+			value, err = t.castChangingColumnValue(ctx, col, newData)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		} else if col.State != model.StatePublic {
 			// If col is in write only or write reorganization state
 			// and the value is not default, keep the original value.
 			value, err = table.GetColOriginDefaultValue(ctx, col.ToInfo())
@@ -271,6 +307,9 @@ func (t *Table) UpdateRecord(ctx context.Context, h int64, oldData, newData []ty
 	if err = bs.SaveTo(txn); err != nil {
 		return errors.Trace(err)
 	}
+	if err = t.updateRebuildingRecord(ctx, h, oldData, newData); err != nil {
+		return errors.Trace(err)
+	}
 	if shouldWriteBinlog(ctx) {
 		err = t.addUpdateBinlog(ctx, binlogOldRow, binlogNewRow, binlogColIDs)
 		if err != nil {
@@ -283,7 +322,8 @@ func (t *Table) UpdateRecord(ctx context.Context, h int64, oldData, newData []ty
 func (t *Table) rebuildIndices(rm kv.RetrieverMutator, h int64, touched []bool, oldData []types.Datum, newData []types.Datum) error {
 	for _, idx := range t.DeletableIndices() {
 		for _, ic := range idx.Meta().Columns {
-			if !touched[ic.Offset] {
+			// The non-public columns can't be updated.
+			if ic.Offset >= len(touched) || !touched[ic.Offset] {
 				continue
 			}
 			oldVs, err := idx.FetchValues(oldData)
@@ -298,13 +338,16 @@ func (t *Table) rebuildIndices(rm kv.RetrieverMutator, h int64, touched []bool,
 	}
 	for _, idx := range t.WritableIndices() {
 		for _, ic := range idx.Meta().Columns {
-			if !touched[ic.Offset] {
+			if ic.Offset >= len(touched) || !touched[ic.Offset] {
 				continue
 			}
 			newVs, err := idx.FetchValues(newData)
 			if err != nil {
 				return errors.Trace(err)
 			}
+			if err = checkPrimaryIndexNotNull(idx, newVs); err != nil {
+				return errors.Trace(err)
+			}
 			if err := t.buildIndexForRow(rm, h, newVs, idx); err != nil {
 				return errors.Trace(err)
 			}
@@ -330,7 +373,11 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 			return 0, errors.Trace(err)
 		}
 	}
+	return t.addRecord(ctx, recordID, r)
+}
 
+// addRecord adds a row with the given handle.
+func (t *Table) addRecord(ctx context.Context, recordID int64, r []types.Datum) (int64, error) {
 	txn := ctx.Txn()
 	bs := kv.NewBufferStore(txn)
 
@@ -340,7 +387,11 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 	}
 
 	// Insert new entries into indices.
-	h, err := t.addIndices(ctx, recordID, r, bs)
+	writableRow, err := t.fillWritableRow(ctx, r)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	h, err := t.addIndices(ctx, recordID, writableRow, bs)
 	if err != nil {
 		return h, errors.Trace(err)
 	}
@@ -352,7 +403,13 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 
 	for _, col := range t.WritableCols() {
 		var value types.Datum
-		if col.State != model.StatePublic {
+		if col.ChangeStateInfo != nil && col.State != model.StatePublic {
+			// If col is the changing column of a modify column job, convert the value from the origin column.
+			value, err = t.castChangingColumnValue(ctx, col, r)
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+		} else if col.State != model.StatePublic {
 			// If col is in write only or write reorganization state, we must add it with its default value.
 			value, err = table.GetColOriginDefaultValue(ctx, col.ToInfo())
 			if err != nil {
@@ -378,6 +435,9 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 	if err = bs.SaveTo(txn); err != nil {
 		return 0, errors.Trace(err)
 	}
+	if err = t.addRebuildingRecord(ctx, recordID, r); err != nil {
+		return 0, errors.Trace(err)
+	}
 	if shouldWriteBinlog(ctx) {
 		// For insert, TiDB and Binlog can use same row and schema.
 		binlogRow = row
@@ -392,6 +452,40 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 	return recordID, nil
 }
 
+// fillWritableRow fills the values of the non-public writable columns which aren't in the row, so the indices
+// being added with the columns can fetch their values. The columns can't be written by users before they are public,
+// so their values are the origin default values.
+func (t *Table) fillWritableRow(ctx context.Context, r []types.Datum) ([]types.Datum, error) {
+	cols := t.WritableCols()
+	if len(r) >= len(cols) {
+		return r, nil
+	}
+	row := make([]types.Datum, len(cols))
+	copy(row, r)
+	for _, col := range cols[len(r):] {
+		if col == nil {
+			continue
+		}
+		var err error
+		if col.ChangeStateInfo != nil {
+			row[col.Offset], err = t.castChangingColumnValue(ctx, col, r)
+		} else {
+			row[col.Offset], err = table.GetColOriginDefaultValue(ctx, col.ToInfo())
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return row, nil
+}
+
+// castChangingColumnValue gets the value of the changing column from the value of the column it depends on.
+func (t *Table) castChangingColumnValue(ctx context.Context, col *table.Column, r []types.Datum) (types.Datum, error) {
+	depCol := t.Cols()[col.ChangeStateInfo.DependencyColumnOffset]
+	value, err := table.CastColumnValue(ctx, r[depCol.Offset], depCol.ToInfo(), col.ToInfo())
+	return value, errors.Trace(err)
+}
+
 // genIndexKeyStr generates index content string representation.
 func (t *Table) genIndexKeyStr(colVals []types.Datum) (string, error) {
 	// Pass pre-composed error to txn.
@@ -435,6 +529,9 @@ func (t *Table) addIndices(ctx context.Context, recordID int64, r []types.Datum,
 		if err2 != nil {
 			return 0, errors.Trace(err2)
 		}
+		if err2 = checkPrimaryIndexNotNull(v, colVals); err2 != nil {
+			return 0, errors.Trace(err2)
+		}
 		var dupKeyErr error
 		if !skipCheck && (v.Meta().Unique || v.Meta().Primary) {
 			entryKey, err1 := t.genIndexKeyStr(colVals)
@@ -455,6 +552,20 @@ func (t *Table) addIndices(ctx context.Context, recordID int64, r []types.Datum,
 	return 0, nil
 }
 
+// checkPrimaryIndexNotNull checks the values of the primary index are not null.
+// The columns may not have the not null flag yet when the primary key is being added.
+func checkPrimaryIndexNotNull(idx table.Index, vals []types.Datum) error {
+	if !idx.Meta().Primary {
+		return nil
+	}
+	for i, val := range vals {
+		if val.IsNull() {
+			return table.ErrColumnCantNull.Gen("Column %s can't be null.", idx.Meta().Columns[i].Name)
+		}
+	}
+	return nil
+}
+
 // RowWithCols implements table.Table RowWithCols interface.
 func (t *Table) RowWithCols(ctx context.Context, h int64, cols []*table.Column) ([]types.Datum, error) {
 	// Get raw row data from kv.
@@ -520,7 +631,15 @@ func (t *Table) RemoveRecord(ctx context.Context, h int64, r []types.Datum) erro
 	if err != nil {
 		return errors.Trace(err)
 	}
-	err = t.removeRowIndices(ctx, h, r)
+	writableRow, err := t.fillWritableRow(ctx, r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = t.removeRowIndices(ctx, h, writableRow)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = t.removeRebuildingRecord(ctx, h, r)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -729,7 +848,7 @@ func (t *Table) RebaseAutoID(newBase int64, isSetStep bool) error {
 
 // Seek implements table.Table Seek interface.
 func (t *Table) Seek(ctx context.Context, h int64) (int64, bool, error) {
-	seekKey := tablecodec.EncodeRowKeyWithHandle(t.ID, h)
+	seekKey := t.RecordKey(h)
 	iter, err := ctx.Txn().Seek(seekKey)
 	if !iter.Valid() || !iter.Key().HasPrefix(t.RecordPrefix()) {
 		// No more records in the table, skip to the end.
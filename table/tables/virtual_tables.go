@@ -56,7 +56,15 @@ func (vt *VirtualTable) IterRecords(ctx context.Context, startKey kv.Key, cols [
 	if err != nil {
 		return errors.Trace(err)
 	}
-	for i, row := range rows {
+	for i, fullRow := range rows {
+		row := fullRow
+		// The columns may be pruned, so we only keep the required ones.
+		if len(cols) != len(fullRow) {
+			row = make([]types.Datum, len(cols))
+			for j, col := range cols {
+				row[j] = fullRow[col.Offset]
+			}
+		}
 		more, err := fn(int64(i), row, cols)
 		if err != nil {
 			return errors.Trace(err)
@@ -83,6 +83,8 @@ const (
 	ClassMockTikv
 	ClassJSON
 	ClassTiKV
+	ClassXServer
+	ClassUtil
 	// Add more as needed.
 )
 
@@ -110,6 +112,8 @@ var errClz2Str = map[ErrClass]string{
 	ClassMockTikv:      "mocktikv",
 	ClassJSON:          "json",
 	ClassTiKV:          "tikv",
+	ClassXServer:       "xserver",
+	ClassUtil:          "util",
 }
 
 // String implements fmt.Stringer interface.
@@ -350,7 +350,7 @@ func createServer() {
 			Addr:   fmt.Sprintf("%s:%d", cfg.XProtocol.XHost, cfg.XProtocol.XPort),
 			Socket: cfg.XProtocol.XSocket,
 		}
-		xsvr, err = xserver.NewServer(xcfg)
+		xsvr, err = xserver.NewServer(xcfg, driver)
 		terror.MustNil(err)
 	}
 }
@@ -383,12 +383,15 @@ func setupMetrics() {
 }
 
 func runServer() {
-	err := svr.Run()
-	terror.MustNil(err)
 	if cfg.XProtocol.XServer {
-		err := xsvr.Run()
-		terror.MustNil(err)
+		// The x server serves along with the server, it's closed before the server in the signal handler.
+		go func() {
+			err := xsvr.Run()
+			terror.MustNil(err)
+		}()
 	}
+	err := svr.Run()
+	terror.MustNil(err)
 }
 
 func cleanup() {
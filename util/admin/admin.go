@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -245,8 +246,15 @@ func checkIndexAndRecord(txn kv.Transaction, t table.Table, idx table.Index) err
 	defer it.Close()
 
 	cols := make([]*table.Column, len(idx.Meta().Columns))
+	var collations []string
+	if idx.Meta().HasCollationKeys() {
+		collations = make([]string, len(cols))
+	}
 	for i, col := range idx.Meta().Columns {
 		cols[i] = t.Cols()[col.Offset]
+		if collations != nil {
+			collations[i] = cols[i].Collate
+		}
 	}
 
 	for {
@@ -265,6 +273,8 @@ func checkIndexAndRecord(txn kv.Transaction, t table.Table, idx table.Index) err
 		if err != nil {
 			return errors.Trace(err)
 		}
+		// The index stores the sort keys of the strings in case-insensitive collations.
+		vals2 = codec.CollationKeys(vals2, collations)
 		if !reflect.DeepEqual(vals1, vals2) {
 			record1 := &RecordData{Handle: h, Values: vals1}
 			record2 := &RecordData{Handle: h, Values: vals2}
@@ -35,6 +35,18 @@ func (user *UserIdentity) String() string {
 	return fmt.Sprintf("%s@%s", user.Username, user.Hostname)
 }
 
+// RoleIdentity represents a role name.
+type RoleIdentity struct {
+	Username string
+	Hostname string
+}
+
+// String converts RoleIdentity to the format user@host.
+func (role *RoleIdentity) String() string {
+	// TODO: Escape username and hostname.
+	return fmt.Sprintf("`%s`@`%s`", role.Username, role.Hostname)
+}
+
 // CheckScrambledPassword check scrambled password received from client.
 // The new authentication is performed in following manner:
 //   SERVER:  public_seed=create_random_string()
@@ -65,6 +77,22 @@ func CheckScrambledPassword(salt, hpwd, auth []byte) bool {
 	return bytes.Equal(hpwd, Sha1Hash(hash))
 }
 
+// ScramblePassword computes the scrambled password from the plaintext password and the salt,
+// it is the reply the client sends in the authentication described in CheckScrambledPassword.
+// It is used when the plaintext password is received, e.g. by the PLAIN mechanism of X Protocol.
+func ScramblePassword(salt []byte, pwd string) []byte {
+	if len(pwd) == 0 {
+		return nil
+	}
+	stage1 := Sha1Hash([]byte(pwd))
+	stage2 := Sha1Hash(stage1)
+	hash := Sha1Hash(append(append([]byte{}, salt...), stage2...))
+	for i := range hash {
+		hash[i] ^= stage1[i]
+	}
+	return hash
+}
+
 // Sha1Hash is an util function to calculate sha1 hash.
 func Sha1Hash(bs []byte) []byte {
 	crypt := sha1.New()
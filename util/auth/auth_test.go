@@ -14,10 +14,16 @@
 package auth
 
 import (
+	"testing"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/util/testleak"
 )
 
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
 var _ = Suite(&testAuthSuite{})
 
 type testAuthSuite struct {
@@ -48,3 +54,50 @@ func (s *testAuthSuite) TestCheckScramble(c *C) {
 	res := CheckScrambledPassword(salt, hpwd, auth)
 	c.Assert(res, IsTrue)
 }
+
+func (s *testAuthSuite) TestScramblePassword(c *C) {
+	defer testleak.AfterTest(c)()
+	salt := []byte{85, 92, 45, 22, 58, 79, 107, 6, 122, 125, 58, 80, 12, 90, 103, 32, 90, 10, 74, 82}
+	auth := []byte{24, 180, 183, 225, 166, 6, 81, 102, 70, 248, 199, 143, 91, 204, 169, 9, 161, 171, 203, 33}
+	c.Assert(ScramblePassword(salt, "abc"), DeepEquals, auth)
+	c.Assert(ScramblePassword(salt, ""), IsNil)
+}
+
+func (s *testAuthSuite) TestSha256Crypt(c *C) {
+	defer testleak.AfterTest(c)()
+	// The results are the same as `openssl passwd -5`.
+	c.Assert(string(sha256Crypt([]byte("Hello world!"), []byte("saltstring"), 5000)), Equals,
+		"5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5")
+	c.Assert(string(sha256Crypt([]byte("Hello world!"), []byte("saltstringsaltst"), 10000)), Equals,
+		"3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA")
+	c.Assert(string(sha256Crypt([]byte("we have a short salt string but not a short password"), []byte("roundstoolow"), 1000)), Equals,
+		"p20OiWa5GmKDHyeQuvXKgAXjYozUMLD5yQL6RzRpZCC")
+}
+
+func (s *testAuthSuite) TestSha2Password(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(NewSha2Password(""), Equals, "")
+	pwhash := NewSha2Password("abc")
+	c.Assert(pwhash, HasLen, 70)
+	c.Assert(pwhash[:7], Equals, "$A$005$")
+	c.Assert(NewSha2Password("abc"), Not(Equals), pwhash)
+	ok, err := CheckSha2Password(pwhash, "abc")
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	ok, err = CheckSha2Password(pwhash, "abd")
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+	_, err = CheckSha2Password("*23AE809DDACAF96AF0FD78ED04B6A265E05AA257", "123")
+	c.Assert(err, NotNil)
+}
+
+func (s *testAuthSuite) TestSha2Scramble(c *C) {
+	defer testleak.AfterTest(c)()
+	nonce := []byte{85, 92, 45, 22, 58, 79, 107, 6, 122, 125, 58, 80, 12, 90, 103, 32, 90, 10, 74, 82}
+	scramble := Sha2Scramble(nonce, "abc")
+	c.Assert(scramble, HasLen, 32)
+	c.Assert(CheckSha2Scramble(Sha2Digest("abc"), nonce, scramble), IsTrue)
+	c.Assert(CheckSha2Scramble(Sha2Digest("abd"), nonce, scramble), IsFalse)
+	c.Assert(CheckSha2Scramble(Sha2Digest("abc"), nonce, scramble[1:]), IsFalse)
+	c.Assert(Sha2Scramble(nonce, ""), IsNil)
+}
@@ -0,0 +1,216 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+
+	"github.com/juju/errors"
+)
+
+// The password hash of caching_sha2_password and sha256_password is stored in the same
+// format as MySQL, which is "$A$" + rounds/1000 in 3 hex digits + "$" + salt + sha256-crypt digest.
+// See https://www.akkadia.org/drepper/SHA-crypt.txt for the sha256-crypt algorithm.
+const (
+	sha2PasswordPrefix     = "$A$"
+	sha2SaltLength         = 20
+	sha2DigestLength       = 43
+	sha2IterationsPerCount = 1000
+	// sha2DefaultIterations is the same as the default of MySQL.
+	sha2DefaultIterations = 5000
+)
+
+const crypt64Chars = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// saltChars is used to generate the salt. The salt only contains letters and digits,
+// so the hash can be used in SQL statements without escaping.
+const saltChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// NewSha2Password encodes the plaintext password to the hash used by caching_sha2_password and sha256_password.
+func NewSha2Password(pwd string) string {
+	if len(pwd) == 0 {
+		return ""
+	}
+	salt := make([]byte, sha2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+	for i := range salt {
+		salt[i] = saltChars[int(salt[i])%len(saltChars)]
+	}
+	digest := sha256Crypt([]byte(pwd), salt, sha2DefaultIterations)
+	return fmt.Sprintf("%s%03X$%s%s", sha2PasswordPrefix, sha2DefaultIterations/sha2IterationsPerCount, salt, digest)
+}
+
+// CheckSha2Password checks the plaintext password against the hash generated by NewSha2Password.
+func CheckSha2Password(pwhash string, pwd string) (bool, error) {
+	// $A$005$ + salt + digest
+	if len(pwhash) != len(sha2PasswordPrefix)+4+sha2SaltLength+sha2DigestLength ||
+		pwhash[:len(sha2PasswordPrefix)] != sha2PasswordPrefix || pwhash[len(sha2PasswordPrefix)+3] != '$' {
+		return false, errors.Errorf("invalid sha2 password hash")
+	}
+	pos := len(sha2PasswordPrefix)
+	count, err := strconv.ParseUint(pwhash[pos:pos+3], 16, 32)
+	if err != nil {
+		return false, errors.Errorf("invalid sha2 password hash")
+	}
+	pos += 4
+	salt := []byte(pwhash[pos : pos+sha2SaltLength])
+	pos += sha2SaltLength
+	digest := sha256Crypt([]byte(pwd), salt, int(count)*sha2IterationsPerCount)
+	return subtle.ConstantTimeCompare(digest, []byte(pwhash[pos:])) == 1, nil
+}
+
+// Sha2Digest returns SHA256(SHA256(pwd)), it's cached by the server for the fast authentication
+// of caching_sha2_password.
+func Sha2Digest(pwd string) []byte {
+	stage1 := sha256.Sum256([]byte(pwd))
+	stage2 := sha256.Sum256(stage1[:])
+	return stage2[:]
+}
+
+// Sha2Scramble computes the scrambled password sent by the client in the fast authentication of
+// caching_sha2_password. It's XOR(SHA256(pwd), SHA256(SHA256(SHA256(pwd)), nonce)).
+func Sha2Scramble(nonce []byte, pwd string) []byte {
+	if len(pwd) == 0 {
+		return nil
+	}
+	stage1 := sha256.Sum256([]byte(pwd))
+	scramble := sha2XORHash(Sha2Digest(pwd), nonce)
+	for i := range scramble {
+		scramble[i] ^= stage1[i]
+	}
+	return scramble
+}
+
+// CheckSha2Scramble checks the scramble computed by Sha2Scramble, digest is the Sha2Digest of the password.
+func CheckSha2Scramble(digest, nonce, scramble []byte) bool {
+	if len(scramble) != sha256.Size {
+		return false
+	}
+	stage1 := sha2XORHash(digest, nonce)
+	for i := range stage1 {
+		stage1[i] ^= scramble[i]
+	}
+	stage2 := sha256.Sum256(stage1)
+	return subtle.ConstantTimeCompare(stage2[:], digest) == 1
+}
+
+func sha2XORHash(digest, nonce []byte) []byte {
+	h := sha256.New()
+	h.Write(digest)
+	h.Write(nonce)
+	return h.Sum(nil)
+}
+
+// sha256Crypt computes the sha256-crypt digest of key, the result is encoded in 43 characters.
+func sha256Crypt(key, salt []byte, rounds int) []byte {
+	// Digest B.
+	h := sha256.New()
+	h.Write(key)
+	h.Write(salt)
+	h.Write(key)
+	digestB := h.Sum(nil)
+
+	// Digest A.
+	h.Reset()
+	h.Write(key)
+	h.Write(salt)
+	i := len(key)
+	for ; i > sha256.Size; i -= sha256.Size {
+		h.Write(digestB)
+	}
+	h.Write(digestB[:i])
+	for i = len(key); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write(digestB)
+		} else {
+			h.Write(key)
+		}
+	}
+	digestA := h.Sum(nil)
+
+	// Byte sequence P.
+	h.Reset()
+	for i = 0; i < len(key); i++ {
+		h.Write(key)
+	}
+	seqP := repeatBytes(h.Sum(nil), len(key))
+
+	// Byte sequence S.
+	h.Reset()
+	for i = 0; i < 16+int(digestA[0]); i++ {
+		h.Write(salt)
+	}
+	seqS := repeatBytes(h.Sum(nil), len(salt))
+
+	digestC := digestA
+	for i = 0; i < rounds; i++ {
+		h.Reset()
+		if i&1 != 0 {
+			h.Write(seqP)
+		} else {
+			h.Write(digestC)
+		}
+		if i%3 != 0 {
+			h.Write(seqS)
+		}
+		if i%7 != 0 {
+			h.Write(seqP)
+		}
+		if i&1 != 0 {
+			h.Write(digestC)
+		} else {
+			h.Write(seqP)
+		}
+		digestC = h.Sum(digestC[:0])
+	}
+
+	c := digestC
+	buf := make([]byte, 0, sha2DigestLength)
+	buf = appendCrypt64(buf, c[0], c[10], c[20], 4)
+	buf = appendCrypt64(buf, c[21], c[1], c[11], 4)
+	buf = appendCrypt64(buf, c[12], c[22], c[2], 4)
+	buf = appendCrypt64(buf, c[3], c[13], c[23], 4)
+	buf = appendCrypt64(buf, c[24], c[4], c[14], 4)
+	buf = appendCrypt64(buf, c[15], c[25], c[5], 4)
+	buf = appendCrypt64(buf, c[6], c[16], c[26], 4)
+	buf = appendCrypt64(buf, c[27], c[7], c[17], 4)
+	buf = appendCrypt64(buf, c[18], c[28], c[8], 4)
+	buf = appendCrypt64(buf, c[9], c[19], c[29], 4)
+	buf = appendCrypt64(buf, 0, c[31], c[30], 3)
+	return buf
+}
+
+// repeatBytes repeats b to the length n.
+func repeatBytes(b []byte, n int) []byte {
+	seq := make([]byte, 0, n)
+	for len(seq)+len(b) < n {
+		seq = append(seq, b...)
+	}
+	return append(seq, b[:n-len(seq)]...)
+}
+
+func appendCrypt64(buf []byte, b2, b1, b0 byte, n int) []byte {
+	w := uint(b2)<<16 | uint(b1)<<8 | uint(b0)
+	for ; n > 0; n-- {
+		buf = append(buf, crypt64Chars[w&0x3f])
+		w >>= 6
+	}
+	return buf
+}
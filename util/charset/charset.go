@@ -47,6 +47,7 @@ var charsetInfos = []*Charset{
 	{CharsetUTF8MB4, CollationUTF8MB4, make(map[string]*Collation), "UTF-8 Unicode", 4},
 	{CharsetASCII, CollationASCII, make(map[string]*Collation), "US ASCII", 1},
 	{CharsetLatin1, CollationLatin1, make(map[string]*Collation), "Latin1", 1},
+	{CharsetGBK, CollationGBK, make(map[string]*Collation), "GBK Simplified Chinese", 2},
 	{CharsetBin, CollationBin, make(map[string]*Collation), "binary", 1},
 }
 
@@ -165,6 +166,17 @@ func GetCharsetInfoByID(coID int) (string, string, error) {
 	return "", "", errors.Errorf("Unknown charset id %d", coID)
 }
 
+// GetCollationByName returns the collation by its name.
+func GetCollationByName(name string) (*Collation, error) {
+	name = strings.ToLower(name)
+	for _, collation := range collations {
+		if collation.Name == name {
+			return collation, nil
+		}
+	}
+	return nil, errors.Errorf("Unknown collation %s", name)
+}
+
 // GetCollations returns a list for all collations.
 func GetCollations() []*Collation {
 	return collations
@@ -191,6 +203,11 @@ const (
 	CharsetLatin1 = "latin1"
 	// CollationLatin1 is the default collation for CharsetLatin1.
 	CollationLatin1 = "latin1_bin"
+	// CharsetGBK is a multi-byte charset for simplified Chinese, the strings are converted
+	// from and to utf8 at the protocol boundary.
+	CharsetGBK = "gbk"
+	// CollationGBK is the default collation for CharsetGBK.
+	CollationGBK = "gbk_chinese_ci"
 )
 
 var collations = []*Collation{
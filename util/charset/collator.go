@@ -0,0 +1,196 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package charset
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Collator compares the strings by a collation.
+// The strings are always kept in utf8, whatever the charset is.
+type Collator interface {
+	// Compare returns an integer comparing the two strings by the collation.
+	Compare(a, b string) int
+	// Key returns the sort key of the string. The keys of two strings are equal in bytes if and only if
+	// the strings are equal by the collation, and the keys are in the same order as the strings.
+	// The key is a valid utf8 string which is equal to the original string by the collation.
+	Key(s string) []byte
+	// Fold maps every character of the string to the character representing its weight,
+	// it's used to match the characters by the collation, e.g. in LIKE.
+	Fold(s string) string
+}
+
+var (
+	binCollatorInstance       Collator = binCollator{}
+	generalCICollatorInstance Collator = generalCICollator{}
+	unicodeCICollatorInstance Collator = unicodeCICollator{}
+)
+
+// GetCollator returns the collator of the collation.
+// The *_unicode_ci collations are compared by the unicode case folding and decomposition,
+// the other *_ci collations are compared like *_general_ci, and the rest are compared in binary.
+func GetCollator(collate string) Collator {
+	collate = strings.ToLower(collate)
+	if !strings.HasSuffix(collate, "_ci") {
+		return binCollatorInstance
+	}
+	if strings.Contains(collate, "_unicode") {
+		return unicodeCICollatorInstance
+	}
+	return generalCICollatorInstance
+}
+
+// IsCICollation returns if the collation is case-insensitive, so the strings which are not
+// equal in binary may be equal by the collation.
+func IsCICollation(collate string) bool {
+	return GetCollator(collate) != binCollatorInstance
+}
+
+// binCollator compares the strings in binary.
+type binCollator struct{}
+
+func (binCollator) Compare(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+func (binCollator) Key(s string) []byte {
+	return []byte(s)
+}
+
+func (binCollator) Fold(s string) string {
+	return s
+}
+
+// generalCICollator implements *_general_ci, every character is compared by the upper case of its
+// base character without the accents, the characters out of the BMP are all equal to U+FFFD.
+// The trailing spaces are ignored.
+type generalCICollator struct{}
+
+func (generalCICollator) Compare(a, b string) int {
+	a, b = trimTrailingSpaces(a), trimTrailingSpaces(b)
+	for len(a) > 0 && len(b) > 0 {
+		ra, sizeA := utf8.DecodeRuneInString(a)
+		rb, sizeB := utf8.DecodeRuneInString(b)
+		wa, wb := generalCIWeight(ra), generalCIWeight(rb)
+		if wa != wb {
+			if wa < wb {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[sizeA:], b[sizeB:]
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+func (c generalCICollator) Key(s string) []byte {
+	return []byte(c.Fold(trimTrailingSpaces(s)))
+}
+
+func (generalCICollator) Fold(s string) string {
+	return strings.Map(generalCIWeight, s)
+}
+
+// generalCIWeight returns the character representing the weight of r in *_general_ci.
+func generalCIWeight(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'a' <= r && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		return r
+	}
+	if r > 0xFFFF {
+		return unicode.ReplacementChar
+	}
+	if r == 'ß' {
+		return 'S'
+	}
+	if d := norm.NFD.PropertiesString(string(r)).Decomposition(); len(d) > 0 {
+		r, _ = utf8.DecodeRune(d)
+	}
+	return unicode.ToUpper(r)
+}
+
+// unicodeCICollator implements *_unicode_ci. The characters are decomposed by compatibility, the accents
+// are removed and the rest are case folded, so a character may be equal to several characters, e.g. 'ß' = 'ss'.
+// The characters out of the BMP are all equal to U+FFFD and the trailing spaces are ignored.
+type unicodeCICollator struct{}
+
+func (c unicodeCICollator) Compare(a, b string) int {
+	return strings.Compare(c.Fold(trimTrailingSpaces(a)), c.Fold(trimTrailingSpaces(b)))
+}
+
+func (c unicodeCICollator) Key(s string) []byte {
+	return []byte(c.Fold(trimTrailingSpaces(s)))
+}
+
+func (unicodeCICollator) Fold(s string) string {
+	isASCII := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			isASCII = false
+			break
+		}
+	}
+	if isASCII {
+		return strings.ToUpper(s)
+	}
+	buf := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xFFFF {
+			buf = appendRune(buf, unicode.ReplacementChar)
+			continue
+		}
+		if r == 'ß' {
+			buf = append(buf, "SS"...)
+			continue
+		}
+		d := norm.NFKD.PropertiesString(string(r)).Decomposition()
+		if len(d) == 0 {
+			buf = appendRune(buf, unicode.ToUpper(r))
+			continue
+		}
+		for len(d) > 0 {
+			dr, size := utf8.DecodeRune(d)
+			d = d[size:]
+			if unicode.Is(unicode.Mn, dr) {
+				continue
+			}
+			buf = appendRune(buf, unicode.ToUpper(dr))
+		}
+	}
+	return string(buf)
+}
+
+func appendRune(buf []byte, r rune) []byte {
+	var tmp [utf8.UTFMax]byte
+	n := utf8.EncodeRune(tmp[:], r)
+	return append(buf, tmp[:n]...)
+}
+
+// trimTrailingSpaces removes the trailing spaces, because the strings are padded with spaces
+// to the same length when they are compared.
+func trimTrailingSpaces(s string) string {
+	return strings.TrimRight(s, " ")
+}
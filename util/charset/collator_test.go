@@ -0,0 +1,93 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package charset
+
+import (
+	"bytes"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func (s *testCharsetSuite) TestGetCollator(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		collate string
+		ci      bool
+	}{
+		{"utf8_bin", false},
+		{"binary", false},
+		{"", false},
+		{"utf8mb4_general_ci", true},
+		{"UTF8_GENERAL_CI", true},
+		{"utf8mb4_unicode_ci", true},
+		{"latin1_swedish_ci", true},
+		{"gbk_chinese_ci", true},
+	}
+	for _, tt := range tests {
+		c.Assert(IsCICollation(tt.collate), Equals, tt.ci, Commentf("collate: %s", tt.collate))
+	}
+	c.Assert(GetCollator("utf8_unicode_ci"), Equals, unicodeCICollatorInstance)
+	c.Assert(GetCollator("utf8_general_ci"), Equals, generalCICollatorInstance)
+}
+
+func (s *testCharsetSuite) TestCollatorCompare(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		collate string
+		a       string
+		b       string
+		cmp     int
+	}{
+		{"utf8_bin", "a", "A", 1},
+		{"utf8_bin", "a", "a ", -1},
+		{"utf8_general_ci", "a", "A", 0},
+		{"utf8_general_ci", "a", "a  ", 0},
+		{"utf8_general_ci", "a", "b", -1},
+		{"utf8_general_ci", "B", "a", 1},
+		{"utf8_general_ci", "ab", "a", 1},
+		{"utf8_general_ci", "café", "CAFE", 0},
+		{"utf8_general_ci", "ß", "s", 0},
+		{"utf8_general_ci", "ß", "ss", -1},
+		{"utf8_general_ci", "😀", "😃", 0},
+		{"utf8_unicode_ci", "ß", "ss", 0},
+		{"utf8_unicode_ci", "Straße", "STRASSE", 0},
+		{"utf8_unicode_ci", "ﬁ", "FI", 0},
+		{"utf8_unicode_ci", "Ä", "a ", 0},
+		{"utf8_unicode_ci", "a", "b", -1},
+	}
+	for _, tt := range tests {
+		collator := GetCollator(tt.collate)
+		comment := Commentf("collate: %s, a: %s, b: %s", tt.collate, tt.a, tt.b)
+		c.Assert(collator.Compare(tt.a, tt.b), Equals, tt.cmp, comment)
+		c.Assert(collator.Compare(tt.b, tt.a), Equals, -tt.cmp, comment)
+		// The keys are in the same order as the strings.
+		c.Assert(bytes.Compare(collator.Key(tt.a), collator.Key(tt.b)), Equals, tt.cmp, comment)
+	}
+}
+
+func (s *testCharsetSuite) TestCollatorKey(c *C) {
+	defer testleak.AfterTest(c)()
+	for _, collate := range []string{"utf8_general_ci", "utf8_unicode_ci"} {
+		collator := GetCollator(collate)
+		for _, str := range []string{"", "abc ", "Café", "Straße", "中文", "😀"} {
+			key := collator.Key(str)
+			// The key is equal to the string by the collation, and it's its own key.
+			c.Assert(collator.Compare(str, string(key)), Equals, 0, Commentf("collate: %s, str: %s", collate, str))
+			c.Assert(collator.Key(string(key)), DeepEquals, key, Commentf("collate: %s, str: %s", collate, str))
+		}
+	}
+	c.Assert(GetCollator("utf8_general_ci").Fold("aé "), Equals, "AE ")
+	c.Assert(GetCollator("utf8_bin").Key("aé "), DeepEquals, []byte("aé "))
+}
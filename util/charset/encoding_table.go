@@ -35,6 +35,17 @@ func Lookup(label string) (e encoding.Encoding, name string) {
 	return enc.e, enc.name
 }
 
+// Encoding returns the encoding to convert the strings of the charset from and to utf8 at the protocol boundary.
+// It returns nil if the strings of the charset are kept as they are, e.g. utf8, utf8mb4, ascii and binary.
+func Encoding(cs string) encoding.Encoding {
+	switch strings.ToLower(cs) {
+	case CharsetGBK, CharsetLatin1:
+		e, _ := Lookup(cs)
+		return e
+	}
+	return nil
+}
+
 var encodings = map[string]struct {
 	e    encoding.Encoding
 	name string
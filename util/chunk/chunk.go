@@ -0,0 +1,219 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// Chunk stores multiple rows of data in columnar format, every column is stored in a Column.
+// The values are appended in compact format and can be accessed without decoding,
+// the allocated memory is reused after the Chunk is reset.
+type Chunk struct {
+	fields  []*types.FieldType
+	columns []*Column
+	// numVirtualRows is the number of rows of the Chunk which has no column.
+	numVirtualRows int
+}
+
+// NewChunk creates a new Chunk with the field types of the columns.
+func NewChunk(fields []*types.FieldType) *Chunk {
+	chk := &Chunk{
+		fields:  fields,
+		columns: make([]*Column, 0, len(fields)),
+	}
+	for _, ft := range fields {
+		chk.columns = append(chk.columns, newColumn(getElemLen(ft)))
+	}
+	return chk
+}
+
+// Reset resets the Chunk, so the memory it allocated can be reused.
+func (c *Chunk) Reset() {
+	for _, col := range c.columns {
+		col.reset()
+	}
+	c.numVirtualRows = 0
+}
+
+// NumCols returns the number of columns in the Chunk.
+func (c *Chunk) NumCols() int {
+	return len(c.columns)
+}
+
+// SetNumVirtualRows sets the number of rows of the Chunk which has no column.
+func (c *Chunk) SetNumVirtualRows(numVirtualRows int) {
+	c.numVirtualRows = numVirtualRows
+}
+
+// NumRows returns the number of rows in the Chunk.
+func (c *Chunk) NumRows() int {
+	if len(c.columns) == 0 {
+		return c.numVirtualRows
+	}
+	return c.columns[0].length
+}
+
+// Fields returns the field types of the columns.
+func (c *Chunk) Fields() []*types.FieldType {
+	return c.fields
+}
+
+// Column returns the column of the Chunk.
+func (c *Chunk) Column(colIdx int) *Column {
+	return c.columns[colIdx]
+}
+
+// GetRow returns the Row of the Chunk.
+func (c *Chunk) GetRow(rowIdx int) Row {
+	return Row{c: c, idx: rowIdx}
+}
+
+// AppendRow appends a row of another Chunk which has the same field types.
+func (c *Chunk) AppendRow(row Row) {
+	if len(c.columns) == 0 {
+		c.numVirtualRows++
+		return
+	}
+	for colIdx, col := range c.columns {
+		src := row.c.columns[colIdx]
+		if col.elemLen == src.elemLen {
+			col.appendCell(src, row.idx)
+			continue
+		}
+		d := row.GetDatum(colIdx)
+		c.AppendDatum(colIdx, &d)
+	}
+}
+
+// AppendColumn appends all the values of a column in another Chunk to the column.
+func (c *Chunk) AppendColumn(colIdx int, src *Chunk, srcColIdx int) {
+	col, srcCol := c.columns[colIdx], src.columns[srcColIdx]
+	for i := 0; i < srcCol.length; i++ {
+		if col.elemLen == srcCol.elemLen {
+			col.appendCell(srcCol, i)
+			continue
+		}
+		d := src.GetRow(i).GetDatum(srcColIdx)
+		c.AppendDatum(colIdx, &d)
+		col = c.columns[colIdx]
+	}
+}
+
+// AppendNull appends a null value to the column.
+func (c *Chunk) AppendNull(colIdx int) {
+	c.columns[colIdx].appendNull()
+}
+
+// AppendInt64 appends an int64 value to the column.
+func (c *Chunk) AppendInt64(colIdx int, i int64) {
+	c.columns[colIdx].appendInt64(i)
+}
+
+// AppendUint64 appends an uint64 value to the column.
+func (c *Chunk) AppendUint64(colIdx int, u uint64) {
+	c.columns[colIdx].appendInt64(int64(u))
+}
+
+// AppendFloat32 appends a float32 value to the column.
+func (c *Chunk) AppendFloat32(colIdx int, f float32) {
+	c.columns[colIdx].appendFloat32(f)
+}
+
+// AppendFloat64 appends a float64 value to the column.
+func (c *Chunk) AppendFloat64(colIdx int, f float64) {
+	c.columns[colIdx].appendFloat64(f)
+}
+
+// AppendString appends a string value to the column.
+func (c *Chunk) AppendString(colIdx int, s string) {
+	c.columns[colIdx].appendBytes([]byte(s))
+}
+
+// AppendBytes appends a bytes value to the column.
+func (c *Chunk) AppendBytes(colIdx int, b []byte) {
+	c.columns[colIdx].appendBytes(b)
+}
+
+// AppendMyDecimal appends a MyDecimal value to the column.
+func (c *Chunk) AppendMyDecimal(colIdx int, dec *types.MyDecimal) {
+	c.columns[colIdx].appendMyDecimal(dec)
+}
+
+// AppendDuration appends a Duration value to the column.
+func (c *Chunk) AppendDuration(colIdx int, dur types.Duration) {
+	c.columns[colIdx].appendDuration(dur)
+}
+
+// AppendDatum appends a Datum to the column.
+// If the kind of the Datum doesn't match the field type of the column, the column is changed to store Datums,
+// so the Datum can be got back without any conversion.
+func (c *Chunk) AppendDatum(colIdx int, d *types.Datum) {
+	col := c.columns[colIdx]
+	if d.IsNull() {
+		col.appendNull()
+		return
+	}
+	if !c.matchKind(colIdx, d.Kind()) {
+		col = c.toDatumColumn(colIdx)
+	}
+	switch col.elemLen {
+	case datumElemLen:
+		col.appendDatum(d)
+	case varElemLen:
+		col.appendBytes(d.GetBytes())
+	case 4:
+		col.appendFloat32(d.GetFloat32())
+	case sizeDuration:
+		col.appendDuration(d.GetMysqlDuration())
+	case sizeMyDecimal:
+		col.appendMyDecimal(d.GetMysqlDecimal())
+	default:
+		// The int64, uint64 and float64 values are all stored in the 8 bytes of the Datum.
+		col.appendInt64(d.GetInt64())
+	}
+}
+
+// matchKind checks whether the Datum kind can be stored in the column without conversion.
+func (c *Chunk) matchKind(colIdx int, kind byte) bool {
+	switch c.columns[colIdx].elemLen {
+	case datumElemLen:
+		return true
+	case varElemLen:
+		return kind == types.KindString || kind == types.KindBytes
+	}
+	switch c.fields[colIdx].Tp {
+	case mysql.TypeFloat:
+		return kind == types.KindFloat32
+	case mysql.TypeDouble:
+		return kind == types.KindFloat64
+	case mysql.TypeDuration:
+		return kind == types.KindMysqlDuration
+	case mysql.TypeNewDecimal:
+		return kind == types.KindMysqlDecimal
+	}
+	return kind == types.KindInt64 || kind == types.KindUint64
+}
+
+// toDatumColumn changes the column to store Datums.
+func (c *Chunk) toDatumColumn(colIdx int) *Column {
+	col := newColumn(datumElemLen)
+	for i := 0; i < c.columns[colIdx].length; i++ {
+		d := c.GetRow(i).GetDatum(colIdx)
+		col.appendDatum(&d)
+	}
+	c.columns[colIdx] = col
+	return col
+}
@@ -0,0 +1,184 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testChunkSuite{})
+
+type testChunkSuite struct{}
+
+func newFieldTypes() []*types.FieldType {
+	unsignedTp := types.NewFieldType(mysql.TypeLonglong)
+	unsignedTp.Flag |= mysql.UnsignedFlag
+	return []*types.FieldType{
+		types.NewFieldType(mysql.TypeLonglong),
+		unsignedTp,
+		types.NewFieldType(mysql.TypeFloat),
+		types.NewFieldType(mysql.TypeDouble),
+		types.NewFieldType(mysql.TypeVarchar),
+		types.NewFieldType(mysql.TypeBlob),
+		types.NewFieldType(mysql.TypeNewDecimal),
+		types.NewFieldType(mysql.TypeDuration),
+		types.NewFieldType(mysql.TypeDatetime),
+	}
+}
+
+func newDatumRow(i int) []types.Datum {
+	if i%3 == 2 {
+		return make([]types.Datum, 9)
+	}
+	return []types.Datum{
+		types.NewIntDatum(int64(-i)),
+		types.NewUintDatum(uint64(i)),
+		types.NewFloat32Datum(float32(i) + 0.5),
+		types.NewFloat64Datum(float64(i) + 0.25),
+		types.NewStringDatum(string(make([]byte, i))),
+		types.NewBytesDatum([]byte{byte(i), byte(i)}),
+		types.NewDecimalDatum(types.NewDecFromInt(int64(i))),
+		types.NewDurationDatum(types.Duration{Duration: time.Duration(i) * time.Second, Fsp: 2}),
+		types.NewDatum(types.Time{Time: types.FromDate(2017, 10, i%28+1, 0, 0, 0, 0), Type: mysql.TypeDatetime}),
+	}
+}
+
+func (s *testChunkSuite) TestAppendAndGet(c *C) {
+	defer testleak.AfterTest(c)()
+	chk := NewChunk(newFieldTypes())
+	for round := 0; round < 2; round++ {
+		chk.Reset()
+		c.Assert(chk.NumRows(), Equals, 0)
+		for i := 0; i < 100; i++ {
+			row := newDatumRow(i)
+			for colIdx := range row {
+				chk.AppendDatum(colIdx, &row[colIdx])
+			}
+		}
+		c.Assert(chk.NumCols(), Equals, 9)
+		c.Assert(chk.NumRows(), Equals, 100)
+		for i := 0; i < 100; i++ {
+			row := chk.GetRow(i)
+			expected := newDatumRow(i)
+			for colIdx, d := range row.GetDatumRow() {
+				c.Assert(d.Kind(), Equals, expected[colIdx].Kind(), Commentf("row %d column %d", i, colIdx))
+				cmp, err := d.CompareDatum(nil, &expected[colIdx])
+				c.Assert(err, IsNil)
+				c.Assert(cmp, Equals, 0, Commentf("row %d column %d", i, colIdx))
+			}
+			c.Assert(row.IsNull(0), Equals, i%3 == 2)
+			if i%3 != 2 {
+				c.Assert(row.GetInt64(0), Equals, int64(-i))
+				c.Assert(row.GetUint64(1), Equals, uint64(i))
+				c.Assert(row.GetFloat32(2), Equals, float32(i)+0.5)
+				c.Assert(row.GetFloat64(3), Equals, float64(i)+0.25)
+				c.Assert(row.GetString(4), Equals, string(make([]byte, i)))
+				c.Assert(row.GetBytes(5), DeepEquals, []byte{byte(i), byte(i)})
+				c.Assert(row.GetDuration(7).Fsp, Equals, 2)
+			}
+		}
+		c.Assert(chk.Column(0).NullCount(), Equals, 33)
+	}
+}
+
+func (s *testChunkSuite) TestTypedValues(c *C) {
+	defer testleak.AfterTest(c)()
+	chk := NewChunk([]*types.FieldType{
+		types.NewFieldType(mysql.TypeLonglong),
+		types.NewFieldType(mysql.TypeDouble),
+		types.NewFieldType(mysql.TypeFloat),
+	})
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			chk.AppendNull(0)
+			chk.AppendNull(1)
+			chk.AppendNull(2)
+			continue
+		}
+		chk.AppendInt64(0, int64(i))
+		chk.AppendFloat64(1, float64(i))
+		chk.AppendFloat32(2, float32(i))
+	}
+	ints, ok := chk.Column(0).Int64s()
+	c.Assert(ok, IsTrue)
+	c.Assert(ints, DeepEquals, []int64{0, 1, 2, 3, 4, 0, 6, 7, 8, 9})
+	reals, ok := chk.Column(1).Float64s()
+	c.Assert(ok, IsTrue)
+	c.Assert(reals[9], Equals, float64(9))
+	float32s, ok := chk.Column(2).Float32s()
+	c.Assert(ok, IsTrue)
+	c.Assert(float32s[8], Equals, float32(8))
+	_, ok = chk.Column(2).Int64s()
+	c.Assert(ok, IsFalse)
+	c.Assert(chk.Column(0).IsNull(5), IsTrue)
+	c.Assert(chk.Column(0).IsNull(6), IsFalse)
+}
+
+func (s *testChunkSuite) TestAppendRow(c *C) {
+	defer testleak.AfterTest(c)()
+	src := NewChunk(newFieldTypes())
+	for i := 0; i < 20; i++ {
+		row := newDatumRow(i)
+		for colIdx := range row {
+			src.AppendDatum(colIdx, &row[colIdx])
+		}
+	}
+	dst := NewChunk(newFieldTypes())
+	for i := 0; i < src.NumRows(); i += 2 {
+		dst.AppendRow(src.GetRow(i))
+	}
+	c.Assert(dst.NumRows(), Equals, 10)
+	for i := 0; i < dst.NumRows(); i++ {
+		expected := newDatumRow(i * 2)
+		for colIdx, d := range dst.GetRow(i).GetDatumRow() {
+			cmp, err := d.CompareDatum(nil, &expected[colIdx])
+			c.Assert(err, IsNil)
+			c.Assert(cmp, Equals, 0)
+		}
+	}
+
+	// The chunk without column only counts the rows.
+	empty := NewChunk(nil)
+	empty.AppendRow(src.GetRow(0))
+	empty.AppendRow(src.GetRow(1))
+	c.Assert(empty.NumRows(), Equals, 2)
+}
+
+func (s *testChunkSuite) TestMismatchedDatum(c *C) {
+	defer testleak.AfterTest(c)()
+	chk := NewChunk([]*types.FieldType{types.NewFieldType(mysql.TypeLonglong)})
+	chk.AppendInt64(0, 1)
+	chk.AppendNull(0)
+	str := types.NewStringDatum("abc")
+	chk.AppendDatum(0, &str)
+	c.Assert(chk.NumRows(), Equals, 3)
+	c.Assert(chk.GetRow(0).GetInt64(0), Equals, int64(1))
+	c.Assert(chk.GetRow(1).IsNull(0), IsTrue)
+	d := chk.GetRow(2).GetDatum(0)
+	c.Assert(d.Kind(), Equals, types.KindString)
+	c.Assert(d.GetString(), Equals, "abc")
+	_, ok := chk.Column(0).Int64s()
+	c.Assert(ok, IsFalse)
+}
@@ -0,0 +1,261 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"unsafe"
+
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/types"
+)
+
+const (
+	// varElemLen is the element length of the columns which store var-length values.
+	varElemLen = -1
+	// datumElemLen is the element length of the columns which store Datums,
+	// it's used by the types which have no compact layout, like Time, Enum, Set, Bit and JSON.
+	datumElemLen = 0
+)
+
+var (
+	sizeDuration  = int(unsafe.Sizeof(types.Duration{}))
+	sizeMyDecimal = int(unsafe.Sizeof(types.MyDecimal{}))
+)
+
+// getElemLen returns the element length of the column storing values of the field type.
+func getElemLen(ft *types.FieldType) int {
+	switch ft.Tp {
+	case mysql.TypeFloat:
+		return 4
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong,
+		mysql.TypeYear, mysql.TypeDouble:
+		return 8
+	case mysql.TypeDuration:
+		return sizeDuration
+	case mysql.TypeNewDecimal:
+		return sizeMyDecimal
+	case mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeString, mysql.TypeTinyBlob,
+		mysql.TypeMediumBlob, mysql.TypeBlob, mysql.TypeLongBlob:
+		return varElemLen
+	}
+	return datumElemLen
+}
+
+// Column stores the values of a column in a Chunk.
+// The fixed-width values are stored in "data" one after another, the var-length values are stored in "data" and
+// located by "offsets", and the other values are stored in "datums". A bit in "nullBitmap" is 0 if the value is null.
+type Column struct {
+	length     int
+	nullCount  int
+	nullBitmap []byte
+	elemLen    int
+	elemBuf    []byte
+	offsets    []int64
+	data       []byte
+	datums     []types.Datum
+}
+
+func newColumn(elemLen int) *Column {
+	c := &Column{elemLen: elemLen}
+	if elemLen > 0 {
+		c.elemBuf = make([]byte, elemLen)
+	} else if elemLen == varElemLen {
+		c.offsets = []int64{0}
+	}
+	return c
+}
+
+func (c *Column) reset() {
+	c.length = 0
+	c.nullCount = 0
+	c.nullBitmap = c.nullBitmap[:0]
+	c.data = c.data[:0]
+	if c.elemLen == varElemLen {
+		c.offsets = c.offsets[:1]
+	}
+	for i := range c.datums {
+		c.datums[i] = types.Datum{}
+	}
+	c.datums = c.datums[:0]
+}
+
+// Len returns the number of values in the column.
+func (c *Column) Len() int {
+	return c.length
+}
+
+// NullCount returns the number of null values in the column.
+func (c *Column) NullCount() int {
+	return c.nullCount
+}
+
+// IsNull returns whether the value of the row is null.
+func (c *Column) IsNull(rowIdx int) bool {
+	return c.nullBitmap[rowIdx>>3]&(1<<(uint(rowIdx)&7)) == 0
+}
+
+// Int64s returns the int64 values of the column, the values of the null rows are 0.
+// The second return value is false if the column doesn't store 8 bytes fixed-width values.
+// NOTE: The returned slice shares the memory with the column, it's invalid after the Chunk is reset.
+func (c *Column) Int64s() ([]int64, bool) {
+	if c.elemLen != 8 {
+		return nil, false
+	}
+	if c.length == 0 {
+		return nil, true
+	}
+	return (*[1 << 30]int64)(unsafe.Pointer(&c.data[0]))[:c.length:c.length], true
+}
+
+// Float64s returns the float64 values of the column, the values of the null rows are 0.
+// The second return value is false if the column doesn't store 8 bytes fixed-width values.
+// NOTE: The returned slice shares the memory with the column, it's invalid after the Chunk is reset.
+func (c *Column) Float64s() ([]float64, bool) {
+	if c.elemLen != 8 {
+		return nil, false
+	}
+	if c.length == 0 {
+		return nil, true
+	}
+	return (*[1 << 30]float64)(unsafe.Pointer(&c.data[0]))[:c.length:c.length], true
+}
+
+// Float32s returns the float32 values of the column, the values of the null rows are 0.
+// The second return value is false if the column doesn't store 4 bytes fixed-width values.
+// NOTE: The returned slice shares the memory with the column, it's invalid after the Chunk is reset.
+func (c *Column) Float32s() ([]float32, bool) {
+	if c.elemLen != 4 {
+		return nil, false
+	}
+	if c.length == 0 {
+		return nil, true
+	}
+	return (*[1 << 30]float32)(unsafe.Pointer(&c.data[0]))[:c.length:c.length], true
+}
+
+func (c *Column) appendNullBitmap(notNull bool) {
+	idx := c.length >> 3
+	if idx >= len(c.nullBitmap) {
+		c.nullBitmap = append(c.nullBitmap, 0)
+	}
+	if notNull {
+		c.nullBitmap[idx] |= byte(1 << (uint(c.length) & 7))
+	} else {
+		c.nullCount++
+	}
+}
+
+// finishAppendFixed appends the value in elemBuf.
+func (c *Column) finishAppendFixed() {
+	c.data = append(c.data, c.elemBuf...)
+	c.appendNullBitmap(true)
+	c.length++
+}
+
+func (c *Column) appendNull() {
+	c.appendNullBitmap(false)
+	switch {
+	case c.elemLen > 0:
+		// The null values take the space too, so the fixed-width values can be located by the row index.
+		for i := range c.elemBuf {
+			c.elemBuf[i] = 0
+		}
+		c.data = append(c.data, c.elemBuf...)
+	case c.elemLen == varElemLen:
+		c.offsets = append(c.offsets, int64(len(c.data)))
+	default:
+		c.datums = append(c.datums, types.Datum{})
+	}
+	c.length++
+}
+
+func (c *Column) appendInt64(i int64) {
+	*(*int64)(unsafe.Pointer(&c.elemBuf[0])) = i
+	c.finishAppendFixed()
+}
+
+func (c *Column) appendFloat32(f float32) {
+	*(*float32)(unsafe.Pointer(&c.elemBuf[0])) = f
+	c.finishAppendFixed()
+}
+
+func (c *Column) appendFloat64(f float64) {
+	*(*float64)(unsafe.Pointer(&c.elemBuf[0])) = f
+	c.finishAppendFixed()
+}
+
+func (c *Column) appendDuration(d types.Duration) {
+	*(*types.Duration)(unsafe.Pointer(&c.elemBuf[0])) = d
+	c.finishAppendFixed()
+}
+
+func (c *Column) appendMyDecimal(d *types.MyDecimal) {
+	*(*types.MyDecimal)(unsafe.Pointer(&c.elemBuf[0])) = *d
+	c.finishAppendFixed()
+}
+
+func (c *Column) appendBytes(b []byte) {
+	c.data = append(c.data, b...)
+	c.offsets = append(c.offsets, int64(len(c.data)))
+	c.appendNullBitmap(true)
+	c.length++
+}
+
+func (c *Column) appendDatum(d *types.Datum) {
+	c.datums = append(c.datums, *d)
+	c.appendNullBitmap(!d.IsNull())
+	c.length++
+}
+
+// appendCell appends the value of the row in the source column, which must have the same element length.
+func (c *Column) appendCell(src *Column, rowIdx int) {
+	if src.IsNull(rowIdx) {
+		c.appendNull()
+		return
+	}
+	switch {
+	case c.elemLen > 0:
+		c.data = append(c.data, src.data[rowIdx*c.elemLen:(rowIdx+1)*c.elemLen]...)
+		c.appendNullBitmap(true)
+		c.length++
+	case c.elemLen == varElemLen:
+		c.appendBytes(src.getBytes(rowIdx))
+	default:
+		c.appendDatum(&src.datums[rowIdx])
+	}
+}
+
+func (c *Column) getInt64(rowIdx int) int64 {
+	return *(*int64)(unsafe.Pointer(&c.data[rowIdx*8]))
+}
+
+func (c *Column) getFloat32(rowIdx int) float32 {
+	return *(*float32)(unsafe.Pointer(&c.data[rowIdx*4]))
+}
+
+func (c *Column) getFloat64(rowIdx int) float64 {
+	return *(*float64)(unsafe.Pointer(&c.data[rowIdx*8]))
+}
+
+func (c *Column) getDuration(rowIdx int) types.Duration {
+	return *(*types.Duration)(unsafe.Pointer(&c.data[rowIdx*c.elemLen]))
+}
+
+func (c *Column) getMyDecimal(rowIdx int) *types.MyDecimal {
+	return (*types.MyDecimal)(unsafe.Pointer(&c.data[rowIdx*c.elemLen]))
+}
+
+func (c *Column) getBytes(rowIdx int) []byte {
+	return c.data[c.offsets[rowIdx]:c.offsets[rowIdx+1]]
+}
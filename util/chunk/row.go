@@ -0,0 +1,153 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// Row represents a row of data in a Chunk, it's only valid before the Chunk is reset.
+type Row struct {
+	c   *Chunk
+	idx int
+}
+
+// Idx returns the row index of the Row in the Chunk.
+func (r Row) Idx() int {
+	return r.idx
+}
+
+// Len returns the number of values in the Row.
+func (r Row) Len() int {
+	return r.c.NumCols()
+}
+
+// IsNull returns whether the value of the column is null.
+func (r Row) IsNull(colIdx int) bool {
+	return r.c.columns[colIdx].IsNull(r.idx)
+}
+
+// GetInt64 returns the int64 value of the column.
+func (r Row) GetInt64(colIdx int) int64 {
+	col := r.c.columns[colIdx]
+	if col.elemLen == datumElemLen {
+		return col.datums[r.idx].GetInt64()
+	}
+	return col.getInt64(r.idx)
+}
+
+// GetUint64 returns the uint64 value of the column.
+func (r Row) GetUint64(colIdx int) uint64 {
+	return uint64(r.GetInt64(colIdx))
+}
+
+// GetFloat32 returns the float32 value of the column.
+func (r Row) GetFloat32(colIdx int) float32 {
+	col := r.c.columns[colIdx]
+	if col.elemLen == datumElemLen {
+		return col.datums[r.idx].GetFloat32()
+	}
+	return col.getFloat32(r.idx)
+}
+
+// GetFloat64 returns the float64 value of the column.
+func (r Row) GetFloat64(colIdx int) float64 {
+	col := r.c.columns[colIdx]
+	if col.elemLen == datumElemLen {
+		return col.datums[r.idx].GetFloat64()
+	}
+	return col.getFloat64(r.idx)
+}
+
+// GetString returns the string value of the column.
+func (r Row) GetString(colIdx int) string {
+	return string(r.GetBytes(colIdx))
+}
+
+// GetBytes returns the bytes value of the column.
+// NOTE: The returned slice shares the memory with the Chunk.
+func (r Row) GetBytes(colIdx int) []byte {
+	col := r.c.columns[colIdx]
+	if col.elemLen == datumElemLen {
+		return col.datums[r.idx].GetBytes()
+	}
+	return col.getBytes(r.idx)
+}
+
+// GetMyDecimal returns the MyDecimal value of the column.
+// NOTE: The returned decimal shares the memory with the Chunk.
+func (r Row) GetMyDecimal(colIdx int) *types.MyDecimal {
+	col := r.c.columns[colIdx]
+	if col.elemLen == datumElemLen {
+		return col.datums[r.idx].GetMysqlDecimal()
+	}
+	return col.getMyDecimal(r.idx)
+}
+
+// GetDuration returns the Duration value of the column.
+func (r Row) GetDuration(colIdx int) types.Duration {
+	col := r.c.columns[colIdx]
+	if col.elemLen == datumElemLen {
+		return col.datums[r.idx].GetMysqlDuration()
+	}
+	return col.getDuration(r.idx)
+}
+
+// GetDatum returns the Datum of the column, the kind of the Datum is decided by the field type of the column.
+// The returned Datum doesn't share any memory with the Chunk.
+func (r Row) GetDatum(colIdx int) types.Datum {
+	var d types.Datum
+	col := r.c.columns[colIdx]
+	if col.IsNull(r.idx) {
+		return d
+	}
+	if col.elemLen == datumElemLen {
+		return col.datums[r.idx]
+	}
+	switch ft := r.c.fields[colIdx]; ft.Tp {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong, mysql.TypeYear:
+		if mysql.HasUnsignedFlag(ft.Flag) {
+			d.SetUint64(uint64(col.getInt64(r.idx)))
+		} else {
+			d.SetInt64(col.getInt64(r.idx))
+		}
+	case mysql.TypeFloat:
+		d.SetFloat32(col.getFloat32(r.idx))
+	case mysql.TypeDouble:
+		d.SetFloat64(col.getFloat64(r.idx))
+	case mysql.TypeDuration:
+		d.SetMysqlDuration(col.getDuration(r.idx))
+	case mysql.TypeNewDecimal:
+		dec := *col.getMyDecimal(r.idx)
+		d.SetMysqlDecimal(&dec)
+		// The frac of the Datum is used by the aggregate functions like avg.
+		d.SetFrac(int(dec.GetDigitsFrac()))
+	case mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeString:
+		d.SetString(string(col.getBytes(r.idx)))
+	default:
+		b := col.getBytes(r.idx)
+		d.SetBytes(append(make([]byte, 0, len(b)), b...))
+	}
+	return d
+}
+
+// GetDatumRow returns all the Datums of the Row.
+func (r Row) GetDatumRow() []types.Datum {
+	datums := make([]types.Datum, r.c.NumCols())
+	for colIdx := range datums {
+		datums[colIdx] = r.GetDatum(colIdx)
+	}
+	return datums
+}
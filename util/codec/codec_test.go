@@ -835,3 +835,26 @@ func (s *testCodecSuite) TestSetRawValues(c *C) {
 		c.Assert(encoded, BytesEquals, rawVal.GetBytes())
 	}
 }
+
+func (s *testCodecSuite) TestCollationKey(c *C) {
+	defer testleak.AfterTest(c)()
+	vals := types.MakeDatums("abc ", "Abc", int64(1), []byte("abc"))
+	collations := []string{"utf8_general_ci", "utf8_general_ci", "utf8_general_ci", "utf8_bin"}
+	keys := CollationKeys(vals, collations)
+	c.Assert(keys[0].GetBytes(), DeepEquals, []byte("ABC"))
+	c.Assert(keys[1].GetBytes(), DeepEquals, []byte("ABC"))
+	c.Assert(keys[2].GetInt64(), Equals, int64(1))
+	c.Assert(keys[3].GetBytes(), DeepEquals, []byte("abc"))
+	// The values are not changed.
+	c.Assert(vals[0].GetString(), Equals, "abc ")
+
+	b1, err := EncodeKey(nil, keys[0])
+	c.Assert(err, IsNil)
+	b2, err := EncodeKey(nil, keys[1])
+	c.Assert(err, IsNil)
+	c.Assert(b1, DeepEquals, b2)
+
+	// The datums without collations are kept.
+	c.Assert(CollationKeys(vals, nil), DeepEquals, vals)
+	c.Assert(CollationKey(vals[1], "utf8_bin"), DeepEquals, vals[1])
+}
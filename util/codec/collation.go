@@ -0,0 +1,59 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codec
+
+import (
+	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// CollationKey returns the datum to be encoded for d in the keys compared by the collation.
+// The strings of the case-insensitive collations are replaced by their sort keys, so the strings
+// equal by the collation are encoded to the same key, and the keys are in the order of the collation.
+func CollationKey(d types.Datum, collate string) types.Datum {
+	if !needCollationKey(d, collate) {
+		return d
+	}
+	var key types.Datum
+	key.SetBytes(charset.GetCollator(collate).Key(d.GetString()))
+	return key
+}
+
+func needCollationKey(d types.Datum, collate string) bool {
+	if d.Kind() != types.KindString && d.Kind() != types.KindBytes {
+		return false
+	}
+	return charset.IsCICollation(collate)
+}
+
+// CollationKeys converts the datums to the datums to be encoded in the keys, collations[i] is the collation of vals[i].
+// The datums without collations are kept as they are. The vals is returned as it is if no datum
+// needs to be converted, otherwise a new slice is returned.
+func CollationKeys(vals []types.Datum, collations []string) []types.Datum {
+	var keys []types.Datum
+	for i := range vals {
+		if i >= len(collations) || !needCollationKey(vals[i], collations[i]) {
+			continue
+		}
+		if keys == nil {
+			keys = make([]types.Datum, len(vals))
+			copy(keys, vals)
+		}
+		keys[i] = CollationKey(vals[i], collations[i])
+	}
+	if keys == nil {
+		return vals
+	}
+	return keys
+}
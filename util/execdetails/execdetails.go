@@ -0,0 +1,215 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execdetails
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RuntimeStatsColl collects the runtime statistics of the executors of a statement, keyed by the explain ID
+// of the plans. It is only created for EXPLAIN ANALYZE.
+type RuntimeStatsColl struct {
+	mu        sync.Mutex
+	rootStats map[string]*RuntimeStats
+	copStats  map[string]*CopRuntimeStats
+}
+
+// NewRuntimeStatsColl creates a new runtime statistics collection.
+func NewRuntimeStatsColl() *RuntimeStatsColl {
+	return &RuntimeStatsColl{
+		rootStats: make(map[string]*RuntimeStats),
+		copStats:  make(map[string]*CopRuntimeStats),
+	}
+}
+
+// GetRootStats gets the runtime statistics of the executor built from the plan, it's created if not exists.
+func (e *RuntimeStatsColl) GetRootStats(planID string) *RuntimeStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats, ok := e.rootStats[planID]
+	if !ok {
+		stats = &RuntimeStats{}
+		e.rootStats[planID] = stats
+	}
+	return stats
+}
+
+// GetCopStats gets the statistics of the coprocessor tasks which the plan is pushed down to,
+// it's created if not exists.
+func (e *RuntimeStatsColl) GetCopStats(planID string) *CopRuntimeStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats, ok := e.copStats[planID]
+	if !ok {
+		stats = &CopRuntimeStats{}
+		e.copStats[planID] = stats
+	}
+	return stats
+}
+
+// ExistsRootStats checks whether the runtime statistics of the plan exist.
+func (e *RuntimeStatsColl) ExistsRootStats(planID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, ok := e.rootStats[planID]
+	return ok
+}
+
+// ExistsCopStats checks whether the coprocessor statistics of the plan exist.
+func (e *RuntimeStatsColl) ExistsCopStats(planID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, ok := e.copStats[planID]
+	return ok
+}
+
+// RuntimeStats collects the execution information of an executor.
+// The executor may be called in the background goroutines, so the fields are updated atomically.
+type RuntimeStats struct {
+	// loops is the number of times the executor is opened.
+	loops int32
+	// rows is the number of rows returned by the executor.
+	rows int64
+	// consume is the wall time spent in the executor and its children, in nanoseconds.
+	consume int64
+	// memory is the peak memory used by the executor to buffer the rows, in bytes.
+	memory int64
+}
+
+// RecordLoop records that the executor is opened once more.
+func (e *RuntimeStats) RecordLoop() {
+	atomic.AddInt32(&e.loops, 1)
+}
+
+// Record records the time spent in a call of the executor and the number of the returned rows.
+func (e *RuntimeStats) Record(d time.Duration, rowNum int) {
+	atomic.AddInt64(&e.consume, int64(d))
+	atomic.AddInt64(&e.rows, int64(rowNum))
+}
+
+// RecordMemory records the memory used by the executor, only the peak is kept.
+func (e *RuntimeStats) RecordMemory(bytes int64) {
+	for {
+		old := atomic.LoadInt64(&e.memory)
+		if bytes <= old || atomic.CompareAndSwapInt64(&e.memory, old, bytes) {
+			return
+		}
+	}
+}
+
+// Loops returns the number of times the executor is opened.
+func (e *RuntimeStats) Loops() int32 {
+	return atomic.LoadInt32(&e.loops)
+}
+
+// Rows returns the number of rows returned by the executor.
+func (e *RuntimeStats) Rows() int64 {
+	return atomic.LoadInt64(&e.rows)
+}
+
+// Time returns the wall time spent in the executor.
+func (e *RuntimeStats) Time() time.Duration {
+	return time.Duration(atomic.LoadInt64(&e.consume))
+}
+
+// Memory returns the peak memory used by the executor.
+func (e *RuntimeStats) Memory() int64 {
+	return atomic.LoadInt64(&e.memory)
+}
+
+func (e *RuntimeStats) String() string {
+	s := fmt.Sprintf("time:%v, loops:%d, rows:%d", e.Time(), e.Loops(), e.Rows())
+	if mem := e.Memory(); mem > 0 {
+		s += ", memory:" + FormatBytes(mem)
+	}
+	return s
+}
+
+// CopRuntimeStats collects the information of the coprocessor tasks sent by a distsql request.
+type CopRuntimeStats struct {
+	mu sync.Mutex
+	// tasks is the number of the coprocessor tasks, each region is handled by a task.
+	tasks int
+	// totalTime and maxTime are the time spent to receive the response of the tasks.
+	totalTime time.Duration
+	maxTime   time.Duration
+	// rows and bytes are the number of rows and the size of the data returned by the tasks.
+	rows  int64
+	bytes int64
+}
+
+// RecordTask records the response of a coprocessor task. It does nothing for a nil receiver,
+// so the callers don't need to check whether the statistics are collected.
+func (e *CopRuntimeStats) RecordTask(d time.Duration, bytes int) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	e.tasks++
+	e.totalTime += d
+	if d > e.maxTime {
+		e.maxTime = d
+	}
+	e.bytes += int64(bytes)
+	e.mu.Unlock()
+}
+
+// RecordRows records the rows returned by the coprocessor tasks. It does nothing for a nil receiver.
+func (e *CopRuntimeStats) RecordRows(rowNum int) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	e.rows += int64(rowNum)
+	e.mu.Unlock()
+}
+
+// Tasks returns the number of the coprocessor tasks.
+func (e *CopRuntimeStats) Tasks() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.tasks
+}
+
+// Rows returns the number of rows returned by the coprocessor tasks.
+func (e *CopRuntimeStats) Rows() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rows
+}
+
+func (e *CopRuntimeStats) String() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return fmt.Sprintf("cop_task:{num:%d, max:%v, total:%v, rows:%d, bytes:%s}",
+		e.tasks, e.maxTime, e.totalTime, e.rows, FormatBytes(e.bytes))
+}
+
+// FormatBytes formats the size in bytes into a human readable string, e.g. "1.5 KB".
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d Bytes", bytes)
+	}
+	units := []string{"KB", "MB", "GB", "TB"}
+	value, i := float64(bytes)/unit, 0
+	for value >= unit && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
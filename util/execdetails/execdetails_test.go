@@ -0,0 +1,63 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execdetails
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testExecDetailsSuite{})
+
+type testExecDetailsSuite struct{}
+
+func (s *testExecDetailsSuite) TestRuntimeStats(c *C) {
+	coll := NewRuntimeStatsColl()
+	c.Assert(coll.ExistsRootStats("Sort_1"), IsFalse)
+	stats := coll.GetRootStats("Sort_1")
+	c.Assert(coll.GetRootStats("Sort_1"), Equals, stats)
+	c.Assert(coll.ExistsRootStats("Sort_1"), IsTrue)
+
+	stats.RecordLoop()
+	stats.Record(time.Millisecond, 1)
+	stats.Record(time.Millisecond, 0)
+	stats.RecordMemory(2048)
+	stats.RecordMemory(1024)
+	c.Assert(stats.String(), Equals, "time:2ms, loops:1, rows:1, memory:2.0 KB")
+
+	var nilStats *CopRuntimeStats
+	nilStats.RecordTask(time.Second, 1)
+	nilStats.RecordRows(1)
+
+	copStats := coll.GetCopStats("TableScan_2")
+	c.Assert(coll.ExistsCopStats("TableScan_2"), IsTrue)
+	copStats.RecordTask(time.Millisecond, 100)
+	copStats.RecordTask(2*time.Millisecond, 100)
+	copStats.RecordRows(3)
+	c.Assert(copStats.Tasks(), Equals, 2)
+	c.Assert(copStats.String(), Equals, "cop_task:{num:2, max:2ms, total:3ms, rows:3, bytes:200 Bytes}")
+}
+
+func (s *testExecDetailsSuite) TestFormatBytes(c *C) {
+	c.Assert(FormatBytes(0), Equals, "0 Bytes")
+	c.Assert(FormatBytes(1023), Equals, "1023 Bytes")
+	c.Assert(FormatBytes(1536), Equals, "1.5 KB")
+	c.Assert(FormatBytes(3*1024*1024), Equals, "3.0 MB")
+}
@@ -415,7 +415,7 @@ func (fs *FileSorter) openAllFiles() error {
 
 // fetchNextRow fetches the next row given the source file index.
 func (fs *FileSorter) fetchNextRow(index int) (*comparableRow, error) {
-	n, err := fs.fds[index].Read(fs.head)
+	n, err := io.ReadFull(fs.fds[index], fs.head)
 	if err == io.EOF {
 		return nil, nil
 	}
@@ -426,8 +426,12 @@ func (fs *FileSorter) fetchNextRow(index int) (*comparableRow, error) {
 		return nil, errors.New("incorrect header")
 	}
 	rowSize := int(binary.BigEndian.Uint64(fs.head))
+	if rowSize > len(fs.rowBytes) {
+		return nil, errors.New("incorrect row")
+	}
 
-	n, err = fs.fds[index].Read(fs.rowBytes)
+	// The rows have variable sizes, so only the current row is read.
+	n, err = io.ReadFull(fs.fds[index], fs.rowBytes[:rowSize])
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -435,7 +439,7 @@ func (fs *FileSorter) fetchNextRow(index int) (*comparableRow, error) {
 		return nil, errors.New("incorrect row")
 	}
 
-	fs.dcod, err = codec.Decode(fs.rowBytes, fs.keySize+fs.valSize+1)
+	fs.dcod, err = codec.Decode(fs.rowBytes[:rowSize], fs.keySize+fs.valSize+1)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -458,8 +462,8 @@ func (fs *FileSorter) Input(key []types.Datum, val []types.Datum, handle int64)
 	}
 
 	assigned := false
-	abortTime := time.Duration(1) * time.Minute           // 1 minute
-	cooldownTime := time.Duration(100) * time.Millisecond // 100 milliseconds
+	abortTime := time.Duration(1) * time.Minute          // 1 minute
+	cooldownTime := time.Duration(10) * time.Millisecond // 10 milliseconds
 	row := &comparableRow{
 		key:    key,
 		val:    val,
@@ -253,6 +253,40 @@ func (s *testFileSortSuite) TestMultipleWorkers(c *C) {
 	}
 }
 
+func (s *testFileSortSuite) TestVariableRowSize(c *C) {
+	defer testleak.AfterTest(c)()
+
+	sc := new(variable.StatementContext)
+	byDesc := []bool{false}
+	tmpDir, err := ioutil.TempDir("", "util_filesort_test")
+	c.Assert(err, IsNil)
+
+	fsBuilder := new(Builder)
+	fs, err := fsBuilder.SetSC(sc).SetSchema(1, 1).SetBuf(4).SetWorkers(1).SetDesc(byDesc).SetDir(tmpDir).Build()
+	c.Assert(err, IsNil)
+	defer fs.Close()
+
+	// The rows have different sizes, so they can't be read back with a fixed size.
+	nRows := 20
+	for i := nRows - 1; i >= 0; i-- {
+		key := []types.Datum{types.NewIntDatum(int64(i))}
+		val := []types.Datum{types.NewBytesDatum(make([]byte, i*i))}
+		err = fs.Input(key, val, int64(i))
+		c.Assert(err, IsNil)
+	}
+
+	for i := 0; i < nRows; i++ {
+		key, val, handle, err := fs.Output()
+		c.Assert(err, IsNil)
+		c.Assert(key[0].GetInt64(), Equals, int64(i))
+		c.Assert(val[0].GetBytes(), HasLen, i*i)
+		c.Assert(handle, Equals, int64(i))
+	}
+	key, _, _, err := fs.Output()
+	c.Assert(err, IsNil)
+	c.Assert(key, IsNil)
+}
+
 func (s *testFileSortSuite) TestClose(c *C) {
 	defer testleak.AfterTest(c)()
 
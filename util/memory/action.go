@@ -0,0 +1,65 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+)
+
+// ErrMemExceedThreshold is returned when the memory usage of a query exceeds its quota and the query is canceled.
+var ErrMemExceedThreshold = terror.ClassUtil.New(codeMemExceedThreshold, mysql.MySQLErrName[mysql.ErrMemExceedThreshold])
+
+const codeMemExceedThreshold terror.ErrCode = 8001
+
+func init() {
+	utilMySQLErrCodes := map[terror.ErrCode]uint16{
+		codeMemExceedThreshold: mysql.ErrMemExceedThreshold,
+	}
+	terror.ErrClassToMySQLCodes[terror.ClassUtil] = utilMySQLErrCodes
+}
+
+// ActionOnExceed is the action taken when the memory usage of a Tracker exceeds its limit.
+type ActionOnExceed interface {
+	// Action is called with the Tracker whose limit is exceeded, the returned error cancels the query.
+	// It may be called concurrently by the executors which consume memory in multiple goroutines.
+	Action(t *Tracker) error
+}
+
+// LogOnExceed logs a warning only once when the memory usage exceeds the limit, the query keeps running.
+type LogOnExceed struct {
+	// ConnID is the connection ID of the query, it's used in the log.
+	ConnID uint64
+
+	once sync.Once
+}
+
+// Action implements the ActionOnExceed interface.
+func (a *LogOnExceed) Action(t *Tracker) error {
+	a.once.Do(func() {
+		log.Warnf("[%d] memory exceeds quota, %s", a.ConnID, t.String())
+	})
+	return nil
+}
+
+// CancelOnExceed cancels the query when the memory usage exceeds the limit.
+type CancelOnExceed struct{}
+
+// Action implements the ActionOnExceed interface.
+func (a *CancelOnExceed) Action(t *Tracker) error {
+	return ErrMemExceedThreshold.GenByArgs(t.Label(), t.BytesConsumed(), t.BytesLimit())
+}
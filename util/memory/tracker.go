@@ -0,0 +1,156 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Tracker is used to track the memory usage during query execution.
+// It contains an optional limit and can be arranged into a tree structure
+// such that the consumption tracked by a Tracker is also tracked by its ancestors.
+// The typical tree is:
+//  1. The root Tracker is created for a statement and attached to the StatementContext.
+//  2. The executors which buffer data, e.g. HashJoinExec, HashAggExec and SortExec, create their
+//     own Trackers and attach them to the root Tracker.
+//
+// The consumption is reported by the executors with Consume, the action of the Tracker is called
+// when its limit is exceeded.
+//
+// NOTE: The methods of Tracker are thread-safe.
+type Tracker struct {
+	mu struct {
+		sync.Mutex
+		children []*Tracker
+	}
+
+	label          string
+	bytesConsumed  int64 // Consumed bytes, it's accessed atomically.
+	bytesLimit     int64 // Negative or zero value means no limit.
+	actionOnExceed ActionOnExceed
+	parent         *Tracker
+}
+
+// NewTracker creates a memory tracker.
+//  1. "label" is the label used in the usage string.
+//  2. "bytesLimit <= 0" means no limit.
+func NewTracker(label string, bytesLimit int64) *Tracker {
+	return &Tracker{
+		label:      label,
+		bytesLimit: bytesLimit,
+	}
+}
+
+// SetActionOnExceed sets the action when memory usage exceeds the limit.
+func (t *Tracker) SetActionOnExceed(a ActionOnExceed) {
+	t.actionOnExceed = a
+}
+
+// Label returns the label of the Tracker.
+func (t *Tracker) Label() string {
+	return t.label
+}
+
+// AttachTo attaches the Tracker as a child of the parent, the consumed bytes of the Tracker are added to
+// the parent. A nil parent is ignored, so the Tracker is still the root of its own tree.
+func (t *Tracker) AttachTo(parent *Tracker) {
+	if parent == nil {
+		return
+	}
+	if t.parent != nil {
+		t.Detach()
+	}
+	parent.mu.Lock()
+	parent.mu.children = append(parent.mu.children, t)
+	parent.mu.Unlock()
+
+	t.parent = parent
+	// The limit of the parent is not checked here, it's checked when the consumption grows.
+	for p := parent; p != nil; p = p.parent {
+		atomic.AddInt64(&p.bytesConsumed, t.BytesConsumed())
+	}
+}
+
+// Detach detaches the Tracker from its parent, the consumed bytes of the Tracker are released from its ancestors.
+func (t *Tracker) Detach() {
+	parent := t.parent
+	if parent == nil {
+		return
+	}
+	parent.mu.Lock()
+	for i, child := range parent.mu.children {
+		if child == t {
+			parent.mu.children = append(parent.mu.children[:i], parent.mu.children[i+1:]...)
+			break
+		}
+	}
+	parent.mu.Unlock()
+
+	t.parent = nil
+	for p := parent; p != nil; p = p.parent {
+		atomic.AddInt64(&p.bytesConsumed, -t.BytesConsumed())
+	}
+}
+
+// Consume is used to consume a memory usage. "bytes" can be a negative value, which means this is a memory release
+// operation. The consumption is also added to all the ancestors. If the limit of the Tracker or any of its ancestors
+// is exceeded, the action of that Tracker is called and the first error returned by the actions is returned.
+func (t *Tracker) Consume(bytes int64) error {
+	var err error
+	for tracker := t; tracker != nil; tracker = tracker.parent {
+		consumed := atomic.AddInt64(&tracker.bytesConsumed, bytes)
+		if bytes <= 0 || tracker.bytesLimit <= 0 || consumed <= tracker.bytesLimit || tracker.actionOnExceed == nil {
+			continue
+		}
+		if actErr := tracker.actionOnExceed.Action(tracker); err == nil {
+			err = actErr
+		}
+	}
+	return err
+}
+
+// BytesConsumed returns the consumed memory usage value in bytes.
+func (t *Tracker) BytesConsumed() int64 {
+	return atomic.LoadInt64(&t.bytesConsumed)
+}
+
+// BytesLimit returns the memory limit of the Tracker in bytes, negative or zero value means no limit.
+func (t *Tracker) BytesLimit() int64 {
+	return t.bytesLimit
+}
+
+// String returns the string representation of the Tracker tree.
+func (t *Tracker) String() string {
+	buffer := bytes.NewBufferString("\n")
+	t.toString("", buffer)
+	return buffer.String()
+}
+
+func (t *Tracker) toString(indent string, buffer *bytes.Buffer) {
+	fmt.Fprintf(buffer, "%s\"%s\"{\n", indent, t.label)
+	if t.bytesLimit > 0 {
+		fmt.Fprintf(buffer, "%s  \"quota\": %d bytes\n", indent, t.bytesLimit)
+	}
+	fmt.Fprintf(buffer, "%s  \"consumed\": %d bytes\n", indent, t.BytesConsumed())
+
+	t.mu.Lock()
+	for _, child := range t.mu.children {
+		child.toString(indent+"  ", buffer)
+	}
+	t.mu.Unlock()
+	fmt.Fprintf(buffer, "%s}\n", indent)
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testSuite{})
+
+type testSuite struct{}
+
+func (s *testSuite) TestConsume(c *C) {
+	defer testleak.AfterTest(c)()
+	root := NewTracker("root", -1)
+	child1 := NewTracker("child1", -1)
+	child2 := NewTracker("child2", -1)
+	child1.AttachTo(root)
+	child2.AttachTo(root)
+
+	c.Assert(child1.Consume(100), IsNil)
+	c.Assert(child2.Consume(200), IsNil)
+	c.Assert(child1.Consume(-50), IsNil)
+	c.Assert(child1.BytesConsumed(), Equals, int64(50))
+	c.Assert(child2.BytesConsumed(), Equals, int64(200))
+	c.Assert(root.BytesConsumed(), Equals, int64(250))
+
+	// The consumption of a child is released from its ancestors when it's detached.
+	child2.Detach()
+	c.Assert(root.BytesConsumed(), Equals, int64(50))
+	c.Assert(child2.BytesConsumed(), Equals, int64(200))
+	child2.AttachTo(root)
+	c.Assert(root.BytesConsumed(), Equals, int64(250))
+
+	// A nil parent is ignored.
+	child3 := NewTracker("child3", -1)
+	child3.AttachTo(nil)
+	c.Assert(child3.Consume(10), IsNil)
+	c.Assert(root.BytesConsumed(), Equals, int64(250))
+
+	str := root.String()
+	c.Assert(strings.Contains(str, `"root"`), IsTrue)
+	c.Assert(strings.Contains(str, `"child1"`), IsTrue)
+	c.Assert(strings.Contains(str, `"consumed": 250 bytes`), IsTrue)
+}
+
+func (s *testSuite) TestActionOnExceed(c *C) {
+	defer testleak.AfterTest(c)()
+	root := NewTracker("root", 100)
+	root.SetActionOnExceed(&CancelOnExceed{})
+	child := NewTracker("child", -1)
+	child.AttachTo(root)
+
+	c.Assert(child.Consume(100), IsNil)
+	err := child.Consume(1)
+	c.Assert(terror.ErrorEqual(err, ErrMemExceedThreshold), IsTrue, Commentf("err %v", err))
+	// Releasing memory never takes the action.
+	c.Assert(child.Consume(-1), IsNil)
+
+	root = NewTracker("root", 100)
+	root.SetActionOnExceed(&LogOnExceed{})
+	child = NewTracker("child", -1)
+	child.AttachTo(root)
+	c.Assert(child.Consume(200), IsNil)
+	c.Assert(child.Consume(200), IsNil)
+	c.Assert(root.BytesConsumed(), Equals, int64(400))
+}
@@ -15,6 +15,7 @@ package mvmap
 
 import (
 	"bytes"
+	"unsafe"
 )
 
 type entry struct {
@@ -178,6 +179,19 @@ func (m *MVMap) Len() int {
 	return m.length
 }
 
+// MemoryUsage returns the estimated memory usage of the mv map in bytes.
+func (m *MVMap) MemoryUsage() int64 {
+	var usage int64
+	for _, slice := range m.dataStore.slices {
+		usage += int64(cap(slice))
+	}
+	for _, slice := range m.entryStore.slices {
+		usage += int64(cap(slice)) * int64(unsafe.Sizeof(entry{}))
+	}
+	usage += int64(len(m.hashTable)) * int64(unsafe.Sizeof(uint64(0))+unsafe.Sizeof(entryAddr{}))
+	return usage
+}
+
 // Iterator is used to iterate the MVMap.
 type Iterator struct {
 	m        *MVMap
@@ -52,6 +52,15 @@ func TestMVMap(t *testing.T) {
 	if key != nil || val != nil {
 		t.FailNow()
 	}
+
+	usage := m.MemoryUsage()
+	if usage <= 0 {
+		t.FailNow()
+	}
+	m.Put([]byte("ghi"), make([]byte, maxDataSliceLen))
+	if m.MemoryUsage() <= usage+maxDataSliceLen {
+		t.FailNow()
+	}
 }
 
 func BenchmarkMVMapPut(b *testing.B) {
@@ -23,7 +23,8 @@ import (
 
 func buildIndexRange(sc *variable.StatementContext, cols []*expression.Column, lengths []int,
 	accessCondition []expression.Expression) ([]*types.IndexRange, error) {
-	rb := builder{sc: sc}
+	// The columns in case-insensitive collations are excluded by IndexInfo2Cols if the index keeps the raw strings.
+	rb := builder{sc: sc, sortKey: true}
 	var (
 		ranges  []*types.IndexRange
 		eqCount int
@@ -23,6 +23,7 @@ import (
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -118,6 +119,35 @@ func (r *pointSorter) Swap(i, j int) {
 type builder struct {
 	err error
 	sc  *variable.StatementContext
+	// sortKey is true when the ranges are built for the indexes keeping the sort keys of the strings
+	// in case-insensitive collations, so the points are converted to the sort keys.
+	// The indexes of the old versions keep the raw strings, the points are kept as they are.
+	sortKey bool
+}
+
+// isSortKeyColumn checks if the points of the column should be the sort keys.
+func (r *builder) isSortKeyColumn(expr expression.Expression) (string, bool) {
+	col, ok := expr.(*expression.Column)
+	if !r.sortKey || !ok || !charset.IsCICollation(col.RetType.Collate) {
+		return "", false
+	}
+	return col.RetType.Collate, true
+}
+
+// toSortKey converts the value compared with the column to the sort key of the column's collation.
+func (r *builder) toSortKey(expr expression.Expression, value types.Datum) types.Datum {
+	collate, ok := r.isSortKeyColumn(expr)
+	if !ok || value.IsNull() {
+		return value
+	}
+	s, err := value.ToString()
+	if err != nil {
+		r.err = errors.Trace(err)
+		return value
+	}
+	var key types.Datum
+	key.SetBytes(charset.GetCollator(collate).Key(s))
+	return key
 }
 
 func (r *builder) build(expr expression.Expression) []point {
@@ -167,7 +197,7 @@ func (r *builder) buildFormBinOp(expr *expression.ScalarFunction) []point {
 	var value types.Datum
 	var op string
 	if v, ok := expr.GetArgs()[0].(*expression.Constant); ok {
-		value = v.Value
+		value = r.toSortKey(expr.GetArgs()[1], v.Value)
 		switch expr.FuncName.L {
 		case ast.GE:
 			op = ast.LE
@@ -181,7 +211,7 @@ func (r *builder) buildFormBinOp(expr *expression.ScalarFunction) []point {
 			op = expr.FuncName.L
 		}
 	} else {
-		value = expr.GetArgs()[1].(*expression.Constant).Value
+		value = r.toSortKey(expr.GetArgs()[0], expr.GetArgs()[1].(*expression.Constant).Value)
 		op = expr.FuncName.L
 	}
 	if value.IsNull() {
@@ -268,8 +298,9 @@ func (r *builder) newBuildFromIn(expr *expression.ScalarFunction) []point {
 			r.err = ErrUnsupportedType.Gen("expr:%v is not constant", e)
 			return fullRange
 		}
-		startPoint := point{value: types.NewDatum(v.Value.GetValue()), start: true}
-		endPoint := point{value: types.NewDatum(v.Value.GetValue())}
+		value := r.toSortKey(expr.GetArgs()[0], types.NewDatum(v.Value.GetValue()))
+		startPoint := point{value: value, start: true}
+		endPoint := point{value: value}
 		rangePoints = append(rangePoints, startPoint, endPoint)
 	}
 	sorter := pointSorter{points: rangePoints, sc: r.sc}
@@ -343,6 +374,12 @@ func (r *builder) newBuildFromPatternLike(expr *expression.ScalarFunction) []poi
 		}
 		lowValue = append(lowValue, pattern[i])
 	}
+	if collate, ok := r.isSortKeyColumn(expr.GetArgs()[0]); ok {
+		// The strings matching the prefix by the collation have the sort keys beginning with the sort key of the prefix,
+		// the sort key of "abc_x" may be the one of "abc" as the trailing spaces are trimmed, so it's not excluded.
+		lowValue = charset.GetCollator(collate).Key(string(lowValue))
+		exclude = false
+	}
 	if len(lowValue) == 0 {
 		return []point{{value: types.MinNotNullDatum(), start: true}, {value: types.MaxValueDatum()}}
 	}
@@ -495,6 +532,10 @@ func (r *builder) convertPoint(point point, tp *types.FieldType) point {
 	case types.KindMaxValue, types.KindMinNotNull:
 		return point
 	}
+	if r.sortKey && charset.IsCICollation(tp.Collate) && point.value.Kind() == types.KindBytes {
+		// The value is already the sort key of the column.
+		return point
+	}
 	casted, err := point.value.ConvertTo(r.sc, tp)
 	if err != nil {
 		r.err = errors.Trace(err)
@@ -22,6 +22,7 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -44,7 +45,7 @@ func FullIndexRange() []*types.IndexRange {
 // BuildIndexRange will build range of index for PhysicalIndexScan
 func BuildIndexRange(sc *variable.StatementContext, tblInfo *model.TableInfo, index *model.IndexInfo,
 	accessInAndEqCount int, accessCondition []expression.Expression) ([]*types.IndexRange, error) {
-	rb := builder{sc: sc}
+	rb := builder{sc: sc, sortKey: index.HasCollationKeys()}
 	var ranges []*types.IndexRange
 	for i := 0; i < accessInAndEqCount; i++ {
 		// Build ranges for equal or in access conditions.
@@ -122,26 +123,33 @@ func refineRangeDatum(v *types.Datum, ic *model.IndexColumn) {
 
 // getEQFunctionOffset judge if the expression is a eq function like A = 1 where a is an index.
 // If so, it will return the offset of A in index columns. e.g. for index(C,B,A), A's offset is 2.
-func getEQFunctionOffset(expr expression.Expression, cols []*model.IndexColumn) int {
+func getEQFunctionOffset(expr expression.Expression, index *model.IndexInfo) int {
 	f, ok := expr.(*expression.ScalarFunction)
 	if !ok || f.FuncName.L != ast.EQ {
 		return -1
 	}
 	if c, ok := f.GetArgs()[0].(*expression.Column); ok {
 		if _, ok := f.GetArgs()[1].(*expression.Constant); ok {
-			for i, col := range cols {
-				if col.Name.L == c.ColName.L {
-					return i
-				}
-			}
+			return indexColumnOffset(c, index)
 		}
 	} else if _, ok := f.GetArgs()[0].(*expression.Constant); ok {
 		if c, ok := f.GetArgs()[1].(*expression.Column); ok {
-			for i, col := range cols {
-				if col.Name.L == c.ColName.L {
-					return i
-				}
-			}
+			return indexColumnOffset(c, index)
+		}
+	}
+	return -1
+}
+
+// indexColumnOffset returns the offset of the column in the index columns, or -1 if the index can't be used
+// to build the ranges of the column.
+func indexColumnOffset(c *expression.Column, index *model.IndexInfo) int {
+	// The index keeping the raw strings can't be used to match the strings in case-insensitive collations.
+	if !index.ComparedByCollation(c.RetType.Collate) {
+		return -1
+	}
+	for i, col := range index.Columns {
+		if col.Name.L == c.ColName.L {
+			return i
 		}
 	}
 	return -1
@@ -166,6 +174,10 @@ func checkIndexCondition(condition expression.Expression, indexColumns []*model.
 		if pKName.L == col.ColName.L {
 			continue
 		}
+		// The index keeps the sort keys instead of the strings in case-insensitive collations.
+		if charset.IsCICollation(col.RetType.Collate) {
+			return false
+		}
 		isIndexColumn := false
 		for _, indCol := range indexColumns {
 			if col.ColName.L == indCol.Name.L && indCol.Length == types.UnspecifiedLength {
@@ -211,7 +223,7 @@ func DetachIndexScanConditions(conditions []expression.Expression, index *model.
 		conditions[i] = expression.PushDownNot(cond, false, nil)
 	}
 	for _, cond := range conditions {
-		offset := getEQFunctionOffset(cond, index.Columns)
+		offset := getEQFunctionOffset(cond, index)
 		if offset != -1 {
 			accessConds[offset] = cond
 		}
@@ -352,7 +364,7 @@ func (c *conditionChecker) findEqOrInFunc(conditions []expression.Expression) in
 	for i, cond := range conditions {
 		var offset int
 		if c.idx != nil {
-			offset = getEQFunctionOffset(cond, c.idx.Columns)
+			offset = getEQFunctionOffset(cond, c.idx)
 		} else {
 			offset = getEQColOffset(cond, c.cols)
 		}
@@ -429,6 +441,7 @@ func (c *conditionChecker) checkLikeFunc(scalar *expression.ScalarFunction) bool
 		return false
 	}
 	if len(patternStr) == 0 {
+		c.reserveCICollation(scalar)
 		return true
 	}
 	escape := byte(scalar.GetArgs()[2].(*expression.Constant).Value.GetInt64())
@@ -454,9 +467,18 @@ func (c *conditionChecker) checkLikeFunc(scalar *expression.ScalarFunction) bool
 			break
 		}
 	}
+	c.reserveCICollation(scalar)
 	return true
 }
 
+// reserveCICollation reserves the LIKE condition on the column in case-insensitive collation,
+// because the range built by the sort key of the prefix may contain the strings not matching the pattern.
+func (c *conditionChecker) reserveCICollation(like *expression.ScalarFunction) {
+	if charset.IsCICollation(like.GetArgs()[0].GetType().Collate) {
+		c.shouldReserve = true
+	}
+}
+
 func (c *conditionChecker) checkColumn(expr expression.Expression) bool {
 	col, ok := expr.(*expression.Column)
 	if !ok {
@@ -466,7 +488,7 @@ func (c *conditionChecker) checkColumn(expr expression.Expression) bool {
 		return c.colName.L == col.ColName.L
 	}
 	if c.idx != nil {
-		return col.ColName.L == c.idx.Columns[c.columnOffset].Name.L
+		return col.ColName.L == c.idx.Columns[c.columnOffset].Name.L && c.idx.ComparedByCollation(col.RetType.Collate)
 	}
 	if len(c.cols) > 0 {
 		return col.Equal(c.cols[c.columnOffset], nil)
@@ -66,8 +66,8 @@ func (res *Result) Sort() *Result {
 		a := res.rows[i]
 		b := res.rows[j]
 		for i := range a {
-			if a[i] < b[i] {
-				return true
+			if a[i] != b[i] {
+				return a[i] < b[i]
 			}
 		}
 		return false
@@ -20,6 +20,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unsafe"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/mysql"
@@ -99,6 +100,11 @@ func (d *Datum) SetLength(l int) {
 	d.length = uint32(l)
 }
 
+// MemUsage gets the estimated memory usage of the datum in bytes.
+func (d *Datum) MemUsage() int64 {
+	return int64(unsafe.Sizeof(*d)) + int64(cap(d.b))
+}
+
 // IsNull checks if datum is null.
 func (d *Datum) IsNull() bool {
 	return d.k == KindNull
@@ -295,7 +295,8 @@ func (j JSON) Modify(pathExprList []PathExpression, values []JSON, mt ModifyType
 	return j, nil
 }
 
-// set is for Modify. The result JSON maybe share something with input JSON.
+// set is for Modify. The result JSON maybe share something with input JSON,
+// but the input JSON is never changed, the modified objects and arrays are copied.
 func set(j JSON, pathExpr PathExpression, value JSON, mt ModifyType) JSON {
 	if len(pathExpr.legs) == 0 {
 		if mt&ModifyReplace != 0 {
@@ -313,6 +314,7 @@ func set(j JSON, pathExpr PathExpression, value JSON, mt ModifyType) JSON {
 			shouldUnwrap = true
 		}
 		var index = currentLeg.arrayIndex
+		j = j.shallowCopy()
 		if len(j.Array) > index {
 			// e.g. json_replace('[1, 2, 3]', '$[0]', "x") => '["x", 2, 3]'
 			j.Array[index] = set(j.Array[index], subPathExpr, value, mt)
@@ -325,6 +327,7 @@ func set(j JSON, pathExpr PathExpression, value JSON, mt ModifyType) JSON {
 		}
 	} else if currentLeg.typ == pathLegKey && j.TypeCode == TypeCodeObject {
 		var key = currentLeg.dotKey
+		j = j.shallowCopy()
 		if child, ok := j.Object[key]; ok {
 			// e.g. json_replace('{"a": 1}', '$.a', 2) => '{"a": 2}'
 			j.Object[key] = set(child, subPathExpr, value, mt)
@@ -361,6 +364,7 @@ func remove(j JSON, pathExpr PathExpression) JSON {
 	currentLeg, subPathExpr := pathExpr.popOneLeg()
 	if currentLeg.typ == pathLegIndex && j.TypeCode == TypeCodeArray {
 		var index = currentLeg.arrayIndex
+		j = j.shallowCopy()
 		if len(j.Array) > index {
 			if len(subPathExpr.legs) == 0 {
 				j.Array = append(j.Array[0:index], j.Array[index+1:]...)
@@ -370,6 +374,7 @@ func remove(j JSON, pathExpr PathExpression) JSON {
 		}
 	} else if currentLeg.typ == pathLegKey && j.TypeCode == TypeCodeObject {
 		var key = currentLeg.dotKey
+		j = j.shallowCopy()
 		if child, ok := j.Object[key]; ok {
 			if len(subPathExpr.legs) == 0 {
 				delete(j.Object, key)
@@ -380,3 +385,20 @@ func remove(j JSON, pathExpr PathExpression) JSON {
 	}
 	return j
 }
+
+// shallowCopy copies the object or array of j, the elements are shared with j.
+// The modification functions copy the objects and arrays on the modified path, so that the input
+// JSON isn't changed, it may be the old value of a row.
+func (j JSON) shallowCopy() JSON {
+	switch j.TypeCode {
+	case TypeCodeObject:
+		obj := make(map[string]JSON, len(j.Object))
+		for k, v := range j.Object {
+			obj[k] = v
+		}
+		j.Object = obj
+	case TypeCodeArray:
+		j.Array = append([]JSON(nil), j.Array...)
+	}
+	return j
+}
@@ -219,6 +219,10 @@ func (s *testJSONSuite) TestJSONModify(c *C) {
 			cmp, err = CompareJSON(obtain, expected)
 			c.Assert(err, IsNil)
 			c.Assert(cmp, Equals, 0)
+			// The input JSON is not changed.
+			cmp, err = CompareJSON(base, mustParseFromString(tt.base))
+			c.Assert(err, IsNil)
+			c.Assert(cmp, Equals, 0)
 		} else {
 			c.Assert(err, NotNil)
 		}
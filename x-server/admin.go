@@ -0,0 +1,371 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xserver
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/server"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tipb/go-mysqlx/Datatypes"
+)
+
+// Notice names of the admin commands enable_notices, disable_notices and list_notices.
+const (
+	noticeWarnings          = "warnings"
+	noticeAccountExpired    = "account_expired"
+	noticeGeneratedInsertID = "generated_insert_id"
+	noticeRowsAffected      = "rows_affected"
+	noticeProducedMessage   = "produced_message"
+)
+
+// fixedNotices are the notices that are always enabled.
+var fixedNotices = []string{noticeAccountExpired, noticeGeneratedInsertID, noticeRowsAffected, noticeProducedMessage}
+
+// createCollectionSQL is the statement to create a collection, the _id column is the primary key
+// generated from the _id member of the documents.
+const createCollectionSQL = "CREATE TABLE %s%s (doc JSON, _id VARCHAR(32) GENERATED ALWAYS AS " +
+	"(JSON_UNQUOTE(JSON_EXTRACT(doc, '$._id'))) STORED NOT NULL PRIMARY KEY) CHARSET utf8mb4"
+
+// adminArgs gets the arguments of an admin command. The arguments are positional scalars,
+// the names are only used in the error messages.
+type adminArgs struct {
+	args []*Mysqlx_Datatypes.Any
+	pos  int
+}
+
+// stringArg gets the next argument as a string, it returns "" if the argument is optional and missing.
+func (a *adminArgs) stringArg(name string, optional bool) (string, error) {
+	if a.pos >= len(a.args) {
+		if optional {
+			return "", nil
+		}
+		return "", errXCmdNumArguments.GenByArgs(a.pos+1, len(a.args))
+	}
+	arg := a.args[a.pos]
+	a.pos++
+	s := arg.GetScalar()
+	if arg.GetType() == Mysqlx_Datatypes.Any_SCALAR {
+		switch s.GetType() {
+		case Mysqlx_Datatypes.Scalar_V_STRING:
+			return string(s.GetVString().GetValue()), nil
+		case Mysqlx_Datatypes.Scalar_V_OCTETS:
+			return string(s.GetVOctets().GetValue()), nil
+		}
+	}
+	return "", errXCmdArgumentType.GenByArgs(name, a.pos-1, "string")
+}
+
+// uintArg gets the next argument as an unsigned integer.
+func (a *adminArgs) uintArg(name string) (uint64, error) {
+	if a.pos >= len(a.args) {
+		return 0, errXCmdNumArguments.GenByArgs(a.pos+1, len(a.args))
+	}
+	arg := a.args[a.pos]
+	a.pos++
+	s := arg.GetScalar()
+	if arg.GetType() == Mysqlx_Datatypes.Any_SCALAR {
+		switch s.GetType() {
+		case Mysqlx_Datatypes.Scalar_V_UINT:
+			return s.GetVUnsignedInt(), nil
+		case Mysqlx_Datatypes.Scalar_V_SINT:
+			if s.GetVSignedInt() >= 0 {
+				return uint64(s.GetVSignedInt()), nil
+			}
+		}
+	}
+	return 0, errXCmdArgumentType.GenByArgs(name, a.pos-1, "unsigned int")
+}
+
+// end checks that all the arguments are used.
+func (a *adminArgs) end() error {
+	if a.pos != len(a.args) {
+		return errXCmdNumArguments.GenByArgs(a.pos, len(a.args))
+	}
+	return nil
+}
+
+// handleAdminCommand handles the admin commands sent in StmtExecute with the xplugin or mysqlx namespace.
+func (cc *clientConn) handleAdminCommand(namespace, cmd string, args []*Mysqlx_Datatypes.Any) error {
+	a := &adminArgs{args: args}
+	switch cmd {
+	case "ping":
+		if err := a.end(); err != nil {
+			return errors.Trace(err)
+		}
+		return cc.writeStmtExecuteOK(true)
+	case "list_clients":
+		if err := a.end(); err != nil {
+			return errors.Trace(err)
+		}
+		return cc.writeMemResultSet(cc.listClients())
+	case "kill_client":
+		return cc.killClient(a)
+	case "create_collection", "ensure_collection":
+		return cc.createCollection(a, cmd == "ensure_collection")
+	case "drop_collection":
+		schema, name, err := collectionArgs(a)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return cc.executeSQL("DROP TABLE "+qualifiedName(schema, name), false)
+	case "list_objects":
+		rs, err := cc.listObjects(a)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return cc.writeMemResultSet(rs)
+	case "enable_notices", "disable_notices":
+		return cc.setNotices(a, cmd == "enable_notices")
+	case "list_notices":
+		if err := a.end(); err != nil {
+			return errors.Trace(err)
+		}
+		return cc.writeMemResultSet(cc.listNotices())
+	}
+	return errXInvalidAdminCommand.GenByArgs(namespace, cmd)
+}
+
+func (cc *clientConn) writeMemResultSet(rs *memResultSet) error {
+	if err := cc.writeResultSet(rs, false, false); err != nil {
+		return errors.Trace(err)
+	}
+	return cc.writeStmtExecuteOK(false)
+}
+
+func newColumn(name string, tp byte, flag uint16) *server.ColumnInfo {
+	return &server.ColumnInfo{Name: name, OrgName: name, Type: tp, Flag: flag}
+}
+
+func (cc *clientConn) listClients() *memResultSet {
+	rs := &memResultSet{
+		columns: []*server.ColumnInfo{
+			newColumn("client_id", mysql.TypeLonglong, uint16(mysql.UnsignedFlag)),
+			newColumn("user", mysql.TypeVarchar, 0),
+			newColumn("host", mysql.TypeVarchar, 0),
+			newColumn("sql_session", mysql.TypeLonglong, uint16(mysql.UnsignedFlag)),
+		},
+	}
+	s := cc.server
+	s.rwlock.RLock()
+	for id, client := range s.clients {
+		if client.killed || client.ctx == nil {
+			continue
+		}
+		host, _, err := net.SplitHostPort(client.conn.RemoteAddr().String())
+		if err != nil {
+			host = client.conn.RemoteAddr().String()
+		}
+		rs.rows = append(rs.rows, types.MakeDatums(uint64(id), client.user, host, uint64(id)))
+	}
+	s.rwlock.RUnlock()
+	sort.Slice(rs.rows, func(i, j int) bool {
+		return rs.rows[i][0].GetUint64() < rs.rows[j][0].GetUint64()
+	})
+	return rs
+}
+
+func (cc *clientConn) killClient(a *adminArgs) error {
+	id, err := a.uintArg("id")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = a.end(); err != nil {
+		return errors.Trace(err)
+	}
+	s := cc.server
+	s.rwlock.RLock()
+	client, ok := s.clients[uint32(id)]
+	ok = ok && client.ctx != nil
+	s.rwlock.RUnlock()
+	if !ok {
+		return errNoSuchThread.GenByArgs(id)
+	}
+	s.Kill(id, false)
+	return cc.writeStmtExecuteOK(true)
+}
+
+// collectionArgs gets the schema and the name of the collection, the schema is the current
+// database if it's empty.
+func collectionArgs(a *adminArgs) (string, string, error) {
+	schema, err := a.stringArg("schema", false)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	name, err := a.stringArg("name", false)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	if err = a.end(); err != nil {
+		return "", "", errors.Trace(err)
+	}
+	if name == "" {
+		return "", "", errXBadTable
+	}
+	return schema, name, nil
+}
+
+func qualifiedName(schema, name string) string {
+	if schema == "" {
+		return quoteIdentifier(name)
+	}
+	return quoteIdentifier(schema) + "." + quoteIdentifier(name)
+}
+
+func (cc *clientConn) createCollection(a *adminArgs, ifNotExists bool) error {
+	schema, name, err := collectionArgs(a)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ifNotExistsStr := ""
+	if ifNotExists {
+		ifNotExistsStr = "IF NOT EXISTS "
+	}
+	return cc.executeSQL(fmt.Sprintf(createCollectionSQL, ifNotExistsStr, qualifiedName(schema, name)), false)
+}
+
+// listObjects lists the tables, the views and the collections of a schema,
+// the objects can be filtered by a LIKE pattern of the name.
+func (cc *clientConn) listObjects(a *adminArgs) (*memResultSet, error) {
+	schema, err := a.stringArg("schema", true)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	pattern, err := a.stringArg("pattern", true)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = a.end(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if schema == "" {
+		schema = cc.ctx.CurrentDB()
+	}
+	cond := "TABLE_SCHEMA = " + quoteString(schema)
+	if pattern != "" {
+		cond += " AND TABLE_NAME LIKE " + quoteString(pattern)
+	}
+	tables, err := cc.queryRows("SELECT TABLE_NAME, TABLE_TYPE FROM information_schema.TABLES WHERE " + cond +
+		" ORDER BY TABLE_NAME")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	columns, err := cc.queryRows("SELECT TABLE_NAME, COLUMN_NAME, DATA_TYPE FROM information_schema.COLUMNS WHERE " + cond)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// A collection has the doc and _id columns only.
+	numColumns := make(map[string]int)
+	docColumns := make(map[string]int)
+	for _, row := range columns {
+		tbl := row[0].GetString()
+		numColumns[tbl]++
+		col, tp := row[1].GetString(), row[2].GetString()
+		if (col == docColumn && tp == "json") || col == idMember {
+			docColumns[tbl]++
+		}
+	}
+	rs := &memResultSet{
+		columns: []*server.ColumnInfo{
+			newColumn("name", mysql.TypeVarchar, 0),
+			newColumn("type", mysql.TypeVarchar, 0),
+		},
+	}
+	for _, row := range tables {
+		tbl := row[0].GetString()
+		tp := "TABLE"
+		if row[1].GetString() == "VIEW" {
+			tp = "VIEW"
+		} else if numColumns[tbl] == 2 && docColumns[tbl] == 2 {
+			tp = "COLLECTION"
+		}
+		rs.rows = append(rs.rows, types.MakeDatums(tbl, tp))
+	}
+	return rs, nil
+}
+
+// queryRows executes the sql and returns the rows of the first result set.
+func (cc *clientConn) queryRows(sql string) ([][]types.Datum, error) {
+	rss, err := cc.ctx.Execute(sql)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer func() {
+		for _, rs := range rss {
+			terror.Call(rs.Close)
+		}
+	}()
+	if len(rss) == 0 {
+		return nil, nil
+	}
+	var rows [][]types.Datum
+	for {
+		row, err := rss[0].Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			return rows, nil
+		}
+		rows = append(rows, row)
+	}
+}
+
+func (cc *clientConn) setNotices(a *adminArgs, enable bool) error {
+	if len(a.args) == 0 {
+		return errXCmdNumArguments.GenByArgs(1, 0)
+	}
+	noWarnings := cc.noWarnings
+	for a.pos < len(a.args) {
+		name, err := a.stringArg("notice", false)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		switch name {
+		case noticeWarnings:
+			noWarnings = !enable
+		case noticeAccountExpired, noticeGeneratedInsertID, noticeRowsAffected, noticeProducedMessage:
+			if !enable {
+				return errXCannotDisableNotice.GenByArgs(name)
+			}
+		default:
+			return errXBadNotice.GenByArgs(name)
+		}
+	}
+	cc.noWarnings = noWarnings
+	return cc.writeStmtExecuteOK(true)
+}
+
+func (cc *clientConn) listNotices() *memResultSet {
+	rs := &memResultSet{
+		columns: []*server.ColumnInfo{
+			newColumn("notice", mysql.TypeVarchar, 0),
+			newColumn("enabled", mysql.TypeLonglong, 0),
+		},
+	}
+	enabled := int64(1)
+	if cc.noWarnings {
+		enabled = 0
+	}
+	rs.rows = append(rs.rows, types.MakeDatums(noticeWarnings, enabled))
+	for _, name := range fixedNotices {
+		rs.rows = append(rs.rows, types.MakeDatums(name, int64(1)))
+	}
+	return rs
+}
@@ -0,0 +1,260 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xserver
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"net"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/server"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/auth"
+	"github.com/pingcap/tipb/go-mysqlx"
+	"github.com/pingcap/tipb/go-mysqlx/Datatypes"
+	"github.com/pingcap/tipb/go-mysqlx/Notice"
+	"github.com/pingcap/tipb/go-mysqlx/Session"
+)
+
+// Authentication mechanism names.
+const (
+	mechMySQL41 = "MYSQL41"
+	mechPlain   = "PLAIN"
+)
+
+// account is the account the client authenticates as.
+type account struct {
+	schema string
+	user   string
+	// scramble is the password scrambled with the salt of the connection, see auth.CheckScrambledPassword.
+	scramble []byte
+	// password is the plaintext password, it's only known by PLAIN and used for the sha2 authentication plugins.
+	password []byte
+}
+
+// authMechanism is an authentication mechanism of X Protocol.
+type authMechanism interface {
+	// next handles the auth data sent by the client in AuthenticateStart or AuthenticateContinue.
+	// It returns the challenge sent back in AuthenticateContinue, or the account to authenticate
+	// if the client has sent all the data.
+	next(cc *clientConn, data []byte) (challenge []byte, acc *account, err error)
+}
+
+// mysql41Auth is the challenge-response authentication like mysql_native_password.
+// The server sends the salt, then the client replies with "schema\0user\0*hex(scramble)",
+// the scramble part is empty if the password is empty.
+type mysql41Auth struct {
+	saltSent bool
+}
+
+func (a *mysql41Auth) next(cc *clientConn, data []byte) ([]byte, *account, error) {
+	if !a.saltSent {
+		a.saltSent = true
+		return cc.salt, nil, nil
+	}
+	parts := bytes.SplitN(data, []byte{0}, 3)
+	if len(parts) != 3 {
+		return nil, nil, errXInvalidProtocolData.GenByArgs("malformed MYSQL41 auth data")
+	}
+	acc := &account{schema: string(parts[0]), user: string(parts[1])}
+	if len(parts[2]) > 0 {
+		if parts[2][0] != '*' {
+			return nil, nil, errXInvalidProtocolData.GenByArgs("malformed MYSQL41 auth data")
+		}
+		scramble, err := hex.DecodeString(string(parts[2][1:]))
+		if err != nil {
+			return nil, nil, errXInvalidProtocolData.GenByArgs("malformed MYSQL41 auth data")
+		}
+		acc.scramble = scramble
+	}
+	return nil, acc, nil
+}
+
+// plainAuth receives the plaintext password in "schema\0user\0password",
+// so it's only allowed on a secure transport.
+type plainAuth struct{}
+
+func (a *plainAuth) next(cc *clientConn, data []byte) ([]byte, *account, error) {
+	parts := bytes.SplitN(data, []byte{0}, 3)
+	if len(parts) != 3 {
+		return nil, nil, errXInvalidProtocolData.GenByArgs("malformed PLAIN auth data")
+	}
+	return nil, &account{
+		schema:   string(parts[0]),
+		user:     string(parts[1]),
+		scramble: auth.ScramblePassword(cc.salt, string(parts[2])),
+		password: parts[2],
+	}, nil
+}
+
+// isSecureTransport checks whether the client connects through a unix socket,
+// the plaintext password is only accepted on a secure transport.
+func (cc *clientConn) isSecureTransport() bool {
+	_, ok := cc.conn.(*net.UnixConn)
+	return ok
+}
+
+func (cc *clientConn) authMechanismNames() []string {
+	if cc.isSecureTransport() {
+		return []string{mechMySQL41, mechPlain}
+	}
+	return []string{mechMySQL41}
+}
+
+// startAuthentication gives the client handshakeTimeout to authenticate, the connection is closed
+// if the client doesn't send anything before the deadline.
+func (cc *clientConn) startAuthentication() error {
+	cc.authDeadline = time.Now().Add(handshakeTimeout)
+	return errors.Trace(cc.conn.SetDeadline(cc.authDeadline))
+}
+
+// handleAuthentication handles the authentication messages, both in the handshake and after the
+// session is reset. The connection is closed when the client doesn't authenticate before the
+// deadline or after maxAuthAttempts failed authentications.
+func (cc *clientConn) handleAuthentication(msgType Mysqlx.ClientMessages_Type, payload []byte) error {
+	if cc.ctx == nil && time.Now().After(cc.authDeadline) {
+		log.Warnf("[%d] authentication timeout, close this connection", cc.connectionID)
+		return errors.Trace(io.EOF)
+	}
+	var err error
+	if msgType == Mysqlx.ClientMessages_SESS_AUTHENTICATE_START {
+		err = cc.handleAuthenticateStart(payload)
+	} else {
+		err = cc.handleAuthenticateContinue(payload)
+	}
+	if err == nil || cc.authFailures < maxAuthAttempts {
+		return errors.Trace(err)
+	}
+	log.Warnf("[%d] too many failed authentications, close this connection", cc.connectionID)
+	if err1 := cc.writeError(err); err1 != nil {
+		return errors.Trace(err1)
+	}
+	if err1 := cc.flush(); err1 != nil {
+		return errors.Trace(err1)
+	}
+	return errors.Trace(io.EOF)
+}
+
+func (cc *clientConn) handleAuthenticateStart(payload []byte) error {
+	var msg Mysqlx_Session.AuthenticateStart
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return errors.Trace(errXBadMessage)
+	}
+	if cc.ctx != nil {
+		return errUnknownCom
+	}
+	switch msg.GetMechName() {
+	case mechMySQL41:
+		cc.auth = &mysql41Auth{}
+	case mechPlain:
+		if !cc.isSecureTransport() {
+			return errNotSupportedAuthMode.GenByArgs(msg.GetMechName())
+		}
+		cc.auth = &plainAuth{}
+	default:
+		return errNotSupportedAuthMode.GenByArgs(msg.GetMechName())
+	}
+	return cc.authenticate(msg.GetAuthData())
+}
+
+func (cc *clientConn) handleAuthenticateContinue(payload []byte) error {
+	var msg Mysqlx_Session.AuthenticateContinue
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return errors.Trace(errXBadMessage)
+	}
+	if cc.auth == nil {
+		return errUnknownCom
+	}
+	return cc.authenticate(msg.GetAuthData())
+}
+
+// authenticate passes the auth data to the authentication in progress,
+// the session is opened once the client has sent all the auth data.
+func (cc *clientConn) authenticate(data []byte) error {
+	challenge, acc, err := cc.auth.next(cc, data)
+	if err != nil {
+		cc.auth = nil
+		cc.authFailures++
+		return errors.Trace(err)
+	}
+	if acc == nil {
+		return cc.writePacket(Mysqlx.ServerMessages_SESS_AUTHENTICATE_CONTINUE,
+			&Mysqlx_Session.AuthenticateContinue{AuthData: challenge})
+	}
+	cc.auth = nil
+	if err = cc.openSessionAndDoAuth(acc); err != nil {
+		cc.authFailures++
+		return errors.Trace(err)
+	}
+	if err = cc.conn.SetDeadline(time.Time{}); err != nil {
+		return errors.Trace(err)
+	}
+	err = cc.writeSessionStateChanged(Mysqlx_Notice.SessionStateChanged_CLIENT_ID_ASSIGNED, &Mysqlx_Datatypes.Scalar{
+		Type:         Mysqlx_Datatypes.Scalar_V_UINT.Enum(),
+		VUnsignedInt: proto.Uint64(uint64(cc.connectionID)),
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return cc.writePacket(Mysqlx.ServerMessages_SESS_AUTHENTICATE_OK, &Mysqlx_Session.AuthenticateOk{})
+}
+
+func (cc *clientConn) openSessionAndDoAuth(acc *account) error {
+	ctx, err := cc.server.driver.OpenCtx(uint64(cc.connectionID), 0, cc.collation, acc.schema, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !cc.server.skipAuth() {
+		addr := cc.conn.RemoteAddr().String()
+		host, _, err1 := net.SplitHostPort(addr)
+		if err1 != nil || !authAccount(ctx, &auth.UserIdentity{Username: acc.user, Hostname: host}, acc, cc.salt) {
+			log.Warnf("[%d] access denied for user %s from %s", cc.connectionID, acc.user, addr)
+			terror.Log(errors.Trace(ctx.Close()))
+			return errors.Trace(errAccessDenied)
+		}
+	}
+	if acc.schema != "" {
+		if _, err = ctx.Execute("use " + quoteIdentifier(acc.schema)); err != nil {
+			terror.Log(errors.Trace(ctx.Close()))
+			return errors.Trace(err)
+		}
+	}
+	ctx.SetSessionManager(cc.server)
+	cc.user = acc.user
+	cc.dbname = acc.schema
+	cc.server.rwlock.Lock()
+	cc.ctx = ctx
+	cc.server.rwlock.Unlock()
+	return nil
+}
+
+// authAccount verifies the account with the auth data required by the authentication plugin of the user.
+func authAccount(ctx server.QueryCtx, user *auth.UserIdentity, acc *account, salt []byte) bool {
+	switch ctx.AuthPlugin(user) {
+	case mysql.AuthCachingSha2Password, mysql.AuthSha256Password:
+		if acc.password == nil {
+			// MYSQL41 can't authenticate the sha2 plugins.
+			return false
+		}
+		return ctx.Auth(user, acc.password, salt)
+	default:
+		return ctx.Auth(user, acc.scramble, salt)
+	}
+}
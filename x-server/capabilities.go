@@ -0,0 +1,128 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xserver
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+	"github.com/pingcap/tipb/go-mysqlx"
+	"github.com/pingcap/tipb/go-mysqlx/Connection"
+	"github.com/pingcap/tipb/go-mysqlx/Datatypes"
+)
+
+// Capability names.
+const (
+	capTLS            = "tls"
+	capAuthMechanisms = "authentication.mechanisms"
+	capDocFormats     = "doc.formats"
+	capNodeType       = "node_type"
+	capPwdExpireOK    = "client.pwd_expire_ok"
+)
+
+// handleCapabilitiesGet sends the capabilities of the server to the client.
+func (cc *clientConn) handleCapabilitiesGet() error {
+	caps := &Mysqlx_Connection.Capabilities{
+		Capabilities: []*Mysqlx_Connection.Capability{
+			newCapability(capTLS, newBoolAny(false)),
+			newCapability(capAuthMechanisms, newStringArrayAny(cc.authMechanismNames())),
+			newCapability(capDocFormats, newStringAny("text")),
+			newCapability(capNodeType, newStringAny("mysql")),
+			newCapability(capPwdExpireOK, newBoolAny(cc.pwdExpireOK)),
+		},
+	}
+	return cc.writePacket(Mysqlx.ServerMessages_CONN_CAPABILITIES, caps)
+}
+
+// handleCapabilitiesSet sets the capabilities requested by the client,
+// none of them is set if any of them can't be set.
+func (cc *clientConn) handleCapabilitiesSet(payload []byte) error {
+	var msg Mysqlx_Connection.CapabilitiesSet
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return errors.Trace(errXBadMessage)
+	}
+	pwdExpireOK := cc.pwdExpireOK
+	for _, c := range msg.GetCapabilities().GetCapabilities() {
+		switch c.GetName() {
+		case capTLS:
+			v, ok := anyToBool(c.GetValue())
+			// TLS is not supported by the x server yet.
+			if !ok || v {
+				return errXCapabilitiesPrepareFailed.GenByArgs(c.GetName())
+			}
+		case capPwdExpireOK:
+			v, ok := anyToBool(c.GetValue())
+			if !ok {
+				return errXCapabilitiesPrepareFailed.GenByArgs(c.GetName())
+			}
+			pwdExpireOK = v
+		default:
+			return errXCapabilityNotFound.GenByArgs(c.GetName())
+		}
+	}
+	cc.pwdExpireOK = pwdExpireOK
+	return cc.writeOK("")
+}
+
+func newCapability(name string, value *Mysqlx_Datatypes.Any) *Mysqlx_Connection.Capability {
+	return &Mysqlx_Connection.Capability{Name: proto.String(name), Value: value}
+}
+
+func newScalarAny(scalar *Mysqlx_Datatypes.Scalar) *Mysqlx_Datatypes.Any {
+	return &Mysqlx_Datatypes.Any{
+		Type:   Mysqlx_Datatypes.Any_SCALAR.Enum(),
+		Scalar: scalar,
+	}
+}
+
+func newBoolAny(v bool) *Mysqlx_Datatypes.Any {
+	return newScalarAny(&Mysqlx_Datatypes.Scalar{
+		Type:  Mysqlx_Datatypes.Scalar_V_BOOL.Enum(),
+		VBool: proto.Bool(v),
+	})
+}
+
+func newStringAny(v string) *Mysqlx_Datatypes.Any {
+	return newScalarAny(&Mysqlx_Datatypes.Scalar{
+		Type:    Mysqlx_Datatypes.Scalar_V_STRING.Enum(),
+		VString: &Mysqlx_Datatypes.Scalar_String{Value: []byte(v)},
+	})
+}
+
+func newStringArrayAny(vs []string) *Mysqlx_Datatypes.Any {
+	arr := &Mysqlx_Datatypes.Array{}
+	for _, v := range vs {
+		arr.Value = append(arr.Value, newStringAny(v))
+	}
+	return &Mysqlx_Datatypes.Any{
+		Type:  Mysqlx_Datatypes.Any_ARRAY.Enum(),
+		Array: arr,
+	}
+}
+
+// anyToBool gets the bool value of a scalar, integers are accepted as well.
+func anyToBool(v *Mysqlx_Datatypes.Any) (bool, bool) {
+	if v.GetType() != Mysqlx_Datatypes.Any_SCALAR {
+		return false, false
+	}
+	s := v.GetScalar()
+	switch s.GetType() {
+	case Mysqlx_Datatypes.Scalar_V_BOOL:
+		return s.GetVBool(), true
+	case Mysqlx_Datatypes.Scalar_V_SINT:
+		return s.GetVSignedInt() != 0, true
+	case Mysqlx_Datatypes.Scalar_V_UINT:
+		return s.GetVUnsignedInt() != 0, true
+	}
+	return false, false
+}
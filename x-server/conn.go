@@ -14,74 +14,165 @@
 package xserver
 
 import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
 	"io"
 	"net"
+	"runtime"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/golang/protobuf/proto"
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/server"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/arena"
+	"github.com/pingcap/tipb/go-mysqlx"
 )
 
+const (
+	defaultReaderSize = 16 * 1024
+	defaultWriterSize = 16 * 1024
+	// maxPacketSize is the max size of a message sent by the client, it's the same as the
+	// default value of mysqlx_max_allowed_packet in MySQL.
+	maxPacketSize = 64 * 1024 * 1024
+	// maxAuthAttempts is the number of failed authentications after which the connection is closed.
+	maxAuthAttempts = 3
+)
+
+// handshakeTimeout is the time the client has to finish the handshake, it's the same as the
+// default value of connect_timeout in MySQL.
+var handshakeTimeout = 10 * time.Second
+
 // clientConn represents a connection between server and client,
 // it maintains connection specific state, handles client query.
 type clientConn struct {
 	conn         net.Conn
+	bufReader    *bufio.Reader
+	bufWriter    *bufio.Writer
 	server       *Server         // a reference of server instance.
+	ctx          server.QueryCtx // an interface to execute sql statements, it's nil until the client is authenticated.
 	connectionID uint32          // atomically allocated by a global variable, unique in process scope.
 	collation    uint8           // collation used by client, may be different from the collation used by database.
 	user         string          // user of the client.
 	dbname       string          // default database name.
 	salt         []byte          // random bytes used for authentication.
 	alloc        arena.Allocator // an memory allocator for reducing memory allocation.
+	auth         authMechanism   // the authentication in progress, nil if there is none.
+	expects      []*expectBlock  // the stack of the open expectation blocks.
+	pwdExpireOK  bool            // the client.pwd_expire_ok capability set by the client.
+	noWarnings   bool            // whether the warnings notice is disabled by the client.
+	authFailures int             // the number of failed authentications of the connection.
+	authDeadline time.Time       // the time the client has to authenticate before, while it's not authenticated.
 	killed       bool
 }
 
+func (cc *clientConn) String() string {
+	collationStr := mysql.Collations[cc.collation]
+	return fmt.Sprintf("id:%d, addr:%s, collation:%s, user:%s",
+		cc.connectionID, cc.conn.RemoteAddr(), collationStr, cc.user,
+	)
+}
+
+// Run reads client messages and writes the results to client in for loop, if there is a panic during
+// message handling, it will be recovered and log the panic error.
+// This function returns and the connection is closed if there is an IO error or there is a panic.
 func (cc *clientConn) Run() {
+	const size = 4096
 	defer func() {
-		x := recover()
-		if x != nil {
-			log.Error(x)
+		r := recover()
+		if r != nil {
+			buf := make([]byte, size)
+			stackSize := runtime.Stack(buf, false)
+			buf = buf[:stackSize]
+			log.Errorf("[%d] %v, %s", cc.connectionID, r, buf)
 		}
 		err := cc.Close()
 		terror.Log(errors.Trace(err))
 	}()
 
 	for !cc.killed {
+		cc.alloc.Reset()
 		tp, payload, err := cc.readPacket()
-		if err != nil {
-			if terror.ErrorNotEqual(err, io.EOF) {
+		if err != nil || cc.killed {
+			if terror.ErrorNotEqual(err, io.EOF) && !cc.killed {
 				log.Errorf("[%d] read packet error, close this connection %s",
 					cc.connectionID, errors.ErrorStack(err))
 			}
+			if cc.killed {
+				log.Warnf("[%d] session is killed.", cc.connectionID)
+			}
 			return
 		}
 		if err = cc.dispatch(tp, payload); err != nil {
-			if terror.ErrorEqual(err, terror.ErrResultUndetermined) {
+			if terror.ErrorEqual(err, io.EOF) {
+				return
+			} else if terror.ErrResultUndetermined.Equal(err) {
 				log.Errorf("[%d] result undetermined error, close this connection %s",
 					cc.connectionID, errors.ErrorStack(err))
-			} else if terror.ErrorEqual(err, terror.ErrCritical) {
+				return
+			} else if terror.ErrCritical.Equal(err) {
 				log.Errorf("[%d] critical error, stop the server listener %s",
 					cc.connectionID, errors.ErrorStack(err))
 				select {
 				case cc.server.stopListenerCh <- struct{}{}:
 				default:
 				}
+				return
 			}
-			log.Warnf("[%d] dispatch error: %s, %s", cc.connectionID, cc, err)
-			cc.writeError(err)
+			log.Warnf("[%d] dispatch error: %s, %s", cc.connectionID, cc, errors.ErrorStack(err))
+			err1 := cc.writeError(err)
+			terror.Log(errors.Trace(err1))
+		}
+		if err = cc.flush(); err != nil {
+			log.Errorf("[%d] write packet error, close this connection %s",
+				cc.connectionID, errors.ErrorStack(err))
 			return
 		}
 	}
 }
 
 func (cc *clientConn) Close() error {
-	err := cc.conn.Close()
-	return errors.Trace(err)
+	cc.server.rwlock.Lock()
+	delete(cc.server.clients, cc.connectionID)
+	cc.server.rwlock.Unlock()
+	if !cc.killed {
+		// The connection of a killed client is closed by Kill.
+		err := cc.conn.Close()
+		terror.Log(errors.Trace(err))
+	}
+	if cc.ctx != nil {
+		return cc.ctx.Close()
+	}
+	return nil
 }
 
+// handshake negotiates the capabilities and authenticates the client.
+// Unlike the MySQL protocol, the server doesn't send a greeting, the client starts with the
+// capabilities messages and then authenticates with one of the authentication mechanisms.
+// The limits of the authentication are enforced by handleAuthentication.
 func (cc *clientConn) handshake() error {
-	// TODO: implement it.
+	if err := cc.startAuthentication(); err != nil {
+		return errors.Trace(err)
+	}
+	for cc.ctx == nil {
+		tp, payload, err := cc.readPacket()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = cc.dispatch(tp, payload); err != nil {
+			if terror.ErrorEqual(err, io.EOF) {
+				return errors.Trace(err)
+			}
+			err1 := cc.writeError(err)
+			terror.Log(errors.Trace(err1))
+		}
+		if err = cc.flush(); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	return nil
 }
 
@@ -92,12 +183,132 @@ func (cc *clientConn) handshake() error {
 // ------------------------------------------------------
 // See: https://dev.mysql.com/doc/internals/en/x-protocol-messages-messages.html
 func (cc *clientConn) readPacket() (byte, []byte, error) {
-	return 0x00, nil, nil
+	var header [5]byte
+	if _, err := io.ReadFull(cc.bufReader, header[:]); err != nil {
+		return 0x00, nil, errors.Trace(err)
+	}
+	length := binary.LittleEndian.Uint32(header[:4])
+	if length < 1 || length > maxPacketSize {
+		return 0x00, nil, errors.Trace(errXBadMessage)
+	}
+	payload := make([]byte, length-1)
+	if _, err := io.ReadFull(cc.bufReader, payload); err != nil {
+		return 0x00, nil, errors.Trace(err)
+	}
+	return header[4], payload, nil
 }
 
+// writePacket writes a message to the buffer, it's sent to the client when flush is called.
+func (cc *clientConn) writePacket(tp Mysqlx.ServerMessages_Type, msg proto.Message) error {
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data := cc.alloc.AllocWithLen(5, 5+len(payload))
+	binary.LittleEndian.PutUint32(data, uint32(len(payload)+1))
+	data[4] = byte(tp)
+	data = append(data, payload...)
+	_, err = cc.bufWriter.Write(data)
+	return errors.Trace(err)
+}
+
+func (cc *clientConn) flush() error {
+	return errors.Trace(cc.bufWriter.Flush())
+}
+
+// dispatch handles a client message, the messages other than the connection and session ones
+// are only accepted after the client is authenticated.
 func (cc *clientConn) dispatch(tp byte, payload []byte) error {
-	return nil
+	msgType := Mysqlx.ClientMessages_Type(tp)
+	switch msgType {
+	case Mysqlx.ClientMessages_CON_CAPABILITIES_GET:
+		return cc.handleCapabilitiesGet()
+	case Mysqlx.ClientMessages_CON_CAPABILITIES_SET:
+		return cc.handleCapabilitiesSet(payload)
+	case Mysqlx.ClientMessages_CON_CLOSE, Mysqlx.ClientMessages_SESS_CLOSE:
+		if err := cc.writeOK("bye!"); err != nil {
+			return errors.Trace(err)
+		}
+		if err := cc.flush(); err != nil {
+			return errors.Trace(err)
+		}
+		return io.EOF
+	case Mysqlx.ClientMessages_SESS_AUTHENTICATE_START, Mysqlx.ClientMessages_SESS_AUTHENTICATE_CONTINUE:
+		return cc.handleAuthentication(msgType, payload)
+	}
+	if cc.ctx == nil {
+		return errUnknownCom
+	}
+
+	switch msgType {
+	case Mysqlx.ClientMessages_EXPECT_OPEN:
+		return cc.handleExpectOpen(payload)
+	case Mysqlx.ClientMessages_EXPECT_CLOSE:
+		return cc.handleExpectClose()
+	}
+	// A failed message in an expectation block with the no_error condition fails the following messages.
+	if err := cc.checkExpectations(); err != nil {
+		return errors.Trace(err)
+	}
+	err := cc.dispatchSession(msgType, payload)
+	if err != nil {
+		cc.markExpectFailed()
+	}
+	return errors.Trace(err)
+}
+
+// dispatchSession handles the messages of an authenticated session.
+func (cc *clientConn) dispatchSession(msgType Mysqlx.ClientMessages_Type, payload []byte) error {
+	switch msgType {
+	case Mysqlx.ClientMessages_SESS_RESET:
+		return cc.handleSessionReset()
+	case Mysqlx.ClientMessages_SQL_STMT_EXECUTE:
+		return cc.handleStmtExecute(payload)
+	case Mysqlx.ClientMessages_CRUD_FIND:
+		return cc.handleCrudFind(payload)
+	case Mysqlx.ClientMessages_CRUD_INSERT:
+		return cc.handleCrudInsert(payload)
+	case Mysqlx.ClientMessages_CRUD_UPDATE:
+		return cc.handleCrudUpdate(payload)
+	case Mysqlx.ClientMessages_CRUD_DELETE:
+		return cc.handleCrudDelete(payload)
+	case Mysqlx.ClientMessages_CRUD_CREATE_VIEW:
+		return cc.handleCrudCreateView(payload)
+	case Mysqlx.ClientMessages_CRUD_MODIFY_VIEW:
+		return cc.handleCrudModifyView(payload)
+	case Mysqlx.ClientMessages_CRUD_DROP_VIEW:
+		return cc.handleCrudDropView(payload)
+	default:
+		return errUnknownCom
+	}
+}
+
+// handleSessionReset closes the current session, the client needs to authenticate again to start a new one,
+// with the same limits as the handshake.
+func (cc *clientConn) handleSessionReset() error {
+	cc.server.rwlock.Lock()
+	ctx := cc.ctx
+	cc.ctx = nil
+	cc.server.rwlock.Unlock()
+	cc.expects = nil
+	cc.noWarnings = false
+	if err := ctx.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := cc.startAuthentication(); err != nil {
+		return errors.Trace(err)
+	}
+	return cc.writeOK("")
+}
+
+func (cc *clientConn) writeOK(msg string) error {
+	ok := &Mysqlx.Ok{}
+	if msg != "" {
+		ok.Msg = &msg
+	}
+	return cc.writePacket(Mysqlx.ServerMessages_OK, ok)
 }
 
-func (cc *clientConn) writeError(e error) {
+func (cc *clientConn) writeError(e error) error {
+	return cc.writePacket(Mysqlx.ServerMessages_ERROR, errorToMsg(e, Mysqlx.Error_ERROR))
 }
@@ -0,0 +1,534 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xserver
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+	"github.com/pingcap/tipb/go-mysqlx/Crud"
+	"github.com/pingcap/tipb/go-mysqlx/Datatypes"
+	"github.com/pingcap/tipb/go-mysqlx/Expr"
+	"github.com/twinj/uuid"
+)
+
+// The CRUD messages are translated to SQL statements. A collection is a table with the documents in
+// the JSON column doc, and the _id column generated from the _id member of the documents, see createCollectionSQL.
+
+// idMember is the member of a document that identifies the document.
+const idMember = "_id"
+
+func (cc *clientConn) handleCrudFind(payload []byte) error {
+	var msg Mysqlx_Crud.Find
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return errors.Trace(errXBadMessage)
+	}
+	sql, err := buildFind(&msg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return cc.executeSQL(sql, false)
+}
+
+func (cc *clientConn) handleCrudInsert(payload []byte) error {
+	var msg Mysqlx_Crud.Insert
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return errors.Trace(errXBadMessage)
+	}
+	sql, err := buildInsert(&msg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return cc.executeSQL(sql, false)
+}
+
+func (cc *clientConn) handleCrudUpdate(payload []byte) error {
+	var msg Mysqlx_Crud.Update
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return errors.Trace(errXBadMessage)
+	}
+	sql, err := buildUpdate(&msg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return cc.executeSQL(sql, false)
+}
+
+func (cc *clientConn) handleCrudDelete(payload []byte) error {
+	var msg Mysqlx_Crud.Delete
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return errors.Trace(errXBadMessage)
+	}
+	sql, err := buildDelete(&msg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return cc.executeSQL(sql, false)
+}
+
+func (cc *clientConn) handleCrudCreateView(payload []byte) error {
+	var msg Mysqlx_Crud.CreateView
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return errors.Trace(errXBadMessage)
+	}
+	sql, err := buildView(msg.GetReplaceExisting(), msg.GetCollection(), msg.Definer, msg.Algorithm, msg.Security,
+		msg.Check, msg.GetColumn(), msg.GetStmt())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return cc.executeSQL(sql, false)
+}
+
+// handleCrudModifyView replaces the view with the new definition, the query of the view must be
+// sent by the client because ALTER VIEW is not supported.
+func (cc *clientConn) handleCrudModifyView(payload []byte) error {
+	var msg Mysqlx_Crud.ModifyView
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return errors.Trace(errXBadMessage)
+	}
+	if msg.GetStmt() == nil {
+		return errXInvalidArgument.GenByArgs("stmt")
+	}
+	sql, err := buildView(true, msg.GetCollection(), msg.Definer, msg.Algorithm, msg.Security,
+		msg.Check, msg.GetColumn(), msg.GetStmt())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return cc.executeSQL(sql, false)
+}
+
+func (cc *clientConn) handleCrudDropView(payload []byte) error {
+	var msg Mysqlx_Crud.DropView
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return errors.Trace(errXBadMessage)
+	}
+	name, err := tableName(msg.GetCollection())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sql := "DROP VIEW " + name
+	if msg.GetIfExists() {
+		sql = "DROP VIEW IF EXISTS " + name
+	}
+	return cc.executeSQL(sql, false)
+}
+
+func isDocument(model Mysqlx_Crud.DataModel) bool {
+	// The data model is DOCUMENT by default.
+	return model != Mysqlx_Crud.DataModel_TABLE
+}
+
+// tableName returns the quoted name of the table or collection.
+func tableName(coll *Mysqlx_Crud.Collection) (string, error) {
+	if coll.GetName() == "" {
+		return "", errXBadTable
+	}
+	return qualifiedName(coll.GetSchema(), coll.GetName()), nil
+}
+
+func buildFind(msg *Mysqlx_Crud.Find) (string, error) {
+	isDoc := isDocument(msg.GetDataModel())
+	args := msg.GetArgs()
+	name, err := tableName(msg.GetCollection())
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("SELECT ")
+	if err = writeProjection(&buf, msg.GetProjection(), args, isDoc); err != nil {
+		return "", errors.Trace(err)
+	}
+	buf.WriteString(" FROM ")
+	buf.WriteString(name)
+	if err = writeCriteria(&buf, " WHERE ", msg.GetCriteria(), args, isDoc); err != nil {
+		return "", errors.Trace(err)
+	}
+	for i, e := range msg.GetGrouping() {
+		if i == 0 {
+			buf.WriteString(" GROUP BY ")
+		} else {
+			buf.WriteString(", ")
+		}
+		s, err := exprToSQL(e, args, isDoc)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		buf.WriteString(s)
+	}
+	if err = writeCriteria(&buf, " HAVING ", msg.GetGroupingCriteria(), args, isDoc); err != nil {
+		return "", errors.Trace(err)
+	}
+	if err = writeOrder(&buf, msg.GetOrder(), args, isDoc); err != nil {
+		return "", errors.Trace(err)
+	}
+	if limit := msg.GetLimit(); limit != nil {
+		buf.WriteString(" LIMIT ")
+		if limit.Offset != nil {
+			buf.WriteString(strconv.FormatUint(limit.GetOffset(), 10))
+			buf.WriteString(", ")
+		}
+		buf.WriteString(strconv.FormatUint(limit.GetRowCount(), 10))
+	}
+	return buf.String(), nil
+}
+
+// writeProjection writes the select fields. For a collection, the projection builds a new document
+// with the aliases as the members.
+func writeProjection(buf *bytes.Buffer, projs []*Mysqlx_Crud.Projection, args []*Mysqlx_Datatypes.Scalar, isDoc bool) error {
+	if len(projs) == 0 {
+		if isDoc {
+			buf.WriteString(docColumn)
+		} else {
+			buf.WriteByte('*')
+		}
+		return nil
+	}
+	if isDoc {
+		buf.WriteString("JSON_OBJECT(")
+	}
+	for i, proj := range projs {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		s, err := exprToSQL(proj.GetSource(), args, isDoc)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !isDoc {
+			buf.WriteString(s)
+			if proj.Alias != nil {
+				buf.WriteString(" AS ")
+				buf.WriteString(quoteIdentifier(proj.GetAlias()))
+			}
+			continue
+		}
+		alias := proj.GetAlias()
+		if alias == "" {
+			// Use the last member of the document path if there is no alias.
+			path := proj.GetSource().GetIdentifier().GetDocumentPath()
+			if len(path) == 0 || path[len(path)-1].GetType() != Mysqlx_Expr.DocumentPathItem_MEMBER {
+				return errXBadProjection
+			}
+			alias = path[len(path)-1].GetValue()
+		}
+		buf.WriteString(quoteString(alias))
+		buf.WriteString(", ")
+		buf.WriteString(s)
+	}
+	if isDoc {
+		buf.WriteString(") AS ")
+		buf.WriteString(docColumn)
+	}
+	return nil
+}
+
+func writeCriteria(buf *bytes.Buffer, clause string, criteria *Mysqlx_Expr.Expr, args []*Mysqlx_Datatypes.Scalar, isDoc bool) error {
+	if criteria == nil {
+		return nil
+	}
+	s, err := exprToSQL(criteria, args, isDoc)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	buf.WriteString(clause)
+	buf.WriteString(s)
+	return nil
+}
+
+func writeOrder(buf *bytes.Buffer, order []*Mysqlx_Crud.Order, args []*Mysqlx_Datatypes.Scalar, isDoc bool) error {
+	for i, o := range order {
+		if i == 0 {
+			buf.WriteString(" ORDER BY ")
+		} else {
+			buf.WriteString(", ")
+		}
+		s, err := exprToSQL(o.GetExpr(), args, isDoc)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		buf.WriteString(s)
+		if o.GetDirection() == Mysqlx_Crud.Order_DESC {
+			buf.WriteString(" DESC")
+		}
+	}
+	return nil
+}
+
+// writeLimit writes the limit of UPDATE and DELETE, which doesn't accept an offset.
+func writeLimit(buf *bytes.Buffer, limit *Mysqlx_Crud.Limit) error {
+	if limit == nil {
+		return nil
+	}
+	if limit.GetOffset() != 0 {
+		return errXInvalidArgument.GenByArgs("offset")
+	}
+	buf.WriteString(" LIMIT ")
+	buf.WriteString(strconv.FormatUint(limit.GetRowCount(), 10))
+	return nil
+}
+
+// buildInsert builds the INSERT statement. A document without _id gets a generated one,
+// the existing _id is kept because JSON_INSERT doesn't replace the member.
+func buildInsert(msg *Mysqlx_Crud.Insert) (string, error) {
+	isDoc := isDocument(msg.GetDataModel())
+	args := msg.GetArgs()
+	name, err := tableName(msg.GetCollection())
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("INSERT INTO ")
+	buf.WriteString(name)
+	numFields := 1
+	if isDoc {
+		if len(msg.GetProjection()) > 0 {
+			return "", errXBadProjection
+		}
+		buf.WriteString(" (" + docColumn + ")")
+	} else if len(msg.GetProjection()) > 0 {
+		numFields = len(msg.GetProjection())
+		buf.WriteString(" (")
+		for i, col := range msg.GetProjection() {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(quoteIdentifier(col.GetName()))
+		}
+		buf.WriteByte(')')
+	}
+	buf.WriteString(" VALUES ")
+	for i, row := range msg.GetRow() {
+		fields := row.GetField()
+		if (isDoc || len(msg.GetProjection()) > 0) && len(fields) != numFields {
+			return "", errXBadInsertData
+		}
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteByte('(')
+		for j, field := range fields {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			s, err := exprToSQL(field, args, isDoc)
+			if err != nil {
+				return "", errors.Trace(err)
+			}
+			if isDoc {
+				s = "JSON_INSERT(" + s + ", '$." + idMember + "', " + quoteString(generateDocumentID()) + ")"
+			}
+			buf.WriteString(s)
+		}
+		buf.WriteByte(')')
+	}
+	if len(msg.GetRow()) == 0 {
+		return "", errXBadInsertData
+	}
+	return buf.String(), nil
+}
+
+// generateDocumentID generates the _id of a document, it fits in the _id column of a collection.
+func generateDocumentID() string {
+	return strings.Replace(uuid.NewV1().String(), "-", "", -1)
+}
+
+// updateFuncs maps the update operations of documents to the JSON functions.
+var updateFuncs = map[Mysqlx_Crud.UpdateOperation_UpdateType]string{
+	Mysqlx_Crud.UpdateOperation_ITEM_SET:     "JSON_SET",
+	Mysqlx_Crud.UpdateOperation_ITEM_REPLACE: "JSON_REPLACE",
+	Mysqlx_Crud.UpdateOperation_ITEM_REMOVE:  "JSON_REMOVE",
+	Mysqlx_Crud.UpdateOperation_ITEM_MERGE:   "JSON_MERGE",
+}
+
+// buildUpdate builds the UPDATE statement. The operations on the same column are nested,
+// e.g. JSON_SET(JSON_REMOVE(doc, '$.a'), '$.b', 1) for removing a and setting b of the documents.
+func buildUpdate(msg *Mysqlx_Crud.Update) (string, error) {
+	isDoc := isDocument(msg.GetDataModel())
+	args := msg.GetArgs()
+	name, err := tableName(msg.GetCollection())
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if len(msg.GetOperation()) == 0 {
+		return "", errXBadUpdateData
+	}
+	var columns []string
+	values := make(map[string]string)
+	for _, op := range msg.GetOperation() {
+		src := op.GetSource()
+		column := docColumn
+		if !isDoc {
+			if src.GetName() == "" {
+				return "", errXBadColumnToUpdate
+			}
+			column = columnName(src)
+		} else if src.GetName() != "" || src.GetTableName() != "" || src.GetSchemaName() != "" {
+			return "", errXBadColumnToUpdate
+		}
+		cur, ok := values[column]
+		if !ok {
+			columns = append(columns, column)
+			cur = column
+		}
+		var value string
+		if op.GetValue() != nil {
+			value, err = exprToSQL(op.GetValue(), args, isDoc)
+			if err != nil {
+				return "", errors.Trace(err)
+			}
+		}
+		tp := op.GetOperation()
+		if tp == Mysqlx_Crud.UpdateOperation_SET {
+			if isDoc || len(src.GetDocumentPath()) > 0 {
+				return "", errXBadTypeOfUpdate.GenByArgs(tp.String())
+			}
+			values[column] = value
+			continue
+		}
+		fn, ok := updateFuncs[tp]
+		if !ok {
+			return "", errXBadTypeOfUpdate.GenByArgs(tp.String())
+		}
+		if tp == Mysqlx_Crud.UpdateOperation_ITEM_MERGE {
+			values[column] = fn + "(" + cur + ", " + value + ")"
+			continue
+		}
+		docPath := src.GetDocumentPath()
+		if len(docPath) == 0 {
+			return "", errXBadMemberToUpdate
+		}
+		if isDoc && docPath[0].GetType() == Mysqlx_Expr.DocumentPathItem_MEMBER && docPath[0].GetValue() == idMember {
+			// The _id of a document can't be changed.
+			return "", errXBadMemberToUpdate
+		}
+		path, err := documentPath(docPath)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		if tp == Mysqlx_Crud.UpdateOperation_ITEM_REMOVE {
+			values[column] = fn + "(" + cur + ", " + quoteString(path) + ")"
+		} else {
+			values[column] = fn + "(" + cur + ", " + quoteString(path) + ", " + value + ")"
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString("UPDATE ")
+	buf.WriteString(name)
+	buf.WriteString(" SET ")
+	for i, column := range columns {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(column)
+		buf.WriteString(" = ")
+		buf.WriteString(values[column])
+	}
+	if err = writeCriteria(&buf, " WHERE ", msg.GetCriteria(), args, isDoc); err != nil {
+		return "", errors.Trace(err)
+	}
+	if err = writeOrder(&buf, msg.GetOrder(), args, isDoc); err != nil {
+		return "", errors.Trace(err)
+	}
+	if err = writeLimit(&buf, msg.GetLimit()); err != nil {
+		return "", errors.Trace(err)
+	}
+	return buf.String(), nil
+}
+
+func buildDelete(msg *Mysqlx_Crud.Delete) (string, error) {
+	isDoc := isDocument(msg.GetDataModel())
+	args := msg.GetArgs()
+	name, err := tableName(msg.GetCollection())
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("DELETE FROM ")
+	buf.WriteString(name)
+	if err = writeCriteria(&buf, " WHERE ", msg.GetCriteria(), args, isDoc); err != nil {
+		return "", errors.Trace(err)
+	}
+	if err = writeOrder(&buf, msg.GetOrder(), args, isDoc); err != nil {
+		return "", errors.Trace(err)
+	}
+	if err = writeLimit(&buf, msg.GetLimit()); err != nil {
+		return "", errors.Trace(err)
+	}
+	return buf.String(), nil
+}
+
+// buildView builds the CREATE VIEW statement of CreateView and ModifyView, the options
+// not set are left to the default of CREATE VIEW.
+func buildView(replace bool, coll *Mysqlx_Crud.Collection, definer *string, algorithm *Mysqlx_Crud.ViewAlgorithm,
+	security *Mysqlx_Crud.ViewSqlSecurity, check *Mysqlx_Crud.ViewCheckOption, columns []string, stmt *Mysqlx_Crud.Find) (string, error) {
+	name, err := tableName(coll)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	query, err := buildFind(stmt)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("CREATE ")
+	if replace {
+		buf.WriteString("OR REPLACE ")
+	}
+	if algorithm != nil {
+		buf.WriteString("ALGORITHM = ")
+		buf.WriteString(algorithm.String())
+		buf.WriteByte(' ')
+	}
+	if definer != nil {
+		buf.WriteString("DEFINER = ")
+		buf.WriteString(quoteDefiner(*definer))
+		buf.WriteByte(' ')
+	}
+	if security != nil {
+		buf.WriteString("SQL SECURITY ")
+		buf.WriteString(security.String())
+		buf.WriteByte(' ')
+	}
+	buf.WriteString("VIEW ")
+	buf.WriteString(name)
+	if len(columns) > 0 {
+		buf.WriteString(" (")
+		for i, col := range columns {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(quoteIdentifier(col))
+		}
+		buf.WriteByte(')')
+	}
+	buf.WriteString(" AS ")
+	buf.WriteString(query)
+	if check != nil {
+		buf.WriteString(" WITH ")
+		buf.WriteString(check.String())
+		buf.WriteString(" CHECK OPTION")
+	}
+	return buf.String(), nil
+}
+
+// quoteDefiner quotes the definer in the form of user@host.
+func quoteDefiner(definer string) string {
+	if idx := strings.LastIndexByte(definer, '@'); idx >= 0 {
+		return quoteString(definer[:idx]) + "@" + quoteString(definer[idx+1:])
+	}
+	return quoteString(definer)
+}
@@ -0,0 +1,142 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xserver
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tipb/go-mysqlx"
+)
+
+// X Protocol error codes, they are the same as the ER_X_* codes of the MySQL X plugin.
+const (
+	codeXBadMessage                = 5000
+	codeXCapabilitiesPrepareFailed = 5001
+	codeXCapabilityNotFound        = 5002
+	codeXInvalidProtocolData       = 5003
+	codeXInvalidArgument           = 5012
+	codeXBadInsertData             = 5014
+	codeXCmdNumArguments           = 5015
+	codeXCmdArgumentType           = 5016
+	codeXBadUpdateData             = 5050
+	codeXBadTypeOfUpdate           = 5051
+	codeXBadColumnToUpdate         = 5052
+	codeXBadMemberToUpdate         = 5053
+	codeXBadTable                  = 5113
+	codeXBadProjection             = 5114
+	codeXExprBadOperator           = 5150
+	codeXExprBadNumArgs            = 5151
+	codeXExprMissingArg            = 5152
+	codeXExprBadTypeValue          = 5153
+	codeXExprBadValue              = 5154
+	codeXInvalidAdminCommand       = 5157
+	codeXExpectNotOpen             = 5158
+	codeXExpectFailed              = 5159
+	codeXExpectBadCondition        = 5160
+	codeXInvalidNamespace          = 5162
+	codeXBadNotice                 = 5163
+	codeXCannotDisableNotice       = 5164
+	codeUnknownCom                 = mysql.ErrUnknownCom
+	codeAccessDenied               = mysql.ErrAccessDenied
+	codeNotSupportedAuthMode       = mysql.ErrNotSupportedAuthMode
+	codeNoSuchThread               = mysql.ErrNoSuchThread
+)
+
+var (
+	errXBadMessage                = terror.ClassXServer.New(codeXBadMessage, "Invalid message")
+	errXCapabilitiesPrepareFailed = terror.ClassXServer.New(codeXCapabilitiesPrepareFailed, "Capability prepare failed for '%s'")
+	errXCapabilityNotFound        = terror.ClassXServer.New(codeXCapabilityNotFound, "Capability '%s' doesn't exist")
+	errXInvalidProtocolData       = terror.ClassXServer.New(codeXInvalidProtocolData, "Invalid protocol data: %s")
+	errXInvalidArgument           = terror.ClassXServer.New(codeXInvalidArgument, "Invalid value for argument '%s'")
+	errXBadInsertData             = terror.ClassXServer.New(codeXBadInsertData, "Wrong number of fields in row being inserted")
+	errXCmdNumArguments           = terror.ClassXServer.New(codeXCmdNumArguments, "Invalid number of arguments, expected %d but got %d")
+	errXCmdArgumentType           = terror.ClassXServer.New(codeXCmdArgumentType, "Invalid type for argument '%s' at #%d (should be %s)")
+	errXBadUpdateData             = terror.ClassXServer.New(codeXBadUpdateData, "Invalid data for update operation on document collection table")
+	errXBadTypeOfUpdate           = terror.ClassXServer.New(codeXBadTypeOfUpdate, "Invalid type of update operation %s")
+	errXBadColumnToUpdate         = terror.ClassXServer.New(codeXBadColumnToUpdate, "Invalid column name to update")
+	errXBadMemberToUpdate         = terror.ClassXServer.New(codeXBadMemberToUpdate, "Invalid member in update expression")
+	errXBadTable                  = terror.ClassXServer.New(codeXBadTable, "Invalid name of table/collection")
+	errXBadProjection             = terror.ClassXServer.New(codeXBadProjection, "Invalid projection target name")
+	errXExprBadOperator           = terror.ClassXServer.New(codeXExprBadOperator, "Invalid operator %s")
+	errXExprBadNumArgs            = terror.ClassXServer.New(codeXExprBadNumArgs, "Invalid number of arguments for operator %s")
+	errXExprMissingArg            = terror.ClassXServer.New(codeXExprMissingArg, "Invalid value of placeholder %d")
+	errXExprBadTypeValue          = terror.ClassXServer.New(codeXExprBadTypeValue, "Invalid type of value %s")
+	errXExprBadValue              = terror.ClassXServer.New(codeXExprBadValue, "Invalid value: %s")
+	errXInvalidAdminCommand       = terror.ClassXServer.New(codeXInvalidAdminCommand, "Invalid %s command %s")
+	errXExpectNotOpen             = terror.ClassXServer.New(codeXExpectNotOpen, "Expect block currently not open")
+	errXExpectFailed              = terror.ClassXServer.New(codeXExpectFailed, "Expectation failed: %s")
+	errXExpectBadCondition        = terror.ClassXServer.New(codeXExpectBadCondition, "Unknown condition key %d")
+	errXInvalidNamespace          = terror.ClassXServer.New(codeXInvalidNamespace, "Unknown namespace %s")
+	errXBadNotice                 = terror.ClassXServer.New(codeXBadNotice, "Invalid notice name %s")
+	errXCannotDisableNotice       = terror.ClassXServer.New(codeXCannotDisableNotice, "Cannot disable notice %s")
+	errUnknownCom                 = terror.ClassXServer.New(codeUnknownCom, "Unexpected message received")
+	errAccessDenied               = terror.ClassXServer.New(codeAccessDenied, "Invalid user or password")
+	errNotSupportedAuthMode       = terror.ClassXServer.New(codeNotSupportedAuthMode, "Invalid authentication method %s")
+	errNoSuchThread               = terror.ClassXServer.New(codeNoSuchThread, "Unknown thread id: %d")
+)
+
+func init() {
+	xserverMySQLErrCodes := map[terror.ErrCode]uint16{
+		codeXBadMessage:                codeXBadMessage,
+		codeXCapabilitiesPrepareFailed: codeXCapabilitiesPrepareFailed,
+		codeXCapabilityNotFound:        codeXCapabilityNotFound,
+		codeXInvalidProtocolData:       codeXInvalidProtocolData,
+		codeXInvalidArgument:           codeXInvalidArgument,
+		codeXBadInsertData:             codeXBadInsertData,
+		codeXCmdNumArguments:           codeXCmdNumArguments,
+		codeXCmdArgumentType:           codeXCmdArgumentType,
+		codeXBadUpdateData:             codeXBadUpdateData,
+		codeXBadTypeOfUpdate:           codeXBadTypeOfUpdate,
+		codeXBadColumnToUpdate:         codeXBadColumnToUpdate,
+		codeXBadMemberToUpdate:         codeXBadMemberToUpdate,
+		codeXBadTable:                  codeXBadTable,
+		codeXBadProjection:             codeXBadProjection,
+		codeXExprBadOperator:           codeXExprBadOperator,
+		codeXExprBadNumArgs:            codeXExprBadNumArgs,
+		codeXExprMissingArg:            codeXExprMissingArg,
+		codeXExprBadTypeValue:          codeXExprBadTypeValue,
+		codeXExprBadValue:              codeXExprBadValue,
+		codeXInvalidAdminCommand:       codeXInvalidAdminCommand,
+		codeXExpectNotOpen:             codeXExpectNotOpen,
+		codeXExpectFailed:              codeXExpectFailed,
+		codeXExpectBadCondition:        codeXExpectBadCondition,
+		codeXInvalidNamespace:          codeXInvalidNamespace,
+		codeXBadNotice:                 codeXBadNotice,
+		codeXCannotDisableNotice:       codeXCannotDisableNotice,
+		codeUnknownCom:                 codeUnknownCom,
+		codeAccessDenied:               codeAccessDenied,
+		codeNotSupportedAuthMode:       codeNotSupportedAuthMode,
+		codeNoSuchThread:               codeNoSuchThread,
+	}
+	terror.ErrClassToMySQLCodes[terror.ClassXServer] = xserverMySQLErrCodes
+}
+
+// errorToMsg converts an error to the Mysqlx.Error message sent to the client.
+func errorToMsg(e error, severity Mysqlx.Error_Severity) *Mysqlx.Error {
+	var m *mysql.SQLError
+	originErr := errors.Cause(e)
+	if te, ok := originErr.(*terror.Error); ok {
+		m = te.ToSQLError()
+	} else {
+		m = mysql.NewErrf(mysql.ErrUnknown, "%s", e.Error())
+	}
+	code := uint32(m.Code)
+	return &Mysqlx.Error{
+		Severity: &severity,
+		Code:     &code,
+		SqlState: &m.State,
+		Msg:      &m.Message,
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xserver
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+	"github.com/pingcap/tipb/go-mysqlx/Expect"
+)
+
+// expectNoError is the condition key of no_error, the messages in the block fail
+// once a message in the block fails.
+const expectNoError = 1
+
+// expectBlock is an expectation block opened by Expect.Open.
+type expectBlock struct {
+	noError bool
+	// failed is true if a message in the block fails.
+	failed bool
+}
+
+func (cc *clientConn) handleExpectOpen(payload []byte) error {
+	var msg Mysqlx_Expect.Open
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return errors.Trace(errXBadMessage)
+	}
+	block := &expectBlock{}
+	if msg.GetOp() == Mysqlx_Expect.Open_EXPECT_CTX_COPY_PREV && len(cc.expects) > 0 {
+		*block = *cc.expects[len(cc.expects)-1]
+	}
+	for _, cond := range msg.GetCond() {
+		if cond.GetConditionKey() != expectNoError {
+			// The block is still opened, the client closes it when it gets the error.
+			cc.expects = append(cc.expects, block)
+			return errXExpectBadCondition.GenByArgs(cond.GetConditionKey())
+		}
+		block.noError = cond.GetOp() == Mysqlx_Expect.Open_Condition_EXPECT_OP_SET
+	}
+	if err := cc.checkExpectations(); err != nil {
+		cc.expects = append(cc.expects, block)
+		return errors.Trace(err)
+	}
+	cc.expects = append(cc.expects, block)
+	return cc.writeOK("")
+}
+
+func (cc *clientConn) handleExpectClose() error {
+	if len(cc.expects) == 0 {
+		return errXExpectNotOpen
+	}
+	block := cc.expects[len(cc.expects)-1]
+	cc.expects = cc.expects[:len(cc.expects)-1]
+	if block.noError && block.failed {
+		// The failure is passed to the outer block.
+		cc.markExpectFailed()
+		return errXExpectFailed.GenByArgs("no_error")
+	}
+	return cc.writeOK("")
+}
+
+// checkExpectations fails the message if a previous message in the current block failed.
+func (cc *clientConn) checkExpectations() error {
+	if len(cc.expects) == 0 {
+		return nil
+	}
+	block := cc.expects[len(cc.expects)-1]
+	if block.noError && block.failed {
+		return errXExpectFailed.GenByArgs("no_error")
+	}
+	return nil
+}
+
+// markExpectFailed marks the current block failed.
+func (cc *clientConn) markExpectFailed() {
+	if len(cc.expects) > 0 {
+		cc.expects[len(cc.expects)-1].failed = true
+	}
+}
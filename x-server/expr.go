@@ -0,0 +1,492 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xserver
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tipb/go-mysqlx/Datatypes"
+	"github.com/pingcap/tipb/go-mysqlx/Expr"
+)
+
+// docColumn is the column of a collection that stores the documents.
+const docColumn = "doc"
+
+// binaryOperators maps the binary operators of X Protocol to SQL.
+var binaryOperators = map[string]string{
+	"==":         "=",
+	"!=":         "!=",
+	">":          ">",
+	">=":         ">=",
+	"<":          "<",
+	"<=":         "<=",
+	"&&":         "AND",
+	"and":        "AND",
+	"||":         "OR",
+	"or":         "OR",
+	"xor":        "XOR",
+	"+":          "+",
+	"-":          "-",
+	"*":          "*",
+	"/":          "/",
+	"div":        "DIV",
+	"%":          "%",
+	"&":          "&",
+	"|":          "|",
+	"^":          "^",
+	"<<":         "<<",
+	">>":         ">>",
+	"is":         "IS",
+	"is_not":     "IS NOT",
+	"regexp":     "REGEXP",
+	"not_regexp": "NOT REGEXP",
+}
+
+// unaryOperators maps the unary operators of X Protocol to SQL.
+var unaryOperators = map[string]string{
+	"!":          "NOT ",
+	"not":        "NOT ",
+	"~":          "~",
+	"sign_plus":  "+",
+	"sign_minus": "-",
+}
+
+// intervalUnits are the units accepted by date_add and date_sub.
+var intervalUnits = map[string]struct{}{
+	"MICROSECOND": {}, "SECOND": {}, "MINUTE": {}, "HOUR": {}, "DAY": {}, "WEEK": {}, "MONTH": {},
+	"QUARTER": {}, "YEAR": {}, "SECOND_MICROSECOND": {}, "MINUTE_MICROSECOND": {}, "MINUTE_SECOND": {},
+	"HOUR_MICROSECOND": {}, "HOUR_SECOND": {}, "HOUR_MINUTE": {}, "DAY_MICROSECOND": {}, "DAY_SECOND": {},
+	"DAY_MINUTE": {}, "DAY_HOUR": {}, "YEAR_MONTH": {},
+}
+
+// castTypes are the target types accepted by cast.
+var castTypes = map[string]struct{}{
+	"BINARY": {}, "CHAR": {}, "DATE": {}, "DATETIME": {}, "DECIMAL": {}, "JSON": {},
+	"SIGNED": {}, "SIGNED INTEGER": {}, "TIME": {}, "UNSIGNED": {}, "UNSIGNED INTEGER": {},
+}
+
+// exprBuilder translates the expressions of X Protocol to SQL.
+type exprBuilder struct {
+	buf bytes.Buffer
+	// args are the values of the placeholders.
+	args []*Mysqlx_Datatypes.Scalar
+	// isDoc is true if the expression works on a collection, the document paths
+	// without column name refer to the documents then.
+	isDoc bool
+}
+
+// exprToSQL translates an expression of X Protocol to SQL.
+func exprToSQL(e *Mysqlx_Expr.Expr, args []*Mysqlx_Datatypes.Scalar, isDoc bool) (string, error) {
+	b := &exprBuilder{args: args, isDoc: isDoc}
+	if err := b.build(e); err != nil {
+		return "", errors.Trace(err)
+	}
+	return b.buf.String(), nil
+}
+
+func (b *exprBuilder) build(e *Mysqlx_Expr.Expr) error {
+	switch e.GetType() {
+	case Mysqlx_Expr.Expr_IDENT:
+		return b.buildIdent(e.GetIdentifier())
+	case Mysqlx_Expr.Expr_LITERAL:
+		lit, err := scalarToSQL(e.GetLiteral())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteString(lit)
+	case Mysqlx_Expr.Expr_PLACEHOLDER:
+		pos := e.GetPosition()
+		if int(pos) >= len(b.args) {
+			return errXExprMissingArg.GenByArgs(pos)
+		}
+		lit, err := scalarToSQL(b.args[pos])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteString(lit)
+	case Mysqlx_Expr.Expr_FUNC_CALL:
+		return b.buildFuncCall(e.GetFunctionCall())
+	case Mysqlx_Expr.Expr_OPERATOR:
+		return b.buildOperator(e.GetOperator())
+	case Mysqlx_Expr.Expr_OBJECT:
+		b.buf.WriteString("JSON_OBJECT(")
+		for i, fld := range e.GetObject().GetFld() {
+			if i > 0 {
+				b.buf.WriteString(", ")
+			}
+			b.buf.WriteString(quoteString(fld.GetKey()))
+			b.buf.WriteString(", ")
+			if err := b.build(fld.GetValue()); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		b.buf.WriteByte(')')
+	case Mysqlx_Expr.Expr_ARRAY:
+		b.buf.WriteString("JSON_ARRAY(")
+		if err := b.buildList(e.GetArray().GetValue()); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteByte(')')
+	default:
+		// The session variables are not supported.
+		return errXExprBadTypeValue.GenByArgs(e.GetType().String())
+	}
+	return nil
+}
+
+func (b *exprBuilder) buildList(exprs []*Mysqlx_Expr.Expr) error {
+	for i, e := range exprs {
+		if i > 0 {
+			b.buf.WriteString(", ")
+		}
+		if err := b.build(e); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+func (b *exprBuilder) buildIdent(id *Mysqlx_Expr.ColumnIdentifier) error {
+	if len(id.GetDocumentPath()) == 0 {
+		if id.GetName() == "" {
+			return errXExprBadValue.GenByArgs("empty column name")
+		}
+		b.buf.WriteString(columnName(id))
+		return nil
+	}
+	column := docColumn
+	if id.GetName() != "" {
+		column = columnName(id)
+	} else if !b.isDoc {
+		return errXExprBadValue.GenByArgs("document path without column name")
+	}
+	path, err := documentPath(id.GetDocumentPath())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	b.buf.WriteString("JSON_EXTRACT(")
+	b.buf.WriteString(column)
+	b.buf.WriteString(", ")
+	b.buf.WriteString(quoteString(path))
+	b.buf.WriteByte(')')
+	return nil
+}
+
+func (b *exprBuilder) buildFuncCall(fc *Mysqlx_Expr.FunctionCall) error {
+	name := fc.GetName()
+	if !isPlainIdentifier(name.GetName()) {
+		return errXExprBadValue.GenByArgs("invalid function name " + name.GetName())
+	}
+	if name.GetSchemaName() != "" {
+		b.buf.WriteString(quoteIdentifier(name.GetSchemaName()))
+		b.buf.WriteByte('.')
+	}
+	b.buf.WriteString(name.GetName())
+	b.buf.WriteByte('(')
+	if err := b.buildList(fc.GetParam()); err != nil {
+		return errors.Trace(err)
+	}
+	b.buf.WriteByte(')')
+	return nil
+}
+
+func (b *exprBuilder) buildOperator(op *Mysqlx_Expr.Operator) error {
+	name, params := op.GetName(), op.GetParam()
+	if sqlOp, ok := binaryOperators[name]; ok {
+		if name == "*" && len(params) == 0 {
+			// It's the * in COUNT(*).
+			b.buf.WriteByte('*')
+			return nil
+		}
+		if len(params) != 2 {
+			return errXExprBadNumArgs.GenByArgs(name)
+		}
+		return b.buildInfix(params[0], " "+sqlOp+" ", params[1])
+	}
+	if sqlOp, ok := unaryOperators[name]; ok {
+		if len(params) != 1 {
+			return errXExprBadNumArgs.GenByArgs(name)
+		}
+		b.buf.WriteByte('(')
+		b.buf.WriteString(sqlOp)
+		if err := b.build(params[0]); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteByte(')')
+		return nil
+	}
+	switch name {
+	case "in", "not_in":
+		if len(params) < 2 {
+			return errXExprBadNumArgs.GenByArgs(name)
+		}
+		sqlOp := " IN ("
+		if name == "not_in" {
+			sqlOp = " NOT IN ("
+		}
+		b.buf.WriteByte('(')
+		if err := b.build(params[0]); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteString(sqlOp)
+		if err := b.buildList(params[1:]); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteString("))")
+		return nil
+	case "like", "not_like":
+		if len(params) != 2 && len(params) != 3 {
+			return errXExprBadNumArgs.GenByArgs(name)
+		}
+		sqlOp := " LIKE "
+		if name == "not_like" {
+			sqlOp = " NOT LIKE "
+		}
+		if len(params) == 2 {
+			return b.buildInfix(params[0], sqlOp, params[1])
+		}
+		b.buf.WriteByte('(')
+		if err := b.build(params[0]); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteString(sqlOp)
+		if err := b.build(params[1]); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteString(" ESCAPE ")
+		if err := b.build(params[2]); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteByte(')')
+		return nil
+	case "between", "not_between":
+		if len(params) != 3 {
+			return errXExprBadNumArgs.GenByArgs(name)
+		}
+		sqlOp := " BETWEEN "
+		if name == "not_between" {
+			sqlOp = " NOT BETWEEN "
+		}
+		b.buf.WriteByte('(')
+		if err := b.build(params[0]); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteString(sqlOp)
+		if err := b.build(params[1]); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteString(" AND ")
+		if err := b.build(params[2]); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteByte(')')
+		return nil
+	case "cast":
+		if len(params) != 2 {
+			return errXExprBadNumArgs.GenByArgs(name)
+		}
+		tp, err := literalString(params[1])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		tp = strings.ToUpper(tp)
+		if _, ok := castTypes[tp]; !ok {
+			return errXExprBadValue.GenByArgs("invalid cast type " + tp)
+		}
+		b.buf.WriteString("CAST(")
+		if err = b.build(params[0]); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteString(" AS ")
+		b.buf.WriteString(tp)
+		b.buf.WriteByte(')')
+		return nil
+	case "date_add", "date_sub":
+		if len(params) != 3 {
+			return errXExprBadNumArgs.GenByArgs(name)
+		}
+		unit, err := literalString(params[2])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		unit = strings.ToUpper(unit)
+		if _, ok := intervalUnits[unit]; !ok {
+			return errXExprBadValue.GenByArgs("invalid interval unit " + unit)
+		}
+		b.buf.WriteString(strings.ToUpper(name))
+		b.buf.WriteByte('(')
+		if err = b.build(params[0]); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteString(", INTERVAL ")
+		if err = b.build(params[1]); err != nil {
+			return errors.Trace(err)
+		}
+		b.buf.WriteByte(' ')
+		b.buf.WriteString(unit)
+		b.buf.WriteByte(')')
+		return nil
+	}
+	return errXExprBadOperator.GenByArgs(name)
+}
+
+func (b *exprBuilder) buildInfix(left *Mysqlx_Expr.Expr, op string, right *Mysqlx_Expr.Expr) error {
+	b.buf.WriteByte('(')
+	if err := b.build(left); err != nil {
+		return errors.Trace(err)
+	}
+	b.buf.WriteString(op)
+	if err := b.build(right); err != nil {
+		return errors.Trace(err)
+	}
+	b.buf.WriteByte(')')
+	return nil
+}
+
+// literalString gets the string of a literal, it's used for the keywords passed as literals
+// like the type of cast.
+func literalString(e *Mysqlx_Expr.Expr) (string, error) {
+	if e.GetType() == Mysqlx_Expr.Expr_LITERAL {
+		lit := e.GetLiteral()
+		switch lit.GetType() {
+		case Mysqlx_Datatypes.Scalar_V_OCTETS:
+			return string(lit.GetVOctets().GetValue()), nil
+		case Mysqlx_Datatypes.Scalar_V_STRING:
+			return string(lit.GetVString().GetValue()), nil
+		}
+	}
+	return "", errXExprBadTypeValue.GenByArgs(e.GetType().String())
+}
+
+// columnName returns the quoted name of the column with its qualifiers.
+func columnName(id *Mysqlx_Expr.ColumnIdentifier) string {
+	var buf bytes.Buffer
+	if id.GetSchemaName() != "" {
+		buf.WriteString(quoteIdentifier(id.GetSchemaName()))
+		buf.WriteByte('.')
+	}
+	if id.GetTableName() != "" {
+		buf.WriteString(quoteIdentifier(id.GetTableName()))
+		buf.WriteByte('.')
+	}
+	buf.WriteString(quoteIdentifier(id.GetName()))
+	return buf.String()
+}
+
+// documentPath translates a document path to the path of the JSON functions.
+func documentPath(items []*Mysqlx_Expr.DocumentPathItem) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('$')
+	for _, item := range items {
+		switch item.GetType() {
+		case Mysqlx_Expr.DocumentPathItem_MEMBER:
+			buf.WriteByte('.')
+			if isPlainIdentifier(item.GetValue()) {
+				buf.WriteString(item.GetValue())
+			} else {
+				buf.WriteString(strconv.Quote(item.GetValue()))
+			}
+		case Mysqlx_Expr.DocumentPathItem_MEMBER_ASTERISK:
+			buf.WriteString(".*")
+		case Mysqlx_Expr.DocumentPathItem_ARRAY_INDEX:
+			buf.WriteByte('[')
+			buf.WriteString(strconv.FormatUint(uint64(item.GetIndex()), 10))
+			buf.WriteByte(']')
+		case Mysqlx_Expr.DocumentPathItem_ARRAY_INDEX_ASTERISK:
+			buf.WriteString("[*]")
+		case Mysqlx_Expr.DocumentPathItem_DOUBLE_ASTERISK:
+			buf.WriteString("**")
+		default:
+			return "", errXExprBadValue.GenByArgs("invalid document path")
+		}
+	}
+	return buf.String(), nil
+}
+
+// isPlainIdentifier checks whether s is a nonempty identifier that needs no quotes.
+func isPlainIdentifier(s string) bool {
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// scalarToSQL translates a scalar to a SQL literal.
+func scalarToSQL(s *Mysqlx_Datatypes.Scalar) (string, error) {
+	switch s.GetType() {
+	case Mysqlx_Datatypes.Scalar_V_SINT:
+		return strconv.FormatInt(s.GetVSignedInt(), 10), nil
+	case Mysqlx_Datatypes.Scalar_V_UINT:
+		return strconv.FormatUint(s.GetVUnsignedInt(), 10), nil
+	case Mysqlx_Datatypes.Scalar_V_NULL:
+		return "NULL", nil
+	case Mysqlx_Datatypes.Scalar_V_OCTETS:
+		str := quoteString(string(s.GetVOctets().GetValue()))
+		if s.GetVOctets().GetContentType() == contentTypeJSON {
+			return "CAST(" + str + " AS JSON)", nil
+		}
+		return str, nil
+	case Mysqlx_Datatypes.Scalar_V_DOUBLE:
+		return strconv.FormatFloat(s.GetVDouble(), 'g', -1, 64), nil
+	case Mysqlx_Datatypes.Scalar_V_FLOAT:
+		return strconv.FormatFloat(float64(s.GetVFloat()), 'g', -1, 32), nil
+	case Mysqlx_Datatypes.Scalar_V_BOOL:
+		if s.GetVBool() {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case Mysqlx_Datatypes.Scalar_V_STRING:
+		return quoteString(string(s.GetVString().GetValue())), nil
+	}
+	return "", errXExprBadTypeValue.GenByArgs(s.GetType().String())
+}
+
+// quoteString quotes s as a SQL string literal.
+func quoteString(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '\'':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case 0:
+			buf.WriteString(`\0`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\032':
+			buf.WriteString(`\Z`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte('\'')
+	return buf.String()
+}
+
+// quoteIdentifier quotes s as a SQL identifier.
+func quoteIdentifier(s string) string {
+	return "`" + strings.Replace(s, "`", "``", -1) + "`"
+}
@@ -0,0 +1,101 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xserver
+
+import (
+	"github.com/golang/protobuf/proto"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tipb/go-mysqlx/Datatypes"
+	"github.com/pingcap/tipb/go-mysqlx/Expr"
+)
+
+var _ = Suite(&testExprSuite{})
+
+type testExprSuite struct{}
+
+func column(name string) *Mysqlx_Expr.Expr {
+	return &Mysqlx_Expr.Expr{
+		Type:       Mysqlx_Expr.Expr_IDENT.Enum(),
+		Identifier: &Mysqlx_Expr.ColumnIdentifier{Name: proto.String(name)},
+	}
+}
+
+func placeholder(pos uint32) *Mysqlx_Expr.Expr {
+	return &Mysqlx_Expr.Expr{Type: Mysqlx_Expr.Expr_PLACEHOLDER.Enum(), Position: proto.Uint32(pos)}
+}
+
+func (s *testExprSuite) TestExprToSQL(c *C) {
+	args := []*Mysqlx_Datatypes.Scalar{newUintScalar(7)}
+	tests := []struct {
+		expr  *Mysqlx_Expr.Expr
+		isDoc bool
+		sql   string
+	}{
+		{column("a`b"), false, "`a``b`"},
+		{member("a", "b c"), true, `JSON_EXTRACT(doc, '$.a."b c"')`},
+		{literal("it's"), false, `'it\'s'`},
+		{operator("==", column("a"), placeholder(0)), false, "(`a` = 7)"},
+		{operator("&&", operator(">", member("a"), literal(1)), operator("not", member("b"))), true,
+			"((JSON_EXTRACT(doc, '$.a') > 1) AND (NOT JSON_EXTRACT(doc, '$.b')))"},
+		{operator("in", column("a"), literal(1), literal(2)), false, "(`a` IN (1, 2))"},
+		{operator("not_between", column("a"), literal(1), literal(2)), false, "(`a` NOT BETWEEN 1 AND 2)"},
+		{operator("like", column("a"), literal("x%"), literal("!")), false, "(`a` LIKE 'x%' ESCAPE '!')"},
+		{operator("cast", column("a"), literal("signed")), false, "CAST(`a` AS SIGNED)"},
+		{operator("date_add", column("a"), literal(1), literal("day")), false, "DATE_ADD(`a`, INTERVAL 1 DAY)"},
+		{object("a", literal(1), "b", &Mysqlx_Expr.Expr{
+			Type:  Mysqlx_Expr.Expr_ARRAY.Enum(),
+			Array: &Mysqlx_Expr.Array{Value: []*Mysqlx_Expr.Expr{literal("x")}},
+		}), true, "JSON_OBJECT('a', 1, 'b', JSON_ARRAY('x'))"},
+	}
+	for _, t := range tests {
+		sql, err := exprToSQL(t.expr, args, t.isDoc)
+		c.Assert(err, IsNil)
+		c.Assert(sql, Equals, t.sql)
+	}
+
+	errTests := []struct {
+		expr *Mysqlx_Expr.Expr
+		err  *terror.Error
+	}{
+		{member("a"), errXExprBadValue},
+		{placeholder(1), errXExprMissingArg},
+		{operator("==", column("a")), errXExprBadNumArgs},
+		{operator("unknown"), errXExprBadOperator},
+		{operator("cast", column("a"), literal("int; drop")), errXExprBadValue},
+	}
+	for _, t := range errTests {
+		_, err := exprToSQL(t.expr, args, false)
+		c.Assert(t.err.Equal(err), IsTrue, Commentf("%v", err))
+	}
+}
+
+func (s *testExprSuite) TestBindArgs(c *C) {
+	args := []*Mysqlx_Datatypes.Any{newStringAny("a'b"), newScalarAny(newUintScalar(1))}
+	tests := []struct {
+		sql    string
+		result string
+	}{
+		{"select ?, ?", `select 'a\'b', 1`},
+		{"select '?', \"?\", `?`, ?, ? # ?", "select '?', \"?\", `?`, 'a\\'b', 1 # ?"},
+		{"select /* ? */ ?, 'it''s ?', ? -- ?", `select /* ? */ 'a\'b', 'it''s ?', 1 -- ?`},
+	}
+	for _, t := range tests {
+		sql, err := bindArgs(t.sql, args)
+		c.Assert(err, IsNil)
+		c.Assert(sql, Equals, t.result)
+	}
+	_, err := bindArgs("select ?", args)
+	c.Assert(errXCmdNumArguments.Equal(err), IsTrue)
+}
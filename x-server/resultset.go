@@ -0,0 +1,341 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xserver
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/server"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tipb/go-mysqlx"
+	"github.com/pingcap/tipb/go-mysqlx/Resultset"
+)
+
+// Content types of the BYTES columns.
+const (
+	contentTypeGeometry = 1
+	contentTypeJSON     = 2
+)
+
+// Flags of the column meta data, the flags with the same value are only used with the specified types.
+const (
+	flagUintZerofill      = 0x0001 // SINT, UINT
+	flagDoubleUnsigned    = 0x0001 // DOUBLE, FLOAT, DECIMAL
+	flagBytesRightpad     = 0x0001 // BYTES
+	flagDatetimeTimestamp = 0x0001 // DATETIME
+	flagNotNull           = 0x0010
+	flagPrimaryKey        = 0x0020
+	flagUniqueKey         = 0x0040
+	flagMultipleKey       = 0x0080
+	flagAutoIncrement     = 0x0100
+)
+
+// writeResultSet writes the column meta data and the rows of a result set,
+// it ends with FetchDoneMoreResultsets if there are more result sets, or FetchDone if it's the last one.
+func (cc *clientConn) writeResultSet(rs server.ResultSet, compact, more bool) error {
+	defer terror.Call(rs.Close)
+	columns, err := rs.Columns()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	fieldTypes := make([]Mysqlx_Resultset.ColumnMetaData_FieldType, 0, len(columns))
+	for _, col := range columns {
+		meta := columnToMeta(col, compact)
+		fieldTypes = append(fieldTypes, meta.GetType())
+		if err = cc.writePacket(Mysqlx.ServerMessages_RESULTSET_COLUMN_META_DATA, meta); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for {
+		row, err := rs.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		msg := &Mysqlx_Resultset.Row{Field: make([][]byte, 0, len(row))}
+		for i, d := range row {
+			field, err := dumpXValue(fieldTypes[i], d)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			msg.Field = append(msg.Field, field)
+		}
+		if err = cc.writePacket(Mysqlx.ServerMessages_RESULTSET_ROW, msg); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if more {
+		return cc.writePacket(Mysqlx.ServerMessages_RESULTSET_FETCH_DONE_MORE_RESULTSETS, &Mysqlx_Resultset.FetchDoneMoreResultsets{})
+	}
+	return cc.writePacket(Mysqlx.ServerMessages_RESULTSET_FETCH_DONE, &Mysqlx_Resultset.FetchDone{})
+}
+
+// columnToMeta converts the column info of the MySQL protocol to the column meta data of X Protocol.
+// Only the type related fields are filled if compact is true.
+func columnToMeta(col *server.ColumnInfo, compact bool) *Mysqlx_Resultset.ColumnMetaData {
+	meta := &Mysqlx_Resultset.ColumnMetaData{}
+	var flags uint32
+	switch col.Type {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+		if mysql.HasUnsignedFlag(uint(col.Flag)) {
+			meta.Type = Mysqlx_Resultset.ColumnMetaData_UINT.Enum()
+		} else {
+			meta.Type = Mysqlx_Resultset.ColumnMetaData_SINT.Enum()
+		}
+		if mysql.HasZerofillFlag(uint(col.Flag)) {
+			flags |= flagUintZerofill
+		}
+	case mysql.TypeYear:
+		meta.Type = Mysqlx_Resultset.ColumnMetaData_UINT.Enum()
+	case mysql.TypeFloat, mysql.TypeDouble, mysql.TypeNewDecimal, mysql.TypeDecimal:
+		switch col.Type {
+		case mysql.TypeFloat:
+			meta.Type = Mysqlx_Resultset.ColumnMetaData_FLOAT.Enum()
+		case mysql.TypeDouble:
+			meta.Type = Mysqlx_Resultset.ColumnMetaData_DOUBLE.Enum()
+		default:
+			meta.Type = Mysqlx_Resultset.ColumnMetaData_DECIMAL.Enum()
+		}
+		if mysql.HasUnsignedFlag(uint(col.Flag)) {
+			flags |= flagDoubleUnsigned
+		}
+		if col.Decimal != mysql.NotFixedDec {
+			meta.FractionalDigits = proto.Uint32(uint32(col.Decimal))
+		}
+	case mysql.TypeDate, mysql.TypeNewDate, mysql.TypeDatetime, mysql.TypeTimestamp:
+		meta.Type = Mysqlx_Resultset.ColumnMetaData_DATETIME.Enum()
+		if col.Type == mysql.TypeTimestamp {
+			flags |= flagDatetimeTimestamp
+		}
+	case mysql.TypeDuration:
+		meta.Type = Mysqlx_Resultset.ColumnMetaData_TIME.Enum()
+	case mysql.TypeBit:
+		meta.Type = Mysqlx_Resultset.ColumnMetaData_BIT.Enum()
+	case mysql.TypeEnum:
+		meta.Type = Mysqlx_Resultset.ColumnMetaData_ENUM.Enum()
+	case mysql.TypeSet:
+		meta.Type = Mysqlx_Resultset.ColumnMetaData_SET.Enum()
+	default:
+		meta.Type = Mysqlx_Resultset.ColumnMetaData_BYTES.Enum()
+		switch col.Type {
+		case mysql.TypeJSON:
+			meta.ContentType = proto.Uint32(contentTypeJSON)
+		case mysql.TypeGeometry:
+			meta.ContentType = proto.Uint32(contentTypeGeometry)
+		case mysql.TypeString:
+			flags |= flagBytesRightpad
+		}
+		meta.Collation = proto.Uint64(uint64(col.Charset))
+	}
+	if compact {
+		return meta
+	}
+	if mysql.HasNotNullFlag(uint(col.Flag)) {
+		flags |= flagNotNull
+	}
+	if mysql.HasPriKeyFlag(uint(col.Flag)) {
+		flags |= flagPrimaryKey
+	}
+	if mysql.HasUniKeyFlag(uint(col.Flag)) {
+		flags |= flagUniqueKey
+	}
+	if mysql.HasMultipleKeyFlag(uint(col.Flag)) {
+		flags |= flagMultipleKey
+	}
+	if mysql.HasAutoIncrementFlag(uint(col.Flag)) {
+		flags |= flagAutoIncrement
+	}
+	meta.Name = []byte(col.Name)
+	meta.OriginalName = []byte(col.OrgName)
+	meta.Table = []byte(col.Table)
+	meta.OriginalTable = []byte(col.OrgTable)
+	meta.Schema = []byte(col.Schema)
+	meta.Catalog = []byte("def")
+	meta.Length = proto.Uint32(col.ColumnLength)
+	meta.Flags = proto.Uint32(flags)
+	return meta
+}
+
+// dumpXValue encodes a value in the format of the field type, a NULL value is encoded as an empty field.
+// See https://dev.mysql.com/doc/internals/en/x-protocol-messages-messages.html#resultset
+func dumpXValue(tp Mysqlx_Resultset.ColumnMetaData_FieldType, d types.Datum) ([]byte, error) {
+	if d.IsNull() {
+		return []byte{}, nil
+	}
+	sc := new(variable.StatementContext)
+	switch tp {
+	case Mysqlx_Resultset.ColumnMetaData_SINT:
+		v, err := d.ToInt64(sc)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return proto.EncodeVarint(uint64(v<<1) ^ uint64(v>>63)), nil
+	case Mysqlx_Resultset.ColumnMetaData_UINT, Mysqlx_Resultset.ColumnMetaData_BIT:
+		var v uint64
+		switch d.Kind() {
+		case types.KindUint64:
+			v = d.GetUint64()
+		case types.KindBinaryLiteral, types.KindMysqlBit:
+			u, err := d.GetBinaryLiteral().ToInt()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			v = u
+		default:
+			i, err := d.ToInt64(sc)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			v = uint64(i)
+		}
+		return proto.EncodeVarint(v), nil
+	case Mysqlx_Resultset.ColumnMetaData_DOUBLE:
+		v, err := d.ToFloat64(sc)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(v))
+		return buf, nil
+	case Mysqlx_Resultset.ColumnMetaData_FLOAT:
+		v, err := d.ToFloat64(sc)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		buf := make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(v)))
+		return buf, nil
+	case Mysqlx_Resultset.ColumnMetaData_DECIMAL:
+		s, err := d.ToString()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return dumpDecimal(s), nil
+	case Mysqlx_Resultset.ColumnMetaData_DATETIME:
+		if d.Kind() != types.KindMysqlTime {
+			break
+		}
+		return dumpDatetime(d.GetMysqlTime()), nil
+	case Mysqlx_Resultset.ColumnMetaData_TIME:
+		if d.Kind() != types.KindMysqlDuration {
+			break
+		}
+		return dumpTime(d.GetMysqlDuration().Duration), nil
+	case Mysqlx_Resultset.ColumnMetaData_SET:
+		s, err := d.ToString()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return dumpSet(s), nil
+	}
+	var b []byte
+	if d.Kind() == types.KindMysqlJSON {
+		b = []byte(d.GetMysqlJSON().String())
+	} else {
+		s, err := d.ToString()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		b = []byte(s)
+	}
+	// The BYTES and ENUM values end with an extra \0 to distinguish the empty value from NULL.
+	return append(b, 0), nil
+}
+
+// dumpDecimal encodes a decimal string as the scale byte followed by the BCD digits,
+// the sign nibble 0xc or 0xd follows the last digit and pads the last byte with 0.
+func dumpDecimal(s string) []byte {
+	sign := byte(0xc)
+	if strings.HasPrefix(s, "-") {
+		sign = 0xd
+		s = s[1:]
+	}
+	var scale byte
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale = byte(len(s) - i - 1)
+		s = s[:i] + s[i+1:]
+	}
+	buf := make([]byte, 0, len(s)/2+2)
+	buf = append(buf, scale)
+	i := 0
+	for ; i+1 < len(s); i += 2 {
+		buf = append(buf, (s[i]-'0')<<4|(s[i+1]-'0'))
+	}
+	if i < len(s) {
+		buf = append(buf, (s[i]-'0')<<4|sign)
+	} else {
+		buf = append(buf, sign<<4)
+	}
+	return buf
+}
+
+// dumpDatetime encodes the date as the varints of year, month and day,
+// the varints of hour, minute, second and microsecond follow for the datetime and timestamp.
+func dumpDatetime(t types.Time) []byte {
+	buf := proto.EncodeVarint(uint64(t.Time.Year()))
+	buf = append(buf, proto.EncodeVarint(uint64(t.Time.Month()))...)
+	buf = append(buf, proto.EncodeVarint(uint64(t.Time.Day()))...)
+	if t.Type == mysql.TypeDate {
+		return buf
+	}
+	buf = append(buf, proto.EncodeVarint(uint64(t.Time.Hour()))...)
+	buf = append(buf, proto.EncodeVarint(uint64(t.Time.Minute()))...)
+	buf = append(buf, proto.EncodeVarint(uint64(t.Time.Second()))...)
+	if t.Time.Microsecond() != 0 {
+		buf = append(buf, proto.EncodeVarint(uint64(t.Time.Microsecond()))...)
+	}
+	return buf
+}
+
+// dumpTime encodes the duration as the sign byte followed by the varints of hour, minute, second and microsecond.
+func dumpTime(dur time.Duration) []byte {
+	buf := []byte{0x00}
+	if dur < 0 {
+		buf[0] = 0x01
+		dur = -dur
+	}
+	hours := dur / time.Hour
+	dur -= hours * time.Hour
+	minutes := dur / time.Minute
+	dur -= minutes * time.Minute
+	seconds := dur / time.Second
+	dur -= seconds * time.Second
+	buf = append(buf, proto.EncodeVarint(uint64(hours))...)
+	buf = append(buf, proto.EncodeVarint(uint64(minutes))...)
+	buf = append(buf, proto.EncodeVarint(uint64(seconds))...)
+	return append(buf, proto.EncodeVarint(uint64(dur/time.Microsecond))...)
+}
+
+// dumpSet encodes the elements of a set as the length prefixed strings, an empty set is encoded as 0x01.
+func dumpSet(s string) []byte {
+	if s == "" {
+		return []byte{0x01}
+	}
+	var buf []byte
+	for _, elem := range strings.Split(s, ",") {
+		buf = append(buf, proto.EncodeVarint(uint64(len(elem)))...)
+		buf = append(buf, elem...)
+	}
+	return buf
+}
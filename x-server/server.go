@@ -14,6 +14,7 @@
 package xserver
 
 import (
+	"bufio"
 	"math/rand"
 	"net"
 	"sync"
@@ -38,19 +39,24 @@ var (
 // Server is the MySQL X protocol server
 type Server struct {
 	cfg               *Config
+	driver            server.IDriver
 	listener          net.Listener
 	rwlock            *sync.RWMutex
 	concurrentLimiter *server.TokenLimiter
+	clients           map[uint32]*clientConn
 
 	stopListenerCh chan struct{}
 }
 
 // NewServer creates a new Server.
-func NewServer(cfg *Config) (s *Server, err error) {
+// The statements received from the clients are executed by the QueryCtx opened from driver.
+func NewServer(cfg *Config, driver server.IDriver) (s *Server, err error) {
 	s = &Server{
 		cfg:               cfg,
+		driver:            driver,
 		concurrentLimiter: server.NewTokenLimiter(tokenLimit),
 		rwlock:            &sync.RWMutex{},
+		clients:           make(map[uint32]*clientConn),
 		stopListenerCh:    make(chan struct{}, 1),
 	}
 	if cfg.Socket != "" {
@@ -63,7 +69,7 @@ func NewServer(cfg *Config) (s *Server, err error) {
 		return nil, errors.Trace(err)
 	}
 	rand.Seed(time.Now().UTC().UnixNano())
-	log.Infof("Server run MySQL Protocol Listen at [%s]", s.cfg.Addr)
+	log.Infof("Server run MySQL X Protocol Listen at [%s]", s.cfg.Addr)
 	return s, nil
 }
 
@@ -118,10 +124,13 @@ func (s *Server) onConn(c net.Conn) {
 		// Some keep alive services will send request to TiDB and disconnect immediately.
 		// So we use info log level.
 		log.Infof("handshake error %s", errors.ErrorStack(err))
-		err := c.Close()
+		err := conn.Close()
 		terror.Log(errors.Trace(err))
 		return
 	}
+	s.rwlock.Lock()
+	s.clients[conn.connectionID] = conn
+	s.rwlock.Unlock()
 	conn.Run()
 }
 
@@ -130,6 +139,8 @@ func (s *Server) onConn(c net.Conn) {
 func (s *Server) newConn(conn net.Conn) *clientConn {
 	cc := &clientConn{
 		conn:         conn,
+		bufReader:    bufio.NewReaderSize(conn, defaultReaderSize),
+		bufWriter:    bufio.NewWriterSize(conn, defaultWriterSize),
 		server:       s,
 		connectionID: atomic.AddUint32(&baseConnID, 1),
 		collation:    mysql.DefaultCollationID,
@@ -139,3 +150,39 @@ func (s *Server) newConn(conn net.Conn) *clientConn {
 	cc.salt = util.RandomBuf(20)
 	return cc
 }
+
+func (s *Server) skipAuth() bool {
+	return s.cfg.SkipAuth
+}
+
+// ShowProcessList implements the SessionManager interface.
+func (s *Server) ShowProcessList() []util.ProcessInfo {
+	var rs []util.ProcessInfo
+	s.rwlock.RLock()
+	for _, client := range s.clients {
+		if client.killed || client.ctx == nil {
+			continue
+		}
+		rs = append(rs, client.ctx.ShowProcess())
+	}
+	s.rwlock.RUnlock()
+	return rs
+}
+
+// Kill implements the SessionManager interface.
+func (s *Server) Kill(connectionID uint64, query bool) {
+	s.rwlock.Lock()
+	defer s.rwlock.Unlock()
+
+	conn, ok := s.clients[uint32(connectionID)]
+	if !ok || conn.ctx == nil {
+		return
+	}
+
+	conn.ctx.Cancel()
+	if !query {
+		conn.killed = true
+		// Unblock the connection waiting for the next message.
+		terror.Log(errors.Trace(conn.conn.Close()))
+	}
+}
@@ -0,0 +1,600 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xserver
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/server"
+	"github.com/pingcap/tidb/util/auth"
+	"github.com/pingcap/tipb/go-mysqlx"
+	"github.com/pingcap/tipb/go-mysqlx/Connection"
+	"github.com/pingcap/tipb/go-mysqlx/Crud"
+	"github.com/pingcap/tipb/go-mysqlx/Datatypes"
+	"github.com/pingcap/tipb/go-mysqlx/Expect"
+	"github.com/pingcap/tipb/go-mysqlx/Expr"
+	"github.com/pingcap/tipb/go-mysqlx/Notice"
+	"github.com/pingcap/tipb/go-mysqlx/Resultset"
+	"github.com/pingcap/tipb/go-mysqlx/Session"
+	"github.com/pingcap/tipb/go-mysqlx/Sql"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testXServerSuite{})
+
+type testXServerSuite struct {
+	server *Server
+}
+
+func (s *testXServerSuite) SetUpSuite(c *C) {
+	store, err := tidb.NewStore("memory:///tmp/tidb")
+	c.Assert(err, IsNil)
+	_, err = tidb.BootstrapSession(store)
+	c.Assert(err, IsNil)
+	s.server, err = NewServer(&Config{Addr: "127.0.0.1:0"}, server.NewTiDBDriver(store))
+	c.Assert(err, IsNil)
+	go s.server.Run()
+}
+
+func (s *testXServerSuite) TearDownSuite(c *C) {
+	s.server.Close()
+}
+
+// testClient is a client of X Protocol that checks the messages sent by the server.
+type testClient struct {
+	c    *C
+	conn net.Conn
+}
+
+// result is the messages received for a statement.
+type result struct {
+	columns []*Mysqlx_Resultset.ColumnMetaData
+	rows    [][][]byte
+	notices []*Mysqlx_Notice.Frame
+	err     *Mysqlx.Error
+}
+
+func (s *testXServerSuite) newClient(c *C) *testClient {
+	conn, err := net.Dial("tcp", s.server.listener.Addr().String())
+	c.Assert(err, IsNil)
+	return &testClient{c: c, conn: conn}
+}
+
+func (tc *testClient) close() {
+	tc.send(Mysqlx.ClientMessages_CON_CLOSE, &Mysqlx_Connection.Close{})
+	tc.expectOK()
+	tc.c.Assert(tc.conn.Close(), IsNil)
+}
+
+func (tc *testClient) send(tp Mysqlx.ClientMessages_Type, msg proto.Message) {
+	payload, err := proto.Marshal(msg)
+	tc.c.Assert(err, IsNil)
+	header := make([]byte, 5)
+	binary.LittleEndian.PutUint32(header, uint32(len(payload)+1))
+	header[4] = byte(tp)
+	_, err = tc.conn.Write(append(header, payload...))
+	tc.c.Assert(err, IsNil)
+}
+
+func (tc *testClient) recv() (Mysqlx.ServerMessages_Type, []byte) {
+	header := make([]byte, 5)
+	_, err := io.ReadFull(tc.conn, header)
+	tc.c.Assert(err, IsNil)
+	payload := make([]byte, binary.LittleEndian.Uint32(header)-1)
+	_, err = io.ReadFull(tc.conn, payload)
+	tc.c.Assert(err, IsNil)
+	return Mysqlx.ServerMessages_Type(header[4]), payload
+}
+
+// recvMsg receives a message of the type.
+func (tc *testClient) recvMsg(tp Mysqlx.ServerMessages_Type, msg proto.Message) {
+	recvTp, payload := tc.recv()
+	if recvTp == Mysqlx.ServerMessages_ERROR {
+		var e Mysqlx.Error
+		tc.c.Assert(proto.Unmarshal(payload, &e), IsNil)
+		tc.c.Fatalf("unexpected error %s", e.String())
+	}
+	tc.c.Assert(recvTp, Equals, tp)
+	tc.c.Assert(proto.Unmarshal(payload, msg), IsNil)
+}
+
+func (tc *testClient) expectOK() {
+	tc.recvMsg(Mysqlx.ServerMessages_OK, &Mysqlx.Ok{})
+}
+
+func (tc *testClient) expectError(code uint32) {
+	tp, payload := tc.recv()
+	tc.c.Assert(tp, Equals, Mysqlx.ServerMessages_ERROR)
+	var e Mysqlx.Error
+	tc.c.Assert(proto.Unmarshal(payload, &e), IsNil)
+	tc.c.Assert(e.GetCode(), Equals, code, Commentf("%s", e.GetMsg()))
+}
+
+func (tc *testClient) authenticate(user, password string) *Mysqlx.Error {
+	tc.send(Mysqlx.ClientMessages_SESS_AUTHENTICATE_START, &Mysqlx_Session.AuthenticateStart{
+		MechName: proto.String(mechMySQL41),
+	})
+	var cont Mysqlx_Session.AuthenticateContinue
+	tc.recvMsg(Mysqlx.ServerMessages_SESS_AUTHENTICATE_CONTINUE, &cont)
+	tc.c.Assert(cont.GetAuthData(), HasLen, 20)
+	data := "\x00" + user + "\x00"
+	if password != "" {
+		data += "*" + hex.EncodeToString(auth.ScramblePassword(cont.GetAuthData(), password))
+	}
+	tc.send(Mysqlx.ClientMessages_SESS_AUTHENTICATE_CONTINUE, &Mysqlx_Session.AuthenticateContinue{
+		AuthData: []byte(data),
+	})
+	tp, payload := tc.recv()
+	if tp == Mysqlx.ServerMessages_ERROR {
+		var e Mysqlx.Error
+		tc.c.Assert(proto.Unmarshal(payload, &e), IsNil)
+		return &e
+	}
+	tc.c.Assert(tp, Equals, Mysqlx.ServerMessages_NOTICE)
+	tc.recvMsg(Mysqlx.ServerMessages_SESS_AUTHENTICATE_OK, &Mysqlx_Session.AuthenticateOk{})
+	return nil
+}
+
+// receiveResult receives the result sets, the notices and StmtExecuteOk or Error.
+func (tc *testClient) receiveResult() *result {
+	r := &result{}
+	for {
+		tp, payload := tc.recv()
+		switch tp {
+		case Mysqlx.ServerMessages_RESULTSET_COLUMN_META_DATA:
+			var col Mysqlx_Resultset.ColumnMetaData
+			tc.c.Assert(proto.Unmarshal(payload, &col), IsNil)
+			r.columns = append(r.columns, &col)
+		case Mysqlx.ServerMessages_RESULTSET_ROW:
+			var row Mysqlx_Resultset.Row
+			tc.c.Assert(proto.Unmarshal(payload, &row), IsNil)
+			r.rows = append(r.rows, row.GetField())
+		case Mysqlx.ServerMessages_NOTICE:
+			var frame Mysqlx_Notice.Frame
+			tc.c.Assert(proto.Unmarshal(payload, &frame), IsNil)
+			r.notices = append(r.notices, &frame)
+		case Mysqlx.ServerMessages_RESULTSET_FETCH_DONE, Mysqlx.ServerMessages_RESULTSET_FETCH_DONE_MORE_RESULTSETS:
+		case Mysqlx.ServerMessages_SQL_STMT_EXECUTE_OK:
+			return r
+		case Mysqlx.ServerMessages_ERROR:
+			r.err = &Mysqlx.Error{}
+			tc.c.Assert(proto.Unmarshal(payload, r.err), IsNil)
+			return r
+		default:
+			tc.c.Fatalf("unexpected message type %s", tp)
+		}
+	}
+}
+
+func (tc *testClient) execute(sql string, args ...*Mysqlx_Datatypes.Any) *result {
+	tc.send(Mysqlx.ClientMessages_SQL_STMT_EXECUTE, &Mysqlx_Sql.StmtExecute{
+		Stmt: []byte(sql),
+		Args: args,
+	})
+	return tc.receiveResult()
+}
+
+func (tc *testClient) mustExecute(sql string, args ...*Mysqlx_Datatypes.Any) *result {
+	r := tc.execute(sql, args...)
+	tc.c.Assert(r.err, IsNil, Commentf("%s", r.err.GetMsg()))
+	return r
+}
+
+func (tc *testClient) adminCommand(cmd string, args ...*Mysqlx_Datatypes.Any) *result {
+	tc.send(Mysqlx.ClientMessages_SQL_STMT_EXECUTE, &Mysqlx_Sql.StmtExecute{
+		Namespace: proto.String(namespaceMysqlx),
+		Stmt:      []byte(cmd),
+		Args:      args,
+	})
+	return tc.receiveResult()
+}
+
+// rowsAffected gets the rows affected in the notices.
+func (r *result) rowsAffected(c *C) uint64 {
+	for _, frame := range r.notices {
+		if frame.GetType() != noticeTypeSessionStateChanged {
+			continue
+		}
+		var changed Mysqlx_Notice.SessionStateChanged
+		c.Assert(proto.Unmarshal(frame.GetPayload(), &changed), IsNil)
+		if changed.GetParam() == Mysqlx_Notice.SessionStateChanged_ROWS_AFFECTED {
+			return changed.GetValue().GetVUnsignedInt()
+		}
+	}
+	c.Fatal("no rows affected notice")
+	return 0
+}
+
+// stringRows gets the rows of the string columns.
+func (r *result) stringRows() [][]string {
+	var rows [][]string
+	for _, row := range r.rows {
+		var strs []string
+		for _, field := range row {
+			// The strings end with '\0'.
+			strs = append(strs, string(field[:len(field)-1]))
+		}
+		rows = append(rows, strs)
+	}
+	return rows
+}
+
+func (s *testXServerSuite) TestCapabilities(c *C) {
+	tc := s.newClient(c)
+	tc.send(Mysqlx.ClientMessages_CON_CAPABILITIES_GET, &Mysqlx_Connection.CapabilitiesGet{})
+	var caps Mysqlx_Connection.Capabilities
+	tc.recvMsg(Mysqlx.ServerMessages_CONN_CAPABILITIES, &caps)
+	names := make(map[string]*Mysqlx_Datatypes.Any)
+	for _, cap := range caps.GetCapabilities() {
+		names[cap.GetName()] = cap.GetValue()
+	}
+	c.Assert(names, HasLen, 5)
+	mechs := names[capAuthMechanisms].GetArray().GetValue()
+	c.Assert(mechs, HasLen, 1)
+	c.Assert(string(mechs[0].GetScalar().GetVString().GetValue()), Equals, mechMySQL41)
+
+	setCap := func(name string, value bool) {
+		tc.send(Mysqlx.ClientMessages_CON_CAPABILITIES_SET, &Mysqlx_Connection.CapabilitiesSet{
+			Capabilities: &Mysqlx_Connection.Capabilities{
+				Capabilities: []*Mysqlx_Connection.Capability{newCapability(name, newBoolAny(value))},
+			},
+		})
+	}
+	setCap(capPwdExpireOK, true)
+	tc.expectOK()
+	setCap(capTLS, true)
+	tc.expectError(codeXCapabilitiesPrepareFailed)
+	setCap("unknown", true)
+	tc.expectError(codeXCapabilityNotFound)
+
+	// Only the connection messages are accepted before the client is authenticated.
+	tc.send(Mysqlx.ClientMessages_SQL_STMT_EXECUTE, &Mysqlx_Sql.StmtExecute{Stmt: []byte("select 1")})
+	tc.expectError(codeUnknownCom)
+	tc.close()
+}
+
+func (s *testXServerSuite) TestAuthenticate(c *C) {
+	tc := s.newClient(c)
+	tc.send(Mysqlx.ClientMessages_SESS_AUTHENTICATE_START, &Mysqlx_Session.AuthenticateStart{
+		MechName: proto.String(mechPlain),
+	})
+	// PLAIN is not allowed on an insecure transport.
+	tc.expectError(codeNotSupportedAuthMode)
+	e := tc.authenticate("root", "wrong")
+	c.Assert(e, NotNil)
+	c.Assert(e.GetCode(), Equals, uint32(codeAccessDenied))
+	c.Assert(tc.authenticate("root", ""), IsNil)
+	tc.mustExecute("create user 'xuser'@'%' identified by 'xpwd'")
+	tc.mustExecute("flush privileges")
+	tc.close()
+
+	tc = s.newClient(c)
+	c.Assert(tc.authenticate("xuser", "xpwd"), IsNil)
+	r := tc.mustExecute("select current_user()")
+	c.Assert(r.stringRows(), DeepEquals, [][]string{{"xuser@127.0.0.1"}})
+	tc.close()
+}
+
+func (s *testXServerSuite) TestHandshakeLimits(c *C) {
+	// The connection is closed after too many failed authentications.
+	tc := s.newClient(c)
+	for i := 0; i < maxAuthAttempts; i++ {
+		e := tc.authenticate("root", "wrong")
+		c.Assert(e, NotNil)
+		c.Assert(e.GetCode(), Equals, uint32(codeAccessDenied))
+	}
+	_, err := tc.conn.Read(make([]byte, 1))
+	c.Assert(err, NotNil)
+	c.Assert(tc.conn.Close(), IsNil)
+
+	// The connection is closed if the client doesn't finish the handshake in time.
+	origTimeout := handshakeTimeout
+	handshakeTimeout = 100 * time.Millisecond
+	defer func() {
+		handshakeTimeout = origTimeout
+	}()
+	tc = s.newClient(c)
+	_, err = tc.conn.Read(make([]byte, 1))
+	c.Assert(err, NotNil)
+	c.Assert(tc.conn.Close(), IsNil)
+
+	// The deadline is cleared once the client is authenticated.
+	tc = s.newClient(c)
+	c.Assert(tc.authenticate("root", ""), IsNil)
+	time.Sleep(2 * handshakeTimeout)
+	tc.mustExecute("select 1")
+
+	// The same limits apply when the client authenticates again after the session is reset.
+	tc.send(Mysqlx.ClientMessages_SESS_RESET, &Mysqlx_Session.Reset{})
+	tc.expectOK()
+	_, err = tc.conn.Read(make([]byte, 1))
+	c.Assert(err, NotNil)
+	c.Assert(tc.conn.Close(), IsNil)
+
+	handshakeTimeout = origTimeout
+	tc = s.newClient(c)
+	c.Assert(tc.authenticate("root", ""), IsNil)
+	tc.send(Mysqlx.ClientMessages_SESS_RESET, &Mysqlx_Session.Reset{})
+	tc.expectOK()
+	for i := 0; i < maxAuthAttempts; i++ {
+		e := tc.authenticate("root", "wrong")
+		c.Assert(e, NotNil)
+		c.Assert(e.GetCode(), Equals, uint32(codeAccessDenied))
+	}
+	_, err = tc.conn.Read(make([]byte, 1))
+	c.Assert(err, NotNil)
+	c.Assert(tc.conn.Close(), IsNil)
+}
+
+func (s *testXServerSuite) TestStmtExecute(c *C) {
+	tc := s.newClient(c)
+	c.Assert(tc.authenticate("root", ""), IsNil)
+	tc.mustExecute("create database if not exists x_sql")
+	tc.mustExecute("use x_sql")
+	tc.mustExecute("create table t (id int primary key auto_increment, name varchar(20))")
+	r := tc.mustExecute("insert into t (name) values ('a'), (?)", newStringAny("b'?"))
+	c.Assert(r.rowsAffected(c), Equals, uint64(2))
+
+	r = tc.mustExecute("select name, id from t where id > ? order by id", newScalarAny(newUintScalar(0)))
+	c.Assert(r.columns, HasLen, 2)
+	c.Assert(string(r.columns[0].GetName()), Equals, "name")
+	c.Assert(r.columns[1].GetType(), Equals, Mysqlx_Resultset.ColumnMetaData_SINT)
+	c.Assert(r.rows, HasLen, 2)
+	c.Assert(string(r.rows[1][0]), Equals, "b'?\x00")
+	// The signed integer is zigzag encoded.
+	c.Assert(r.rows[1][1], DeepEquals, []byte{4})
+
+	r = tc.execute("select * from not_exists")
+	c.Assert(r.err, NotNil)
+	c.Assert(r.err.GetCode(), Equals, uint32(1146))
+	r = tc.execute("select ?, ?", newStringAny("a"))
+	c.Assert(r.err.GetCode(), Equals, uint32(codeXCmdNumArguments))
+
+	// The warnings are sent in the notices.
+	r = tc.mustExecute("select 1/0")
+	c.Assert(r.notices, HasLen, 1)
+	c.Assert(r.notices[0].GetType(), Equals, uint32(noticeTypeWarning))
+	r = tc.adminCommand("disable_notices", newStringAny(noticeWarnings))
+	c.Assert(r.err, IsNil)
+	r = tc.mustExecute("select 1/0")
+	c.Assert(r.notices, HasLen, 0)
+	r = tc.adminCommand("disable_notices", newStringAny(noticeRowsAffected))
+	c.Assert(r.err.GetCode(), Equals, uint32(codeXCannotDisableNotice))
+	tc.close()
+}
+
+func (s *testXServerSuite) TestExpect(c *C) {
+	tc := s.newClient(c)
+	c.Assert(tc.authenticate("root", ""), IsNil)
+	tc.send(Mysqlx.ClientMessages_EXPECT_CLOSE, &Mysqlx_Expect.Close{})
+	tc.expectError(codeXExpectNotOpen)
+
+	tc.send(Mysqlx.ClientMessages_EXPECT_OPEN, &Mysqlx_Expect.Open{
+		Cond: []*Mysqlx_Expect.Open_Condition{{ConditionKey: proto.Uint32(expectNoError)}},
+	})
+	tc.expectOK()
+	tc.mustExecute("select 1")
+	r := tc.execute("select * from not_exists.t")
+	c.Assert(r.err.GetCode(), Equals, uint32(1146))
+	// The messages after the failed one fail.
+	r = tc.execute("select 1")
+	c.Assert(r.err.GetCode(), Equals, uint32(codeXExpectFailed))
+	tc.send(Mysqlx.ClientMessages_EXPECT_CLOSE, &Mysqlx_Expect.Close{})
+	tc.expectError(codeXExpectFailed)
+	tc.mustExecute("select 1")
+
+	tc.send(Mysqlx.ClientMessages_EXPECT_OPEN, &Mysqlx_Expect.Open{
+		Cond: []*Mysqlx_Expect.Open_Condition{{ConditionKey: proto.Uint32(100)}},
+	})
+	tc.expectError(codeXExpectBadCondition)
+	tc.send(Mysqlx.ClientMessages_EXPECT_CLOSE, &Mysqlx_Expect.Close{})
+	tc.expectOK()
+	tc.close()
+}
+
+func (s *testXServerSuite) TestAdminCommand(c *C) {
+	tc := s.newClient(c)
+	c.Assert(tc.authenticate("root", ""), IsNil)
+	c.Assert(tc.adminCommand("ping").err, IsNil)
+	r := tc.adminCommand("list_clients")
+	c.Assert(r.err, IsNil)
+	c.Assert(len(r.rows), Greater, 0)
+	c.Assert(tc.adminCommand("unknown").err.GetCode(), Equals, uint32(codeXInvalidAdminCommand))
+	c.Assert(tc.adminCommand("kill_client", newScalarAny(newUintScalar(1<<30))).err.GetCode(), Equals, uint32(codeNoSuchThread))
+
+	tc.mustExecute("create database if not exists x_admin")
+	tc.mustExecute("use x_admin")
+	tc.mustExecute("create table t (a int)")
+	tc.mustExecute("create view v as select a from t")
+	c.Assert(tc.adminCommand("create_collection", newStringAny("x_admin"), newStringAny("coll")).err, IsNil)
+	c.Assert(tc.adminCommand("create_collection", newStringAny("x_admin"), newStringAny("coll")).err.GetCode(), Equals, uint32(1050))
+	c.Assert(tc.adminCommand("ensure_collection", newStringAny("x_admin"), newStringAny("coll")).err, IsNil)
+	r = tc.adminCommand("list_objects", newStringAny("x_admin"))
+	c.Assert(r.err, IsNil)
+	c.Assert(r.stringRows(), DeepEquals, [][]string{{"coll", "COLLECTION"}, {"t", "TABLE"}, {"v", "VIEW"}})
+	r = tc.adminCommand("list_objects", newStringAny("x_admin"), newStringAny("c%"))
+	c.Assert(r.stringRows(), DeepEquals, [][]string{{"coll", "COLLECTION"}})
+	c.Assert(tc.adminCommand("drop_collection", newStringAny("x_admin"), newStringAny("coll")).err, IsNil)
+	r = tc.adminCommand("list_objects", newStringAny("x_admin"), newStringAny("c%"))
+	c.Assert(r.rows, HasLen, 0)
+
+	// The client is closed after it's killed.
+	tc2 := s.newClient(c)
+	c.Assert(tc2.authenticate("root", ""), IsNil)
+	c.Assert(len(tc.adminCommand("list_clients").rows), Greater, 1)
+	c.Assert(tc.adminCommand("kill_client", newScalarAny(newUintScalar(uint64(tc2.clientID(s))))).err, IsNil)
+	_, err := io.ReadFull(tc2.conn, make([]byte, 1))
+	c.Assert(err, NotNil)
+	tc.close()
+}
+
+// clientID gets the connection ID of the client in the server.
+func (tc *testClient) clientID(s *testXServerSuite) uint32 {
+	local := tc.conn.LocalAddr().String()
+	s.server.rwlock.RLock()
+	defer s.server.rwlock.RUnlock()
+	for id, client := range s.server.clients {
+		if client.conn.RemoteAddr().String() == local {
+			return id
+		}
+	}
+	tc.c.Fatal("client not found")
+	return 0
+}
+
+func memberIdent(members ...string) *Mysqlx_Expr.ColumnIdentifier {
+	id := &Mysqlx_Expr.ColumnIdentifier{}
+	for _, m := range members {
+		id.DocumentPath = append(id.DocumentPath, &Mysqlx_Expr.DocumentPathItem{
+			Type:  Mysqlx_Expr.DocumentPathItem_MEMBER.Enum(),
+			Value: proto.String(m),
+		})
+	}
+	return id
+}
+
+func member(members ...string) *Mysqlx_Expr.Expr {
+	return &Mysqlx_Expr.Expr{Type: Mysqlx_Expr.Expr_IDENT.Enum(), Identifier: memberIdent(members...)}
+}
+
+func literal(v interface{}) *Mysqlx_Expr.Expr {
+	var s *Mysqlx_Datatypes.Scalar
+	switch x := v.(type) {
+	case int:
+		s = &Mysqlx_Datatypes.Scalar{Type: Mysqlx_Datatypes.Scalar_V_SINT.Enum(), VSignedInt: proto.Int64(int64(x))}
+	case string:
+		s = &Mysqlx_Datatypes.Scalar{
+			Type:    Mysqlx_Datatypes.Scalar_V_STRING.Enum(),
+			VString: &Mysqlx_Datatypes.Scalar_String{Value: []byte(x)},
+		}
+	}
+	return &Mysqlx_Expr.Expr{Type: Mysqlx_Expr.Expr_LITERAL.Enum(), Literal: s}
+}
+
+func operator(name string, params ...*Mysqlx_Expr.Expr) *Mysqlx_Expr.Expr {
+	return &Mysqlx_Expr.Expr{
+		Type:     Mysqlx_Expr.Expr_OPERATOR.Enum(),
+		Operator: &Mysqlx_Expr.Operator{Name: proto.String(name), Param: params},
+	}
+}
+
+func object(kvs ...interface{}) *Mysqlx_Expr.Expr {
+	obj := &Mysqlx_Expr.Object{}
+	for i := 0; i < len(kvs); i += 2 {
+		obj.Fld = append(obj.Fld, &Mysqlx_Expr.Object_ObjectField{
+			Key:   proto.String(kvs[i].(string)),
+			Value: kvs[i+1].(*Mysqlx_Expr.Expr),
+		})
+	}
+	return &Mysqlx_Expr.Expr{Type: Mysqlx_Expr.Expr_OBJECT.Enum(), Object: obj}
+}
+
+func (s *testXServerSuite) TestCrud(c *C) {
+	tc := s.newClient(c)
+	c.Assert(tc.authenticate("root", ""), IsNil)
+	tc.mustExecute("create database if not exists x_crud")
+	tc.mustExecute("use x_crud")
+	c.Assert(tc.adminCommand("create_collection", newStringAny("x_crud"), newStringAny("coll")).err, IsNil)
+	coll := &Mysqlx_Crud.Collection{Name: proto.String("coll"), Schema: proto.String("x_crud")}
+
+	tc.send(Mysqlx.ClientMessages_CRUD_INSERT, &Mysqlx_Crud.Insert{
+		Collection: coll,
+		Row: []*Mysqlx_Crud.Insert_TypedRow{
+			{Field: []*Mysqlx_Expr.Expr{object("_id", literal("1"), "name", literal("a"), "age", literal(10))}},
+			{Field: []*Mysqlx_Expr.Expr{object("name", literal("b"), "age", literal(20))}},
+		},
+	})
+	c.Assert(tc.receiveResult().rowsAffected(c), Equals, uint64(2))
+
+	find := func(criteria *Mysqlx_Expr.Expr, projs ...*Mysqlx_Crud.Projection) [][]string {
+		tc.send(Mysqlx.ClientMessages_CRUD_FIND, &Mysqlx_Crud.Find{
+			Collection: coll,
+			Projection: projs,
+			Criteria:   criteria,
+			Order:      []*Mysqlx_Crud.Order{{Expr: member("age")}},
+		})
+		r := tc.receiveResult()
+		c.Assert(r.err, IsNil, Commentf("%s", r.err.GetMsg()))
+		return r.stringRows()
+	}
+	c.Assert(find(operator("==", member("name"), literal("a"))), DeepEquals,
+		[][]string{{`{"_id":"1","age":10,"name":"a"}`}})
+	c.Assert(find(operator(">", member("age"), literal(5)), &Mysqlx_Crud.Projection{Source: member("name")}), DeepEquals,
+		[][]string{{`{"name":"a"}`}, {`{"name":"b"}`}})
+	// The generated _id is the primary key.
+	r := tc.mustExecute("select length(_id) from coll where _id != '1'")
+	c.Assert(r.rows, DeepEquals, [][][]byte{{{64}}})
+
+	tc.send(Mysqlx.ClientMessages_CRUD_UPDATE, &Mysqlx_Crud.Update{
+		Collection: coll,
+		Criteria:   operator("==", member("_id"), literal("1")),
+		Operation: []*Mysqlx_Crud.UpdateOperation{
+			{Source: memberIdent("age"), Operation: Mysqlx_Crud.UpdateOperation_ITEM_SET.Enum(), Value: literal(30)},
+			{Source: memberIdent("name"), Operation: Mysqlx_Crud.UpdateOperation_ITEM_REMOVE.Enum()},
+		},
+	})
+	c.Assert(tc.receiveResult().rowsAffected(c), Equals, uint64(1))
+	c.Assert(find(operator("==", member("_id"), literal("1"))), DeepEquals, [][]string{{`{"_id":"1","age":30}`}})
+
+	tc.send(Mysqlx.ClientMessages_CRUD_UPDATE, &Mysqlx_Crud.Update{
+		Collection: coll,
+		Operation: []*Mysqlx_Crud.UpdateOperation{
+			{Source: memberIdent("_id"), Operation: Mysqlx_Crud.UpdateOperation_ITEM_SET.Enum(), Value: literal("2")},
+		},
+	})
+	c.Assert(tc.receiveResult().err.GetCode(), Equals, uint32(codeXBadMemberToUpdate))
+
+	tc.send(Mysqlx.ClientMessages_CRUD_DELETE, &Mysqlx_Crud.Delete{
+		Collection: coll,
+		Criteria:   operator("<", member("age"), literal(25)),
+	})
+	c.Assert(tc.receiveResult().rowsAffected(c), Equals, uint64(1))
+	c.Assert(find(nil), DeepEquals, [][]string{{`{"_id":"1","age":30}`}})
+
+	// The table data model works on the columns.
+	tc.mustExecute("create table t (a int, b varchar(10))")
+	tbl := &Mysqlx_Crud.Collection{Name: proto.String("t")}
+	tc.send(Mysqlx.ClientMessages_CRUD_INSERT, &Mysqlx_Crud.Insert{
+		Collection: tbl,
+		DataModel:  Mysqlx_Crud.DataModel_TABLE.Enum(),
+		Projection: []*Mysqlx_Crud.Column{{Name: proto.String("b")}, {Name: proto.String("a")}},
+		Row: []*Mysqlx_Crud.Insert_TypedRow{
+			{Field: []*Mysqlx_Expr.Expr{literal("x"), literal(1)}},
+		},
+	})
+	c.Assert(tc.receiveResult().rowsAffected(c), Equals, uint64(1))
+	tc.send(Mysqlx.ClientMessages_CRUD_INSERT, &Mysqlx_Crud.Insert{
+		Collection: tbl,
+		DataModel:  Mysqlx_Crud.DataModel_TABLE.Enum(),
+		Projection: []*Mysqlx_Crud.Column{{Name: proto.String("b")}},
+		Row: []*Mysqlx_Crud.Insert_TypedRow{
+			{Field: []*Mysqlx_Expr.Expr{literal("x"), literal(1)}},
+		},
+	})
+	c.Assert(tc.receiveResult().err.GetCode(), Equals, uint32(codeXBadInsertData))
+	r = tc.mustExecute("select b from t")
+	c.Assert(r.stringRows(), DeepEquals, [][]string{{"x"}})
+	tc.close()
+}
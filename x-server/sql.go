@@ -0,0 +1,273 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xserver
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/server"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tipb/go-mysqlx"
+	"github.com/pingcap/tipb/go-mysqlx/Datatypes"
+	"github.com/pingcap/tipb/go-mysqlx/Notice"
+	"github.com/pingcap/tipb/go-mysqlx/Sql"
+)
+
+// Namespaces of StmtExecute.
+const (
+	namespaceSQL = "sql"
+	// namespaceXPlugin is the namespace of the admin commands, it's renamed to mysqlx in MySQL 8.0.
+	namespaceXPlugin = "xplugin"
+	namespaceMysqlx  = "mysqlx"
+)
+
+// Notice frame types.
+const (
+	noticeTypeWarning             = 1
+	noticeTypeSessionStateChanged = 3
+)
+
+func (cc *clientConn) handleStmtExecute(payload []byte) error {
+	var msg Mysqlx_Sql.StmtExecute
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return errors.Trace(errXBadMessage)
+	}
+	switch msg.GetNamespace() {
+	case namespaceSQL:
+		sql, err := bindArgs(string(msg.GetStmt()), msg.GetArgs())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return cc.executeSQL(sql, msg.GetCompactMetadata())
+	case namespaceXPlugin, namespaceMysqlx:
+		return cc.handleAdminCommand(msg.GetNamespace(), string(msg.GetStmt()), msg.GetArgs())
+	default:
+		return errXInvalidNamespace.GenByArgs(msg.GetNamespace())
+	}
+}
+
+// executeSQL executes the sql and writes the result sets, the notices and StmtExecuteOk to the client.
+func (cc *clientConn) executeSQL(sql string, compact bool) error {
+	rss, err := cc.ctx.Execute(sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for i, rs := range rss {
+		if err = cc.writeResultSet(rs, compact, i < len(rss)-1); err != nil {
+			// Close the remaining result sets.
+			for _, rs := range rss[i+1:] {
+				terror.Call(rs.Close)
+			}
+			return errors.Trace(err)
+		}
+	}
+	return cc.writeStmtExecuteOK(len(rss) == 0)
+}
+
+// writeStmtExecuteOK writes the notices of the executed statement and StmtExecuteOk.
+// The rows affected and the generated insert ID are only sent for the statements without result set.
+func (cc *clientConn) writeStmtExecuteOK(noResultSet bool) error {
+	if err := cc.writeWarnings(); err != nil {
+		return errors.Trace(err)
+	}
+	if noResultSet {
+		if id := cc.ctx.LastInsertID(); id > 0 {
+			err := cc.writeSessionStateChanged(Mysqlx_Notice.SessionStateChanged_GENERATED_INSERT_ID, newUintScalar(id))
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		err := cc.writeSessionStateChanged(Mysqlx_Notice.SessionStateChanged_ROWS_AFFECTED, newUintScalar(cc.ctx.AffectedRows()))
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return cc.writePacket(Mysqlx.ServerMessages_SQL_STMT_EXECUTE_OK, &Mysqlx_Sql.StmtExecuteOk{})
+}
+
+// writeWarnings sends the warnings of the last statement if the warnings notice is enabled.
+func (cc *clientConn) writeWarnings() error {
+	if cc.noWarnings || cc.ctx.WarningCount() == 0 {
+		return nil
+	}
+	rss, err := cc.ctx.Execute("SHOW WARNINGS")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		for _, rs := range rss {
+			terror.Call(rs.Close)
+		}
+	}()
+	if len(rss) == 0 {
+		return nil
+	}
+	for {
+		row, err := rss[0].Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			return nil
+		}
+		// The columns are Level, Code and Message.
+		level := Mysqlx_Notice.Warning_WARNING
+		switch row[0].GetString() {
+		case "Note":
+			level = Mysqlx_Notice.Warning_NOTE
+		case "Error":
+			level = Mysqlx_Notice.Warning_ERROR
+		}
+		warning := &Mysqlx_Notice.Warning{
+			Level: &level,
+			Code:  proto.Uint32(uint32(row[1].GetInt64())),
+			Msg:   proto.String(row[2].GetString()),
+		}
+		if err = cc.writeNotice(noticeTypeWarning, warning); err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
+
+func (cc *clientConn) writeSessionStateChanged(param Mysqlx_Notice.SessionStateChanged_Parameter, value *Mysqlx_Datatypes.Scalar) error {
+	return cc.writeNotice(noticeTypeSessionStateChanged, &Mysqlx_Notice.SessionStateChanged{
+		Param: &param,
+		Value: value,
+	})
+}
+
+// writeNotice writes a notice in the scope of the current session.
+func (cc *clientConn) writeNotice(tp uint32, msg proto.Message) error {
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return cc.writePacket(Mysqlx.ServerMessages_NOTICE, &Mysqlx_Notice.Frame{
+		Type:    proto.Uint32(tp),
+		Scope:   Mysqlx_Notice.Frame_LOCAL.Enum(),
+		Payload: payload,
+	})
+}
+
+func newUintScalar(v uint64) *Mysqlx_Datatypes.Scalar {
+	return &Mysqlx_Datatypes.Scalar{
+		Type:         Mysqlx_Datatypes.Scalar_V_UINT.Enum(),
+		VUnsignedInt: proto.Uint64(v),
+	}
+}
+
+// bindArgs replaces the ? placeholders in the sql with the args,
+// the ? in the quoted strings, the quoted identifiers and the comments are not placeholders.
+func bindArgs(sql string, args []*Mysqlx_Datatypes.Any) (string, error) {
+	if len(args) == 0 && strings.IndexByte(sql, '?') < 0 {
+		return sql, nil
+	}
+	var buf bytes.Buffer
+	n := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := skipQuoted(sql, i)
+			buf.WriteString(sql[i:end])
+			i = end - 1
+		case c == '#' || (c == '-' && strings.HasPrefix(sql[i:], "-- ")):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			buf.WriteString(sql[i : i+end])
+			i += end - 1
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i
+			} else {
+				end += 4
+			}
+			buf.WriteString(sql[i : i+end])
+			i += end - 1
+		case c == '?':
+			if n < len(args) {
+				if args[n].GetType() != Mysqlx_Datatypes.Any_SCALAR {
+					return "", errXCmdArgumentType.GenByArgs("?", n, "scalar")
+				}
+				lit, err := scalarToSQL(args[n].GetScalar())
+				if err != nil {
+					return "", errors.Trace(err)
+				}
+				buf.WriteString(lit)
+			}
+			n++
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	if n != len(args) {
+		return "", errXCmdNumArguments.GenByArgs(n, len(args))
+	}
+	return buf.String(), nil
+}
+
+// skipQuoted returns the position after the quoted string starting at start.
+func skipQuoted(sql string, start int) int {
+	quote := sql[start]
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			// Two quotes in a row is an escaped quote.
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// memResultSet is a result set of the rows in memory, it's used for the results of the admin commands.
+type memResultSet struct {
+	columns []*server.ColumnInfo
+	rows    [][]types.Datum
+	cursor  int
+}
+
+// Columns implements server.ResultSet Columns interface.
+func (rs *memResultSet) Columns() ([]*server.ColumnInfo, error) {
+	return rs.columns, nil
+}
+
+// Next implements server.ResultSet Next interface.
+func (rs *memResultSet) Next() ([]types.Datum, error) {
+	if rs.cursor >= len(rs.rows) {
+		return nil, nil
+	}
+	row := rs.rows[rs.cursor]
+	rs.cursor++
+	return row, nil
+}
+
+// Close implements server.ResultSet Close interface.
+func (rs *memResultSet) Close() error {
+	return nil
+}